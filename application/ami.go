@@ -0,0 +1,51 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/domain/services"
+)
+
+// ApplyAMIResolution compares the images behind an AMI finding by their
+// attributes rather than their IDs, so an equivalent rebaked image is not
+// reported as drift. AWS is only queried when the report has an AMI finding.
+func ApplyAMIResolution(ctx context.Context, repo repositories.ImageRepository, report *models.DriftReport, tagKey string) error {
+	actual, expected, ok := services.DriftedAMIs(report)
+	if !ok {
+		return nil
+	}
+
+	images, err := repo.GetByIDs(ctx, []string{actual, expected})
+	if err != nil {
+		return fmt.Errorf("failed to fetch AMIs from AWS: %w", err)
+	}
+
+	services.ResolveAMIDrift(report, images, tagKey)
+	return nil
+}
+
+// ApplyAMIAgeCheck adds advisory findings to report when the AMI actual runs
+// was created more than maxAge before now or is deprecated. AWS is queried
+// once per AMI however many instances use it; an AMI that can no longer be
+// described is noted as a warning.
+func ApplyAMIAgeCheck(ctx context.Context, repo repositories.ImageRepository, report *models.DriftReport, actual *models.Instance, maxAge time.Duration, now time.Time) error {
+	if actual == nil || actual.AMI == "" {
+		return nil
+	}
+
+	images, err := repo.GetByIDs(ctx, []string{actual.AMI})
+	if err != nil {
+		return fmt.Errorf("failed to fetch AMIs from AWS: %w", err)
+	}
+	if len(images) == 0 {
+		report.AddWarning(fmt.Sprintf("AMI %s could not be described, so its age was not checked", actual.AMI))
+		return nil
+	}
+
+	services.CheckAMIAge(report, images[0], maxAge, now)
+	return nil
+}
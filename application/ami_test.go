@@ -0,0 +1,82 @@
+package application_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/application"
+	"driftdetector/domain/models"
+)
+
+// fakeImageRepo returns fixed images and records the requested IDs
+type fakeImageRepo struct {
+	images    []*models.Image
+	requested []string
+}
+
+func (r *fakeImageRepo) GetByIDs(ctx context.Context, ids []string) ([]*models.Image, error) {
+	r.requested = append(r.requested, ids...)
+	return r.images, nil
+}
+
+func TestApplyAMIResolution(t *testing.T) {
+	t.Run("equivalent images are not drift", func(t *testing.T) {
+		repo := &fakeImageRepo{images: []*models.Image{
+			{ID: "ami-new", Name: "web-20240601", Architecture: "x86_64"},
+			{ID: "ami-old", Name: "web-20240501", Architecture: "x86_64"},
+		}}
+		report := models.NewDriftReport("i-1")
+		report.AddDrift(models.NewDrift(models.DriftTypeModified, "AMI", "ami-new", "ami-old", "Value mismatch"))
+
+		err := application.ApplyAMIResolution(context.Background(), repo, report, "")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ami-new", "ami-old"}, repo.requested)
+		assert.Empty(t, report.Drifts)
+		assert.False(t, report.HasDrift)
+	})
+
+	t.Run("AWS is not queried without an AMI finding", func(t *testing.T) {
+		repo := &fakeImageRepo{}
+		report := models.NewDriftReport("i-1")
+		report.AddDrift(models.NewDrift(models.DriftTypeModified, "KeyName", "a", "b", "Value mismatch"))
+
+		err := application.ApplyAMIResolution(context.Background(), repo, report, "app_version")
+
+		require.NoError(t, err)
+		assert.Empty(t, repo.requested)
+		assert.Len(t, report.Drifts, 1)
+	})
+}
+
+func TestApplyAMIAgeCheck(t *testing.T) {
+	now := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("the AMI the instance runs is described", func(t *testing.T) {
+		repo := &fakeImageRepo{images: []*models.Image{{ID: "ami-1", CreationDate: &created}}}
+		report := models.NewDriftReport("i-1")
+
+		err := application.ApplyAMIAgeCheck(context.Background(), repo, report, &models.Instance{AMI: "ami-1"}, 90*24*time.Hour, now)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"ami-1"}, repo.requested)
+		require.Len(t, report.StaleAMIs(), 1)
+		assert.False(t, report.HasDrift)
+	})
+
+	t.Run("an AMI AWS no longer describes is a warning", func(t *testing.T) {
+		repo := &fakeImageRepo{}
+		report := models.NewDriftReport("i-1")
+
+		err := application.ApplyAMIAgeCheck(context.Background(), repo, report, &models.Instance{AMI: "ami-gone"}, 90*24*time.Hour, now)
+
+		require.NoError(t, err)
+		assert.Empty(t, report.Drifts)
+		assert.Equal(t, []string{"AMI ami-gone could not be described, so its age was not checked"}, report.Warnings)
+	})
+}
@@ -0,0 +1,270 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	awsrepo "driftdetector/infrastructure/aws"
+	"driftdetector/infrastructure/awsutil"
+	"driftdetector/infrastructure/terraform"
+)
+
+// Ensure the lazy repositories implement the repository interfaces
+var (
+	_ repositories.InstanceRepository      = (*lazyInstanceRepository)(nil)
+	_ repositories.SecurityGroupRepository = (*lazySecurityGroupRepository)(nil)
+	_ repositories.ImageRepository         = (*lazyImageRepository)(nil)
+	_ repositories.SubnetRepository        = (*lazySubnetRepository)(nil)
+	_ repositories.ElasticIPRepository     = (*lazyElasticIPRepository)(nil)
+	_ terraform.AMIResolver                = (*lazyAMIResolver)(nil)
+	_ awsutil.S3GetObjectAPI               = (*lazyS3Client)(nil)
+	_ awsutil.S3PutObjectAPI               = (*lazyS3Uploader)(nil)
+)
+
+// ErrAWSDisabled is returned by the AWS-backed repositories of a container
+// built WithoutAWS
+var ErrAWSDisabled = errors.New("AWS access is disabled")
+
+// WithoutAWS builds a container that never loads AWS config or credentials.
+// Terraform files can still be read; AWS-backed operations, including
+// reading s3:// state, fail with ErrAWSDisabled.
+func WithoutAWS() ContainerOption {
+	return func(c *Container) error {
+		c.withoutAWS = true
+		return nil
+	}
+}
+
+// initAWS loads the AWS config, unless one was provided, and creates the AWS
+// clients. It runs once, on the first AWS-backed operation, so commands that
+// only read local Terraform files work without AWS credentials. A missing
+// region is not fatal here; commands that call AWS resolve it strictly with
+// ResolveAWSConfig.
+func (c *Container) initAWS(ctx context.Context) error {
+	c.awsOnce.Do(func() {
+		if c.withoutAWS {
+			c.awsErr = ErrAWSDisabled
+			return
+		}
+
+		if c.awsConfig.Region == "" {
+			cfg, err := awsrepo.NewConfigResolver(c.awsRegion, c.awsProfile).Resolve(ctx)
+			if err != nil && !errors.Is(err, awsrepo.ErrRegionNotResolved) {
+				c.awsErr = fmt.Errorf("failed to load AWS config: %w", err)
+				return
+			}
+			c.awsConfig = cfg
+		}
+
+		// Remote state usually lives in the account the tool runs in, so it
+		// is read without the role assumed for EC2
+		baseConfig := c.awsConfig
+		if c.assumeRole != nil {
+			c.awsConfig = awsrepo.AssumeRoleConfig(c.awsConfig, c.awsFactory.NewSTSClient(c.awsConfig), *c.assumeRole)
+		}
+
+		ec2Client := c.awsFactory.NewEC2Client(c.awsConfig)
+		repoOpts := []awsrepo.EC2RepositoryOption{awsrepo.WithUserData(), awsrepo.WithInstanceAttributes()}
+		if c.resolveIAM {
+			repoOpts = append(repoOpts, awsrepo.WithIAMProfileAssociations())
+		}
+		if c.requireFullAccess {
+			repoOpts = append(repoOpts, awsrepo.WithRequireFullAccess())
+		}
+		var sgOpts []awsrepo.SecurityGroupRepositoryOption
+		var imageOpts []awsrepo.ImageRepositoryOption
+		var subnetOpts []awsrepo.SubnetRepositoryOption
+		var eipOpts []awsrepo.ElasticIPRepositoryOption
+		if c.maxAttempts > 0 {
+			retry := awsutil.DefaultRetryOptions()
+			retry.MaxAttempts = c.maxAttempts
+			repoOpts = append(repoOpts, awsrepo.WithRetryOptions(retry))
+			sgOpts = append(sgOpts, awsrepo.WithSecurityGroupRetryOptions(retry))
+			imageOpts = append(imageOpts, awsrepo.WithImageRetryOptions(retry))
+			subnetOpts = append(subnetOpts, awsrepo.WithSubnetRetryOptions(retry))
+			eipOpts = append(eipOpts, awsrepo.WithElasticIPRetryOptions(retry))
+		}
+		c.ec2Repo = awsrepo.NewEC2Repository(ec2Client, repoOpts...)
+		c.ec2SGRepo = awsrepo.NewSecurityGroupRepository(ec2Client, sgOpts...)
+		images := awsrepo.NewImageRepository(ec2Client, imageOpts...)
+		c.ec2ImgRepo = images
+		c.ec2AMIs = images
+		c.ec2Subnets = awsrepo.NewSubnetRepository(ec2Client, subnetOpts...)
+		c.ec2EIPs = awsrepo.NewElasticIPRepository(ec2Client, eipOpts...)
+
+		// Reports are uploaded in the region of the tool, while remote state
+		// is read optionally in another region
+		c.s3Uploader = c.awsFactory.NewS3Client(baseConfig)
+		c.s3Client = c.s3Uploader
+		if c.stateRegion != "" {
+			stateConfig := baseConfig.Copy()
+			stateConfig.Region = c.stateRegion
+			c.s3Client = c.awsFactory.NewS3Client(stateConfig)
+		}
+	})
+	return c.awsErr
+}
+
+// lazyInstanceRepository reads instances from EC2, initializing AWS on first use
+type lazyInstanceRepository struct {
+	c *Container
+}
+
+func (r *lazyInstanceRepository) repo(ctx context.Context) (repositories.InstanceRepository, error) {
+	if err := r.c.initAWS(ctx); err != nil {
+		return nil, err
+	}
+	return r.c.ec2Repo, nil
+}
+
+func (r *lazyInstanceRepository) GetByID(ctx context.Context, id string) (*models.Instance, error) {
+	repo, err := r.repo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetByID(ctx, id)
+}
+
+func (r *lazyInstanceRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.Instance, error) {
+	repo, err := r.repo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return repo.GetByIDs(ctx, ids)
+}
+
+func (r *lazyInstanceRepository) FindAll(ctx context.Context) ([]*models.Instance, error) {
+	repo, err := r.repo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return repo.FindAll(ctx)
+}
+
+func (r *lazyInstanceRepository) Find(ctx context.Context, filter repositories.InstanceFilter) ([]*models.Instance, error) {
+	repo, err := r.repo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return repo.Find(ctx, filter)
+}
+
+func (r *lazyInstanceRepository) FindByTag(ctx context.Context, key, value string) ([]*models.Instance, error) {
+	repo, err := r.repo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return repo.FindByTag(ctx, key, value)
+}
+
+func (r *lazyInstanceRepository) Save(ctx context.Context, instance *models.Instance) error {
+	repo, err := r.repo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.Save(ctx, instance)
+}
+
+func (r *lazyInstanceRepository) Delete(ctx context.Context, id string) error {
+	repo, err := r.repo(ctx)
+	if err != nil {
+		return err
+	}
+	return repo.Delete(ctx, id)
+}
+
+// lazySecurityGroupRepository reads security groups from EC2, initializing
+// AWS on first use
+type lazySecurityGroupRepository struct {
+	c *Container
+}
+
+func (r *lazySecurityGroupRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.SecurityGroupConfig, error) {
+	if err := r.c.initAWS(ctx); err != nil {
+		return nil, err
+	}
+	return r.c.ec2SGRepo.GetByIDs(ctx, ids)
+}
+
+// lazyImageRepository reads AMIs from EC2, initializing AWS on first use
+type lazyImageRepository struct {
+	c *Container
+}
+
+func (r *lazyImageRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.Image, error) {
+	if err := r.c.initAWS(ctx); err != nil {
+		return nil, err
+	}
+	return r.c.ec2ImgRepo.GetByIDs(ctx, ids)
+}
+
+// lazySubnetRepository reads subnets from EC2, initializing AWS on first use
+type lazySubnetRepository struct {
+	c *Container
+}
+
+func (r *lazySubnetRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.Subnet, error) {
+	if err := r.c.initAWS(ctx); err != nil {
+		return nil, err
+	}
+	return r.c.ec2Subnets.GetByIDs(ctx, ids)
+}
+
+// lazyElasticIPRepository reads Elastic IPs from EC2, initializing AWS on first use
+type lazyElasticIPRepository struct {
+	c *Container
+}
+
+func (r *lazyElasticIPRepository) FindAll(ctx context.Context) ([]*models.ElasticIP, error) {
+	if err := r.c.initAWS(ctx); err != nil {
+		return nil, err
+	}
+	return r.c.ec2EIPs.FindAll(ctx)
+}
+
+// lazyAMIResolver resolves data "aws_ami" blocks, initializing AWS on first use
+type lazyAMIResolver struct {
+	c *Container
+}
+
+func (r *lazyAMIResolver) ResolveAMI(ctx context.Context, query terraform.AMIQuery) (string, error) {
+	if err := r.c.initAWS(ctx); err != nil {
+		return "", err
+	}
+	return r.c.ec2AMIs.ResolveAMI(ctx, query)
+}
+
+// lazyS3Client reads remote Terraform state, initializing AWS on first use
+type lazyS3Client struct {
+	c *Container
+}
+
+func (l *lazyS3Client) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	if err := l.c.initAWS(ctx); err != nil {
+		return nil, err
+	}
+	if l.c.s3Client == nil {
+		return nil, errors.New("no S3 client is configured")
+	}
+	return l.c.s3Client.GetObject(ctx, params, optFns...)
+}
+
+// lazyS3Uploader uploads drift reports, initializing AWS on first use
+type lazyS3Uploader struct {
+	c *Container
+}
+
+func (l *lazyS3Uploader) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	if err := l.c.initAWS(ctx); err != nil {
+		return nil, err
+	}
+	if l.c.s3Uploader == nil {
+		return nil, errors.New("no S3 client is configured")
+	}
+	return l.c.s3Uploader.PutObject(ctx, params, optFns...)
+}
@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/domain/services"
+)
+
+// AuditIMDSCommand represents the command to audit the instance metadata
+// service settings of every instance recorded in Terraform state
+type AuditIMDSCommand struct {
+	TerraformStateFile string
+	TerraformDir       string
+}
+
+// AuditIMDSHandler handles the AuditIMDSCommand
+type AuditIMDSHandler struct {
+	instanceRepo repositories.InstanceRepository
+	tfStateRepo  repositories.TerraformStateRepository
+	concurrency  int
+}
+
+// NewAuditIMDSHandler creates a new AuditIMDSHandler
+func NewAuditIMDSHandler(instanceRepo repositories.InstanceRepository, tfStateRepo repositories.TerraformStateRepository) *AuditIMDSHandler {
+	return &AuditIMDSHandler{
+		instanceRepo: instanceRepo,
+		tfStateRepo:  tfStateRepo,
+		concurrency:  defaultFetchConcurrency,
+	}
+}
+
+// Handle processes the AuditIMDSCommand. Results are ordered by instance ID;
+// instances missing from AWS are reported as such rather than failing the run.
+func (h *AuditIMDSHandler) Handle(ctx context.Context, cmd AuditIMDSCommand) ([]*models.IMDSAudit, error) {
+	desiredInstances, err := NewTerraformSource(h.tfStateRepo, cmd.TerraformStateFile, cmd.TerraformDir, "").Instances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Configuration files carry no IDs, so only state-backed instances can be audited
+	desiredByID := make(map[string]*models.Instance)
+	var ids []string
+	for _, inst := range desiredInstances {
+		if inst.ID == "" {
+			continue
+		}
+		if _, seen := desiredByID[inst.ID]; !seen {
+			ids = append(ids, inst.ID)
+		}
+		desiredByID[inst.ID] = inst
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no instances with IDs found in Terraform state")
+	}
+	sort.Strings(ids)
+
+	actualByID, err := fetchInstances(ctx, h.instanceRepo, ids, h.concurrency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instances from AWS: %w", err)
+	}
+
+	audits := make([]*models.IMDSAudit, 0, len(ids))
+	for _, id := range ids {
+		// A terminated instance no longer has a metadata service to audit
+		actual := actualByID[id]
+		if actual != nil && actual.IsTerminated() {
+			actual = nil
+		}
+		audits = append(audits, services.AuditIMDS(actual, desiredByID[id]))
+	}
+	return audits, nil
+}
@@ -0,0 +1,49 @@
+package commands_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/application/commands"
+	"driftdetector/domain/models"
+)
+
+func TestAuditIMDSHandler_Handle(t *testing.T) {
+	withTokens := func(id, tokens string) *models.Instance {
+		inst := models.NewInstance(id, "t3.micro", "ami-1")
+		inst.MetadataOptions = &models.MetadataOptions{HTTPTokens: tokens, HTTPEndpoint: models.HTTPEndpointEnabled}
+		return inst
+	}
+	terminated := withTokens("i-3", models.HTTPTokensOptional)
+	terminated.State = models.InstanceStateTerminated
+
+	handler := commands.NewAuditIMDSHandler(
+		&fakeInstanceRepo{instances: map[string]*models.Instance{
+			"i-1": withTokens("i-1", models.HTTPTokensRequired),
+			"i-2": withTokens("i-2", models.HTTPTokensOptional),
+			"i-3": terminated,
+		}},
+		&fakeStateRepo{instances: []*models.Instance{
+			withTokens("i-4", models.HTTPTokensRequired),
+			withTokens("i-2", models.HTTPTokensRequired),
+			withTokens("i-1", models.HTTPTokensRequired),
+			withTokens("i-3", models.HTTPTokensRequired),
+			models.NewInstance("", "t3.micro", "ami-1"),
+		}},
+	)
+
+	audits, err := handler.Handle(context.Background(), commands.AuditIMDSCommand{TerraformStateFile: "prod.tfstate"})
+
+	require.NoError(t, err)
+	var statuses []models.IMDSStatus
+	for _, audit := range audits {
+		statuses = append(statuses, audit.Status)
+	}
+	assert.Equal(t, []models.IMDSStatus{models.IMDSCompliant, models.IMDSViolation, models.IMDSMissing, models.IMDSMissing}, statuses,
+		"ordered by ID, with configurations without an ID left out")
+	assert.Equal(t, "i-4", audits[3].InstanceID)
+	assert.Equal(t, models.HTTPTokensOptional, audits[1].Actual.HTTPTokens)
+}
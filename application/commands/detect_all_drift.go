@@ -0,0 +1,283 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/domain/services"
+)
+
+const (
+	// defaultFetchConcurrency bounds the AWS lookups in flight at once
+	defaultFetchConcurrency = 10
+	// fetchBatchSize is the number of instance IDs described per request
+	fetchBatchSize = 100
+)
+
+// DetectAllDriftCommand represents the command to detect drift for every
+// instance recorded in Terraform state
+type DetectAllDriftCommand struct {
+	TerraformStateFile string
+	TerraformDir       string
+	TerraformPlanFile  string
+	// Desired, when set, is read instead of the Terraform files above
+	Desired Source
+}
+
+// InstanceDriftResult is the outcome of drift detection for one instance
+type InstanceDriftResult struct {
+	Report *models.DriftReport
+	// Actual is nil when the instance no longer exists in AWS, or is not
+	// compared because it is terminated or stopped
+	Actual  *models.Instance
+	Desired *models.Instance
+}
+
+// DetectAllDriftHandler handles the DetectAllDriftCommand
+type DetectAllDriftHandler struct {
+	detectionService services.DetectionService
+	instanceRepo     repositories.InstanceRepository
+	tfStateRepo      repositories.TerraformStateRepository
+	concurrency      int
+	includeStopped   bool
+}
+
+// DetectAllDriftOption configures a DetectAllDriftHandler
+type DetectAllDriftOption func(*DetectAllDriftHandler)
+
+// WithFetchConcurrency sets the maximum number of AWS lookups in flight at once
+func WithFetchConcurrency(n int) DetectAllDriftOption {
+	return func(h *DetectAllDriftHandler) {
+		if n > 0 {
+			h.concurrency = n
+		}
+	}
+}
+
+// WithIncludeStopped compares stopped instances field by field instead of
+// reporting them as removed; their configuration still applies when started
+func WithIncludeStopped(include bool) DetectAllDriftOption {
+	return func(h *DetectAllDriftHandler) {
+		h.includeStopped = include
+	}
+}
+
+// NewDetectAllDriftHandler creates a new DetectAllDriftHandler
+func NewDetectAllDriftHandler(
+	detectionService services.DetectionService,
+	instanceRepo repositories.InstanceRepository,
+	tfStateRepo repositories.TerraformStateRepository,
+	opts ...DetectAllDriftOption,
+) *DetectAllDriftHandler {
+	h := &DetectAllDriftHandler{
+		detectionService: detectionService,
+		instanceRepo:     instanceRepo,
+		tfStateRepo:      tfStateRepo,
+		concurrency:      defaultFetchConcurrency,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Handle processes the DetectAllDriftCommand. Results are ordered by instance ID.
+// Instances present in Terraform but missing, terminated or stopped in AWS
+// are reported as removed rather than failing the run. Instances are compared concurrently; when some
+// comparisons fail, the other results are returned with a *services.BatchError.
+func (h *DetectAllDriftHandler) Handle(ctx context.Context, cmd DetectAllDriftCommand) ([]*InstanceDriftResult, error) {
+	source := cmd.Desired
+	if source == nil {
+		source = NewTerraformSource(h.tfStateRepo, cmd.TerraformStateFile, cmd.TerraformDir, cmd.TerraformPlanFile)
+	}
+	desiredInstances, err := source.Instances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Configuration files carry no IDs, so only state-backed instances can be checked
+	desiredByID := make(map[string]*models.Instance)
+	var ids []string
+	for _, inst := range desiredInstances {
+		if inst.ID == "" {
+			continue
+		}
+		if _, seen := desiredByID[inst.ID]; !seen {
+			ids = append(ids, inst.ID)
+		}
+		desiredByID[inst.ID] = inst
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no instances with IDs found in Terraform state")
+	}
+	sort.Strings(ids)
+
+	actualByID, err := fetchInstances(ctx, h.instanceRepo, ids, h.concurrency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instances from AWS: %w", err)
+	}
+
+	var pairs []services.InstancePair
+	for _, id := range ids {
+		if actual, found := actualByID[id]; found && InactiveInstanceReport(actual, h.includeStopped) == nil {
+			pairs = append(pairs, services.InstancePair{Actual: actual, Desired: desiredByID[id]})
+		}
+	}
+	reports, detectErr := h.detectionService.BatchDetectDriftConcurrent(ctx, pairs, h.concurrency)
+	var batchErr *services.BatchError
+	if detectErr != nil && !errors.As(detectErr, &batchErr) {
+		return nil, fmt.Errorf("failed to detect drift: %w", detectErr)
+	}
+
+	results := make([]*InstanceDriftResult, 0, len(ids))
+	for _, id := range ids {
+		desired := desiredByID[id]
+		actual, found := actualByID[id]
+		if !found {
+			results = append(results, &InstanceDriftResult{Report: MissingInstanceReport(id), Desired: desired})
+			continue
+		}
+		// Inactive instances are not compared, so they carry no Actual
+		if report := InactiveInstanceReport(actual, h.includeStopped); report != nil {
+			results = append(results, &InstanceDriftResult{Report: report, Desired: desired})
+			continue
+		}
+
+		// Failed comparisons are reported through detectErr
+		if report, ok := reports[id]; ok {
+			results = append(results, &InstanceDriftResult{Report: report, Actual: actual, Desired: desired})
+		}
+	}
+
+	return results, detectErr
+}
+
+// fetchInstances retrieves instances from repo in batches of fetchBatchSize,
+// with at most concurrency requests in flight. EC2 rejects a batch outright when any
+// ID is unknown, in which case the instances of that batch are fetched
+// individually so that the missing ones can be told apart.
+func fetchInstances(ctx context.Context, repo repositories.InstanceRepository, ids []string, concurrency int) (map[string]*models.Instance, error) {
+	byID, errs := fetchInstancesEach(ctx, repo, ids, concurrency)
+
+	// Requests cut short by cancellation fail with the context's error
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if err, failed := errs[id]; failed {
+			return nil, err
+		}
+	}
+	return byID, nil
+}
+
+// fetchInstancesEach retrieves instances like fetchInstances, but keeps
+// going when a batch fails: the batch's error is returned for each of its
+// IDs alongside the instances of the other batches
+func fetchInstancesEach(ctx context.Context, repo repositories.InstanceRepository, ids []string, concurrency int) (map[string]*models.Instance, map[string]error) {
+	batches := make(chan []string)
+	go func() {
+		defer close(batches)
+		for i := 0; i < len(ids); i += fetchBatchSize {
+			end := i + fetchBatchSize
+			if end > len(ids) {
+				end = len(ids)
+			}
+			select {
+			case batches <- ids[i:end]:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		byID = make(map[string]*models.Instance, len(ids))
+		errs = make(map[string]error)
+	)
+
+	workers := concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				instances, err := fetchBatch(ctx, repo, batch)
+
+				mu.Lock()
+				for _, inst := range instances {
+					byID[inst.ID] = inst
+				}
+				if err != nil {
+					for _, id := range batch {
+						errs[id] = err
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Batches never sent because of cancellation fail with the context's error
+	if err := ctx.Err(); err != nil {
+		for _, id := range ids {
+			if _, found := byID[id]; !found {
+				if _, failed := errs[id]; !failed {
+					errs[id] = err
+				}
+			}
+		}
+	}
+	return byID, errs
+}
+
+// fetchBatch describes one batch of instances, falling back to one request per
+// instance when the batch contains an ID that does not exist
+func fetchBatch(ctx context.Context, repo repositories.InstanceRepository, ids []string) ([]*models.Instance, error) {
+	instances, err := repo.GetByIDs(ctx, ids)
+	if err == nil {
+		return instances, nil
+	}
+	if !errors.Is(err, repositories.ErrInstanceNotFound) {
+		return nil, err
+	}
+
+	var found []*models.Instance
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		inst, err := repo.GetByID(ctx, id)
+		switch {
+		case err == nil:
+			found = append(found, inst)
+		case errors.Is(err, repositories.ErrInstanceNotFound):
+			// Reported as removed by the caller
+		default:
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// HasDrift reports whether any result contains drift
+func HasDrift(results []*InstanceDriftResult) bool {
+	for _, r := range results {
+		if r.Report.HasDrifts() {
+			return true
+		}
+	}
+	return false
+}
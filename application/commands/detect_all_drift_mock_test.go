@@ -0,0 +1,48 @@
+package commands_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/application/commands"
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+	"driftdetector/infrastructure/mock"
+	"driftdetector/infrastructure/terraform"
+)
+
+// TestDetectAllDriftHandler_MockFixtures checks every instance of a state
+// file against instances loaded from mock files, without AWS
+func TestDetectAllDriftHandler_MockFixtures(t *testing.T) {
+	configs, err := mock.LoadInstanceConfigs("../../testdata/mock_tests/batch")
+	require.NoError(t, err)
+
+	handler := commands.NewDetectAllDriftHandler(
+		services.NewDetectionService(),
+		mock.NewInstanceRepository(configs...),
+		terraform.NewTerraformRepository(nil),
+		commands.WithFetchConcurrency(2),
+	)
+
+	results, err := handler.Handle(context.Background(), commands.DetectAllDriftCommand{
+		TerraformStateFile: "../../testdata/mock_tests/batch.tfstate",
+	})
+	require.NoError(t, err)
+
+	drifts := make(map[string][]string)
+	for _, result := range results {
+		var paths []string
+		for _, d := range result.Report.Drifts {
+			paths = append(paths, string(d.Type)+" "+d.Path)
+		}
+		drifts[result.Report.InstanceID] = paths
+	}
+	assert.Equal(t, map[string][]string{
+		"i-0batch00000000web": nil,
+		"i-0batch00000000app": {string(models.DriftTypeModified) + " Type"},
+		"i-0batch000000000db": {string(models.DriftTypeRemoved) + " "},
+	}, drifts)
+}
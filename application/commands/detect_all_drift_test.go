@@ -0,0 +1,364 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/application/commands"
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/domain/services"
+)
+
+// fakeInstanceRepo serves instances from memory and, like EC2, rejects a
+// batch lookup when any requested ID is unknown
+type fakeInstanceRepo struct {
+	repositories.InstanceRepository
+	instances map[string]*models.Instance
+	err       error
+}
+
+func (r *fakeInstanceRepo) GetByID(ctx context.Context, id string) (*models.Instance, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	inst, ok := r.instances[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", repositories.ErrInstanceNotFound, id)
+	}
+	return inst, nil
+}
+
+func (r *fakeInstanceRepo) GetByIDs(ctx context.Context, ids []string) ([]*models.Instance, error) {
+	var found []*models.Instance
+	for _, id := range ids {
+		inst, err := r.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, inst)
+	}
+	return found, nil
+}
+
+// fakeStateRepo returns a fixed set of desired instances
+type fakeStateRepo struct {
+	instances []*models.Instance
+}
+
+func (r *fakeStateRepo) GetInstanceConfigs(ctx context.Context, statePath string) ([]*models.Instance, error) {
+	return r.instances, nil
+}
+
+func (r *fakeStateRepo) GetInstanceConfigsFromDir(ctx context.Context, dir string) ([]*models.Instance, error) {
+	return r.instances, nil
+}
+
+func newHandler(actual map[string]*models.Instance, desired []*models.Instance) *commands.DetectAllDriftHandler {
+	return commands.NewDetectAllDriftHandler(
+		services.NewDetectionService(),
+		&fakeInstanceRepo{instances: actual},
+		&fakeStateRepo{instances: desired},
+	)
+}
+
+func TestDetectAllDriftHandler_Handle(t *testing.T) {
+	cmd := commands.DetectAllDriftCommand{TerraformStateFile: "terraform.tfstate"}
+
+	t.Run("every instance is compared", func(t *testing.T) {
+		// Given three instances in state, one of which has drifted
+		desired := []*models.Instance{
+			models.NewInstance("i-3", "t3.micro", "ami-1"),
+			models.NewInstance("i-1", "t3.micro", "ami-1"),
+			models.NewInstance("i-2", "t3.micro", "ami-1"),
+		}
+		actual := map[string]*models.Instance{
+			"i-1": models.NewInstance("i-1", "t3.micro", "ami-1"),
+			"i-2": models.NewInstance("i-2", "t3.large", "ami-1"),
+			"i-3": models.NewInstance("i-3", "t3.micro", "ami-1"),
+		}
+
+		// When
+		results, err := newHandler(actual, desired).Handle(context.Background(), cmd)
+
+		// Then results are ordered by ID and only i-2 drifted
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		assert.Equal(t, "i-1", results[0].Report.InstanceID)
+		assert.Equal(t, "i-2", results[1].Report.InstanceID)
+		assert.Equal(t, "i-3", results[2].Report.InstanceID)
+		assert.False(t, results[0].Report.HasDrifts())
+		assert.True(t, results[1].Report.HasDrifts())
+		assert.True(t, commands.HasDrift(results))
+	})
+
+	t.Run("instances missing from AWS are reported as removed", func(t *testing.T) {
+		desired := []*models.Instance{
+			models.NewInstance("i-1", "t3.micro", "ami-1"),
+			models.NewInstance("i-gone", "t3.micro", "ami-1"),
+		}
+		actual := map[string]*models.Instance{
+			"i-1": models.NewInstance("i-1", "t3.micro", "ami-1"),
+		}
+
+		results, err := newHandler(actual, desired).Handle(context.Background(), cmd)
+
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.False(t, results[0].Report.HasDrifts())
+
+		gone := results[1]
+		assert.Equal(t, "i-gone", gone.Report.InstanceID)
+		assert.Nil(t, gone.Actual)
+		require.Len(t, gone.Report.Drifts, 1)
+		assert.Equal(t, models.DriftTypeRemoved, gone.Report.Drifts[0].Type)
+	})
+
+	t.Run("terminated and stopped instances are reported as removed", func(t *testing.T) {
+		desired := []*models.Instance{
+			models.NewInstance("i-stopped", "t3.micro", "ami-1"),
+			models.NewInstance("i-terminated", "t3.micro", "ami-1"),
+		}
+		stopped := models.NewInstance("i-stopped", "t3.large", "ami-1")
+		stopped.State = models.InstanceStateStopped
+		terminated := models.NewInstance("i-terminated", "", "")
+		terminated.State = models.InstanceStateTerminated
+		actual := map[string]*models.Instance{"i-stopped": stopped, "i-terminated": terminated}
+
+		results, err := newHandler(actual, desired).Handle(context.Background(), cmd)
+
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		for _, result := range results {
+			assert.Nil(t, result.Actual, result.Report.InstanceID)
+			require.Len(t, result.Report.Drifts, 1, result.Report.InstanceID)
+			assert.Equal(t, models.DriftTypeRemoved, result.Report.Drifts[0].Type)
+			assert.Empty(t, result.Report.Drifts[0].Path)
+			assert.Nil(t, result.Report.Drifts[0].Expected)
+		}
+		assert.Equal(t, models.InstanceStateStopped, results[0].Report.Drifts[0].Actual)
+		assert.Equal(t, "Instance exists in Terraform but is stopped in AWS", results[0].Report.Drifts[0].Description)
+		assert.Equal(t, "Instance exists in Terraform but is terminated in AWS", results[1].Report.Drifts[0].Description)
+
+		// With stopped instances included, only the terminated one is skipped
+		handler := commands.NewDetectAllDriftHandler(
+			services.NewDetectionService(),
+			&fakeInstanceRepo{instances: actual},
+			&fakeStateRepo{instances: desired},
+			commands.WithIncludeStopped(true),
+		)
+		results, err = handler.Handle(context.Background(), cmd)
+
+		require.NoError(t, err)
+		require.Len(t, results, 2)
+		assert.Equal(t, stopped, results[0].Actual)
+		require.Len(t, results[0].Report.Drifts, 1)
+		assert.Equal(t, "Type", results[0].Report.Drifts[0].Path)
+		assert.Nil(t, results[1].Actual)
+	})
+
+	t.Run("configurations without IDs are skipped", func(t *testing.T) {
+		unbound := models.NewInstance("", "t3.micro", "ami-1")
+		unbound.ResourceAddress = "aws_instance.web"
+
+		_, err := newHandler(nil, []*models.Instance{unbound}).Handle(context.Background(), cmd)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("other AWS errors abort the run", func(t *testing.T) {
+		handler := commands.NewDetectAllDriftHandler(
+			services.NewDetectionService(),
+			&fakeInstanceRepo{err: errors.New("access denied")},
+			&fakeStateRepo{instances: []*models.Instance{models.NewInstance("i-1", "t3.micro", "ami-1")}},
+		)
+
+		_, err := handler.Handle(context.Background(), cmd)
+
+		assert.Error(t, err)
+	})
+}
+
+// failingDetectionService fails the comparison of the listed instances and
+// compares the rest with the default service
+type failingDetectionService struct {
+	*services.DefaultDetectionService
+	fail map[string]error
+}
+
+func (s *failingDetectionService) BatchDetectDriftConcurrent(ctx context.Context, pairs []services.InstancePair, workers int) (map[string]*models.DriftReport, error) {
+	var remaining []services.InstancePair
+	for _, pair := range pairs {
+		if _, ok := s.fail[pair.Actual.ID]; !ok {
+			remaining = append(remaining, pair)
+		}
+	}
+	reports, err := s.DefaultDetectionService.BatchDetectDriftConcurrent(ctx, remaining, workers)
+	if err != nil {
+		return nil, err
+	}
+	return reports, &services.BatchError{Errors: s.fail}
+}
+
+func TestDetectAllDriftHandler_PartialFailure(t *testing.T) {
+	cmd := commands.DetectAllDriftCommand{TerraformStateFile: "terraform.tfstate"}
+
+	// Given three instances, the comparison of one of which fails
+	var desired []*models.Instance
+	actual := make(map[string]*models.Instance)
+	for _, id := range []string{"i-1", "i-2", "i-3"} {
+		desired = append(desired, models.NewInstance(id, "t3.micro", "ami-1"))
+		actual[id] = models.NewInstance(id, "t3.micro", "ami-1")
+	}
+	actual["i-3"].Type = "t3.large"
+	handler := commands.NewDetectAllDriftHandler(
+		&failingDetectionService{
+			DefaultDetectionService: services.NewDetectionService(),
+			fail:                    map[string]error{"i-2": errors.New("boom")},
+		},
+		&fakeInstanceRepo{instances: actual},
+		&fakeStateRepo{instances: desired},
+	)
+
+	// When
+	results, err := handler.Handle(context.Background(), cmd)
+
+	// Then the other instances are still reported, alongside the failure
+	var batchErr *services.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Contains(t, batchErr.Errors, "i-2")
+	require.Len(t, results, 2)
+	assert.Equal(t, "i-1", results[0].Report.InstanceID)
+	assert.Equal(t, "i-3", results[1].Report.InstanceID)
+	assert.True(t, results[1].Report.HasDrifts())
+}
+
+// countingInstanceRepo records how many lookups are in flight at once and the
+// size of every batch request
+type countingInstanceRepo struct {
+	fakeInstanceRepo
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	batchSizes  []int
+}
+
+func (r *countingInstanceRepo) enter() {
+	r.mu.Lock()
+	r.inFlight++
+	if r.inFlight > r.maxInFlight {
+		r.maxInFlight = r.inFlight
+	}
+	r.mu.Unlock()
+	time.Sleep(time.Millisecond)
+}
+
+func (r *countingInstanceRepo) leave() {
+	r.mu.Lock()
+	r.inFlight--
+	r.mu.Unlock()
+}
+
+func (r *countingInstanceRepo) GetByID(ctx context.Context, id string) (*models.Instance, error) {
+	r.enter()
+	defer r.leave()
+	return r.fakeInstanceRepo.GetByID(ctx, id)
+}
+
+func (r *countingInstanceRepo) GetByIDs(ctx context.Context, ids []string) ([]*models.Instance, error) {
+	r.enter()
+	defer r.leave()
+	r.mu.Lock()
+	r.batchSizes = append(r.batchSizes, len(ids))
+	r.mu.Unlock()
+	return r.fakeInstanceRepo.GetByIDs(ctx, ids)
+}
+
+func TestDetectAllDriftHandler_FetchConcurrency(t *testing.T) {
+	cmd := commands.DetectAllDriftCommand{TerraformStateFile: "terraform.tfstate"}
+
+	// Given 1,000 instances in state, one of which no longer exists
+	actual := make(map[string]*models.Instance)
+	var desired []*models.Instance
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("i-%04d", i)
+		desired = append(desired, models.NewInstance(id, "t3.micro", "ami-1"))
+		if i != 500 {
+			actual[id] = models.NewInstance(id, "t3.micro", "ami-1")
+		}
+	}
+	repo := &countingInstanceRepo{fakeInstanceRepo: fakeInstanceRepo{instances: actual}}
+	handler := commands.NewDetectAllDriftHandler(
+		services.NewDetectionService(),
+		repo,
+		&fakeStateRepo{instances: desired},
+		commands.WithFetchConcurrency(3),
+	)
+
+	// When checking all of them
+	results, err := handler.Handle(context.Background(), cmd)
+
+	// Then lookups are batched and never exceed the concurrency limit
+	require.NoError(t, err)
+	require.Len(t, results, 1000)
+	assert.Nil(t, results[500].Actual)
+	assert.LessOrEqual(t, repo.maxInFlight, 3)
+	assert.Len(t, repo.batchSizes, 10)
+	for _, size := range repo.batchSizes {
+		assert.LessOrEqual(t, size, 100)
+	}
+}
+
+// blockingInstanceRepo stands in for an unresponsive AWS endpoint: lookups
+// block until their context ends
+type blockingInstanceRepo struct {
+	repositories.InstanceRepository
+	started chan struct{}
+	once    sync.Once
+}
+
+func (r *blockingInstanceRepo) GetByIDs(ctx context.Context, ids []string) ([]*models.Instance, error) {
+	r.once.Do(func() { close(r.started) })
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestDetectAllDriftHandler_Cancellation(t *testing.T) {
+	var desired []*models.Instance
+	for i := 0; i < 500; i++ {
+		desired = append(desired, models.NewInstance(fmt.Sprintf("i-%04d", i), "t3.micro", "ami-1"))
+	}
+	repo := &blockingInstanceRepo{started: make(chan struct{})}
+	handler := commands.NewDetectAllDriftHandler(
+		services.NewDetectionService(),
+		repo,
+		&fakeStateRepo{instances: desired},
+		commands.WithFetchConcurrency(2),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		_, err := handler.Handle(ctx, commands.DetectAllDriftCommand{TerraformStateFile: "terraform.tfstate"})
+		done <- err
+	}()
+
+	// Cancel once the lookups are in flight
+	<-repo.started
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Handle did not return after its context was canceled")
+	}
+}
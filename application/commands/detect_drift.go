@@ -14,6 +14,7 @@ type DetectDriftCommand struct {
 	InstanceID string
 	TerraformStateFile string
 	TerraformDir      string
+	TerraformPlanFile string
 }
 
 // DetectDriftHandler handles the DetectDriftCommand
@@ -45,17 +46,9 @@ func (h *DetectDriftHandler) Handle(ctx context.Context, cmd DetectDriftCommand)
 	}
 
 	// Get desired state from Terraform
-	var desiredInstances []*models.Instance
-	if cmd.TerraformStateFile != "" {
-		desiredInstances, err = h.tfStateRepo.GetInstanceConfigs(ctx, cmd.TerraformStateFile)
-	} else if cmd.TerraformDir != "" {
-		desiredInstances, err = h.tfStateRepo.GetInstanceConfigsFromDir(ctx, cmd.TerraformDir)
-	} else {
-		return nil, fmt.Errorf("either terraform state file or directory must be provided")
-	}
-
+	desiredInstances, err := NewTerraformSource(h.tfStateRepo, cmd.TerraformStateFile, cmd.TerraformDir, cmd.TerraformPlanFile).Instances(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get desired state from Terraform: %w", err)
+		return nil, err
 	}
 
 	// Find the matching desired instance
@@ -79,3 +72,13 @@ func (h *DetectDriftHandler) Handle(ctx context.Context, cmd DetectDriftCommand)
 
 	return report, nil
 }
+
+// LoadPlanInstances reads the planned instance configurations from a plan file,
+// if the Terraform repository supports reading plans
+func LoadPlanInstances(ctx context.Context, repo repositories.TerraformStateRepository, planFile string) ([]*models.Instance, error) {
+	planRepo, ok := repo.(repositories.PlanRepository)
+	if !ok {
+		return nil, fmt.Errorf("terraform repository cannot read plan files")
+	}
+	return planRepo.GetInstanceConfigsFromPlan(ctx, planFile)
+}
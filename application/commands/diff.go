@@ -0,0 +1,123 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+// DiffCommand represents the command to compare the instances of two
+// sources, such as yesterday's state snapshot and today's, without AWS
+type DiffCommand struct {
+	// Left holds the expected configurations, e.g. the older state
+	Left Source
+	// Right holds the configurations compared against them
+	Right Source
+}
+
+// DiffHandler handles the DiffCommand
+type DiffHandler struct {
+	detectionService services.DetectionService
+}
+
+// NewDiffHandler creates a new DiffHandler
+func NewDiffHandler(detectionService services.DetectionService) *DiffHandler {
+	return &DiffHandler{detectionService: detectionService}
+}
+
+// Handle processes the DiffCommand. Instances are paired by ID, then by
+// resource address, and each pair is compared with the right side as actual
+// and the left as expected. An instance found on one side only is reported
+// as a single added or removed finding. Results are ordered by instance ID.
+func (h *DiffHandler) Handle(ctx context.Context, cmd DiffCommand) ([]*InstanceDriftResult, error) {
+	left, err := cmd.Left.Instances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", cmd.Left, err)
+	}
+	right, err := cmd.Right.Instances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", cmd.Right, err)
+	}
+
+	var results []*InstanceDriftResult
+	paired := make(map[*models.Instance]bool, len(right))
+	for _, desired := range left {
+		actual := pairInstance(desired, right, paired)
+		if actual == nil {
+			report := models.NewDriftReport(instanceKey(desired))
+			report.AddDrift(models.NewDrift(models.DriftTypeRemoved, "", nil, nil,
+				fmt.Sprintf("Instance exists in %s but not in %s", cmd.Left, cmd.Right)))
+			results = append(results, &InstanceDriftResult{Report: report, Desired: desired})
+			continue
+		}
+		paired[actual] = true
+
+		report, err := h.compare(ctx, actual, desired)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compare %s: %w", instanceKey(actual), err)
+		}
+		results = append(results, &InstanceDriftResult{Report: report, Actual: actual, Desired: desired})
+	}
+
+	for _, actual := range right {
+		if paired[actual] {
+			continue
+		}
+		report := models.NewDriftReport(instanceKey(actual))
+		report.AddDrift(models.NewDrift(models.DriftTypeAdded, "", nil, nil,
+			fmt.Sprintf("Instance exists in %s but not in %s", cmd.Right, cmd.Left)))
+		results = append(results, &InstanceDriftResult{Report: report, Actual: actual})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Report.InstanceID < results[j].Report.InstanceID
+	})
+	return results, nil
+}
+
+// compare compares a pair of instances. A pair matched by resource address
+// with different IDs is a replaced instance: its settings are compared as if
+// the IDs agreed, and the replacement is reported as a change of ID.
+func (h *DiffHandler) compare(ctx context.Context, actual, desired *models.Instance) (*models.DriftReport, error) {
+	if actual.ID == desired.ID {
+		return h.detectionService.DetectDrift(ctx, actual, desired)
+	}
+
+	replaced := *desired
+	replaced.ID = actual.ID
+	report, err := h.detectionService.DetectDrift(ctx, actual, &replaced)
+	if err != nil {
+		return nil, err
+	}
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, "ID", actual.ID, desired.ID,
+		fmt.Sprintf("Instance %s was replaced", desired.ResourceAddress)))
+	return report, nil
+}
+
+// pairInstance returns the instance of candidates not yet paired that has
+// the ID of desired, or failing that its resource address
+func pairInstance(desired *models.Instance, candidates []*models.Instance, paired map[*models.Instance]bool) *models.Instance {
+	for _, candidate := range candidates {
+		if !paired[candidate] && desired.ID != "" && candidate.ID == desired.ID {
+			return candidate
+		}
+	}
+	for _, candidate := range candidates {
+		if !paired[candidate] && desired.ResourceAddress != "" && candidate.ResourceAddress == desired.ResourceAddress {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// instanceKey identifies an instance in reports by its ID, or by its
+// resource address when it has none
+func instanceKey(instance *models.Instance) string {
+	if instance.ID != "" {
+		return instance.ID
+	}
+	return instance.ResourceAddress
+}
@@ -0,0 +1,89 @@
+package commands_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/application/commands"
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestDiffHandler_Handle(t *testing.T) {
+	// Given yesterday's state and today's, in which web was resized, api was
+	// replaced, db was destroyed and cache was created
+	addressed := func(id, address, instanceType string) *models.Instance {
+		inst := models.NewInstance(id, instanceType, "ami-1")
+		inst.ResourceAddress = address
+		return inst
+	}
+	left := commands.NewStaticSource("old.tfstate",
+		addressed("i-web", "aws_instance.web", "t3.micro"),
+		addressed("i-api", "aws_instance.api", "t3.small"),
+		addressed("i-db", "aws_instance.db", "r5.large"),
+	)
+	right := commands.NewStaticSource("new.tfstate",
+		addressed("i-web", "aws_instance.web", "t3.large"),
+		addressed("i-api2", "aws_instance.api", "t3.small"),
+		addressed("i-cache", "aws_instance.cache", "r5.large"),
+	)
+
+	// When
+	results, err := commands.NewDiffHandler(services.NewDetectionService()).Handle(context.Background(),
+		commands.DiffCommand{Left: left, Right: right})
+
+	// Then
+	require.NoError(t, err)
+	byID := make(map[string]*commands.InstanceDriftResult)
+	var ids []string
+	for _, result := range results {
+		ids = append(ids, result.Report.InstanceID)
+		byID[result.Report.InstanceID] = result
+	}
+	assert.Equal(t, []string{"i-api2", "i-cache", "i-db", "i-web"}, ids, "results are ordered by instance ID")
+
+	web := byID["i-web"].Report
+	require.Len(t, web.Drifts, 1)
+	assert.Equal(t, "Type", web.Drifts[0].Path)
+	assert.Equal(t, "t3.large", web.Drifts[0].Actual)
+	assert.Equal(t, "t3.micro", web.Drifts[0].Expected)
+
+	api := byID["i-api2"].Report
+	require.Len(t, api.Drifts, 1, "a replaced instance is paired by address")
+	assert.Equal(t, "ID", api.Drifts[0].Path)
+	assert.Equal(t, "i-api2", api.Drifts[0].Actual)
+	assert.Equal(t, "i-api", api.Drifts[0].Expected)
+
+	db := byID["i-db"]
+	require.Len(t, db.Report.Drifts, 1)
+	assert.Equal(t, models.DriftTypeRemoved, db.Report.Drifts[0].Type)
+	assert.Equal(t, "Instance exists in old.tfstate but not in new.tfstate", db.Report.Drifts[0].Description)
+	assert.Nil(t, db.Actual)
+
+	cache := byID["i-cache"]
+	require.Len(t, cache.Report.Drifts, 1)
+	assert.Equal(t, models.DriftTypeAdded, cache.Report.Drifts[0].Type)
+	assert.Nil(t, cache.Desired)
+}
+
+func TestDiffHandler_Handle_Snapshot(t *testing.T) {
+	// A snapshot has no resource address, so it is paired by ID
+	state := models.NewInstance("i-1", "t3.micro", "ami-1")
+	state.ResourceAddress = "aws_instance.web"
+	state.AddTag("Name", "web")
+	snapshot := models.NewInstance("i-1", "t3.micro", "ami-1")
+	snapshot.AddTag("Name", "web")
+
+	results, err := commands.NewDiffHandler(services.NewDetectionService()).Handle(context.Background(),
+		commands.DiffCommand{
+			Left:  commands.NewStaticSource("terraform.tfstate", state),
+			Right: commands.NewStaticSource("i-1.json", snapshot),
+		})
+
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Report.HasDrifts())
+}
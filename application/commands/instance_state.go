@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"fmt"
+
+	"driftdetector/domain/models"
+)
+
+// MissingInstanceReport returns the report of an instance recorded in
+// Terraform that AWS does not know, holding a single removed finding
+func MissingInstanceReport(id string) *models.DriftReport {
+	report := models.NewDriftReport(id)
+	report.AddDrift(models.NewDrift(
+		models.DriftTypeRemoved,
+		"",
+		nil,
+		nil,
+		"Instance exists in Terraform state but was not found in AWS",
+	))
+	return report
+}
+
+// InactiveInstanceReport returns a report holding a single removed finding
+// when AWS reports actual terminated, or stopped and includeStopped is false.
+// Comparing such an instance field by field would only report noise. It
+// returns nil when the instance should be compared.
+func InactiveInstanceReport(actual *models.Instance, includeStopped bool) *models.DriftReport {
+	if !actual.IsTerminated() && (includeStopped || !actual.IsStopped()) {
+		return nil
+	}
+
+	report := models.NewDriftReport(actual.ID)
+	report.AddDrift(models.NewDrift(
+		models.DriftTypeRemoved,
+		"",
+		actual.State,
+		nil,
+		fmt.Sprintf("Instance exists in Terraform but is %s in AWS", actual.State),
+	))
+	return report
+}
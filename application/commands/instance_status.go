@@ -0,0 +1,142 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/domain/services"
+)
+
+// Status summarizes whether a Terraform configuration matches its instance
+type Status string
+
+const (
+	// StatusInSync means the instance has no drift
+	StatusInSync Status = "IN_SYNC"
+	// StatusDrifted means the instance has drifted from its configuration
+	StatusDrifted Status = "DRIFTED"
+	// StatusNotFound means AWS does not know the instance or it is terminated
+	StatusNotFound Status = "NOT_FOUND"
+	// StatusError means the instance could not be read or compared
+	StatusError Status = "ERROR"
+	// StatusUnknown means the configuration records no instance ID to look up,
+	// as for configurations parsed from .tf files
+	StatusUnknown Status = "UNKNOWN"
+)
+
+// InstanceStatus is the drift status of one Terraform configuration
+type InstanceStatus struct {
+	Status     Status `json:"status"`
+	DriftCount int    `json:"drift_count"`
+	Error      string `json:"error,omitempty"`
+}
+
+// String returns the status as listed in a table, with the drift count of
+// a drifted instance, e.g. DRIFTED(3)
+func (s *InstanceStatus) String() string {
+	if s.Status == StatusDrifted {
+		return fmt.Sprintf("%s(%d)", s.Status, s.DriftCount)
+	}
+	return string(s.Status)
+}
+
+// InstanceStatusHandler compares Terraform configurations with their
+// instances in AWS to report a status for each
+type InstanceStatusHandler struct {
+	detectionService services.DetectionService
+	instanceRepo     repositories.InstanceRepository
+	concurrency      int
+}
+
+// NewInstanceStatusHandler creates a new InstanceStatusHandler with at most
+// concurrency AWS lookups and comparisons in flight at once; below one
+// defaults to 10
+func NewInstanceStatusHandler(detectionService services.DetectionService, instanceRepo repositories.InstanceRepository, concurrency int) *InstanceStatusHandler {
+	if concurrency < 1 {
+		concurrency = defaultFetchConcurrency
+	}
+	return &InstanceStatusHandler{
+		detectionService: detectionService,
+		instanceRepo:     instanceRepo,
+		concurrency:      concurrency,
+	}
+}
+
+// Handle returns the status of each of configs, in the same order. An
+// instance that cannot be read or compared gets StatusError without
+// affecting the others; a stopped instance has the single finding the
+// detect command reports for it.
+func (h *InstanceStatusHandler) Handle(ctx context.Context, configs []*models.Instance) []*InstanceStatus {
+	desiredByID := make(map[string]*models.Instance)
+	var ids []string
+	for _, config := range configs {
+		if config.ID == "" {
+			continue
+		}
+		if _, seen := desiredByID[config.ID]; !seen {
+			ids = append(ids, config.ID)
+		}
+		desiredByID[config.ID] = config
+	}
+	sort.Strings(ids)
+
+	actualByID, fetchErrs := fetchInstancesEach(ctx, h.instanceRepo, ids, h.concurrency)
+
+	statusByID := make(map[string]*InstanceStatus, len(ids))
+	var pairs []services.InstancePair
+	for _, id := range ids {
+		actual, found := actualByID[id]
+		switch {
+		case fetchErrs[id] != nil:
+			statusByID[id] = &InstanceStatus{Status: StatusError, Error: fetchErrs[id].Error()}
+		case !found || actual.IsTerminated():
+			statusByID[id] = &InstanceStatus{Status: StatusNotFound}
+		default:
+			if report := InactiveInstanceReport(actual, false); report != nil {
+				statusByID[id] = reportStatus(report)
+				continue
+			}
+			pairs = append(pairs, services.InstancePair{Actual: actual, Desired: desiredByID[id]})
+		}
+	}
+
+	reports, err := h.detectionService.BatchDetectDriftConcurrent(ctx, pairs, h.concurrency)
+	var batchErr *services.BatchError
+	errors.As(err, &batchErr)
+	for _, pair := range pairs {
+		id := pair.Actual.ID
+		switch report, ok := reports[id]; {
+		case ok:
+			statusByID[id] = reportStatus(report)
+		case batchErr != nil && batchErr.Errors[id] != nil:
+			statusByID[id] = &InstanceStatus{Status: StatusError, Error: batchErr.Errors[id].Error()}
+		default:
+			if err == nil {
+				err = errors.New("no report was returned")
+			}
+			statusByID[id] = &InstanceStatus{Status: StatusError, Error: err.Error()}
+		}
+	}
+
+	statuses := make([]*InstanceStatus, len(configs))
+	for i, config := range configs {
+		if config.ID == "" {
+			statuses[i] = &InstanceStatus{Status: StatusUnknown}
+			continue
+		}
+		statuses[i] = statusByID[config.ID]
+	}
+	return statuses
+}
+
+// reportStatus returns the status of an instance compared into report
+func reportStatus(report *models.DriftReport) *InstanceStatus {
+	if !report.HasDrifts() {
+		return &InstanceStatus{Status: StatusInSync}
+	}
+	return &InstanceStatus{Status: StatusDrifted, DriftCount: len(report.Drifts)}
+}
@@ -0,0 +1,78 @@
+package commands_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/application/commands"
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestInstanceStatusHandler_Handle(t *testing.T) {
+	// Given configurations that are in sync, drifted, missing, terminated,
+	// failing to compare and without an ID
+	desired := []*models.Instance{
+		models.NewInstance("i-sync", "t3.micro", "ami-1"),
+		models.NewInstance("i-drift", "t3.micro", "ami-1"),
+		models.NewInstance("i-missing", "t3.micro", "ami-1"),
+		models.NewInstance("i-gone", "t3.micro", "ami-1"),
+		models.NewInstance("i-fail", "t3.micro", "ami-1"),
+		models.NewInstance("", "t3.micro", "ami-1"),
+	}
+	terminated := models.NewInstance("i-gone", "t3.micro", "ami-1")
+	terminated.State = models.InstanceStateTerminated
+	actual := map[string]*models.Instance{
+		"i-sync":  models.NewInstance("i-sync", "t3.micro", "ami-1"),
+		"i-drift": models.NewInstance("i-drift", "t3.large", "ami-2"),
+		"i-gone":  terminated,
+		"i-fail":  models.NewInstance("i-fail", "t3.micro", "ami-1"),
+	}
+	handler := commands.NewInstanceStatusHandler(
+		&failingDetectionService{
+			DefaultDetectionService: services.NewDetectionService(),
+			fail:                    map[string]error{"i-fail": errors.New("boom")},
+		},
+		&fakeInstanceRepo{instances: actual},
+		2,
+	)
+
+	// When
+	statuses := handler.Handle(context.Background(), desired)
+
+	// Then each configuration has its status, in order, despite the failure
+	require.Len(t, statuses, len(desired))
+	var got []string
+	for _, status := range statuses {
+		got = append(got, status.String())
+	}
+	assert.Equal(t, []string{"IN_SYNC", "DRIFTED(2)", "NOT_FOUND", "NOT_FOUND", "ERROR", "UNKNOWN"}, got)
+	assert.Equal(t, 2, statuses[1].DriftCount)
+	assert.Equal(t, "boom", statuses[4].Error)
+}
+
+func TestInstanceStatusHandler_FetchFailure(t *testing.T) {
+	// Given an instance repository that cannot be read
+	handler := commands.NewInstanceStatusHandler(
+		services.NewDetectionService(),
+		&fakeInstanceRepo{err: errors.New("access denied")},
+		0,
+	)
+	desired := []*models.Instance{
+		models.NewInstance("i-1", "t3.micro", "ami-1"),
+		models.NewInstance("", "t3.micro", "ami-1"),
+	}
+
+	// When
+	statuses := handler.Handle(context.Background(), desired)
+
+	// Then the instance is listed with the error, and the configuration without an ID stays unknown
+	require.Len(t, statuses, 2)
+	assert.Equal(t, commands.StatusError, statuses[0].Status)
+	assert.Equal(t, "access denied", statuses[0].Error)
+	assert.Equal(t, commands.StatusUnknown, statuses[1].Status)
+}
@@ -0,0 +1,70 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+)
+
+// ErrNoMatchingConfig is returned when no Terraform configuration matches an instance
+var ErrNoMatchingConfig = errors.New("no matching Terraform configuration")
+
+// MatchInstanceConfig returns the Terraform configuration for an instance read
+// from AWS, as matched by a ConfigMatcher. It fails when several
+// configurations match, e.g. resources sharing a Name tag.
+func MatchInstanceConfig(configs []*models.Instance, actual *models.Instance, resourceAddress string) (*models.Instance, error) {
+	candidate, err := NewConfigMatcher(configs).Match(actual, resourceAddress)
+	if err != nil {
+		return nil, err
+	}
+	return candidate.Config, nil
+}
+
+// candidateAddresses lists the distinct resource addresses of configs
+func candidateAddresses(configs []*models.Instance) string {
+	seen := make(map[string]bool)
+	var addresses []string
+	for _, inst := range configs {
+		if inst.ResourceAddress != "" && !seen[inst.ResourceAddress] {
+			seen[inst.ResourceAddress] = true
+			addresses = append(addresses, inst.ResourceAddress)
+		}
+	}
+	if len(addresses) == 0 {
+		return "none"
+	}
+	sort.Strings(addresses)
+	return strings.Join(addresses, ", ")
+}
+
+// FindMatchingConfig returns the Terraform configuration for an instance,
+// matching by instance ID first and then by resource address
+func FindMatchingConfig(configs []*models.Instance, instanceID, resourceAddress string) *models.Instance {
+	for _, inst := range configs {
+		if inst.ID != "" && inst.ID == instanceID {
+			return inst
+		}
+	}
+
+	if resourceAddress == "" {
+		return nil
+	}
+
+	for _, inst := range configs {
+		if inst.ResourceAddress == resourceAddress {
+			return inst
+		}
+	}
+
+	return nil
+}
+
+// FindInstanceByName returns the one running instance whose Name tag equals
+// name. The error lists the candidates when several match.
+func FindInstanceByName(ctx context.Context, repo repositories.InstanceRepository, name string) (*models.Instance, error) {
+	return NewConfigMatcher(nil).FindInstanceByName(ctx, repo, name)
+}
@@ -0,0 +1,89 @@
+package commands_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/application/commands"
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/infrastructure/mock"
+	legacy "driftdetector/models"
+)
+
+func TestMatchInstanceConfig(t *testing.T) {
+	config := func(id, address, name string) *models.Instance {
+		inst := models.NewInstance(id, "t3.micro", "ami-1")
+		inst.ResourceAddress = address
+		if name != "" {
+			inst.AddTag("Name", name)
+		}
+		return inst
+	}
+	bastion := config("i-bastion", "aws_instance.bastion", "bastion")
+	web := config("", "aws_instance.web", "web")
+	api := config("", "aws_instance.api", "api")
+	configs := []*models.Instance{bastion, web, api}
+
+	actual := models.NewInstance("i-prod", "t3.micro", "ami-1")
+	actual.AddTag("Name", "web")
+
+	t.Run("by instance ID", func(t *testing.T) {
+		got, err := commands.MatchInstanceConfig(configs, models.NewInstance("i-bastion", "t3.micro", "ami-1"), "")
+		require.NoError(t, err)
+		assert.Same(t, bastion, got)
+	})
+
+	t.Run("by resource address before Name tag", func(t *testing.T) {
+		got, err := commands.MatchInstanceConfig(configs, actual, "aws_instance.api")
+		require.NoError(t, err)
+		assert.Same(t, api, got)
+	})
+
+	t.Run("by Name tag", func(t *testing.T) {
+		got, err := commands.MatchInstanceConfig(configs, actual, "")
+		require.NoError(t, err)
+		assert.Same(t, web, got)
+	})
+
+	t.Run("no match lists candidates", func(t *testing.T) {
+		_, err := commands.MatchInstanceConfig(configs, models.NewInstance("i-other", "t3.micro", "ami-1"), "")
+
+		assert.ErrorIs(t, err, commands.ErrNoMatchingConfig)
+		assert.ErrorContains(t, err, "aws_instance.api, aws_instance.bastion, aws_instance.web")
+	})
+
+	t.Run("unknown resource address", func(t *testing.T) {
+		_, err := commands.MatchInstanceConfig(configs, actual, "aws_instance.db")
+
+		assert.ErrorIs(t, err, commands.ErrNoMatchingConfig)
+		assert.ErrorContains(t, err, "aws_instance.db")
+	})
+}
+
+func TestFindInstanceByName(t *testing.T) {
+	named := func(id, name string) *legacy.InstanceConfig {
+		return &legacy.InstanceConfig{InstanceID: id, Tags: map[string]string{"Name": name}}
+	}
+	repo := mock.NewInstanceRepository(named("i-web", "web"), named("i-api-1", "api"), named("i-api-2", "api"))
+
+	t.Run("one match", func(t *testing.T) {
+		got, err := commands.FindInstanceByName(context.Background(), repo, "web")
+		require.NoError(t, err)
+		assert.Equal(t, "i-web", got.ID)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		_, err := commands.FindInstanceByName(context.Background(), repo, "db")
+		assert.ErrorIs(t, err, repositories.ErrInstanceNotFound)
+	})
+
+	t.Run("several matches list the candidates", func(t *testing.T) {
+		_, err := commands.FindInstanceByName(context.Background(), repo, "api")
+		assert.ErrorIs(t, err, commands.ErrAmbiguousName)
+		assert.ErrorContains(t, err, "candidates: i-api-1, i-api-2")
+	})
+}
@@ -0,0 +1,152 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+)
+
+// ErrAmbiguousMatch is returned when more than one Terraform configuration
+// matches an instance
+var ErrAmbiguousMatch = errors.New("more than one Terraform configuration matches")
+
+// MatchByResourceAddress means the configuration has the resource address
+// the user named
+const MatchByResourceAddress Match = "resource_address"
+
+// Candidate is a Terraform configuration that may describe an instance,
+// with the reason it matched
+type Candidate struct {
+	Config    *models.Instance
+	MatchedBy Match
+}
+
+// String describes the candidate by its resource address and, when known,
+// its instance ID
+func (c Candidate) String() string {
+	address := c.Config.ResourceAddress
+	if address == "" {
+		address = "<unknown resource>"
+	}
+	if c.Config.ID == "" {
+		return address
+	}
+	return fmt.Sprintf("%s (%s)", address, c.Config.ID)
+}
+
+// ConfigMatcher pairs instances read from AWS with their Terraform
+// configurations. Several configurations may share a Name tag, e.g. the
+// instances of a resource using count, so every candidate is returned and
+// a match is only made when exactly one remains.
+type ConfigMatcher struct {
+	configs []*models.Instance
+	// PickFirst selects the first of several candidates instead of failing
+	PickFirst bool
+}
+
+// NewConfigMatcher creates a ConfigMatcher over configs, kept in their order
+func NewConfigMatcher(configs []*models.Instance) *ConfigMatcher {
+	return &ConfigMatcher{configs: configs}
+}
+
+// Candidates returns the configurations matching actual: those recording its
+// instance ID, else those at the resource address the user named, else those
+// whose Name tag equals the instance's. An explicit address is preferred over
+// the Name tag so that a shared or copied Name cannot select the wrong
+// resource.
+func (m *ConfigMatcher) Candidates(actual *models.Instance, resourceAddress string) []Candidate {
+	if candidates := m.filter(MatchByInstanceID, func(inst *models.Instance) bool {
+		return inst.ID != "" && inst.ID == actual.ID
+	}); len(candidates) > 0 {
+		return candidates
+	}
+
+	if resourceAddress != "" {
+		return m.filter(MatchByResourceAddress, func(inst *models.Instance) bool {
+			return inst.ResourceAddress == resourceAddress
+		})
+	}
+
+	name := actual.Tags["Name"]
+	if name == "" {
+		return nil
+	}
+	return m.filter(MatchByName, func(inst *models.Instance) bool {
+		return inst.Tags["Name"] == name
+	})
+}
+
+// filter returns the configurations for which keep is true, as candidates
+// matched by reason
+func (m *ConfigMatcher) filter(reason Match, keep func(*models.Instance) bool) []Candidate {
+	var candidates []Candidate
+	for _, inst := range m.configs {
+		if keep(inst) {
+			candidates = append(candidates, Candidate{Config: inst, MatchedBy: reason})
+		}
+	}
+	return candidates
+}
+
+// Match returns the one configuration matching actual. When nothing
+// matches, the error lists the resource addresses found; when several
+// match, it lists the candidates unless PickFirst is set.
+func (m *ConfigMatcher) Match(actual *models.Instance, resourceAddress string) (Candidate, error) {
+	candidates := m.Candidates(actual, resourceAddress)
+	switch {
+	case len(candidates) == 1, len(candidates) > 1 && m.PickFirst:
+		return candidates[0], nil
+	case len(candidates) > 1:
+		descriptions := make([]string, 0, len(candidates))
+		for _, candidate := range candidates {
+			descriptions = append(descriptions, candidate.String())
+		}
+		return Candidate{}, fmt.Errorf("%w instance %s by %s; candidates: %s (select one with --resource, or pass --pick-first)",
+			ErrAmbiguousMatch, actual.ID, candidates[0].MatchedBy, strings.Join(descriptions, ", "))
+	case resourceAddress != "":
+		return Candidate{}, fmt.Errorf("%w: resource %s not found; candidates: %s", ErrNoMatchingConfig, resourceAddress, candidateAddresses(m.configs))
+	default:
+		return Candidate{}, fmt.Errorf("%w for instance %s; candidates: %s (select one with --resource)", ErrNoMatchingConfig, actual.ID, candidateAddresses(m.configs))
+	}
+}
+
+// ErrAmbiguousName is returned when more than one running instance carries
+// the Name tag an instance is looked up by
+var ErrAmbiguousName = errors.New("more than one running instance has this name")
+
+// FindInstanceByName returns the one running instance whose Name tag equals
+// name, or the one with the lowest ID when PickFirst is set. When several
+// match, the error lists their IDs together with the resource address each
+// one is recorded at.
+func (m *ConfigMatcher) FindInstanceByName(ctx context.Context, repo repositories.InstanceRepository, name string) (*models.Instance, error) {
+	instances, err := repo.FindByTag(ctx, "Name", name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find instances named %s: %w", name, err)
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("%w: no running instance is named %s", repositories.ErrInstanceNotFound, name)
+	}
+
+	sorted := append([]*models.Instance(nil), instances...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	if len(sorted) == 1 || m.PickFirst {
+		return sorted[0], nil
+	}
+
+	descriptions := make([]string, 0, len(sorted))
+	for _, inst := range sorted {
+		description := inst.ID
+		if candidates := m.filter(MatchByInstanceID, func(config *models.Instance) bool {
+			return config.ID == inst.ID
+		}); len(candidates) > 0 && candidates[0].Config.ResourceAddress != "" {
+			description = fmt.Sprintf("%s (%s)", inst.ID, candidates[0].Config.ResourceAddress)
+		}
+		descriptions = append(descriptions, description)
+	}
+	return nil, fmt.Errorf("%w: %s; candidates: %s (select one with --instance, or pass --pick-first)", ErrAmbiguousName, name, strings.Join(descriptions, ", "))
+}
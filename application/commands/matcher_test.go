@@ -0,0 +1,172 @@
+package commands_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/application/commands"
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/mock"
+	legacy "driftdetector/models"
+)
+
+func TestConfigMatcher_Match(t *testing.T) {
+	config := func(id, address, name string) *models.Instance {
+		inst := models.NewInstance(id, "t3.micro", "ami-1")
+		inst.ResourceAddress = address
+		if name != "" {
+			inst.AddTag("Name", name)
+		}
+		return inst
+	}
+	bastion := config("i-bastion", "aws_instance.bastion", "bastion")
+	web0 := config("i-web-0", "aws_instance.web[0]", "web")
+	web1 := config("i-web-1", "aws_instance.web[1]", "web")
+	api := config("", "aws_instance.api", "api")
+	unnamed := config("", "aws_instance.worker", "")
+	configs := []*models.Instance{bastion, web0, web1, api, unnamed}
+
+	named := func(id, name string) *models.Instance {
+		inst := models.NewInstance(id, "t3.micro", "ami-1")
+		if name != "" {
+			inst.AddTag("Name", name)
+		}
+		return inst
+	}
+
+	tests := []struct {
+		name      string
+		actual    *models.Instance
+		address   string
+		pickFirst bool
+		want      *models.Instance
+		matchedBy commands.Match
+		wantErr   error
+		errText   string
+	}{
+		{
+			name:      "unique ID match wins over a shared Name tag",
+			actual:    named("i-web-1", "web"),
+			want:      web1,
+			matchedBy: commands.MatchByInstanceID,
+		},
+		{
+			name:      "unique Name match",
+			actual:    named("i-new", "api"),
+			want:      api,
+			matchedBy: commands.MatchByName,
+		},
+		{
+			name:      "resource address before Name tag",
+			actual:    named("i-new", "web"),
+			address:   "aws_instance.web[1]",
+			want:      web1,
+			matchedBy: commands.MatchByResourceAddress,
+		},
+		{
+			name:    "multiple Name matches list the candidates",
+			actual:  named("i-new", "web"),
+			wantErr: commands.ErrAmbiguousMatch,
+			errText: "candidates: aws_instance.web[0] (i-web-0), aws_instance.web[1] (i-web-1)",
+		},
+		{
+			name:      "multiple Name matches with pick first",
+			actual:    named("i-new", "web"),
+			pickFirst: true,
+			want:      web0,
+			matchedBy: commands.MatchByName,
+		},
+		{
+			name:    "no match lists the resource addresses",
+			actual:  named("i-other", "db"),
+			wantErr: commands.ErrNoMatchingConfig,
+			errText: "aws_instance.api, aws_instance.bastion, aws_instance.web[0], aws_instance.web[1], aws_instance.worker",
+		},
+		{
+			name:    "an empty Name tag never matches",
+			actual:  named("i-other", ""),
+			wantErr: commands.ErrNoMatchingConfig,
+		},
+		{
+			name:    "unknown resource address",
+			actual:  named("i-new", "web"),
+			address: "aws_instance.db",
+			wantErr: commands.ErrNoMatchingConfig,
+			errText: "resource aws_instance.db not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given a matcher over configurations, two of them sharing a Name tag
+			matcher := commands.NewConfigMatcher(configs)
+			matcher.PickFirst = tt.pickFirst
+
+			// When the instance is matched
+			got, err := matcher.Match(tt.actual, tt.address)
+
+			// Then the one candidate is returned, or the error explains why none is
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				if tt.errText != "" {
+					assert.ErrorContains(t, err, tt.errText)
+				}
+				return
+			}
+			require.NoError(t, err)
+			assert.Same(t, tt.want, got.Config)
+			assert.Equal(t, tt.matchedBy, got.MatchedBy)
+		})
+	}
+}
+
+func TestConfigMatcher_Candidates(t *testing.T) {
+	// Given two configurations sharing a Name tag
+	web0 := models.NewInstance("", "t3.micro", "ami-1")
+	web0.ResourceAddress = "aws_instance.web[0]"
+	web0.AddTag("Name", "web")
+	web1 := models.NewInstance("", "t3.micro", "ami-1")
+	web1.ResourceAddress = "aws_instance.web[1]"
+	web1.AddTag("Name", "web")
+	actual := models.NewInstance("i-new", "t3.micro", "ami-1")
+	actual.AddTag("Name", "web")
+
+	// When the candidates for an instance with that name are listed
+	candidates := commands.NewConfigMatcher([]*models.Instance{web0, web1}).Candidates(actual, "")
+
+	// Then both are returned in order, matched by name
+	require.Len(t, candidates, 2)
+	assert.Same(t, web0, candidates[0].Config)
+	assert.Same(t, web1, candidates[1].Config)
+	assert.Equal(t, commands.MatchByName, candidates[1].MatchedBy)
+	assert.Equal(t, "aws_instance.web[0]", candidates[0].String())
+}
+
+func TestConfigMatcher_FindInstanceByName(t *testing.T) {
+	named := func(id, name string) *legacy.InstanceConfig {
+		return &legacy.InstanceConfig{InstanceID: id, Tags: map[string]string{"Name": name}}
+	}
+	repo := mock.NewInstanceRepository(named("i-web-1", "web"), named("i-web-0", "web"))
+	config := models.NewInstance("i-web-0", "t3.micro", "ami-1")
+	config.ResourceAddress = "aws_instance.web[0]"
+
+	t.Run("several matches list IDs and resource addresses", func(t *testing.T) {
+		_, err := commands.NewConfigMatcher([]*models.Instance{config}).FindInstanceByName(context.Background(), repo, "web")
+
+		assert.ErrorIs(t, err, commands.ErrAmbiguousName)
+		assert.ErrorContains(t, err, "candidates: i-web-0 (aws_instance.web[0]), i-web-1 (select one")
+	})
+
+	t.Run("pick first takes the lowest ID", func(t *testing.T) {
+		matcher := commands.NewConfigMatcher([]*models.Instance{config})
+		matcher.PickFirst = true
+
+		got, err := matcher.FindInstanceByName(context.Background(), repo, "web")
+
+		require.NoError(t, err)
+		assert.Equal(t, "i-web-0", got.ID)
+	})
+}
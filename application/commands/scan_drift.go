@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/domain/services"
+)
+
+// ScanDriftCommand represents the command to discover running instances by
+// tag and check each of them against Terraform
+type ScanDriftCommand struct {
+	Tags map[string]string
+	// Filter further narrows the instances scanned; its tags are required
+	// along with Tags, and without states only running instances are scanned
+	Filter             repositories.InstanceFilter
+	TerraformStateFile string
+	TerraformDir       string
+	// AccountID is recorded on every result, when instances are read from
+	// one of several accounts
+	AccountID string
+}
+
+// Match describes how an AWS instance was paired with its Terraform configuration
+type Match string
+
+const (
+	// MatchByInstanceID means the configuration records the instance's ID
+	MatchByInstanceID Match = "instance_id"
+	// MatchByName means the configuration's Name tag equals the instance's
+	MatchByName Match = "name"
+)
+
+// ScanResult is the outcome of scanning one AWS instance
+type ScanResult struct {
+	AccountID  string `json:"account_id,omitempty"`
+	InstanceID string `json:"instance_id"`
+	Name       string `json:"name,omitempty"`
+	// Managed is false when no Terraform configuration matches the instance
+	Managed   bool                `json:"managed"`
+	MatchedBy Match               `json:"matched_by,omitempty"`
+	Report    *models.DriftReport `json:"report,omitempty"`
+}
+
+// DriftCount returns the number of drift findings, or 0 for unmanaged instances
+func (r *ScanResult) DriftCount() int {
+	if r.Report == nil {
+		return 0
+	}
+	return len(r.Report.Drifts)
+}
+
+// ScanDriftHandler handles the ScanDriftCommand
+type ScanDriftHandler struct {
+	detectionService services.DetectionService
+	instanceRepo     repositories.InstanceRepository
+	tfStateRepo      repositories.TerraformStateRepository
+}
+
+// NewScanDriftHandler creates a new ScanDriftHandler
+func NewScanDriftHandler(
+	detectionService services.DetectionService,
+	instanceRepo repositories.InstanceRepository,
+	tfStateRepo repositories.TerraformStateRepository,
+) *ScanDriftHandler {
+	return &ScanDriftHandler{
+		detectionService: detectionService,
+		instanceRepo:     instanceRepo,
+		tfStateRepo:      tfStateRepo,
+	}
+}
+
+// Handle processes the ScanDriftCommand. Instances matching the tags and
+// filter are paired with Terraform configuration by instance ID, then by Name tag.
+// Instances without a match are returned as unmanaged. Results are ordered
+// by instance ID.
+func (h *ScanDriftHandler) Handle(ctx context.Context, cmd ScanDriftCommand) ([]*ScanResult, error) {
+	desiredInstances, err := NewTerraformSource(h.tfStateRepo, cmd.TerraformStateFile, cmd.TerraformDir, "").Instances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := cmd.Filter
+	if len(cmd.Tags) > 0 {
+		filter.Tags = make(map[string]string, len(cmd.Tags)+len(cmd.Filter.Tags))
+		for key, value := range cmd.Filter.Tags {
+			filter.Tags[key] = value
+		}
+		for key, value := range cmd.Tags {
+			filter.Tags[key] = value
+		}
+	}
+	if len(filter.States) == 0 {
+		filter.States = []string{models.InstanceStateRunning}
+	}
+
+	actualInstances, err := h.instanceRepo.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find instances in AWS: %w", err)
+	}
+
+	// A scan covers many instances, so one sharing its Name tag with several
+	// configurations is compared against the first instead of failing all
+	matcher := NewConfigMatcher(desiredInstances)
+	matcher.PickFirst = true
+
+	results := make([]*ScanResult, 0, len(actualInstances))
+	for _, actual := range actualInstances {
+		result := &ScanResult{AccountID: cmd.AccountID, InstanceID: actual.ID, Name: actual.Tags["Name"]}
+		results = append(results, result)
+
+		candidate, err := matcher.Match(actual, "")
+		if err != nil {
+			continue
+		}
+		desired := candidate.Config
+		result.Managed = true
+		result.MatchedBy = candidate.MatchedBy
+
+		// Compare against a copy carrying the AWS ID, since a Name match may
+		// come from a configuration without an ID or with a stale one
+		matched := *desired
+		matched.ID = actual.ID
+
+		report, err := h.detectionService.DetectDrift(ctx, actual, &matched)
+		if err != nil {
+			return nil, fmt.Errorf("failed to detect drift for %s: %w", actual.ID, err)
+		}
+		if desired.ID != "" && desired.ID != actual.ID {
+			report.AddDrift(models.NewDrift(
+				models.DriftTypeModified,
+				"ID",
+				actual.ID,
+				desired.ID,
+				"Instance matched by Name tag has a different ID in Terraform state",
+			))
+		}
+		result.Report = report
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].InstanceID < results[j].InstanceID })
+	return results, nil
+}
@@ -0,0 +1,131 @@
+package commands_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/application/commands"
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/domain/services"
+)
+
+// Find returns the instances matching filter
+func (r *fakeInstanceRepo) Find(ctx context.Context, filter repositories.InstanceFilter) ([]*models.Instance, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	var found []*models.Instance
+	for _, inst := range r.instances {
+		if filter.Matches(inst) {
+			found = append(found, inst)
+		}
+	}
+	return found, nil
+}
+
+// taggedInstance returns an instance with Environment and Name tags
+func taggedInstance(id, instanceType, env, name string) *models.Instance {
+	inst := models.NewInstance(id, instanceType, "ami-1")
+	inst.AddTag("Environment", env)
+	if name != "" {
+		inst.AddTag("Name", name)
+	}
+	return inst
+}
+
+func TestScanDriftHandler_Handle(t *testing.T) {
+	actual := map[string]*models.Instance{
+		"i-1":     taggedInstance("i-1", "t3.micro", "prod", "web"),
+		"i-2":     taggedInstance("i-2", "t3.large", "prod", "api"),
+		"i-3":     taggedInstance("i-3", "t3.micro", "prod", "adhoc"),
+		"i-other": taggedInstance("i-other", "t3.micro", "dev", "web"),
+	}
+	desired := []*models.Instance{
+		taggedInstance("i-1", "t3.micro", "prod", "web"),
+		taggedInstance("i-old", "t3.micro", "prod", "api"),
+	}
+
+	handler := commands.NewScanDriftHandler(
+		services.NewDetectionService(),
+		&fakeInstanceRepo{instances: actual},
+		&fakeStateRepo{instances: desired},
+	)
+
+	results, err := handler.Handle(context.Background(), commands.ScanDriftCommand{
+		Tags:               map[string]string{"Environment": "prod"},
+		TerraformStateFile: "prod.tfstate",
+		AccountID:          "111111111111",
+	})
+
+	require.NoError(t, err)
+	require.Len(t, results, 3, "Only instances with matching tags are scanned")
+
+	byID := make(map[string]*commands.ScanResult)
+	for _, r := range results {
+		byID[r.InstanceID] = r
+		assert.Equal(t, "111111111111", r.AccountID)
+	}
+
+	web := byID["i-1"]
+	assert.True(t, web.Managed)
+	assert.Equal(t, commands.MatchByInstanceID, web.MatchedBy)
+	assert.Equal(t, 0, web.DriftCount())
+
+	api := byID["i-2"]
+	assert.True(t, api.Managed)
+	assert.Equal(t, commands.MatchByName, api.MatchedBy)
+	require.NotNil(t, api.Report)
+	var paths []string
+	for _, d := range api.Report.Drifts {
+		paths = append(paths, d.Path)
+	}
+	assert.ElementsMatch(t, []string{"Type", "ID"}, paths, "Name matches report the stale ID")
+
+	adhoc := byID["i-3"]
+	assert.False(t, adhoc.Managed)
+	assert.Nil(t, adhoc.Report)
+	assert.Equal(t, 0, adhoc.DriftCount())
+}
+
+func TestScanDriftHandler_HandleFilter(t *testing.T) {
+	stopped := taggedInstance("i-4", "t3.large", "prod", "batch")
+	stopped.State = models.InstanceStateStopped
+	actual := map[string]*models.Instance{
+		"i-1": taggedInstance("i-1", "t3.micro", "prod", "web"),
+		"i-2": taggedInstance("i-2", "t3.large", "prod", "api"),
+		"i-3": taggedInstance("i-3", "t3.large", "dev", "api"),
+		"i-4": stopped,
+	}
+	handler := commands.NewScanDriftHandler(
+		services.NewDetectionService(),
+		&fakeInstanceRepo{instances: actual},
+		&fakeStateRepo{},
+	)
+
+	t.Run("combines with the tags and scans running instances", func(t *testing.T) {
+		results, err := handler.Handle(context.Background(), commands.ScanDriftCommand{
+			Tags:               map[string]string{"Environment": "prod"},
+			Filter:             repositories.InstanceFilter{InstanceTypes: []string{"t3.l*"}},
+			TerraformStateFile: "prod.tfstate",
+		})
+
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "i-2", results[0].InstanceID)
+	})
+
+	t.Run("states replace running", func(t *testing.T) {
+		results, err := handler.Handle(context.Background(), commands.ScanDriftCommand{
+			Filter:             repositories.InstanceFilter{States: []string{models.InstanceStateStopped}},
+			TerraformStateFile: "prod.tfstate",
+		})
+
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		assert.Equal(t, "i-4", results[0].InstanceID)
+	})
+}
@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+)
+
+// Source provides instance configurations read from somewhere other than
+// AWS, such as a Terraform state or an instance snapshot
+type Source interface {
+	// Instances returns the configurations the source holds
+	Instances(ctx context.Context) ([]*models.Instance, error)
+	// String names the source in reports and errors, e.g. its path
+	String() string
+}
+
+// terraformSource reads instances from a Terraform state file, configuration
+// directory or plan, whichever is set
+type terraformSource struct {
+	repo      repositories.TerraformStateRepository
+	stateFile string
+	dir       string
+	planFile  string
+}
+
+// NewTerraformSource returns a Source reading the state file, the
+// configuration directory or the plan file, in that order of preference
+func NewTerraformSource(repo repositories.TerraformStateRepository, stateFile, dir, planFile string) Source {
+	return &terraformSource{repo: repo, stateFile: stateFile, dir: dir, planFile: planFile}
+}
+
+func (s *terraformSource) Instances(ctx context.Context) ([]*models.Instance, error) {
+	var instances []*models.Instance
+	var err error
+	if s.stateFile != "" {
+		instances, err = s.repo.GetInstanceConfigs(ctx, s.stateFile)
+	} else if s.dir != "" {
+		instances, err = s.repo.GetInstanceConfigsFromDir(ctx, s.dir)
+	} else if s.planFile != "" {
+		instances, err = LoadPlanInstances(ctx, s.repo, s.planFile)
+	} else {
+		return nil, fmt.Errorf("either terraform state file, directory or plan file must be provided")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get desired state from Terraform: %w", err)
+	}
+
+	return instances, nil
+}
+
+func (s *terraformSource) String() string {
+	switch {
+	case s.stateFile != "":
+		return s.stateFile
+	case s.dir != "":
+		return s.dir
+	default:
+		return s.planFile
+	}
+}
+
+// staticSource holds instances loaded up front, such as a snapshot file's
+type staticSource struct {
+	name      string
+	instances []*models.Instance
+}
+
+// NewStaticSource returns a Source named name that holds instances
+func NewStaticSource(name string, instances ...*models.Instance) Source {
+	return &staticSource{name: name, instances: instances}
+}
+
+func (s *staticSource) Instances(ctx context.Context) ([]*models.Instance, error) {
+	return s.instances, nil
+}
+
+func (s *staticSource) String() string {
+	return s.name
+}
+
+// securityGroupSource resolves the security group references of another
+// source's instances
+type securityGroupSource struct {
+	Source
+	groups []*models.SecurityGroupConfig
+}
+
+// WithSecurityGroupRefs returns a Source reading source and replacing the
+// references to aws_security_group resources in its instances, such as
+// aws_security_group.web.id, with the IDs groups records for them. Without
+// groups, source is returned as it is.
+func WithSecurityGroupRefs(source Source, groups []*models.SecurityGroupConfig) Source {
+	if len(groups) == 0 {
+		return source
+	}
+	return &securityGroupSource{Source: source, groups: groups}
+}
+
+func (s *securityGroupSource) Instances(ctx context.Context) ([]*models.Instance, error) {
+	instances, err := s.Source.Instances(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, instance := range instances {
+		instance.ResolveSecurityGroupRefs(s.groups)
+	}
+	return instances, nil
+}
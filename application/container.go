@@ -3,11 +3,16 @@ package application
 import (
 	"context"
 	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/config"
 	detectionsvc "driftdetector/domain/services"
+	"driftdetector/infrastructure/awsutil"
 	awsrepo "driftdetector/infrastructure/aws"
+	"driftdetector/infrastructure/cache"
 	"driftdetector/infrastructure/terraform"
 	tfrepo "driftdetector/infrastructure/terraform"
 	repositories "driftdetector/domain/repositories"
@@ -18,6 +23,10 @@ type Container struct {
 	// Repositories
 	instanceRepo repositories.InstanceRepository
 	tfRepo      repositories.TerraformStateRepository
+	sgRepo      repositories.SecurityGroupRepository
+	imageRepo   repositories.ImageRepository
+	subnetRepo  repositories.SubnetRepository
+	eipRepo     repositories.ElasticIPRepository
 
 	// Services
 	detectionSvc detectionsvc.DetectionService
@@ -26,8 +35,57 @@ type Container struct {
 	awsFactory awsrepo.ClientFactory
 	tfParser   terraform.StateParser
 
+	// Terraform CLI integration
+	planRunner terraform.PlanRunner
+
+	// Detector configuration
+	detectorOpts []detectionsvc.DetectorOption
+
 	// AWS Config
-	awsConfig aws.Config
+	awsConfig  aws.Config
+	awsRegion  string
+	awsProfile string
+
+	// Role assumed for EC2 calls, e.g. in another account
+	assumeRole *awsrepo.AssumeRole
+
+	// Region of the S3 bucket holding remote Terraform state
+	stateRegion string
+
+	// Input read for the terraform.StdinState location; nil reads os.Stdin
+	stdin io.Reader
+
+	// Fail on state with resources that cannot be decoded
+	strictState bool
+
+	// Variable assignments for Terraform configuration files
+	hclOpts []terraform.HCLParserOption
+
+	// Read instance profiles from their IAM associations
+	resolveIAM bool
+
+	// Fail on instances whose settings could not all be read
+	requireFullAccess bool
+
+	// Attempts made for each EC2 call; zero keeps the repository default
+	maxAttempts int
+
+	// Directory instances are cached in between runs; empty disables the cache
+	cacheDir  string
+	cacheOpts []cache.InstanceRepositoryOption
+
+	// AWS clients, created by initAWS on the first AWS-backed operation
+	withoutAWS bool
+	awsOnce    sync.Once
+	awsErr     error
+	ec2Repo    repositories.InstanceRepository
+	ec2SGRepo  repositories.SecurityGroupRepository
+	ec2ImgRepo repositories.ImageRepository
+	ec2Subnets repositories.SubnetRepository
+	ec2EIPs    repositories.ElasticIPRepository
+	ec2AMIs    terraform.AMIResolver
+	s3Client   awsrepo.S3API
+	s3Uploader awsrepo.S3API
 }
 
 // ContainerOption is a function that configures the container
@@ -41,6 +99,141 @@ func WithAWSConfig(cfg aws.Config) ContainerOption {
 	}
 }
 
+// WithRegion sets the AWS region used when no config is provided, taking
+// precedence over the environment and shared config
+func WithRegion(region string) ContainerOption {
+	return func(c *Container) error {
+		c.awsRegion = region
+		return nil
+	}
+}
+
+// WithProfile selects the shared config profile used when no config is provided
+func WithProfile(profile string) ContainerOption {
+	return func(c *Container) error {
+		c.awsProfile = profile
+		return nil
+	}
+}
+
+// WithAssumeRole reads instances with the credentials of the IAM role
+// roleARN, assumed with the loaded credentials. externalID is passed when
+// the role's trust policy requires one.
+func WithAssumeRole(roleARN, externalID string) ContainerOption {
+	return func(c *Container) error {
+		role, err := awsrepo.ParseAssumeRole(roleARN, externalID)
+		if err != nil {
+			return err
+		}
+		c.assumeRole = &role
+		return nil
+	}
+}
+
+// WithStateRegion sets the region used to read s3:// Terraform state, for
+// buckets outside the region the instances are read from
+func WithStateRegion(region string) ContainerOption {
+	return func(c *Container) error {
+		c.stateRegion = region
+		return nil
+	}
+}
+
+// WithStdin sets the input a state location of - is read from, such as
+// the input of a command
+func WithStdin(in io.Reader) ContainerOption {
+	return func(c *Container) error {
+		c.stdin = in
+		return nil
+	}
+}
+
+// WithStrictState fails on Terraform state holding a resource that cannot
+// be decoded, instead of leaving the resource out with a warning
+func WithStrictState() ContainerOption {
+	return func(c *Container) error {
+		c.strictState = true
+		return nil
+	}
+}
+
+// WithTerraformVariables assigns input variables used to evaluate Terraform
+// configuration files, like terraform -var-file and -var
+func WithTerraformVariables(varFiles []string, vars map[string]string) ContainerOption {
+	return func(c *Container) error {
+		for _, path := range varFiles {
+			if _, err := os.Stat(path); err != nil {
+				return fmt.Errorf("reading variable file: %w", err)
+			}
+		}
+		c.hclOpts = append(c.hclOpts, terraform.WithVarFiles(varFiles...), terraform.WithVars(vars))
+		return nil
+	}
+}
+
+// WithWorkspace reads the state of the named Terraform workspace from
+// configuration directories; "" keeps the workspace selected in each
+func WithWorkspace(name string) ContainerOption {
+	return func(c *Container) error {
+		if name != "" {
+			c.hclOpts = append(c.hclOpts, terraform.WithWorkspace(name))
+		}
+		return nil
+	}
+}
+
+// WithIAMProfileResolution reads each instance's instance profile from its
+// current IAM association rather than from DescribeInstances
+func WithIAMProfileResolution() ContainerOption {
+	return func(c *Container) error {
+		c.resolveIAM = true
+		return nil
+	}
+}
+
+// WithRequireFullAccess fails reading instances from EC2 when the
+// credentials may not read all of their settings, such as the volumes
+// without ec2:DescribeVolumes, rather than leaving those settings uncompared
+func WithRequireFullAccess() ContainerOption {
+	return func(c *Container) error {
+		c.requireFullAccess = true
+		return nil
+	}
+}
+
+// WithDataSourceResolution looks up the data "aws_ami" blocks of Terraform
+// configuration in AWS when the state next to the configuration does not
+// record them
+func WithDataSourceResolution() ContainerOption {
+	return func(c *Container) error {
+		c.hclOpts = append(c.hclOpts, terraform.WithAMIResolver(&lazyAMIResolver{c: c}))
+		return nil
+	}
+}
+
+// WithMaxAttempts sets how many times each EC2 call is attempted before a
+// throttling or transient error is returned
+func WithMaxAttempts(n int) ContainerOption {
+	return func(c *Container) error {
+		if n < 1 {
+			return fmt.Errorf("max attempts must be at least 1, got %d", n)
+		}
+		c.maxAttempts = n
+		return nil
+	}
+}
+
+// WithInstanceRepository reads instances from repo instead of EC2
+func WithInstanceRepository(repo repositories.InstanceRepository) ContainerOption {
+	return func(c *Container) error {
+		if repo == nil {
+			return fmt.Errorf("instance repository cannot be nil")
+		}
+		c.instanceRepo = repo
+		return nil
+	}
+}
+
 // WithAWSFactory allows setting a custom AWS client factory
 func WithAWSFactory(factory awsrepo.ClientFactory) ContainerOption {
 	return func(c *Container) error {
@@ -60,12 +253,77 @@ func WithTerraformParser(parser terraform.StateParser) ContainerOption {
 	}
 }
 
-// NewContainer creates a new application container with all dependencies
+// WithPlanRunner allows setting a custom Terraform plan runner
+func WithPlanRunner(runner terraform.PlanRunner) ContainerOption {
+	return func(c *Container) error {
+		if runner == nil {
+			return fmt.Errorf("Terraform plan runner cannot be nil")
+		}
+		c.planRunner = runner
+		return nil
+	}
+}
+
+// WithDetectorOptions configures the drift detector used by the detection service
+func WithDetectorOptions(opts ...detectionsvc.DetectorOption) ContainerOption {
+	return func(c *Container) error {
+		c.detectorOpts = append(c.detectorOpts, opts...)
+		return nil
+	}
+}
+
+// WithDetectionService uses svc to compare instances, e.g. a detector
+// configured through pkg/driftdetector. Options given with
+// WithDetectorOptions are then not used.
+func WithDetectionService(svc detectionsvc.DetectionService) ContainerOption {
+	return func(c *Container) error {
+		if svc == nil {
+			return fmt.Errorf("detection service cannot be nil")
+		}
+		c.detectionSvc = svc
+		return nil
+	}
+}
+
+// WithInstanceCache caches the instances read by the instance repository in
+// dir, serving them from there for ttl instead of describing them again.
+// refresh reads every instance anew, still caching it.
+func WithInstanceCache(dir string, ttl time.Duration, refresh bool) ContainerOption {
+	return func(c *Container) error {
+		if dir == "" {
+			return fmt.Errorf("cache directory cannot be empty")
+		}
+		if ttl <= 0 {
+			return fmt.Errorf("cache TTL must be positive, got %s", ttl)
+		}
+		c.cacheDir = dir
+		c.cacheOpts = []cache.InstanceRepositoryOption{cache.WithTTL(ttl)}
+		if refresh {
+			c.cacheOpts = append(c.cacheOpts, cache.WithRefresh())
+		}
+		return nil
+	}
+}
+
+// ResolveAWSConfig loads the AWS config for region and profile and returns an
+// option that applies it, failing with a descriptive error when no region can
+// be resolved from the flag, environment or shared config
+func ResolveAWSConfig(ctx context.Context, region, profile string) (ContainerOption, error) {
+	cfg, err := awsrepo.NewConfigResolver(region, profile).Resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return WithAWSConfig(cfg), nil
+}
+
+// NewContainer creates a new application container with all dependencies.
+// AWS config and clients are only loaded once an AWS-backed repository is
+// used, so constructing a container never requires AWS credentials.
 func NewContainer(ctx context.Context, opts ...ContainerOption) (*Container, error) {
 	// Create container with default values
 	container := &Container{
 		awsFactory: awsrepo.NewClientFactory(),
-		tfParser:   &terraform.StateFileParser{},
+		planRunner: terraform.NewCLIPlanRunner("terraform"),
 	}
 
 	// Apply options
@@ -75,24 +333,41 @@ func NewContainer(ctx context.Context, opts ...ContainerOption) (*Container, err
 		}
 	}
 
-	// Initialize AWS config if not provided
-	if container.awsConfig.Region == "" {
-		cfg, err := config.LoadDefaultConfig(ctx)
-		if err != nil {
-			return nil, fmt.Errorf("loading AWS config: %w", err)
+	// Remote state is read through S3 once a state location needs it, and
+	// piped state from stdin
+	if container.tfParser == nil {
+		var parserOpts []terraform.StateFileParserOption
+		if container.strictState {
+			parserOpts = append(parserOpts, terraform.WithStrictState())
 		}
-		container.awsConfig = cfg
+		container.tfParser = terraform.NewStateFileParser(terraform.NewStateReader(&lazyS3Client{c: container}, terraform.WithStdin(container.stdin)), parserOpts...)
 	}
 
-	// Initialize AWS clients
-	ec2Client := container.awsFactory.NewEC2Client(container.awsConfig)
-
 	// Initialize repositories
-	container.instanceRepo = awsrepo.NewEC2Repository(ec2Client)
-	container.tfRepo = tfrepo.NewTerraformRepository(container.tfParser)
+	if container.instanceRepo == nil {
+		container.instanceRepo = &lazyInstanceRepository{c: container}
+	}
+	if container.cacheDir != "" {
+		cached, err := cache.NewInstanceRepository(container.instanceRepo, container.cacheDir, container.cacheOpts...)
+		if err != nil {
+			return nil, err
+		}
+		container.instanceRepo = cached
+	}
+	container.sgRepo = &lazySecurityGroupRepository{c: container}
+	container.imageRepo = &lazyImageRepository{c: container}
+	container.subnetRepo = &lazySubnetRepository{c: container}
+	container.eipRepo = &lazyElasticIPRepository{c: container}
+	container.tfRepo = tfrepo.NewTerraformRepository(container.tfParser, container.hclOpts...)
 
 	// Initialize services
-	container.detectionSvc = detectionsvc.NewDetectionService()
+	if container.detectionSvc == nil {
+		detectionSvc, err := detectionsvc.NewDetectionServiceWithOptions(container.detectorOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("configuring drift detector: %w", err)
+		}
+		container.detectionSvc = detectionSvc
+	}
 
 	return container, nil
 }
@@ -102,17 +377,54 @@ func (c *Container) GetInstanceRepository() repositories.InstanceRepository {
 	return c.instanceRepo
 }
 
+// GetReportUploader returns the S3 client drift reports are uploaded with.
+// It uses the AWS config of the tool, not the role assumed for EC2 or the
+// region of remote state.
+func (c *Container) GetReportUploader() awsutil.S3PutObjectAPI {
+	return &lazyS3Uploader{c: c}
+}
+
 // GetTerraformRepository returns the Terraform state repository
 func (c *Container) GetTerraformRepository() repositories.TerraformStateRepository {
 	return c.tfRepo
 }
 
+// GetSecurityGroupRepository returns the security group repository
+func (c *Container) GetSecurityGroupRepository() repositories.SecurityGroupRepository {
+	return c.sgRepo
+}
+
+// GetImageRepository returns the AMI repository, which caches every image it
+// reads for the life of the container
+func (c *Container) GetImageRepository() repositories.ImageRepository {
+	return c.imageRepo
+}
+
+// GetSubnetRepository returns the subnet repository, which caches every
+// subnet it reads for the life of the container
+func (c *Container) GetSubnetRepository() repositories.SubnetRepository {
+	return c.subnetRepo
+}
+
+// GetElasticIPRepository returns the Elastic IP repository, which describes
+// the addresses of the region once for the life of the container
+func (c *Container) GetElasticIPRepository() repositories.ElasticIPRepository {
+	return c.eipRepo
+}
+
 // GetDetectionService returns the detection service
 func (c *Container) GetDetectionService() detectionsvc.DetectionService {
 	return c.detectionSvc
 }
 
-// GetAWSConfig returns the AWS config
+// GetPlanRunner returns the Terraform plan runner
+func (c *Container) GetPlanRunner() terraform.PlanRunner {
+	return c.planRunner
+}
+
+// GetAWSConfig returns the AWS config, loading it if no AWS-backed operation
+// has yet. A config that fails to load is returned empty.
 func (c *Container) GetAWSConfig() aws.Config {
+	_ = c.initAWS(context.Background())
 	return c.awsConfig
 }
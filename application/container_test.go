@@ -2,12 +2,20 @@ package application_test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"driftdetector/application"
 	"driftdetector/domain/models"
@@ -18,6 +26,8 @@ import (
 // MockAWSFactory is a test implementation of the AWS ClientFactory interface
 type MockAWSFactory struct {
 	NewEC2ClientFunc func(cfg aws.Config) awsrepo.EC2API
+	NewS3ClientFunc  func(cfg aws.Config) awsrepo.S3API
+	NewSTSClientFunc func(cfg aws.Config) awsrepo.STSAPI
 }
 
 func (m *MockAWSFactory) NewEC2Client(cfg aws.Config) awsrepo.EC2API {
@@ -28,6 +38,20 @@ func (m *MockAWSFactory) NewEC2Client(cfg aws.Config) awsrepo.EC2API {
 	return &MockEC2API{}
 }
 
+func (m *MockAWSFactory) NewS3Client(cfg aws.Config) awsrepo.S3API {
+	if m.NewS3ClientFunc != nil {
+		return m.NewS3ClientFunc(cfg)
+	}
+	return nil
+}
+
+func (m *MockAWSFactory) NewSTSClient(cfg aws.Config) awsrepo.STSAPI {
+	if m.NewSTSClientFunc != nil {
+		return m.NewSTSClientFunc(cfg)
+	}
+	return nil
+}
+
 // MockTerraformParser is a test implementation of the StateParser interface
 type MockTerraformParser struct {
 	ParseStateFunc func(ctx context.Context, path string) (*models.TerraformState, error)
@@ -42,10 +66,12 @@ func (m *MockTerraformParser) ParseState(ctx context.Context, path string) (*mod
 
 // MockEC2API is a test implementation of the EC2API interface
 type MockEC2API struct {
-	FindAllFunc          func(ctx context.Context) ([]*models.Instance, error)
-	GetByIDFunc          func(ctx context.Context, id string) (*models.Instance, error)
-	DescribeInstancesFunc func(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
-	DescribeVolumesFunc   func(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error)
+	FindAllFunc                   func(ctx context.Context) ([]*models.Instance, error)
+	GetByIDFunc                   func(ctx context.Context, id string) (*models.Instance, error)
+	DescribeInstancesFunc         func(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+	DescribeVolumesFunc           func(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error)
+	DescribeSecurityGroupsFunc    func(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error)
+	DescribeInstanceAttributeFunc func(ctx context.Context, params *ec2.DescribeInstanceAttributeInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceAttributeOutput, error)
 }
 
 // Implement the EC2API interface methods
@@ -69,6 +95,54 @@ func (m *MockEC2API) DescribeVolumes(ctx context.Context, params *ec2.DescribeVo
 	}, nil
 }
 
+func (m *MockEC2API) DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+	if m.DescribeSecurityGroupsFunc != nil {
+		return m.DescribeSecurityGroupsFunc(ctx, params, optFns...)
+	}
+	// Return empty result by default
+	return &ec2.DescribeSecurityGroupsOutput{
+		SecurityGroups: []types.SecurityGroup{},
+	}, nil
+}
+
+func (m *MockEC2API) DescribeInstanceAttribute(ctx context.Context, params *ec2.DescribeInstanceAttributeInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceAttributeOutput, error) {
+	if m.DescribeInstanceAttributeFunc != nil {
+		return m.DescribeInstanceAttributeFunc(ctx, params, optFns...)
+	}
+	// Return empty result by default
+	return &ec2.DescribeInstanceAttributeOutput{}, nil
+}
+
+func (m *MockEC2API) DescribeLaunchTemplateVersions(ctx context.Context, params *ec2.DescribeLaunchTemplateVersionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeLaunchTemplateVersionsOutput, error) {
+	// Return empty result by default
+	return &ec2.DescribeLaunchTemplateVersionsOutput{}, nil
+}
+
+func (m *MockEC2API) DescribeIamInstanceProfileAssociations(ctx context.Context, params *ec2.DescribeIamInstanceProfileAssociationsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeIamInstanceProfileAssociationsOutput, error) {
+	// Return empty result by default
+	return &ec2.DescribeIamInstanceProfileAssociationsOutput{}, nil
+}
+
+func (m *MockEC2API) DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+	// Return empty result by default
+	return &ec2.DescribeImagesOutput{}, nil
+}
+
+func (m *MockEC2API) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	// Return empty result by default
+	return &ec2.DescribeSubnetsOutput{}, nil
+}
+
+func (m *MockEC2API) DescribeAddresses(ctx context.Context, params *ec2.DescribeAddressesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAddressesOutput, error) {
+	// Return empty result by default
+	return &ec2.DescribeAddressesOutput{}, nil
+}
+
+func (m *MockEC2API) DescribeSpotInstanceRequests(ctx context.Context, params *ec2.DescribeSpotInstanceRequestsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSpotInstanceRequestsOutput, error) {
+	// Return empty result by default
+	return &ec2.DescribeSpotInstanceRequestsOutput{}, nil
+}
+
 // Helper methods for testing
 func (m *MockEC2API) FindAll(ctx context.Context) ([]*models.Instance, error) {
 	if m.FindAllFunc != nil {
@@ -147,6 +221,33 @@ func TestNewContainer(t *testing.T) {
 		assert.NotNil(t, container, "Should return a container")
 	})
 
+	t.Run("region option takes precedence over the environment", func(t *testing.T) {
+		// Given
+		t.Setenv("AWS_REGION", "us-east-1")
+
+		// When
+		container, err := application.NewContainer(ctx,
+			application.WithRegion("eu-west-1"),
+		)
+
+		// Then
+		assert.NoError(t, err, "Should not return an error")
+		assert.Equal(t, "eu-west-1", container.GetAWSConfig().Region, "Region option should be used")
+	})
+
+	t.Run("AWS_DEFAULT_REGION is used as a fallback", func(t *testing.T) {
+		// Given
+		t.Setenv("AWS_REGION", "")
+		t.Setenv("AWS_DEFAULT_REGION", "ap-southeast-2")
+
+		// When
+		container, err := application.NewContainer(ctx)
+
+		// Then
+		assert.NoError(t, err, "Should not return an error")
+		assert.Equal(t, "ap-southeast-2", container.GetAWSConfig().Region, "AWS_DEFAULT_REGION should be used")
+	})
+
 	t.Run("successful creation with custom AWS factory", func(t *testing.T) {
 		// Given
 		mockEC2 := &MockEC2API{
@@ -181,6 +282,54 @@ func TestNewContainer(t *testing.T) {
 		assert.NotNil(t, container, "Should return a container")
 	})
 
+	t.Run("state region overrides the region for remote state", func(t *testing.T) {
+		// Given
+		var s3Region string
+		factory := &MockAWSFactory{
+			NewS3ClientFunc: func(cfg aws.Config) awsrepo.S3API {
+				s3Region = cfg.Region
+				return nil
+			},
+		}
+
+		// When remote state is read
+		container, err := application.NewContainer(ctx,
+			application.WithAWSConfig(aws.Config{Region: "us-east-1"}),
+			application.WithAWSFactory(factory),
+			application.WithStateRegion("eu-central-1"),
+		)
+		assert.NoError(t, err, "Should not return an error")
+		_, _ = container.GetTerraformRepository().GetInstanceConfigs(ctx, "s3://bucket/terraform.tfstate")
+
+		// Then
+		assert.Equal(t, "eu-central-1", s3Region, "S3 client should use the state region")
+		assert.Equal(t, "us-east-1", container.GetAWSConfig().Region, "Instances should still use the AWS region")
+	})
+
+	t.Run("reports are uploaded in the AWS region, not the state region", func(t *testing.T) {
+		// Given
+		var regions []string
+		factory := &MockAWSFactory{
+			NewS3ClientFunc: func(cfg aws.Config) awsrepo.S3API {
+				regions = append(regions, cfg.Region)
+				return nil
+			},
+		}
+
+		// When a report is uploaded
+		container, err := application.NewContainer(ctx,
+			application.WithAWSConfig(aws.Config{Region: "us-east-1"}),
+			application.WithAWSFactory(factory),
+			application.WithStateRegion("eu-central-1"),
+		)
+		assert.NoError(t, err, "Should not return an error")
+		_, err = container.GetReportUploader().PutObject(ctx, &s3.PutObjectInput{})
+
+		// Then
+		assert.Error(t, err, "Should fail without a configured S3 client")
+		assert.Equal(t, []string{"us-east-1", "eu-central-1"}, regions)
+	})
+
 	t.Run("successful creation with custom Terraform parser", func(t *testing.T) {
 		// Given
 		parser := &MockTerraformParser{}
@@ -194,6 +343,247 @@ func TestNewContainer(t *testing.T) {
 		assert.NoError(t, err, "Should not return an error")
 		assert.NotNil(t, container, "Should return a container")
 	})
+
+	t.Run("detection service replaces detector options", func(t *testing.T) {
+		// Given
+		svc := services.NewDetectionService()
+
+		// When
+		container, err := application.NewContainer(ctx,
+			application.WithDetectorOptions(services.WithIgnoredPaths("Tags[")),
+			application.WithDetectionService(svc),
+		)
+
+		// Then
+		assert.NoError(t, err, "Invalid detector options should not be used")
+		assert.Same(t, svc, container.GetDetectionService(), "Should use the given service")
+	})
+}
+
+func TestNewContainer_LazyAWS(t *testing.T) {
+	ctx := context.Background()
+
+	// A profile missing from an empty shared config cannot be loaded
+	emptyConfig := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(emptyConfig, nil, 0644))
+	t.Setenv("AWS_CONFIG_FILE", emptyConfig)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", emptyConfig)
+
+	state := filepath.Join(t.TempDir(), "terraform.tfstate")
+	require.NoError(t, os.WriteFile(state, []byte(`{"version": 4, "resources": []}`), 0644))
+
+	t.Run("AWS is only loaded by AWS-backed operations", func(t *testing.T) {
+		// Given
+		created := 0
+		factory := &MockAWSFactory{
+			NewEC2ClientFunc: func(cfg aws.Config) awsrepo.EC2API {
+				created++
+				return &MockEC2API{}
+			},
+		}
+
+		// When
+		container, err := application.NewContainer(ctx,
+			application.WithProfile("missing"),
+			application.WithAWSFactory(factory),
+		)
+
+		// Then local state can be read without AWS
+		require.NoError(t, err, "Construction should not load AWS config")
+		_, err = container.GetTerraformRepository().GetInstanceConfigs(ctx, state)
+		assert.NoError(t, err, "Local state should not need AWS")
+		assert.Zero(t, created, "No EC2 client should be created")
+
+		// And the config error surfaces on the first AWS call
+		_, err = container.GetInstanceRepository().GetByID(ctx, "i-1234567890abcdef0")
+		assert.ErrorContains(t, err, "failed to load AWS config")
+		assert.Zero(t, created)
+	})
+
+	t.Run("AWS clients are created once", func(t *testing.T) {
+		// Given
+		created := 0
+		factory := &MockAWSFactory{
+			NewEC2ClientFunc: func(cfg aws.Config) awsrepo.EC2API {
+				created++
+				return &MockEC2API{}
+			},
+		}
+		container, err := application.NewContainer(ctx,
+			application.WithAWSConfig(aws.Config{Region: "us-east-1"}),
+			application.WithAWSFactory(factory),
+		)
+		require.NoError(t, err)
+
+		// When
+		_, _ = container.GetInstanceRepository().GetByID(ctx, "i-1")
+		_, _ = container.GetSecurityGroupRepository().GetByIDs(ctx, []string{"sg-1"})
+
+		// Then
+		assert.Equal(t, 1, created)
+	})
+
+	t.Run("WithoutAWS", func(t *testing.T) {
+		// Given
+		factory := &MockAWSFactory{
+			NewEC2ClientFunc: func(cfg aws.Config) awsrepo.EC2API {
+				t.Fatal("no EC2 client should be created")
+				return nil
+			},
+		}
+		container, err := application.NewContainer(ctx, application.WithoutAWS(), application.WithAWSFactory(factory))
+		require.NoError(t, err)
+
+		// When
+		_, localErr := container.GetTerraformRepository().GetInstanceConfigs(ctx, state)
+		_, awsErr := container.GetInstanceRepository().GetByID(ctx, "i-1")
+		_, s3Err := container.GetTerraformRepository().GetInstanceConfigs(ctx, "s3://bucket/terraform.tfstate")
+
+		// Then
+		assert.NoError(t, localErr)
+		assert.ErrorIs(t, awsErr, application.ErrAWSDisabled)
+		assert.ErrorIs(t, s3Err, application.ErrAWSDisabled)
+	})
+}
+
+// MockSTSAPI records the roles assumed through it
+type MockSTSAPI struct {
+	Roles []string
+}
+
+func (m *MockSTSAPI) AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	m.Roles = append(m.Roles, aws.ToString(params.RoleArn))
+	return &sts.AssumeRoleOutput{Credentials: &ststypes.Credentials{
+		AccessKeyId:     aws.String("ASIAASSUMED"),
+		SecretAccessKey: aws.String("secret"),
+		SessionToken:    aws.String("token"),
+		Expiration:      aws.Time(time.Now().Add(time.Hour)),
+	}}, nil
+}
+
+func TestNewContainer_InstanceCache(t *testing.T) {
+	ctx := context.Background()
+
+	// Given an EC2 API counting DescribeInstances calls
+	described := 0
+	factory := &MockAWSFactory{
+		NewEC2ClientFunc: func(cfg aws.Config) awsrepo.EC2API {
+			return &MockEC2API{
+				DescribeInstancesFunc: func(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+					described++
+					return &ec2.DescribeInstancesOutput{
+						Reservations: []types.Reservation{{
+							Instances: []types.Instance{{
+								InstanceId:   aws.String("i-1"),
+								InstanceType: types.InstanceTypeT3Micro,
+							}},
+						}},
+					}, nil
+				},
+			}
+		},
+	}
+	dir := t.TempDir()
+	newContainer := func(refresh bool) *application.Container {
+		container, err := application.NewContainer(ctx,
+			application.WithAWSConfig(aws.Config{Region: "us-east-1"}),
+			application.WithAWSFactory(factory),
+			application.WithInstanceCache(dir, time.Minute, refresh),
+		)
+		require.NoError(t, err)
+		return container
+	}
+
+	// When two runs read the same instance
+	first, err := newContainer(false).GetInstanceRepository().GetByID(ctx, "i-1")
+	require.NoError(t, err)
+	second, err := newContainer(false).GetInstanceRepository().GetByID(ctx, "i-1")
+	require.NoError(t, err)
+
+	// Then the second run reads it from the cache
+	assert.Equal(t, 1, described)
+	assert.Equal(t, first.Type, second.Type)
+	assert.FileExists(t, filepath.Join(dir, "i-1.json"))
+
+	// And refresh reads it again
+	_, err = newContainer(true).GetInstanceRepository().GetByID(ctx, "i-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, described)
+
+	_, err = application.NewContainer(ctx, application.WithInstanceCache(dir, 0, false))
+	assert.ErrorContains(t, err, "cache TTL must be positive")
+}
+
+func TestNewContainer_AssumeRole(t *testing.T) {
+	ctx := context.Background()
+	base := aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKIATOOLING", "secret", ""),
+	}
+
+	t.Run("EC2 is called with the assumed role", func(t *testing.T) {
+		// Given
+		stsClient := &MockSTSAPI{}
+		var stsConfig, ec2Config, s3Config aws.Config
+		factory := &MockAWSFactory{
+			NewSTSClientFunc: func(cfg aws.Config) awsrepo.STSAPI {
+				stsConfig = cfg
+				return stsClient
+			},
+			NewEC2ClientFunc: func(cfg aws.Config) awsrepo.EC2API {
+				ec2Config = cfg
+				return &MockEC2API{}
+			},
+			NewS3ClientFunc: func(cfg aws.Config) awsrepo.S3API {
+				s3Config = cfg
+				return nil
+			},
+		}
+		container, err := application.NewContainer(ctx,
+			application.WithAWSConfig(base),
+			application.WithAssumeRole("arn:aws:iam::111111111111:role/drift-reader", "ext-1"),
+			application.WithAWSFactory(factory),
+		)
+		require.NoError(t, err)
+
+		// When
+		_, _ = container.GetInstanceRepository().GetByID(ctx, "i-1")
+
+		// Then STS is called with the tooling credentials
+		stsCreds, err := stsConfig.Credentials.Retrieve(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "AKIATOOLING", stsCreds.AccessKeyID)
+
+		// And EC2 with the assumed role's
+		ec2Creds, err := ec2Config.Credentials.Retrieve(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "ASIAASSUMED", ec2Creds.AccessKeyID)
+		assert.Equal(t, []string{"arn:aws:iam::111111111111:role/drift-reader"}, stsClient.Roles)
+
+		// And remote state with the tooling credentials
+		s3Creds, err := s3Config.Credentials.Retrieve(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, "AKIATOOLING", s3Creds.AccessKeyID)
+	})
+
+	t.Run("without a role no STS client is created", func(t *testing.T) {
+		factory := &MockAWSFactory{
+			NewSTSClientFunc: func(cfg aws.Config) awsrepo.STSAPI {
+				t.Fatal("no STS client should be created")
+				return nil
+			},
+		}
+		container, err := application.NewContainer(ctx, application.WithAWSConfig(base), application.WithAWSFactory(factory))
+		require.NoError(t, err)
+
+		_, _ = container.GetInstanceRepository().GetByID(ctx, "i-1")
+	})
+
+	t.Run("invalid role ARN", func(t *testing.T) {
+		_, err := application.NewContainer(ctx, application.WithAssumeRole("drift-reader", ""))
+
+		assert.ErrorContains(t, err, "invalid role ARN")
+	})
 }
 
 func TestContainer_Getters(t *testing.T) {
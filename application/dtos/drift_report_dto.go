@@ -11,6 +11,7 @@ type DriftDTO struct {
 	Actual      interface{} `json:"actual,omitempty"`
 	Expected    interface{} `json:"expected,omitempty"`
 	Description string      `json:"description"`
+	PlanStatus  string      `json:"plan_status,omitempty"`
 }
 
 // DriftReportDTO represents a drift report in the application layer
@@ -34,6 +35,7 @@ func NewDriftReportDTO(report *models.DriftReport) *DriftReportDTO {
 			Actual:      d.Actual,
 			Expected:    d.Expected,
 			Description: d.Description,
+			PlanStatus:  string(d.PlanStatus),
 		}
 	}
 
@@ -0,0 +1,121 @@
+package application
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/terraform"
+)
+
+// ReferencedFile describes an external input whose contents affect a run
+type ReferencedFile struct {
+	Role    string
+	Path    string
+	Entries int
+}
+
+// DetectOptions holds the resolved detector options for a run
+type DetectOptions struct {
+	IgnoredPaths []string
+	OnlyPaths    []string
+	Flags        map[string]bool
+	Files        []ReferencedFile
+}
+
+// ResolveEffectiveConfig captures the resolved options into a serializable
+// EffectiveConfig, hashing every referenced file so the inputs can be audited later
+func ResolveEffectiveConfig(opts DetectOptions) (*models.EffectiveConfig, error) {
+	cfg := &models.EffectiveConfig{
+		IgnoredPaths: append([]string{}, opts.IgnoredPaths...),
+		Flags:        make(map[string]bool, len(opts.Flags)),
+	}
+	sort.Strings(cfg.IgnoredPaths)
+	if len(opts.OnlyPaths) > 0 {
+		cfg.OnlyPaths = append([]string{}, opts.OnlyPaths...)
+		sort.Strings(cfg.OnlyPaths)
+	}
+
+	for name, val := range opts.Flags {
+		cfg.Flags[name] = val
+	}
+
+	for _, f := range opts.Files {
+		if f.Path == "" {
+			continue
+		}
+
+		// Remote and piped state are recorded by location only
+		var hash string
+		if !terraform.IsRemoteState(f.Path) && f.Path != terraform.StdinState {
+			var err error
+			hash, err = hashFile(f.Path)
+			if err != nil {
+				return nil, fmt.Errorf("hashing %s file: %w", f.Role, err)
+			}
+		}
+
+		cfg.Files = append(cfg.Files, models.FileReference{
+			Role:    f.Role,
+			Path:    f.Path,
+			SHA256:  hash,
+			Entries: f.Entries,
+		})
+	}
+
+	sort.Slice(cfg.Files, func(i, j int) bool {
+		return cfg.Files[i].Role < cfg.Files[j].Role
+	})
+
+	return cfg, nil
+}
+
+// hashFile returns the hex-encoded SHA-256 digest of a file, or of every
+// regular file beneath it when path is a directory
+func hashFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	if !info.IsDir() {
+		if err := copyFileInto(h, path); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "", err
+	}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		fmt.Fprintf(h, "%s\x00", e.Name())
+		if err := copyFileInto(h, filepath.Join(path, e.Name())); err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// copyFileInto streams a file's contents into w
+func copyFileInto(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
@@ -0,0 +1,91 @@
+package application_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/application"
+)
+
+func TestResolveEffectiveConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	stateFile := filepath.Join(tempDir, "terraform.tfstate")
+	require.NoError(t, os.WriteFile(stateFile, []byte(`{"version": 4}`), 0644))
+
+	resolve := func(ignored ...string) ([]byte, string) {
+		cfg, err := application.ResolveEffectiveConfig(application.DetectOptions{
+			IgnoredPaths: ignored,
+			Flags:        map[string]bool{"verify_plan": false},
+			Files:        []application.ReferencedFile{{Role: "state_file", Path: stateFile, Entries: 1}},
+		})
+		require.NoError(t, err)
+
+		data, err := json.Marshal(cfg)
+		require.NoError(t, err)
+		return data, cfg.Summary()
+	}
+
+	t.Run("identical runs produce identical blocks", func(t *testing.T) {
+		first, firstSummary := resolve("Tags.LastModified", "PublicIPAddress")
+		second, secondSummary := resolve("PublicIPAddress", "Tags.LastModified")
+
+		assert.JSONEq(t, string(first), string(second))
+		assert.Equal(t, firstSummary, secondSummary)
+	})
+
+	t.Run("different ignore rules produce different blocks", func(t *testing.T) {
+		first, firstSummary := resolve("PublicIPAddress")
+		second, secondSummary := resolve("PrivateIPAddress")
+
+		assert.NotEqual(t, string(first), string(second))
+		assert.NotEqual(t, firstSummary, secondSummary)
+		assert.Contains(t, firstSummary, "ignore=[PublicIPAddress]")
+	})
+
+	t.Run("selected paths are recorded when set", func(t *testing.T) {
+		cfg, err := application.ResolveEffectiveConfig(application.DetectOptions{OnlyPaths: []string{"Type", "Tags"}})
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"Tags", "Type"}, cfg.OnlyPaths)
+		assert.Contains(t, cfg.Summary(), "only=[Tags,Type]")
+
+		_, summary := resolve("PublicIPAddress")
+		assert.NotContains(t, summary, "only=")
+	})
+
+	t.Run("file contents are hashed", func(t *testing.T) {
+		before, _ := resolve()
+		require.NoError(t, os.WriteFile(stateFile, []byte(`{"version": 4, "serial": 2}`), 0644))
+		after, _ := resolve()
+
+		assert.NotEqual(t, string(before), string(after))
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := application.ResolveEffectiveConfig(application.DetectOptions{
+			Files: []application.ReferencedFile{{Role: "baseline", Path: filepath.Join(tempDir, "missing.yaml")}},
+		})
+
+		assert.Error(t, err)
+	})
+
+	t.Run("remote and piped state are recorded by location", func(t *testing.T) {
+		cfg, err := application.ResolveEffectiveConfig(application.DetectOptions{
+			Files: []application.ReferencedFile{
+				{Role: "state_file", Path: "-"},
+				{Role: "tf_plan", Path: "s3://bucket/plan.json"},
+			},
+		})
+
+		require.NoError(t, err)
+		require.Len(t, cfg.Files, 2)
+		for _, file := range cfg.Files {
+			assert.Empty(t, file.SHA256, file.Path)
+		}
+	})
+}
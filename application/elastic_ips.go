@@ -0,0 +1,49 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/domain/services"
+)
+
+// LoadElasticIPConfigs reads aws_eip and aws_eip_association resources from
+// a state file, if the Terraform repository supports it. It returns nil when
+// there is nothing to read.
+func LoadElasticIPConfigs(ctx context.Context, tfRepo repositories.TerraformStateRepository, stateFile string) ([]*models.ElasticIP, error) {
+	eipRepo, ok := tfRepo.(repositories.ElasticIPStateRepository)
+	if !ok || stateFile == "" {
+		return nil, nil
+	}
+
+	eips, err := eipRepo.GetElasticIPConfigs(ctx, stateFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading Elastic IPs from Terraform state: %w", err)
+	}
+	return eips, nil
+}
+
+// ApplyElasticIPDrift adds drift in the Elastic IPs associated with the
+// instance of report. AWS is only queried when the state declares at least
+// one Elastic IP, so addresses are not reported for configurations that
+// leave them to another tool.
+func ApplyElasticIPDrift(
+	ctx context.Context,
+	svc services.DetectionService,
+	repo repositories.ElasticIPRepository,
+	report *models.DriftReport,
+	desired []*models.ElasticIP,
+) error {
+	if len(desired) == 0 {
+		return nil
+	}
+
+	current, err := repo.FindAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch Elastic IPs from AWS: %w", err)
+	}
+
+	return svc.DetectElasticIPDrift(ctx, report, current, desired)
+}
@@ -0,0 +1,49 @@
+package application_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/application"
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+// fakeElasticIPRepo returns fixed addresses and counts the calls
+type fakeElasticIPRepo struct {
+	eips  []*models.ElasticIP
+	calls int
+}
+
+func (r *fakeElasticIPRepo) FindAll(ctx context.Context) ([]*models.ElasticIP, error) {
+	r.calls++
+	return r.eips, nil
+}
+
+func TestApplyElasticIPDrift(t *testing.T) {
+	t.Run("detached addresses are reported", func(t *testing.T) {
+		repo := &fakeElasticIPRepo{eips: []*models.ElasticIP{{AllocationID: "eipalloc-web", PublicIP: "203.0.113.10"}}}
+		desired := []*models.ElasticIP{{AllocationID: "eipalloc-web", PublicIP: "203.0.113.10", InstanceID: "i-1", ResourceAddress: "aws_eip.web"}}
+		report := models.NewDriftReport("i-1")
+
+		err := application.ApplyElasticIPDrift(context.Background(), services.NewDetectionService(), repo, report, desired)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, repo.calls)
+		require.Len(t, report.Drifts, 1)
+		assert.Equal(t, "ElasticIPs[eipalloc-web]", report.Drifts[0].Path)
+		assert.Equal(t, models.DriftTypeAdded, report.Drifts[0].Type)
+	})
+
+	t.Run("no AWS call without declared addresses", func(t *testing.T) {
+		repo := &fakeElasticIPRepo{}
+
+		err := application.ApplyElasticIPDrift(context.Background(), services.NewDetectionService(), repo, models.NewDriftReport("i-1"), nil)
+
+		require.NoError(t, err)
+		assert.Zero(t, repo.calls)
+	})
+}
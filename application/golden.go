@@ -0,0 +1,23 @@
+package application
+
+import (
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+// ApplyGoldenTemplates compares the instance against the golden template whose
+// selector matches it, in addition to the Terraform comparison already in the
+// report. Golden mismatches are advisory, so they leave HasDrift unchanged. It
+// returns the template that was applied, or nil if none matched.
+func ApplyGoldenTemplates(report *models.DriftReport, actual *models.Instance, templates []*models.GoldenTemplate) *models.GoldenTemplate {
+	for _, template := range templates {
+		if !template.Selector.Matches(actual) {
+			continue
+		}
+		for _, drift := range services.CompareGolden(actual, template) {
+			report.AddAdvisory(drift)
+		}
+		return template
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package application_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"driftdetector/application"
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestApplyGoldenTemplates(t *testing.T) {
+	templates := []*models.GoldenTemplate{
+		{
+			Name:     "web",
+			Selector: models.GoldenSelector{Tags: map[string]string{"Role": "web"}},
+			Config:   &models.Instance{Type: "t3.micro"},
+			Fields:   []string{"instance_type"},
+		},
+		{
+			Name:     "batch",
+			Selector: models.GoldenSelector{Tags: map[string]string{"Role": "batch"}},
+			Config:   &models.Instance{Type: "c5.xlarge"},
+			Fields:   []string{"instance_type"},
+		},
+	}
+
+	actual := models.NewInstance("i-123", "c5.large", "ami-123")
+	actual.AddTag("Role", "batch")
+	desired := models.NewInstance("i-123", "c5.xlarge", "ami-123")
+	desired.AddTag("Role", "batch")
+
+	report := services.NewDriftDetector().CompareInstances(actual, desired)
+	terraformDrifts := len(report.Drifts)
+
+	applied := application.ApplyGoldenTemplates(report, actual, templates)
+
+	assert.Equal(t, "batch", applied.Name)
+	assert.Len(t, report.GoldenMismatches(), 1)
+	assert.Len(t, report.Drifts, terraformDrifts+1, "Golden findings are added alongside Terraform drift")
+	assert.Equal(t, "golden.batch.instance_type", report.GoldenMismatches()[0].Path)
+
+	t.Run("golden mismatches alone are not drift", func(t *testing.T) {
+		// Given an instance matching Terraform but not its golden template
+		report := services.NewDriftDetector().CompareInstances(actual, actual)
+
+		// When the template is applied
+		application.ApplyGoldenTemplates(report, actual, templates)
+
+		// Then the mismatch is reported without the report having drifted
+		assert.Len(t, report.GoldenMismatches(), 1)
+		assert.False(t, report.HasDrifts())
+	})
+
+	t.Run("no matching template", func(t *testing.T) {
+		other := models.NewInstance("i-456", "t3.micro", "ami-123")
+		report := models.NewDriftReport(other.ID)
+
+		assert.Nil(t, application.ApplyGoldenTemplates(report, other, templates))
+		assert.False(t, report.HasDrifts())
+	})
+}
@@ -0,0 +1,84 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Outbox delivers writes, such as saved reports or notifications, in the
+// background and in the order they were sent, so a slow destination does not
+// hold up checks. Flush waits for what is still pending, e.g. on shutdown.
+type Outbox struct {
+	mu      sync.Mutex
+	pending []func(ctx context.Context) error
+	idle    chan struct{}
+	onError func(err error)
+}
+
+// NewOutbox creates an Outbox calling onError, when not nil, with every
+// delivery that fails
+func NewOutbox(onError func(err error)) *Outbox {
+	idle := make(chan struct{})
+	close(idle)
+	return &Outbox{idle: idle, onError: onError}
+}
+
+// Send queues deliver to run after the writes sent before it
+func (o *Outbox) Send(deliver func(ctx context.Context) error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.pending = append(o.pending, deliver)
+	if o.isIdle() {
+		o.idle = make(chan struct{})
+		go o.run()
+	}
+}
+
+// Flush waits until every write sent so far has been delivered, or fails
+// naming how many were not once ctx is done
+func (o *Outbox) Flush(ctx context.Context) error {
+	o.mu.Lock()
+	idle := o.idle
+	o.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		o.mu.Lock()
+		defer o.mu.Unlock()
+		return fmt.Errorf("%d pending write(s) not delivered: %w", len(o.pending), ctx.Err())
+	}
+}
+
+// run delivers pending writes until none are left
+func (o *Outbox) run() {
+	for {
+		o.mu.Lock()
+		if len(o.pending) == 0 {
+			close(o.idle)
+			o.mu.Unlock()
+			return
+		}
+		deliver := o.pending[0]
+		o.pending = o.pending[1:]
+		o.mu.Unlock()
+
+		// Writes are delivered even while the process shuts down
+		if err := deliver(context.Background()); err != nil && o.onError != nil {
+			o.onError(err)
+		}
+	}
+}
+
+// isIdle reports whether no delivery is running; o.mu must be held
+func (o *Outbox) isIdle() bool {
+	select {
+	case <-o.idle:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,62 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/application"
+)
+
+func TestOutbox(t *testing.T) {
+	t.Run("flush waits for pending writes, delivered in order", func(t *testing.T) {
+		// Given writes held up by a slow destination
+		var mu sync.Mutex
+		var delivered []int
+		release := make(chan struct{})
+		var failures []error
+		outbox := application.NewOutbox(func(err error) { failures = append(failures, err) })
+		for i := 1; i <= 3; i++ {
+			outbox.Send(func(ctx context.Context) error {
+				<-release
+				mu.Lock()
+				defer mu.Unlock()
+				delivered = append(delivered, i)
+				if i == 2 {
+					return errors.New("bucket unavailable")
+				}
+				return nil
+			})
+		}
+
+		// When the destination recovers while the outbox is flushed
+		close(release)
+		require.NoError(t, outbox.Flush(context.Background()))
+
+		// Then every write was delivered and the failure reported
+		assert.Equal(t, []int{1, 2, 3}, delivered)
+		assert.Len(t, failures, 1)
+	})
+
+	t.Run("flush gives up when its context ends", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+		outbox := application.NewOutbox(nil)
+		outbox.Send(func(ctx context.Context) error { <-release; return nil })
+		outbox.Send(func(ctx context.Context) error { return nil })
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		assert.ErrorContains(t, outbox.Flush(ctx), "1 pending write(s) not delivered")
+	})
+
+	t.Run("an empty outbox flushes at once", func(t *testing.T) {
+		assert.NoError(t, application.NewOutbox(nil).Flush(context.Background()))
+	})
+}
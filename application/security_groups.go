@@ -0,0 +1,61 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/domain/services"
+)
+
+// LoadSecurityGroupConfigs reads aws_security_group resources from a state file,
+// if the Terraform repository supports it. It returns nil when there is nothing to read.
+func LoadSecurityGroupConfigs(ctx context.Context, tfRepo repositories.TerraformStateRepository, stateFile string) ([]*models.SecurityGroupConfig, error) {
+	sgRepo, ok := tfRepo.(repositories.SecurityGroupStateRepository)
+	if !ok || stateFile == "" {
+		return nil, nil
+	}
+
+	groups, err := sgRepo.GetSecurityGroupConfigs(ctx, stateFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading security groups from Terraform state: %w", err)
+	}
+	return groups, nil
+}
+
+// ApplySecurityGroupDrift adds rule-level drift for the Terraform-managed
+// security groups attached to the instance. AWS is only queried when at
+// least one attached group is declared in Terraform.
+func ApplySecurityGroupDrift(
+	ctx context.Context,
+	svc services.DetectionService,
+	repo repositories.SecurityGroupRepository,
+	report *models.DriftReport,
+	actual *models.Instance,
+	desired []*models.SecurityGroupConfig,
+) error {
+	attached := make(map[string]bool, len(actual.SecurityGroups))
+	for _, sg := range actual.SecurityGroups {
+		attached[sg.GroupID] = true
+	}
+
+	var managed []*models.SecurityGroupConfig
+	var ids []string
+	for _, group := range desired {
+		if attached[group.GroupID] {
+			managed = append(managed, group)
+			ids = append(ids, group.GroupID)
+		}
+	}
+	if len(managed) == 0 {
+		return nil
+	}
+
+	current, err := repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to fetch security groups from AWS: %w", err)
+	}
+
+	return svc.DetectSecurityGroupDrift(ctx, report, current, managed)
+}
@@ -0,0 +1,63 @@
+package application_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/application"
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+// fakeSecurityGroupRepo returns fixed groups and records the requested IDs
+type fakeSecurityGroupRepo struct {
+	groups    []*models.SecurityGroupConfig
+	requested []string
+}
+
+func (r *fakeSecurityGroupRepo) GetByIDs(ctx context.Context, ids []string) ([]*models.SecurityGroupConfig, error) {
+	r.requested = append(r.requested, ids...)
+	return r.groups, nil
+}
+
+func TestApplySecurityGroupDrift(t *testing.T) {
+	desired := []*models.SecurityGroupConfig{
+		{GroupID: "sg-web", Ingress: []models.SecurityGroupRule{
+			{Protocol: "tcp", FromPort: 443, ToPort: 443, CIDRBlocks: []string{"0.0.0.0/0"}},
+		}},
+		{GroupID: "sg-other"},
+	}
+
+	t.Run("attached managed groups are compared", func(t *testing.T) {
+		repo := &fakeSecurityGroupRepo{groups: []*models.SecurityGroupConfig{
+			{GroupID: "sg-web", Ingress: []models.SecurityGroupRule{
+				{Protocol: "tcp", FromPort: 443, ToPort: 443, CIDRBlocks: []string{"0.0.0.0/0"}},
+				{Protocol: "tcp", FromPort: 22, ToPort: 22, CIDRBlocks: []string{"0.0.0.0/0"}},
+			}},
+		}}
+		actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+		actual.SecurityGroups = []models.SecurityGroup{{GroupID: "sg-web"}, {GroupID: "sg-unmanaged"}}
+		report := models.NewDriftReport("i-1")
+
+		err := application.ApplySecurityGroupDrift(context.Background(), services.NewDetectionService(), repo, report, actual, desired)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"sg-web"}, repo.requested)
+		require.Len(t, report.Drifts, 1)
+		assert.Equal(t, "SecurityGroups[sg-web].Ingress[tcp/22]", report.Drifts[0].Path)
+	})
+
+	t.Run("no AWS call without managed groups", func(t *testing.T) {
+		repo := &fakeSecurityGroupRepo{}
+		actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+		actual.SecurityGroups = []models.SecurityGroup{{GroupID: "sg-unmanaged"}}
+
+		err := application.ApplySecurityGroupDrift(context.Background(), services.NewDetectionService(), repo, models.NewDriftReport("i-1"), actual, desired)
+
+		require.NoError(t, err)
+		assert.Empty(t, repo.requested)
+	})
+}
@@ -0,0 +1,35 @@
+package application
+
+import (
+	"fmt"
+	"os"
+
+	appcommands "driftdetector/application/commands"
+	"driftdetector/domain/repositories"
+	"driftdetector/infrastructure/mock"
+	"driftdetector/infrastructure/terraform"
+)
+
+// NewFileSource returns a Source for the file at path, which may hold a
+// Terraform state or an instance written by the snapshot command. The two
+// are told apart by content; s3:// locations and stdin are always read as
+// state.
+func NewFileSource(tfRepo repositories.TerraformStateRepository, path string) (appcommands.Source, error) {
+	if terraform.IsRemoteState(path) || path == terraform.StdinState {
+		return appcommands.NewTerraformSource(tfRepo, path, "", ""), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if terraform.IsStateDocument(data) {
+		return appcommands.NewTerraformSource(tfRepo, path, "", ""), nil
+	}
+
+	config, err := mock.LoadInstanceConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return appcommands.NewStaticSource(path, config.ToInstance()), nil
+}
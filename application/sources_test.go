@@ -0,0 +1,53 @@
+package application_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/application"
+)
+
+func TestNewFileSource(t *testing.T) {
+	ctx := context.Background()
+	container, err := application.NewContainer(ctx, application.WithoutAWS())
+	require.NoError(t, err)
+	tfRepo := container.GetTerraformRepository()
+
+	t.Run("state is read as Terraform state", func(t *testing.T) {
+		source, err := application.NewFileSource(tfRepo, "../testdata/terraform/state/raw_state.tfstate")
+		require.NoError(t, err)
+
+		instances, err := source.Instances(ctx)
+		require.NoError(t, err)
+		require.NotEmpty(t, instances)
+		assert.Equal(t, "aws_instance.bastion", instances[0].ResourceAddress)
+	})
+
+	t.Run("snapshot is read as an instance", func(t *testing.T) {
+		snapshot := filepath.Join(t.TempDir(), "i-1.json")
+		require.NoError(t, os.WriteFile(snapshot, []byte(`{"instance_id": "i-1", "instance_type": "t3.micro"}`), 0o600))
+
+		source, err := application.NewFileSource(tfRepo, snapshot)
+		require.NoError(t, err)
+
+		instances, err := source.Instances(ctx)
+		require.NoError(t, err)
+		require.Len(t, instances, 1)
+		assert.Equal(t, "i-1", instances[0].ID)
+		assert.Equal(t, "t3.micro", instances[0].Type)
+		assert.Equal(t, snapshot, source.String())
+	})
+
+	t.Run("invalid snapshot", func(t *testing.T) {
+		snapshot := filepath.Join(t.TempDir(), "bad.json")
+		require.NoError(t, os.WriteFile(snapshot, []byte(`{"instance_typ": "t3.micro"}`), 0o600))
+
+		_, err := application.NewFileSource(tfRepo, snapshot)
+		assert.ErrorContains(t, err, `unknown field "instance_typ"`)
+	})
+}
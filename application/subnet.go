@@ -0,0 +1,30 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/domain/services"
+)
+
+// ApplySubnetResolution checks that the actual subnet of an instance with an
+// availability zone finding is in the expected zone, reporting a subnet in
+// another zone as one finding instead of separate zone and subnet findings.
+// AWS is only queried when the report has an availability zone finding and
+// desired sets both the zone and the subnet.
+func ApplySubnetResolution(ctx context.Context, repo repositories.SubnetRepository, report *models.DriftReport, actual, desired *models.Instance) error {
+	subnetID, ok := services.DriftedSubnet(report, actual, desired)
+	if !ok {
+		return nil
+	}
+
+	subnets, err := repo.GetByIDs(ctx, []string{subnetID})
+	if err != nil {
+		return fmt.Errorf("failed to fetch subnets from AWS: %w", err)
+	}
+
+	services.ResolveSubnetDrift(report, actual, desired, subnets)
+	return nil
+}
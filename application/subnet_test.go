@@ -0,0 +1,60 @@
+package application_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/application"
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+// fakeSubnetRepo returns fixed subnets and records the requested IDs
+type fakeSubnetRepo struct {
+	subnets   []*models.Subnet
+	requested []string
+}
+
+func (r *fakeSubnetRepo) GetByIDs(ctx context.Context, ids []string) ([]*models.Subnet, error) {
+	r.requested = append(r.requested, ids...)
+	return r.subnets, nil
+}
+
+func TestApplySubnetResolution(t *testing.T) {
+	placed := func(az, subnet string) *models.Instance {
+		instance := models.NewInstance("i-1", "t3.micro", "ami-1")
+		instance.AvailabilityZone = az
+		instance.SubnetID = subnet
+		return instance
+	}
+
+	t.Run("subnet in another zone is one finding", func(t *testing.T) {
+		repo := &fakeSubnetRepo{subnets: []*models.Subnet{{ID: "subnet-abc", AvailabilityZone: "us-east-1b"}}}
+		actual, desired := placed("us-east-1b", "subnet-abc"), placed("us-east-1a", "subnet-def")
+		report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+		err := application.ApplySubnetResolution(context.Background(), repo, report, actual, desired)
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"subnet-abc"}, repo.requested)
+		require.Len(t, report.Drifts, 1)
+		assert.Equal(t, "SubnetID", report.Drifts[0].Path)
+		assert.Equal(t, "subnet subnet-abc is in us-east-1b, expected us-east-1a", report.Drifts[0].Description)
+	})
+
+	t.Run("AWS is not queried without an availability zone finding", func(t *testing.T) {
+		repo := &fakeSubnetRepo{}
+		actual, desired := placed("us-east-1a", "subnet-abc"), placed("us-east-1a", "subnet-def")
+		report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+		err := application.ApplySubnetResolution(context.Background(), repo, report, actual, desired)
+
+		require.NoError(t, err)
+		assert.Empty(t, repo.requested)
+		require.Len(t, report.Drifts, 1)
+		assert.Equal(t, "SubnetID", report.Drifts[0].Path)
+	})
+}
@@ -0,0 +1,232 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"driftdetector/domain/models"
+)
+
+const (
+	// defaultWatchInterval is the time between checks when none is configured
+	defaultWatchInterval = 5 * time.Minute
+	// defaultWatchMaxBackoff caps the wait after repeated failed checks
+	defaultWatchMaxBackoff = 30 * time.Minute
+)
+
+// WatchClock abstracts time so the watch loop can be tested without waiting
+type WatchClock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemWatchClock is the default WatchClock backed by the time package
+type systemWatchClock struct{}
+
+// Now returns the current wall-clock time
+func (systemWatchClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for the duration to elapse and then sends the current time
+func (systemWatchClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// DetectFunc runs one drift check
+type DetectFunc func(ctx context.Context) (*models.DriftReport, error)
+
+// WorkTracker registers checks in flight, so that a shutdown can wait for
+// them. Begin fails once no new work is accepted.
+type WorkTracker interface {
+	Begin() (func(), error)
+}
+
+// Watcher repeatedly runs a drift check and reports when its outcome changes
+type Watcher struct {
+	detect     DetectFunc
+	clock      WatchClock
+	interval   time.Duration
+	maxBackoff time.Duration
+	onChange   func(report *models.DriftReport)
+	onReport   func(report *models.DriftReport, at time.Time) error
+	onError    func(err error, retryIn time.Duration)
+	onCheck    func(at time.Time)
+	tracker    WorkTracker
+}
+
+// WatchOption configures a Watcher
+type WatchOption func(*Watcher)
+
+// WithWatchInterval sets the time between checks
+func WithWatchInterval(interval time.Duration) WatchOption {
+	return func(w *Watcher) {
+		w.interval = interval
+	}
+}
+
+// WithWatchMaxBackoff caps the wait between checks after repeated failures
+func WithWatchMaxBackoff(max time.Duration) WatchOption {
+	return func(w *Watcher) {
+		w.maxBackoff = max
+	}
+}
+
+// WithWatchClock sets the clock used to schedule checks
+func WithWatchClock(clock WatchClock) WatchOption {
+	return func(w *Watcher) {
+		w.clock = clock
+	}
+}
+
+// OnDriftChange is called with the first report and with every report whose
+// set of findings differs from the previous successful check
+func OnDriftChange(fn func(report *models.DriftReport)) WatchOption {
+	return func(w *Watcher) {
+		w.onChange = fn
+	}
+}
+
+// OnWatchReport is called with every successful report and the time it was
+// produced. An error from fn stops the watch.
+func OnWatchReport(fn func(report *models.DriftReport, at time.Time) error) WatchOption {
+	return func(w *Watcher) {
+		w.onReport = fn
+	}
+}
+
+// OnWatchError is called when a check fails, with the time until the next attempt
+func OnWatchError(fn func(err error, retryIn time.Duration)) WatchOption {
+	return func(w *Watcher) {
+		w.onError = fn
+	}
+}
+
+// OnWatchCheck is called after every completed check, whether it succeeded
+// or failed, e.g. to show a liveness probe that the loop is not stuck
+func OnWatchCheck(fn func(at time.Time)) WatchOption {
+	return func(w *Watcher) {
+		w.onCheck = fn
+	}
+}
+
+// WithWatchTracker registers every check with tracker. A check in flight when
+// the watch is cancelled then runs to completion, reports included, and the
+// watch ends once tracker accepts no more work.
+func WithWatchTracker(tracker WorkTracker) WatchOption {
+	return func(w *Watcher) {
+		w.tracker = tracker
+	}
+}
+
+// NewWatcher creates a Watcher that runs detect on every interval
+func NewWatcher(detect DetectFunc, opts ...WatchOption) *Watcher {
+	w := &Watcher{
+		detect:     detect,
+		clock:      systemWatchClock{},
+		interval:   defaultWatchInterval,
+		maxBackoff: defaultWatchMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w
+}
+
+// Run checks for drift immediately and then after every interval until ctx is
+// cancelled, which ends the watch without an error. After consecutive failed
+// checks the wait doubles, up to the maximum backoff.
+func (w *Watcher) Run(ctx context.Context) error {
+	if w.interval <= 0 {
+		return fmt.Errorf("watch interval must be positive, got %s", w.interval)
+	}
+
+	var last string
+	seen := false
+	failures := 0
+
+	for {
+		wait := w.interval
+
+		checkCtx, done := ctx, func() {}
+		if w.tracker != nil {
+			var err error
+			if done, err = w.tracker.Begin(); err != nil {
+				return nil
+			}
+			checkCtx = context.WithoutCancel(ctx)
+		}
+
+		report, err := w.detect(checkCtx)
+		switch {
+		case checkCtx.Err() != nil:
+			done()
+			return nil
+		case err != nil:
+			failures++
+			wait = w.backoff(failures)
+			if w.onError != nil {
+				w.onError(err, wait)
+			}
+		default:
+			failures = 0
+			key := driftSetKey(report)
+			if (!seen || key != last) && w.onChange != nil {
+				w.onChange(report)
+			}
+			last, seen = key, true
+
+			if w.onReport != nil {
+				if err := w.onReport(report, w.clock.Now()); err != nil {
+					done()
+					return err
+				}
+			}
+		}
+		if w.onCheck != nil {
+			w.onCheck(w.clock.Now())
+		}
+		done()
+
+		// A watch cancelled during the check ends without waiting
+		if ctx.Err() != nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-w.clock.After(wait):
+		}
+	}
+}
+
+// backoff returns the wait after the given number of consecutive failures
+func (w *Watcher) backoff(failures int) time.Duration {
+	max := w.maxBackoff
+	if max < w.interval {
+		max = w.interval
+	}
+
+	wait := w.interval
+	for i := 1; i < failures && wait < max; i++ {
+		wait *= 2
+	}
+	if wait > max {
+		wait = max
+	}
+	return wait
+}
+
+// driftSetKey identifies the set of findings in a report, independently of
+// their order, so consecutive reports can be compared
+func driftSetKey(report *models.DriftReport) string {
+	entries := make([]string, 0, len(report.Drifts))
+	for _, d := range report.Drifts {
+		entries = append(entries, fmt.Sprintf("%s|%s|%v|%v", d.Type, d.Path, d.Actual, d.Expected))
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, "\n")
+}
@@ -0,0 +1,222 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/application"
+	"driftdetector/domain/models"
+)
+
+// fakeWatchClock fires every wait immediately and records its duration
+type fakeWatchClock struct {
+	now   time.Time
+	waits []time.Duration
+}
+
+func (c *fakeWatchClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeWatchClock) After(d time.Duration) <-chan time.Time {
+	c.waits = append(c.waits, d)
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+// scriptedDetect returns one scripted outcome per call and cancels the watch
+// once the script is exhausted
+func scriptedDetect(cancel context.CancelFunc, outcomes ...func() (*models.DriftReport, error)) application.DetectFunc {
+	calls := 0
+	return func(ctx context.Context) (*models.DriftReport, error) {
+		if calls >= len(outcomes) {
+			cancel()
+			return nil, ctx.Err()
+		}
+		outcome := outcomes[calls]
+		calls++
+		return outcome()
+	}
+}
+
+func reportWith(paths ...string) func() (*models.DriftReport, error) {
+	return func() (*models.DriftReport, error) {
+		report := models.NewDriftReport("i-1")
+		for _, p := range paths {
+			report.AddDrift(models.NewDrift(models.DriftTypeModified, p, "a", "b", "Value mismatch"))
+		}
+		return report, nil
+	}
+}
+
+func failure() (*models.DriftReport, error) {
+	return nil, errors.New("throttled")
+}
+
+func TestWatcher_Run(t *testing.T) {
+	t.Run("reports only changes in the drift set", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		clock := &fakeWatchClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+		var changes [][]string
+		var reported []time.Time
+		watcher := application.NewWatcher(
+			scriptedDetect(cancel,
+				reportWith(),
+				reportWith(),
+				reportWith("Type"),
+				reportWith("Type"),
+				reportWith("Type", "AMI"),
+			),
+			application.WithWatchInterval(time.Minute),
+			application.WithWatchClock(clock),
+			application.OnDriftChange(func(report *models.DriftReport) {
+				var paths []string
+				for _, d := range report.Drifts {
+					paths = append(paths, d.Path)
+				}
+				changes = append(changes, paths)
+			}),
+			application.OnWatchReport(func(report *models.DriftReport, at time.Time) error {
+				reported = append(reported, at)
+				return nil
+			}),
+		)
+
+		err := watcher.Run(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, [][]string{nil, {"Type"}, {"Type", "AMI"}}, changes)
+		assert.Len(t, reported, 5)
+		assert.Equal(t, time.Minute, reported[1].Sub(reported[0]))
+	})
+
+	t.Run("backs off on repeated errors", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		clock := &fakeWatchClock{}
+
+		errCount := 0
+		watcher := application.NewWatcher(
+			scriptedDetect(cancel, failure, failure, failure, failure, reportWith(), failure),
+			application.WithWatchInterval(time.Minute),
+			application.WithWatchMaxBackoff(5*time.Minute),
+			application.WithWatchClock(clock),
+			application.OnWatchError(func(err error, retryIn time.Duration) {
+				errCount++
+			}),
+		)
+
+		err := watcher.Run(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, 5, errCount)
+		assert.Equal(t, []time.Duration{
+			time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute,
+			time.Minute, time.Minute,
+		}, clock.waits)
+	})
+
+	t.Run("report errors stop the watch", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		watcher := application.NewWatcher(
+			scriptedDetect(cancel, reportWith()),
+			application.WithWatchClock(&fakeWatchClock{}),
+			application.OnWatchReport(func(report *models.DriftReport, at time.Time) error {
+				return errors.New("disk full")
+			}),
+		)
+
+		assert.EqualError(t, watcher.Run(ctx), "disk full")
+	})
+
+	t.Run("every completed check is signalled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		checks := 0
+		watcher := application.NewWatcher(
+			scriptedDetect(cancel, reportWith(), failure, reportWith("Type")),
+			application.WithWatchClock(&fakeWatchClock{}),
+			application.OnWatchCheck(func(at time.Time) { checks++ }),
+		)
+
+		require.NoError(t, watcher.Run(ctx))
+		assert.Equal(t, 3, checks)
+	})
+
+	t.Run("a tracked check in flight finishes when the watch is cancelled", func(t *testing.T) {
+		// Given a check that is running when the watch is cancelled
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		tracker := &fakeTracker{}
+		var reported []*models.DriftReport
+		watcher := application.NewWatcher(
+			func(checkCtx context.Context) (*models.DriftReport, error) {
+				cancel()
+				// The check is not cancelled with the watch
+				require.NoError(t, checkCtx.Err())
+				return reportWith("Type")()
+			},
+			application.WithWatchClock(&fakeWatchClock{}),
+			application.WithWatchTracker(tracker),
+			application.OnWatchReport(func(report *models.DriftReport, at time.Time) error {
+				reported = append(reported, report)
+				return nil
+			}),
+		)
+
+		// When the watch runs
+		require.NoError(t, watcher.Run(ctx))
+
+		// Then the report is delivered and the check completed
+		assert.Len(t, reported, 1)
+		assert.Equal(t, 1, tracker.begun)
+		assert.Equal(t, 1, tracker.done)
+	})
+
+	t.Run("the watch ends once the tracker accepts no work", func(t *testing.T) {
+		checks := 0
+		watcher := application.NewWatcher(
+			func(context.Context) (*models.DriftReport, error) {
+				checks++
+				return reportWith()()
+			},
+			application.WithWatchTracker(&fakeTracker{draining: true}),
+		)
+
+		require.NoError(t, watcher.Run(context.Background()))
+		assert.Zero(t, checks)
+	})
+
+	t.Run("rejects a non-positive interval", func(t *testing.T) {
+		watcher := application.NewWatcher(scriptedDetect(func() {}), application.WithWatchInterval(0))
+
+		assert.Error(t, watcher.Run(context.Background()))
+	})
+}
+
+// fakeTracker counts the checks it registers
+type fakeTracker struct {
+	draining bool
+	begun    int
+	done     int
+}
+
+func (t *fakeTracker) Begin() (func(), error) {
+	if t.draining {
+		return nil, errors.New("shutting down")
+	}
+	t.begun++
+	return func() { t.done++ }, nil
+}
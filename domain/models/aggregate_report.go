@@ -0,0 +1,28 @@
+package models
+
+// AggregateReport summarizes drift detection across several instances
+type AggregateReport struct {
+    TotalInstances int            `json:"total_instances"`
+    Drifted        int            `json:"drifted"`
+    Errors         int            `json:"errors"`
+    // Failures describes the instances that could not be checked
+    Failures       []string       `json:"failures,omitempty"`
+    Reports        []*DriftReport `json:"reports"`
+}
+
+// NewAggregateReport summarizes reports, the instances that were checked,
+// and failures, one message per instance that could not be checked
+func NewAggregateReport(reports []*DriftReport, failures []string) *AggregateReport {
+    aggregate := &AggregateReport{
+        TotalInstances: len(reports) + len(failures),
+        Errors:         len(failures),
+        Failures:       failures,
+        Reports:        reports,
+    }
+    for _, report := range reports {
+        if report.HasDrifts() {
+            aggregate.Drifted++
+        }
+    }
+    return aggregate
+}
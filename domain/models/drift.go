@@ -1,5 +1,10 @@
 package models
 
+import (
+    "fmt"
+    "time"
+)
+
 // DriftType represents the type of drift detected
 type DriftType string
 
@@ -10,6 +15,31 @@ const (
     DriftTypeRemoved DriftType = "REMOVED"
     // DriftTypeModified indicates a field exists in both but with different values
     DriftTypeModified DriftType = "MODIFIED"
+    // DriftTypePrerequisiteViolation indicates a declared capability whose prerequisites are not met
+    DriftTypePrerequisiteViolation DriftType = "PREREQUISITE_VIOLATION"
+    // DriftTypePolicyViolation indicates a finding raised by a user-supplied policy
+    DriftTypePolicyViolation DriftType = "POLICY_VIOLATION"
+    // DriftTypeGoldenMismatch indicates an instance differs from its golden template
+    DriftTypeGoldenMismatch DriftType = "GOLDEN_MISMATCH"
+    // DriftTypeStaleAMI indicates an instance runs an AMI that is too old or deprecated
+    DriftTypeStaleAMI DriftType = "STALE_AMI"
+)
+
+// Advisory reports whether findings of type t are kept apart from Terraform
+// drift: they are listed with the others but do not make a report drifted,
+// and fail a run only with their own flag
+func (t DriftType) Advisory() bool {
+    return t == DriftTypeStaleAMI || t == DriftTypeGoldenMismatch
+}
+
+// PlanStatus records whether a Terraform plan would reconcile a drift finding
+type PlanStatus string
+
+const (
+    // PlanStatusFixedByApply indicates the plan changes the drifted attribute
+    PlanStatusFixedByApply PlanStatus = "will be fixed by apply"
+    // PlanStatusNotAddressed indicates the plan leaves the drifted attribute untouched
+    PlanStatusNotAddressed PlanStatus = "not addressed by Terraform"
 )
 
 // Drift represents a single drift finding in our domain
@@ -20,15 +50,46 @@ type Drift struct {
     Actual      interface{} `json:"actual,omitempty"`
     Expected    interface{} `json:"expected,omitempty"`
     Description string      `json:"description"`
+    Severity    Severity    `json:"severity,omitempty"`
+    PlanStatus  PlanStatus  `json:"plan_status,omitempty"`
+    Policy      *PolicyReference `json:"policy,omitempty"`
+    // Source is where the drifted argument, or else the resource, is
+    // declared in Terraform configuration, when it was read from
+    // configuration files
+    Source      *SourceLocation  `json:"source,omitempty"`
+    // Remediation suggests how to reconcile the finding, e.g. the AWS CLI
+    // command restoring the expected security groups
+    Remediation string           `json:"remediation,omitempty"`
 }
 
-// NewDrift creates a new Drift value object
+// PolicyReference identifies the policy rule that produced a finding
+type PolicyReference struct {
+    File string `json:"file"`
+    Rule string `json:"rule"`
+}
+
+// SourceLocation identifies a line of a Terraform configuration file
+type SourceLocation struct {
+    File string `json:"file"`
+    Line int    `json:"line,omitempty"`
+}
+
+// String returns the location as file:line, e.g. main.tf:42
+func (l *SourceLocation) String() string {
+    if l.Line == 0 {
+        return l.File
+    }
+    return fmt.Sprintf("%s:%d", l.File, l.Line)
+}
+
+// NewDrift creates a new Drift value object. Actual and expected values are
+// stored as plain data, see PlainValue.
 func NewDrift(driftType DriftType, path string, actual, expected interface{}, description string) Drift {
     return Drift{
         Type:        driftType,
         Path:        path,
-        Actual:      actual,
-        Expected:    expected,
+        Actual:      PlainValue(actual),
+        Expected:    PlainValue(expected),
         Description: description,
     }
 }
@@ -39,6 +100,22 @@ type DriftReport struct {
     InstanceID string  `json:"instance_id"`
     HasDrift   bool    `json:"has_drift"`
     Drifts     []Drift `json:"drifts"`
+    Metadata   *ReportMetadata `json:"metadata,omitempty"`
+    
+    // Warnings note comparisons that may be less precise than usual
+    Warnings []string `json:"warnings,omitempty"`
+    
+    // SuppressedByLifecycle counts the findings left out because the
+    // resource's lifecycle ignore_changes lists their fields
+    SuppressedByLifecycle int `json:"suppressed_by_lifecycle,omitempty"`
+    
+    // Acknowledged holds the findings left out of Drifts because a baseline
+    // of accepted drift lists them with the same value
+    Acknowledged []Drift `json:"acknowledged,omitempty"`
+    
+    // CheckedAt is when the instance was checked; it is set on reports kept
+    // as history, such as those saved by watch --report-dir
+    CheckedAt time.Time `json:"checked_at,omitzero"`
 }
 
 // NewDriftReport creates a new DriftReport
@@ -55,6 +132,27 @@ func (r *DriftReport) AddDrift(drift Drift) {
     r.HasDrift = true
 }
 
+// AddAdvisory adds an advisory finding, leaving HasDrift unchanged
+func (r *DriftReport) AddAdvisory(drift Drift) {
+    r.Drifts = append(r.Drifts, drift)
+}
+
+// ContainsDrift reports whether drifts holds a finding that is not advisory,
+// i.e. whether a report with those findings has drifted
+func ContainsDrift(drifts []Drift) bool {
+    for _, d := range drifts {
+        if !d.Type.Advisory() {
+            return true
+        }
+    }
+    return false
+}
+
+// AddWarning records a note about how the comparison was made
+func (r *DriftReport) AddWarning(warning string) {
+    r.Warnings = append(r.Warnings, warning)
+}
+
 // GetDrifts returns all drifts in the report
 func (r *DriftReport) GetDrifts() []Drift {
     return r.Drifts
@@ -64,3 +162,37 @@ func (r *DriftReport) GetDrifts() []Drift {
 func (r *DriftReport) HasDrifts() bool {
     return r.HasDrift
 }
+
+// UnaddressedDrifts returns the drifts that a verified plan does not reconcile
+func (r *DriftReport) UnaddressedDrifts() []Drift {
+    var drifts []Drift
+    for _, d := range r.Drifts {
+        // Advisory findings are not Terraform drift for a plan to fix
+        if d.PlanStatus != PlanStatusFixedByApply && !d.Type.Advisory() {
+            drifts = append(drifts, d)
+        }
+    }
+    return drifts
+}
+
+// GoldenMismatches returns the findings produced by golden template comparison
+func (r *DriftReport) GoldenMismatches() []Drift {
+    var drifts []Drift
+    for _, d := range r.Drifts {
+        if d.Type == DriftTypeGoldenMismatch {
+            drifts = append(drifts, d)
+        }
+    }
+    return drifts
+}
+
+// StaleAMIs returns the findings about an AMI that is too old or deprecated
+func (r *DriftReport) StaleAMIs() []Drift {
+    var drifts []Drift
+    for _, d := range r.Drifts {
+        if d.Type == DriftTypeStaleAMI {
+            drifts = append(drifts, d)
+        }
+    }
+    return drifts
+}
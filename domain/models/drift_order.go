@@ -0,0 +1,75 @@
+package models
+
+import (
+    "sort"
+    "strings"
+)
+
+// SortDrifts orders the findings by path, then by drift type, so reports of
+// identical inputs are identical. Paths are compared naturally: runs of
+// digits by value, so Tags[A] < Tags[B] and Disks[2] < Disks[10].
+func (r *DriftReport) SortDrifts() {
+    sort.SliceStable(r.Drifts, func(i, j int) bool {
+        a, b := r.Drifts[i], r.Drifts[j]
+        if c := ComparePaths(a.Path, b.Path); c != 0 {
+            return c < 0
+        }
+        return a.Type < b.Type
+    })
+}
+
+// ComparePaths compares two drift paths naturally, returning -1, 0 or +1.
+// Paths that only differ in leading zeros are ordered by their text.
+func ComparePaths(a, b string) int {
+    x, y := a, b
+    for x != "" && y != "" {
+        xRun, xDigits := leadingRun(x)
+        yRun, yDigits := leadingRun(y)
+
+        var c int
+        if xDigits && yDigits {
+            c = compareNumbers(xRun, yRun)
+        } else {
+            c = strings.Compare(xRun, yRun)
+        }
+        if c != 0 {
+            return c
+        }
+        x, y = x[len(xRun):], y[len(yRun):]
+    }
+
+    switch {
+    case x == "" && y != "":
+        return -1
+    case x != "" && y == "":
+        return 1
+    }
+    return strings.Compare(a, b)
+}
+
+// leadingRun returns the leading run of digits or of other characters of s
+func leadingRun(s string) (string, bool) {
+    digits := isDigit(s[0])
+    end := 1
+    for end < len(s) && isDigit(s[end]) == digits {
+        end++
+    }
+    return s[:end], digits
+}
+
+// compareNumbers compares two runs of digits by value
+func compareNumbers(a, b string) int {
+    a = strings.TrimLeft(a, "0")
+    b = strings.TrimLeft(b, "0")
+    if len(a) != len(b) {
+        if len(a) < len(b) {
+            return -1
+        }
+        return 1
+    }
+    return strings.Compare(a, b)
+}
+
+func isDigit(c byte) bool {
+    return c >= '0' && c <= '9'
+}
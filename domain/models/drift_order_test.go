@@ -0,0 +1,46 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"driftdetector/domain/models"
+)
+
+func TestComparePaths(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"Tags[A]", "Tags[B]", -1},
+		{"Disks[2]", "Disks[10]", -1},
+		{"Disks[10].Size", "Disks[9].Size", 1},
+		{"AMI", "AMI", 0},
+		{"Tags", "Tags.Name", -1},
+		{"Disks[02]", "Disks[2]", -1},
+		{"NetworkInterfaces[1].Groups[sg-2]", "NetworkInterfaces[1].Groups[sg-10]", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			assert.Equal(t, tt.want, models.ComparePaths(tt.a, tt.b))
+			assert.Equal(t, -tt.want, models.ComparePaths(tt.b, tt.a))
+		})
+	}
+}
+
+func TestDriftReport_SortDrifts(t *testing.T) {
+	report := models.NewDriftReport("i-1")
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, "Disks[10]", nil, nil, ""))
+	report.AddDrift(models.NewDrift(models.DriftTypeRemoved, "AMI", nil, nil, ""))
+	report.AddDrift(models.NewDrift(models.DriftTypeAdded, "Disks[2]", nil, nil, ""))
+	report.AddDrift(models.NewDrift(models.DriftTypeAdded, "AMI", nil, nil, ""))
+
+	report.SortDrifts()
+
+	var got []string
+	for _, d := range report.Drifts {
+		got = append(got, d.Path+" "+string(d.Type))
+	}
+	assert.Equal(t, []string{"AMI ADDED", "AMI REMOVED", "Disks[2] ADDED", "Disks[10] MODIFIED"}, got)
+}
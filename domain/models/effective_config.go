@@ -0,0 +1,67 @@
+package models
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+)
+
+// ReportMetadata carries information about how a report was produced
+type ReportMetadata struct {
+    EffectiveConfig *EffectiveConfig `json:"effective_config,omitempty"`
+}
+
+// EffectiveConfig captures the detector options that were in effect for a run
+// after all configuration sources have been merged
+type EffectiveConfig struct {
+    // IgnoredPaths are drift paths excluded from comparison
+    IgnoredPaths []string `json:"ignored_paths"`
+
+    // OnlyPaths, when set, are the drift paths comparison was restricted to
+    OnlyPaths []string `json:"only_paths,omitempty"`
+
+    // Flags holds boolean detector switches keyed by option name
+    Flags map[string]bool `json:"flags,omitempty"`
+
+    // Files references the external inputs the run depended on
+    Files []FileReference `json:"files,omitempty"`
+}
+
+// FileReference identifies an external file by content hash
+type FileReference struct {
+    Role    string `json:"role"`
+    Path    string `json:"path"`
+    SHA256  string `json:"sha256"`
+    Entries int    `json:"entries,omitempty"`
+}
+
+// Summary renders the effective configuration as a single compact line
+func (c *EffectiveConfig) Summary() string {
+    if c == nil {
+        return ""
+    }
+
+    parts := []string{fmt.Sprintf("ignore=[%s]", strings.Join(c.IgnoredPaths, ","))}
+    if len(c.OnlyPaths) > 0 {
+        parts = append(parts, fmt.Sprintf("only=[%s]", strings.Join(c.OnlyPaths, ",")))
+    }
+
+    flagNames := make([]string, 0, len(c.Flags))
+    for name := range c.Flags {
+        flagNames = append(flagNames, name)
+    }
+    sort.Strings(flagNames)
+    for _, name := range flagNames {
+        parts = append(parts, fmt.Sprintf("%s=%t", name, c.Flags[name]))
+    }
+
+    for _, f := range c.Files {
+        hash := f.SHA256
+        if len(hash) > 12 {
+            hash = hash[:12]
+        }
+        parts = append(parts, fmt.Sprintf("%s=%s", f.Role, hash))
+    }
+
+    return strings.Join(parts, " ")
+}
@@ -0,0 +1,13 @@
+package models
+
+// ElasticIP describes an Elastic IP address and the instance it is
+// associated with
+type ElasticIP struct {
+    AllocationID string `json:"allocation_id"`
+    PublicIP     string `json:"public_ip,omitempty"`
+    // InstanceID is empty when the address is not associated with an instance
+    InstanceID string `json:"instance_id,omitempty"`
+    // ResourceAddress is the Terraform resource declaring the association,
+    // or the address when no aws_eip_association does
+    ResourceAddress string `json:"resource_address,omitempty"`
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+    "path"
+    "sort"
+    "strings"
+)
+
+// GoldenTemplate describes a shape that every instance matching its selector
+// is expected to have, independent of the Terraform module that created it
+type GoldenTemplate struct {
+    Name     string
+    Selector GoldenSelector
+    // Config holds the expected values of the fields listed in Fields
+    Config *Instance
+    // Fields lists the JSON field names present in the template file;
+    // fields absent from the template are not compared
+    Fields []string
+}
+
+// GoldenSelector chooses which instances a golden template applies to.
+// All constraints must match; patterns use shell glob syntax.
+type GoldenSelector struct {
+    Tags        map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+    NamePattern string            `json:"name_pattern,omitempty" yaml:"name_pattern,omitempty"`
+}
+
+// constraints returns the selector as a map of attribute to pattern,
+// treating the name pattern as a constraint on the Name tag
+func (s GoldenSelector) constraints() map[string]string {
+    c := make(map[string]string, len(s.Tags)+1)
+    for k, v := range s.Tags {
+        c[k] = v
+    }
+    if s.NamePattern != "" {
+        c["Name"] = s.NamePattern
+    }
+    return c
+}
+
+// IsEmpty returns true if the selector has no constraints
+func (s GoldenSelector) IsEmpty() bool {
+    return len(s.Tags) == 0 && s.NamePattern == ""
+}
+
+// Matches returns true if the instance satisfies every selector constraint
+func (s GoldenSelector) Matches(instance *Instance) bool {
+    if instance == nil || s.IsEmpty() {
+        return false
+    }
+    for key, pattern := range s.constraints() {
+        value, ok := instance.Tags[key]
+        if !ok {
+            return false
+        }
+        if matched, err := path.Match(pattern, value); err != nil || !matched {
+            return false
+        }
+    }
+    return true
+}
+
+// Overlaps returns true if some instance could match both selectors.
+// Selectors are disjoint only when they constrain a shared attribute with
+// patterns that can never match the same value.
+func (s GoldenSelector) Overlaps(other GoldenSelector) bool {
+    mine, theirs := s.constraints(), other.constraints()
+
+    keys := make([]string, 0, len(mine))
+    for k := range mine {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+
+    for _, key := range keys {
+        if pattern, ok := theirs[key]; ok && patternsDisjoint(mine[key], pattern) {
+            return false
+        }
+    }
+    return true
+}
+
+// patternsDisjoint conservatively decides whether two glob patterns can never
+// match the same string by comparing their literal prefixes and suffixes
+func patternsDisjoint(a, b string) bool {
+    prefixA, suffixA := literalAffixes(a)
+    prefixB, suffixB := literalAffixes(b)
+
+    if !strings.HasPrefix(prefixA, prefixB) && !strings.HasPrefix(prefixB, prefixA) {
+        return true
+    }
+    if !strings.HasSuffix(suffixA, suffixB) && !strings.HasSuffix(suffixB, suffixA) {
+        return true
+    }
+    return false
+}
+
+// literalAffixes returns the text before the first and after the last glob
+// metacharacter; a pattern without metacharacters is its own prefix and suffix
+func literalAffixes(pattern string) (string, string) {
+    first := strings.IndexAny(pattern, "*?[\\")
+    if first < 0 {
+        return pattern, pattern
+    }
+    last := strings.LastIndexAny(pattern, "*?]")
+    return pattern[:first], pattern[last+1:]
+}
@@ -0,0 +1,24 @@
+package models
+
+import "strings"
+
+// instanceProfileARNMarker separates an instance profile ARN's account from
+// the profile's path and name
+const instanceProfileARNMarker = ":instance-profile/"
+
+// InstanceProfileName returns the name of an IAM instance profile referred to
+// by name or by ARN. Terraform stores the name; EC2 returns the ARN, e.g.
+// arn:aws:iam::123456789012:instance-profile/path/web, whose name is "web".
+func InstanceProfileName(ref string) string {
+    ref = strings.TrimSpace(ref)
+    if !strings.HasPrefix(ref, "arn:") || !strings.Contains(ref, instanceProfileARNMarker) {
+        return ref
+    }
+    return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+// InstanceProfileEqual reports whether two references, each a name or an
+// ARN, refer to the same instance profile
+func InstanceProfileEqual(a, b string) bool {
+    return InstanceProfileName(a) == InstanceProfileName(b)
+}
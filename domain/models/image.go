@@ -0,0 +1,29 @@
+package models
+
+import (
+    "strings"
+    "time"
+)
+
+// Image describes the AMI an instance is launched from
+type Image struct {
+    ID           string            `json:"id"`
+    Name         string            `json:"name,omitempty"`
+    OwnerID      string            `json:"owner_id,omitempty"`
+    Architecture string            `json:"architecture,omitempty"`
+    Tags         map[string]string `json:"tags,omitempty"`
+    
+    // CreationDate is when the image was registered
+    CreationDate *time.Time `json:"creation_date,omitempty"`
+    
+    // DeprecationTime is when the image is or was deprecated, if it is set
+    // to be
+    DeprecationTime *time.Time `json:"deprecation_time,omitempty"`
+}
+
+// NameStem returns the image name without the build stamp a bake appends,
+// such as the date and time in web-2024-05-01T1030. Images rebaked from the
+// same recipe share a stem while their IDs and full names differ.
+func (i *Image) NameStem() string {
+    return strings.TrimRight(i.Name, "0123456789-_.:T")
+}
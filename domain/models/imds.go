@@ -0,0 +1,39 @@
+package models
+
+// Instance metadata service settings
+const (
+    HTTPTokensRequired   = "required"
+    HTTPTokensOptional   = "optional"
+    HTTPEndpointEnabled  = "enabled"
+    HTTPEndpointDisabled = "disabled"
+)
+
+// IMDSStatus is the outcome of auditing an instance's metadata service settings
+type IMDSStatus string
+
+const (
+    // IMDSCompliant means IMDSv1 is not allowed and the settings match Terraform
+    IMDSCompliant IMDSStatus = "OK"
+    // IMDSWarning means IMDSv1 is allowed, as Terraform configures it, or
+    // the endpoint differs from Terraform
+    IMDSWarning IMDSStatus = "WARNING"
+    // IMDSViolation means IMDSv1 is allowed although Terraform requires tokens
+    // or disables the endpoint
+    IMDSViolation IMDSStatus = "VIOLATION"
+    // IMDSMissing means the instance was not found in AWS
+    IMDSMissing IMDSStatus = "MISSING"
+)
+
+// IMDSAudit compares the metadata service settings of one instance with its
+// Terraform configuration
+type IMDSAudit struct {
+    InstanceID      string           `json:"instance_id"`
+    ResourceAddress string           `json:"resource_address,omitempty"`
+    // Expected is nil when Terraform does not configure metadata_options
+    Expected        *MetadataOptions `json:"expected,omitempty"`
+    // Actual is nil when the instance was not found or AWS reported no settings
+    Actual          *MetadataOptions `json:"actual,omitempty"`
+    Status          IMDSStatus       `json:"status"`
+    // Findings explain a status other than OK
+    Findings        []string         `json:"findings,omitempty"`
+}
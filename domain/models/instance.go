@@ -1,6 +1,9 @@
 package models
 
-import "encoding/json"
+import (
+    "encoding/json"
+    "time"
+)
 
 // Instance represents the domain model for an EC2 instance in our domain
 // This is the aggregate root for instance-related operations
@@ -12,6 +15,26 @@ type Instance struct {
     KeyName        string            `json:"key_name"`
     Tags           map[string]string `json:"tags"`
     
+    // ResourceAddress is the Terraform address (e.g. aws_instance.web) the
+    // configuration was read from; it is empty for instances read from AWS
+    ResourceAddress string `json:"resource_address,omitempty"`
+    
+    // Source is where the resource block is declared; it is only known for
+    // configurations parsed from .tf files
+    Source *SourceLocation `json:"source,omitempty"`
+    
+    // SourceMap maps the field paths the configuration sets, such as
+    // RootVolumeSize or Tags[Name], to the line setting them
+    SourceMap map[string]*SourceLocation `json:"source_map,omitempty"`
+    
+    // State is the lifecycle state AWS reports, such as running or stopped;
+    // it is empty for configurations read from Terraform
+    State string `json:"state,omitempty"`
+    
+    // LaunchTime is when AWS last launched the instance; it is nil for
+    // configurations read from Terraform
+    LaunchTime *time.Time `json:"launch_time,omitempty"`
+    
     // Networking
     VPCID                   string         `json:"vpc_id"`
     SubnetID                string         `json:"subnet_id"`
@@ -22,11 +45,21 @@ type Instance struct {
     PrivateDNSName          string         `json:"private_dns_name"`
     PublicDNSName           string         `json:"public_dns_name"`
     
+    // NetworkInterfaces are the attached network interfaces, ordered by
+    // device index; they are only compared when the configuration declares some
+    NetworkInterfaces       []NetworkInterface `json:"network_interfaces,omitempty"`
+    
     // Storage
     RootVolumeSize          int            `json:"root_volume_size"`
     RootVolumeType          string         `json:"root_volume_type"`
     RootVolumeIops          int            `json:"root_volume_iops,omitempty"`
+    RootVolumeThroughput    int            `json:"root_volume_throughput,omitempty"`
     RootVolumeEncrypted     *bool          `json:"root_volume_encrypted,omitempty"`
+    RootVolumeKMSKeyID      string         `json:"root_volume_kms_key_id,omitempty"`
+    EBSOptimized            *bool          `json:"ebs_optimized,omitempty"`
+    
+    // EBSBlockDevices are the non-root EBS volumes, ordered by device name
+    EBSBlockDevices         []EBSBlockDevice `json:"ebs_block_devices,omitempty"`
     
     // IAM and Monitoring
     IAMInstanceProfile      string         `json:"iam_instance_profile,omitempty"`
@@ -35,16 +68,190 @@ type Instance struct {
     // Placement
     AvailabilityZone        string         `json:"availability_zone,omitempty"`
     Tenancy                string         `json:"tenancy,omitempty"`
+    HostID                 string         `json:"host_id,omitempty"`
+    PlacementGroup         string         `json:"placement_group,omitempty"`
+    PartitionNumber        int            `json:"partition_number,omitempty"`
+    
+    // CPU
+    CPUCoreCount            int            `json:"cpu_core_count,omitempty"`
+    CPUThreadsPerCore       int            `json:"cpu_threads_per_core,omitempty"`
+    
+    // Hibernation and Enclave
+    Hibernation             *HibernationOptions `json:"hibernation,omitempty"`
+    EnclaveOptions          *EnclaveOptions     `json:"enclave_options,omitempty"`
+    
+    // Instance Metadata Service
+    MetadataOptions         *MetadataOptions    `json:"metadata_options,omitempty"`
+    
+    // InstanceLifecycle is how the instance is purchased: empty for
+    // on-demand, or the market it runs in, such as "spot"
+    InstanceLifecycle       string              `json:"instance_lifecycle,omitempty"`
+    
+    // SpotInstanceRequestID is the spot request that launched the instance.
+    // It is a reference, not a setting.
+    SpotInstanceRequestID   string              `json:"spot_instance_request_id,omitempty"`
+    
+    // InstanceMarketOptions are the spot settings of an instance that is
+    // not on-demand
+    InstanceMarketOptions   *InstanceMarketOptions `json:"instance_market_options,omitempty"`
+    
+    // Termination protection
+    DisableAPITermination   *bool               `json:"disable_api_termination,omitempty"`
+    
+    // InstanceInitiatedShutdownBehavior is "stop" or "terminate"; AWS
+    // reports "stop" when it was never set
+    InstanceInitiatedShutdownBehavior string  `json:"instance_initiated_shutdown_behavior,omitempty"`
+    
+    // LaunchTemplate is the launch template the instance was created from.
+    // It is a reference, not a setting; the settings the template supplies
+    // are merged into the fields above.
+    LaunchTemplate          *LaunchTemplateSpecification `json:"launch_template,omitempty"`
+    
+    // UserData is plain text, base64 or a SHA-1 hash depending on its source;
+    // compare it with UserDataEqual rather than directly
+    UserData                string              `json:"user_data,omitempty"`
+    
+    // UnknownFields names the fields whose planned values are only known
+    // after apply; they are not compared for drift
+    UnknownFields           []string            `json:"unknown_fields,omitempty"`
+    
+    // UnresolvedFields maps the fields set from data sources that could not
+    // be read, such as AMI from data.aws_ami.ubuntu, to the data source;
+    // they cannot be verified and are not compared
+    UnresolvedFields        map[string]string   `json:"unresolved_fields,omitempty"`
+    
+    // UnresolvedRefs maps the fields set from other resources, such as
+    // SecurityGroups from aws_security_group.web.id, to the references the
+    // configuration alone cannot evaluate. ResolveSecurityGroupRefs fills them
+    // in from Terraform state; the rest are reported as unresolved.
+    UnresolvedRefs          map[string][]string `json:"unresolved_refs,omitempty"`
+    
+    // UnavailableFields maps the fields AWS did not allow reading, such as
+    // RootVolumeSize without ec2:DescribeVolumes, to the missing permission;
+    // it is only set on instances read from AWS, and the fields are not compared
+    UnavailableFields       map[string]string   `json:"unavailable_fields,omitempty"`
+    
+    // IgnoreChanges are the field paths, such as AMI or Tags[LastPatched],
+    // the resource's lifecycle ignore_changes lists; Terraform expects them
+    // to drift, so findings in them are suppressed
+    IgnoreChanges           []string            `json:"ignore_changes,omitempty"`
+    
+    // ParseWarnings are the parts of the configuration that could not be
+    // read; they are reported as warnings of the instance's drift report
+    ParseWarnings           []ParseWarning      `json:"parse_warnings,omitempty"`
     
     // Additional fields as needed...
 }
 
+// Instance states reported by AWS
+const (
+    InstanceStatePending      = "pending"
+    InstanceStateRunning      = "running"
+    InstanceStateShuttingDown = "shutting-down"
+    InstanceStateTerminated   = "terminated"
+    InstanceStateStopping     = "stopping"
+    InstanceStateStopped      = "stopped"
+)
+
 // SecurityGroup represents a security group associated with an instance
 type SecurityGroup struct {
     GroupID   string `json:"id"`
     GroupName string `json:"name,omitempty"`
 }
 
+// EBSBlockDevice describes a non-root EBS volume attached to an instance
+type EBSBlockDevice struct {
+    DeviceName          string `json:"device_name"`
+    VolumeSize          int    `json:"volume_size,omitempty"`
+    VolumeType          string `json:"volume_type,omitempty"`
+    Iops                int    `json:"iops,omitempty"`
+    Throughput          int    `json:"throughput,omitempty"`
+    Encrypted           *bool  `json:"encrypted,omitempty"`
+    KMSKeyID            string `json:"kms_key_id,omitempty"`
+    DeleteOnTermination *bool  `json:"delete_on_termination,omitempty"`
+    // Tags are the volume's own tags; they are only compared when the
+    // configuration sets some
+    Tags                map[string]string `json:"tags,omitempty"`
+}
+
+// NetworkInterface describes a network interface attached to an instance
+type NetworkInterface struct {
+    DeviceIndex         int    `json:"device_index"`
+    NetworkInterfaceID  string `json:"network_interface_id,omitempty"`
+    DeleteOnTermination *bool  `json:"delete_on_termination,omitempty"`
+    // PrivateIPAddresses are sorted, so their order carries no meaning
+    PrivateIPAddresses  []string `json:"private_ip_addresses,omitempty"`
+    // Groups are the security groups of the interface; those of the primary
+    // interface are the instance's SecurityGroups
+    Groups              []SecurityGroup `json:"groups,omitempty"`
+}
+
+// HibernationOptions describes whether an instance is configured for hibernation
+type HibernationOptions struct {
+    Configured bool `json:"configured"`
+}
+
+// EnclaveOptions describes whether Nitro Enclaves are enabled for an instance
+type EnclaveOptions struct {
+    Enabled bool `json:"enabled"`
+}
+
+// MetadataOptions describes the instance metadata service (IMDS) settings
+type MetadataOptions struct {
+    HTTPEndpoint            string `json:"http_endpoint,omitempty"`
+    HTTPTokens              string `json:"http_tokens,omitempty"`
+    HTTPPutResponseHopLimit int    `json:"http_put_response_hop_limit,omitempty"`
+    InstanceMetadataTags    string `json:"instance_metadata_tags,omitempty"`
+}
+
+// Purchase options of instances
+const (
+    // InstanceLifecycleOnDemand labels instances AWS reports no lifecycle for
+    InstanceLifecycleOnDemand = "on-demand"
+    InstanceLifecycleSpot     = "spot"
+
+    // SpotInterruptionTerminate is the interruption behavior of spot
+    // instances that do not set one
+    SpotInterruptionTerminate = "terminate"
+)
+
+// InstanceMarketOptions describes the market an instance is purchased in
+type InstanceMarketOptions struct {
+    MarketType                   string `json:"market_type,omitempty"`
+    MaxPrice                     string `json:"max_price,omitempty"`
+    InstanceInterruptionBehavior string `json:"instance_interruption_behavior,omitempty"`
+}
+
+// Lifecycle returns how the instance is purchased, "on-demand" when it
+// records no lifecycle
+func (i *Instance) Lifecycle() string {
+    if i.InstanceLifecycle == "" {
+        return InstanceLifecycleOnDemand
+    }
+    return i.InstanceLifecycle
+}
+
+// HibernationConfigured returns true if the instance declares hibernation
+func (i *Instance) HibernationConfigured() bool {
+    return i.Hibernation != nil && i.Hibernation.Configured
+}
+
+// EnclaveEnabled returns true if the instance declares Nitro Enclaves
+func (i *Instance) EnclaveEnabled() bool {
+    return i.EnclaveOptions != nil && i.EnclaveOptions.Enabled
+}
+
+// IsTerminated returns true if AWS reports the instance terminated or
+// shutting down
+func (i *Instance) IsTerminated() bool {
+    return i.State == InstanceStateTerminated || i.State == InstanceStateShuttingDown
+}
+
+// IsStopped returns true if AWS reports the instance stopped or stopping
+func (i *Instance) IsStopped() bool {
+    return i.State == InstanceStateStopped || i.State == InstanceStateStopping
+}
+
 // NewInstance creates a new Instance with required fields
 func NewInstance(id, instanceType, ami string) *Instance {
     return &Instance{
@@ -63,21 +270,70 @@ func (i *Instance) AddTag(key, value string) {
     i.Tags[key] = value
 }
 
+// MarkUnavailable records that fields could not be read without permission
+func (i *Instance) MarkUnavailable(permission string, fields ...string) {
+    if i.UnavailableFields == nil {
+        i.UnavailableFields = make(map[string]string, len(fields))
+    }
+    for _, field := range fields {
+        i.UnavailableFields[field] = permission
+    }
+}
+
+// ResolveSecurityGroupRefs adds the IDs of the groups referenced in
+// UnresolvedRefs, such as aws_security_group.web.id, to SecurityGroups when
+// groups records them. References to other groups are kept unresolved.
+func (i *Instance) ResolveSecurityGroupRefs(groups []*SecurityGroupConfig) {
+    refs := i.UnresolvedRefs["SecurityGroups"]
+    if len(refs) == 0 {
+        return
+    }
+
+    ids := make(map[string]string, len(groups))
+    for _, group := range groups {
+        if group.ResourceAddress != "" {
+            ids[group.ResourceAddress+".id"] = group.GroupID
+        }
+    }
+
+    var unresolved []string
+    for _, ref := range refs {
+        if id, ok := ids[ref]; ok {
+            i.SecurityGroups = append(i.SecurityGroups, SecurityGroup{GroupID: id})
+        } else {
+            unresolved = append(unresolved, ref)
+        }
+    }
+
+    if len(unresolved) > 0 {
+        i.UnresolvedRefs["SecurityGroups"] = unresolved
+        return
+    }
+    delete(i.UnresolvedRefs, "SecurityGroups")
+    if len(i.UnresolvedRefs) == 0 {
+        i.UnresolvedRefs = nil
+    }
+}
+
 // IsValid checks if the instance has the minimum required fields
 func (i *Instance) IsValid() bool {
     return i.ID != "" && i.Type != "" && i.AMI != ""
 }
 
+// instanceAlias has Instance's fields but not its methods, which keeps
+// UnmarshalJSON from recursing into itself
+type instanceAlias Instance
+
 // Custom unmarshal to handle different tag formats
 type instanceJSON struct {
-    *Instance
+    *instanceAlias
     RawTags interface{} `json:"tags,omitempty"`
 }
 
 // UnmarshalJSON implements custom JSON unmarshaling for Instance
 func (i *Instance) UnmarshalJSON(data []byte) error {
     var temp instanceJSON
-    temp.Instance = i
+    temp.instanceAlias = (*instanceAlias)(i)
     
     if err := json.Unmarshal(data, &temp); err != nil {
         return err
@@ -85,28 +341,47 @@ func (i *Instance) UnmarshalJSON(data []byte) error {
     
     // Handle different tag formats
     if temp.RawTags != nil {
-        switch v := temp.RawTags.(type) {
-        case map[string]interface{}:
-            i.Tags = make(map[string]string)
-            for key, val := range v {
-                if strVal, ok := val.(string); ok {
-                    i.Tags[key] = strVal
-                }
+        i.Tags = DecodeTags(temp.RawTags)
+    }
+    
+    return nil
+}
+
+// DecodeTags converts tags decoded from JSON into a map. They may be a map of
+// strings or a list of pairs as AWS returns them, [{"Key": "Name", "Value":
+// "web"}], also with the lowercase "key" and "value" some tools emit. Entries
+// that are not strings are skipped.
+func DecodeTags(raw interface{}) map[string]string {
+    tags := make(map[string]string)
+    switch v := raw.(type) {
+    case map[string]interface{}:
+        for key, val := range v {
+            if strVal, ok := val.(string); ok {
+                tags[key] = strVal
+            }
+        }
+    case []interface{}:
+        for _, item := range v {
+            tag, ok := item.(map[string]interface{})
+            if !ok {
+                continue
             }
-        case []interface{}:
-            // Handle array of tag objects if needed
-            i.Tags = make(map[string]string)
-            for _, item := range v {
-                if tag, ok := item.(map[string]interface{}); ok {
-                    if key, kOk := tag["Key"].(string); kOk {
-                        if val, vOk := tag["Value"].(string); vOk {
-                            i.Tags[key] = val
-                        }
-                    }
-                }
+            key, keyOk := tagField(tag, "Key", "key")
+            val, valOk := tagField(tag, "Value", "value")
+            if keyOk && valOk {
+                tags[key] = val
             }
         }
     }
-    
-    return nil
+    return tags
+}
+
+// tagField returns the first of names set to a string in tag
+func tagField(tag map[string]interface{}, names ...string) (string, bool) {
+    for _, name := range names {
+        if v, ok := tag[name].(string); ok {
+            return v, true
+        }
+    }
+    return "", false
 }
@@ -0,0 +1,109 @@
+package models
+
+import "strings"
+
+// InstanceTypeInfo describes the capabilities of an EC2 instance type that
+// matter for prerequisite validation
+type InstanceTypeInfo struct {
+    Name                  string
+    VCPUs                 int
+    MemoryGiB             float64
+    HibernationSupported  bool
+    EnclavesSupported     bool
+}
+
+// maxHibernationMemoryGiB is the largest RAM size AWS supports for hibernation
+const maxHibernationMemoryGiB = 150
+
+// instanceFamily describes capabilities shared by every size in a family
+type instanceFamily struct {
+    memoryPerVCPU float64
+    hibernation   bool
+    nitro         bool
+    burstable     bool
+}
+
+// instanceFamilies lists the families the detector knows about
+var instanceFamilies = map[string]instanceFamily{
+    "t2":  {hibernation: true, burstable: true},
+    "t3":  {hibernation: true, nitro: true, burstable: true},
+    "t3a": {hibernation: true, nitro: true, burstable: true},
+    "m4":  {memoryPerVCPU: 4, hibernation: true},
+    "m5":  {memoryPerVCPU: 4, hibernation: true, nitro: true},
+    "m5a": {memoryPerVCPU: 4, hibernation: true, nitro: true},
+    "m6i": {memoryPerVCPU: 4, hibernation: true, nitro: true},
+    "m7i": {memoryPerVCPU: 4, hibernation: true, nitro: true},
+    "c4":  {memoryPerVCPU: 1.875, hibernation: true},
+    "c5":  {memoryPerVCPU: 2, hibernation: true, nitro: true},
+    "c6i": {memoryPerVCPU: 2, hibernation: true, nitro: true},
+    "c7i": {memoryPerVCPU: 2, hibernation: true, nitro: true},
+    "r4":  {memoryPerVCPU: 7.625, hibernation: true},
+    "r5":  {memoryPerVCPU: 8, hibernation: true, nitro: true},
+    "r6i": {memoryPerVCPU: 8, hibernation: true, nitro: true},
+    "r7i": {memoryPerVCPU: 8, hibernation: true, nitro: true},
+    "x1":  {memoryPerVCPU: 15.25},
+    "z1d": {memoryPerVCPU: 8, nitro: true},
+}
+
+// instanceSizeVCPUs maps standard size suffixes to vCPU counts
+var instanceSizeVCPUs = map[string]int{
+    "large":    2,
+    "xlarge":   4,
+    "2xlarge":  8,
+    "4xlarge":  16,
+    "8xlarge":  32,
+    "12xlarge": 48,
+    "16xlarge": 64,
+    "24xlarge": 96,
+}
+
+// burstableSizes maps burstable size suffixes to vCPU count and memory
+var burstableSizes = map[string]struct {
+    vcpus     int
+    memoryGiB float64
+}{
+    "nano":    {2, 0.5},
+    "micro":   {2, 1},
+    "small":   {2, 2},
+    "medium":  {2, 4},
+    "large":   {2, 8},
+    "xlarge":  {4, 16},
+    "2xlarge": {8, 32},
+}
+
+// LookupInstanceType returns capability information for an instance type.
+// The second return value is false when the type is not in the table.
+func LookupInstanceType(name string) (InstanceTypeInfo, bool) {
+    familyName, size, ok := strings.Cut(name, ".")
+    if !ok {
+        return InstanceTypeInfo{}, false
+    }
+
+    family, ok := instanceFamilies[familyName]
+    if !ok {
+        return InstanceTypeInfo{}, false
+    }
+
+    info := InstanceTypeInfo{Name: name}
+    if family.burstable {
+        spec, ok := burstableSizes[size]
+        if !ok {
+            return InstanceTypeInfo{}, false
+        }
+        info.VCPUs = spec.vcpus
+        info.MemoryGiB = spec.memoryGiB
+    } else {
+        vcpus, ok := instanceSizeVCPUs[size]
+        if !ok {
+            return InstanceTypeInfo{}, false
+        }
+        info.VCPUs = vcpus
+        info.MemoryGiB = float64(vcpus) * family.memoryPerVCPU
+    }
+
+    info.HibernationSupported = family.hibernation && info.MemoryGiB <= maxHibernationMemoryGiB
+    // Nitro Enclaves need a Nitro-based, non-burstable type with at least 4 vCPUs
+    info.EnclavesSupported = family.nitro && !family.burstable && info.VCPUs >= 4
+
+    return info, true
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+    "sort"
+    "strconv"
+)
+
+// Launch template versions that are resolved when an instance is launched
+const (
+    LaunchTemplateVersionLatest  = "$Latest"
+    LaunchTemplateVersionDefault = "$Default"
+)
+
+// LaunchTemplateSpecification identifies the launch template version an
+// instance is created from, by ID or by name
+type LaunchTemplateSpecification struct {
+    ID      string `json:"id,omitempty"`
+    Name    string `json:"name,omitempty"`
+    Version string `json:"version,omitempty"`
+}
+
+// String describes the template for messages, e.g. lt-0abc@$Latest
+func (s LaunchTemplateSpecification) String() string {
+    ref := s.ID
+    if ref == "" {
+        ref = s.Name
+    }
+    version := s.Version
+    if version == "" {
+        version = LaunchTemplateVersionDefault
+    }
+    return ref + "@" + version
+}
+
+// LaunchTemplate is the instance configuration of one launch template version
+type LaunchTemplate struct {
+    ID             string
+    Name           string
+    LatestVersion  int
+    DefaultVersion int
+
+    // Instance holds the settings the template supplies to instances
+    Instance *Instance
+}
+
+// Supplies reports whether the template's settings are those of version,
+// given that they are the settings of its latest version
+func (t *LaunchTemplate) Supplies(version string) bool {
+    switch version {
+    case LaunchTemplateVersionLatest:
+        return true
+    case "", LaunchTemplateVersionDefault:
+        return t.DefaultVersion == t.LatestVersion
+    default:
+        n, err := strconv.Atoi(version)
+        return err == nil && n == t.LatestVersion
+    }
+}
+
+// MergeLaunchTemplate fills the settings the instance leaves unset from
+// template. As in AWS, settings on the instance override the template.
+func (i *Instance) MergeLaunchTemplate(template *Instance) {
+    if template == nil {
+        return
+    }
+
+    mergeString(&i.Type, template.Type)
+    mergeString(&i.AMI, template.AMI)
+    mergeString(&i.KeyName, template.KeyName)
+    mergeString(&i.IAMInstanceProfile, template.IAMInstanceProfile)
+    mergeString(&i.UserData, template.UserData)
+
+    for key, value := range template.Tags {
+        if _, ok := i.Tags[key]; !ok {
+            i.AddTag(key, value)
+        }
+    }
+
+    if len(i.SecurityGroups) == 0 {
+        i.SecurityGroups = append([]SecurityGroup(nil), template.SecurityGroups...)
+    }
+
+    // Root volume
+    mergeInt(&i.RootVolumeSize, template.RootVolumeSize)
+    mergeString(&i.RootVolumeType, template.RootVolumeType)
+    mergeInt(&i.RootVolumeIops, template.RootVolumeIops)
+    mergeInt(&i.RootVolumeThroughput, template.RootVolumeThroughput)
+    mergeBool(&i.RootVolumeEncrypted, template.RootVolumeEncrypted)
+    mergeString(&i.RootVolumeKMSKeyID, template.RootVolumeKMSKeyID)
+    mergeBool(&i.EBSOptimized, template.EBSOptimized)
+
+    // Block device mappings on the instance replace the template's for the
+    // same device name
+    devices := make(map[string]bool, len(i.EBSBlockDevices))
+    for _, device := range i.EBSBlockDevices {
+        devices[device.DeviceName] = true
+    }
+    for _, device := range template.EBSBlockDevices {
+        if !devices[device.DeviceName] {
+            i.EBSBlockDevices = append(i.EBSBlockDevices, device)
+        }
+    }
+    sort.Slice(i.EBSBlockDevices, func(a, b int) bool {
+        return i.EBSBlockDevices[a].DeviceName < i.EBSBlockDevices[b].DeviceName
+    })
+
+    mergeBool(&i.Monitoring, template.Monitoring)
+    mergeBool(&i.DisableAPITermination, template.DisableAPITermination)
+    mergeString(&i.InstanceInitiatedShutdownBehavior, template.InstanceInitiatedShutdownBehavior)
+
+    // Placement
+    mergeString(&i.AvailabilityZone, template.AvailabilityZone)
+    mergeString(&i.Tenancy, template.Tenancy)
+    mergeString(&i.HostID, template.HostID)
+    mergeString(&i.PlacementGroup, template.PlacementGroup)
+    mergeInt(&i.PartitionNumber, template.PartitionNumber)
+
+    mergeInt(&i.CPUCoreCount, template.CPUCoreCount)
+    mergeInt(&i.CPUThreadsPerCore, template.CPUThreadsPerCore)
+
+    if i.Hibernation == nil && template.Hibernation != nil {
+        hibernation := *template.Hibernation
+        i.Hibernation = &hibernation
+    }
+    if i.EnclaveOptions == nil && template.EnclaveOptions != nil {
+        enclave := *template.EnclaveOptions
+        i.EnclaveOptions = &enclave
+    }
+    if i.MetadataOptions == nil && template.MetadataOptions != nil {
+        metadata := *template.MetadataOptions
+        i.MetadataOptions = &metadata
+    }
+    if i.InstanceMarketOptions == nil && template.InstanceMarketOptions != nil {
+        market := *template.InstanceMarketOptions
+        i.InstanceMarketOptions = &market
+    }
+    mergeString(&i.InstanceLifecycle, template.InstanceLifecycle)
+}
+
+func mergeString(dst *string, src string) {
+    if *dst == "" {
+        *dst = src
+    }
+}
+
+func mergeInt(dst *int, src int) {
+    if *dst == 0 {
+        *dst = src
+    }
+}
+
+func mergeBool(dst **bool, src *bool) {
+    if *dst == nil && src != nil {
+        v := *src
+        *dst = &v
+    }
+}
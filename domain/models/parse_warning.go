@@ -0,0 +1,27 @@
+package models
+
+import "fmt"
+
+// ParseWarning records a part of an instance's configuration the parser
+// could not read, such as an attribute whose expression failed to evaluate.
+// The setting is left out of the instance, so it is not compared.
+type ParseWarning struct {
+    File      string `json:"file,omitempty"`
+    Line      int    `json:"line,omitempty"`
+    Attribute string `json:"attribute,omitempty"`
+    Reason    string `json:"reason"`
+}
+
+// String returns the warning as location: attribute: reason, e.g.
+// main.tf:12: subnet_id: Unknown variable
+func (w ParseWarning) String() string {
+    s := w.Reason
+    if w.Attribute != "" {
+        s = fmt.Sprintf("%s: %s", w.Attribute, s)
+    }
+    if w.File != "" {
+        location := &SourceLocation{File: w.File, Line: w.Line}
+        s = fmt.Sprintf("%s: %s", location, s)
+    }
+    return s
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+    "encoding"
+    "fmt"
+    "reflect"
+    "strings"
+)
+
+// PlainValue returns v as plain data: structs become maps keyed by their JSON
+// field names, pointers are replaced by the values they refer to, and maps
+// and slices holding either become map[string]interface{} and
+// []interface{}. Values without structs or pointers, such as strings, ints
+// or map[string]string, are returned as they are. Drift values are kept
+// plain so every output format renders them alike and without addresses.
+func PlainValue(v interface{}) interface{} {
+    if v == nil {
+        return nil
+    }
+    return plainValue(reflect.ValueOf(v))
+}
+
+// textMarshalerType is implemented by values, such as time.Time, that have
+// their own text form
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+func plainValue(v reflect.Value) interface{} {
+    if !v.IsValid() {
+        return nil
+    }
+    if !needsPlaining(v.Type()) {
+        return v.Interface()
+    }
+    if v.Type().Implements(textMarshalerType) && (v.Kind() != reflect.Ptr || !v.IsNil()) {
+        if text, err := v.Interface().(encoding.TextMarshaler).MarshalText(); err == nil {
+            return string(text)
+        }
+    }
+
+    switch v.Kind() {
+    case reflect.Ptr, reflect.Interface:
+        if v.IsNil() {
+            return nil
+        }
+        return plainValue(v.Elem())
+
+    case reflect.Struct:
+        fields := make(map[string]interface{}, v.NumField())
+        for i := 0; i < v.NumField(); i++ {
+            field := v.Type().Field(i)
+            if !field.IsExported() {
+                continue
+            }
+            name, omitEmpty, skip := jsonFieldName(field)
+            if skip || (omitEmpty && v.Field(i).IsZero()) {
+                continue
+            }
+            fields[name] = plainValue(v.Field(i))
+        }
+        return fields
+
+    case reflect.Map:
+        if v.IsNil() {
+            return nil
+        }
+        entries := make(map[string]interface{}, v.Len())
+        iter := v.MapRange()
+        for iter.Next() {
+            entries[fmt.Sprintf("%v", iter.Key().Interface())] = plainValue(iter.Value())
+        }
+        return entries
+
+    case reflect.Slice, reflect.Array:
+        if v.Kind() == reflect.Slice && v.IsNil() {
+            return nil
+        }
+        elems := make([]interface{}, v.Len())
+        for i := range elems {
+            elems[i] = plainValue(v.Index(i))
+        }
+        return elems
+
+    default:
+        return v.Interface()
+    }
+}
+
+// needsPlaining reports whether values of type t may hold a struct, a
+// pointer or an interface, which PlainValue replaces
+func needsPlaining(t reflect.Type) bool {
+    switch t.Kind() {
+    case reflect.Struct, reflect.Ptr, reflect.Interface:
+        return true
+    case reflect.Map:
+        return needsPlaining(t.Key()) || needsPlaining(t.Elem())
+    case reflect.Slice, reflect.Array:
+        return needsPlaining(t.Elem())
+    default:
+        return false
+    }
+}
+
+// jsonFieldName returns the name field is marshalled under, whether it is
+// left out when empty, and whether it is never marshalled
+func jsonFieldName(field reflect.StructField) (name string, omitEmpty, skip bool) {
+    tag := field.Tag.Get("json")
+    if tag == "-" {
+        return "", false, true
+    }
+    name, opts, _ := strings.Cut(tag, ",")
+    if name == "" {
+        name = field.Name
+    }
+    for _, opt := range strings.Split(opts, ",") {
+        if opt == "omitempty" || opt == "omitzero" {
+            omitEmpty = true
+        }
+    }
+    return name, omitEmpty, false
+}
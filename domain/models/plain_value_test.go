@@ -0,0 +1,67 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"driftdetector/domain/models"
+)
+
+func TestPlainValue(t *testing.T) {
+	enabled := true
+	var noOptions *models.MetadataOptions
+
+	tests := []struct {
+		name  string
+		value interface{}
+		want  interface{}
+	}{
+		{name: "nil", value: nil, want: nil},
+		{name: "scalar", value: "t3.micro", want: "t3.micro"},
+		{name: "int", value: 8, want: 8},
+		{name: "plain map", value: map[string]string{"Name": "web"}, want: map[string]string{"Name": "web"}},
+		{name: "plain slice", value: []string{"sg-1"}, want: []string{"sg-1"}},
+		{name: "pointer to scalar", value: &enabled, want: true},
+		{name: "nil pointer", value: noOptions, want: nil},
+		{
+			name:  "pointer to struct",
+			value: &models.MetadataOptions{HTTPEndpoint: "enabled", HTTPTokens: "optional"},
+			want:  map[string]interface{}{"http_endpoint": "enabled", "http_tokens": "optional"},
+		},
+		{
+			name: "slice of structs",
+			value: []models.EBSBlockDevice{
+				{DeviceName: "/dev/sdf", VolumeSize: 20, Encrypted: &enabled, Tags: map[string]string{"Backup": "daily"}},
+			},
+			want: []interface{}{
+				map[string]interface{}{"device_name": "/dev/sdf", "volume_size": 20, "encrypted": true, "tags": map[string]string{"Backup": "daily"}},
+			},
+		},
+		{
+			name:  "map of interfaces",
+			value: map[string]interface{}{"options": &models.EnclaveOptions{}, "count": 2},
+			want:  map[string]interface{}{"options": map[string]interface{}{"enabled": false}, "count": 2},
+		},
+		{
+			name:  "text marshaler",
+			value: time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+			want:  "2026-10-16T00:00:00Z",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, models.PlainValue(tt.value))
+		})
+	}
+}
+
+func TestNewDrift_StoresPlainValues(t *testing.T) {
+	drift := models.NewDrift(models.DriftTypeRemoved, "MetadataOptions",
+		&models.MetadataOptions{HTTPTokens: "required"}, nil, "Value set in AWS is not declared in Terraform")
+
+	assert.Equal(t, map[string]interface{}{"http_tokens": "required"}, drift.Actual)
+	assert.Nil(t, drift.Expected)
+}
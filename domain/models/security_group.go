@@ -0,0 +1,111 @@
+package models
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+)
+
+// SecurityGroupConfig represents the configuration of a security group and its rules
+type SecurityGroupConfig struct {
+    GroupID     string              `json:"id"`
+    Name        string              `json:"name,omitempty"`
+    Description string              `json:"description,omitempty"`
+    VPCID       string              `json:"vpc_id,omitempty"`
+    Tags        map[string]string   `json:"tags,omitempty"`
+    // ResourceAddress is the aws_security_group resource the group was read
+    // from, such as aws_security_group.web; empty for groups read from AWS
+    ResourceAddress string          `json:"resource_address,omitempty"`
+    Ingress     []SecurityGroupRule `json:"ingress,omitempty"`
+    Egress      []SecurityGroupRule `json:"egress,omitempty"`
+}
+
+// SecurityGroupRule is a single ingress or egress rule
+type SecurityGroupRule struct {
+    Protocol       string   `json:"protocol"`
+    FromPort       int      `json:"from_port"`
+    ToPort         int      `json:"to_port"`
+    CIDRBlocks     []string `json:"cidr_blocks,omitempty"`
+    IPv6CIDRBlocks []string `json:"ipv6_cidr_blocks,omitempty"`
+    PrefixListIDs  []string `json:"prefix_list_ids,omitempty"`
+    SecurityGroups []string `json:"security_groups,omitempty"`
+    Description    string   `json:"description,omitempty"`
+}
+
+// protocolNames maps IANA protocol numbers to the names EC2 reports
+var protocolNames = map[string]string{
+    "6":   "tcp",
+    "17":  "udp",
+    "1":   "icmp",
+    "58":  "icmpv6",
+    "all": "-1",
+}
+
+// NormalizeProtocol returns the canonical form of a rule protocol, so that
+// "6", "TCP" and "tcp" compare equal
+func NormalizeProtocol(protocol string) string {
+    p := strings.ToLower(strings.TrimSpace(protocol))
+    if name, ok := protocolNames[p]; ok {
+        return name
+    }
+    return p
+}
+
+// Key identifies the protocol and port range a rule applies to,
+// e.g. "tcp/443", "tcp/8000-8080" or "all"
+func (r SecurityGroupRule) Key() string {
+    protocol := NormalizeProtocol(r.Protocol)
+    if protocol == "-1" {
+        return "all"
+    }
+    if r.FromPort == r.ToPort {
+        return fmt.Sprintf("%s/%d", protocol, r.FromPort)
+    }
+    return fmt.Sprintf("%s/%d-%d", protocol, r.FromPort, r.ToPort)
+}
+
+// Sources returns the sorted, prefixed sources (or destinations) a rule allows
+func (r SecurityGroupRule) Sources() []string {
+    var sources []string
+    for _, c := range r.CIDRBlocks {
+        sources = append(sources, "cidr:"+c)
+    }
+    for _, c := range r.IPv6CIDRBlocks {
+        sources = append(sources, "ipv6:"+c)
+    }
+    for _, p := range r.PrefixListIDs {
+        sources = append(sources, "prefix-list:"+p)
+    }
+    for _, g := range r.SecurityGroups {
+        sources = append(sources, "sg:"+g)
+    }
+    sort.Strings(sources)
+    return sources
+}
+
+// RuleSet groups rules by Key, merging the sources of rules that share a port
+// range. Terraform may split one EC2 permission across several blocks, so
+// only the merged set is meaningful to compare.
+func RuleSet(rules []SecurityGroupRule) map[string][]string {
+    merged := make(map[string]map[string]bool)
+    for _, r := range rules {
+        key := r.Key()
+        if merged[key] == nil {
+            merged[key] = make(map[string]bool)
+        }
+        for _, s := range r.Sources() {
+            merged[key][s] = true
+        }
+    }
+
+    set := make(map[string][]string, len(merged))
+    for key, sources := range merged {
+        list := make([]string, 0, len(sources))
+        for s := range sources {
+            list = append(list, s)
+        }
+        sort.Strings(list)
+        set[key] = list
+    }
+    return set
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+    "fmt"
+    "strings"
+)
+
+// Severity ranks how much a drift finding matters
+type Severity string
+
+const (
+    // SeverityInfo marks cosmetic differences, such as tags
+    SeverityInfo Severity = "INFO"
+    // SeverityWarning marks differences worth reviewing
+    SeverityWarning Severity = "WARNING"
+    // SeverityCritical marks differences that weaken security or availability
+    SeverityCritical Severity = "CRITICAL"
+)
+
+// severityRank orders severities from least to most important
+var severityRank = map[Severity]int{
+    SeverityInfo:     1,
+    SeverityWarning:  2,
+    SeverityCritical: 3,
+}
+
+// ParseSeverity parses a severity name, ignoring case
+func ParseSeverity(s string) (Severity, error) {
+    severity := Severity(strings.ToUpper(strings.TrimSpace(s)))
+    if _, ok := severityRank[severity]; !ok {
+        return "", fmt.Errorf("unknown severity %q: expected INFO, WARNING or CRITICAL", s)
+    }
+    return severity, nil
+}
+
+// AtLeast reports whether s is as important as min or more
+func (s Severity) AtLeast(min Severity) bool {
+    return severityRank[s] >= severityRank[min]
+}
+
+// SeverityRule assigns a severity to every drift path under Path
+type SeverityRule struct {
+    Path     string   `yaml:"path" json:"path"`
+    Severity Severity `yaml:"severity" json:"severity"`
+}
+
+// DefaultSeverityRules returns the built-in classification. Paths without a
+// matching rule are WARNING.
+func DefaultSeverityRules() []SeverityRule {
+    return []SeverityRule{
+        {Path: "SecurityGroups", Severity: SeverityCritical},
+        {Path: "MetadataOptions.HTTPTokens", Severity: SeverityCritical},
+        {Path: "DisableAPITermination", Severity: SeverityCritical},
+        {Path: "Tags", Severity: SeverityInfo},
+    }
+}
+
+// ClassifySeverity returns the severity of the most specific rule matching
+// path. When two rules have the same path the later one wins, so overrides
+// can be appended to the defaults.
+func ClassifySeverity(rules []SeverityRule, path string) Severity {
+    path = strings.TrimPrefix(path, ".")
+
+    severity := SeverityWarning
+    longest := -1
+    for _, rule := range rules {
+        if !severityRuleMatches(rule.Path, path) || len(rule.Path) < longest {
+            continue
+        }
+        severity = rule.Severity
+        longest = len(rule.Path)
+    }
+    return severity
+}
+
+// severityRuleMatches reports whether prefix is path or one of its parents
+func severityRuleMatches(prefix, path string) bool {
+    if !strings.HasPrefix(path, prefix) {
+        return false
+    }
+    rest := path[len(prefix):]
+    return rest == "" || rest[0] == '.' || rest[0] == '['
+}
+
+// ApplySeverity classifies every finding that does not have a severity yet
+func (r *DriftReport) ApplySeverity(rules []SeverityRule) {
+    for i := range r.Drifts {
+        if r.Drifts[i].Severity == "" {
+            r.Drifts[i].Severity = ClassifySeverity(rules, r.Drifts[i].Path)
+        }
+    }
+}
+
+// FilterBySeverity returns a copy of the report keeping only the findings at
+// or above min
+func (r *DriftReport) FilterBySeverity(min Severity) *DriftReport {
+    filtered := *r
+    filtered.Drifts = make([]Drift, 0, len(r.Drifts))
+    for _, d := range r.Drifts {
+        if d.Severity == "" || d.Severity.AtLeast(min) {
+            filtered.Drifts = append(filtered.Drifts, d)
+        }
+    }
+    filtered.HasDrift = ContainsDrift(filtered.Drifts)
+    return &filtered
+}
@@ -0,0 +1,8 @@
+package models
+
+// Subnet describes the VPC subnet an instance is launched into
+type Subnet struct {
+    ID               string `json:"id"`
+    AvailabilityZone string `json:"availability_zone,omitempty"`
+    VPCID            string `json:"vpc_id,omitempty"`
+}
@@ -0,0 +1,112 @@
+package models
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+)
+
+// ReportSummary condenses the drift report of one instance to counts
+type ReportSummary struct {
+    InstanceID      string            `json:"instance_id"`
+    Drifted         bool              `json:"drifted"`
+    Findings        int               `json:"findings"`
+    // ByType counts the findings of each drift type
+    ByType          map[DriftType]int `json:"by_type,omitempty"`
+    // HighestSeverity is the severity of the most important finding, if any
+    HighestSeverity Severity          `json:"highest_severity,omitempty"`
+}
+
+// Summary counts the findings of the report by type and finds the highest
+// severity among them
+func (r *DriftReport) Summary() ReportSummary {
+    summary := ReportSummary{
+        InstanceID: r.InstanceID,
+        Drifted:    r.HasDrifts(),
+    }
+    for _, d := range r.Drifts {
+        if d.Type == "" {
+            continue
+        }
+        summary.add(d.Type, 1, d.Severity)
+    }
+    return summary
+}
+
+// add counts n findings of driftType at severity
+func (s *ReportSummary) add(driftType DriftType, n int, severity Severity) {
+    if s.ByType == nil {
+        s.ByType = make(map[DriftType]int)
+    }
+    s.ByType[driftType] += n
+    s.Findings += n
+    if severityRank[severity] > severityRank[s.HighestSeverity] {
+        s.HighestSeverity = severity
+    }
+}
+
+// String describes the summary in one line, e.g.
+// "i-123: drifted, MODIFIED=2 REMOVED=1, highest severity CRITICAL"
+func (s ReportSummary) String() string {
+    if !s.Drifted {
+        return s.InstanceID + ": no drift"
+    }
+    line := fmt.Sprintf("%s: drifted, %s", s.InstanceID, s.Counts())
+    if s.HighestSeverity != "" {
+        line += ", highest severity " + string(s.HighestSeverity)
+    }
+    return line
+}
+
+// Counts lists the findings by type as TYPE=n, ordered by type
+func (s ReportSummary) Counts() string {
+    parts := make([]string, 0, len(s.ByType))
+    for driftType, n := range s.ByType {
+        parts = append(parts, fmt.Sprintf("%s=%d", driftType, n))
+    }
+    sort.Strings(parts)
+    return strings.Join(parts, " ")
+}
+
+// AggregateSummary condenses the reports of several instances to counts,
+// without their findings
+type AggregateSummary struct {
+    TotalInstances  int               `json:"total_instances"`
+    Drifted         int               `json:"drifted"`
+    Errors          int               `json:"errors"`
+    // Findings, ByType and HighestSeverity cover every instance together
+    Findings        int               `json:"findings"`
+    ByType          map[DriftType]int `json:"by_type,omitempty"`
+    HighestSeverity Severity          `json:"highest_severity,omitempty"`
+    Instances       []ReportSummary   `json:"instances"`
+    Failures        []string          `json:"failures,omitempty"`
+}
+
+// Summary condenses every report and totals the counts
+func (a *AggregateReport) Summary() AggregateSummary {
+    summary := AggregateSummary{
+        TotalInstances: a.TotalInstances,
+        Drifted:        a.Drifted,
+        Errors:         a.Errors,
+        Instances:      make([]ReportSummary, 0, len(a.Reports)),
+        Failures:       a.Failures,
+    }
+
+    var totals ReportSummary
+    for _, report := range a.Reports {
+        instance := report.Summary()
+        summary.Instances = append(summary.Instances, instance)
+        for driftType, n := range instance.ByType {
+            totals.add(driftType, n, instance.HighestSeverity)
+        }
+    }
+    summary.Findings = totals.Findings
+    summary.ByType = totals.ByType
+    summary.HighestSeverity = totals.HighestSeverity
+    return summary
+}
+
+// String describes the totals in one line, e.g. for a report header
+func (s AggregateSummary) String() string {
+    return fmt.Sprintf("Checked %d instance(s), %d with drift, %d error(s)", s.TotalInstances, s.Drifted, s.Errors)
+}
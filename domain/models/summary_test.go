@@ -0,0 +1,79 @@
+package models_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"driftdetector/domain/models"
+)
+
+func newDrift(driftType models.DriftType, path string, severity models.Severity) models.Drift {
+	d := models.NewDrift(driftType, path, "actual", "expected", "Value mismatch")
+	d.Severity = severity
+	return d
+}
+
+func TestDriftReport_Summary(t *testing.T) {
+	t.Run("drifted", func(t *testing.T) {
+		report := models.NewDriftReport("i-1")
+		report.AddDrift(newDrift(models.DriftTypeModified, "Type", models.SeverityWarning))
+		report.AddDrift(newDrift(models.DriftTypeModified, "SecurityGroups", models.SeverityCritical))
+		report.AddDrift(newDrift(models.DriftTypeAdded, ".Tags.Owner", ""))
+
+		summary := report.Summary()
+
+		assert.Equal(t, models.ReportSummary{
+			InstanceID:      "i-1",
+			Drifted:         true,
+			Findings:        3,
+			ByType:          map[models.DriftType]int{models.DriftTypeModified: 2, models.DriftTypeAdded: 1},
+			HighestSeverity: models.SeverityCritical,
+		}, summary)
+		assert.Equal(t, "i-1: drifted, ADDED=1 MODIFIED=2, highest severity CRITICAL", summary.String())
+	})
+
+	t.Run("unclassified findings", func(t *testing.T) {
+		report := models.NewDriftReport("i-1")
+		report.AddDrift(newDrift(models.DriftTypeRemoved, "", ""))
+
+		summary := report.Summary()
+
+		assert.Empty(t, summary.HighestSeverity)
+		assert.Equal(t, "i-1: drifted, REMOVED=1", summary.String())
+	})
+
+	t.Run("no drift", func(t *testing.T) {
+		summary := models.NewDriftReport("i-1").Summary()
+
+		assert.False(t, summary.Drifted)
+		assert.Zero(t, summary.Findings)
+		assert.Empty(t, summary.ByType)
+		assert.Equal(t, "i-1: no drift", summary.String())
+	})
+}
+
+func TestAggregateReport_Summary(t *testing.T) {
+	web := models.NewDriftReport("i-1")
+	web.AddDrift(newDrift(models.DriftTypeModified, "Type", models.SeverityWarning))
+	db := models.NewDriftReport("i-2")
+	db.AddDrift(newDrift(models.DriftTypeModified, "AMI", models.SeverityInfo))
+	db.AddDrift(newDrift(models.DriftTypeRemoved, "EBSBlockDevices", models.SeverityCritical))
+	clean := models.NewDriftReport("i-3")
+
+	summary := models.NewAggregateReport([]*models.DriftReport{web, db, clean}, []string{"i-4: access denied"}).Summary()
+
+	assert.Equal(t, 4, summary.TotalInstances)
+	assert.Equal(t, 2, summary.Drifted)
+	assert.Equal(t, 1, summary.Errors)
+	assert.Equal(t, 3, summary.Findings)
+	assert.Equal(t, map[models.DriftType]int{models.DriftTypeModified: 2, models.DriftTypeRemoved: 1}, summary.ByType)
+	assert.Equal(t, models.SeverityCritical, summary.HighestSeverity)
+	assert.Equal(t, []string{"i-4: access denied"}, summary.Failures)
+	if assert.Len(t, summary.Instances, 3) {
+		assert.Equal(t, "i-1: drifted, MODIFIED=1, highest severity WARNING", summary.Instances[0].String())
+		assert.Equal(t, "i-2: drifted, MODIFIED=1 REMOVED=1, highest severity CRITICAL", summary.Instances[1].String())
+		assert.Equal(t, "i-3: no drift", summary.Instances[2].String())
+	}
+	assert.Equal(t, "Checked 4 instance(s), 2 with drift, 1 error(s)", summary.String())
+}
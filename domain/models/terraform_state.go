@@ -19,6 +19,10 @@ type TerraformState struct {
 	
 	// Resources contains the resources from the Terraform state
 	Resources []TerraformResource `json:"resources"`
+
+	// Warnings name the resources left out of Resources because they could
+	// not be decoded, with the reason
+	Warnings []string `json:"-"`
 }
 
 // TerraformOutput represents a Terraform output
@@ -40,6 +44,8 @@ type TerraformResource struct {
 
 // TerraformResourceInstance represents an instance of a Terraform resource
 type TerraformResourceInstance struct {
+	// IndexKey is the count index or for_each key, nil for a single instance
+	IndexKey      interface{}            `json:"index_key,omitempty"`
 	SchemaVersion int                    `json:"schema_version"`
 	Attributes   map[string]interface{} `json:"attributes"`
 	// Add other fields as needed
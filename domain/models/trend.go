@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// DriftTrend summarizes how an instance's drift evolved over a series of checks
+type DriftTrend struct {
+    InstanceID string      `json:"instance_id"`
+    // Checks is the number of reports the trend was built from
+    Checks     int         `json:"checks"`
+    From       time.Time   `json:"from,omitzero"`
+    To         time.Time   `json:"to,omitzero"`
+    Paths      []PathTrend `json:"paths"`
+}
+
+// PathTrend is the history of one drift path across the checks of a DriftTrend
+type PathTrend struct {
+    Path        string    `json:"path"`
+    // Occurrences counts the checks that reported the path
+    Occurrences int       `json:"occurrences"`
+    FirstSeen   time.Time `json:"first_seen"`
+    LastSeen    time.Time `json:"last_seen"`
+    // Resolved reports whether the path was gone at the latest check
+    Resolved    bool      `json:"resolved"`
+    // DurationSeconds is the total time the path drifted, over all intervals
+    DurationSeconds int64 `json:"duration_seconds"`
+    // Intervals lists the uninterrupted runs of checks that reported the path
+    Intervals   []DriftInterval `json:"intervals"`
+}
+
+// DriftInterval is a run of consecutive checks that reported a drift path
+type DriftInterval struct {
+    FirstSeen  time.Time  `json:"first_seen"`
+    LastSeen   time.Time  `json:"last_seen"`
+    // ResolvedAt is the first check after the run that no longer reported
+    // the path; it is nil while the path still drifts
+    ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+    // DurationSeconds runs from FirstSeen to ResolvedAt, or to LastSeen
+    // while the path still drifts
+    DurationSeconds int64 `json:"duration_seconds"`
+}
+
+// Duration returns how long the path drifted over all intervals
+func (t PathTrend) Duration() time.Duration {
+    return time.Duration(t.DurationSeconds) * time.Second
+}
+
+// Duration returns how long the interval lasted
+func (i DriftInterval) Duration() time.Duration {
+    return time.Duration(i.DurationSeconds) * time.Second
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+    "crypto/sha1"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "regexp"
+    "strings"
+    "unicode/utf8"
+)
+
+// sha1HexPattern matches the SHA-1 digest Terraform stores in state for user_data
+var sha1HexPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// DecodeUserData returns the raw content of user data that may be base64
+// encoded. Values that do not decode to valid UTF-8 are returned unchanged.
+func DecodeUserData(s string) string {
+    trimmed := strings.TrimSpace(s)
+    if trimmed == "" {
+        return ""
+    }
+
+    decoded, err := base64.StdEncoding.DecodeString(trimmed)
+    if err != nil || !utf8.Valid(decoded) {
+        return s
+    }
+    return string(decoded)
+}
+
+// NormalizeUserData decodes base64 user data, converts CRLF line endings and
+// trims trailing whitespace, so that semantically identical scripts compare equal
+func NormalizeUserData(s string) string {
+    content := DecodeUserData(s)
+    content = strings.ReplaceAll(content, "\r\n", "\n")
+    return strings.TrimRight(content, " \t\n")
+}
+
+// IsUserDataHash reports whether s is a SHA-1 digest rather than user data content
+func IsUserDataHash(s string) bool {
+    return sha1HexPattern.MatchString(s)
+}
+
+// UserDataEqual reports whether two user data values have the same content.
+// Either side may be plain text, base64 or the SHA-1 digest Terraform keeps in state.
+func UserDataEqual(a, b string) bool {
+    aHash, bHash := IsUserDataHash(a), IsUserDataHash(b)
+    switch {
+    case aHash && bHash:
+        return a == b
+    case aHash:
+        return matchesUserDataHash(a, b)
+    case bHash:
+        return matchesUserDataHash(b, a)
+    }
+    return UserDataFingerprint(a) == UserDataFingerprint(b)
+}
+
+// matchesUserDataHash compares a state digest with content, which Terraform
+// hashes exactly as written
+func matchesUserDataHash(hash, content string) bool {
+    raw := DecodeUserData(content)
+    for _, candidate := range []string{raw, NormalizeUserData(content), NormalizeUserData(content) + "\n"} {
+        sum := sha1.Sum([]byte(candidate))
+        if hex.EncodeToString(sum[:]) == hash {
+            return true
+        }
+    }
+    return false
+}
+
+// UserDataFingerprint returns a short SHA-256 of the normalized content, used
+// to report user data drift without printing the script itself
+func UserDataFingerprint(s string) string {
+    if IsUserDataHash(s) {
+        return "sha1:" + s[:12]
+    }
+    normalized := NormalizeUserData(s)
+    if normalized == "" {
+        return ""
+    }
+    sum := sha256.Sum256([]byte(normalized))
+    return "sha256:" + hex.EncodeToString(sum[:])[:12]
+}
+
+// NormalizedUserData returns the instance's decoded, normalized user data
+func (i *Instance) NormalizedUserData() string {
+    return NormalizeUserData(i.UserData)
+}
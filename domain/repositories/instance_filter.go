@@ -0,0 +1,217 @@
+package repositories
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"driftdetector/domain/models"
+)
+
+// InstanceFilter narrows the instances returned by InstanceRepository.Find.
+// Empty fields do not filter. An instance must match every field, and a
+// field listing several values accepts any of them.
+type InstanceFilter struct {
+	// Tags maps tag keys to required values; an empty value or "*" matches any value
+	Tags map[string]string
+	// States lists the accepted instance states, e.g. "running"
+	States []string
+	// InstanceTypes lists the accepted instance types, which may use the
+	// wildcards * and ?, e.g. "t3.*"
+	InstanceTypes []string
+	// SubnetIDs lists the accepted subnets
+	SubnetIDs []string
+	// VPCIDs lists the accepted VPCs
+	VPCIDs []string
+	// AvailabilityZones lists the accepted availability zones
+	AvailabilityZones []string
+	// LaunchedBefore and LaunchedAfter bound the launch time when they are
+	// not zero. DescribeInstances cannot filter launch times by range, so
+	// repositories reading from AWS apply them to the instances returned.
+	LaunchedBefore time.Time
+	LaunchedAfter  time.Time
+}
+
+// filterKeys are the keys ParseInstanceFilter accepts, with the field each sets
+var filterKeys = map[string]func(f *InstanceFilter, value string) error{
+	"instance-type": func(f *InstanceFilter, value string) error {
+		if _, err := path.Match(value, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q", value)
+		}
+		f.InstanceTypes = append(f.InstanceTypes, value)
+		return nil
+	},
+	"instance-state": func(f *InstanceFilter, value string) error {
+		switch value {
+		case models.InstanceStatePending, models.InstanceStateRunning, models.InstanceStateShuttingDown,
+			models.InstanceStateTerminated, models.InstanceStateStopping, models.InstanceStateStopped:
+		default:
+			return fmt.Errorf("unknown state %q", value)
+		}
+		f.States = append(f.States, value)
+		return nil
+	},
+	"subnet-id": func(f *InstanceFilter, value string) error {
+		f.SubnetIDs = append(f.SubnetIDs, value)
+		return nil
+	},
+	"vpc-id": func(f *InstanceFilter, value string) error {
+		f.VPCIDs = append(f.VPCIDs, value)
+		return nil
+	},
+	"availability-zone": func(f *InstanceFilter, value string) error {
+		f.AvailabilityZones = append(f.AvailabilityZones, value)
+		return nil
+	},
+	// Repeated bounds narrow the range
+	"launched-before": func(f *InstanceFilter, value string) error {
+		t, err := parseFilterTime(value)
+		if err == nil && (f.LaunchedBefore.IsZero() || t.Before(f.LaunchedBefore)) {
+			f.LaunchedBefore = t
+		}
+		return err
+	},
+	"launched-after": func(f *InstanceFilter, value string) error {
+		t, err := parseFilterTime(value)
+		if err == nil && t.After(f.LaunchedAfter) {
+			f.LaunchedAfter = t
+		}
+		return err
+	},
+}
+
+// tagFilterPrefix starts the keys filtering on a tag, e.g. tag:Environment
+const tagFilterPrefix = "tag:"
+
+// FilterKeys returns the keys ParseInstanceFilter accepts, sorted
+func FilterKeys() []string {
+	keys := make([]string, 0, len(filterKeys)+1)
+	for key := range filterKeys {
+		keys = append(keys, key)
+	}
+	keys = append(keys, tagFilterPrefix+"<key>")
+	sort.Strings(keys)
+	return keys
+}
+
+// ParseInstanceFilter parses expressions of the form key=value, such as
+// instance-type=t3.* or launched-before=2023-01-01, into a filter. A value
+// may list several alternatives separated by commas; tag:<key>=value takes a
+// single value, and an empty value or "*" matches any. Expressions with
+// different keys must all match.
+func ParseInstanceFilter(expressions []string) (InstanceFilter, error) {
+	var filter InstanceFilter
+	for _, expr := range expressions {
+		key, value, ok := strings.Cut(expr, "=")
+		key = strings.TrimSpace(key)
+		if !ok && !strings.HasPrefix(key, tagFilterPrefix) {
+			return InstanceFilter{}, fmt.Errorf("invalid filter %q: expected key=value", expr)
+		}
+
+		if tagKey, isTag := strings.CutPrefix(key, tagFilterPrefix); isTag {
+			if tagKey == "" {
+				return InstanceFilter{}, fmt.Errorf("invalid filter %q: missing tag key", expr)
+			}
+			if filter.Tags == nil {
+				filter.Tags = make(map[string]string)
+			}
+			filter.Tags[tagKey] = value
+			continue
+		}
+
+		set, known := filterKeys[key]
+		if !known {
+			return InstanceFilter{}, fmt.Errorf("invalid filter %q: unknown key %q, expected one of %s", expr, key, strings.Join(FilterKeys(), ", "))
+		}
+		for _, v := range strings.Split(value, ",") {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				return InstanceFilter{}, fmt.Errorf("invalid filter %q: empty value", expr)
+			}
+			if err := set(&filter, v); err != nil {
+				return InstanceFilter{}, fmt.Errorf("invalid filter %q: %w", expr, err)
+			}
+		}
+	}
+	return filter, nil
+}
+
+// parseFilterTime parses a date such as 2023-01-01, which is midnight UTC,
+// or an RFC 3339 time
+func parseFilterTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: expected YYYY-MM-DD or RFC 3339", value)
+	}
+	return t, nil
+}
+
+// Matches reports whether instance matches every field of the filter. An
+// instance without a state counts as running, and one without a launch time
+// does not match launch time bounds.
+func (f InstanceFilter) Matches(instance *models.Instance) bool {
+	for key, want := range f.Tags {
+		got, ok := instance.Tags[key]
+		if !ok || (want != "" && want != "*" && got != want) {
+			return false
+		}
+	}
+
+	state := instance.State
+	if state == "" {
+		state = models.InstanceStateRunning
+	}
+	return matchesAny(f.States, state) &&
+		matchesAnyPattern(f.InstanceTypes, instance.Type) &&
+		matchesAny(f.SubnetIDs, instance.SubnetID) &&
+		matchesAny(f.VPCIDs, instance.VPCID) &&
+		matchesAny(f.AvailabilityZones, instance.AvailabilityZone) &&
+		f.MatchesLaunchTime(instance)
+}
+
+// MatchesLaunchTime reports whether instance was launched within the
+// filter's launch time bounds
+func (f InstanceFilter) MatchesLaunchTime(instance *models.Instance) bool {
+	if f.LaunchedBefore.IsZero() && f.LaunchedAfter.IsZero() {
+		return true
+	}
+	if instance.LaunchTime == nil {
+		return false
+	}
+	if !f.LaunchedBefore.IsZero() && !instance.LaunchTime.Before(f.LaunchedBefore) {
+		return false
+	}
+	return f.LaunchedAfter.IsZero() || instance.LaunchTime.After(f.LaunchedAfter)
+}
+
+// matchesAny reports whether values is empty or holds value
+func matchesAny(values []string, value string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAnyPattern reports whether patterns is empty or one of them
+// matches value
+func matchesAnyPattern(patterns []string, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, value); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package repositories_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+)
+
+func TestParseInstanceFilter(t *testing.T) {
+	t.Run("keys and combinations", func(t *testing.T) {
+		filter, err := repositories.ParseInstanceFilter([]string{
+			"instance-type=t3.*,m5.large",
+			"instance-type=c6i.*",
+			"subnet-id=subnet-abc",
+			"vpc-id=vpc-1",
+			"availability-zone=us-east-1a",
+			"instance-state=stopped",
+			"tag:Environment=prod",
+			"tag:Owner",
+			"launched-before=2023-06-01",
+			"launched-before=2023-01-01",
+			"launched-after=2022-01-01T12:00:00Z",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, repositories.InstanceFilter{
+			Tags:              map[string]string{"Environment": "prod", "Owner": ""},
+			States:            []string{"stopped"},
+			InstanceTypes:     []string{"t3.*", "m5.large", "c6i.*"},
+			SubnetIDs:         []string{"subnet-abc"},
+			VPCIDs:            []string{"vpc-1"},
+			AvailabilityZones: []string{"us-east-1a"},
+			LaunchedBefore:    time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			LaunchedAfter:     time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC),
+		}, filter, "repeated bounds keep the narrowest range")
+	})
+
+	t.Run("no expressions", func(t *testing.T) {
+		filter, err := repositories.ParseInstanceFilter(nil)
+
+		require.NoError(t, err)
+		assert.Equal(t, repositories.InstanceFilter{}, filter)
+	})
+
+	invalid := map[string]string{
+		"unknown key":   "image-id=ami-1",
+		"missing value": "instance-type",
+		"empty value":   "subnet-id=",
+		"bad state":     "instance-state=asleep",
+		"bad time":      "launched-before=yesterday",
+		"bad pattern":   "instance-type=t3.[",
+		"empty tag key": "tag:=prod",
+	}
+	for name, expr := range invalid {
+		t.Run(name, func(t *testing.T) {
+			_, err := repositories.ParseInstanceFilter([]string{expr})
+
+			assert.ErrorContains(t, err, expr)
+		})
+	}
+
+	t.Run("unknown keys list the supported ones", func(t *testing.T) {
+		_, err := repositories.ParseInstanceFilter([]string{"image-id=ami-1"})
+
+		assert.ErrorContains(t, err, "availability-zone, instance-state, instance-type, launched-after, launched-before, subnet-id, tag:<key>, vpc-id")
+	})
+}
+
+func TestInstanceFilter_Matches(t *testing.T) {
+	launched := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	instance := models.NewInstance("i-1", "t3.large", "ami-1")
+	instance.SubnetID = "subnet-abc"
+	instance.VPCID = "vpc-1"
+	instance.AvailabilityZone = "us-east-1a"
+	instance.LaunchTime = &launched
+	instance.AddTag("Environment", "prod")
+
+	tests := []struct {
+		name    string
+		filter  repositories.InstanceFilter
+		matches bool
+	}{
+		{"empty filter", repositories.InstanceFilter{}, true},
+		{"type pattern", repositories.InstanceFilter{InstanceTypes: []string{"m5.*", "t3.*"}}, true},
+		{"other type", repositories.InstanceFilter{InstanceTypes: []string{"t3.micro"}}, false},
+		{"subnet", repositories.InstanceFilter{SubnetIDs: []string{"subnet-abc"}}, true},
+		{"other subnet", repositories.InstanceFilter{SubnetIDs: []string{"subnet-xyz"}}, false},
+		{"other VPC", repositories.InstanceFilter{VPCIDs: []string{"vpc-2"}}, false},
+		{"other zone", repositories.InstanceFilter{AvailabilityZones: []string{"us-east-1b"}}, false},
+		{"no state counts as running", repositories.InstanceFilter{States: []string{models.InstanceStateRunning}}, true},
+		{"tag with any value", repositories.InstanceFilter{Tags: map[string]string{"Environment": "*"}}, true},
+		{"other tag value", repositories.InstanceFilter{Tags: map[string]string{"Environment": "dev"}}, false},
+		{"launched before", repositories.InstanceFilter{LaunchedBefore: launched.AddDate(0, 0, 1)}, true},
+		{"launched at the bound", repositories.InstanceFilter{LaunchedBefore: launched}, false},
+		{"launched after", repositories.InstanceFilter{LaunchedAfter: launched.AddDate(0, 0, -1)}, true},
+		{"launched before the range", repositories.InstanceFilter{LaunchedAfter: launched.AddDate(0, 0, 1)}, false},
+		{"all fields", repositories.InstanceFilter{
+			InstanceTypes:  []string{"t3.*"},
+			SubnetIDs:      []string{"subnet-abc"},
+			Tags:           map[string]string{"Environment": "prod"},
+			LaunchedBefore: launched.AddDate(1, 0, 0),
+		}, true},
+		{"one field fails", repositories.InstanceFilter{
+			InstanceTypes: []string{"t3.*"},
+			SubnetIDs:     []string{"subnet-xyz"},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.matches, tt.filter.Matches(instance))
+		})
+	}
+
+	t.Run("without a launch time", func(t *testing.T) {
+		unlaunched := models.NewInstance("i-2", "t3.large", "ami-1")
+
+		assert.False(t, repositories.InstanceFilter{LaunchedAfter: launched}.Matches(unlaunched))
+		assert.True(t, repositories.InstanceFilter{InstanceTypes: []string{"t3.*"}}.Matches(unlaunched))
+	})
+}
@@ -2,9 +2,13 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"driftdetector/domain/models"
 )
 
+// ErrInstanceNotFound is returned (possibly wrapped) when an instance does not exist
+var ErrInstanceNotFound = errors.New("instance not found")
+
 // InstanceRepository defines the interface for instance persistence operations
 type InstanceRepository interface {
 	// GetByID retrieves an instance by its ID
@@ -16,6 +20,12 @@ type InstanceRepository interface {
 	// FindAll retrieves all instances (with pagination support if needed)
 	FindAll(ctx context.Context) ([]*models.Instance, error)
 	
+	// Find retrieves the instances matching filter
+	Find(ctx context.Context, filter InstanceFilter) ([]*models.Instance, error)
+	
+	// FindByTag retrieves the running instances whose tag key has value
+	FindByTag(ctx context.Context, key, value string) ([]*models.Instance, error)
+	
 	// Save persists an instance
 	Save(ctx context.Context, instance *models.Instance) error
 	
@@ -32,6 +42,13 @@ type DriftDetectionRepository interface {
 	GetDriftHistory(instanceID string, limit int) ([]*models.DriftReport, error)
 }
 
+// DriftHistoryRepository defines the interface for reading drift reports kept from earlier checks
+type DriftHistoryRepository interface {
+	// GetDriftHistory returns up to limit of the instance's most recent
+	// reports, oldest first; a limit of 0 or less returns all of them
+	GetDriftHistory(instanceID string, limit int) ([]*models.DriftReport, error)
+}
+
 // TerraformStateRepository defines the interface for accessing Terraform state
 type TerraformStateRepository interface {
 	// GetInstanceConfigs extracts instance configurations from Terraform state
@@ -40,3 +57,50 @@ type TerraformStateRepository interface {
 	// GetInstanceConfigsFromDir extracts instance configurations from Terraform directory
 	GetInstanceConfigsFromDir(ctx context.Context, dir string) ([]*models.Instance, error)
 }
+
+// SecurityGroupRepository defines the interface for reading security groups from the cloud provider
+type SecurityGroupRepository interface {
+	// GetByIDs retrieves the configuration and rules of the given security groups
+	GetByIDs(ctx context.Context, ids []string) ([]*models.SecurityGroupConfig, error)
+}
+
+// ImageRepository defines the interface for reading AMIs from the cloud provider
+type ImageRepository interface {
+	// GetByIDs retrieves the given images; images that no longer exist are left out
+	GetByIDs(ctx context.Context, ids []string) ([]*models.Image, error)
+}
+
+// SubnetRepository defines the interface for reading VPC subnets from the cloud provider
+type SubnetRepository interface {
+	// GetByIDs retrieves the given subnets; subnets that no longer exist are left out
+	GetByIDs(ctx context.Context, ids []string) ([]*models.Subnet, error)
+}
+
+// ElasticIPRepository defines the interface for reading Elastic IP addresses from the cloud provider
+type ElasticIPRepository interface {
+	// FindAll retrieves every Elastic IP address and its association
+	FindAll(ctx context.Context) ([]*models.ElasticIP, error)
+}
+
+// SecurityGroupStateRepository is implemented by Terraform state repositories
+// that can also extract aws_security_group resources
+type SecurityGroupStateRepository interface {
+	// GetSecurityGroupConfigs extracts security group configurations from Terraform state
+	GetSecurityGroupConfigs(ctx context.Context, statePath string) ([]*models.SecurityGroupConfig, error)
+}
+
+// ElasticIPStateRepository is implemented by Terraform state repositories
+// that can also extract aws_eip and aws_eip_association resources
+type ElasticIPStateRepository interface {
+	// GetElasticIPConfigs extracts the Elastic IP addresses and the instances
+	// they are expected to be associated with from Terraform state
+	GetElasticIPConfigs(ctx context.Context, statePath string) ([]*models.ElasticIP, error)
+}
+
+// PlanRepository is implemented by Terraform repositories that can read the
+// expected configuration from a plan rendered with terraform show -json
+type PlanRepository interface {
+	// GetInstanceConfigsFromPlan extracts the planned instance configurations,
+	// skipping instances the plan destroys
+	GetInstanceConfigsFromPlan(ctx context.Context, planPath string) ([]*models.Instance, error)
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"fmt"
+
+	"driftdetector/domain/models"
+)
+
+// amiPath is the drift path of the instance's image ID
+const amiPath = "AMI"
+
+// DriftedAMIs returns the actual and expected image IDs of the AMI finding
+// in report, if there is one
+func DriftedAMIs(report *models.DriftReport) (actual, expected string, ok bool) {
+	for _, d := range report.Drifts {
+		if d.Path != amiPath || d.Type != models.DriftTypeModified {
+			continue
+		}
+		actual, _ = d.Actual.(string)
+		expected, _ = d.Expected.(string)
+		return actual, expected, actual != "" && expected != ""
+	}
+	return "", "", false
+}
+
+// ResolveAMIDrift replaces the AMI finding of report, which compares image
+// IDs, with findings for the attributes in which the two images differ: the
+// name without its build stamp, the owner, the architecture and the value of
+// tagKey when it is not empty. A rebaked but equivalent image leaves no
+// finding. The ID comparison is kept, with a warning, when either image is
+// missing from images.
+func ResolveAMIDrift(report *models.DriftReport, images []*models.Image, tagKey string) {
+	actualID, expectedID, ok := DriftedAMIs(report)
+	if !ok {
+		return
+	}
+
+	byID := make(map[string]*models.Image, len(images))
+	for _, image := range images {
+		byID[image.ID] = image
+	}
+	actual, expected := byID[actualID], byID[expectedID]
+	if actual == nil || expected == nil {
+		missing := actualID
+		if actual != nil {
+			missing = expectedID
+		}
+		report.AddWarning(fmt.Sprintf("AMI %s could not be described, so AMIs are compared by ID", missing))
+		return
+	}
+
+	drifts := make([]models.Drift, 0, len(report.Drifts))
+	for _, d := range report.Drifts {
+		if d.Path == amiPath && d.Type == models.DriftTypeModified {
+			drifts = append(drifts, compareImages(actual, expected, tagKey, d.Source)...)
+			continue
+		}
+		drifts = append(drifts, d)
+	}
+	report.Drifts = drifts
+	report.HasDrift = models.ContainsDrift(drifts)
+}
+
+// compareImages returns a finding for each compared attribute in which
+// actual and expected differ
+func compareImages(actual, expected *models.Image, tagKey string, source *models.SourceLocation) []models.Drift {
+	replaced := fmt.Sprintf("AMI %s replaced %s", actual.ID, expected.ID)
+
+	var drifts []models.Drift
+	add := func(path string, actualValue, expectedValue interface{}, description string) {
+		drift := models.NewDrift(models.DriftTypeModified, path, actualValue, expectedValue, replaced+" "+description)
+		drift.Source = source
+		drifts = append(drifts, drift)
+	}
+
+	if actual.NameStem() != expected.NameStem() {
+		add(amiPath+".Name", actual.Name, expected.Name, "and was built from a different image name")
+	}
+	if actual.OwnerID != expected.OwnerID {
+		add(amiPath+".OwnerID", actual.OwnerID, expected.OwnerID, "and has a different owner")
+	}
+	if actual.Architecture != expected.Architecture {
+		add(amiPath+".Architecture", actual.Architecture, expected.Architecture, "and has a different architecture")
+	}
+	if tagKey != "" && actual.Tags[tagKey] != expected.Tags[tagKey] {
+		add(amiPath+".Tags."+tagKey, actual.Tags[tagKey], expected.Tags[tagKey], fmt.Sprintf("and has a different %s tag", tagKey))
+	}
+	return drifts
+}
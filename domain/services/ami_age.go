@@ -0,0 +1,34 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"driftdetector/domain/models"
+)
+
+// CheckAMIAge adds a STALE_AMI finding to report when image, the AMI the
+// instance runs, was created more than maxAge before now, and another when
+// it was deprecated by now. The findings are advisory, so they leave
+// HasDrift unchanged; they are WARNING whatever the severity rules say.
+func CheckAMIAge(report *models.DriftReport, image *models.Image, maxAge time.Duration, now time.Time) {
+	if image == nil {
+		return
+	}
+
+	add := func(actual interface{}, description string) {
+		drift := models.NewDrift(models.DriftTypeStaleAMI, amiPath, actual, nil, description)
+		drift.Severity = models.SeverityWarning
+		report.AddAdvisory(drift)
+	}
+
+	if image.CreationDate != nil && maxAge > 0 {
+		if age := now.Sub(*image.CreationDate); age > maxAge {
+			add(image.CreationDate.UTC().Format(time.DateOnly), fmt.Sprintf("AMI %s is %d days old", image.ID, int(age.Hours()/24)))
+		}
+	}
+	if image.DeprecationTime != nil && !image.DeprecationTime.After(now) {
+		deprecated := image.DeprecationTime.UTC().Format(time.DateOnly)
+		add(deprecated, fmt.Sprintf("AMI %s was deprecated on %s", image.ID, deprecated))
+	}
+}
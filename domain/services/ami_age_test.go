@@ -0,0 +1,87 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestCheckAMIAge(t *testing.T) {
+	now := time.Date(2024, 9, 1, 0, 0, 0, 0, time.UTC)
+	at := func(year int, month time.Month, day int) *time.Time {
+		t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	t.Run("an old AMI is an advisory finding", func(t *testing.T) {
+		// Given an AMI created 123 days ago
+		report := models.NewDriftReport("i-1")
+		image := &models.Image{ID: "ami-1", CreationDate: at(2024, 5, 1)}
+
+		// When it is checked against 90 days
+		services.CheckAMIAge(report, image, 90*24*time.Hour, now)
+
+		// Then its age is noted without making the report drifted
+		require.Len(t, report.Drifts, 1)
+		drift := report.Drifts[0]
+		assert.Equal(t, models.DriftTypeStaleAMI, drift.Type)
+		assert.Equal(t, "AMI", drift.Path)
+		assert.Equal(t, "2024-05-01", drift.Actual)
+		assert.Equal(t, "AMI ami-1 is 123 days old", drift.Description)
+		assert.Equal(t, models.SeverityWarning, drift.Severity)
+		assert.False(t, report.HasDrift)
+		assert.Len(t, report.StaleAMIs(), 1)
+	})
+
+	t.Run("a fresh AMI is not noted", func(t *testing.T) {
+		report := models.NewDriftReport("i-1")
+		image := &models.Image{ID: "ami-1", CreationDate: at(2024, 8, 1)}
+
+		services.CheckAMIAge(report, image, 90*24*time.Hour, now)
+
+		assert.Empty(t, report.Drifts)
+	})
+
+	t.Run("a deprecated AMI is noted whatever its age", func(t *testing.T) {
+		report := models.NewDriftReport("i-1")
+		image := &models.Image{ID: "ami-1", CreationDate: at(2024, 8, 1), DeprecationTime: at(2024, 8, 15)}
+
+		services.CheckAMIAge(report, image, 90*24*time.Hour, now)
+
+		require.Len(t, report.Drifts, 1)
+		assert.Equal(t, "AMI ami-1 was deprecated on 2024-08-15", report.Drifts[0].Description)
+		assert.False(t, report.HasDrift)
+	})
+
+	t.Run("a deprecation to come is not noted", func(t *testing.T) {
+		report := models.NewDriftReport("i-1")
+		image := &models.Image{ID: "ami-1", CreationDate: at(2024, 8, 1), DeprecationTime: at(2025, 8, 1)}
+
+		services.CheckAMIAge(report, image, 90*24*time.Hour, now)
+
+		assert.Empty(t, report.Drifts)
+	})
+
+	t.Run("filtering keeps a stale AMI advisory", func(t *testing.T) {
+		// Given a report with INFO drift and a stale AMI
+		report := models.NewDriftReport("i-1")
+		drift := models.NewDrift(models.DriftTypeModified, "KeyName", "a", "b", "Value mismatch")
+		drift.Severity = models.SeverityInfo
+		report.AddDrift(drift)
+		services.CheckAMIAge(report, &models.Image{ID: "ami-1", CreationDate: at(2020, 1, 1)}, 90*24*time.Hour, now)
+
+		// When only warnings are kept
+		warnings := report.FilterBySeverity(models.SeverityWarning)
+
+		// Then the stale AMI left is not drift
+		assert.True(t, report.HasDrift)
+		require.Len(t, warnings.Drifts, 1)
+		assert.Equal(t, models.DriftTypeStaleAMI, warnings.Drifts[0].Type)
+		assert.False(t, warnings.HasDrift)
+	})
+}
@@ -0,0 +1,80 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestResolveAMIDrift(t *testing.T) {
+	// amiReport compares an instance running ami-new with one expected to run ami-old
+	amiReport := func() *models.DriftReport {
+		actual := models.NewInstance("i-1", "t3.micro", "ami-new")
+		desired := models.NewInstance("i-1", "t3.micro", "ami-old")
+		desired.KeyName = "deploy"
+		return services.NewDriftDetector().CompareInstances(actual, desired)
+	}
+	baked := func(id, name, version string) *models.Image {
+		return &models.Image{ID: id, Name: name, OwnerID: "123456789012", Architecture: "x86_64", Tags: map[string]string{"app_version": version}}
+	}
+
+	t.Run("rebaked image is not drift", func(t *testing.T) {
+		report := amiReport()
+
+		services.ResolveAMIDrift(report, []*models.Image{
+			baked("ami-new", "web-2024-06-01T0930", "1.4.2"),
+			baked("ami-old", "web-2024-05-01T1200", "1.4.2"),
+		}, "app_version")
+
+		require.Len(t, report.Drifts, 1)
+		assert.Equal(t, "KeyName", report.Drifts[0].Path, "other findings are kept")
+	})
+
+	t.Run("differing attributes replace the ID finding", func(t *testing.T) {
+		report := amiReport()
+		actual := baked("ami-new", "api-2024-06-01", "1.5.0")
+		actual.Architecture = "arm64"
+
+		services.ResolveAMIDrift(report, []*models.Image{actual, baked("ami-old", "web-2024-05-01", "1.4.2")}, "app_version")
+
+		drifts := make(map[string]models.Drift)
+		for _, d := range report.Drifts {
+			drifts[d.Path] = d
+		}
+		assert.NotContains(t, drifts, "AMI")
+		assert.Contains(t, drifts, "KeyName")
+		require.Contains(t, drifts, "AMI.Name")
+		assert.Equal(t, "api-2024-06-01", drifts["AMI.Name"].Actual)
+		assert.Contains(t, drifts["AMI.Name"].Description, "ami-new replaced ami-old")
+		assert.Contains(t, drifts, "AMI.Architecture")
+		require.Contains(t, drifts, "AMI.Tags.app_version")
+		assert.Equal(t, "1.4.2", drifts["AMI.Tags.app_version"].Expected)
+		assert.NotContains(t, drifts, "AMI.OwnerID")
+	})
+
+	t.Run("tag is only compared when configured", func(t *testing.T) {
+		report := amiReport()
+
+		services.ResolveAMIDrift(report, []*models.Image{
+			baked("ami-new", "web-2", "1.5.0"),
+			baked("ami-old", "web-1", "1.4.2"),
+		}, "")
+
+		require.Len(t, report.Drifts, 1)
+		assert.Equal(t, "KeyName", report.Drifts[0].Path)
+	})
+
+	t.Run("image that cannot be described keeps the ID finding", func(t *testing.T) {
+		report := amiReport()
+
+		services.ResolveAMIDrift(report, []*models.Image{baked("ami-new", "web-2", "1.4.2")}, "app_version")
+
+		assert.Len(t, report.Drifts, 2)
+		require.Len(t, report.Warnings, 1)
+		assert.Contains(t, report.Warnings[0], "ami-old")
+	})
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"driftdetector/domain/models"
+)
+
+// Comparer decides whether an actual and an expected value are equal. When
+// they are not, detail explains how they differ and becomes the description
+// of the finding.
+type Comparer func(actual, expected interface{}) (equal bool, detail string)
+
+// pathComparer is a Comparer registered for a field path
+type pathComparer struct {
+	segments []string
+	fn       Comparer
+}
+
+// WithComparer compares the fields matching pathGlob with fn
+func WithComparer(pathGlob string, fn Comparer) DetectorOption {
+	return func(d *DriftDetector) error {
+		return d.RegisterComparer(pathGlob, fn)
+	}
+}
+
+// RegisterComparer compares the fields matching pathGlob with fn instead of
+// by equality. pathGlob is written like an ignore path, e.g. "Tags[Name]" or
+// "EBSBlockDevices[*].VolumeSize", and must match the whole path of a field.
+// The first comparer registered for a path applies.
+func (d *DriftDetector) RegisterComparer(pathGlob string, fn Comparer) error {
+	if fn == nil {
+		return fmt.Errorf("comparer for %q is nil", pathGlob)
+	}
+	segments, err := parseFieldPath(pathGlob)
+	if err != nil {
+		return err
+	}
+	d.comparers = append(d.comparers, pathComparer{segments: segments, fn: fn})
+	return nil
+}
+
+// comparerFor returns the comparer registered for the field at segments, or nil
+func (d *DriftDetector) comparerFor(segments []string) Comparer {
+	for _, c := range d.comparers {
+		if len(c.segments) == len(segments) && matchSegments(c.segments, segments) {
+			return c.fn
+		}
+	}
+	return nil
+}
+
+// compareCustom compares a field with its registered comparer, recording a
+// finding at path when the comparer reports a difference. It returns false
+// when no comparer is registered for the field.
+func (d *DriftDetector) compareCustom(path string, segments []string, actual, expected interface{}, report *models.DriftReport) bool {
+	fn := d.comparerFor(segments)
+	if fn == nil {
+		return false
+	}
+
+	if equal, detail := fn(actual, expected); !equal {
+		if detail == "" {
+			detail = "Value mismatch"
+		}
+		report.AddDrift(models.NewDrift(models.DriftTypeModified, path, actual, expected, detail))
+	}
+	return true
+}
+
+// CaseInsensitive is a Comparer treating strings that differ only in case as
+// equal. Other values are compared by equality.
+func CaseInsensitive(actual, expected interface{}) (bool, string) {
+	a, aok := actual.(string)
+	e, eok := expected.(string)
+	if !aok || !eok {
+		return reflect.DeepEqual(actual, expected), ""
+	}
+	if strings.EqualFold(a, e) {
+		return true, ""
+	}
+	return false, fmt.Sprintf("Value mismatch: %q and %q differ ignoring case", a, e)
+}
+
+// NumericTolerance returns a Comparer treating numbers, or strings holding
+// numbers, as equal when they differ by at most tolerance. Other values are
+// compared by equality.
+func NumericTolerance(tolerance float64) Comparer {
+	return func(actual, expected interface{}) (bool, string) {
+		a, aok := toFloat(actual)
+		e, eok := toFloat(expected)
+		if !aok || !eok {
+			return reflect.DeepEqual(actual, expected), ""
+		}
+		diff := a - e
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff <= tolerance {
+			return true, ""
+		}
+		return false, fmt.Sprintf("Value differs by %s, more than the tolerance of %s",
+			strconv.FormatFloat(diff, 'f', -1, 64), strconv.FormatFloat(tolerance, 'f', -1, 64))
+	}
+}
+
+// NamedComparer returns a built-in comparer by name: "ci" compares strings
+// case-insensitively and "tolerance:N" compares numbers within N of each other
+func NamedComparer(name string) (Comparer, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "ci":
+		return CaseInsensitive, nil
+	case strings.HasPrefix(name, "tolerance:"):
+		tolerance, err := strconv.ParseFloat(strings.TrimPrefix(name, "tolerance:"), 64)
+		if err != nil || tolerance < 0 {
+			return nil, fmt.Errorf("invalid comparer %q: tolerance must be a non-negative number", name)
+		}
+		return NumericTolerance(tolerance), nil
+	default:
+		return nil, fmt.Errorf("unknown comparer %q (expected ci or tolerance:N)", name)
+	}
+}
+
+// toFloat converts a number, or a string holding one, to a float64
+func toFloat(value interface{}) (float64, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v.String()), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
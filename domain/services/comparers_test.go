@@ -0,0 +1,123 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestDriftDetector_Comparers(t *testing.T) {
+	newPair := func() (*models.Instance, *models.Instance) {
+		actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+		actual.AvailabilityZone = "US-EAST-1A"
+		actual.RootVolumeSize = 102
+		actual.AddTag("Owner", "Platform")
+		actual.EBSBlockDevices = []models.EBSBlockDevice{{DeviceName: "/dev/sdf", VolumeSize: 51}}
+
+		desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+		desired.AvailabilityZone = "us-east-1a"
+		desired.RootVolumeSize = 100
+		desired.AddTag("Owner", "platform")
+		desired.EBSBlockDevices = []models.EBSBlockDevice{{DeviceName: "/dev/sdf", VolumeSize: 50}}
+		return actual, desired
+	}
+
+	tests := []struct {
+		name     string
+		opts     []services.DetectorOption
+		expected []string
+	}{
+		{
+			name:     "no comparers",
+			expected: []string{"AvailabilityZone", "RootVolumeSize", "Tags.Owner", "EBSBlockDevices[/dev/sdf].VolumeSize"},
+		},
+		{
+			name: "case-insensitive fields and map keys",
+			opts: []services.DetectorOption{
+				services.WithComparer("AvailabilityZone", services.CaseInsensitive),
+				services.WithComparer("Tags[*]", services.CaseInsensitive),
+			},
+			expected: []string{"RootVolumeSize", "EBSBlockDevices[/dev/sdf].VolumeSize"},
+		},
+		{
+			name: "numeric tolerance with a glob",
+			opts: []services.DetectorOption{
+				services.WithComparer("RootVolumeSize", services.NumericTolerance(1)),
+				services.WithComparer("EBSBlockDevices[/dev/*].VolumeSize", services.NumericTolerance(1)),
+			},
+			expected: []string{"AvailabilityZone", "RootVolumeSize", "Tags.Owner"},
+		},
+		{
+			name:     "a comparer only matches whole paths",
+			opts:     []services.DetectorOption{services.WithComparer("*VolumeSize", services.NumericTolerance(10))},
+			expected: []string{"AvailabilityZone", "Tags.Owner", "EBSBlockDevices[/dev/sdf].VolumeSize"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector, err := services.NewDriftDetectorWithOptions(tt.opts...)
+			require.NoError(t, err)
+
+			report := detector.CompareInstances(newPair())
+
+			assert.ElementsMatch(t, tt.expected, driftPaths(report))
+		})
+	}
+}
+
+func TestDriftDetector_ComparerDetail(t *testing.T) {
+	detector, err := services.NewDriftDetectorWithOptions(
+		services.WithComparer("RootVolumeSize", services.NumericTolerance(1)),
+		services.WithComparer("KeyName", func(actual, expected interface{}) (bool, string) {
+			return false, ""
+		}),
+	)
+	require.NoError(t, err)
+
+	actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+	actual.RootVolumeSize = 110
+	desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+	desired.RootVolumeSize = 100
+
+	report := detector.CompareInstances(actual, desired)
+
+	require.Len(t, report.Drifts, 2)
+	descriptions := map[string]string{}
+	for _, d := range report.Drifts {
+		descriptions[d.Path] = d.Description
+	}
+	assert.Equal(t, "Value differs by 10, more than the tolerance of 1", descriptions["RootVolumeSize"])
+	assert.Equal(t, "Value mismatch", descriptions["KeyName"], "an empty detail falls back to the default description")
+}
+
+func TestNamedComparer(t *testing.T) {
+	ci, err := services.NamedComparer("ci")
+	require.NoError(t, err)
+	equal, _ := ci("Web", "web")
+	assert.True(t, equal)
+
+	tolerance, err := services.NamedComparer("tolerance:0.5")
+	require.NoError(t, err)
+	equal, _ = tolerance("10.4", 10)
+	assert.True(t, equal, "numbers held in strings are compared as numbers")
+	equal, detail := tolerance(11, 10)
+	assert.False(t, equal)
+	assert.Contains(t, detail, "tolerance of 0.5")
+
+	for _, name := range []string{"exact", "tolerance:", "tolerance:-1"} {
+		_, err := services.NamedComparer(name)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestRegisterComparer_Invalid(t *testing.T) {
+	detector := services.NewDriftDetector()
+
+	assert.Error(t, detector.RegisterComparer("Tags[Name", services.CaseInsensitive))
+	assert.Error(t, detector.RegisterComparer("KeyName", nil))
+}
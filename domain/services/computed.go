@@ -0,0 +1,94 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"driftdetector/domain/models"
+)
+
+// defaultIgnoredFields are computed by AWS and almost never declared in
+// Terraform, so comparing them would report drift on every instance
+var defaultIgnoredFields = []string{
+	"PublicIPAddress",
+	"PrivateDNSName",
+	"PublicDNSName",
+	"HostID",
+}
+
+// awsDefaultValues are the values AWS reports for settings left unset at
+// launch. An unset expected value equals its default unless the detector is
+// created WithStrict.
+var awsDefaultValues = map[string]string{
+	"InstanceInitiatedShutdownBehavior": "stop",
+}
+
+// ListDefaultIgnoredFields returns the fields a detector skips unless it is
+// created WithStrict or the field is named in WithComparedFields
+func ListDefaultIgnoredFields() []string {
+	return append([]string(nil), defaultIgnoredFields...)
+}
+
+// WithStrict compares every field, including those skipped by default, and
+// reports an unset setting that AWS reports at its default value
+func WithStrict() DetectorOption {
+	return func(d *DriftDetector) error {
+		d.computedFields = nil
+		d.awsDefaults = nil
+		d.volumeDefaults = nil
+		return nil
+	}
+}
+
+// WithComparedFields compares the named fields although they are skipped by
+// default. Naming a field that is not skipped by default is an error.
+func WithComparedFields(fields ...string) DetectorOption {
+	return func(d *DriftDetector) error {
+		for _, field := range fields {
+			if !isDefaultIgnored(field) {
+				return fmt.Errorf("cannot compare %s: it is not skipped by default (skipped: %s)",
+					field, strings.Join(defaultIgnoredFields, ", "))
+			}
+			delete(d.computedFields, field)
+		}
+		return nil
+	}
+}
+
+// computedFieldSet returns defaultIgnoredFields as a set
+func computedFieldSet() map[string]bool {
+	set := make(map[string]bool, len(defaultIgnoredFields))
+	for _, f := range defaultIgnoredFields {
+		set[f] = true
+	}
+	return set
+}
+
+// isDefaultIgnored reports whether field is in defaultIgnoredFields
+func isDefaultIgnored(field string) bool {
+	for _, f := range defaultIgnoredFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// applyAWSDefaults returns desired with each unset field in awsDefaults set
+// to its default when actual reports that default
+func (d *DriftDetector) applyAWSDefaults(actual, desired *models.Instance) *models.Instance {
+	resolved := desired
+	actualVal := reflect.ValueOf(actual).Elem()
+	for name, value := range d.awsDefaults {
+		if reflect.ValueOf(resolved).Elem().FieldByName(name).String() != "" || actualVal.FieldByName(name).String() != value {
+			continue
+		}
+		if resolved == desired {
+			c := *desired
+			resolved = &c
+		}
+		reflect.ValueOf(resolved).Elem().FieldByName(name).SetString(value)
+	}
+	return resolved
+}
@@ -0,0 +1,69 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func computedPair() (*models.Instance, *models.Instance) {
+	actual := models.NewInstance("i-1", "t3.large", "ami-1")
+	actual.PublicIPAddress = "203.0.113.10"
+	actual.PublicDNSName = "ec2-203-0-113-10.compute-1.amazonaws.com"
+	actual.PrivateDNSName = "ip-10-0-0-5.ec2.internal"
+	actual.HostID = "h-0123456789abcdef0"
+
+	desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+	return actual, desired
+}
+
+func TestDriftDetector_DefaultIgnoredFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []services.DetectorOption
+		paths []string
+	}{
+		{
+			name:  "computed fields skipped by default",
+			paths: []string{"Type"},
+		},
+		{
+			name:  "strict compares every field",
+			opts:  []services.DetectorOption{services.WithStrict()},
+			paths: []string{"HostID", "PrivateDNSName", "PublicDNSName", "PublicIPAddress", "Type"},
+		},
+		{
+			name:  "one field compared again",
+			opts:  []services.DetectorOption{services.WithComparedFields("PublicIPAddress")},
+			paths: []string{"PublicIPAddress", "Type"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector, err := services.NewDriftDetectorWithOptions(tt.opts...)
+			require.NoError(t, err)
+
+			actual, desired := computedPair()
+			assert.Equal(t, tt.paths, driftPaths(detector.CompareInstances(actual, desired)))
+		})
+	}
+}
+
+func TestWithComparedFields_NotSkipped(t *testing.T) {
+	_, err := services.NewDriftDetectorWithOptions(services.WithComparedFields("AMI"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "PublicIPAddress")
+}
+
+func TestListDefaultIgnoredFields(t *testing.T) {
+	fields := services.ListDefaultIgnoredFields()
+	assert.Contains(t, fields, "PublicIPAddress")
+
+	fields[0] = "changed"
+	assert.NotContains(t, services.ListDefaultIgnoredFields(), "changed", "callers get a copy")
+}
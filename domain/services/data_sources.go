@@ -0,0 +1,49 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"driftdetector/domain/models"
+)
+
+// unverifiableFields returns desired with every field in its UnresolvedFields
+// or UnresolvedRefs taken from actual, and notes each of them in report.
+// Their expected values come from data sources that could not be read or
+// resources that could not be resolved, so comparing them would report drift
+// against an empty value or hide it without a trace.
+func unverifiableFields(actual, desired *models.Instance, report *models.DriftReport) *models.Instance {
+	if len(desired.UnresolvedFields) == 0 && len(desired.UnresolvedRefs) == 0 {
+		return desired
+	}
+
+	names := make([]string, 0, len(desired.UnresolvedFields)+len(desired.UnresolvedRefs))
+	for name := range desired.UnresolvedFields {
+		names = append(names, name)
+	}
+	for name := range desired.UnresolvedRefs {
+		if _, ok := desired.UnresolvedFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	resolved := *desired
+	actualVal := reflect.ValueOf(actual).Elem()
+	resolvedVal := reflect.ValueOf(&resolved).Elem()
+	for _, name := range names {
+		field := resolvedVal.FieldByName(name)
+		if field.IsValid() && field.CanSet() {
+			field.Set(actualVal.FieldByName(name))
+		}
+		if source, ok := desired.UnresolvedFields[name]; ok {
+			report.AddWarning(fmt.Sprintf("%s is unverifiable: %s could not be resolved", name, source))
+		} else {
+			report.AddWarning(fmt.Sprintf("%s is unresolved: %s not found in Terraform state", name, strings.Join(desired.UnresolvedRefs[name], ", ")))
+		}
+	}
+
+	return &resolved
+}
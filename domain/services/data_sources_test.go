@@ -0,0 +1,60 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestDriftDetector_UnresolvedFields(t *testing.T) {
+	actual := models.NewInstance("i-1", "t3.micro", "ami-0abc")
+	desired := models.NewInstance("i-1", "t3.small", "")
+	desired.UnresolvedFields = map[string]string{"AMI": "data.aws_ami.ubuntu"}
+
+	report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+	assert.Equal(t, []string{"Type"}, driftPaths(report), "the AMI is not compared against an empty value")
+	assert.Equal(t, []string{"AMI is unverifiable: data.aws_ami.ubuntu could not be resolved"}, report.Warnings)
+	assert.Empty(t, desired.AMI, "the desired instance is not modified")
+}
+
+func TestDriftDetector_UnresolvedRefs(t *testing.T) {
+	actual := models.NewInstance("i-1", "t3.micro", "ami-0abc")
+	actual.SecurityGroups = []models.SecurityGroup{{GroupID: "sg-web"}}
+	desired := models.NewInstance("i-1", "t3.micro", "ami-0abc")
+	desired.UnresolvedRefs = map[string][]string{"SecurityGroups": {"aws_security_group.web.id"}}
+
+	report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+	assert.Empty(t, driftPaths(report), "the security groups are not reported as added")
+	assert.Equal(t, []string{"SecurityGroups is unresolved: aws_security_group.web.id not found in Terraform state"}, report.Warnings)
+}
+
+func TestDriftDetector_ParseWarnings(t *testing.T) {
+	actual := models.NewInstance("i-1", "t3.micro", "ami-0abc")
+	desired := models.NewInstance("i-1", "t3.micro", "ami-0abc")
+	desired.ParseWarnings = []models.ParseWarning{{File: "main.tf", Line: 3, Attribute: "key_name", Reason: "Unknown variable"}}
+
+	report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+	assert.Empty(t, driftPaths(report), "the warnings themselves are not compared")
+	assert.Equal(t, []string{"main.tf:3: key_name: Unknown variable"}, report.Warnings)
+}
+
+func TestDriftDetector_UnavailableFields(t *testing.T) {
+	actual := models.NewInstance("i-1", "t3.micro", "ami-0abc")
+	actual.MarkUnavailable("ec2:DescribeVolumes", "RootVolumeSize", "RootVolumeType", "RootVolumeEncrypted")
+	encrypted := true
+	desired := models.NewInstance("i-1", "t3.small", "ami-0abc")
+	desired.RootVolumeSize = 20
+	desired.RootVolumeType = "gp3"
+	desired.RootVolumeEncrypted = &encrypted
+
+	report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+	assert.Equal(t, []string{"Type"}, driftPaths(report), "the root volume is not reported as removed")
+	assert.Equal(t, []string{"root volume attributes could not be verified (missing ec2:DescribeVolumes)"}, report.Warnings)
+}
@@ -2,6 +2,12 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
 	"driftdetector/domain/models"
 )
 
@@ -12,7 +18,18 @@ type DetectionService interface {
 	
 	// BatchDetectDrift performs drift detection for multiple instances
 	BatchDetectDrift(ctx context.Context, actual, desired []*models.Instance) (map[string]*models.DriftReport, error)
+
+	// BatchDetectDriftConcurrent compares instance pairs with at most workers
+	// comparisons running at once. Reports are keyed by instance ID; pairs that
+	// fail are collected in a *BatchError returned with the other reports.
+	BatchDetectDriftConcurrent(ctx context.Context, pairs []InstancePair, workers int) (map[string]*models.DriftReport, error)
 	
+	// DetectSecurityGroupDrift adds rule-level drift for the instance's security groups to report
+	DetectSecurityGroupDrift(ctx context.Context, report *models.DriftReport, actual, desired []*models.SecurityGroupConfig) error
+
+	// DetectElasticIPDrift adds drift in the Elastic IPs associated with the instance to report
+	DetectElasticIPDrift(ctx context.Context, report *models.DriftReport, actual, desired []*models.ElasticIP) error
+
 	// GetDriftHistory retrieves historical drift reports for an instance
 	GetDriftHistory(instanceID string, limit int) ([]*models.DriftReport, error)
 }
@@ -29,8 +46,20 @@ func NewDetectionService() *DefaultDetectionService {
 	}
 }
 
-// DetectDrift implements the DetectionService interface
-func (s *DefaultDetectionService) DetectDrift(ctx context.Context, actual, desired *models.Instance) (*models.DriftReport, error) {
+// NewDetectionServiceWithOptions creates a DefaultDetectionService whose
+// detector is configured by opts
+func NewDetectionServiceWithOptions(opts ...DetectorOption) (*DefaultDetectionService, error) {
+	detector, err := NewDriftDetectorWithOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &DefaultDetectionService{detector: detector}, nil
+}
+
+// DetectDrift implements the DetectionService interface. A comparison that
+// cannot complete is returned as an error wrapping ErrComparisonFailed
+// rather than stopping the process, so a batch reports it with the instance.
+func (s *DefaultDetectionService) DetectDrift(ctx context.Context, actual, desired *models.Instance) (report *models.DriftReport, err error) {
 	if actual == nil || desired == nil {
 		return nil, ErrInvalidInput
 	}
@@ -39,17 +68,21 @@ func (s *DefaultDetectionService) DetectDrift(ctx context.Context, actual, desir
 		return nil, ErrInstanceMismatch
 	}
 
-	report := s.detector.CompareInstances(actual, desired)
-	return report, nil
+	defer func() {
+		if r := recover(); r != nil {
+			report, err = nil, fmt.Errorf("%w: %v", ErrComparisonFailed, r)
+		}
+	}()
+	return s.detector.CompareInstances(actual, desired), nil
 }
 
-// BatchDetectDrift implements the DetectionService interface
+// BatchDetectDrift implements the DetectionService interface. Matched
+// instances are compared concurrently; a comparison that fails does not stop
+// the others, and the failures are returned together as a *BatchError.
 func (s *DefaultDetectionService) BatchDetectDrift(
 	ctx context.Context,
 	actual, desired []*models.Instance,
 ) (map[string]*models.DriftReport, error) {
-	reports := make(map[string]*models.DriftReport)
-
 	// Create a map of desired instances by ID for quick lookup
 	desiredMap := make(map[string]*models.Instance)
 	for _, inst := range desired {
@@ -57,14 +90,17 @@ func (s *DefaultDetectionService) BatchDetectDrift(
 	}
 
 	// Compare each actual instance with its desired state
+	var pairs []InstancePair
 	for _, actualInst := range actual {
 		if desiredInst, exists := desiredMap[actualInst.ID]; exists {
-			report, err := s.DetectDrift(ctx, actualInst, desiredInst)
-			if err != nil {
-				return nil, err
-			}
-			reports[actualInst.ID] = report
-		} else {
+			pairs = append(pairs, InstancePair{Actual: actualInst, Desired: desiredInst})
+		}
+	}
+	reports, err := s.BatchDetectDriftConcurrent(ctx, pairs, 0)
+	failed := failedIDs(err)
+
+	for _, actualInst := range actual {
+		if _, exists := desiredMap[actualInst.ID]; !exists {
 			// Handle case where instance exists in actual but not in desired
 			report := models.NewDriftReport(actualInst.ID)
 			report.AddDrift(models.NewDrift(
@@ -80,6 +116,9 @@ func (s *DefaultDetectionService) BatchDetectDrift(
 
 	// Check for instances that exist in desired but not in actual
 	for _, desiredInst := range desired {
+		if _, exists := failed[desiredInst.ID]; exists {
+			continue
+		}
 		if _, exists := reports[desiredInst.ID]; !exists {
 			report := models.NewDriftReport(desiredInst.ID)
 			report.AddDrift(models.NewDrift(
@@ -93,9 +132,165 @@ func (s *DefaultDetectionService) BatchDetectDrift(
 		}
 	}
 
+	return reports, err
+}
+
+// InstancePair is an instance as found in AWS and as declared in Terraform
+type InstancePair struct {
+	Actual  *models.Instance
+	Desired *models.Instance
+}
+
+// BatchError collects the comparisons that failed in a batch, keyed by
+// instance ID
+type BatchError struct {
+	Errors map[string]error
+}
+
+// Error lists the failures in instance ID order
+func (e *BatchError) Error() string {
+	ids := e.ids()
+	msgs := make([]string, len(ids))
+	for i, id := range ids {
+		msgs[i] = fmt.Sprintf("%s: %v", id, e.Errors[id])
+	}
+	return fmt.Sprintf("drift detection failed for %d instance(s): %s", len(ids), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the failures in instance ID order, so errors.Is and
+// errors.As see each of them
+func (e *BatchError) Unwrap() []error {
+	ids := e.ids()
+	errs := make([]error, len(ids))
+	for i, id := range ids {
+		errs[i] = e.Errors[id]
+	}
+	return errs
+}
+
+func (e *BatchError) ids() []string {
+	ids := make([]string, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// failedIDs returns the instances that failed in err, which may be nil
+func failedIDs(err error) map[string]error {
+	if batchErr, ok := err.(*BatchError); ok {
+		return batchErr.Errors
+	}
+	return nil
+}
+
+// BatchDetectDriftConcurrent implements the DetectionService interface.
+// workers below one defaults to the number of CPUs.
+func (s *DefaultDetectionService) BatchDetectDriftConcurrent(
+	ctx context.Context,
+	pairs []InstancePair,
+	workers int,
+) (map[string]*models.DriftReport, error) {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	jobs := make(chan InstancePair)
+	go func() {
+		defer close(jobs)
+		for _, pair := range pairs {
+			select {
+			case jobs <- pair:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		reports = make(map[string]*models.DriftReport, len(pairs))
+		failed  = make(map[string]error)
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pair := range jobs {
+				id := pairID(pair)
+				report, err := s.DetectDrift(ctx, pair.Actual, pair.Desired)
+
+				mu.Lock()
+				if err != nil {
+					failed[id] = err
+				} else {
+					reports[id] = report
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Pairs never started because the context ended fail with its error
+	if err := ctx.Err(); err != nil {
+		for _, pair := range pairs {
+			id := pairID(pair)
+			if _, done := reports[id]; !done {
+				if _, done := failed[id]; !done {
+					failed[id] = err
+				}
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return reports, &BatchError{Errors: failed}
+	}
 	return reports, nil
 }
 
+// pairID returns the instance ID of a pair, from whichever side has one
+func pairID(pair InstancePair) string {
+	if pair.Actual != nil {
+		return pair.Actual.ID
+	}
+	if pair.Desired != nil {
+		return pair.Desired.ID
+	}
+	return ""
+}
+
+// DetectSecurityGroupDrift implements the DetectionService interface
+func (s *DefaultDetectionService) DetectSecurityGroupDrift(
+	ctx context.Context,
+	report *models.DriftReport,
+	actual, desired []*models.SecurityGroupConfig,
+) error {
+	if report == nil {
+		return ErrInvalidInput
+	}
+
+	s.detector.CompareSecurityGroups(actual, desired, report)
+	return nil
+}
+
+// DetectElasticIPDrift implements the DetectionService interface
+func (s *DefaultDetectionService) DetectElasticIPDrift(
+	ctx context.Context,
+	report *models.DriftReport,
+	actual, desired []*models.ElasticIP,
+) error {
+	if report == nil {
+		return ErrInvalidInput
+	}
+
+	s.detector.CompareElasticIPs(report.InstanceID, actual, desired, report)
+	return nil
+}
+
 // GetDriftHistory implements the DetectionService interface
 func (s *DefaultDetectionService) GetDriftHistory(instanceID string, limit int) ([]*models.DriftReport, error) {
 	// Implementation would typically query a persistence layer
@@ -107,6 +302,7 @@ func (s *DefaultDetectionService) GetDriftHistory(instanceID string, limit int)
 var (
 	ErrInvalidInput     = NewDomainError("invalid input parameters")
 	ErrInstanceMismatch = NewDomainError("instance IDs do not match")
+	ErrComparisonFailed = NewDomainError("instance comparison failed")
 )
 
 // DomainError represents a domain-specific error
@@ -0,0 +1,151 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestDetectionService_BatchDetectDriftConcurrent(t *testing.T) {
+	svc := services.NewDetectionService()
+
+	t.Run("reports are keyed by instance ID", func(t *testing.T) {
+		// Given 200 pairs, every tenth of which has drifted
+		var pairs []services.InstancePair
+		for i := 0; i < 200; i++ {
+			id := fmt.Sprintf("i-%03d", i)
+			actual := models.NewInstance(id, "t3.micro", "ami-1")
+			if i%10 == 0 {
+				actual.Type = "t3.large"
+			}
+			pairs = append(pairs, services.InstancePair{Actual: actual, Desired: models.NewInstance(id, "t3.micro", "ami-1")})
+		}
+
+		// When comparing them across several workers
+		reports, err := svc.BatchDetectDriftConcurrent(context.Background(), pairs, 8)
+
+		// Then each report belongs to its own instance
+		require.NoError(t, err)
+		require.Len(t, reports, 200)
+		for i := 0; i < 200; i++ {
+			id := fmt.Sprintf("i-%03d", i)
+			require.Contains(t, reports, id)
+			assert.Equal(t, id, reports[id].InstanceID)
+			assert.Equal(t, i%10 == 0, reports[id].HasDrifts(), id)
+		}
+	})
+
+	t.Run("failures do not stop the other comparisons", func(t *testing.T) {
+		pairs := []services.InstancePair{
+			{Actual: models.NewInstance("i-1", "t3.micro", "ami-1"), Desired: models.NewInstance("i-1", "t3.micro", "ami-1")},
+			{Actual: models.NewInstance("i-2", "t3.micro", "ami-1"), Desired: models.NewInstance("i-other", "t3.micro", "ami-1")},
+			{Actual: models.NewInstance("i-3", "t3.micro", "ami-1"), Desired: nil},
+		}
+
+		reports, err := svc.BatchDetectDriftConcurrent(context.Background(), pairs, 2)
+
+		require.Error(t, err)
+		assert.Len(t, reports, 1)
+		assert.Contains(t, reports, "i-1")
+
+		var batchErr *services.BatchError
+		require.True(t, errors.As(err, &batchErr))
+		assert.Len(t, batchErr.Errors, 2)
+		assert.ErrorIs(t, err, services.ErrInstanceMismatch)
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+		assert.Equal(t, "drift detection failed for 2 instance(s): i-2: "+services.ErrInstanceMismatch.Error()+"; i-3: "+services.ErrInvalidInput.Error(), err.Error())
+	})
+
+	t.Run("a cancelled context fails the remaining pairs", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		pairs := []services.InstancePair{
+			{Actual: models.NewInstance("i-1", "t3.micro", "ami-1"), Desired: models.NewInstance("i-1", "t3.micro", "ami-1")},
+			{Actual: models.NewInstance("i-2", "t3.micro", "ami-1"), Desired: models.NewInstance("i-2", "t3.micro", "ami-1")},
+		}
+
+		reports, err := svc.BatchDetectDriftConcurrent(ctx, pairs, 1)
+
+		require.Error(t, err)
+		assert.ErrorIs(t, err, context.Canceled)
+		var batchErr *services.BatchError
+		require.True(t, errors.As(err, &batchErr))
+		assert.Equal(t, len(pairs), len(reports)+len(batchErr.Errors))
+	})
+}
+
+func TestDetectionService_BatchDetectDrift(t *testing.T) {
+	actual := []*models.Instance{
+		models.NewInstance("i-1", "t3.large", "ami-1"),
+		models.NewInstance("i-extra", "t3.micro", "ami-1"),
+	}
+	desired := []*models.Instance{
+		models.NewInstance("i-1", "t3.micro", "ami-1"),
+		models.NewInstance("i-missing", "t3.micro", "ami-1"),
+	}
+
+	reports, err := services.NewDetectionService().BatchDetectDrift(context.Background(), actual, desired)
+
+	require.NoError(t, err)
+	require.Len(t, reports, 3)
+	assert.True(t, reports["i-1"].HasDrifts())
+	assert.Equal(t, models.DriftTypeRemoved, reports["i-extra"].Drifts[0].Type)
+	assert.Equal(t, models.DriftTypeAdded, reports["i-missing"].Drifts[0].Type)
+}
+
+func TestDetectionService_DetectDrift_DeterministicOrder(t *testing.T) {
+	// Given many tags changed, added and removed, so map iteration order
+	// would show in the report
+	actual := models.NewInstance("i-1", "t3.large", "ami-2")
+	desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+	for i := 0; i < 30; i++ {
+		actual.AddTag(fmt.Sprintf("Changed%d", i), "new")
+		desired.AddTag(fmt.Sprintf("Changed%d", i), "old")
+		actual.AddTag(fmt.Sprintf("Unmanaged%d", i), "x")
+		desired.AddTag(fmt.Sprintf("Missing%d", i), "y")
+	}
+	actual.EBSBlockDevices = []models.EBSBlockDevice{{DeviceName: "/dev/sdg", VolumeSize: 20}, {DeviceName: "/dev/sdf", VolumeSize: 10}}
+	desired.EBSBlockDevices = []models.EBSBlockDevice{{DeviceName: "/dev/sdf", VolumeSize: 15}, {DeviceName: "/dev/sdg", VolumeSize: 25}}
+	svc := services.NewDetectionService()
+
+	// When
+	var first []byte
+	for run := 0; run < 50; run++ {
+		report, err := svc.DetectDrift(context.Background(), actual, desired)
+		require.NoError(t, err)
+		out, err := json.Marshal(report)
+		require.NoError(t, err)
+
+		// Then
+		if run == 0 {
+			first = out
+			assert.Greater(t, len(report.Drifts), 90)
+			continue
+		}
+		require.Equal(t, string(first), string(out), "run %d", run)
+	}
+}
+
+func TestDetectionService_DetectDrift_ComparisonFailure(t *testing.T) {
+	// Given a comparer that cannot handle the values it is given
+	svc, err := services.NewDetectionServiceWithOptions(services.WithComparer("Type", func(actual, expected interface{}) (bool, string) {
+		panic("unexpected value")
+	}))
+	require.NoError(t, err)
+
+	// When comparing an instance
+	report, err := svc.DetectDrift(context.Background(), models.NewInstance("i-1", "t3.micro", "ami-1"), models.NewInstance("i-1", "t3.micro", "ami-1"))
+
+	// Then the failure is returned instead of stopping the process
+	assert.Nil(t, report)
+	assert.ErrorIs(t, err, services.ErrComparisonFailed)
+	assert.ErrorContains(t, err, "unexpected value")
+}
@@ -3,6 +3,8 @@ package services
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 
 	"driftdetector/domain/models"
@@ -13,6 +15,40 @@ import (
 type DriftDetector struct {
 	// ignoredFields are fields that should be excluded from drift detection
 	ignoredFields map[string]bool
+
+	// ignorePatterns are user-supplied field paths, split into segments
+	ignorePatterns [][]string
+
+	// onlyPatterns, when set, restrict comparison to the field paths they
+	// match, split into segments
+	onlyPatterns [][]string
+
+	// computedFields are the default ignored fields still skipped, see
+	// defaultIgnoredFields
+	computedFields map[string]bool
+
+	// awsDefaults are the defaults unset expected values are compared as,
+	// see awsDefaultValues
+	awsDefaults map[string]string
+
+	// volumeDefaults are the volume performance defaults unset expected
+	// values are compared as, see volumeTypeDefaults
+	volumeDefaults map[string]volumePerformance
+
+	// severityRules classify findings by path
+	severityRules []models.SeverityRule
+
+	// strictNil reports a nil pointer and a pointer to a zero value as drift
+	strictNil bool
+
+	// defaultTags are merged into the expected tags of every instance
+	defaultTags map[string]string
+
+	// includeAWSTags compares aws:-prefixed tags instead of skipping them
+	includeAWSTags bool
+
+	// comparers replace equality for the fields they are registered for
+	comparers []pathComparer
 }
 
 // NewDriftDetector creates a new instance of DriftDetector
@@ -20,33 +56,171 @@ func NewDriftDetector() *DriftDetector {
 	return &DriftDetector{
 		ignoredFields: map[string]bool{
 			// Add fields that should be ignored during comparison
+			"ResourceAddress": true,
+			// Source only records where the configuration was declared
+			"Source": true,
+			// State is the lifecycle state AWS reports, not a setting
+			"State": true,
+			// LaunchTime is when AWS launched the instance, not a setting
+			"LaunchTime": true,
+			// UserData is compared by content in compareUserData
+			"UserData": true,
+			// IAMInstanceProfile is compared by name in compareIAMInstanceProfile
+			"IAMInstanceProfile": true,
+			// InstanceLifecycle and InstanceMarketOptions are compared in compareMarketOptions
+			"InstanceLifecycle":     true,
+			"InstanceMarketOptions": true,
+			// SpotInstanceRequestID only records which request launched the instance
+			"SpotInstanceRequestID": true,
+			// UnknownFields only marks which fields to skip
+			"UnknownFields": true,
+			// UnresolvedFields is reported in unverifiableFields
+			"UnresolvedFields": true,
+			// UnresolvedRefs is reported in unverifiableFields
+			"UnresolvedRefs": true,
+			// UnavailableFields is reported in unavailableFields
+			"UnavailableFields": true,
+			// LaunchTemplate only records where merged settings came from
+			"LaunchTemplate": true,
+			// IgnoreChanges only marks which fields to suppress
+			"IgnoreChanges": true,
+			// SourceMap only records where settings were declared
+			"SourceMap": true,
+			// ParseWarnings are reported as warnings of the report
+			"ParseWarnings": true,
 		},
+		computedFields: computedFieldSet(),
+		awsDefaults:    awsDefaultValues,
+		volumeDefaults: volumeTypeDefaults,
+		severityRules:  models.DefaultSeverityRules(),
 	}
 }
 
-// CompareInstances compares two instances and returns a drift report
+// WithStrictNil makes a nil pointer differ from a pointer to its type's zero
+// value, e.g. an unset monitoring flag from one set to false
+func WithStrictNil() DetectorOption {
+	return func(d *DriftDetector) error {
+		d.strictNil = true
+		return nil
+	}
+}
+
+// CompareInstances compares two instances and returns a drift report.
+// Findings in the fields desired.IgnoreChanges lists are left out and counted
+// in the report's SuppressedByLifecycle.
 func (d *DriftDetector) CompareInstances(actual, desired *models.Instance) *models.DriftReport {
+	if len(desired.IgnoreChanges) == 0 {
+		return d.compareInstances(actual, desired)
+	}
+
+	lifecycle, err := d.withIgnoredPaths(desired.IgnoreChanges...)
+	if err != nil {
+		report := d.compareInstances(actual, desired)
+		report.AddWarning(fmt.Sprintf("lifecycle ignore_changes not applied: %v", err))
+		return report
+	}
+
+	// Ignoring fields only removes findings, so the difference is what was suppressed
+	report := lifecycle.compareInstances(actual, desired)
+	report.SuppressedByLifecycle = len(d.compareInstances(actual, desired).Drifts) - len(report.Drifts)
+	return report
+}
+
+// withIgnoredPaths returns a copy of the detector that also ignores patterns,
+// leaving the detector itself, which may be in use concurrently, unchanged
+func (d *DriftDetector) withIgnoredPaths(patterns ...string) (*DriftDetector, error) {
+	c := *d
+	c.ignorePatterns = append([][]string(nil), d.ignorePatterns...)
+	if err := c.IgnoreFields(patterns...); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// compareInstances compares two instances without regard to IgnoreChanges
+func (d *DriftDetector) compareInstances(actual, desired *models.Instance) *models.DriftReport {
 	report := models.NewDriftReport(actual.ID)
+	for _, warning := range desired.ParseWarnings {
+		report.AddWarning(warning.String())
+	}
+
+	// Values Terraform will only know after apply cannot have drifted
+	desired = resolveUnknownFields(actual, desired)
+	desired = d.applyAWSDefaults(actual, desired)
+	desired = d.applyVolumeDefaults(actual, desired)
+	desired = unverifiableFields(actual, desired, report)
+	desired = unavailableFields(actual, desired, report)
+	desired = d.applyDefaultTags(desired)
+	desired = resolveUnmanagedVolumeTags(actual, desired)
+	desired = d.resolveSecurityGroups(actual, desired, report)
+	desired = resolveNetworkInterfaces(actual, desired)
+	desired = resolveImpliedPlacement(actual, desired)
 
 	// Use reflection to compare struct fields
 	actualVal := reflect.ValueOf(actual).Elem()
 	desiredVal := reflect.ValueOf(desired).Elem()
 
-	d.compareStruct("", actualVal, desiredVal, report)
+	d.compareStruct("", nil, actualVal, desiredVal, report)
+	d.compareUserData(actual, desired, report)
+	d.compareIAMInstanceProfile(actual, desired, report)
+	d.compareMarketOptions(actual, desired, report)
+	d.checkPrerequisites(actual, desired, report)
+	report.ApplySeverity(d.severityRules)
+	report.SortDrifts()
+
+	// Point findings at the argument, or else the resource, declaring them
+	sources := newSourceIndex(desired)
+	for i := range report.Drifts {
+		report.Drifts[i].Source = sources.lookup(report.Drifts[i].Path)
+	}
 
 	return report
 }
 
-// compareStruct recursively compares struct fields
-func (d *DriftDetector) compareStruct(prefix string, actual, expected reflect.Value, report *models.DriftReport) {
-	// Implementation of struct comparison logic
-	// This is a simplified version - you'll want to expand this
-	// to handle all the different field types and edge cases
+// resolveUnknownFields returns desired with every field listed in its
+// UnknownFields taken from actual
+func resolveUnknownFields(actual, desired *models.Instance) *models.Instance {
+	if len(desired.UnknownFields) == 0 {
+		return desired
+	}
+
+	resolved := *desired
+	actualVal := reflect.ValueOf(actual).Elem()
+	resolvedVal := reflect.ValueOf(&resolved).Elem()
+	for _, name := range desired.UnknownFields {
+		field := resolvedVal.FieldByName(name)
+		if field.IsValid() && field.CanSet() {
+			field.Set(actualVal.FieldByName(name))
+		}
+	}
+
+	return &resolved
+}
 
-	if actual.Kind() != expected.Kind() {
+// compareStruct recursively compares struct fields.
+// segments holds the field path used to match ignore patterns.
+func (d *DriftDetector) compareStruct(prefix string, segments []string, actual, expected reflect.Value, report *models.DriftReport) {
+	if d.isIgnored(segments) {
+		return
+	}
+
+	// A value missing on one side, such as a nil entry of a
+	// map[string]interface{}, is reported for its whole subtree
+	if !actual.IsValid() || !expected.IsValid() {
+		d.compareMissing(prefix, actual, expected, report)
+		return
+	}
+
+	// Pointers are compared by the values they refer to
+	if actual.Kind() != reflect.Ptr && d.compareCustom(strings.TrimPrefix(prefix, "."), segments, actual.Interface(), expected.Interface(), report) {
+		return
+	}
+
+	// Fields are matched by index, so only values of the same type are walked
+	if actual.Type() != expected.Type() {
 		report.AddDrift(models.NewDrift(
 			models.DriftTypeModified,
-			prefix,
+			strings.TrimPrefix(prefix, "."),
 			actual.Interface(),
 			expected.Interface(),
 			"Type mismatch",
@@ -57,25 +231,29 @@ func (d *DriftDetector) compareStruct(prefix string, actual, expected reflect.Va
 	switch actual.Kind() {
 	case reflect.Struct:
 		for i := 0; i < actual.NumField(); i++ {
-			fieldName := actual.Type().Field(i).Name
+			field := actual.Type().Field(i)
+			fieldName := field.Name
 			fieldPath := prefix + "." + fieldName
 
-			// Skip ignored fields
-			if d.ignoredFields[fieldName] {
+			// Skip ignored fields, and unexported ones that cannot be read
+			if d.ignoredFields[fieldName] || !field.IsExported() {
 				continue
 			}
 
 			actualField := actual.Field(i)
 			expectedField := expected.Field(i)
 
-			d.compareStruct(fieldPath, actualField, expectedField, report)
+			d.compareStruct(fieldPath, appendSegment(segments, fieldName), actualField, expectedField, report)
 		}
 
 	case reflect.Map:
-		d.compareMaps(prefix, actual, expected, report)
+		d.compareMaps(prefix, segments, actual, expected, report)
 
 	case reflect.Slice, reflect.Array:
-		d.compareSlices(prefix, actual, expected, report)
+		d.compareSlices(prefix, segments, actual, expected, report)
+
+	case reflect.Ptr:
+		d.comparePointers(prefix, segments, actual, expected, report)
 
 	default:
 		if !reflect.DeepEqual(actual.Interface(), expected.Interface()) {
@@ -90,31 +268,109 @@ func (d *DriftDetector) compareStruct(prefix string, actual, expected reflect.Va
 	}
 }
 
-// compareMaps compares two map values
-func (d *DriftDetector) compareMaps(prefix string, actual, expected reflect.Value, report *models.DriftReport) {
-	// Implementation for comparing maps
-	// This is a simplified version
+// comparePointers compares the values two pointers refer to. A pointer set
+// on one side only is a single finding for everything it refers to; unless
+// strictNil is set, a nil pointer equals a pointer to a zero value.
+func (d *DriftDetector) comparePointers(prefix string, segments []string, actual, expected reflect.Value, report *models.DriftReport) {
+	switch {
+	case actual.IsNil() && expected.IsNil():
+		return
+	case actual.IsNil() || expected.IsNil():
+		set := actual
+		if set.IsNil() {
+			set = expected
+		}
+		if !d.strictNil && set.Elem().IsZero() {
+			return
+		}
+		d.compareMissing(prefix, nilToInvalid(actual), nilToInvalid(expected), report)
+	default:
+		d.compareStruct(prefix, segments, actual.Elem(), expected.Elem(), report)
+	}
+}
+
+// nilToInvalid returns the zero Value for a nil pointer, so compareMissing
+// treats it as missing
+func nilToInvalid(v reflect.Value) reflect.Value {
+	if v.IsNil() {
+		return reflect.Value{}
+	}
+	return v
+}
+
+// compareMissing reports a value that is missing, i.e. invalid, on one or
+// both sides: one declared in Terraform only is added, one set in AWS only
+// is removed, as for elements of keyed slices
+func (d *DriftDetector) compareMissing(prefix string, actual, expected reflect.Value, report *models.DriftReport) {
+	path := strings.TrimPrefix(prefix, ".")
+	switch {
+	case !actual.IsValid() && !expected.IsValid():
+		return
+	case !actual.IsValid():
+		report.AddDrift(models.NewDrift(
+			models.DriftTypeAdded,
+			path,
+			nil,
+			valueInterface(expected),
+			"Value declared in Terraform is not set in AWS",
+		))
+	default:
+		report.AddDrift(models.NewDrift(
+			models.DriftTypeRemoved,
+			path,
+			valueInterface(actual),
+			nil,
+			"Value set in AWS is not declared in Terraform",
+		))
+	}
+}
+
+// valueInterface returns the value held by v, or nil when it cannot be read
+func valueInterface(v reflect.Value) interface{} {
+	if !v.IsValid() || !v.CanInterface() {
+		return nil
+	}
+	return v.Interface()
+}
 
-	for _, key := range actual.MapKeys() {
-		keyStr := key.String()
+// compareMaps compares two map values key by key, reporting each entry at
+// prefix.key. Values that are themselves maps, slices or structs are
+// compared element by element under prefix[key], so a change deep inside is
+// reported at its own path.
+func (d *DriftDetector) compareMaps(prefix string, segments []string, actual, expected reflect.Value, report *models.DriftReport) {
+	path := strings.TrimPrefix(prefix, ".")
+	for _, key := range sortedMapKeys(actual) {
+		keyStr := mapKeyString(key)
+		if d.isAWSTag(segments, keyStr) || d.isIgnored(appendSegment(segments, keyStr)) {
+			continue
+		}
 		actualValue := actual.MapIndex(key)
 		expectedValue := expected.MapIndex(key)
 
 		if !expectedValue.IsValid() {
 			report.AddDrift(models.NewDrift(
 				models.DriftTypeRemoved,
-				prefix+"."+keyStr,
-				nil,
+				path+"."+keyStr,
+				actualValue.Interface(),
 				nil,
 				"Field removed",
 			))
 			continue
 		}
 
+		if d.compareCustom(path+"."+keyStr, appendSegment(segments, keyStr), actualValue.Interface(), expectedValue.Interface(), report) {
+			continue
+		}
+
+		if a, e, ok := nestedValues(actualValue, expectedValue); ok {
+			d.compareStruct(path+"["+keyStr+"]", appendSegment(segments, keyStr), a, e, report)
+			continue
+		}
+
 		if !reflect.DeepEqual(actualValue.Interface(), expectedValue.Interface()) {
 			report.AddDrift(models.NewDrift(
 				models.DriftTypeModified,
-				prefix+"."+keyStr,
+				path+"."+keyStr,
 				actualValue.Interface(),
 				expectedValue.Interface(),
 				"Value modified",
@@ -123,38 +379,130 @@ func (d *DriftDetector) compareMaps(prefix string, actual, expected reflect.Valu
 	}
 
 	// Check for added fields
-	for _, key := range expected.MapKeys() {
-		keyStr := key.String()
+	for _, key := range sortedMapKeys(expected) {
+		keyStr := mapKeyString(key)
+		if d.isAWSTag(segments, keyStr) || d.isIgnored(appendSegment(segments, keyStr)) {
+			continue
+		}
 		if !actual.MapIndex(key).IsValid() {
-			expectedValue := expected.MapIndex(key)
 			report.AddDrift(models.NewDrift(
 				models.DriftTypeAdded,
-				prefix+"."+keyStr,
+				path+"."+keyStr,
 				nil,
-				expectedValue.Interface(),
+				expected.MapIndex(key).Interface(),
 				"Field added",
 			))
 		}
 	}
 }
 
-// compareSlices compares two slice/array values
-func (d *DriftDetector) compareSlices(prefix string, actual, expected reflect.Value, report *models.DriftReport) {
-	// Implementation for comparing slices/arrays
-	// This is a simplified version
+// nestedValues returns the values held by two map entries when both are
+// maps, slices or structs of the same type, such as the nested maps of a
+// map[string]interface{}. Other values are compared as a whole.
+func nestedValues(actual, expected reflect.Value) (reflect.Value, reflect.Value, bool) {
+	for actual.Kind() == reflect.Interface && !actual.IsNil() {
+		actual = actual.Elem()
+	}
+	for expected.Kind() == reflect.Interface && !expected.IsNil() {
+		expected = expected.Elem()
+	}
+	if actual.Type() != expected.Type() {
+		return actual, expected, false
+	}
+	switch actual.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		return actual, expected, true
+	default:
+		return actual, expected, false
+	}
+}
+
+// mapKeyString formats a map key for drift paths and ignore patterns
+func mapKeyString(key reflect.Value) string {
+	if key.Kind() == reflect.String {
+		return key.String()
+	}
+	return fmt.Sprintf("%v", key.Interface())
+}
+
+// sortedMapKeys returns the keys of the map m in natural order, so findings
+// and their descriptions do not depend on map iteration order
+func sortedMapKeys(m reflect.Value) []reflect.Value {
+	keys := m.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return models.ComparePaths(mapKeyString(keys[i]), mapKeyString(keys[j])) < 0
+	})
+	return keys
+}
 
-	if actual.Len() != expected.Len() {
-		report.AddDrift(models.NewDrift(
-			models.DriftTypeModified,
-			prefix,
-			actual.Interface(),
-			expected.Interface(),
-			"Length mismatch",
-		))
+// compareSlices compares two slice/array values. Elements of registered
+// types are matched by key; any other slice is compared by position, with a
+// warning on the report when elements of a struct type differ. Either way,
+// each element is reported at its own path, e.g. PrivateIPAddresses[1].
+func (d *DriftDetector) compareSlices(prefix string, segments []string, actual, expected reflect.Value, report *models.DriftReport) {
+	elemType := actual.Type().Elem()
+	if keyOf, ok := sliceKeyers[elemType]; ok {
+		if d.compareKeyedSlices(prefix, segments, keyOf, actual, expected, report) {
+			return
+		}
+		report.AddWarning(fmt.Sprintf("%s: elements without unique keys were compared by position", strings.TrimPrefix(prefix, ".")))
+	} else if elemType.Kind() == reflect.Struct && !reflect.DeepEqual(actual.Interface(), expected.Interface()) {
+		report.AddWarning(fmt.Sprintf("%s: no key is registered for %s, elements were compared by position", strings.TrimPrefix(prefix, "."), elemType.Name()))
+	}
+
+	// Elements are compared by position; those past the end of the shorter
+	// slice are set on one side only
+	path := strings.TrimPrefix(prefix, ".")
+	for i := 0; i < actual.Len() || i < expected.Len(); i++ {
+		elemSegments := appendSegment(segments, strconv.Itoa(i))
+		elemPath := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case i >= expected.Len():
+			if !d.isIgnored(elemSegments) {
+				d.compareMissing(elemPath, actual.Index(i), reflect.Value{}, report)
+			}
+		case i >= actual.Len():
+			if !d.isIgnored(elemSegments) {
+				d.compareMissing(elemPath, reflect.Value{}, expected.Index(i), report)
+			}
+		default:
+			d.compareStruct(elemPath, elemSegments, actual.Index(i), expected.Index(i), report)
+		}
+	}
+}
+
+// compareUserData compares user data by content, so base64 encoding, line
+// endings, trailing newlines and Terraform's stored hash do not cause drift
+func (d *DriftDetector) compareUserData(actual, desired *models.Instance, report *models.DriftReport) {
+	segments := []string{"UserData"}
+	if d.isIgnored(segments) || d.compareCustom("UserData", segments, actual.UserData, desired.UserData, report) ||
+		models.UserDataEqual(actual.UserData, desired.UserData) {
 		return
 	}
 
-	for i := 0; i < actual.Len(); i++ {
-		d.compareStruct(fmt.Sprintf("%s[%d]", prefix, i), actual.Index(i), expected.Index(i), report)
+	report.AddDrift(models.NewDrift(
+		models.DriftTypeModified,
+		"UserData",
+		models.UserDataFingerprint(actual.UserData),
+		models.UserDataFingerprint(desired.UserData),
+		"User data content differs",
+	))
+}
+
+// compareIAMInstanceProfile compares instance profiles by name, so a profile
+// EC2 reports by ARN matches the name Terraform records
+func (d *DriftDetector) compareIAMInstanceProfile(actual, desired *models.Instance, report *models.DriftReport) {
+	segments := []string{"IAMInstanceProfile"}
+	if d.isIgnored(segments) || d.compareCustom("IAMInstanceProfile", segments, actual.IAMInstanceProfile, desired.IAMInstanceProfile, report) ||
+		models.InstanceProfileEqual(actual.IAMInstanceProfile, desired.IAMInstanceProfile) {
+		return
 	}
+
+	report.AddDrift(models.NewDrift(
+		models.DriftTypeModified,
+		"IAMInstanceProfile",
+		models.InstanceProfileName(actual.IAMInstanceProfile),
+		models.InstanceProfileName(desired.IAMInstanceProfile),
+		"IAM instance profile differs",
+	))
 }
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"fmt"
+
+	"driftdetector/domain/models"
+)
+
+// elasticIPsPath prefixes the findings about the Elastic IPs of an instance
+const elasticIPsPath = "ElasticIPs"
+
+// CompareElasticIPs adds findings about the Elastic IPs of the instance
+// instanceID to report, at ElasticIPs[allocation-id]: an address Terraform
+// associates with the instance is ADDED when AWS does not associate it with
+// any instance and MODIFIED, at its InstanceID, when AWS associates it with
+// another one, while an address AWS associates with the instance that
+// Terraform does not is REMOVED.
+func (d *DriftDetector) CompareElasticIPs(instanceID string, actual, desired []*models.ElasticIP, report *models.DriftReport) {
+	actualByID := make(map[string]*models.ElasticIP, len(actual))
+	for _, eip := range actual {
+		actualByID[eip.AllocationID] = eip
+	}
+	desiredByID := make(map[string]*models.ElasticIP, len(desired))
+	for _, eip := range desired {
+		desiredByID[eip.AllocationID] = eip
+	}
+
+	for _, want := range desired {
+		if want.InstanceID != instanceID {
+			continue
+		}
+		segments := []string{elasticIPsPath, want.AllocationID}
+		if d.isIgnored(segments) {
+			continue
+		}
+		prefix := fmt.Sprintf("%s[%s]", elasticIPsPath, want.AllocationID)
+
+		got := actualByID[want.AllocationID]
+		switch {
+		case got == nil:
+			report.AddDrift(models.NewDrift(models.DriftTypeAdded, prefix, nil, elasticIPLabel(want),
+				fmt.Sprintf("Elastic IP %s declared by %s no longer exists in AWS", elasticIPLabel(want), want.ResourceAddress)))
+		case got.InstanceID == "":
+			report.AddDrift(models.NewDrift(models.DriftTypeAdded, prefix, nil, elasticIPLabel(got),
+				fmt.Sprintf("Elastic IP %s declared by %s is not associated with any instance", elasticIPLabel(got), want.ResourceAddress)))
+		case got.InstanceID != instanceID && !d.isIgnored(appendSegment(segments, "InstanceID")):
+			report.AddDrift(models.NewDrift(models.DriftTypeModified, prefix+".InstanceID", got.InstanceID, instanceID,
+				fmt.Sprintf("Elastic IP %s declared by %s is associated with %s", elasticIPLabel(got), want.ResourceAddress, got.InstanceID)))
+		}
+	}
+
+	for _, got := range actual {
+		if got.InstanceID != instanceID {
+			continue
+		}
+		want := desiredByID[got.AllocationID]
+		if want != nil && want.InstanceID == instanceID {
+			continue
+		}
+		if d.isIgnored([]string{elasticIPsPath, got.AllocationID}) {
+			continue
+		}
+
+		description := fmt.Sprintf("Elastic IP %s is associated in AWS but not in Terraform", elasticIPLabel(got))
+		if want != nil && want.InstanceID != "" {
+			description = fmt.Sprintf("Elastic IP %s is associated in AWS, but %s associates it with %s", elasticIPLabel(got), want.ResourceAddress, want.InstanceID)
+		}
+		report.AddDrift(models.NewDrift(models.DriftTypeRemoved, fmt.Sprintf("%s[%s]", elasticIPsPath, got.AllocationID), elasticIPLabel(got), nil, description))
+	}
+}
+
+// elasticIPLabel names an address by its public IP, or by its allocation ID
+// when the IP is not known
+func elasticIPLabel(eip *models.ElasticIP) string {
+	if eip.PublicIP == "" {
+		return eip.AllocationID
+	}
+	return eip.PublicIP
+}
@@ -0,0 +1,105 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestDriftDetector_CompareElasticIPs(t *testing.T) {
+	web := &models.ElasticIP{AllocationID: "eipalloc-web", PublicIP: "203.0.113.10", InstanceID: "i-1", ResourceAddress: "aws_eip_association.web"}
+	moved := func(eip *models.ElasticIP, instanceID string) *models.ElasticIP {
+		copied := *eip
+		copied.InstanceID = instanceID
+		return &copied
+	}
+
+	tests := []struct {
+		name    string
+		actual  []*models.ElasticIP
+		desired []*models.ElasticIP
+		opts    []services.DetectorOption
+		drifts  []models.Drift
+	}{
+		{
+			name:    "associated as declared",
+			actual:  []*models.ElasticIP{web},
+			desired: []*models.ElasticIP{web},
+		},
+		{
+			name:    "detached in the console",
+			actual:  []*models.ElasticIP{moved(web, "")},
+			desired: []*models.ElasticIP{web},
+			drifts: []models.Drift{
+				models.NewDrift(models.DriftTypeAdded, "ElasticIPs[eipalloc-web]", nil, "203.0.113.10",
+					"Elastic IP 203.0.113.10 declared by aws_eip_association.web is not associated with any instance"),
+			},
+		},
+		{
+			name:    "released",
+			desired: []*models.ElasticIP{web},
+			drifts: []models.Drift{
+				models.NewDrift(models.DriftTypeAdded, "ElasticIPs[eipalloc-web]", nil, "203.0.113.10",
+					"Elastic IP 203.0.113.10 declared by aws_eip_association.web no longer exists in AWS"),
+			},
+		},
+		{
+			name:    "moved to another instance",
+			actual:  []*models.ElasticIP{moved(web, "i-2")},
+			desired: []*models.ElasticIP{web},
+			drifts: []models.Drift{
+				models.NewDrift(models.DriftTypeModified, "ElasticIPs[eipalloc-web].InstanceID", "i-2", "i-1",
+					"Elastic IP 203.0.113.10 declared by aws_eip_association.web is associated with i-2"),
+			},
+		},
+		{
+			name:    "unexpected address attached",
+			actual:  []*models.ElasticIP{web, {AllocationID: "eipalloc-extra", PublicIP: "198.51.100.7", InstanceID: "i-1"}},
+			desired: []*models.ElasticIP{web},
+			drifts: []models.Drift{
+				models.NewDrift(models.DriftTypeRemoved, "ElasticIPs[eipalloc-extra]", "198.51.100.7", nil,
+					"Elastic IP 198.51.100.7 is associated in AWS but not in Terraform"),
+			},
+		},
+		{
+			name:    "address of another instance attached",
+			actual:  []*models.ElasticIP{moved(web, "i-1")},
+			desired: []*models.ElasticIP{moved(web, "i-2")},
+			drifts: []models.Drift{
+				models.NewDrift(models.DriftTypeRemoved, "ElasticIPs[eipalloc-web]", "203.0.113.10", nil,
+					"Elastic IP 203.0.113.10 is associated in AWS, but aws_eip_association.web associates it with i-2"),
+			},
+		},
+		{
+			name:    "addresses of other instances",
+			actual:  []*models.ElasticIP{moved(web, "i-3")},
+			desired: []*models.ElasticIP{moved(web, "i-2")},
+		},
+		{
+			name:    "ignored address",
+			actual:  []*models.ElasticIP{{AllocationID: "eipalloc-extra", InstanceID: "i-1"}},
+			desired: []*models.ElasticIP{web},
+			opts:    []services.DetectorOption{services.WithIgnoredPaths("ElasticIPs[*]")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector, err := services.NewDriftDetectorWithOptions(tt.opts...)
+			require.NoError(t, err)
+			report := models.NewDriftReport("i-1")
+
+			detector.CompareElasticIPs("i-1", tt.actual, tt.desired, report)
+
+			if tt.drifts == nil {
+				assert.Empty(t, report.Drifts)
+				return
+			}
+			assert.Equal(t, tt.drifts, report.Drifts)
+		})
+	}
+}
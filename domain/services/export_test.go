@@ -0,0 +1,16 @@
+package services
+
+import (
+	"reflect"
+
+	"driftdetector/domain/models"
+)
+
+// CompareValues compares two values of the same type under the path name,
+// for tests of shapes the instance model does not hold yet
+func (d *DriftDetector) CompareValues(name string, actual, expected interface{}) *models.DriftReport {
+	report := models.NewDriftReport("i-test")
+	d.compareStruct(name, []string{name}, reflect.ValueOf(actual), reflect.ValueOf(expected), report)
+	report.SortDrifts()
+	return report
+}
@@ -0,0 +1,75 @@
+package services_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+// fuzzSeeds are instance configurations, each missing or holding sections
+// the others do not, paired with each other as the seed corpus
+var fuzzSeeds = []string{
+	`{}`,
+	`{"instance_id": "i-1", "instance_type": "t3.micro", "ami": "ami-1"}`,
+	`{"instance_id": "i-1", "tags": {"Name": "web", "Owner": "ops"}, "security_groups": [{"id": "sg-1"}, {"id": "sg-2"}]}`,
+	`{"instance_id": "i-1", "tags": [{"Key": "Name", "Value": "web"}], "security_groups": [{"id": "sg-1"}, {"id": "sg-1"}]}`,
+	`{"instance_id": "i-1", "metadata_options": {"http_tokens": "required", "http_endpoint": "enabled", "http_put_response_hop_limit": 2}}`,
+	`{"instance_id": "i-1", "metadata_options": {}, "hibernation": {"configured": true}, "enclave_options": {"enabled": false}}`,
+	`{"instance_id": "i-1", "root_volume_type": "gp3", "root_volume_iops": 3000, "ebs_block_devices": [{"device_name": "/dev/sdf", "volume_size": 50, "tags": {"Name": "data"}}]}`,
+	`{"instance_id": "i-1", "ebs_block_devices": [{"device_name": "/dev/sdf"}, {"device_name": "/dev/sdf", "encrypted": true}]}`,
+	`{"instance_id": "i-1", "network_interfaces": [{"device_index": 0, "groups": [{"id": "sg-1"}], "private_ip_addresses": ["10.0.0.1"]}]}`,
+	`{"instance_id": "i-1", "monitoring": true, "ebs_optimized": false, "user_data": "IyEvYmluL3NoCg==", "unknown_fields": ["AMI", "Nope"]}`,
+	`{"instance_id": "i-1", "launch_template": {"id": "lt-1", "version": "$Latest"}, "ignore_changes": ["Tags", "["]}`,
+}
+
+// FuzzDetectDrift compares instance configurations decoded from arbitrary
+// JSON with each other and with fixed ones, so that no shape of either side
+// can make the comparison panic
+func FuzzDetectDrift(f *testing.F) {
+	for _, actual := range fuzzSeeds {
+		for _, desired := range fuzzSeeds {
+			f.Add([]byte(actual), []byte(desired))
+		}
+	}
+
+	strict, err := services.NewDetectionServiceWithOptions(services.WithStrictNil(), services.WithStrict())
+	if err != nil {
+		f.Fatal(err)
+	}
+	detectors := []services.DetectionService{services.NewDetectionService(), strict}
+
+	f.Fuzz(func(t *testing.T, actualJSON, desiredJSON []byte) {
+		var actual, desired models.Instance
+		if json.Unmarshal(actualJSON, &actual) != nil || json.Unmarshal(desiredJSON, &desired) != nil {
+			t.Skip()
+		}
+		desired.ID = actual.ID
+
+		for _, svc := range detectors {
+			// DetectDrift turns a panic into ErrComparisonFailed, so any
+			// error here is a comparison that could not complete
+			report, err := svc.DetectDrift(context.Background(), &actual, &desired)
+			if err != nil {
+				t.Fatalf("comparing %s with %s: %v", actualJSON, desiredJSON, err)
+			}
+			if report.InstanceID != actual.ID {
+				t.Fatalf("report for %q, want %q", report.InstanceID, actual.ID)
+			}
+
+			// An instance has no field drifts from itself, though it may
+			// still miss the prerequisites of what it declares
+			report, err = svc.DetectDrift(context.Background(), &actual, &actual)
+			if err != nil {
+				t.Fatalf("comparing %s with itself: %v", actualJSON, err)
+			}
+			for _, d := range report.Drifts {
+				if d.Type != models.DriftTypePrerequisiteViolation {
+					t.Fatalf("comparing %s with itself: %s %s", actualJSON, d.Type, d.Path)
+				}
+			}
+		}
+	})
+}
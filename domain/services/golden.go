@@ -0,0 +1,102 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"driftdetector/domain/models"
+)
+
+// CompareGolden compares an instance against a golden template and returns
+// GOLDEN_MISMATCH findings. Only fields present in the template are compared;
+// template tags must be present on the instance but extra instance tags are allowed.
+func CompareGolden(actual *models.Instance, template *models.GoldenTemplate) []models.Drift {
+	if actual == nil || template == nil || template.Config == nil {
+		return nil
+	}
+
+	actualVal := reflect.ValueOf(actual).Elem()
+	expectedVal := reflect.ValueOf(template.Config).Elem()
+	prefix := "golden." + template.Name
+
+	var drifts []models.Drift
+	for _, jsonName := range template.Fields {
+		index, ok := jsonFieldIndex(actualVal.Type(), jsonName)
+		if !ok {
+			continue
+		}
+		path := prefix + "." + jsonName
+
+		switch jsonName {
+		case "tags":
+			drifts = append(drifts, compareGoldenTags(template.Name, path, actual.Tags, template.Config.Tags)...)
+		case "security_groups":
+			actualIDs, expectedIDs := securityGroupIDs(actual.SecurityGroups), securityGroupIDs(template.Config.SecurityGroups)
+			if !reflect.DeepEqual(actualIDs, expectedIDs) {
+				drifts = append(drifts, goldenMismatch(template.Name, path, actualIDs, expectedIDs))
+			}
+		default:
+			a, e := actualVal.Field(index).Interface(), expectedVal.Field(index).Interface()
+			if !reflect.DeepEqual(a, e) {
+				drifts = append(drifts, goldenMismatch(template.Name, path, a, e))
+			}
+		}
+	}
+
+	return drifts
+}
+
+// compareGoldenTags checks that every template tag is present with the same value
+func compareGoldenTags(template, path string, actual, expected map[string]string) []models.Drift {
+	keys := make([]string, 0, len(expected))
+	for k := range expected {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var drifts []models.Drift
+	for _, k := range keys {
+		if value, ok := actual[k]; !ok || value != expected[k] {
+			var actualValue interface{}
+			if ok {
+				actualValue = value
+			}
+			drifts = append(drifts, goldenMismatch(template, path+"."+k, actualValue, expected[k]))
+		}
+	}
+	return drifts
+}
+
+// goldenMismatch builds a GOLDEN_MISMATCH drift
+func goldenMismatch(template, path string, actual, expected interface{}) models.Drift {
+	return models.NewDrift(
+		models.DriftTypeGoldenMismatch,
+		path,
+		actual,
+		expected,
+		fmt.Sprintf("Does not match golden template %q", template),
+	)
+}
+
+// securityGroupIDs returns the sorted group IDs of a security group list
+func securityGroupIDs(groups []models.SecurityGroup) []string {
+	ids := make([]string, 0, len(groups))
+	for _, g := range groups {
+		ids = append(ids, g.GroupID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// jsonFieldIndex finds the struct field whose JSON name matches
+func jsonFieldIndex(t reflect.Type, jsonName string) (int, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("json")
+		if name, _, _ := strings.Cut(tag, ","); name == jsonName {
+			return i, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,87 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestGoldenSelector_Matches(t *testing.T) {
+	instance := models.NewInstance("i-123", "t3.micro", "ami-123")
+	instance.AddTag("Name", "batch-worker-01")
+	instance.AddTag("Role", "batch")
+
+	tests := []struct {
+		name     string
+		selector models.GoldenSelector
+		expected bool
+	}{
+		{"tag match", models.GoldenSelector{Tags: map[string]string{"Role": "batch"}}, true},
+		{"tag glob", models.GoldenSelector{Tags: map[string]string{"Role": "b*"}}, true},
+		{"name pattern", models.GoldenSelector{NamePattern: "batch-worker-*"}, true},
+		{"all constraints must match", models.GoldenSelector{NamePattern: "batch-*", Tags: map[string]string{"Role": "web"}}, false},
+		{"missing tag", models.GoldenSelector{Tags: map[string]string{"Team": "data"}}, false},
+		{"empty selector matches nothing", models.GoldenSelector{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.selector.Matches(instance))
+		})
+	}
+}
+
+func TestGoldenSelector_Overlaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     models.GoldenSelector
+		expected bool
+	}{
+		{"different literal tag values", models.GoldenSelector{Tags: map[string]string{"Role": "batch"}}, models.GoldenSelector{Tags: map[string]string{"Role": "web"}}, false},
+		{"disjoint name prefixes", models.GoldenSelector{NamePattern: "batch-*"}, models.GoldenSelector{NamePattern: "web-*"}, false},
+		{"nested name prefixes", models.GoldenSelector{NamePattern: "batch-*"}, models.GoldenSelector{NamePattern: "batch-large-*"}, true},
+		{"different keys", models.GoldenSelector{Tags: map[string]string{"Role": "batch"}}, models.GoldenSelector{Tags: map[string]string{"Team": "data"}}, true},
+		{"disjoint suffixes", models.GoldenSelector{NamePattern: "*-blue"}, models.GoldenSelector{NamePattern: "*-green"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.a.Overlaps(tt.b))
+			assert.Equal(t, tt.expected, tt.b.Overlaps(tt.a), "Overlap must be symmetric")
+		})
+	}
+}
+
+func TestCompareGolden(t *testing.T) {
+	template := &models.GoldenTemplate{
+		Name: "batch",
+		Config: &models.Instance{
+			AMI:            "ami-golden",
+			SecurityGroups: []models.SecurityGroup{{GroupID: "sg-b"}, {GroupID: "sg-a"}},
+			Tags:           map[string]string{"Role": "batch", "Patch": "weekly"},
+			Type:           "m5.large",
+		},
+		Fields: []string{"ami", "security_groups", "tags"},
+	}
+
+	actual := models.NewInstance("i-123", "c5.xlarge", "ami-golden")
+	actual.SecurityGroups = []models.SecurityGroup{{GroupID: "sg-a"}, {GroupID: "sg-b"}}
+	actual.AddTag("Role", "batch")
+	actual.AddTag("Owner", "data")
+
+	drifts := services.CompareGolden(actual, template)
+
+	assert.Len(t, drifts, 1, "Type is absent from the template and extra tags are allowed")
+	assert.Equal(t, models.DriftTypeGoldenMismatch, drifts[0].Type)
+	assert.Equal(t, "golden.batch.tags.Patch", drifts[0].Path)
+	assert.Nil(t, drifts[0].Actual)
+	assert.Equal(t, "weekly", drifts[0].Expected)
+
+	actual.AMI = "ami-other"
+	drifts = services.CompareGolden(actual, template)
+	assert.Len(t, drifts, 2)
+	assert.Equal(t, "golden.batch.ami", drifts[0].Path)
+}
@@ -0,0 +1,60 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestDriftDetector_IAMInstanceProfile(t *testing.T) {
+	const webARN = "arn:aws:iam::123456789012:instance-profile/web"
+
+	tests := []struct {
+		name    string
+		actual  string
+		desired string
+		drift   bool
+	}{
+		{"both empty", "", "", false},
+		{"same name", "web", "web", false},
+		{"ARN from AWS matches name", webARN, "web", false},
+		{"ARN with a path matches name", "arn:aws:iam::123456789012:instance-profile/apps/web", "web", false},
+		{"GovCloud ARN matches name", "arn:aws-us-gov:iam::123456789012:instance-profile/web", "web", false},
+		{"different profile", "arn:aws:iam::123456789012:instance-profile/admin", "web", true},
+		{"detached in AWS", "", "web", true},
+		{"attached outside Terraform", webARN, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+			actual.IAMInstanceProfile = tt.actual
+			desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+			desired.IAMInstanceProfile = tt.desired
+
+			report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+			if tt.drift {
+				assert.Equal(t, []string{"IAMInstanceProfile"}, driftPaths(report))
+				assert.Equal(t, models.InstanceProfileName(tt.actual), report.Drifts[0].Actual, "drift shows profile names")
+			} else {
+				assert.Empty(t, report.Drifts)
+			}
+		})
+	}
+}
+
+func TestDriftDetector_IAMInstanceProfileIgnored(t *testing.T) {
+	actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+	actual.IAMInstanceProfile = "arn:aws:iam::123456789012:instance-profile/admin"
+	desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+	desired.IAMInstanceProfile = "web"
+
+	detector, err := services.NewDriftDetectorWithOptions(services.WithIgnoredPaths("IAMInstanceProfile"))
+	assert.NoError(t, err)
+
+	assert.Empty(t, detector.CompareInstances(actual, desired).Drifts)
+}
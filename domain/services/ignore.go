@@ -0,0 +1,239 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"driftdetector/domain/models"
+)
+
+// DetectorOption configures a DriftDetector
+type DetectorOption func(*DriftDetector) error
+
+// WithIgnoredPaths excludes the given field paths from drift detection
+func WithIgnoredPaths(patterns ...string) DetectorOption {
+	return func(d *DriftDetector) error {
+		return d.IgnoreFields(patterns...)
+	}
+}
+
+// WithOnlyPaths restricts drift detection to the given field paths, which
+// are written like the paths given to IgnoreFields. Fields outside them are
+// not compared at all; ignored paths are still left out of the fields they
+// select.
+func WithOnlyPaths(patterns ...string) DetectorOption {
+	return func(d *DriftDetector) error {
+		for _, p := range patterns {
+			segments, err := parseFieldPath(p)
+			if err != nil {
+				return err
+			}
+			d.onlyPatterns = append(d.onlyPatterns, segments)
+		}
+		return nil
+	}
+}
+
+// NewDriftDetectorWithOptions creates a DriftDetector configured by opts
+func NewDriftDetectorWithOptions(opts ...DetectorOption) (*DriftDetector, error) {
+	d := NewDriftDetector()
+	for _, opt := range opts {
+		if err := opt(d); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// IgnoreFields excludes field paths from drift detection. A path names struct
+// fields separated by dots, with map keys and slice element keys either in brackets
+// or as dotted segments, e.g. "PublicIPAddress", "Tags[aws:*]" or
+// "EBSBlockDevices[*].SnapshotID". Each segment may use path.Match wildcards.
+// Findings at or below an ignored path are suppressed.
+func (d *DriftDetector) IgnoreFields(patterns ...string) error {
+	for _, p := range patterns {
+		segments, err := parseFieldPath(p)
+		if err != nil {
+			return err
+		}
+		d.ignorePatterns = append(d.ignorePatterns, segments)
+	}
+	return nil
+}
+
+// PathFilter drops findings at or below ignored field paths from reports
+// that were already produced, so ignore paths can be tried out without
+// comparing the instances again. A nil PathFilter drops nothing.
+type PathFilter struct {
+	patterns [][]string
+}
+
+// NewPathFilter creates a PathFilter for patterns, which are written like
+// the paths given to IgnoreFields
+func NewPathFilter(patterns ...string) (*PathFilter, error) {
+	f := &PathFilter{}
+	for _, p := range patterns {
+		segments, err := parseFieldPath(p)
+		if err != nil {
+			return nil, err
+		}
+		f.patterns = append(f.patterns, segments)
+	}
+	return f, nil
+}
+
+// Ignores reports whether a finding at path is dropped
+func (f *PathFilter) Ignores(path string) bool {
+	if f == nil {
+		return false
+	}
+	segments, err := parseFieldPath(path)
+	if err != nil {
+		// Findings about the whole instance have an empty path
+		return false
+	}
+	for _, pattern := range f.patterns {
+		if matchSegments(pattern, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns a copy of report without the findings the filter ignores
+func (f *PathFilter) Apply(report *models.DriftReport) *models.DriftReport {
+	if f == nil || report == nil {
+		return report
+	}
+
+	filtered := *report
+	filtered.Drifts = make([]models.Drift, 0, len(report.Drifts))
+	for _, d := range report.Drifts {
+		if !f.Ignores(d.Path) {
+			filtered.Drifts = append(filtered.Drifts, d)
+		}
+	}
+	filtered.HasDrift = models.ContainsDrift(filtered.Drifts)
+	return &filtered
+}
+
+// TopLevelField returns the struct field a finding at path belongs to, e.g.
+// "Tags" for ".Tags.Name", or "" for findings about the whole instance
+func TopLevelField(path string) string {
+	segments, err := parseFieldPath(path)
+	if err != nil {
+		return ""
+	}
+	return segments[0]
+}
+
+// parseFieldPath splits a field path into segments, validating wildcards
+func parseFieldPath(p string) ([]string, error) {
+	var segments []string
+	var current strings.Builder
+	inBracket := false
+
+	flush := func() {
+		if current.Len() > 0 {
+			segments = append(segments, current.String())
+			current.Reset()
+		}
+	}
+
+	for _, r := range strings.TrimSpace(p) {
+		switch {
+		case r == '[' && !inBracket:
+			flush()
+			inBracket = true
+		case r == ']' && inBracket:
+			segments = append(segments, current.String())
+			current.Reset()
+			inBracket = false
+		case r == '.' && !inBracket:
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if inBracket {
+		return nil, fmt.Errorf("invalid field path %q: unclosed bracket", p)
+	}
+	flush()
+
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("invalid field path %q: empty", p)
+	}
+
+	for _, s := range segments {
+		if _, err := path.Match(s, ""); err != nil {
+			return nil, fmt.Errorf("invalid field path %q: %w", p, err)
+		}
+	}
+
+	return segments, nil
+}
+
+// isIgnored reports whether the field at segments is at or below an ignored
+// path, or outside the paths comparison is restricted to
+func (d *DriftDetector) isIgnored(segments []string) bool {
+	if len(segments) > 0 && d.computedFields[segments[0]] {
+		return true
+	}
+	if !d.isSelected(segments) {
+		return true
+	}
+	for _, pattern := range d.ignorePatterns {
+		if matchSegments(pattern, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSelected reports whether the field at segments is compared under
+// onlyPatterns: it is at or below one of them, or above one and so holds a
+// selected field. Every field is selected when there are none.
+func (d *DriftDetector) isSelected(segments []string) bool {
+	if len(d.onlyPatterns) == 0 {
+		return true
+	}
+	for _, pattern := range d.onlyPatterns {
+		if overlapSegments(pattern, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments reports whether pattern matches the leading segments
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) > len(segments) {
+		return false
+	}
+	for i, p := range pattern {
+		if !matchSegment(p, segments[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchSegment reports whether the pattern p matches one segment. A lone *
+// matches any segment, including keys such as device names that contain a
+// slash, which path.Match wildcards do not cross.
+func matchSegment(p, segment string) bool {
+	if p == "*" {
+		return true
+	}
+	ok, _ := path.Match(p, segment)
+	return ok
+}
+
+// appendSegment returns a copy of segments with s appended, so sibling
+// fields never share a backing array
+func appendSegment(segments []string, s string) []string {
+	out := make([]string, len(segments), len(segments)+1)
+	copy(out, segments)
+	return append(out, s)
+}
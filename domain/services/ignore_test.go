@@ -0,0 +1,248 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func driftPaths(report *models.DriftReport) []string {
+	var paths []string
+	for _, d := range report.Drifts {
+		paths = append(paths, d.Path)
+	}
+	return paths
+}
+
+func TestDriftDetector_IgnoreFields(t *testing.T) {
+	newPair := func() (*models.Instance, *models.Instance) {
+		actual := models.NewInstance("i-1", "t3.micro", "ami-resolved")
+		actual.PublicIPAddress = "203.0.113.10"
+		actual.AddTag("Name", "web")
+		actual.AddTag("aws:cloudformation:stack-name", "web-stack")
+		actual.SecurityGroups = []models.SecurityGroup{{GroupID: "sg-1", GroupName: "web"}}
+
+		desired := models.NewInstance("i-1", "t3.micro", "ami-pinned")
+		desired.AddTag("Name", "web-old")
+		desired.AddTag("aws:autoscaling:groupName", "web-asg")
+		desired.SecurityGroups = []models.SecurityGroup{{GroupID: "sg-1", GroupName: "legacy"}}
+		return actual, desired
+	}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		expected []string
+	}{
+		{
+			name:     "nothing ignored",
+			expected: []string{"AMI", "PublicIPAddress", "Tags.Name", "Tags.aws:autoscaling:groupName", "Tags.aws:cloudformation:stack-name", "SecurityGroups[sg-1].GroupName"},
+		},
+		{
+			name:     "top-level fields",
+			patterns: []string{"AMI", "PublicIPAddress"},
+			expected: []string{"Tags.Name", "Tags.aws:autoscaling:groupName", "Tags.aws:cloudformation:stack-name", "SecurityGroups[sg-1].GroupName"},
+		},
+		{
+			name:     "map key glob suppresses added and removed keys",
+			patterns: []string{"Tags[aws:*]"},
+			expected: []string{"AMI", "PublicIPAddress", "Tags.Name", "SecurityGroups[sg-1].GroupName"},
+		},
+		{
+			name:     "dotted map key",
+			patterns: []string{"Tags.Name"},
+			expected: []string{"AMI", "PublicIPAddress", "Tags.aws:autoscaling:groupName", "Tags.aws:cloudformation:stack-name", "SecurityGroups[sg-1].GroupName"},
+		},
+		{
+			name:     "slice element wildcard",
+			patterns: []string{"SecurityGroups[*].GroupName"},
+			expected: []string{"AMI", "PublicIPAddress", "Tags.Name", "Tags.aws:autoscaling:groupName", "Tags.aws:cloudformation:stack-name"},
+		},
+		{
+			name:     "everything below a field",
+			patterns: []string{"Tags", "SecurityGroups"},
+			expected: []string{"AMI", "PublicIPAddress"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// aws: tags and computed fields are compared here so patterns can suppress them
+			detector, err := services.NewDriftDetectorWithOptions(services.WithAWSTags(), services.WithStrict(), services.WithIgnoredPaths(tt.patterns...))
+			require.NoError(t, err)
+
+			actual, desired := newPair()
+			report := detector.CompareInstances(actual, desired)
+
+			assert.ElementsMatch(t, tt.expected, driftPaths(report))
+		})
+	}
+}
+
+func TestDriftDetector_IgnoreFieldsInvalid(t *testing.T) {
+	for _, pattern := range []string{"Tags[aws:*", "", "Tags[[]"} {
+		t.Run(pattern, func(t *testing.T) {
+			_, err := services.NewDriftDetectorWithOptions(services.WithIgnoredPaths(pattern))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestDriftDetector_OnlyPaths(t *testing.T) {
+	newPair := func() (*models.Instance, *models.Instance) {
+		actual := models.NewInstance("i-1", "t3.large", "ami-resolved")
+		actual.AddTag("Name", "web")
+		actual.AddTag("Team", "data")
+		actual.AddTag("aws:cloudformation:stack-name", "web-stack")
+		actual.SecurityGroups = []models.SecurityGroup{{GroupID: "sg-1", GroupName: "web"}}
+		actual.Hibernation = &models.HibernationOptions{Configured: true}
+
+		desired := models.NewInstance("i-1", "t3.micro", "ami-pinned")
+		desired.AddTag("Name", "web-old")
+		desired.AddTag("Team", "web")
+		desired.SecurityGroups = []models.SecurityGroup{{GroupID: "sg-1", GroupName: "legacy"}}
+		desired.Hibernation = &models.HibernationOptions{Configured: true}
+		return actual, desired
+	}
+
+	tests := []struct {
+		name     string
+		only     []string
+		ignored  []string
+		expected []string
+	}{
+		{
+			name:     "nothing selected compares everything",
+			expected: []string{"Type", "AMI", "Tags.Name", "Tags.Team", "Tags.aws:cloudformation:stack-name", "SecurityGroups[sg-1].GroupName", "Hibernation.RootVolumeEncrypted"},
+		},
+		{
+			name:     "top-level fields",
+			only:     []string{"Type", "AMI"},
+			expected: []string{"Type", "AMI"},
+		},
+		{
+			name:     "map key",
+			only:     []string{"Tags[Team]"},
+			expected: []string{"Tags.Team"},
+		},
+		{
+			name:     "slice element field glob",
+			only:     []string{"SecurityGroups[*].GroupName"},
+			expected: []string{"SecurityGroups[sg-1].GroupName"},
+		},
+		{
+			name:     "prerequisites of a selected capability",
+			only:     []string{"Hibernation"},
+			expected: []string{"Hibernation.RootVolumeEncrypted"},
+		},
+		{
+			name:     "ignored paths within the selection",
+			only:     []string{"Tags"},
+			ignored:  []string{"Tags[aws:*]"},
+			expected: []string{"Tags.Name", "Tags.Team"},
+		},
+		{
+			name:     "ignoring the whole selection",
+			only:     []string{"Tags[Team]"},
+			ignored:  []string{"Tags"},
+			expected: nil,
+		},
+		{
+			name:     "ignored paths outside the selection",
+			only:     []string{"Type", "Tags[Name]"},
+			ignored:  []string{"AMI"},
+			expected: []string{"Type", "Tags.Name"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector, err := services.NewDriftDetectorWithOptions(services.WithAWSTags(), services.WithOnlyPaths(tt.only...), services.WithIgnoredPaths(tt.ignored...))
+			require.NoError(t, err)
+
+			actual, desired := newPair()
+			report := detector.CompareInstances(actual, desired)
+
+			assert.ElementsMatch(t, tt.expected, driftPaths(report))
+		})
+	}
+
+	t.Run("invalid path", func(t *testing.T) {
+		_, err := services.NewDriftDetectorWithOptions(services.WithOnlyPaths("Tags["))
+		assert.Error(t, err)
+	})
+}
+
+func TestDriftDetector_LifecycleIgnoreChanges(t *testing.T) {
+	detector, err := services.NewDriftDetectorWithOptions(services.WithIgnoredPaths("KeyName"))
+	require.NoError(t, err)
+
+	actual := models.NewInstance("i-1", "t3.large", "ami-new")
+	actual.KeyName = "rotated"
+	actual.AddTag("LastPatched", "2024-06-01")
+	actual.AddTag("Owner", "ops")
+
+	desired := models.NewInstance("i-1", "t3.micro", "ami-old")
+	desired.KeyName = "original"
+	desired.AddTag("LastPatched", "2024-01-01")
+	desired.AddTag("Owner", "platform")
+	desired.IgnoreChanges = []string{"AMI", "Tags[LastPatched]"}
+
+	report := detector.CompareInstances(actual, desired)
+
+	assert.ElementsMatch(t, []string{"Type", "Tags.Owner"}, driftPaths(report))
+	assert.Equal(t, 2, report.SuppressedByLifecycle, "findings hidden by --ignore are not counted")
+
+	// The detector's own ignore list is unchanged
+	desired.IgnoreChanges = nil
+	report = detector.CompareInstances(actual, desired)
+	assert.ElementsMatch(t, []string{"Type", "AMI", "Tags.LastPatched", "Tags.Owner"}, driftPaths(report))
+	assert.Zero(t, report.SuppressedByLifecycle)
+}
+
+func TestDriftDetector_IgnoreFields_DeviceNames(t *testing.T) {
+	// Device names contain slashes, which a path.Match wildcard does not cross
+	actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+	actual.EBSBlockDevices = []models.EBSBlockDevice{{DeviceName: "/dev/sdf", VolumeSize: 200, VolumeType: "gp3"}}
+	desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+	desired.EBSBlockDevices = []models.EBSBlockDevice{{DeviceName: "/dev/sdf", VolumeSize: 100, VolumeType: "gp2"}}
+
+	detector, err := services.NewDriftDetectorWithOptions(services.WithIgnoredPaths("EBSBlockDevices[*].VolumeSize"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"EBSBlockDevices[/dev/sdf].VolumeType"}, driftPaths(detector.CompareInstances(actual, desired)))
+}
+
+func TestPathFilter_Apply(t *testing.T) {
+	report := models.NewDriftReport("i-1")
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, "AMI", "ami-2", "ami-1", "AMI changed"))
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, ".Tags.Name", "web", "web-old", "tag changed"))
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, "EBSBlockDevices[/dev/sdf].VolumeSize", 200, 100, "size changed"))
+
+	filter, err := services.NewPathFilter("Tags", "EBSBlockDevices[*].VolumeSize")
+	require.NoError(t, err)
+
+	filtered := filter.Apply(report)
+
+	assert.Equal(t, []string{"AMI"}, driftPaths(filtered))
+	assert.True(t, filtered.HasDrift)
+	assert.Len(t, report.Drifts, 3, "the original report is left alone")
+
+	all, err := services.NewPathFilter("AMI", "Tags", "EBSBlockDevices")
+	require.NoError(t, err)
+	assert.False(t, all.Apply(report).HasDrift)
+
+	var none *services.PathFilter
+	assert.Same(t, report, none.Apply(report))
+}
+
+func TestTopLevelField(t *testing.T) {
+	assert.Equal(t, "Tags", services.TopLevelField(".Tags.Name"))
+	assert.Equal(t, "EBSBlockDevices", services.TopLevelField("EBSBlockDevices[/dev/sdf].VolumeSize"))
+	assert.Equal(t, "AMI", services.TopLevelField("AMI"))
+	assert.Empty(t, services.TopLevelField(""))
+}
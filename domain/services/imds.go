@@ -0,0 +1,68 @@
+package services
+
+import (
+	"fmt"
+
+	"driftdetector/domain/models"
+)
+
+// AuditIMDS checks that actual does not allow IMDSv1 and that its metadata
+// endpoint is the one desired configures. IMDSv1 is allowed while the
+// endpoint is enabled and tokens are optional; that is a violation when the
+// configuration requires tokens or disables the endpoint, and a warning
+// otherwise. actual is nil when the instance was not found.
+func AuditIMDS(actual, desired *models.Instance) *models.IMDSAudit {
+	audit := &models.IMDSAudit{
+		InstanceID:      desired.ID,
+		ResourceAddress: desired.ResourceAddress,
+		Expected:        desired.MetadataOptions,
+		Status:          models.IMDSCompliant,
+	}
+	if actual == nil {
+		audit.Status = models.IMDSMissing
+		audit.Findings = []string{"instance not found in AWS"}
+		return audit
+	}
+	audit.Actual = actual.MetadataOptions
+
+	var expected, current models.MetadataOptions
+	if desired.MetadataOptions != nil {
+		expected = *desired.MetadataOptions
+	}
+	if actual.MetadataOptions != nil {
+		current = *actual.MetadataOptions
+	}
+
+	// An unset endpoint is enabled, and unset tokens are optional
+	if current.HTTPEndpoint != models.HTTPEndpointDisabled && current.HTTPTokens != models.HTTPTokensRequired {
+		audit.Findings = append(audit.Findings, "IMDSv1 is allowed: http_tokens is "+orDefault(current.HTTPTokens, models.HTTPTokensOptional))
+		switch {
+		case expected.HTTPTokens == models.HTTPTokensRequired:
+			audit.Status = models.IMDSViolation
+			audit.Findings = append(audit.Findings, "Terraform requires http_tokens = required")
+		case expected.HTTPEndpoint == models.HTTPEndpointDisabled:
+			audit.Status = models.IMDSViolation
+			audit.Findings = append(audit.Findings, "Terraform disables the metadata endpoint")
+		default:
+			audit.Status = models.IMDSWarning
+		}
+	}
+
+	if expected.HTTPEndpoint != "" && expected.HTTPEndpoint != orDefault(current.HTTPEndpoint, models.HTTPEndpointEnabled) {
+		audit.Findings = append(audit.Findings, fmt.Sprintf("http_endpoint is %s, Terraform expects %s",
+			orDefault(current.HTTPEndpoint, models.HTTPEndpointEnabled), expected.HTTPEndpoint))
+		if audit.Status == models.IMDSCompliant {
+			audit.Status = models.IMDSWarning
+		}
+	}
+
+	return audit
+}
+
+// orDefault returns value, or def when value is empty
+func orDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
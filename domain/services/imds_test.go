@@ -0,0 +1,98 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+// imdsInstance returns an instance with the given metadata options, or none
+// when both are empty
+func imdsInstance(tokens, endpoint string) *models.Instance {
+	inst := models.NewInstance("i-1", "t3.micro", "ami-1")
+	if tokens != "" || endpoint != "" {
+		inst.MetadataOptions = &models.MetadataOptions{HTTPTokens: tokens, HTTPEndpoint: endpoint}
+	}
+	return inst
+}
+
+func TestAuditIMDS(t *testing.T) {
+	tests := []struct {
+		name     string
+		actual   *models.Instance
+		desired  *models.Instance
+		status   models.IMDSStatus
+		findings []string
+	}{
+		{
+			name:    "tokens required as configured",
+			actual:  imdsInstance("required", "enabled"),
+			desired: imdsInstance("required", "enabled"),
+			status:  models.IMDSCompliant,
+		},
+		{
+			name:     "IMDSv1 allowed although Terraform requires tokens",
+			actual:   imdsInstance("optional", "enabled"),
+			desired:  imdsInstance("required", ""),
+			status:   models.IMDSViolation,
+			findings: []string{"IMDSv1 is allowed: http_tokens is optional", "Terraform requires http_tokens = required"},
+		},
+		{
+			name:     "IMDSv1 allowed as configured",
+			actual:   imdsInstance("optional", "enabled"),
+			desired:  imdsInstance("optional", ""),
+			status:   models.IMDSWarning,
+			findings: []string{"IMDSv1 is allowed: http_tokens is optional"},
+		},
+		{
+			name:     "no settings on either side",
+			actual:   imdsInstance("", ""),
+			desired:  imdsInstance("", ""),
+			status:   models.IMDSWarning,
+			findings: []string{"IMDSv1 is allowed: http_tokens is optional"},
+		},
+		{
+			name:    "endpoint disabled as configured",
+			actual:  imdsInstance("optional", "disabled"),
+			desired: imdsInstance("", "disabled"),
+			status:  models.IMDSCompliant,
+		},
+		{
+			name:    "endpoint enabled although Terraform disables it",
+			actual:  imdsInstance("optional", "enabled"),
+			desired: imdsInstance("", "disabled"),
+			status:  models.IMDSViolation,
+			findings: []string{
+				"IMDSv1 is allowed: http_tokens is optional",
+				"Terraform disables the metadata endpoint",
+				"http_endpoint is enabled, Terraform expects disabled",
+			},
+		},
+		{
+			name:     "endpoint disabled although Terraform enables it",
+			actual:   imdsInstance("required", "disabled"),
+			desired:  imdsInstance("required", "enabled"),
+			status:   models.IMDSWarning,
+			findings: []string{"http_endpoint is disabled, Terraform expects enabled"},
+		},
+		{
+			name:     "instance not found",
+			desired:  imdsInstance("required", ""),
+			status:   models.IMDSMissing,
+			findings: []string{"instance not found in AWS"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			audit := services.AuditIMDS(tt.actual, tt.desired)
+
+			assert.Equal(t, "i-1", audit.InstanceID)
+			assert.Equal(t, tt.status, audit.Status)
+			assert.Equal(t, tt.findings, audit.Findings)
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestDriftDetector_InstanceOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []services.DetectorOption
+		actual  func(*models.Instance)
+		desired func(*models.Instance)
+		paths   []string
+	}{
+		{
+			name:    "hibernation disabled outside Terraform",
+			actual:  func(i *models.Instance) { i.Hibernation = &models.HibernationOptions{Configured: false} },
+			desired: func(i *models.Instance) { i.Hibernation = &models.HibernationOptions{Configured: true} },
+			paths:   []string{"Hibernation.Configured"},
+		},
+		{
+			name:    "hibernation enabled on both sides",
+			actual:  func(i *models.Instance) { i.Hibernation = &models.HibernationOptions{Configured: true} },
+			desired: func(i *models.Instance) { i.Hibernation = &models.HibernationOptions{Configured: true} },
+		},
+		{
+			name:    "enclave enabled outside Terraform",
+			actual:  func(i *models.Instance) { i.EnclaveOptions = &models.EnclaveOptions{Enabled: true} },
+			desired: func(i *models.Instance) { i.EnclaveOptions = &models.EnclaveOptions{Enabled: false} },
+			paths:   []string{"EnclaveOptions.Enabled"},
+		},
+		{
+			name:    "shutdown behavior changed",
+			actual:  func(i *models.Instance) { i.InstanceInitiatedShutdownBehavior = "terminate" },
+			desired: func(i *models.Instance) { i.InstanceInitiatedShutdownBehavior = "stop" },
+			paths:   []string{"InstanceInitiatedShutdownBehavior"},
+		},
+		{
+			name:    "unset shutdown behavior equals the AWS default",
+			actual:  func(i *models.Instance) { i.InstanceInitiatedShutdownBehavior = "stop" },
+			desired: func(i *models.Instance) {},
+		},
+		{
+			name:    "unset shutdown behavior differs from terminate",
+			actual:  func(i *models.Instance) { i.InstanceInitiatedShutdownBehavior = "terminate" },
+			desired: func(i *models.Instance) {},
+			paths:   []string{"InstanceInitiatedShutdownBehavior"},
+		},
+		{
+			name:    "strict reports the AWS default for an unset shutdown behavior",
+			opts:    []services.DetectorOption{services.WithStrict()},
+			actual:  func(i *models.Instance) { i.InstanceInitiatedShutdownBehavior = "stop" },
+			desired: func(i *models.Instance) {},
+			paths:   []string{"InstanceInitiatedShutdownBehavior"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// An m5.xlarge with an encrypted root volume meets the
+			// hibernation and enclave prerequisites
+			encrypted := true
+			actual := models.NewInstance("i-1", "m5.xlarge", "ami-1")
+			actual.RootVolumeEncrypted = &encrypted
+			tt.actual(actual)
+			desired := models.NewInstance("i-1", "m5.xlarge", "ami-1")
+			desired.RootVolumeEncrypted = &encrypted
+			tt.desired(desired)
+			behavior := desired.InstanceInitiatedShutdownBehavior
+
+			detector, err := services.NewDriftDetectorWithOptions(tt.opts...)
+			require.NoError(t, err)
+			report := detector.CompareInstances(actual, desired)
+
+			if tt.paths == nil {
+				assert.Empty(t, report.Drifts)
+			} else {
+				assert.Equal(t, tt.paths, driftPaths(report))
+			}
+			assert.Equal(t, behavior, desired.InstanceInitiatedShutdownBehavior, "the desired instance is left unchanged")
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestDriftDetector_NestedMaps(t *testing.T) {
+	actual := map[string]map[string]string{
+		"retries": {"max": "5", "backoff": "exponential"},
+		"timeout": {"connect": "10s"},
+		"logging": {"level": "info"},
+	}
+	expected := map[string]map[string]string{
+		"retries": {"max": "3", "backoff": "exponential"},
+		"timeout": {"connect": "10s", "read": "30s"},
+		"tracing": {"enabled": "true"},
+	}
+
+	report := services.NewDriftDetector().CompareValues("Options", actual, expected)
+
+	assert.Equal(t, []string{"Options.logging", "Options.tracing", "Options[retries].max", "Options[timeout].read"}, driftPaths(report))
+	for _, d := range report.Drifts {
+		if d.Path == "Options[retries].max" {
+			assert.Equal(t, models.DriftTypeModified, d.Type)
+			assert.Equal(t, "5", d.Actual)
+			assert.Equal(t, "3", d.Expected)
+		}
+	}
+}
+
+func TestDriftDetector_InterfaceMaps(t *testing.T) {
+	actual := map[string]interface{}{
+		"http":  map[string]interface{}{"port": 80.0, "paths": []interface{}{"/", "/health"}},
+		"name":  "web",
+		"extra": nil,
+	}
+	expected := map[string]interface{}{
+		"http":  map[string]interface{}{"port": 8080.0, "paths": []interface{}{"/", "/status"}},
+		"name":  "web",
+		"extra": nil,
+	}
+
+	report := services.NewDriftDetector().CompareValues("Attributes", actual, expected)
+
+	assert.Equal(t, []string{"Attributes[http].port", "Attributes[http][paths][1]"}, driftPaths(report))
+}
+
+func TestDriftDetector_NonStringKeys(t *testing.T) {
+	actual := map[int]string{2: "two", 10: "ten", 3: "three"}
+	expected := map[int]string{2: "two", 10: "TEN", 4: "four"}
+
+	report := services.NewDriftDetector().CompareValues("Codes", actual, expected)
+
+	require.Len(t, report.Drifts, 3)
+	assert.Equal(t, []string{"Codes.3", "Codes.4", "Codes.10"}, driftPaths(report))
+	assert.Equal(t, models.DriftTypeRemoved, report.Drifts[0].Type)
+	assert.Equal(t, models.DriftTypeAdded, report.Drifts[1].Type)
+	assert.Equal(t, models.DriftTypeModified, report.Drifts[2].Type)
+}
+
+func TestDriftDetector_NestedMapIgnored(t *testing.T) {
+	actual := map[string]map[string]string{"retries": {"max": "5", "backoff": "linear"}}
+	expected := map[string]map[string]string{"retries": {"max": "3", "backoff": "exponential"}}
+
+	detector, err := services.NewDriftDetectorWithOptions(services.WithIgnoredPaths("Options[retries].max"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"Options[retries].backoff"}, driftPaths(detector.CompareValues("Options", actual, expected)))
+}
+
+func TestDriftDetector_MapEntryPaths(t *testing.T) {
+	// Given a map holding both a plain value and a nested map
+	actual := map[string]interface{}{
+		"retries": 5.0,
+		"timeout": map[string]interface{}{"read": "10s"},
+	}
+	expected := map[string]interface{}{
+		"retries": 3.0,
+		"timeout": map[string]interface{}{"read": "30s"},
+	}
+
+	t.Run("plain entries follow a dot and nested entries are bracketed", func(t *testing.T) {
+		report := services.NewDriftDetector().CompareValues("Options", actual, expected)
+
+		assert.Equal(t, []string{"Options.retries", "Options[timeout].read"}, driftPaths(report))
+	})
+
+	t.Run("ignore patterns match both kinds of entry in either notation", func(t *testing.T) {
+		patterns := map[string]string{
+			"Options.retries":    "Options[timeout].read",
+			"Options[retries]":   "Options[timeout].read",
+			"Options.timeout":    "Options.retries",
+			"Options[timeout]":   "Options.retries",
+			"Options.timeout.*":  "Options.retries",
+			"Options[timeout].*": "Options.retries",
+		}
+		for pattern, left := range patterns {
+			detector, err := services.NewDriftDetectorWithOptions(services.WithIgnoredPaths(pattern))
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{left}, driftPaths(detector.CompareValues("Options", actual, expected)), pattern)
+		}
+	})
+
+	t.Run("only patterns match both kinds of entry in either notation", func(t *testing.T) {
+		patterns := map[string]string{
+			"Options.retries":    "Options.retries",
+			"Options[retries]":   "Options.retries",
+			"Options.timeout.*":  "Options[timeout].read",
+			"Options[timeout].*": "Options[timeout].read",
+		}
+		for pattern, path := range patterns {
+			detector, err := services.NewDriftDetectorWithOptions(services.WithOnlyPaths(pattern))
+			require.NoError(t, err)
+
+			assert.Equal(t, []string{path}, driftPaths(detector.CompareValues("Options", actual, expected)), pattern)
+		}
+	})
+}
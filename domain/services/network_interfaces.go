@@ -0,0 +1,68 @@
+package services
+
+import "driftdetector/domain/models"
+
+// resolveNetworkInterfaces returns desired with the network interface
+// settings its configuration leaves to AWS taken from actual. Interfaces are
+// only compared when the configuration declares some, and the groups of the
+// primary interface are left to SecurityGroups so a change is reported once.
+func resolveNetworkInterfaces(actual, desired *models.Instance) *models.Instance {
+	if len(desired.NetworkInterfaces) == 0 {
+		if len(actual.NetworkInterfaces) == 0 {
+			return desired
+		}
+		resolved := *desired
+		resolved.NetworkInterfaces = actual.NetworkInterfaces
+		return &resolved
+	}
+
+	actualByIndex := make(map[int]models.NetworkInterface, len(actual.NetworkInterfaces))
+	for _, ni := range actual.NetworkInterfaces {
+		actualByIndex[ni.DeviceIndex] = ni
+	}
+
+	resolved := *desired
+	resolved.NetworkInterfaces = append([]models.NetworkInterface(nil), desired.NetworkInterfaces...)
+	for i := range resolved.NetworkInterfaces {
+		ni := &resolved.NetworkInterfaces[i]
+		got, ok := actualByIndex[ni.DeviceIndex]
+		if !ok {
+			continue
+		}
+
+		if ni.NetworkInterfaceID == "" {
+			ni.NetworkInterfaceID = got.NetworkInterfaceID
+		}
+		if ni.DeleteOnTermination == nil {
+			ni.DeleteOnTermination = got.DeleteOnTermination
+		}
+		if ni.PrivateIPAddresses == nil {
+			ni.PrivateIPAddresses = got.PrivateIPAddresses
+		}
+		if ni.Groups == nil || (ni.DeviceIndex == 0 && len(desired.SecurityGroups) > 0) {
+			ni.Groups = got.Groups
+		} else {
+			ni.Groups = resolveGroupNames(got.Groups, ni.Groups)
+		}
+	}
+
+	return &resolved
+}
+
+// resolveGroupNames returns desired with the names Terraform does not record
+// taken from the actual groups of the same ID
+func resolveGroupNames(actual, desired []models.SecurityGroup) []models.SecurityGroup {
+	names := make(map[string]string, len(actual))
+	for _, sg := range actual {
+		names[sg.GroupID] = sg.GroupName
+	}
+
+	resolved := make([]models.SecurityGroup, len(desired))
+	for i, sg := range desired {
+		if sg.GroupName == "" {
+			sg.GroupName = names[sg.GroupID]
+		}
+		resolved[i] = sg
+	}
+	return resolved
+}
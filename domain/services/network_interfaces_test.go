@@ -0,0 +1,83 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestDriftDetector_NetworkInterfaces(t *testing.T) {
+	newActual := func() *models.Instance {
+		instance := models.NewInstance("i-1", "t3.micro", "ami-1")
+		instance.SecurityGroups = []models.SecurityGroup{{GroupID: "sg-web", GroupName: "web"}}
+		instance.NetworkInterfaces = []models.NetworkInterface{
+			{DeviceIndex: 0, NetworkInterfaceID: "eni-0", PrivateIPAddresses: []string{"10.0.0.1"}, Groups: []models.SecurityGroup{{GroupID: "sg-web", GroupName: "web"}}},
+			{DeviceIndex: 1, NetworkInterfaceID: "eni-1", PrivateIPAddresses: []string{"10.0.1.1"}, Groups: []models.SecurityGroup{{GroupID: "sg-debug", GroupName: "debug"}}},
+		}
+		return instance
+	}
+
+	driftPaths := func(report *models.DriftReport) map[string]models.DriftType {
+		paths := make(map[string]models.DriftType)
+		for _, d := range report.Drifts {
+			paths[d.Path] = d.Type
+		}
+		return paths
+	}
+
+	t.Run("group swapped on a secondary interface", func(t *testing.T) {
+		desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+		desired.SecurityGroups = []models.SecurityGroup{{GroupID: "sg-web", GroupName: "web"}}
+		desired.NetworkInterfaces = []models.NetworkInterface{
+			{DeviceIndex: 0, NetworkInterfaceID: "eni-0"},
+			{DeviceIndex: 1, NetworkInterfaceID: "eni-1", Groups: []models.SecurityGroup{{GroupID: "sg-app"}}},
+		}
+
+		report := services.NewDriftDetector().CompareInstances(newActual(), desired)
+
+		assert.Equal(t, map[string]models.DriftType{
+			"NetworkInterfaces[1].Groups[sg-app]":   models.DriftTypeAdded,
+			"NetworkInterfaces[1].Groups[sg-debug]": models.DriftTypeRemoved,
+		}, driftPaths(report), "Private IPs left to AWS should not drift")
+	})
+
+	t.Run("primary interface groups are reported once", func(t *testing.T) {
+		desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+		desired.SecurityGroups = []models.SecurityGroup{{GroupID: "sg-old", GroupName: "old"}}
+		desired.NetworkInterfaces = []models.NetworkInterface{
+			{DeviceIndex: 0, Groups: []models.SecurityGroup{{GroupID: "sg-old"}}},
+			{DeviceIndex: 1, Groups: []models.SecurityGroup{{GroupID: "sg-debug"}}},
+		}
+
+		report := services.NewDriftDetector().CompareInstances(newActual(), desired)
+
+		assert.Equal(t, map[string]models.DriftType{
+			"SecurityGroups[sg-old]": models.DriftTypeAdded,
+			"SecurityGroups[sg-web]": models.DriftTypeRemoved,
+		}, driftPaths(report))
+	})
+
+	t.Run("interface attached outside Terraform", func(t *testing.T) {
+		desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+		desired.SecurityGroups = []models.SecurityGroup{{GroupID: "sg-web", GroupName: "web"}}
+		desired.NetworkInterfaces = []models.NetworkInterface{{DeviceIndex: 0}}
+
+		report := services.NewDriftDetector().CompareInstances(newActual(), desired)
+
+		assert.Equal(t, map[string]models.DriftType{
+			"NetworkInterfaces[1]": models.DriftTypeRemoved,
+		}, driftPaths(report))
+	})
+
+	t.Run("interfaces not declared are not compared", func(t *testing.T) {
+		desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+		desired.SecurityGroups = []models.SecurityGroup{{GroupID: "sg-web", GroupName: "web"}}
+
+		report := services.NewDriftDetector().CompareInstances(newActual(), desired)
+
+		assert.Empty(t, report.Drifts)
+	})
+}
@@ -0,0 +1,142 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestDriftDetector_NilAndZeroValues(t *testing.T) {
+	f := false
+	tr := true
+
+	tests := []struct {
+		name     string
+		mutate   func(actual, desired *models.Instance)
+		expected []string
+	}{
+		{
+			name: "nil vs false",
+			mutate: func(actual, desired *models.Instance) {
+				desired.Monitoring = &f
+			},
+		},
+		{
+			name: "nil vs 0",
+			mutate: func(actual, desired *models.Instance) {
+				actual.MetadataOptions = &models.MetadataOptions{HTTPPutResponseHopLimit: 0}
+			},
+		},
+		{
+			name: `nil vs ""`,
+			mutate: func(actual, desired *models.Instance) {
+				desired.MetadataOptions = &models.MetadataOptions{HTTPTokens: ""}
+			},
+		},
+		{
+			name: "nil vs true",
+			mutate: func(actual, desired *models.Instance) {
+				actual.Monitoring = &tr
+			},
+			expected: []string{"Monitoring"},
+		},
+		{
+			name: "values behind both pointers are compared by field",
+			mutate: func(actual, desired *models.Instance) {
+				actual.MetadataOptions = &models.MetadataOptions{HTTPTokens: "optional", HTTPEndpoint: "enabled"}
+				desired.MetadataOptions = &models.MetadataOptions{HTTPTokens: "required", HTTPEndpoint: "enabled"}
+			},
+			expected: []string{"MetadataOptions.HTTPTokens"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+			desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+			tt.mutate(actual, desired)
+
+			report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+			assert.ElementsMatch(t, tt.expected, driftPaths(report))
+		})
+	}
+}
+
+func TestDriftDetector_StrictNil(t *testing.T) {
+	f := false
+	actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+	desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+	desired.Monitoring = &f
+	desired.MetadataOptions = &models.MetadataOptions{}
+
+	detector, err := services.NewDriftDetectorWithOptions(services.WithStrictNil())
+	require.NoError(t, err)
+
+	report := detector.CompareInstances(actual, desired)
+
+	assert.ElementsMatch(t, []string{"Monitoring", "MetadataOptions"}, driftPaths(report))
+}
+
+func TestDriftDetector_PointerSetOnOneSide(t *testing.T) {
+	options := &models.MetadataOptions{HTTPTokens: "required", HTTPEndpoint: "enabled"}
+
+	tests := []struct {
+		name      string
+		mutate    func(actual, desired *models.Instance)
+		driftType models.DriftType
+	}{
+		{
+			name: "declared in Terraform only",
+			mutate: func(actual, desired *models.Instance) {
+				desired.MetadataOptions = options
+			},
+			driftType: models.DriftTypeAdded,
+		},
+		{
+			name: "reported by AWS only",
+			mutate: func(actual, desired *models.Instance) {
+				actual.MetadataOptions = options
+			},
+			driftType: models.DriftTypeRemoved,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+			desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+			tt.mutate(actual, desired)
+
+			report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+			// The whole block is one finding rather than one per field
+			require.Len(t, report.Drifts, 1)
+			assert.Equal(t, "MetadataOptions", report.Drifts[0].Path)
+			assert.Equal(t, tt.driftType, report.Drifts[0].Type)
+		})
+	}
+}
+
+func TestDriftDetector_CompareValues_Mismatched(t *testing.T) {
+	detector := services.NewDriftDetector()
+
+	t.Run("missing value", func(t *testing.T) {
+		report := detector.CompareValues("Options", nil, models.MetadataOptions{HTTPTokens: "required"})
+
+		require.Len(t, report.Drifts, 1)
+		assert.Equal(t, models.DriftTypeAdded, report.Drifts[0].Type)
+		assert.Nil(t, report.Drifts[0].Actual)
+	})
+
+	t.Run("structs of different types", func(t *testing.T) {
+		report := detector.CompareValues("Options", models.MetadataOptions{HTTPTokens: "required"}, models.HibernationOptions{Configured: true})
+
+		require.Len(t, report.Drifts, 1)
+		assert.Equal(t, "Type mismatch", report.Drifts[0].Description)
+	})
+}
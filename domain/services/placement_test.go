@@ -0,0 +1,71 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestDriftDetector_Placement(t *testing.T) {
+	tests := []struct {
+		name    string
+		actual  func(*models.Instance)
+		desired func(*models.Instance)
+		paths   []string
+	}{
+		{
+			name:    "moved from default to dedicated tenancy",
+			actual:  func(i *models.Instance) { i.Tenancy = "dedicated" },
+			desired: func(i *models.Instance) { i.Tenancy = "default" },
+			paths:   []string{"Tenancy"},
+		},
+		{
+			name:    "placement group changed",
+			actual:  func(i *models.Instance) { i.PlacementGroup = "batch" },
+			desired: func(i *models.Instance) { i.PlacementGroup = "web" },
+			paths:   []string{"PlacementGroup"},
+		},
+		{
+			name:    "no placement group on either side",
+			actual:  func(i *models.Instance) { i.PlacementGroup = "" },
+			desired: func(i *models.Instance) { i.PlacementGroup = "" },
+		},
+		{
+			name: "partition changed",
+			actual: func(i *models.Instance) {
+				i.PlacementGroup = "kafka"
+				i.PartitionNumber = 3
+			},
+			desired: func(i *models.Instance) {
+				i.PlacementGroup = "kafka"
+				i.PartitionNumber = 1
+			},
+			paths: []string{"PartitionNumber"},
+		},
+		{
+			name:    "host assigned by AWS is not compared by default",
+			actual:  func(i *models.Instance) { i.HostID = "h-0123456789abcdef0" },
+			desired: func(i *models.Instance) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+			tt.actual(actual)
+			desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+			tt.desired(desired)
+
+			report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+			if tt.paths == nil {
+				assert.Empty(t, report.Drifts)
+			} else {
+				assert.Equal(t, tt.paths, driftPaths(report))
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"fmt"
+
+	"driftdetector/domain/models"
+)
+
+// checkPrerequisites validates cross-field requirements of declared capabilities.
+// A capability flag can match on both sides while the configuration that makes
+// it work has drifted, so these rules inspect the actual instance whenever either
+// side declares the capability and comparison is not restricted to other fields.
+func (d *DriftDetector) checkPrerequisites(actual, desired *models.Instance, report *models.DriftReport) {
+	if (actual.HibernationConfigured() || desired.HibernationConfigured()) && d.isSelected([]string{"Hibernation"}) {
+		d.checkHibernationPrerequisites(actual, report)
+	}
+
+	if (actual.EnclaveEnabled() || desired.EnclaveEnabled()) && d.isSelected([]string{"EnclaveOptions"}) {
+		d.checkEnclavePrerequisites(actual, report)
+	}
+}
+
+// checkHibernationPrerequisites verifies encryption, instance type support and root volume size
+func (d *DriftDetector) checkHibernationPrerequisites(actual *models.Instance, report *models.DriftReport) {
+	if actual.RootVolumeEncrypted == nil || !*actual.RootVolumeEncrypted {
+		report.AddDrift(models.NewDrift(
+			models.DriftTypePrerequisiteViolation,
+			"Hibernation.RootVolumeEncrypted",
+			actual.RootVolumeEncrypted,
+			true,
+			"Hibernation requires an encrypted root volume",
+		))
+	}
+
+	info, known := models.LookupInstanceType(actual.Type)
+	if !known {
+		return
+	}
+
+	if !info.HibernationSupported {
+		report.AddDrift(models.NewDrift(
+			models.DriftTypePrerequisiteViolation,
+			"Hibernation.Type",
+			actual.Type,
+			nil,
+			fmt.Sprintf("Instance type %s does not support hibernation", actual.Type),
+		))
+		return
+	}
+
+	if actual.RootVolumeSize > 0 && float64(actual.RootVolumeSize) < info.MemoryGiB {
+		report.AddDrift(models.NewDrift(
+			models.DriftTypePrerequisiteViolation,
+			"Hibernation.RootVolumeSize",
+			actual.RootVolumeSize,
+			info.MemoryGiB,
+			fmt.Sprintf("Root volume of %d GiB cannot hold the %.4g GiB of RAM of %s", actual.RootVolumeSize, info.MemoryGiB, actual.Type),
+		))
+	}
+}
+
+// checkEnclavePrerequisites verifies the instance type supports Nitro Enclaves
+func (d *DriftDetector) checkEnclavePrerequisites(actual *models.Instance, report *models.DriftReport) {
+	info, known := models.LookupInstanceType(actual.Type)
+	if !known || info.EnclavesSupported {
+		return
+	}
+
+	report.AddDrift(models.NewDrift(
+		models.DriftTypePrerequisiteViolation,
+		"EnclaveOptions.Type",
+		actual.Type,
+		nil,
+		fmt.Sprintf("Instance type %s does not support Nitro Enclaves", actual.Type),
+	))
+}
@@ -0,0 +1,145 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func prerequisiteViolations(report *models.DriftReport) []string {
+	var paths []string
+	for _, d := range report.Drifts {
+		if d.Type == models.DriftTypePrerequisiteViolation {
+			paths = append(paths, d.Path)
+		}
+	}
+	return paths
+}
+
+func TestDriftDetector_HibernationPrerequisites(t *testing.T) {
+	tests := []struct {
+		name      string
+		modify    func(actual, desired *models.Instance)
+		violation []string
+	}{
+		{
+			name:      "all prerequisites met",
+			modify:    func(actual, desired *models.Instance) {},
+			violation: nil,
+		},
+		{
+			name: "unencrypted root volume",
+			modify: func(actual, desired *models.Instance) {
+				actual.RootVolumeEncrypted = boolPtr(false)
+				desired.RootVolumeEncrypted = boolPtr(false)
+			},
+			violation: []string{"Hibernation.RootVolumeEncrypted"},
+		},
+		{
+			name: "unknown encryption",
+			modify: func(actual, desired *models.Instance) {
+				actual.RootVolumeEncrypted = nil
+				desired.RootVolumeEncrypted = nil
+			},
+			violation: []string{"Hibernation.RootVolumeEncrypted"},
+		},
+		{
+			name: "unsupported instance type",
+			modify: func(actual, desired *models.Instance) {
+				actual.Type = "x1.16xlarge"
+				desired.Type = "x1.16xlarge"
+			},
+			violation: []string{"Hibernation.Type"},
+		},
+		{
+			name: "root volume smaller than RAM",
+			modify: func(actual, desired *models.Instance) {
+				actual.RootVolumeSize = 6
+				desired.RootVolumeSize = 6
+			},
+			violation: []string{"Hibernation.RootVolumeSize"},
+		},
+		{
+			name: "only desired declares hibernation",
+			modify: func(actual, desired *models.Instance) {
+				actual.Hibernation = nil
+				actual.RootVolumeEncrypted = boolPtr(false)
+			},
+			violation: []string{"Hibernation.RootVolumeEncrypted"},
+		},
+		{
+			name: "unknown instance type skips type checks",
+			modify: func(actual, desired *models.Instance) {
+				actual.Type = "zz9.plural"
+				desired.Type = "zz9.plural"
+				actual.RootVolumeSize = 1
+				desired.RootVolumeSize = 1
+			},
+			violation: nil,
+		},
+		{
+			name: "hibernation not configured",
+			modify: func(actual, desired *models.Instance) {
+				actual.Hibernation = nil
+				desired.Hibernation = nil
+				actual.RootVolumeEncrypted = boolPtr(false)
+				desired.RootVolumeEncrypted = boolPtr(false)
+			},
+			violation: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newInstance := func() *models.Instance {
+				inst := models.NewInstance("i-123", "m5.large", "ami-123")
+				inst.RootVolumeSize = 30
+				inst.RootVolumeEncrypted = boolPtr(true)
+				inst.Hibernation = &models.HibernationOptions{Configured: true}
+				return inst
+			}
+			actual, desired := newInstance(), newInstance()
+			tt.modify(actual, desired)
+
+			report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+			assert.Equal(t, tt.violation, prerequisiteViolations(report))
+		})
+	}
+}
+
+func TestDriftDetector_EnclavePrerequisites(t *testing.T) {
+	tests := []struct {
+		name         string
+		instanceType string
+		enabled      bool
+		violation    []string
+	}{
+		{"supported type", "m5.xlarge", true, nil},
+		{"too few vCPUs", "m5.large", true, []string{"EnclaveOptions.Type"}},
+		{"burstable type", "t3.2xlarge", true, []string{"EnclaveOptions.Type"}},
+		{"non-Nitro type", "m4.2xlarge", true, []string{"EnclaveOptions.Type"}},
+		{"enclaves disabled", "t3.micro", false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newInstance := func() *models.Instance {
+				inst := models.NewInstance("i-123", tt.instanceType, "ami-123")
+				inst.EnclaveOptions = &models.EnclaveOptions{Enabled: tt.enabled}
+				return inst
+			}
+
+			report := services.NewDriftDetector().CompareInstances(newInstance(), newInstance())
+
+			assert.Equal(t, tt.violation, prerequisiteViolations(report))
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"driftdetector/domain/models"
+)
+
+// DefaultRedactedPaths are the fields whose values are hidden in reports
+// unless redaction is turned off, since reports often end up in CI logs
+var DefaultRedactedPaths = []string{
+	"UserData",
+	"RootVolumeKMSKeyID",
+	"EBSBlockDevices[*].KMSKeyID",
+}
+
+// Redactor hides the values of sensitive fields in drift reports. A nil
+// Redactor redacts nothing.
+type Redactor struct {
+	patterns [][]string
+}
+
+// NewRedactor creates a Redactor for DefaultRedactedPaths and paths, which
+// are written like ignore paths, e.g. "Tags[Secret*]"
+func NewRedactor(paths ...string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, p := range append(append([]string{}, DefaultRedactedPaths...), paths...) {
+		segments, err := parseFieldPath(p)
+		if err != nil {
+			return nil, err
+		}
+		r.patterns = append(r.patterns, segments)
+	}
+	return r, nil
+}
+
+// Redacts reports whether values at path are hidden. Values that contain a
+// sensitive field, such as a whole block device, are hidden too.
+func (r *Redactor) Redacts(path string) bool {
+	if r == nil {
+		return false
+	}
+	segments, err := parseFieldPath(path)
+	if err != nil {
+		// Findings about the whole instance, with an empty path, record
+		// its state in AWS rather than its settings
+		return false
+	}
+	for _, pattern := range r.patterns {
+		if overlapSegments(pattern, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlapSegments reports whether pattern matches segments as far as both
+// go, i.e. whether the field at segments is at, below or above the pattern
+func overlapSegments(pattern, segments []string) bool {
+	for i := 0; i < len(pattern) && i < len(segments); i++ {
+		if !matchSegment(pattern[i], segments[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Redact returns a copy of report whose findings at sensitive paths have
+// their actual and expected values replaced by fingerprints, and suggested
+// remediations reduced to the generic one. Only the copy
+// is changed, so whether and how the instance drifted is unaffected.
+func (r *Redactor) Redact(report *models.DriftReport) *models.DriftReport {
+	if r == nil || report == nil {
+		return report
+	}
+
+	redacted := *report
+	redacted.Drifts = r.redactDrifts(report.Drifts)
+	if report.Acknowledged != nil {
+		redacted.Acknowledged = r.redactDrifts(report.Acknowledged)
+	}
+	return &redacted
+}
+
+// redactDrifts returns a copy of drifts with the values at sensitive paths
+// replaced by fingerprints
+func (r *Redactor) redactDrifts(drifts []models.Drift) []models.Drift {
+	redacted := make([]models.Drift, len(drifts))
+	for i, d := range drifts {
+		if r.Redacts(d.Path) {
+			d.Actual = RedactValue(d.Actual)
+			d.Expected = RedactValue(d.Expected)
+			// A suggestion may quote the expected value
+			if d.Remediation != "" {
+				d.Remediation = defaultRemediation
+			}
+		}
+		redacted[i] = d
+	}
+	return redacted
+}
+
+// RedactValue replaces v with a short SHA-256 fingerprint, such as
+// "sha256:ab12cd34…(redacted)", so equal values can still be recognised.
+// nil stays nil, since an absent value reveals nothing.
+func RedactValue(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%v", v)))
+	return "sha256:" + hex.EncodeToString(sum[:4]) + "…(redacted)"
+}
@@ -0,0 +1,98 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestRedactor_Redact(t *testing.T) {
+	actual := models.NewInstance("i-1", "t3.large", "ami-1")
+	actual.UserData = "#!/bin/bash\nexport DB_PASSWORD=hunter2"
+	actual.RootVolumeKMSKeyID = "arn:aws:kms:us-east-1:123456789012:key/actual"
+	actual.AddTag("Secret", "s3cr3t")
+	actual.EBSBlockDevices = []models.EBSBlockDevice{{DeviceName: "/dev/sdf", KMSKeyID: "arn:aws:kms:us-east-1:123456789012:key/data-actual"}}
+	desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+	desired.UserData = "#!/bin/bash\nexport DB_PASSWORD=changeme"
+	desired.RootVolumeKMSKeyID = "arn:aws:kms:us-east-1:123456789012:key/expected"
+	desired.AddTag("Secret", "old")
+	desired.EBSBlockDevices = []models.EBSBlockDevice{{DeviceName: "/dev/sdf", KMSKeyID: "arn:aws:kms:us-east-1:123456789012:key/data-expected"}}
+
+	report := services.NewDriftDetector().CompareInstances(actual, desired)
+	redactor, err := services.NewRedactor("Tags[Secret]")
+	require.NoError(t, err)
+
+	redacted := redactor.Redact(report)
+
+	values := func(r *models.DriftReport) map[string][2]interface{} {
+		byPath := make(map[string][2]interface{})
+		for _, d := range r.Drifts {
+			byPath[d.Path] = [2]interface{}{d.Actual, d.Expected}
+		}
+		return byPath
+	}
+	original, hidden := values(report), values(redacted)
+
+	assert.Equal(t, driftPaths(report), driftPaths(redacted), "redaction keeps every finding")
+	assert.True(t, redacted.HasDrifts())
+	assert.Equal(t, [2]interface{}{"t3.large", "t3.micro"}, hidden["Type"], "other fields are shown")
+	for _, path := range []string{"UserData", "RootVolumeKMSKeyID", "EBSBlockDevices[/dev/sdf].KMSKeyID", "Tags.Secret"} {
+		require.Contains(t, hidden, path)
+		assert.Regexp(t, `^sha256:[0-9a-f]{8}…\(redacted\)$`, hidden[path][0], path)
+		assert.NotEqual(t, hidden[path][0], hidden[path][1], "%s: different values keep different fingerprints", path)
+	}
+
+	// The report the drift decision is made from is unchanged
+	assert.Equal(t, "arn:aws:kms:us-east-1:123456789012:key/actual", original["RootVolumeKMSKeyID"][0])
+	assert.Equal(t, "s3cr3t", original["Tags.Secret"][0])
+}
+
+func TestRedactor_RedactRemediation(t *testing.T) {
+	report := models.NewDriftReport("i-1")
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, "Type", "t3.large", "t3.micro", "Value modified"))
+	services.SuggestRemediations(report, nil)
+	redactor, err := services.NewRedactor("Type")
+	require.NoError(t, err)
+
+	redacted := redactor.Redact(report)
+
+	assert.NotContains(t, redacted.Drifts[0].Remediation, "t3.micro", "the suggestion does not reveal the expected value")
+	assert.Contains(t, report.Drifts[0].Remediation, "t3.micro")
+}
+
+func TestRedactor_RedactAcknowledged(t *testing.T) {
+	report := models.NewDriftReport("i-1")
+	report.Acknowledged = []models.Drift{models.NewDrift(models.DriftTypeModified, "UserData", "export TOKEN=abc", "", "Value modified")}
+
+	redactor, err := services.NewRedactor()
+	require.NoError(t, err)
+
+	redacted := redactor.Redact(report)
+
+	assert.Regexp(t, `^sha256:`, redacted.Acknowledged[0].Actual, "acknowledged findings are redacted too")
+	assert.Equal(t, "export TOKEN=abc", report.Acknowledged[0].Actual)
+}
+
+func TestRedactor_Redacts(t *testing.T) {
+	redactor, err := services.NewRedactor()
+	require.NoError(t, err)
+
+	assert.True(t, redactor.Redacts("UserData"))
+	assert.True(t, redactor.Redacts("EBSBlockDevices[/dev/sdf].KMSKeyID"))
+	assert.True(t, redactor.Redacts("EBSBlockDevices[/dev/sdf]"), "a whole device holds its key ID")
+	assert.False(t, redactor.Redacts("EBSBlockDevices[/dev/sdf].VolumeSize"))
+	assert.False(t, redactor.Redacts(".Tags.Name"))
+	assert.False(t, redactor.Redacts(""), "instance-level findings show the instance state")
+
+	var disabled *services.Redactor
+	assert.False(t, disabled.Redacts("UserData"))
+	report := models.NewDriftReport("i-1")
+	assert.Same(t, report, disabled.Redact(report))
+
+	_, err = services.NewRedactor("Tags[Secret")
+	assert.ErrorContains(t, err, "unclosed bracket")
+}
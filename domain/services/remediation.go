@@ -0,0 +1,150 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"driftdetector/domain/models"
+)
+
+// remediationRule suggests how to reconcile findings at the paths matching
+// pattern. Hints may refer to {instance}, the instance ID, {key}, the last
+// segment of the path, {expected}, the expected value, and {groups}, the
+// expected security group IDs; a rule whose hint refers to a value that is
+// not known is skipped.
+type remediationRule struct {
+	pattern string
+	// types limits the rule to these kinds of finding; empty matches any
+	types []models.DriftType
+	hint  string
+}
+
+// remediationRules are tried in order, so more specific rules come first.
+// Findings no rule matches are reconciled by terraform apply.
+var remediationRules = []remediationRule{
+	{
+		pattern: "Tags[*]",
+		types:   []models.DriftType{models.DriftTypeRemoved},
+		hint:    "terraform apply will remove the tag, or remove it now with: aws ec2 delete-tags --resources {instance} --tags Key={key}",
+	},
+	{
+		pattern: "Tags[*]",
+		hint:    "terraform apply will re-assert the tag",
+	},
+	{
+		pattern: "Type",
+		types:   []models.DriftType{models.DriftTypeModified},
+		hint:    "terraform apply will stop the instance, change its type to {expected} and start it again",
+	},
+	{
+		pattern: "SecurityGroups[*]",
+		types:   []models.DriftType{models.DriftTypeAdded, models.DriftTypeRemoved},
+		hint:    "terraform apply will restore the security groups, or restore them now with: aws ec2 modify-instance-attribute --instance-id {instance} --groups {groups}",
+	},
+	{
+		pattern: "AMI",
+		hint:    "terraform apply will replace the instance with one launched from {expected}",
+	},
+	{
+		pattern: "AvailabilityZone",
+		hint:    "terraform apply will replace the instance in {expected}",
+	},
+	{
+		pattern: "SubnetID",
+		hint:    "terraform apply will replace the instance in subnet {expected}",
+	},
+}
+
+// defaultRemediation is the hint for field findings no rule matches
+const defaultRemediation = "terraform apply will restore the configured value"
+
+// remediationPlaceholder matches the placeholders of a hint
+var remediationPlaceholder = regexp.MustCompile(`\{[a-z]+\}`)
+
+// SuggestRemediations sets the Remediation of each field finding of report
+// from the first matching rule. desired supplies values such as the expected
+// security groups and may be nil. Policy, golden template and prerequisite
+// findings are left without a suggestion.
+func SuggestRemediations(report *models.DriftReport, desired *models.Instance) {
+	var groups []string
+	if desired != nil {
+		// Groups Terraform names without an ID cannot be passed to the AWS CLI
+		for _, sg := range desired.SecurityGroups {
+			if sg.GroupID != "" {
+				groups = append(groups, sg.GroupID)
+			}
+		}
+	}
+
+	for i := range report.Drifts {
+		d := &report.Drifts[i]
+		switch d.Type {
+		case models.DriftTypeAdded, models.DriftTypeRemoved, models.DriftTypeModified:
+		default:
+			continue
+		}
+
+		segments, err := parseFieldPath(d.Path)
+		if err != nil {
+			continue
+		}
+		values := map[string]string{
+			"{instance}": report.InstanceID,
+			"{key}":      shellQuote(segments[len(segments)-1]),
+			"{groups}":   strings.Join(groups, " "),
+		}
+		if d.Expected != nil {
+			values["{expected}"] = fmt.Sprintf("%v", d.Expected)
+		}
+
+		d.Remediation = defaultRemediation
+		for _, rule := range remediationRules {
+			if hint, ok := rule.render(d, segments, values); ok {
+				d.Remediation = hint
+				break
+			}
+		}
+	}
+}
+
+// render returns the rule's hint for the finding d at segments, or false when
+// the rule does not match it or a value the hint refers to is not known
+func (r remediationRule) render(d *models.Drift, segments []string, values map[string]string) (string, bool) {
+	pattern, err := parseFieldPath(r.pattern)
+	if err != nil || len(pattern) != len(segments) || !matchSegments(pattern, segments) {
+		return "", false
+	}
+	if len(r.types) > 0 && !containsDriftType(r.types, d.Type) {
+		return "", false
+	}
+
+	known := true
+	hint := remediationPlaceholder.ReplaceAllStringFunc(r.hint, func(placeholder string) string {
+		value := values[placeholder]
+		if value == "" {
+			known = false
+		}
+		return value
+	})
+	return hint, known
+}
+
+// containsDriftType reports whether types includes t
+func containsDriftType(types []models.DriftType, t models.DriftType) bool {
+	for _, candidate := range types {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
+
+// shellQuote quotes s for a POSIX shell unless it only holds characters
+// that need no quoting
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.:/@+=") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
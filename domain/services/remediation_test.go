@@ -0,0 +1,104 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestSuggestRemediations(t *testing.T) {
+	desired := models.NewInstance("i-1", "t3.large", "ami-new")
+	desired.SecurityGroups = []models.SecurityGroup{{GroupID: "sg-web"}, {GroupID: "sg-ssh"}}
+
+	tests := []struct {
+		name     string
+		drift    models.Drift
+		desired  *models.Instance
+		expected string
+	}{
+		{
+			name:     "extra tag",
+			drift:    models.NewDrift(models.DriftTypeRemoved, ".Tags.Owner", "alice", nil, "Field removed"),
+			expected: "terraform apply will remove the tag, or remove it now with: aws ec2 delete-tags --resources i-1 --tags Key=Owner",
+		},
+		{
+			name:     "extra tag with a key that needs quoting",
+			drift:    models.NewDrift(models.DriftTypeRemoved, "Tags[Cost Center]", "42", nil, "Field removed"),
+			expected: "terraform apply will remove the tag, or remove it now with: aws ec2 delete-tags --resources i-1 --tags Key='Cost Center'",
+		},
+		{
+			name:     "missing tag",
+			drift:    models.NewDrift(models.DriftTypeAdded, ".Tags.Owner", nil, "alice", "Field added"),
+			expected: "terraform apply will re-assert the tag",
+		},
+		{
+			name:     "changed tag",
+			drift:    models.NewDrift(models.DriftTypeModified, ".Tags.Environment", "test", "production", "Value modified"),
+			expected: "terraform apply will re-assert the tag",
+		},
+		{
+			name:     "instance type",
+			drift:    models.NewDrift(models.DriftTypeModified, "Type", "t3.micro", "t3.large", "Value modified"),
+			expected: "terraform apply will stop the instance, change its type to t3.large and start it again",
+		},
+		{
+			name:     "security group attached outside Terraform",
+			drift:    models.NewDrift(models.DriftTypeAdded, "SecurityGroups[sg-debug]", models.SecurityGroup{GroupID: "sg-debug"}, nil, "Field added"),
+			desired:  desired,
+			expected: "terraform apply will restore the security groups, or restore them now with: aws ec2 modify-instance-attribute --instance-id i-1 --groups sg-web sg-ssh",
+		},
+		{
+			name:     "security groups without the expected groups",
+			drift:    models.NewDrift(models.DriftTypeRemoved, "SecurityGroups[sg-web]", nil, models.SecurityGroup{GroupID: "sg-web"}, "Field removed"),
+			expected: "terraform apply will restore the configured value",
+		},
+		{
+			name:     "security group rule",
+			drift:    models.NewDrift(models.DriftTypeAdded, "SecurityGroups[sg-web].Ingress[tcp/22]", "0.0.0.0/0", nil, "Field added"),
+			desired:  desired,
+			expected: "terraform apply will restore the configured value",
+		},
+		{
+			name:     "AMI",
+			drift:    models.NewDrift(models.DriftTypeModified, "AMI", "ami-old", "ami-new", "Value modified"),
+			expected: "terraform apply will replace the instance with one launched from ami-new",
+		},
+		{
+			name:     "availability zone",
+			drift:    models.NewDrift(models.DriftTypeModified, "AvailabilityZone", "us-east-1b", "us-east-1a", "Value modified"),
+			expected: "terraform apply will replace the instance in us-east-1a",
+		},
+		{
+			name:     "subnet",
+			drift:    models.NewDrift(models.DriftTypeModified, "SubnetID", "subnet-b", "subnet-a", "Value modified"),
+			expected: "terraform apply will replace the instance in subnet subnet-a",
+		},
+		{
+			name:     "any other field",
+			drift:    models.NewDrift(models.DriftTypeModified, "Monitoring", false, true, "Value modified"),
+			expected: "terraform apply will restore the configured value",
+		},
+		{
+			name:  "policy violation",
+			drift: models.NewDrift(models.DriftTypePolicyViolation, "Type", "t3.micro", nil, "instance type is not allowed"),
+		},
+		{
+			name:  "golden template mismatch",
+			drift: models.NewDrift(models.DriftTypeGoldenMismatch, "Type", "t3.micro", "t3.large", "differs from golden template"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := models.NewDriftReport("i-1")
+			report.AddDrift(tt.drift)
+
+			services.SuggestRemediations(report, tt.desired)
+
+			assert.Equal(t, tt.expected, report.Drifts[0].Remediation)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"driftdetector/domain/models"
+)
+
+// resolveSecurityGroups returns desired with its security groups correlated
+// with actual's. Terraform lists groups by ID in vpc_security_group_ids and
+// by name in security_groups, while AWS reports both. A group known by name
+// only takes the ID of the actual group of that name, and a group known by
+// ID takes the name AWS reports; a group listed both ways is kept once.
+// Groups are then compared by ID, or by name when no actual group has it.
+//
+// When Terraform names groups that AWS reports without names, neither
+// identifier can match them, so a single finding replaces the comparison of
+// the groups.
+func (d *DriftDetector) resolveSecurityGroups(actual, desired *models.Instance, report *models.DriftReport) *models.Instance {
+	if len(desired.SecurityGroups) == 0 {
+		return desired
+	}
+
+	namesByID := make(map[string]string, len(actual.SecurityGroups))
+	idsByName := make(map[string]string, len(actual.SecurityGroups))
+	unnamed := false
+	for _, sg := range actual.SecurityGroups {
+		namesByID[sg.GroupID] = sg.GroupName
+		if sg.GroupName == "" {
+			unnamed = true
+			continue
+		}
+		idsByName[sg.GroupName] = sg.GroupID
+	}
+
+	listed := make(map[string]bool, len(desired.SecurityGroups))
+	for _, sg := range desired.SecurityGroups {
+		if sg.GroupID != "" {
+			listed[sg.GroupID] = true
+		}
+	}
+
+	resolved := *desired
+	resolved.SecurityGroups = make([]models.SecurityGroup, 0, len(desired.SecurityGroups))
+	var uncorrelated []string
+	for _, sg := range desired.SecurityGroups {
+		switch {
+		case sg.GroupID == "" && sg.GroupName != "":
+			id, ok := idsByName[sg.GroupName]
+			if !ok {
+				if unnamed {
+					uncorrelated = append(uncorrelated, sg.GroupName)
+				}
+				break
+			}
+			if id != "" {
+				// The group is also listed by ID, or by name more than once
+				if listed[id] {
+					continue
+				}
+				listed[id] = true
+			}
+			sg.GroupID = id
+		case sg.GroupName == "":
+			sg.GroupName = namesByID[sg.GroupID]
+		}
+		resolved.SecurityGroups = append(resolved.SecurityGroups, sg)
+	}
+
+	if len(uncorrelated) == 0 {
+		return &resolved
+	}
+
+	segments := []string{"SecurityGroups"}
+	if !d.isIgnored(segments) {
+		report.AddDrift(models.NewDrift(
+			models.DriftTypeModified,
+			"SecurityGroups",
+			actual.SecurityGroups,
+			desired.SecurityGroups,
+			fmt.Sprintf("Cannot correlate security groups: Terraform names %s, but AWS reports groups without names", strings.Join(uncorrelated, ", ")),
+		))
+	}
+	resolved.SecurityGroups = actual.SecurityGroups
+	return &resolved
+}
@@ -0,0 +1,110 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestDriftDetector_SecurityGroupIDsAndNames(t *testing.T) {
+	named := []models.SecurityGroup{{GroupID: "sg-web", GroupName: "web"}, {GroupID: "sg-ssh", GroupName: "ssh"}}
+	unnamed := []models.SecurityGroup{{GroupID: "sg-web"}, {GroupID: "sg-ssh"}}
+
+	tests := []struct {
+		name     string
+		actual   []models.SecurityGroup
+		desired  []models.SecurityGroup
+		expected map[string]models.DriftType
+	}{
+		{
+			name:    "ID-only configuration takes the names AWS reports",
+			actual:  named,
+			desired: []models.SecurityGroup{{GroupID: "sg-web"}, {GroupID: "sg-ssh"}},
+		},
+		{
+			name:     "ID-only configuration missing a group",
+			actual:   named,
+			desired:  []models.SecurityGroup{{GroupID: "sg-web"}, {GroupID: "sg-db"}},
+			expected: map[string]models.DriftType{"SecurityGroups[sg-db]": models.DriftTypeAdded, "SecurityGroups[sg-ssh]": models.DriftTypeRemoved},
+		},
+		{
+			name:    "name-only configuration is matched by name",
+			actual:  named,
+			desired: []models.SecurityGroup{{GroupName: "ssh"}, {GroupName: "web"}},
+		},
+		{
+			name:     "name-only configuration missing a group",
+			actual:   named,
+			desired:  []models.SecurityGroup{{GroupName: "web"}, {GroupName: "db"}},
+			expected: map[string]models.DriftType{"SecurityGroups[db]": models.DriftTypeAdded, "SecurityGroups[sg-ssh]": models.DriftTypeRemoved},
+		},
+		{
+			name:    "mixed configuration",
+			actual:  named,
+			desired: []models.SecurityGroup{{GroupID: "sg-web"}, {GroupName: "ssh"}},
+		},
+		{
+			name:    "group listed both by ID and by name is kept once",
+			actual:  named,
+			desired: []models.SecurityGroup{{GroupID: "sg-web"}, {GroupID: "sg-ssh"}, {GroupName: "web"}, {GroupName: "ssh"}},
+		},
+		{
+			name:    "ID-only configuration against groups without names",
+			actual:  unnamed,
+			desired: []models.SecurityGroup{{GroupID: "sg-ssh"}, {GroupID: "sg-web"}},
+		},
+		{
+			name:     "names cannot be correlated with groups without names",
+			actual:   unnamed,
+			desired:  []models.SecurityGroup{{GroupID: "sg-web"}, {GroupName: "ssh"}},
+			expected: map[string]models.DriftType{"SecurityGroups": models.DriftTypeModified},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+			actual.SecurityGroups = tt.actual
+			desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+			desired.SecurityGroups = tt.desired
+
+			// When
+			report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+			// Then
+			paths := make(map[string]models.DriftType)
+			for _, d := range report.Drifts {
+				paths[d.Path] = d.Type
+			}
+			if tt.expected == nil {
+				tt.expected = map[string]models.DriftType{}
+			}
+			assert.Equal(t, tt.expected, paths)
+		})
+	}
+}
+
+func TestDriftDetector_SecurityGroupsCannotCorrelate(t *testing.T) {
+	// Given Terraform naming a group AWS reports by ID only
+	actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+	actual.SecurityGroups = []models.SecurityGroup{{GroupID: "sg-web"}}
+	desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+	desired.SecurityGroups = []models.SecurityGroup{{GroupName: "web"}}
+
+	// When
+	report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+	// Then one finding explains why, instead of an added and a removed group
+	if assert.Len(t, report.Drifts, 1) {
+		assert.Equal(t, "Cannot correlate security groups: Terraform names web, but AWS reports groups without names", report.Drifts[0].Description)
+	}
+
+	// And ignoring SecurityGroups leaves it out
+	detector := services.NewDriftDetector()
+	assert.NoError(t, detector.IgnoreFields("SecurityGroups"))
+	assert.Empty(t, detector.CompareInstances(actual, desired).Drifts)
+}
@@ -0,0 +1,108 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"driftdetector/domain/models"
+)
+
+// CompareSecurityGroups compares the rules, description and tags of the security
+// groups declared in Terraform with those in AWS. Groups are matched by ID;
+// desired groups with no actual counterpart are skipped because attachment
+// drift is already reported on the instance. Findings use paths such as
+// SecurityGroups[sg-123].Ingress[tcp/443].
+func (d *DriftDetector) CompareSecurityGroups(actual, desired []*models.SecurityGroupConfig, report *models.DriftReport) {
+	actualByID := make(map[string]*models.SecurityGroupConfig, len(actual))
+	for _, g := range actual {
+		actualByID[g.GroupID] = g
+	}
+
+	for _, want := range desired {
+		got, ok := actualByID[want.GroupID]
+		if !ok {
+			continue
+		}
+
+		prefix := fmt.Sprintf("SecurityGroups[%s]", want.GroupID)
+		segments := []string{"SecurityGroups", want.GroupID}
+		if d.isIgnored(segments) {
+			continue
+		}
+
+		if !d.isIgnored(appendSegment(segments, "Description")) && got.Description != want.Description {
+			report.AddDrift(models.NewDrift(
+				models.DriftTypeModified,
+				prefix+".Description",
+				got.Description,
+				want.Description,
+				"Value mismatch",
+			))
+		}
+
+		d.compareMaps(prefix+".Tags", appendSegment(segments, "Tags"), reflect.ValueOf(got.Tags), reflect.ValueOf(want.Tags), report)
+		d.compareRuleSets(prefix+".Ingress", appendSegment(segments, "Ingress"), got.Ingress, want.Ingress, report)
+		d.compareRuleSets(prefix+".Egress", appendSegment(segments, "Egress"), got.Egress, want.Egress, report)
+	}
+
+	report.ApplySeverity(d.severityRules)
+	report.SortDrifts()
+}
+
+// compareRuleSets compares two rule lists independently of their order
+func (d *DriftDetector) compareRuleSets(prefix string, segments []string, actual, expected []models.SecurityGroupRule, report *models.DriftReport) {
+	actualSet := models.RuleSet(actual)
+	expectedSet := models.RuleSet(expected)
+
+	keys := make(map[string]bool, len(actualSet)+len(expectedSet))
+	for k := range actualSet {
+		keys[k] = true
+	}
+	for k := range expectedSet {
+		keys[k] = true
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for _, key := range sorted {
+		if d.isIgnored(appendSegment(segments, key)) {
+			continue
+		}
+
+		path := fmt.Sprintf("%s[%s]", prefix, key)
+		got, inActual := actualSet[key]
+		want, inExpected := expectedSet[key]
+
+		switch {
+		case !inExpected:
+			report.AddDrift(models.NewDrift(
+				models.DriftTypeRemoved,
+				path,
+				got,
+				nil,
+				"Rule exists in AWS but not in Terraform",
+			))
+		case !inActual:
+			report.AddDrift(models.NewDrift(
+				models.DriftTypeAdded,
+				path,
+				nil,
+				want,
+				"Rule declared in Terraform is missing in AWS",
+			))
+		case !reflect.DeepEqual(got, want):
+			report.AddDrift(models.NewDrift(
+				models.DriftTypeModified,
+				path,
+				got,
+				want,
+				"Rule sources differ",
+			))
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func webGroup() *models.SecurityGroupConfig {
+	return &models.SecurityGroupConfig{
+		GroupID:     "sg-123",
+		Description: "web",
+		Tags:        map[string]string{"Name": "web"},
+		Ingress: []models.SecurityGroupRule{
+			{Protocol: "tcp", FromPort: 443, ToPort: 443, CIDRBlocks: []string{"0.0.0.0/0"}},
+			{Protocol: "tcp", FromPort: 22, ToPort: 22, CIDRBlocks: []string{"10.0.0.0/8", "192.168.0.0/16"}},
+		},
+		Egress: []models.SecurityGroupRule{
+			{Protocol: "-1", CIDRBlocks: []string{"0.0.0.0/0"}},
+		},
+	}
+}
+
+func TestDriftDetector_CompareSecurityGroups(t *testing.T) {
+	tests := []struct {
+		name     string
+		modify   func(actual *models.SecurityGroupConfig)
+		expected map[string]models.DriftType
+	}{
+		{
+			name:     "identical",
+			modify:   func(actual *models.SecurityGroupConfig) {},
+			expected: map[string]models.DriftType{},
+		},
+		{
+			name: "rule order and split blocks are irrelevant",
+			modify: func(actual *models.SecurityGroupConfig) {
+				actual.Ingress = []models.SecurityGroupRule{
+					{Protocol: "6", FromPort: 22, ToPort: 22, CIDRBlocks: []string{"192.168.0.0/16"}},
+					{Protocol: "TCP", FromPort: 443, ToPort: 443, CIDRBlocks: []string{"0.0.0.0/0"}},
+					{Protocol: "tcp", FromPort: 22, ToPort: 22, CIDRBlocks: []string{"10.0.0.0/8"}},
+				}
+			},
+			expected: map[string]models.DriftType{},
+		},
+		{
+			name: "rule added in the console",
+			modify: func(actual *models.SecurityGroupConfig) {
+				actual.Ingress = append(actual.Ingress, models.SecurityGroupRule{
+					Protocol: "tcp", FromPort: 3389, ToPort: 3389, CIDRBlocks: []string{"0.0.0.0/0"},
+				})
+			},
+			expected: map[string]models.DriftType{
+				"SecurityGroups[sg-123].Ingress[tcp/3389]": models.DriftTypeRemoved,
+			},
+		},
+		{
+			name: "rule deleted and source widened",
+			modify: func(actual *models.SecurityGroupConfig) {
+				actual.Ingress = []models.SecurityGroupRule{
+					{Protocol: "tcp", FromPort: 22, ToPort: 22, CIDRBlocks: []string{"0.0.0.0/0"}},
+				}
+			},
+			expected: map[string]models.DriftType{
+				"SecurityGroups[sg-123].Ingress[tcp/443]": models.DriftTypeAdded,
+				"SecurityGroups[sg-123].Ingress[tcp/22]":  models.DriftTypeModified,
+			},
+		},
+		{
+			name: "description, tags and egress",
+			modify: func(actual *models.SecurityGroupConfig) {
+				actual.Description = "changed"
+				actual.Tags["Owner"] = "someone"
+				actual.Egress = nil
+			},
+			expected: map[string]models.DriftType{
+				"SecurityGroups[sg-123].Description": models.DriftTypeModified,
+				"SecurityGroups[sg-123].Tags.Owner":  models.DriftTypeRemoved,
+				"SecurityGroups[sg-123].Egress[all]": models.DriftTypeAdded,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := webGroup()
+			tt.modify(actual)
+
+			report := models.NewDriftReport("i-1")
+			services.NewDriftDetector().CompareSecurityGroups(
+				[]*models.SecurityGroupConfig{actual},
+				[]*models.SecurityGroupConfig{webGroup()},
+				report,
+			)
+
+			found := make(map[string]models.DriftType)
+			for _, d := range report.Drifts {
+				found[d.Path] = d.Type
+			}
+			assert.Equal(t, tt.expected, found)
+		})
+	}
+}
+
+func TestDriftDetector_CompareSecurityGroupsIgnored(t *testing.T) {
+	actual := webGroup()
+	actual.Ingress = nil
+	actual.Description = "changed"
+
+	detector, err := services.NewDriftDetectorWithOptions(services.WithIgnoredPaths("SecurityGroups[*].Ingress"))
+	require.NoError(t, err)
+
+	report := models.NewDriftReport("i-1")
+	detector.CompareSecurityGroups([]*models.SecurityGroupConfig{actual}, []*models.SecurityGroupConfig{webGroup()}, report)
+
+	assert.Equal(t, []string{"SecurityGroups[sg-123].Description"}, driftPaths(report))
+}
+
+func TestDriftDetector_CompareSecurityGroupsUnmatched(t *testing.T) {
+	report := models.NewDriftReport("i-1")
+	services.NewDriftDetector().CompareSecurityGroups(nil, []*models.SecurityGroupConfig{webGroup()}, report)
+
+	assert.Empty(t, report.Drifts, "groups missing in AWS are covered by instance attachment drift")
+}
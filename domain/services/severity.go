@@ -0,0 +1,12 @@
+package services
+
+import "driftdetector/domain/models"
+
+// WithSeverityRules adds severity rules after the defaults. The most specific
+// rule for a path wins, and a rule for the same path as a default replaces it.
+func WithSeverityRules(rules ...models.SeverityRule) DetectorOption {
+	return func(d *DriftDetector) error {
+		d.severityRules = append(d.severityRules, rules...)
+		return nil
+	}
+}
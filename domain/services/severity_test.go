@@ -0,0 +1,60 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestClassifySeverity(t *testing.T) {
+	rules := models.DefaultSeverityRules()
+
+	tests := []struct {
+		path     string
+		expected models.Severity
+	}{
+		{"SecurityGroups[sg-1].Ingress[tcp/22]", models.SeverityCritical},
+		{"MetadataOptions.HTTPTokens", models.SeverityCritical},
+		{"MetadataOptions.HTTPEndpoint", models.SeverityWarning},
+		{"DisableAPITermination", models.SeverityCritical},
+		{".Tags.Name", models.SeverityInfo},
+		{"TagsExtra", models.SeverityWarning},
+		{"Type", models.SeverityWarning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.expected, models.ClassifySeverity(rules, tt.path))
+		})
+	}
+}
+
+func TestDriftDetector_Severity(t *testing.T) {
+	actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+	actual.AddTag("Name", "renamed")
+	desired := models.NewInstance("i-1", "t3.small", "ami-1")
+	desired.AddTag("Name", "web")
+
+	detector, err := services.NewDriftDetectorWithOptions(services.WithSeverityRules(
+		models.SeverityRule{Path: "Tags", Severity: models.SeverityCritical},
+	))
+	require.NoError(t, err)
+
+	report := detector.CompareInstances(actual, desired)
+
+	severities := make(map[string]models.Severity)
+	for _, d := range report.Drifts {
+		severities[d.Path] = d.Severity
+	}
+	assert.Equal(t, models.SeverityWarning, severities["Type"])
+	assert.Equal(t, models.SeverityCritical, severities["Tags.Name"], "configured rules override the defaults")
+
+	filtered := report.FilterBySeverity(models.SeverityCritical)
+	require.Len(t, filtered.Drifts, 1)
+	assert.Equal(t, "Tags.Name", filtered.Drifts[0].Path)
+	assert.Len(t, report.Drifts, 2, "filtering does not change the report")
+}
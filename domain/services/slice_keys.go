@@ -0,0 +1,109 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"driftdetector/domain/models"
+)
+
+// sliceKeyFunc returns the identity of a slice element
+type sliceKeyFunc func(elem reflect.Value) string
+
+// sliceKeyers identifies the elements of slices whose order carries no
+// meaning, so they are matched by key rather than by position
+var sliceKeyers = map[reflect.Type]sliceKeyFunc{
+	reflect.TypeOf(models.EBSBlockDevice{}): func(elem reflect.Value) string {
+		return elem.Interface().(models.EBSBlockDevice).DeviceName
+	},
+	// Groups Terraform names without an ID are matched by name
+	reflect.TypeOf(models.SecurityGroup{}): func(elem reflect.Value) string {
+		sg := elem.Interface().(models.SecurityGroup)
+		if sg.GroupID == "" {
+			return sg.GroupName
+		}
+		return sg.GroupID
+	},
+	reflect.TypeOf(models.NetworkInterface{}): func(elem reflect.Value) string {
+		return strconv.Itoa(elem.Interface().(models.NetworkInterface).DeviceIndex)
+	},
+}
+
+// compareKeyedSlices matches the elements of two slices by key and compares
+// matching elements field by field, producing paths such as
+// EBSBlockDevices[/dev/sdf].VolumeSize. It returns false without reporting
+// anything when an element has an empty or duplicate key.
+func (d *DriftDetector) compareKeyedSlices(prefix string, segments []string, keyOf sliceKeyFunc, actual, expected reflect.Value, report *models.DriftReport) bool {
+	actualByKey, ok := indexByKey(actual, keyOf)
+	if !ok {
+		return false
+	}
+	expectedByKey, ok := indexByKey(expected, keyOf)
+	if !ok {
+		return false
+	}
+
+	keys := make([]string, 0, len(actualByKey)+len(expectedByKey))
+	for k := range actualByKey {
+		keys = append(keys, k)
+	}
+	for k := range expectedByKey {
+		if _, ok := actualByKey[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	path := strings.TrimPrefix(prefix, ".")
+	for _, key := range keys {
+		elemSegments := appendSegment(segments, key)
+		if d.isIgnored(elemSegments) {
+			continue
+		}
+
+		elemPath := fmt.Sprintf("%s[%s]", path, key)
+		got, inActual := actualByKey[key]
+		want, inExpected := expectedByKey[key]
+
+		switch {
+		case !inExpected:
+			report.AddDrift(models.NewDrift(
+				models.DriftTypeRemoved,
+				elemPath,
+				got.Interface(),
+				nil,
+				"Element exists in AWS but not in Terraform",
+			))
+		case !inActual:
+			report.AddDrift(models.NewDrift(
+				models.DriftTypeAdded,
+				elemPath,
+				nil,
+				want.Interface(),
+				"Element declared in Terraform is missing in AWS",
+			))
+		default:
+			d.compareStruct(elemPath, elemSegments, got, want, report)
+		}
+	}
+
+	return true
+}
+
+// indexByKey maps every element of a slice to its key, reporting false if a
+// key is empty or shared by two elements
+func indexByKey(slice reflect.Value, keyOf sliceKeyFunc) (map[string]reflect.Value, bool) {
+	byKey := make(map[string]reflect.Value, slice.Len())
+	for i := 0; i < slice.Len(); i++ {
+		elem := slice.Index(i)
+		key := keyOf(elem)
+		if _, dup := byKey[key]; key == "" || dup {
+			return nil, false
+		}
+		byKey[key] = elem
+	}
+	return byKey, true
+}
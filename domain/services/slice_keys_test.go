@@ -0,0 +1,55 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestDriftDetector_KeyedSlices(t *testing.T) {
+	t.Run("elements are matched by key regardless of order", func(t *testing.T) {
+		actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+		actual.SecurityGroups = []models.SecurityGroup{{GroupID: "sg-b"}, {GroupID: "sg-a", GroupName: "web"}}
+		actual.EBSBlockDevices = []models.EBSBlockDevice{
+			{DeviceName: "/dev/sdf", VolumeSize: 20},
+			{DeviceName: "/dev/sdh", VolumeSize: 5},
+		}
+		desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+		desired.SecurityGroups = []models.SecurityGroup{{GroupID: "sg-a", GroupName: "web"}, {GroupID: "sg-b"}}
+		desired.EBSBlockDevices = []models.EBSBlockDevice{
+			{DeviceName: "/dev/sdf", VolumeSize: 10},
+			{DeviceName: "/dev/sdg", VolumeSize: 5},
+		}
+
+		report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+		drifts := make(map[string]models.DriftType)
+		for _, d := range report.Drifts {
+			drifts[d.Path] = d.Type
+		}
+		assert.Equal(t, map[string]models.DriftType{
+			"EBSBlockDevices[/dev/sdf].VolumeSize": models.DriftTypeModified,
+			"EBSBlockDevices[/dev/sdg]":            models.DriftTypeAdded,
+			"EBSBlockDevices[/dev/sdh]":            models.DriftTypeRemoved,
+		}, drifts)
+		assert.Empty(t, report.Warnings)
+	})
+
+	t.Run("duplicate keys fall back to positions with a warning", func(t *testing.T) {
+		actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+		actual.EBSBlockDevices = []models.EBSBlockDevice{{VolumeSize: 20}, {VolumeSize: 5}}
+		desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+		desired.EBSBlockDevices = []models.EBSBlockDevice{{VolumeSize: 10}, {VolumeSize: 5}}
+
+		report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+		require.Len(t, report.Drifts, 1)
+		assert.Equal(t, "EBSBlockDevices[0].VolumeSize", report.Drifts[0].Path)
+		require.Len(t, report.Warnings, 1)
+		assert.Contains(t, report.Warnings[0], "EBSBlockDevices")
+	})
+}
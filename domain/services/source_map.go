@@ -0,0 +1,50 @@
+package services
+
+import (
+	"strings"
+
+	"driftdetector/domain/models"
+)
+
+// sourceIndex finds where the configuration declares the field at a path
+type sourceIndex struct {
+	fields   map[string]*models.SourceLocation
+	resource *models.SourceLocation
+}
+
+// newSourceIndex indexes the SourceMap of desired by path segments, so that
+// Tags.Name finds the entry for Tags[Name]
+func newSourceIndex(desired *models.Instance) *sourceIndex {
+	index := &sourceIndex{resource: desired.Source}
+	if len(desired.SourceMap) == 0 {
+		return index
+	}
+
+	index.fields = make(map[string]*models.SourceLocation, len(desired.SourceMap))
+	for path, location := range desired.SourceMap {
+		if segments, err := parseFieldPath(path); err == nil {
+			index.fields[segmentKey(segments)] = location
+		}
+	}
+	return index
+}
+
+// lookup returns the location of the longest leading part of path the
+// SourceMap has, else the resource declaration; nil when neither is known
+func (i *sourceIndex) lookup(path string) *models.SourceLocation {
+	segments, err := parseFieldPath(path)
+	if err != nil || len(i.fields) == 0 {
+		return i.resource
+	}
+	for n := len(segments); n > 0; n-- {
+		if location, ok := i.fields[segmentKey(segments[:n])]; ok {
+			return location
+		}
+	}
+	return i.resource
+}
+
+// segmentKey joins segments with a separator no field name or key contains
+func segmentKey(segments []string) string {
+	return strings.Join(segments, "\x00")
+}
@@ -0,0 +1,55 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestDriftDetector_SourceMap(t *testing.T) {
+	resource := &models.SourceLocation{File: "main.tf", Line: 1}
+	desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+	desired.RootVolumeSize = 20
+	desired.KeyName = "deploy"
+	desired.AddTag("Team", "platform")
+	desired.Source = resource
+	desired.SourceMap = map[string]*models.SourceLocation{
+		"Type":           {File: "main.tf", Line: 3},
+		"RootVolumeSize": {File: "main.tf", Line: 6},
+		"Tags":           {File: "main.tf", Line: 10},
+		"Tags[Team]":     {File: "main.tf", Line: 12},
+	}
+
+	actual := models.NewInstance("i-1", "t3.large", "ami-1")
+	actual.RootVolumeSize = 30
+	actual.KeyName = "ops"
+	actual.AddTag("Team", "data")
+	actual.AddTag("Owner", "ops")
+
+	report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+	sources := make(map[string]string)
+	for _, d := range report.Drifts {
+		sources[d.Path] = d.Source.String()
+	}
+	assert.Equal(t, map[string]string{
+		"Type":           "main.tf:3",
+		"RootVolumeSize": "main.tf:6",
+		"Tags.Team":      "main.tf:12",
+		"Tags.Owner":     "main.tf:10",
+		"KeyName":        "main.tf:1",
+	}, sources)
+}
+
+func TestDriftDetector_SourceMapWithoutConfiguration(t *testing.T) {
+	desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+	actual := models.NewInstance("i-1", "t3.large", "ami-1")
+
+	report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+	assert.Len(t, report.Drifts, 1)
+	assert.Nil(t, report.Drifts[0].Source, "state has no source information")
+}
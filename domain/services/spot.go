@@ -0,0 +1,66 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"driftdetector/domain/models"
+)
+
+// compareMarketOptions reports an instance purchased differently than
+// Terraform declares, such as one expected on-demand that runs as spot, and
+// compares the spot settings of instances that run as spot on both sides
+func (d *DriftDetector) compareMarketOptions(actual, desired *models.Instance, report *models.DriftReport) {
+	segments := []string{"InstanceLifecycle"}
+	if !d.isIgnored(segments) && !d.compareCustom("InstanceLifecycle", segments, actual.InstanceLifecycle, desired.InstanceLifecycle, report) &&
+		actual.Lifecycle() != desired.Lifecycle() {
+		report.AddDrift(models.NewDrift(
+			models.DriftTypeModified,
+			"InstanceLifecycle",
+			actual.Lifecycle(),
+			desired.Lifecycle(),
+			fmt.Sprintf("Instance runs as %s, Terraform expects %s", actual.Lifecycle(), desired.Lifecycle()),
+		))
+		return
+	}
+
+	// Settings of a market the instance is not in cannot be compared, and
+	// the spot request AWS is read from may not have been found
+	if actual.InstanceMarketOptions == nil || desired.InstanceMarketOptions == nil {
+		return
+	}
+	expected := resolveMarketOptions(actual.InstanceMarketOptions, desired.InstanceMarketOptions)
+	current := resolveMarketOptions(actual.InstanceMarketOptions, actual.InstanceMarketOptions)
+	d.compareStruct(".InstanceMarketOptions", []string{"InstanceMarketOptions"},
+		reflect.ValueOf(current).Elem(), reflect.ValueOf(expected).Elem(), report)
+}
+
+// resolveMarketOptions returns a copy of desired with the settings it leaves
+// unset taken from actual, or from their default, and with its max price in
+// the form AWS reports, so "0.05" and "0.050000" are equal
+func resolveMarketOptions(actual, desired *models.InstanceMarketOptions) *models.InstanceMarketOptions {
+	resolved := *desired
+	if resolved.MarketType == "" {
+		resolved.MarketType = actual.MarketType
+	}
+	// An unset max price defaults to the on-demand price, which AWS reports
+	if resolved.MaxPrice == "" {
+		resolved.MaxPrice = actual.MaxPrice
+	}
+	resolved.MaxPrice = normalizePrice(resolved.MaxPrice)
+	if resolved.InstanceInterruptionBehavior == "" {
+		resolved.InstanceInterruptionBehavior = models.SpotInterruptionTerminate
+	}
+	return &resolved
+}
+
+// normalizePrice returns price without trailing zeros, or unchanged when it
+// is not a number
+func normalizePrice(price string) string {
+	value, err := strconv.ParseFloat(price, 64)
+	if err != nil {
+		return price
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
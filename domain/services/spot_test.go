@@ -0,0 +1,117 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestDriftDetector_MarketOptions(t *testing.T) {
+	spot := func(maxPrice, interruption string) *models.Instance {
+		instance := models.NewInstance("i-1", "t3.micro", "ami-1")
+		instance.InstanceLifecycle = models.InstanceLifecycleSpot
+		instance.InstanceMarketOptions = &models.InstanceMarketOptions{
+			MarketType:                   models.InstanceLifecycleSpot,
+			MaxPrice:                     maxPrice,
+			InstanceInterruptionBehavior: interruption,
+		}
+		return instance
+	}
+	onDemand := models.NewInstance("i-1", "t3.micro", "ami-1")
+
+	tests := []struct {
+		name    string
+		actual  *models.Instance
+		desired *models.Instance
+		opts    []services.DetectorOption
+		drifts  []models.Drift
+	}{
+		{
+			name:    "on-demand as declared",
+			actual:  onDemand,
+			desired: onDemand,
+		},
+		{
+			name:    "spot as declared",
+			actual:  spot("0.050000", "stop"),
+			desired: spot("0.05", "stop"),
+		},
+		{
+			name:    "running as spot",
+			actual:  spot("0.050000", "terminate"),
+			desired: onDemand,
+			drifts: []models.Drift{
+				models.NewDrift(models.DriftTypeModified, "InstanceLifecycle", "spot", "on-demand",
+					"Instance runs as spot, Terraform expects on-demand"),
+			},
+		},
+		{
+			name:    "running on-demand",
+			actual:  onDemand,
+			desired: spot("", ""),
+			drifts: []models.Drift{
+				models.NewDrift(models.DriftTypeModified, "InstanceLifecycle", "on-demand", "spot",
+					"Instance runs as on-demand, Terraform expects spot"),
+			},
+		},
+		{
+			name:    "interruption behavior changed",
+			actual:  spot("0.050000", "hibernate"),
+			desired: spot("0.05", "stop"),
+			drifts: []models.Drift{
+				models.NewDrift(models.DriftTypeModified, "InstanceMarketOptions.InstanceInterruptionBehavior", "hibernate", "stop", "Value mismatch"),
+			},
+		},
+		{
+			name:    "unset interruption behavior is terminate",
+			actual:  spot("0.050000", "terminate"),
+			desired: spot("", ""),
+		},
+		{
+			name:    "max price changed",
+			actual:  spot("0.100000", "terminate"),
+			desired: spot("0.05", ""),
+			drifts: []models.Drift{
+				models.NewDrift(models.DriftTypeModified, "InstanceMarketOptions.MaxPrice", "0.1", "0.05", "Value mismatch"),
+			},
+		},
+		{
+			name:    "spot request not read",
+			actual:  &models.Instance{ID: "i-1", Type: "t3.micro", AMI: "ami-1", Tags: map[string]string{}, InstanceLifecycle: "spot"},
+			desired: spot("0.05", "stop"),
+		},
+		{
+			name:    "ignored lifecycle",
+			actual:  spot("0.050000", "terminate"),
+			desired: onDemand,
+			opts:    []services.DetectorOption{services.WithIgnoredPaths("InstanceLifecycle")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector, err := services.NewDriftDetectorWithOptions(tt.opts...)
+			require.NoError(t, err)
+
+			report := detector.CompareInstances(tt.actual, tt.desired)
+
+			if tt.drifts == nil {
+				assert.Empty(t, report.Drifts)
+				return
+			}
+			require.Len(t, report.Drifts, len(tt.drifts))
+			for i, want := range tt.drifts {
+				got := report.Drifts[i]
+				assert.Equal(t, want.Type, got.Type)
+				assert.Equal(t, want.Path, got.Path)
+				assert.Equal(t, want.Actual, got.Actual)
+				assert.Equal(t, want.Expected, got.Expected)
+				assert.Equal(t, want.Description, got.Description)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"fmt"
+
+	"driftdetector/domain/models"
+)
+
+// Drift paths of the instance's placement
+const (
+	availabilityZonePath = "AvailabilityZone"
+	subnetIDPath         = "SubnetID"
+)
+
+// resolveImpliedPlacement returns desired with the placement setting its
+// configuration leaves out taken from actual when the other one matches: an
+// instance in the expected subnet is in that subnet's availability zone, and
+// one in the expected availability zone without an expected subnet is in the
+// subnet AWS chose there
+func resolveImpliedPlacement(actual, desired *models.Instance) *models.Instance {
+	switch {
+	case desired.AvailabilityZone == "" && desired.SubnetID != "" && desired.SubnetID == actual.SubnetID:
+		resolved := *desired
+		resolved.AvailabilityZone = actual.AvailabilityZone
+		return &resolved
+	case desired.SubnetID == "" && desired.AvailabilityZone != "" && desired.AvailabilityZone == actual.AvailabilityZone:
+		resolved := *desired
+		resolved.SubnetID = actual.SubnetID
+		return &resolved
+	}
+	return desired
+}
+
+// DriftedSubnet returns the subnet of actual when report has an availability
+// zone finding that the subnet may explain, i.e. when desired sets both the
+// availability zone and the subnet
+func DriftedSubnet(report *models.DriftReport, actual, desired *models.Instance) (string, bool) {
+	if actual == nil || desired == nil || actual.SubnetID == "" || desired.SubnetID == "" || desired.AvailabilityZone == "" {
+		return "", false
+	}
+	for _, d := range report.Drifts {
+		if d.Path == availabilityZonePath && d.Type == models.DriftTypeModified {
+			return actual.SubnetID, true
+		}
+	}
+	return "", false
+}
+
+// ResolveSubnetDrift replaces the availability zone and subnet findings of
+// report with a single finding naming the zone the actual subnet is in, e.g.
+// "subnet subnet-abc is in us-east-1b, expected us-east-1a". The finding is
+// at SubnetID when the subnet changed, else at AvailabilityZone. The
+// findings are kept, with a warning, when the subnet is missing from subnets.
+func ResolveSubnetDrift(report *models.DriftReport, actual, desired *models.Instance, subnets []*models.Subnet) {
+	subnetID, ok := DriftedSubnet(report, actual, desired)
+	if !ok {
+		return
+	}
+
+	var subnet *models.Subnet
+	for _, s := range subnets {
+		if s.ID == subnetID {
+			subnet = s
+			break
+		}
+	}
+	if subnet == nil {
+		report.AddWarning(fmt.Sprintf("subnet %s could not be described, so its availability zone is not checked", subnetID))
+		return
+	}
+	if subnet.AvailabilityZone == desired.AvailabilityZone {
+		return
+	}
+
+	var drift models.Drift
+	description := fmt.Sprintf("subnet %s is in %s, expected %s", subnet.ID, subnet.AvailabilityZone, desired.AvailabilityZone)
+	if subnet.ID != desired.SubnetID {
+		drift = models.NewDrift(models.DriftTypeModified, subnetIDPath, subnet.ID, desired.SubnetID, description)
+	} else {
+		drift = models.NewDrift(models.DriftTypeModified, availabilityZonePath, subnet.AvailabilityZone, desired.AvailabilityZone, description)
+	}
+
+	// The finding takes the place, and the source, of the first one it replaces
+	drifts := make([]models.Drift, 0, len(report.Drifts))
+	placed := false
+	for _, d := range report.Drifts {
+		if d.Path != availabilityZonePath && d.Path != subnetIDPath {
+			drifts = append(drifts, d)
+			continue
+		}
+		if !placed {
+			drift.Source = d.Source
+			drifts = append(drifts, drift)
+			placed = true
+		}
+	}
+	report.Drifts = drifts
+	report.HasDrift = models.ContainsDrift(drifts)
+}
@@ -0,0 +1,118 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+// placedInstance returns an instance in the availability zone and subnet
+func placedInstance(az, subnet string) *models.Instance {
+	instance := models.NewInstance("i-1", "t3.micro", "ami-1")
+	instance.AvailabilityZone = az
+	instance.SubnetID = subnet
+	return instance
+}
+
+func TestDriftDetector_ImpliedPlacement(t *testing.T) {
+	tests := []struct {
+		name    string
+		actual  *models.Instance
+		desired *models.Instance
+		paths   []string
+	}{
+		{
+			name:    "zone implied by the expected subnet",
+			actual:  placedInstance("us-east-1a", "subnet-abc"),
+			desired: placedInstance("", "subnet-abc"),
+		},
+		{
+			name:    "subnet chosen by AWS in the expected zone",
+			actual:  placedInstance("us-east-1a", "subnet-abc"),
+			desired: placedInstance("us-east-1a", ""),
+		},
+		{
+			name:    "instance moved to another subnet without an expected zone",
+			actual:  placedInstance("us-east-1b", "subnet-def"),
+			desired: placedInstance("", "subnet-abc"),
+			paths:   []string{"AvailabilityZone", "SubnetID"},
+		},
+		{
+			name:    "instance in another zone without an expected subnet",
+			actual:  placedInstance("us-east-1b", "subnet-def"),
+			desired: placedInstance("us-east-1a", ""),
+			paths:   []string{"AvailabilityZone", "SubnetID"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := services.NewDriftDetector().CompareInstances(tt.actual, tt.desired)
+
+			if tt.paths == nil {
+				assert.Empty(t, report.Drifts)
+			} else {
+				assert.Equal(t, tt.paths, driftPaths(report))
+			}
+		})
+	}
+}
+
+func TestResolveSubnetDrift(t *testing.T) {
+	subnets := []*models.Subnet{
+		{ID: "subnet-abc", AvailabilityZone: "us-east-1b"},
+		{ID: "subnet-def", AvailabilityZone: "us-east-1a"},
+	}
+	compare := func(actual, desired *models.Instance) *models.DriftReport {
+		desired.KeyName = "deploy"
+		return services.NewDriftDetector().CompareInstances(actual, desired)
+	}
+
+	t.Run("moved subnet replaces both findings", func(t *testing.T) {
+		actual, desired := placedInstance("us-east-1b", "subnet-abc"), placedInstance("us-east-1a", "subnet-def")
+		report := compare(actual, desired)
+
+		services.ResolveSubnetDrift(report, actual, desired, subnets)
+
+		assert.Equal(t, []string{"SubnetID", "KeyName"}, driftPaths(report))
+		drift := report.Drifts[0]
+		assert.Equal(t, "subnet-abc", drift.Actual)
+		assert.Equal(t, "subnet-def", drift.Expected)
+		assert.Equal(t, "subnet subnet-abc is in us-east-1b, expected us-east-1a", drift.Description)
+	})
+
+	t.Run("expected subnet in another zone than the expected zone", func(t *testing.T) {
+		actual, desired := placedInstance("us-east-1b", "subnet-abc"), placedInstance("us-east-1a", "subnet-abc")
+		report := compare(actual, desired)
+
+		services.ResolveSubnetDrift(report, actual, desired, subnets)
+
+		assert.Equal(t, []string{"AvailabilityZone", "KeyName"}, driftPaths(report))
+		assert.Equal(t, "subnet subnet-abc is in us-east-1b, expected us-east-1a", report.Drifts[0].Description)
+	})
+
+	t.Run("subnet that cannot be described keeps the findings", func(t *testing.T) {
+		actual, desired := placedInstance("us-east-1b", "subnet-gone"), placedInstance("us-east-1a", "subnet-def")
+		report := compare(actual, desired)
+
+		services.ResolveSubnetDrift(report, actual, desired, subnets)
+
+		assert.Equal(t, []string{"AvailabilityZone", "KeyName", "SubnetID"}, driftPaths(report))
+		require.Len(t, report.Warnings, 1)
+		assert.Contains(t, report.Warnings[0], "subnet-gone")
+	})
+
+	t.Run("nothing to resolve without an expected subnet", func(t *testing.T) {
+		actual, desired := placedInstance("us-east-1b", "subnet-abc"), placedInstance("us-east-1a", "")
+		report := compare(actual, desired)
+
+		services.ResolveSubnetDrift(report, actual, desired, subnets)
+
+		assert.Equal(t, []string{"AvailabilityZone", "KeyName", "SubnetID"}, driftPaths(report))
+		assert.Empty(t, report.Warnings)
+	})
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"strings"
+
+	"driftdetector/domain/models"
+)
+
+// awsTagPrefix marks tags AWS adds to resources itself, such as
+// aws:autoscaling:groupName. Users cannot set or remove them.
+const awsTagPrefix = "aws:"
+
+// WithDefaultTags merges tags into the expected tags of every instance before
+// comparison, as the provider's default_tags block does. Tags set on the
+// resource take precedence.
+func WithDefaultTags(tags map[string]string) DetectorOption {
+	return func(d *DriftDetector) error {
+		if d.defaultTags == nil {
+			d.defaultTags = make(map[string]string, len(tags))
+		}
+		for k, v := range tags {
+			d.defaultTags[k] = v
+		}
+		return nil
+	}
+}
+
+// WithAWSTags compares tags with the aws: prefix, which are skipped by default
+func WithAWSTags() DetectorOption {
+	return func(d *DriftDetector) error {
+		d.includeAWSTags = true
+		return nil
+	}
+}
+
+// applyDefaultTags returns desired with the detector's default tags merged
+// into its tags
+func (d *DriftDetector) applyDefaultTags(desired *models.Instance) *models.Instance {
+	if len(d.defaultTags) == 0 {
+		return desired
+	}
+
+	merged := *desired
+	merged.Tags = make(map[string]string, len(d.defaultTags)+len(desired.Tags))
+	for k, v := range d.defaultTags {
+		merged.Tags[k] = v
+	}
+	for k, v := range desired.Tags {
+		merged.Tags[k] = v
+	}
+	return &merged
+}
+
+// resolveUnmanagedVolumeTags returns desired with the tags of every block
+// device whose configuration sets none taken from the matching actual
+// device, so volume tags are only compared where Terraform manages them
+func resolveUnmanagedVolumeTags(actual, desired *models.Instance) *models.Instance {
+	actualTags := make(map[string]map[string]string, len(actual.EBSBlockDevices))
+	for _, device := range actual.EBSBlockDevices {
+		actualTags[device.DeviceName] = device.Tags
+	}
+
+	var resolved *models.Instance
+	for i, device := range desired.EBSBlockDevices {
+		tags, ok := actualTags[device.DeviceName]
+		if len(device.Tags) > 0 || !ok || len(tags) == 0 {
+			continue
+		}
+		if resolved == nil {
+			c := *desired
+			c.EBSBlockDevices = append([]models.EBSBlockDevice(nil), desired.EBSBlockDevices...)
+			resolved = &c
+		}
+		resolved.EBSBlockDevices[i].Tags = tags
+	}
+
+	if resolved == nil {
+		return desired
+	}
+	return resolved
+}
+
+// isAWSTag reports whether key is an AWS-managed tag of the instance or one
+// of its volumes that should be skipped
+func (d *DriftDetector) isAWSTag(segments []string, key string) bool {
+	return !d.includeAWSTags &&
+		len(segments) > 0 && segments[len(segments)-1] == "Tags" &&
+		strings.HasPrefix(key, awsTagPrefix)
+}
@@ -0,0 +1,89 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestDriftDetector_Tags(t *testing.T) {
+	// The instance carries provider default tags, its resource tags, a tag
+	// added in the console and tags AWS manages
+	newPair := func() (*models.Instance, *models.Instance) {
+		actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+		actual.AddTag("Name", "web")
+		actual.AddTag("Environment", "prod")
+		actual.AddTag("ManagedBy", "terraform")
+		actual.AddTag("Owner", "alice")
+		actual.AddTag("aws:autoscaling:groupName", "web-asg")
+		actual.AddTag("aws:ec2launchtemplate:id", "lt-1")
+
+		desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+		desired.AddTag("Name", "web")
+		desired.AddTag("Environment", "staging")
+		return actual, desired
+	}
+
+	tests := []struct {
+		name     string
+		opts     []services.DetectorOption
+		expected []string
+	}{
+		{
+			name:     "aws tags are skipped by default",
+			expected: []string{"Tags.Environment", "Tags.ManagedBy", "Tags.Owner"},
+		},
+		{
+			name: "default tags are merged and resource tags win",
+			opts: []services.DetectorOption{services.WithDefaultTags(map[string]string{
+				"Environment": "prod",
+				"ManagedBy":   "terraform",
+			})},
+			expected: []string{"Tags.Environment", "Tags.Owner"},
+		},
+		{
+			name: "aws tags are compared when included",
+			opts: []services.DetectorOption{
+				services.WithDefaultTags(map[string]string{"ManagedBy": "terraform"}),
+				services.WithAWSTags(),
+			},
+			expected: []string{"Tags.Environment", "Tags.Owner", "Tags.aws:autoscaling:groupName", "Tags.aws:ec2launchtemplate:id"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector, err := services.NewDriftDetectorWithOptions(tt.opts...)
+			require.NoError(t, err)
+
+			actual, desired := newPair()
+			report := detector.CompareInstances(actual, desired)
+
+			assert.ElementsMatch(t, tt.expected, driftPaths(report))
+			assert.Len(t, desired.Tags, 2, "the desired instance is not modified")
+		})
+	}
+}
+
+func TestDriftDetector_VolumeTags(t *testing.T) {
+	actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+	actual.EBSBlockDevices = []models.EBSBlockDevice{
+		{DeviceName: "/dev/sdf", VolumeSize: 100, Tags: map[string]string{"Name": "data", "Backup": "daily", "aws:backup:source-resource": "i-1"}},
+		{DeviceName: "/dev/sdg", VolumeSize: 20, Tags: map[string]string{"Name": "logs"}},
+	}
+	desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+	desired.EBSBlockDevices = []models.EBSBlockDevice{
+		{DeviceName: "/dev/sdf", VolumeSize: 100, Tags: map[string]string{"Name": "data"}},
+		{DeviceName: "/dev/sdg", VolumeSize: 20},
+	}
+
+	report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+	assert.Equal(t, []string{"EBSBlockDevices[/dev/sdf].Tags.Backup"}, driftPaths(report),
+		"tags of volumes without configured tags and aws tags are not compared")
+	assert.Nil(t, desired.EBSBlockDevices[1].Tags, "the desired instance is not modified")
+}
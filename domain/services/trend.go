@@ -0,0 +1,85 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"driftdetector/domain/models"
+)
+
+// BuildDriftTrend groups the drift paths of an instance's reports into the
+// runs of consecutive checks that reported them. Reports are ordered by
+// CheckedAt first; a path is resolved at the first later check that no
+// longer reports it, and drifts again in a new interval if it comes back.
+func BuildDriftTrend(instanceID string, reports []*models.DriftReport) *models.DriftTrend {
+	trend := &models.DriftTrend{InstanceID: instanceID, Paths: []models.PathTrend{}}
+
+	ordered := make([]*models.DriftReport, 0, len(reports))
+	for _, report := range reports {
+		if report != nil {
+			ordered = append(ordered, report)
+		}
+	}
+	if len(ordered) == 0 {
+		return trend
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].CheckedAt.Before(ordered[j].CheckedAt)
+	})
+	trend.Checks = len(ordered)
+	trend.From = ordered[0].CheckedAt
+	trend.To = ordered[len(ordered)-1].CheckedAt
+
+	paths := make(map[string]*models.PathTrend)
+	for _, report := range ordered {
+		at := report.CheckedAt
+		seen := make(map[string]bool, len(report.Drifts))
+		for _, drift := range report.Drifts {
+			// Reports stored before map paths lost their leading dot still
+			// name the same finding
+			driftPath := strings.TrimPrefix(drift.Path, ".")
+			if seen[driftPath] {
+				continue
+			}
+			seen[driftPath] = true
+
+			path, ok := paths[driftPath]
+			if !ok {
+				path = &models.PathTrend{Path: driftPath, FirstSeen: at}
+				paths[driftPath] = path
+			}
+			path.Occurrences++
+			path.LastSeen = at
+			if n := len(path.Intervals); n == 0 || path.Intervals[n-1].ResolvedAt != nil {
+				path.Intervals = append(path.Intervals, models.DriftInterval{FirstSeen: at})
+			}
+			path.Intervals[len(path.Intervals)-1].LastSeen = at
+		}
+
+		for key, path := range paths {
+			last := &path.Intervals[len(path.Intervals)-1]
+			if !seen[key] && last.ResolvedAt == nil {
+				resolvedAt := at
+				last.ResolvedAt = &resolvedAt
+			}
+		}
+	}
+
+	for _, path := range paths {
+		for i := range path.Intervals {
+			interval := &path.Intervals[i]
+			end := interval.LastSeen
+			if interval.ResolvedAt != nil {
+				end = *interval.ResolvedAt
+			}
+			interval.DurationSeconds = int64(end.Sub(interval.FirstSeen).Seconds())
+			path.DurationSeconds += interval.DurationSeconds
+		}
+		path.Resolved = path.Intervals[len(path.Intervals)-1].ResolvedAt != nil
+		trend.Paths = append(trend.Paths, *path)
+	}
+	sort.Slice(trend.Paths, func(i, j int) bool {
+		return models.ComparePaths(trend.Paths[i].Path, trend.Paths[j].Path) < 0
+	})
+	return trend
+}
@@ -0,0 +1,185 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+var trendStart = time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+
+// checkAt returns a report checked hours after trendStart with drift at paths
+func checkAt(hours int, paths ...string) *models.DriftReport {
+	report := models.NewDriftReport("i-1")
+	report.CheckedAt = trendStart.Add(time.Duration(hours) * time.Hour)
+	for _, path := range paths {
+		report.AddDrift(models.NewDrift(models.DriftTypeModified, path, "a", "b", ""))
+	}
+	return report
+}
+
+func hoursAfterStart(hours int) time.Time {
+	return trendStart.Add(time.Duration(hours) * time.Hour)
+}
+
+func TestBuildDriftTrend_NoReports(t *testing.T) {
+	trend := services.BuildDriftTrend("i-1", nil)
+
+	assert.Equal(t, "i-1", trend.InstanceID)
+	assert.Zero(t, trend.Checks)
+	assert.True(t, trend.From.IsZero())
+	assert.Empty(t, trend.Paths)
+	assert.NotNil(t, trend.Paths)
+}
+
+func TestBuildDriftTrend_NoDrift(t *testing.T) {
+	trend := services.BuildDriftTrend("i-1", []*models.DriftReport{checkAt(0), checkAt(1)})
+
+	assert.Equal(t, 2, trend.Checks)
+	assert.Equal(t, hoursAfterStart(0), trend.From)
+	assert.Equal(t, hoursAfterStart(1), trend.To)
+	assert.Empty(t, trend.Paths)
+}
+
+func TestBuildDriftTrend_StillDrifting(t *testing.T) {
+	trend := services.BuildDriftTrend("i-1", []*models.DriftReport{
+		checkAt(0),
+		checkAt(1, "Type"),
+		checkAt(3, "Type"),
+	})
+
+	require.Len(t, trend.Paths, 1)
+	path := trend.Paths[0]
+	assert.Equal(t, "Type", path.Path)
+	assert.Equal(t, 2, path.Occurrences)
+	assert.Equal(t, hoursAfterStart(1), path.FirstSeen)
+	assert.Equal(t, hoursAfterStart(3), path.LastSeen)
+	assert.False(t, path.Resolved)
+	assert.Equal(t, 2*time.Hour, path.Duration())
+	require.Len(t, path.Intervals, 1)
+	assert.Nil(t, path.Intervals[0].ResolvedAt)
+}
+
+func TestBuildDriftTrend_Resolved(t *testing.T) {
+	trend := services.BuildDriftTrend("i-1", []*models.DriftReport{
+		checkAt(0, "Type"),
+		checkAt(1, "Type"),
+		checkAt(5),
+		checkAt(6),
+	})
+
+	require.Len(t, trend.Paths, 1)
+	path := trend.Paths[0]
+	assert.True(t, path.Resolved)
+	assert.Equal(t, 2, path.Occurrences)
+	assert.Equal(t, hoursAfterStart(1), path.LastSeen)
+	require.Len(t, path.Intervals, 1)
+	require.NotNil(t, path.Intervals[0].ResolvedAt)
+	assert.Equal(t, hoursAfterStart(5), *path.Intervals[0].ResolvedAt)
+	// The path drifted until the check that found it resolved
+	assert.Equal(t, 5*time.Hour, path.Duration())
+}
+
+func TestBuildDriftTrend_Recurring(t *testing.T) {
+	trend := services.BuildDriftTrend("i-1", []*models.DriftReport{
+		checkAt(0, "KeyName"),
+		checkAt(1),
+		checkAt(2, "KeyName"),
+		checkAt(4, "KeyName"),
+	})
+
+	require.Len(t, trend.Paths, 1)
+	path := trend.Paths[0]
+	assert.Equal(t, 3, path.Occurrences)
+	assert.Equal(t, hoursAfterStart(0), path.FirstSeen)
+	assert.False(t, path.Resolved)
+	require.Len(t, path.Intervals, 2)
+	assert.Equal(t, hoursAfterStart(0), path.Intervals[0].FirstSeen)
+	assert.Equal(t, hoursAfterStart(1), *path.Intervals[0].ResolvedAt)
+	assert.Equal(t, time.Hour, path.Intervals[0].Duration())
+	assert.Equal(t, hoursAfterStart(2), path.Intervals[1].FirstSeen)
+	assert.Equal(t, hoursAfterStart(4), path.Intervals[1].LastSeen)
+	assert.Nil(t, path.Intervals[1].ResolvedAt)
+	assert.Equal(t, 2*time.Hour, path.Intervals[1].Duration())
+	assert.Equal(t, 3*time.Hour, path.Duration())
+}
+
+func TestBuildDriftTrend_SingleCheck(t *testing.T) {
+	trend := services.BuildDriftTrend("i-1", []*models.DriftReport{checkAt(0, "Type")})
+
+	require.Len(t, trend.Paths, 1)
+	assert.Equal(t, 1, trend.Paths[0].Occurrences)
+	assert.False(t, trend.Paths[0].Resolved)
+	assert.Zero(t, trend.Paths[0].Duration())
+}
+
+func TestBuildDriftTrend_OrdersReportsByCheckedAt(t *testing.T) {
+	reports := []*models.DriftReport{
+		checkAt(2),
+		checkAt(0, "Type"),
+		checkAt(1, "Type"),
+	}
+
+	trend := services.BuildDriftTrend("i-1", reports)
+
+	assert.Equal(t, hoursAfterStart(0), trend.From)
+	assert.Equal(t, hoursAfterStart(2), trend.To)
+	require.Len(t, trend.Paths, 1)
+	assert.True(t, trend.Paths[0].Resolved)
+	assert.Equal(t, hoursAfterStart(2), *trend.Paths[0].Intervals[0].ResolvedAt)
+	// The caller's slice keeps its order
+	assert.Equal(t, hoursAfterStart(2), reports[0].CheckedAt)
+}
+
+func TestBuildDriftTrend_SeveralPaths(t *testing.T) {
+	trend := services.BuildDriftTrend("i-1", []*models.DriftReport{
+		checkAt(0, "Type", "Tags.Env"),
+		checkAt(1, "Type", "EBSBlockDevices[/dev/sdf].VolumeSize"),
+		checkAt(2, "Tags.Env"),
+	})
+
+	paths := make([]string, 0, len(trend.Paths))
+	for _, path := range trend.Paths {
+		paths = append(paths, path.Path)
+	}
+	assert.Equal(t, []string{"EBSBlockDevices[/dev/sdf].VolumeSize", "Tags.Env", "Type"}, paths)
+
+	devices, env, instanceType := trend.Paths[0], trend.Paths[1], trend.Paths[2]
+	assert.Equal(t, 2, env.Occurrences)
+	assert.Len(t, env.Intervals, 2)
+	assert.False(t, env.Resolved)
+	assert.Equal(t, 1, devices.Occurrences)
+	assert.True(t, devices.Resolved)
+	assert.Equal(t, 2, instanceType.Occurrences)
+	assert.True(t, instanceType.Resolved)
+}
+
+func TestBuildDriftTrend_DuplicatePathsCountOnce(t *testing.T) {
+	report := checkAt(0, "SecurityGroups", "SecurityGroups")
+	report.AddDrift(models.NewDrift(models.DriftTypeAdded, "SecurityGroups", "sg-2", nil, ""))
+
+	trend := services.BuildDriftTrend("i-1", []*models.DriftReport{report, nil})
+
+	assert.Equal(t, 1, trend.Checks)
+	require.Len(t, trend.Paths, 1)
+	assert.Equal(t, 1, trend.Paths[0].Occurrences)
+}
+
+func TestBuildDriftTrend_LeadingDotPaths(t *testing.T) {
+	// Given a report stored when map paths began with a dot
+	trend := services.BuildDriftTrend("i-1", []*models.DriftReport{
+		checkAt(0, ".Tags.Env"),
+		checkAt(1, "Tags.Env"),
+	})
+
+	// Then both name the same finding
+	require.Len(t, trend.Paths, 1)
+	assert.Equal(t, "Tags.Env", trend.Paths[0].Path)
+	assert.Equal(t, 2, trend.Paths[0].Occurrences)
+	assert.Len(t, trend.Paths[0].Intervals, 1)
+}
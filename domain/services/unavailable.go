@@ -0,0 +1,56 @@
+package services
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"driftdetector/domain/models"
+)
+
+// unavailableFields returns desired with every field in actual's
+// UnavailableFields taken from actual, and notes them in report once per
+// missing permission. AWS did not let those fields be read, so comparing them
+// would report every setting Terraform makes as drift.
+func unavailableFields(actual, desired *models.Instance, report *models.DriftReport) *models.Instance {
+	if len(actual.UnavailableFields) == 0 {
+		return desired
+	}
+
+	resolved := *desired
+	actualVal := reflect.ValueOf(actual).Elem()
+	resolvedVal := reflect.ValueOf(&resolved).Elem()
+	notes := make(map[string]bool)
+	for name, permission := range actual.UnavailableFields {
+		field := resolvedVal.FieldByName(name)
+		if field.IsValid() && field.CanSet() {
+			field.Set(actualVal.FieldByName(name))
+		}
+		notes[fmt.Sprintf("%s could not be verified (missing %s)", unavailableSubject(name), permission)] = true
+	}
+
+	warnings := make([]string, 0, len(notes))
+	for note := range notes {
+		warnings = append(warnings, note)
+	}
+	sort.Strings(warnings)
+	for _, warning := range warnings {
+		report.AddWarning(warning)
+	}
+
+	return &resolved
+}
+
+// unavailableSubject names what an unavailable field describes, so that the
+// fields of one volume are noted together
+func unavailableSubject(name string) string {
+	switch {
+	case strings.HasPrefix(name, "RootVolume"):
+		return "root volume attributes"
+	case name == "EBSBlockDevices":
+		return "EBS block devices"
+	default:
+		return name
+	}
+}
@@ -0,0 +1,24 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestDriftDetector_UnknownFields(t *testing.T) {
+	actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+	actual.PublicIPAddress = "54.1.2.3"
+	actual.RootVolumeSize = 20
+
+	desired := models.NewInstance("i-1", "t3.small", "ami-1")
+	desired.UnknownFields = []string{"PublicIPAddress", "RootVolumeSize"}
+
+	report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+	assert.Equal(t, []string{"Type"}, driftPaths(report), "values known only after apply are not drift")
+	assert.Empty(t, desired.PublicIPAddress, "the desired instance is not modified")
+}
@@ -0,0 +1,65 @@
+package services_test
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+const bootScript = "#!/bin/bash\nyum install -y nginx\nsystemctl start nginx\n"
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDriftDetector_UserData(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte(bootScript))
+
+	tests := []struct {
+		name    string
+		actual  string
+		desired string
+		drift   bool
+	}{
+		{"both empty", "", "", false},
+		{"base64 from AWS matches plain text", encoded, bootScript, false},
+		{"trailing newlines and CRLF", encoded, "#!/bin/bash\r\nyum install -y nginx\r\nsystemctl start nginx\n\n\n", false},
+		{"base64 on both sides", encoded, encoded, false},
+		{"state hash matches content", encoded, sha1Hex(bootScript), false},
+		{"state hash differs", encoded, sha1Hex("#!/bin/bash\necho other\n"), true},
+		{"content differs", encoded, "#!/bin/bash\nyum install -y httpd\n", true},
+		{"removed in AWS", "", bootScript, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+			actual.UserData = tt.actual
+			desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+			desired.UserData = tt.desired
+
+			report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+			if tt.drift {
+				assert.Equal(t, []string{"UserData"}, driftPaths(report))
+				assert.NotContains(t, report.Drifts[0].Actual, "nginx", "drift shows fingerprints, not the script")
+			} else {
+				assert.Empty(t, report.Drifts)
+			}
+		})
+	}
+}
+
+func TestInstance_NormalizedUserData(t *testing.T) {
+	instance := models.NewInstance("i-1", "t3.micro", "ami-1")
+	instance.UserData = base64.StdEncoding.EncodeToString([]byte(bootScript + "\n"))
+
+	assert.Equal(t, "#!/bin/bash\nyum install -y nginx\nsystemctl start nginx", instance.NormalizedUserData())
+}
@@ -0,0 +1,90 @@
+package services
+
+import "driftdetector/domain/models"
+
+// volumePerformance holds the IOPS and throughput, in MiB/s, AWS gives a
+// volume whose configuration leaves them out. Zero means AWS sets no default,
+// so an unset expectation only matches an unset actual value.
+type volumePerformance struct {
+	iops       int
+	throughput int
+	// sizedIOPS marks volume types whose IOPS AWS derives from the size and
+	// which cannot be configured, so any IOPS matches an unset expectation
+	sizedIOPS bool
+}
+
+// volumeTypeDefaults are the performance defaults of each EBS volume type.
+// io1 and io2 need IOPS set explicitly and have no configurable throughput.
+var volumeTypeDefaults = map[string]volumePerformance{
+	"gp2": {sizedIOPS: true},
+	"gp3": {iops: 3000, throughput: 125},
+	"io1": {},
+	"io2": {},
+}
+
+// applyVolumeDefaults returns desired with the unset IOPS and throughput of
+// its root and EBS volumes taken from actual when actual reports the default
+// of its volume type, so a gp3 volume left at 3000 IOPS and 125 MiB/s is not
+// drift. A value that differs from the default is still reported.
+func (d *DriftDetector) applyVolumeDefaults(actual, desired *models.Instance) *models.Instance {
+	if d.volumeDefaults == nil {
+		return desired
+	}
+
+	resolved := *desired
+	changed, devicesCopied := false, false
+	iops, throughput := d.volumeDefault(actual.RootVolumeType, actual.RootVolumeIops, actual.RootVolumeThroughput)
+	if resolved.RootVolumeIops == 0 && iops {
+		resolved.RootVolumeIops = actual.RootVolumeIops
+		changed = true
+	}
+	if resolved.RootVolumeThroughput == 0 && throughput {
+		resolved.RootVolumeThroughput = actual.RootVolumeThroughput
+		changed = true
+	}
+
+	actualDevices := make(map[string]models.EBSBlockDevice, len(actual.EBSBlockDevices))
+	for _, device := range actual.EBSBlockDevices {
+		actualDevices[device.DeviceName] = device
+	}
+	for i, device := range desired.EBSBlockDevices {
+		got, ok := actualDevices[device.DeviceName]
+		if !ok {
+			continue
+		}
+		iops, throughput := d.volumeDefault(got.VolumeType, got.Iops, got.Throughput)
+		iops = iops && device.Iops == 0
+		throughput = throughput && device.Throughput == 0
+		if !iops && !throughput {
+			continue
+		}
+		if !devicesCopied {
+			resolved.EBSBlockDevices = append([]models.EBSBlockDevice(nil), desired.EBSBlockDevices...)
+			devicesCopied = true
+		}
+		changed = true
+		if iops {
+			resolved.EBSBlockDevices[i].Iops = got.Iops
+		}
+		if throughput {
+			resolved.EBSBlockDevices[i].Throughput = got.Throughput
+		}
+	}
+
+	if !changed {
+		return desired
+	}
+	return &resolved
+}
+
+// volumeDefault reports whether the IOPS and throughput of a volume of
+// volumeType are the values AWS gives it when they are left out
+func (d *DriftDetector) volumeDefault(volumeType string, iops, throughput int) (defaultIOPS, defaultThroughput bool) {
+	defaults, ok := d.volumeDefaults[volumeType]
+	if !ok {
+		return false, false
+	}
+	defaultIOPS = iops != 0 && (defaults.sizedIOPS || iops == defaults.iops)
+	defaultThroughput = throughput != 0 && throughput == defaults.throughput
+	return defaultIOPS, defaultThroughput
+}
@@ -0,0 +1,132 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestDriftDetector_VolumeDefaults(t *testing.T) {
+	tests := []struct {
+		name       string
+		volumeType string
+		iops       int
+		throughput int
+		desired    func(*models.Instance)
+		opts       []services.DetectorOption
+		paths      []string
+	}{
+		{
+			name:       "gp3 at its defaults",
+			volumeType: "gp3",
+			iops:       3000,
+			throughput: 125,
+		},
+		{
+			name:       "gp3 provisioned above its defaults",
+			volumeType: "gp3",
+			iops:       6000,
+			throughput: 250,
+			paths:      []string{"RootVolumeIops", "RootVolumeThroughput"},
+		},
+		{
+			name:       "gp3 at the expected values",
+			volumeType: "gp3",
+			iops:       6000,
+			throughput: 250,
+			desired: func(i *models.Instance) {
+				i.RootVolumeIops = 6000
+				i.RootVolumeThroughput = 250
+			},
+		},
+		{
+			name:       "gp3 set back to its default IOPS",
+			volumeType: "gp3",
+			iops:       3000,
+			throughput: 125,
+			desired:    func(i *models.Instance) { i.RootVolumeIops = 6000 },
+			paths:      []string{"RootVolumeIops"},
+		},
+		{
+			name:       "gp2 IOPS follow the size",
+			volumeType: "gp2",
+			iops:       300,
+		},
+		{
+			name:       "io1 IOPS have no default",
+			volumeType: "io1",
+			iops:       3000,
+			paths:      []string{"RootVolumeIops"},
+		},
+		{
+			name:       "io2 at the expected IOPS",
+			volumeType: "io2",
+			iops:       8000,
+			desired:    func(i *models.Instance) { i.RootVolumeIops = 8000 },
+		},
+		{
+			name:       "io2 throughput has no default",
+			volumeType: "io2",
+			iops:       8000,
+			throughput: 125,
+			desired:    func(i *models.Instance) { i.RootVolumeIops = 8000 },
+			paths:      []string{"RootVolumeThroughput"},
+		},
+		{
+			name:       "strict reports gp3 defaults",
+			volumeType: "gp3",
+			iops:       3000,
+			throughput: 125,
+			opts:       []services.DetectorOption{services.WithStrict()},
+			paths:      []string{"RootVolumeIops", "RootVolumeThroughput"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+			actual.RootVolumeType = tt.volumeType
+			actual.RootVolumeIops = tt.iops
+			actual.RootVolumeThroughput = tt.throughput
+			desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+			desired.RootVolumeType = tt.volumeType
+			if tt.desired != nil {
+				tt.desired(desired)
+			}
+			before := *desired
+
+			detector, err := services.NewDriftDetectorWithOptions(tt.opts...)
+			require.NoError(t, err)
+			report := detector.CompareInstances(actual, desired)
+
+			if tt.paths == nil {
+				assert.Empty(t, report.Drifts)
+			} else {
+				assert.Equal(t, tt.paths, driftPaths(report))
+			}
+			assert.Equal(t, before, *desired, "the desired instance is left unchanged")
+		})
+	}
+}
+
+func TestDriftDetector_EBSVolumeDefaults(t *testing.T) {
+	actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+	actual.EBSBlockDevices = []models.EBSBlockDevice{
+		{DeviceName: "/dev/sdf", VolumeSize: 100, VolumeType: "gp3", Iops: 3000, Throughput: 125},
+		{DeviceName: "/dev/sdg", VolumeSize: 100, VolumeType: "gp3", Iops: 4000, Throughput: 125},
+	}
+	desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+	desired.EBSBlockDevices = []models.EBSBlockDevice{
+		{DeviceName: "/dev/sdf", VolumeSize: 100, VolumeType: "gp3"},
+		{DeviceName: "/dev/sdg", VolumeSize: 100, VolumeType: "gp3"},
+	}
+
+	report := services.NewDriftDetector().CompareInstances(actual, desired)
+
+	assert.Equal(t, []string{"EBSBlockDevices[/dev/sdg].Iops"}, driftPaths(report))
+	assert.Zero(t, desired.EBSBlockDevices[0].Iops, "the desired instance is left unchanged")
+}
@@ -0,0 +1,79 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+)
+
+// STSAPI defines the STS operation used to assume a role
+type STSAPI = stscreds.AssumeRoleAPIClient
+
+// RoleSessionName names the sessions of assumed roles, so CloudTrail shows
+// which calls the drift detector made
+const RoleSessionName = "driftdetector"
+
+// AssumeRole identifies a role assumed to read instances in another account
+type AssumeRole struct {
+	RoleARN string
+	// ExternalID is passed when the role's trust policy requires one
+	ExternalID string
+}
+
+// ParseAssumeRole validates roleARN as an IAM role ARN
+func ParseAssumeRole(roleARN, externalID string) (AssumeRole, error) {
+	parsed, err := arn.Parse(roleARN)
+	if err != nil {
+		return AssumeRole{}, fmt.Errorf("invalid role ARN %q: %w", roleARN, err)
+	}
+	if parsed.Service != "iam" || !strings.HasPrefix(parsed.Resource, "role/") {
+		return AssumeRole{}, fmt.Errorf("invalid role ARN %q: expected arn:aws:iam::<account>:role/<name>", roleARN)
+	}
+	return AssumeRole{RoleARN: roleARN, ExternalID: externalID}, nil
+}
+
+// AccountID returns the account the role belongs to, or "" when the ARN
+// does not parse
+func (r AssumeRole) AccountID() string {
+	parsed, err := arn.Parse(r.RoleARN)
+	if err != nil {
+		return ""
+	}
+	return parsed.AccountID
+}
+
+// AssumeRoleConfig returns a copy of cfg whose credentials come from
+// assuming role through client, which calls STS with the credentials of cfg.
+// Credentials are requested on the first AWS call and refreshed before they
+// expire.
+func AssumeRoleConfig(cfg aws.Config, client STSAPI, role AssumeRole) aws.Config {
+	provider := stscreds.NewAssumeRoleProvider(client, role.RoleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = RoleSessionName
+		if role.ExternalID != "" {
+			o.ExternalID = aws.String(role.ExternalID)
+		}
+	})
+
+	assumed := cfg.Copy()
+	assumed.Credentials = aws.NewCredentialsCache(&roleCredentials{provider: provider, role: role})
+	return assumed
+}
+
+// roleCredentials names the role and account in errors assuming the role,
+// which otherwise only surface as a failed EC2 call
+type roleCredentials struct {
+	provider aws.CredentialsProvider
+	role     AssumeRole
+}
+
+func (p *roleCredentials) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	creds, err := p.provider.Retrieve(ctx)
+	if err != nil {
+		return aws.Credentials{}, fmt.Errorf("assuming role %s in account %s: %w", p.role.RoleARN, p.role.AccountID(), err)
+	}
+	return creds, nil
+}
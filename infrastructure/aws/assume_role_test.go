@@ -0,0 +1,79 @@
+package aws_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	awsrepo "driftdetector/infrastructure/aws"
+)
+
+// MockSTSAPI records the AssumeRole calls made to it
+type MockSTSAPI struct {
+	Calls []*sts.AssumeRoleInput
+	Err   error
+}
+
+func (m *MockSTSAPI) AssumeRole(ctx context.Context, params *sts.AssumeRoleInput, optFns ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	m.Calls = append(m.Calls, params)
+	if m.Err != nil {
+		return nil, m.Err
+	}
+	return &sts.AssumeRoleOutput{Credentials: &ststypes.Credentials{
+		AccessKeyId:     aws.String("ASIAASSUMED"),
+		SecretAccessKey: aws.String("secret"),
+		SessionToken:    aws.String("token"),
+		Expiration:      aws.Time(time.Now().Add(time.Hour)),
+	}}, nil
+}
+
+func TestParseAssumeRole(t *testing.T) {
+	role, err := awsrepo.ParseAssumeRole("arn:aws:iam::111111111111:role/drift-reader", "ext-1")
+	require.NoError(t, err)
+	assert.Equal(t, "111111111111", role.AccountID())
+	assert.Equal(t, "ext-1", role.ExternalID)
+
+	for _, invalid := range []string{"drift-reader", "arn:aws:iam::111111111111:user/alice", "arn:aws:s3:::bucket"} {
+		_, err := awsrepo.ParseAssumeRole(invalid, "")
+		assert.ErrorContains(t, err, "invalid role ARN", invalid)
+	}
+}
+
+func TestAssumeRoleConfig(t *testing.T) {
+	role, err := awsrepo.ParseAssumeRole("arn:aws:iam::111111111111:role/drift-reader", "ext-1")
+	require.NoError(t, err)
+
+	t.Run("credentials come from the assumed role", func(t *testing.T) {
+		client := &MockSTSAPI{}
+		cfg := aws.Config{Region: "eu-west-1"}
+
+		assumed := awsrepo.AssumeRoleConfig(cfg, client, role)
+		creds, err := assumed.Credentials.Retrieve(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, "ASIAASSUMED", creds.AccessKeyID)
+		assert.Equal(t, "eu-west-1", assumed.Region)
+		assert.Nil(t, cfg.Credentials, "the original config is left alone")
+		require.Len(t, client.Calls, 1)
+		assert.Equal(t, role.RoleARN, aws.ToString(client.Calls[0].RoleArn))
+		assert.Equal(t, "ext-1", aws.ToString(client.Calls[0].ExternalId))
+		assert.Equal(t, awsrepo.RoleSessionName, aws.ToString(client.Calls[0].RoleSessionName))
+	})
+
+	t.Run("failures name the role and account", func(t *testing.T) {
+		client := &MockSTSAPI{Err: errors.New("AccessDenied: not authorized to perform sts:AssumeRole")}
+
+		_, err := awsrepo.AssumeRoleConfig(aws.Config{}, client, role).Credentials.Retrieve(context.Background())
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "assuming role arn:aws:iam::111111111111:role/drift-reader in account 111111111111")
+		assert.Contains(t, err.Error(), "AccessDenied")
+	})
+}
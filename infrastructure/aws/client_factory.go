@@ -3,12 +3,27 @@ package aws
 import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"driftdetector/infrastructure/awsutil"
 )
 
+// S3API defines the S3 operations used to read remote Terraform state and
+// upload drift reports
+type S3API interface {
+	awsutil.S3GetObjectAPI
+	awsutil.S3PutObjectAPI
+}
+
 // ClientFactory defines an interface for creating AWS service clients
 type ClientFactory interface {
 	// NewEC2Client creates a new EC2 client with the provided config
 	NewEC2Client(cfg aws.Config) EC2API
+	// NewS3Client creates a new S3 client with the provided config
+	NewS3Client(cfg aws.Config) S3API
+	// NewSTSClient creates a new STS client with the provided config
+	NewSTSClient(cfg aws.Config) STSAPI
 }
 
 // defaultClientFactory is the default implementation of ClientFactory
@@ -23,3 +38,13 @@ func NewClientFactory() ClientFactory {
 func (f *defaultClientFactory) NewEC2Client(cfg aws.Config) EC2API {
 	return ec2.NewFromConfig(cfg)
 }
+
+// NewS3Client creates a new S3 client with the provided config
+func (f *defaultClientFactory) NewS3Client(cfg aws.Config) S3API {
+	return s3.NewFromConfig(cfg)
+}
+
+// NewSTSClient creates a new STS client with the provided config
+func (f *defaultClientFactory) NewSTSClient(cfg aws.Config) STSAPI {
+	return sts.NewFromConfig(cfg)
+}
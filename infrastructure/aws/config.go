@@ -0,0 +1,87 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// ErrRegionNotResolved is returned (wrapped) when no source provides an AWS region
+var ErrRegionNotResolved = errors.New("no AWS region configured")
+
+// regionEnvVars are consulted in order when no region is given explicitly
+var regionEnvVars = []string{"AWS_REGION", "AWS_DEFAULT_REGION"}
+
+// ConfigLoader loads an AWS config; config.LoadDefaultConfig satisfies it
+type ConfigLoader func(ctx context.Context, optFns ...func(*config.LoadOptions) error) (aws.Config, error)
+
+// ConfigResolver loads the AWS config with a consistent region precedence:
+// explicit region, then AWS_REGION and AWS_DEFAULT_REGION, then the shared
+// config of the selected profile
+type ConfigResolver struct {
+	// Region is the explicitly requested region, e.g. from --region
+	Region string
+	// Profile selects a shared config profile, e.g. from --profile
+	Profile string
+
+	// LookupEnv and Load are injectable for tests
+	LookupEnv func(key string) (string, bool)
+	Load      ConfigLoader
+}
+
+// NewConfigResolver creates a ConfigResolver that reads the process
+// environment and the shared AWS config files
+func NewConfigResolver(region, profile string) *ConfigResolver {
+	return &ConfigResolver{
+		Region:    region,
+		Profile:   profile,
+		LookupEnv: os.LookupEnv,
+		Load:      config.LoadDefaultConfig,
+	}
+}
+
+// Resolve loads the AWS config. When no source provides a region the loaded
+// config is still returned, together with an error wrapping ErrRegionNotResolved
+// that lists what was tried.
+func (r *ConfigResolver) Resolve(ctx context.Context) (aws.Config, error) {
+	var opts []func(*config.LoadOptions) error
+	if r.Profile != "" {
+		opts = append(opts, config.WithSharedConfigProfile(r.Profile))
+	}
+	if region := r.explicitRegion(); region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+
+	cfg, err := r.Load(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("loading AWS config: %w", err)
+	}
+
+	if cfg.Region == "" {
+		profile := r.Profile
+		if profile == "" {
+			profile = "default"
+		}
+		return cfg, fmt.Errorf("%w: tried --region, %s and %s, and the shared config for profile %q; set one of them",
+			ErrRegionNotResolved, regionEnvVars[0], regionEnvVars[1], profile)
+	}
+
+	return cfg, nil
+}
+
+// explicitRegion returns the region from the flag or environment, or ""
+func (r *ConfigResolver) explicitRegion() string {
+	if r.Region != "" {
+		return r.Region
+	}
+	for _, key := range regionEnvVars {
+		if v, ok := r.LookupEnv(key); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
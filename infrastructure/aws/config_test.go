@@ -0,0 +1,113 @@
+package aws_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	awsrepo "driftdetector/infrastructure/aws"
+)
+
+// fakeLoader applies load options the way LoadDefaultConfig does for region
+// and profile, falling back to the region of the named shared config profile
+func fakeLoader(profileRegions map[string]string, gotProfile *string) awsrepo.ConfigLoader {
+	return func(ctx context.Context, optFns ...func(*config.LoadOptions) error) (aws.Config, error) {
+		var opts config.LoadOptions
+		for _, fn := range optFns {
+			if err := fn(&opts); err != nil {
+				return aws.Config{}, err
+			}
+		}
+
+		profile := opts.SharedConfigProfile
+		if profile == "" {
+			profile = "default"
+		}
+		*gotProfile = profile
+
+		region := opts.Region
+		if region == "" {
+			region = profileRegions[profile]
+		}
+		return aws.Config{Region: region}, nil
+	}
+}
+
+func TestConfigResolver_Resolve(t *testing.T) {
+	tests := []struct {
+		name           string
+		region         string
+		profile        string
+		env            map[string]string
+		shared         map[string]string
+		wantRegion     string
+		wantProfile    string
+		wantUnresolved bool
+	}{
+		{
+			name:        "flag wins over everything",
+			region:      "eu-west-1",
+			env:         map[string]string{"AWS_REGION": "us-east-1", "AWS_DEFAULT_REGION": "us-east-2"},
+			shared:      map[string]string{"default": "ap-south-1"},
+			wantRegion:  "eu-west-1",
+			wantProfile: "default",
+		},
+		{
+			name:        "AWS_REGION wins over AWS_DEFAULT_REGION",
+			env:         map[string]string{"AWS_REGION": "us-east-1", "AWS_DEFAULT_REGION": "us-east-2"},
+			wantRegion:  "us-east-1",
+			wantProfile: "default",
+		},
+		{
+			name:        "AWS_DEFAULT_REGION wins over shared config",
+			env:         map[string]string{"AWS_DEFAULT_REGION": "us-east-2"},
+			shared:      map[string]string{"default": "ap-south-1"},
+			wantRegion:  "us-east-2",
+			wantProfile: "default",
+		},
+		{
+			name:        "shared config of the selected profile",
+			profile:     "prod",
+			shared:      map[string]string{"default": "ap-south-1", "prod": "eu-central-1"},
+			wantRegion:  "eu-central-1",
+			wantProfile: "prod",
+		},
+		{
+			name:           "nothing resolves",
+			profile:        "prod",
+			wantProfile:    "prod",
+			wantUnresolved: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotProfile string
+			resolver := &awsrepo.ConfigResolver{
+				Region:  tt.region,
+				Profile: tt.profile,
+				LookupEnv: func(key string) (string, bool) {
+					v, ok := tt.env[key]
+					return v, ok
+				},
+				Load: fakeLoader(tt.shared, &gotProfile),
+			}
+
+			cfg, err := resolver.Resolve(context.Background())
+
+			assert.Equal(t, tt.wantProfile, gotProfile)
+			if tt.wantUnresolved {
+				require.ErrorIs(t, err, awsrepo.ErrRegionNotResolved)
+				assert.Contains(t, err.Error(), "AWS_DEFAULT_REGION")
+				assert.Contains(t, err.Error(), `"prod"`)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRegion, cfg.Region)
+		})
+	}
+}
@@ -2,13 +2,17 @@ package aws
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
 	"driftdetector/domain/models"
 	"driftdetector/domain/repositories"
+	"driftdetector/infrastructure/awsutil"
+	"driftdetector/infrastructure/logger"
 )
 
 // Ensure EC2Repository implements the InstanceRepository interface
@@ -16,24 +20,106 @@ var _ repositories.InstanceRepository = (*EC2Repository)(nil)
 
 // EC2Repository implements the InstanceRepository interface for AWS EC2
 type EC2Repository struct {
-	client EC2API
+	client            EC2API
+	retry             awsutil.RetryOptions
+	withUserData      bool
+	withInstanceAttrs bool
+	resolveIAM        bool
+	requireFullAccess bool
 }
 
 // EC2API defines the interface for AWS EC2 operations we need
 // This makes it easier to mock for testing
-type EC2API interface {
-	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
-	DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error)
+type EC2API = awsutil.EC2API
+
+// EC2RepositoryOption configures an EC2Repository
+type EC2RepositoryOption func(*EC2Repository)
+
+// WithRetryOptions sets the retry and timeout behaviour for EC2 calls
+func WithRetryOptions(opts awsutil.RetryOptions) EC2RepositoryOption {
+	return func(r *EC2Repository) {
+		r.retry = opts
+	}
+}
+
+// WithUserData fetches each instance's user data, which costs one extra
+// DescribeInstanceAttribute call per instance
+func WithUserData() EC2RepositoryOption {
+	return func(r *EC2Repository) {
+		r.withUserData = true
+	}
+}
+
+// WithInstanceAttributes fetches attributes DescribeInstances does not return,
+// such as termination protection, at one extra call per attribute and instance
+func WithInstanceAttributes() EC2RepositoryOption {
+	return func(r *EC2Repository) {
+		r.withInstanceAttrs = true
+	}
+}
+
+// WithIAMProfileAssociations reads each instance's instance profile from its
+// IAM instance profile association, at one extra call per instance, so a
+// profile swapped or detached outside Terraform is seen as AWS reports it now
+func WithIAMProfileAssociations() EC2RepositoryOption {
+	return func(r *EC2Repository) {
+		r.resolveIAM = true
+	}
+}
+
+// WithRequireFullAccess fails reading instances whose settings could not all
+// be read for lack of permission, instead of marking those settings
+// unavailable so they are not compared
+func WithRequireFullAccess() EC2RepositoryOption {
+	return func(r *EC2Repository) {
+		r.requireFullAccess = true
+	}
 }
 
 // NewEC2Repository creates a new EC2Repository with the provided EC2API client
-func NewEC2Repository(client EC2API) *EC2Repository {
+func NewEC2Repository(client EC2API, opts ...EC2RepositoryOption) *EC2Repository {
 	if client == nil {
 		panic("EC2API client cannot be nil")
 	}
-	return &EC2Repository{
+	repo := &EC2Repository{
 		client: client,
+		retry:  awsutil.DefaultRetryOptions(),
+	}
+	for _, opt := range opts {
+		opt(repo)
+	}
+	return repo
+}
+
+// describeInstances calls DescribeInstances with the configured retry policy,
+// following NextToken until every page has been read. Each page is retried on
+// its own, so a throttled page does not restart the listing. Errors are
+// wrapped with the awsutil sentinel of their class.
+func (r *EC2Repository) describeInstances(ctx context.Context, input *ec2.DescribeInstancesInput) ([]types.Instance, error) {
+	var instances []types.Instance
+	paginator := ec2.NewDescribeInstancesPaginator(r.client, input)
+	for paginator.HasMorePages() {
+		var output *ec2.DescribeInstancesOutput
+		err := r.retry.Do(ctx, func(ctx context.Context) error {
+			var err error
+			output, err = paginator.NextPage(ctx)
+			return err
+		})
+		if err != nil {
+			return nil, awsutil.WrapError(err)
+		}
+		instances = append(instances, reservationInstances(output.Reservations)...)
 	}
+	return instances, nil
+}
+
+// describeError adds context to a DescribeInstances error, marking missing
+// instances with repositories.ErrInstanceNotFound
+func describeError(what string, err error) error {
+	if errors.Is(err, awsutil.ErrNotFound) {
+		return fmt.Errorf("failed to describe %s: %w: %w", what, repositories.ErrInstanceNotFound, err)
+	}
+	return fmt.Errorf("failed to describe %s: %w", what, err)
 }
 
 // GetByID retrieves an instance by its ID
@@ -46,16 +132,20 @@ func (r *EC2Repository) GetByID(ctx context.Context, id string) (*models.Instanc
 		InstanceIds: []string{id},
 	}
 
-	output, err := r.client.DescribeInstances(ctx, input)
+	instances, err := r.describeInstances(ctx, input)
 	if err != nil {
-		return nil, fmt.Errorf("failed to describe instance %s: %w", id, err)
+		return nil, describeError("instance "+id, err)
 	}
 
-	if len(output.Reservations) == 0 || len(output.Reservations[0].Instances) == 0 {
-		return nil, fmt.Errorf("instance %s not found", id)
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("%w: %s", repositories.ErrInstanceNotFound, id)
 	}
 
-	return r.convertToDomainInstance(ctx, output.Reservations[0].Instances[0])
+	converted, err := r.convertToDomainInstances(ctx, instances[:1])
+	if err != nil {
+		return nil, err
+	}
+	return converted[0], nil
 }
 
 // GetByIDs retrieves multiple instances by their IDs
@@ -79,22 +169,17 @@ func (r *EC2Repository) GetByIDs(ctx context.Context, ids []string) ([]*models.I
 			InstanceIds: batch,
 		}
 
-		output, err := r.client.DescribeInstances(ctx, input)
+		// EC2 rejects the whole batch if any ID does not exist
+		described, err := r.describeInstances(ctx, input)
 		if err != nil {
-			return nil, fmt.Errorf("failed to describe instances: %w", err)
+			return nil, describeError("instances", err)
 		}
 
-		for _, reservation := range output.Reservations {
-			for _, instance := range reservation.Instances {
-				converted, err := r.convertToDomainInstance(ctx, instance)
-				if err != nil {
-					// Log the error but continue with other instances
-					fmt.Printf("Warning: Failed to convert instance %s: %v\n", aws.ToString(instance.InstanceId), err)
-					continue
-				}
-				instances = append(instances, converted)
-			}
+		converted, err := r.convertToDomainInstances(ctx, described)
+		if err != nil {
+			return nil, err
 		}
+		instances = append(instances, converted...)
 	}
 
 	return instances, nil
@@ -102,38 +187,80 @@ func (r *EC2Repository) GetByIDs(ctx context.Context, ids []string) ([]*models.I
 
 // FindAll retrieves all instances
 func (r *EC2Repository) FindAll(ctx context.Context) ([]*models.Instance, error) {
-	var instances []*models.Instance
-	var nextToken *string
+	return r.Find(ctx, repositories.InstanceFilter{})
+}
 
-	for {
-		input := &ec2.DescribeInstancesInput{
-			NextToken: nextToken,
-		}
+// Find retrieves the instances matching filter, using DescribeInstances
+// filters for all but the launch time bounds, which are applied to the
+// instances returned
+func (r *EC2Repository) Find(ctx context.Context, filter repositories.InstanceFilter) ([]*models.Instance, error) {
+	input := &ec2.DescribeInstancesInput{
+		Filters: describeFilters(filter),
+	}
 
-		output, err := r.client.DescribeInstances(ctx, input)
-		if err != nil {
-			return nil, fmt.Errorf("failed to describe instances: %w", err)
-		}
+	described, err := r.describeInstances(ctx, input)
+	if err != nil {
+		return nil, describeError("instances", err)
+	}
 
-		for _, res := range output.Reservations {
-			for _, instance := range res.Instances {
-				converted, err := r.convertToDomainInstance(ctx, instance)
-				if err != nil {
-					// Log the error but continue with other instances
-					fmt.Printf("Warning: Failed to convert instance %s: %v\n", aws.ToString(instance.InstanceId), err)
-					continue
-				}
-				instances = append(instances, converted)
-			}
+	instances, err := r.convertToDomainInstances(ctx, described)
+	if err != nil {
+		return nil, err
+	}
+	matching := instances[:0]
+	for _, instance := range instances {
+		if filter.MatchesLaunchTime(instance) {
+			matching = append(matching, instance)
 		}
+	}
+	return matching, nil
+}
 
-		if output.NextToken == nil {
-			break
+// FindByTag retrieves the running instances whose tag key has value
+func (r *EC2Repository) FindByTag(ctx context.Context, key, value string) ([]*models.Instance, error) {
+	return r.Find(ctx, repositories.InstanceFilter{
+		Tags:   map[string]string{key: value},
+		States: []string{models.InstanceStateRunning},
+	})
+}
+
+// describeFilters converts an InstanceFilter into DescribeInstances filters
+func describeFilters(filter repositories.InstanceFilter) []types.Filter {
+	var filters []types.Filter
+
+	keys := make([]string, 0, len(filter.Tags))
+	for key := range filter.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := filter.Tags[key]
+		if value == "" || value == "*" {
+			filters = append(filters, types.Filter{Name: aws.String("tag-key"), Values: []string{key}})
+			continue
 		}
-		nextToken = output.NextToken
+		filters = append(filters, types.Filter{Name: aws.String("tag:" + key), Values: []string{value}})
 	}
 
-	return instances, nil
+	if len(filter.States) > 0 {
+		filters = append(filters, types.Filter{Name: aws.String("instance-state-name"), Values: filter.States})
+	}
+	// DescribeInstances accepts the * and ? wildcards of instance type patterns
+	if len(filter.InstanceTypes) > 0 {
+		filters = append(filters, types.Filter{Name: aws.String("instance-type"), Values: filter.InstanceTypes})
+	}
+	if len(filter.SubnetIDs) > 0 {
+		filters = append(filters, types.Filter{Name: aws.String("subnet-id"), Values: filter.SubnetIDs})
+	}
+	if len(filter.VPCIDs) > 0 {
+		filters = append(filters, types.Filter{Name: aws.String("vpc-id"), Values: filter.VPCIDs})
+	}
+	if len(filter.AvailabilityZones) > 0 {
+		filters = append(filters, types.Filter{Name: aws.String("availability-zone"), Values: filter.AvailabilityZones})
+	}
+
+	return filters
 }
 
 // Save is not implemented as it's not needed for read-only operations
@@ -146,130 +273,272 @@ func (r *EC2Repository) Delete(ctx context.Context, id string) error {
 	return fmt.Errorf("not implemented")
 }
 
-// getVolumeDetails fetches the details of an EBS volume by its ID
-func (r *EC2Repository) getVolumeDetails(ctx context.Context, volumeID string) (*types.Volume, error) {
-	if volumeID == "" {
-		return nil, fmt.Errorf("volume ID cannot be empty")
+// getVolumes fetches the details of EBS volumes, keyed by volume ID
+func (r *EC2Repository) getVolumes(ctx context.Context, volumeIDs []string) (map[string]types.Volume, error) {
+	if len(volumeIDs) == 0 {
+		return nil, fmt.Errorf("at least one volume ID is required")
 	}
 
 	input := &ec2.DescribeVolumesInput{
-		VolumeIds: []string{volumeID},
+		VolumeIds: volumeIDs,
 	}
 
-	result, err := r.client.DescribeVolumes(ctx, input)
+	var result *ec2.DescribeVolumesOutput
+	err := r.retry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = r.client.DescribeVolumes(ctx, input)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to describe volume %s: %w", volumeID, err)
+		return nil, fmt.Errorf("failed to describe volumes %v: %w", volumeIDs, awsutil.WrapError(err))
 	}
 
-	if len(result.Volumes) == 0 {
-		return nil, fmt.Errorf("volume %s not found", volumeID)
+	volumes := make(map[string]types.Volume, len(result.Volumes))
+	for _, volume := range result.Volumes {
+		volumes[aws.ToString(volume.VolumeId)] = volume
 	}
-
-	return &result.Volumes[0], nil
+	return volumes, nil
 }
 
-// convertToDomainInstance converts an AWS EC2 instance to our domain model
-func (r *EC2Repository) convertToDomainInstance(ctx context.Context, instance types.Instance) (*models.Instance, error) {
-	// Create a new instance with basic information
-	domainInstance := &models.Instance{
-		ID:   aws.ToString(instance.InstanceId),
-		Type: string(instance.InstanceType),
+// reservationInstances flattens the instances of DescribeInstances reservations
+func reservationInstances(reservations []types.Reservation) []types.Instance {
+	var instances []types.Instance
+	for _, reservation := range reservations {
+		instances = append(instances, reservation.Instances...)
 	}
+	return instances
+}
 
-	// Set the AMI if available
-	if instance.ImageId != nil {
-		domainInstance.AMI = *instance.ImageId
-	}
+// maxVolumeBatchSize bounds the volume IDs sent in one DescribeVolumes call
+const maxVolumeBatchSize = 500
+
+// describeVolumesPermission is the permission the fields in volumeFields
+// are read with
+const describeVolumesPermission = "ec2:DescribeVolumes"
+
+// volumeFields are the fields only DescribeVolumes reports
+var volumeFields = []string{
+	"RootVolumeSize",
+	"RootVolumeType",
+	"RootVolumeIops",
+	"RootVolumeThroughput",
+	"RootVolumeEncrypted",
+	"RootVolumeKMSKeyID",
+	"EBSBlockDevices",
+}
 
-	// Set the key name if available
-	if instance.KeyName != nil {
-		domainInstance.KeyName = *instance.KeyName
+// convertToDomainInstances converts the instances of one DescribeInstances
+// response, describing the EBS volumes attached to all of them in as few
+// DescribeVolumes calls as possible rather than one call per instance. It
+// only fails when full access is required and some settings were denied.
+func (r *EC2Repository) convertToDomainInstances(ctx context.Context, instances []types.Instance) ([]*models.Instance, error) {
+	var volumeIDs []string
+	for _, instance := range instances {
+		volumeIDs = append(volumeIDs, awsutil.VolumeIDs(instance)...)
 	}
 
-	// Initialize tags map
-	domainInstance.Tags = make(map[string]string)
-
-	// Process tags
-	for _, tag := range instance.Tags {
-		if tag.Key != nil && tag.Value != nil {
-			domainInstance.Tags[*tag.Key] = *tag.Value
+	volumes := make(map[string]types.Volume, len(volumeIDs))
+	var volumesErr error
+	for i := 0; i < len(volumeIDs); i += maxVolumeBatchSize {
+		end := i + maxVolumeBatchSize
+		if end > len(volumeIDs) {
+			end = len(volumeIDs)
+		}
+		batch, err := r.getVolumes(ctx, volumeIDs[i:end])
+		if errors.Is(err, awsutil.ErrAccessDenied) {
+			// Describing the volumes of each instance would be denied too
+			logger.Warn("not allowed to describe volumes; volume settings are not compared", "error", err)
+			volumesErr = err
+			break
+		}
+		if err != nil {
+			// A volume detached since DescribeInstances fails the whole
+			// call, so fall back to describing volumes per instance
+			logger.Warn("failed to describe volumes in batch", "error", err)
+			volumes = nil
+			break
+		}
+		for id, volume := range batch {
+			volumes[id] = volume
 		}
 	}
 
-	// Set networking information
-	if instance.VpcId != nil {
-		domainInstance.VPCID = *instance.VpcId
+	// Spot instances are few, so their requests are read in one call
+	var requestIDs []string
+	for _, instance := range instances {
+		if id := aws.ToString(instance.SpotInstanceRequestId); id != "" {
+			requestIDs = append(requestIDs, id)
+		}
+	}
+	var requests map[string]types.SpotInstanceRequest
+	if len(requestIDs) > 0 {
+		var err error
+		if requests, err = r.getSpotInstanceRequests(ctx, requestIDs); err != nil {
+			// The instances are still compared, without their market options
+			logger.Warn("failed to describe spot instance requests", "error", err)
+		}
 	}
 
-	if instance.SubnetId != nil {
-		domainInstance.SubnetID = *instance.SubnetId
+	converted := make([]*models.Instance, 0, len(instances))
+	for _, instance := range instances {
+		domainInstance := r.convertToDomainInstance(ctx, instance, volumes, volumesErr)
+		if r.requireFullAccess && len(domainInstance.UnavailableFields) > 0 {
+			return nil, fmt.Errorf("%w: cannot read the volumes of instance %s without %s", awsutil.ErrAccessDenied, domainInstance.ID, describeVolumesPermission)
+		}
+		if request, ok := requests[domainInstance.SpotInstanceRequestID]; ok {
+			awsutil.ConvertSpotInstanceRequest(request, awsutil.NewDomainInstanceSetter(domainInstance))
+		}
+		converted = append(converted, domainInstance)
 	}
+	return converted, nil
+}
 
-	if instance.PrivateIpAddress != nil {
-		domainInstance.PrivateIPAddress = *instance.PrivateIpAddress
+// getSpotInstanceRequests fetches spot instance requests, keyed by request ID
+func (r *EC2Repository) getSpotInstanceRequests(ctx context.Context, requestIDs []string) (map[string]types.SpotInstanceRequest, error) {
+	input := &ec2.DescribeSpotInstanceRequestsInput{
+		SpotInstanceRequestIds: requestIDs,
 	}
 
-	if instance.PublicIpAddress != nil {
-		domainInstance.PublicIPAddress = *instance.PublicIpAddress
+	requests := make(map[string]types.SpotInstanceRequest, len(requestIDs))
+	paginator := ec2.NewDescribeSpotInstanceRequestsPaginator(r.client, input)
+	for paginator.HasMorePages() {
+		var output *ec2.DescribeSpotInstanceRequestsOutput
+		err := r.retry.Do(ctx, func(ctx context.Context) error {
+			var err error
+			output, err = paginator.NextPage(ctx)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe spot instance requests %v: %w", requestIDs, err)
+		}
+		for _, request := range output.SpotInstanceRequests {
+			requests[aws.ToString(request.SpotInstanceRequestId)] = request
+		}
 	}
+	return requests, nil
+}
 
-	if instance.PrivateDnsName != nil {
-		domainInstance.PrivateDNSName = *instance.PrivateDnsName
+// convertToDomainInstance converts an AWS EC2 instance to our domain model,
+// using volumes for its EBS volumes, or describing them itself when nil.
+// volumesErr is the error the volumes could not be described with, if any;
+// when access to them is denied, the volume settings are marked unavailable.
+func (r *EC2Repository) convertToDomainInstance(ctx context.Context, instance types.Instance, volumes map[string]types.Volume, volumesErr error) *models.Instance {
+	domainInstance := &models.Instance{
+		Tags: make(map[string]string),
+	}
+	setter := awsutil.NewDomainInstanceSetter(domainInstance)
+	awsutil.ConvertInstance(instance, setter)
+
+	// Set root and secondary volume information if available. When the
+	// batched lookup failed, the volumes are looked up for this instance alone.
+	if volumeIDs := awsutil.VolumeIDs(instance); len(volumeIDs) > 0 {
+		err := volumesErr
+		if volumes == nil && err == nil {
+			volumes, err = r.getVolumes(ctx, volumeIDs)
+		}
+		if errors.Is(err, awsutil.ErrAccessDenied) {
+			domainInstance.MarkUnavailable(describeVolumesPermission, volumeFields...)
+		} else if err != nil {
+			// Log the error but continue with other instance data
+			logger.Warn("failed to get volume details", "instance", domainInstance.ID, "error", err)
+		} else {
+			if rootID, ok := awsutil.RootVolumeID(instance); ok {
+				if volume, found := volumes[rootID]; found {
+					awsutil.ConvertRootVolume(volume, setter)
+				} else {
+					logger.Warn("root volume not found", "instance", domainInstance.ID, "volume", rootID)
+				}
+			}
+			awsutil.ConvertBlockDevices(instance, volumes, setter)
+		}
 	}
 
-	if instance.PublicDnsName != nil {
-		domainInstance.PublicDNSName = *instance.PublicDnsName
+	if r.withUserData && domainInstance.ID != "" {
+		userData, err := r.getUserData(ctx, domainInstance.ID)
+		if err != nil {
+			logger.Warn("failed to get user data", "instance", domainInstance.ID, "error", err)
+		} else {
+			domainInstance.UserData = userData
+		}
 	}
 
-	// Set security groups
-	if len(instance.SecurityGroups) > 0 {
-		domainInstance.SecurityGroups = make([]models.SecurityGroup, 0, len(instance.SecurityGroups))
-		for _, sg := range instance.SecurityGroups {
-			if sg.GroupId != nil {
-				domainInstance.SecurityGroups = append(domainInstance.SecurityGroups, models.SecurityGroup{
-					GroupID:   *sg.GroupId,
-					GroupName: aws.ToString(sg.GroupName),
-				})
+	if r.withInstanceAttrs && domainInstance.ID != "" {
+		for _, attribute := range awsutil.InstanceAttributes() {
+			output, err := r.describeInstanceAttribute(ctx, domainInstance.ID, attribute)
+			if err != nil {
+				logger.Warn("failed to get instance attribute", "instance", domainInstance.ID, "attribute", attribute, "error", err)
+				continue
 			}
+			awsutil.ConvertInstanceAttribute(attribute, output, setter)
 		}
 	}
 
-	// Set root device information if available
-	if instance.RootDeviceName != nil && len(instance.BlockDeviceMappings) > 0 {
-		for _, bd := range instance.BlockDeviceMappings {
-			if bd.DeviceName != nil && *bd.DeviceName == *instance.RootDeviceName && bd.Ebs != nil && bd.Ebs.VolumeId != nil {
-				volume, err := r.getVolumeDetails(ctx, *bd.Ebs.VolumeId)
-				if err != nil {
-					// Log the error but continue with other instance data
-					fmt.Printf("Warning: Failed to get volume details for %s: %v\n", *bd.Ebs.VolumeId, err)
-					continue
-				}
-
-				// Set volume size if available
-				if volume.Size != nil {
-					domainInstance.RootVolumeSize = int(*volume.Size)
-				}
+	if r.resolveIAM && domainInstance.ID != "" {
+		profile, err := r.getIAMInstanceProfile(ctx, domainInstance.ID)
+		if err != nil {
+			logger.Warn("failed to get IAM instance profile association", "instance", domainInstance.ID, "error", err)
+		} else {
+			domainInstance.IAMInstanceProfile = profile
+		}
+	}
 
-				// Set volume type if available
-				if volume.VolumeType != "" {
-					domainInstance.RootVolumeType = string(volume.VolumeType)
-				}
+	return domainInstance
+}
 
-				// Set IOPS if available
-				if volume.Iops != nil {
-					domainInstance.RootVolumeIops = int(*volume.Iops)
-				}
+// getIAMInstanceProfile returns the name of the instance profile associated
+// with an instance, or "" when none is. An association being made counts, as
+// EC2 already reports the new profile for it.
+func (r *EC2Repository) getIAMInstanceProfile(ctx context.Context, instanceID string) (string, error) {
+	input := &ec2.DescribeIamInstanceProfileAssociationsInput{
+		Filters: []types.Filter{{Name: aws.String("instance-id"), Values: []string{instanceID}}},
+	}
 
-				// Set encryption status if available
-				if volume.Encrypted != nil {
-					encrypted := *volume.Encrypted
-					domainInstance.RootVolumeEncrypted = &encrypted
-				}
+	var output *ec2.DescribeIamInstanceProfileAssociationsOutput
+	err := r.retry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		output, err = r.client.DescribeIamInstanceProfileAssociations(ctx, input)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe IAM instance profile associations for %s: %w", instanceID, err)
+	}
 
-				break
+	for _, association := range output.IamInstanceProfileAssociations {
+		switch association.State {
+		case types.IamInstanceProfileAssociationStateAssociated, types.IamInstanceProfileAssociationStateAssociating:
+			if association.IamInstanceProfile != nil {
+				return awsutil.InstanceProfileName(aws.ToString(association.IamInstanceProfile.Arn)), nil
 			}
 		}
 	}
+	return "", nil
+}
+
+// describeInstanceAttribute calls DescribeInstanceAttribute with the configured retry policy
+func (r *EC2Repository) describeInstanceAttribute(ctx context.Context, instanceID string, attribute types.InstanceAttributeName) (*ec2.DescribeInstanceAttributeOutput, error) {
+	input := &ec2.DescribeInstanceAttributeInput{
+		InstanceId: aws.String(instanceID),
+		Attribute:  attribute,
+	}
 
-	return domainInstance, nil
+	var output *ec2.DescribeInstanceAttributeOutput
+	err := r.retry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		output, err = r.client.DescribeInstanceAttribute(ctx, input)
+		return err
+	})
+	return output, err
+}
+
+// getUserData retrieves the base64-encoded user data of an instance
+func (r *EC2Repository) getUserData(ctx context.Context, instanceID string) (string, error) {
+	result, err := r.describeInstanceAttribute(ctx, instanceID, types.InstanceAttributeNameUserData)
+	if err != nil {
+		return "", fmt.Errorf("failed to describe user data for %s: %w", instanceID, err)
+	}
+
+	if result.UserData == nil {
+		return "", nil
+	}
+	return aws.ToString(result.UserData.Value), nil
 }
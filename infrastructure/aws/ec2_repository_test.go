@@ -3,14 +3,21 @@ package aws_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
 	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/domain/services"
 	awsrepo "driftdetector/infrastructure/aws"
+	"driftdetector/infrastructure/awsutil"
 )
 
 // MockEC2API is a mock implementation of the EC2API interface
@@ -34,6 +41,70 @@ func (m *MockEC2API) DescribeVolumes(ctx context.Context, params *ec2.DescribeVo
 	return args.Get(0).(*ec2.DescribeVolumesOutput), args.Error(1)
 }
 
+func (m *MockEC2API) DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ec2.DescribeSecurityGroupsOutput), args.Error(1)
+}
+
+func (m *MockEC2API) DescribeInstanceAttribute(ctx context.Context, params *ec2.DescribeInstanceAttributeInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceAttributeOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ec2.DescribeInstanceAttributeOutput), args.Error(1)
+}
+
+func (m *MockEC2API) DescribeLaunchTemplateVersions(ctx context.Context, params *ec2.DescribeLaunchTemplateVersionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeLaunchTemplateVersionsOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ec2.DescribeLaunchTemplateVersionsOutput), args.Error(1)
+}
+
+func (m *MockEC2API) DescribeIamInstanceProfileAssociations(ctx context.Context, params *ec2.DescribeIamInstanceProfileAssociationsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeIamInstanceProfileAssociationsOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ec2.DescribeIamInstanceProfileAssociationsOutput), args.Error(1)
+}
+
+func (m *MockEC2API) DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ec2.DescribeImagesOutput), args.Error(1)
+}
+
+func (m *MockEC2API) DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ec2.DescribeSubnetsOutput), args.Error(1)
+}
+
+func (m *MockEC2API) DescribeAddresses(ctx context.Context, params *ec2.DescribeAddressesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAddressesOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ec2.DescribeAddressesOutput), args.Error(1)
+}
+
+func (m *MockEC2API) DescribeSpotInstanceRequests(ctx context.Context, params *ec2.DescribeSpotInstanceRequestsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSpotInstanceRequestsOutput, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*ec2.DescribeSpotInstanceRequestsOutput), args.Error(1)
+}
+
 func TestNewEC2Repository(t *testing.T) {
 	// Given
 	mockClient := new(MockEC2API)
@@ -75,7 +146,9 @@ func TestEC2Repository_FindAll(t *testing.T) {
 	})
 
 	t.Run("error from API call", func(t *testing.T) {
-		// Setup mock
+		// Setup mock (fresh, so the successful expectation above does not match first)
+		mockClient := new(MockEC2API)
+		repo := awsrepo.NewEC2Repository(mockClient)
 		expectedErr := assert.AnError
 		mockClient.On("DescribeInstances", mock.Anything, mock.Anything).Return((*ec2.DescribeInstancesOutput)(nil), expectedErr)
 
@@ -134,6 +207,533 @@ func TestEC2Repository_GetByID(t *testing.T) {
 
 		// Then
 		assert.Error(t, err, "Should return an error")
+		assert.ErrorIs(t, err, repositories.ErrInstanceNotFound, "Should identify the instance as missing")
 		assert.Nil(t, instance, "Should not return an instance")
 	})
 }
+
+func TestEC2Repository_GetByID_WithInstanceAttributes(t *testing.T) {
+	// Given
+	mockClient := new(MockEC2API)
+	repo := awsrepo.NewEC2Repository(mockClient, awsrepo.WithInstanceAttributes())
+	instanceID := "i-1234567890abcdef0"
+
+	mockClient.On("DescribeInstances", mock.Anything, mock.Anything).Return(&ec2.DescribeInstancesOutput{
+		Reservations: []types.Reservation{
+			{
+				Instances: []types.Instance{
+					{
+						InstanceId:         aws.String(instanceID),
+						EbsOptimized:       aws.Bool(true),
+						Monitoring:         &types.Monitoring{State: types.MonitoringStateEnabled},
+						HibernationOptions: &types.HibernationOptions{Configured: aws.Bool(true)},
+						EnclaveOptions:     &types.EnclaveOptions{Enabled: aws.Bool(false)},
+					},
+				},
+			},
+		},
+	}, nil)
+	mockClient.On("DescribeInstanceAttribute", mock.Anything, mock.MatchedBy(func(input *ec2.DescribeInstanceAttributeInput) bool {
+		return input.Attribute == types.InstanceAttributeNameDisableApiTermination
+	})).Return(&ec2.DescribeInstanceAttributeOutput{
+		DisableApiTermination: &types.AttributeBooleanValue{Value: aws.Bool(true)},
+	}, nil)
+	mockClient.On("DescribeInstanceAttribute", mock.Anything, mock.MatchedBy(func(input *ec2.DescribeInstanceAttributeInput) bool {
+		return input.Attribute == types.InstanceAttributeNameInstanceInitiatedShutdownBehavior
+	})).Return(&ec2.DescribeInstanceAttributeOutput{
+		InstanceInitiatedShutdownBehavior: &types.AttributeValue{Value: aws.String("terminate")},
+	}, nil)
+
+	// When
+	instance, err := repo.GetByID(context.Background(), instanceID)
+
+	// Then
+	assert.NoError(t, err, "Should not return an error")
+	if assert.NotNil(t, instance.DisableAPITermination, "Termination protection should be read") {
+		assert.True(t, *instance.DisableAPITermination)
+	}
+	if assert.NotNil(t, instance.Monitoring, "Monitoring should be read") {
+		assert.True(t, *instance.Monitoring)
+	}
+	assert.Equal(t, "terminate", instance.InstanceInitiatedShutdownBehavior, "Shutdown behavior should be read")
+	assert.Equal(t, &models.HibernationOptions{Configured: true}, instance.Hibernation)
+	assert.Equal(t, &models.EnclaveOptions{Enabled: false}, instance.EnclaveOptions)
+	mockClient.AssertExpectations(t)
+}
+
+func TestEC2Repository_GetByID_WithIAMProfileAssociations(t *testing.T) {
+	instanceID := "i-1234567890abcdef0"
+	describe := &ec2.DescribeInstancesOutput{
+		Reservations: []types.Reservation{{Instances: []types.Instance{{
+			InstanceId: aws.String(instanceID),
+			IamInstanceProfile: &types.IamInstanceProfile{
+				Arn: aws.String("arn:aws:iam::123456789012:instance-profile/web"),
+			},
+		}}}},
+	}
+
+	tests := []struct {
+		name         string
+		associations []types.IamInstanceProfileAssociation
+		expected     string
+	}{
+		{
+			name: "current association",
+			associations: []types.IamInstanceProfileAssociation{
+				{
+					State:              types.IamInstanceProfileAssociationStateDisassociated,
+					IamInstanceProfile: &types.IamInstanceProfile{Arn: aws.String("arn:aws:iam::123456789012:instance-profile/web")},
+				},
+				{
+					State:              types.IamInstanceProfileAssociationStateAssociated,
+					IamInstanceProfile: &types.IamInstanceProfile{Arn: aws.String("arn:aws:iam::123456789012:instance-profile/ops/admin")},
+				},
+			},
+			expected: "admin",
+		},
+		{
+			name:     "no association",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			mockClient := new(MockEC2API)
+			repo := awsrepo.NewEC2Repository(mockClient, awsrepo.WithIAMProfileAssociations())
+			mockClient.On("DescribeInstances", mock.Anything, mock.Anything).Return(describe, nil)
+			mockClient.On("DescribeIamInstanceProfileAssociations", mock.Anything, mock.MatchedBy(func(input *ec2.DescribeIamInstanceProfileAssociationsInput) bool {
+				return len(input.Filters) == 1 && aws.ToString(input.Filters[0].Name) == "instance-id" && input.Filters[0].Values[0] == instanceID
+			})).Return(&ec2.DescribeIamInstanceProfileAssociationsOutput{IamInstanceProfileAssociations: tt.associations}, nil)
+
+			// When
+			instance, err := repo.GetByID(context.Background(), instanceID)
+
+			// Then
+			assert.NoError(t, err, "Should not return an error")
+			assert.Equal(t, tt.expected, instance.IAMInstanceProfile)
+			mockClient.AssertExpectations(t)
+		})
+	}
+}
+
+// instanceWithVolumes returns an instance with a root volume and the given
+// secondary volumes, attached at /dev/sdf, /dev/sdg and so on
+func instanceWithVolumes(id, root string, secondary ...string) types.Instance {
+	instance := types.Instance{
+		InstanceId:     aws.String(id),
+		RootDeviceName: aws.String("/dev/xvda"),
+		BlockDeviceMappings: []types.InstanceBlockDeviceMapping{
+			{DeviceName: aws.String("/dev/xvda"), Ebs: &types.EbsInstanceBlockDevice{VolumeId: aws.String(root), DeleteOnTermination: aws.Bool(true)}},
+		},
+	}
+	for i, volumeID := range secondary {
+		instance.BlockDeviceMappings = append(instance.BlockDeviceMappings, types.InstanceBlockDeviceMapping{
+			DeviceName: aws.String("/dev/sd" + string(rune('f'+i))),
+			Ebs:        &types.EbsInstanceBlockDevice{VolumeId: aws.String(volumeID), DeleteOnTermination: aws.Bool(false), Status: types.AttachmentStatusAttached},
+		})
+	}
+	return instance
+}
+
+func TestEC2Repository_GetByID_SecondaryVolumes(t *testing.T) {
+	// Given
+	mockClient := new(MockEC2API)
+	repo := awsrepo.NewEC2Repository(mockClient)
+	instanceID := "i-1234567890abcdef0"
+
+	mockClient.On("DescribeInstances", mock.Anything, mock.Anything).Return(&ec2.DescribeInstancesOutput{
+		Reservations: []types.Reservation{
+			{Instances: []types.Instance{instanceWithVolumes(instanceID, "vol-root", "vol-data", "vol-logs")}},
+		},
+	}, nil)
+	mockClient.On("DescribeVolumes", mock.Anything, mock.MatchedBy(func(input *ec2.DescribeVolumesInput) bool {
+		return len(input.VolumeIds) == 3
+	})).Return(&ec2.DescribeVolumesOutput{
+		Volumes: []types.Volume{
+			{VolumeId: aws.String("vol-root"), Size: aws.Int32(8), VolumeType: types.VolumeTypeGp3},
+			{
+				VolumeId: aws.String("vol-data"), Size: aws.Int32(200), VolumeType: types.VolumeTypeGp3, Encrypted: aws.Bool(true),
+				Tags: []types.Tag{{Key: aws.String("Name"), Value: aws.String("data")}},
+			},
+			{VolumeId: aws.String("vol-logs"), Size: aws.Int32(20), VolumeType: types.VolumeTypeSt1, Encrypted: aws.Bool(true)},
+		},
+	}, nil).Once()
+
+	// When
+	instance, err := repo.GetByID(context.Background(), instanceID)
+
+	// Then
+	assert.NoError(t, err, "Should not return an error")
+	assert.Equal(t, 8, instance.RootVolumeSize, "Root volume should come from the batch")
+	if assert.Len(t, instance.EBSBlockDevices, 2, "Secondary volumes should be converted") {
+		data := instance.EBSBlockDevices[0]
+		assert.Equal(t, "/dev/sdf", data.DeviceName)
+		assert.Equal(t, 200, data.VolumeSize)
+		assert.Equal(t, map[string]string{"Name": "data"}, data.Tags)
+		if assert.NotNil(t, data.DeleteOnTermination) {
+			assert.False(t, *data.DeleteOnTermination, "DeleteOnTermination should come from the mapping")
+		}
+	}
+
+	deleteOnTermination := false
+	desired := &models.Instance{
+		ID:             instanceID,
+		RootVolumeSize: 8,
+		RootVolumeType: "gp3",
+		EBSBlockDevices: []models.EBSBlockDevice{
+			{DeviceName: "/dev/sdg", VolumeSize: 20, VolumeType: "st1", Encrypted: aws.Bool(true), DeleteOnTermination: &deleteOnTermination},
+			{DeviceName: "/dev/sdf", VolumeSize: 100, VolumeType: "gp3", Encrypted: aws.Bool(true), DeleteOnTermination: &deleteOnTermination, Tags: map[string]string{"Name": "data"}},
+		},
+	}
+	report := services.NewDriftDetector().CompareInstances(instance, desired)
+	if assert.Len(t, report.Drifts, 1, "Only the resized volume should drift: %v", report.Drifts) {
+		assert.Equal(t, "EBSBlockDevices[/dev/sdf].VolumeSize", report.Drifts[0].Path)
+		assert.Equal(t, 200, report.Drifts[0].Actual)
+		assert.Equal(t, 100, report.Drifts[0].Expected)
+	}
+	mockClient.AssertExpectations(t)
+}
+
+func TestEC2Repository_GetByIDs_BatchesVolumes(t *testing.T) {
+	// Given
+	mockClient := new(MockEC2API)
+	repo := awsrepo.NewEC2Repository(mockClient)
+
+	mockClient.On("DescribeInstances", mock.Anything, mock.Anything).Return(&ec2.DescribeInstancesOutput{
+		Reservations: []types.Reservation{
+			{Instances: []types.Instance{instanceWithVolumes("i-1", "vol-root-1", "vol-data-1")}},
+			{Instances: []types.Instance{instanceWithVolumes("i-2", "vol-root-2")}},
+		},
+	}, nil)
+	mockClient.On("DescribeVolumes", mock.Anything, mock.MatchedBy(func(input *ec2.DescribeVolumesInput) bool {
+		return len(input.VolumeIds) == 3
+	})).Return(&ec2.DescribeVolumesOutput{
+		Volumes: []types.Volume{
+			{VolumeId: aws.String("vol-root-1"), Size: aws.Int32(8)},
+			{VolumeId: aws.String("vol-data-1"), Size: aws.Int32(50)},
+			{VolumeId: aws.String("vol-root-2"), Size: aws.Int32(16)},
+		},
+	}, nil).Once()
+
+	// When
+	instances, err := repo.GetByIDs(context.Background(), []string{"i-1", "i-2"})
+
+	// Then
+	assert.NoError(t, err, "Should not return an error")
+	if assert.Len(t, instances, 2) {
+		assert.Equal(t, 8, instances[0].RootVolumeSize)
+		assert.Len(t, instances[0].EBSBlockDevices, 1)
+		assert.Equal(t, 16, instances[1].RootVolumeSize)
+	}
+	mockClient.AssertNumberOfCalls(t, "DescribeVolumes", 1)
+}
+
+func TestEC2Repository_DescribeVolumesDenied(t *testing.T) {
+	denied := func() *MockEC2API {
+		mockClient := new(MockEC2API)
+		mockClient.On("DescribeInstances", mock.Anything, mock.Anything).Return(&ec2.DescribeInstancesOutput{
+			Reservations: []types.Reservation{
+				{Instances: []types.Instance{instanceWithVolumes("i-1", "vol-root-1", "vol-data-1"), instanceWithVolumes("i-2", "vol-root-2")}},
+			},
+		}, nil)
+		mockClient.On("DescribeVolumes", mock.Anything, mock.Anything).
+			Return(nil, &smithy.GenericAPIError{Code: "UnauthorizedOperation"})
+		return mockClient
+	}
+
+	t.Run("volume settings are not compared", func(t *testing.T) {
+		// Given a role without ec2:DescribeVolumes
+		mockClient := denied()
+		repo := awsrepo.NewEC2Repository(mockClient)
+
+		// When
+		instances, err := repo.GetByIDs(context.Background(), []string{"i-1", "i-2"})
+
+		// Then the instances are read, in one denied call, with their volume settings marked unavailable
+		require.NoError(t, err)
+		require.Len(t, instances, 2)
+		mockClient.AssertNumberOfCalls(t, "DescribeVolumes", 1)
+		assert.Equal(t, "ec2:DescribeVolumes", instances[0].UnavailableFields["RootVolumeSize"])
+		assert.Equal(t, "ec2:DescribeVolumes", instances[0].UnavailableFields["EBSBlockDevices"])
+
+		// And comparing them with configured volumes produces no false drift
+		deleteOnTermination := false
+		desired := &models.Instance{
+			ID:                  "i-1",
+			RootVolumeSize:      20,
+			RootVolumeType:      "gp3",
+			RootVolumeEncrypted: aws.Bool(true),
+			EBSBlockDevices: []models.EBSBlockDevice{
+				{DeviceName: "/dev/sdf", VolumeSize: 100, VolumeType: "gp3", DeleteOnTermination: &deleteOnTermination},
+			},
+		}
+		report := services.NewDriftDetector().CompareInstances(instances[0], desired)
+		assert.Empty(t, report.Drifts)
+		assert.Equal(t, []string{
+			"EBS block devices could not be verified (missing ec2:DescribeVolumes)",
+			"root volume attributes could not be verified (missing ec2:DescribeVolumes)",
+		}, report.Warnings)
+	})
+
+	t.Run("require full access", func(t *testing.T) {
+		// Given a role without ec2:DescribeVolumes and full access required
+		repo := awsrepo.NewEC2Repository(denied(), awsrepo.WithRequireFullAccess())
+
+		// When
+		_, err := repo.GetByIDs(context.Background(), []string{"i-1", "i-2"})
+
+		// Then
+		assert.ErrorIs(t, err, awsutil.ErrAccessDenied)
+		assert.ErrorContains(t, err, "ec2:DescribeVolumes")
+	})
+}
+
+func TestEC2Repository_GetByIDs_SpotInstanceRequests(t *testing.T) {
+	spot := func(id, requestID string) types.Instance {
+		return types.Instance{
+			InstanceId:            aws.String(id),
+			InstanceLifecycle:     types.InstanceLifecycleTypeSpot,
+			SpotInstanceRequestId: aws.String(requestID),
+		}
+	}
+
+	t.Run("reads the requests of every spot instance in one call", func(t *testing.T) {
+		// Given
+		mockClient := new(MockEC2API)
+		repo := awsrepo.NewEC2Repository(mockClient)
+		mockClient.On("DescribeInstances", mock.Anything, mock.Anything).Return(&ec2.DescribeInstancesOutput{
+			Reservations: []types.Reservation{
+				{Instances: []types.Instance{spot("i-1", "sir-1"), spot("i-2", "sir-2"), {InstanceId: aws.String("i-3")}}},
+			},
+		}, nil)
+		mockClient.On("DescribeSpotInstanceRequests", mock.Anything, mock.MatchedBy(func(input *ec2.DescribeSpotInstanceRequestsInput) bool {
+			return assert.ObjectsAreEqual([]string{"sir-1", "sir-2"}, input.SpotInstanceRequestIds)
+		})).Return(&ec2.DescribeSpotInstanceRequestsOutput{
+			SpotInstanceRequests: []types.SpotInstanceRequest{
+				{SpotInstanceRequestId: aws.String("sir-1"), SpotPrice: aws.String("0.050000"), InstanceInterruptionBehavior: types.InstanceInterruptionBehaviorStop},
+				{SpotInstanceRequestId: aws.String("sir-2"), SpotPrice: aws.String("0.100000"), InstanceInterruptionBehavior: types.InstanceInterruptionBehaviorTerminate},
+			},
+		}, nil).Once()
+
+		// When
+		instances, err := repo.GetByIDs(context.Background(), []string{"i-1", "i-2", "i-3"})
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, instances, 3)
+		assert.Equal(t, "spot", instances[0].InstanceLifecycle)
+		assert.Equal(t, "sir-1", instances[0].SpotInstanceRequestID)
+		assert.Equal(t, &models.InstanceMarketOptions{MarketType: "spot", MaxPrice: "0.050000", InstanceInterruptionBehavior: "stop"}, instances[0].InstanceMarketOptions)
+		assert.Equal(t, "0.100000", instances[1].InstanceMarketOptions.MaxPrice)
+		assert.Empty(t, instances[2].InstanceLifecycle, "On-demand instances have no lifecycle")
+		assert.Nil(t, instances[2].InstanceMarketOptions)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("keeps the instances when the requests cannot be read", func(t *testing.T) {
+		// Given
+		mockClient := new(MockEC2API)
+		repo := awsrepo.NewEC2Repository(mockClient)
+		mockClient.On("DescribeInstances", mock.Anything, mock.Anything).Return(&ec2.DescribeInstancesOutput{
+			Reservations: []types.Reservation{{Instances: []types.Instance{spot("i-1", "sir-1")}}},
+		}, nil)
+		mockClient.On("DescribeSpotInstanceRequests", mock.Anything, mock.Anything).
+			Return(nil, &smithy.GenericAPIError{Code: "UnauthorizedOperation"})
+
+		// When
+		instance, err := repo.GetByID(context.Background(), "i-1")
+
+		// Then
+		require.NoError(t, err)
+		assert.Equal(t, "spot", instance.InstanceLifecycle)
+		assert.Nil(t, instance.InstanceMarketOptions, "Market options are unknown")
+	})
+}
+
+func TestEC2Repository_Find(t *testing.T) {
+	// Given
+	mockClient := new(MockEC2API)
+	repo := awsrepo.NewEC2Repository(mockClient)
+
+	mockClient.On("DescribeInstances", mock.Anything, mock.MatchedBy(func(input *ec2.DescribeInstancesInput) bool {
+		if len(input.Filters) != 3 {
+			return false
+		}
+		return aws.ToString(input.Filters[0].Name) == "tag:Environment" && input.Filters[0].Values[0] == "prod" &&
+			aws.ToString(input.Filters[1].Name) == "tag-key" && input.Filters[1].Values[0] == "Team" &&
+			aws.ToString(input.Filters[2].Name) == "instance-state-name" && input.Filters[2].Values[0] == "running"
+	})).Return(&ec2.DescribeInstancesOutput{
+		Reservations: []types.Reservation{
+			{Instances: []types.Instance{{InstanceId: aws.String("i-1234567890abcdef0")}}},
+		},
+	}, nil)
+
+	// When
+	instances, err := repo.Find(context.Background(), repositories.InstanceFilter{
+		Tags:   map[string]string{"Environment": "prod", "Team": "*"},
+		States: []string{"running"},
+	})
+
+	// Then
+	assert.NoError(t, err, "Should not return an error")
+	assert.Len(t, instances, 1, "Should return the matching instance")
+	mockClient.AssertExpectations(t)
+}
+
+func TestEC2Repository_Find_NativeAndClientSideFilters(t *testing.T) {
+	// Given a filter on type, subnet, zone and launch time
+	mockClient := new(MockEC2API)
+	repo := awsrepo.NewEC2Repository(mockClient)
+	launched := func(year int) *time.Time {
+		t := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	mockClient.On("DescribeInstances", mock.Anything, mock.MatchedBy(func(input *ec2.DescribeInstancesInput) bool {
+		names := make(map[string][]string)
+		for _, f := range input.Filters {
+			names[aws.ToString(f.Name)] = f.Values
+		}
+		return assert.ObjectsAreEqual(map[string][]string{
+			"instance-type":     {"t3.*"},
+			"subnet-id":         {"subnet-abc"},
+			"availability-zone": {"us-east-1a"},
+		}, names)
+	})).Return(&ec2.DescribeInstancesOutput{
+		Reservations: []types.Reservation{{Instances: []types.Instance{
+			{InstanceId: aws.String("i-old"), LaunchTime: launched(2021)},
+			{InstanceId: aws.String("i-new"), LaunchTime: launched(2024)},
+			{InstanceId: aws.String("i-unknown")},
+		}}},
+	}, nil)
+
+	// When
+	instances, err := repo.Find(context.Background(), repositories.InstanceFilter{
+		InstanceTypes:     []string{"t3.*"},
+		SubnetIDs:         []string{"subnet-abc"},
+		AvailabilityZones: []string{"us-east-1a"},
+		LaunchedBefore:    time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+
+	// Then AWS filters all but the launch time, which is applied to the results
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	assert.Equal(t, "i-old", instances[0].ID)
+	mockClient.AssertExpectations(t)
+}
+
+func TestEC2Repository_FindByTag(t *testing.T) {
+	// Given
+	mockClient := new(MockEC2API)
+	repo := awsrepo.NewEC2Repository(mockClient)
+
+	mockClient.On("DescribeInstances", mock.Anything, mock.MatchedBy(func(input *ec2.DescribeInstancesInput) bool {
+		return len(input.Filters) == 2 &&
+			aws.ToString(input.Filters[0].Name) == "tag:Name" && input.Filters[0].Values[0] == "web" &&
+			aws.ToString(input.Filters[1].Name) == "instance-state-name" && input.Filters[1].Values[0] == "running"
+	})).Return(&ec2.DescribeInstancesOutput{
+		Reservations: []types.Reservation{
+			{Instances: []types.Instance{{InstanceId: aws.String("i-1234567890abcdef0")}}},
+		},
+	}, nil)
+
+	// When
+	instances, err := repo.FindByTag(context.Background(), "Name", "web")
+
+	// Then
+	assert.NoError(t, err, "Should not return an error")
+	assert.Len(t, instances, 1, "Should return the running instance with the tag")
+	mockClient.AssertExpectations(t)
+}
+
+func TestEC2Repository_Find_Paginates(t *testing.T) {
+	// Given two pages of instances
+	mockClient := new(MockEC2API)
+	repo := awsrepo.NewEC2Repository(mockClient)
+
+	mockClient.On("DescribeInstances", mock.Anything, mock.MatchedBy(func(input *ec2.DescribeInstancesInput) bool {
+		return input.NextToken == nil
+	})).Return(&ec2.DescribeInstancesOutput{
+		Reservations: []types.Reservation{
+			{Instances: []types.Instance{{InstanceId: aws.String("i-1")}}},
+		},
+		NextToken: aws.String("page-2"),
+	}, nil).Once()
+	mockClient.On("DescribeInstances", mock.Anything, mock.MatchedBy(func(input *ec2.DescribeInstancesInput) bool {
+		return aws.ToString(input.NextToken) == "page-2"
+	})).Return(&ec2.DescribeInstancesOutput{
+		Reservations: []types.Reservation{
+			{Instances: []types.Instance{{InstanceId: aws.String("i-2")}, {InstanceId: aws.String("i-3")}}},
+		},
+	}, nil).Once()
+
+	// When
+	instances, err := repo.FindAll(context.Background())
+
+	// Then
+	assert.NoError(t, err, "Should not return an error")
+	assert.Len(t, instances, 3, "Should return the instances of every page")
+	mockClient.AssertExpectations(t)
+}
+
+func TestEC2Repository_GetByID_Errors(t *testing.T) {
+	retry := awsutil.RetryOptions{MaxAttempts: 3, BaseDelay: time.Millisecond}
+	running := &ec2.DescribeInstancesOutput{
+		Reservations: []types.Reservation{
+			{Instances: []types.Instance{{InstanceId: aws.String("i-1"), State: &types.InstanceState{Name: "running"}}}},
+		},
+	}
+
+	t.Run("throttling is retried", func(t *testing.T) {
+		mockClient := new(MockEC2API)
+		repo := awsrepo.NewEC2Repository(mockClient, awsrepo.WithRetryOptions(retry))
+		mockClient.On("DescribeInstances", mock.Anything, mock.Anything).
+			Return(nil, &smithy.GenericAPIError{Code: "RequestLimitExceeded"}).Once()
+		mockClient.On("DescribeInstances", mock.Anything, mock.Anything).Return(running, nil).Once()
+
+		instance, err := repo.GetByID(context.Background(), "i-1")
+
+		assert.NoError(t, err, "Should succeed once AWS stops throttling")
+		assert.Equal(t, "i-1", instance.ID)
+		mockClient.AssertNumberOfCalls(t, "DescribeInstances", 2)
+	})
+
+	t.Run("throttling beyond max attempts", func(t *testing.T) {
+		mockClient := new(MockEC2API)
+		repo := awsrepo.NewEC2Repository(mockClient, awsrepo.WithRetryOptions(retry))
+		mockClient.On("DescribeInstances", mock.Anything, mock.Anything).
+			Return(nil, &smithy.GenericAPIError{Code: "RequestLimitExceeded"})
+
+		_, err := repo.GetByID(context.Background(), "i-1")
+
+		assert.ErrorIs(t, err, awsutil.ErrThrottled)
+		mockClient.AssertNumberOfCalls(t, "DescribeInstances", 3)
+	})
+
+	t.Run("access denied", func(t *testing.T) {
+		mockClient := new(MockEC2API)
+		repo := awsrepo.NewEC2Repository(mockClient, awsrepo.WithRetryOptions(retry))
+		mockClient.On("DescribeInstances", mock.Anything, mock.Anything).
+			Return(nil, &smithy.GenericAPIError{Code: "UnauthorizedOperation"})
+
+		_, err := repo.GetByID(context.Background(), "i-1")
+
+		assert.ErrorIs(t, err, awsutil.ErrAccessDenied)
+		assert.NotErrorIs(t, err, repositories.ErrInstanceNotFound)
+		mockClient.AssertNumberOfCalls(t, "DescribeInstances", 1)
+	})
+
+	t.Run("unknown instance ID", func(t *testing.T) {
+		mockClient := new(MockEC2API)
+		repo := awsrepo.NewEC2Repository(mockClient, awsrepo.WithRetryOptions(retry))
+		mockClient.On("DescribeInstances", mock.Anything, mock.Anything).
+			Return(nil, &smithy.GenericAPIError{Code: "InvalidInstanceID.NotFound"})
+
+		_, err := repo.GetByID(context.Background(), "i-1")
+
+		assert.ErrorIs(t, err, repositories.ErrInstanceNotFound)
+		assert.ErrorIs(t, err, awsutil.ErrNotFound)
+	})
+}
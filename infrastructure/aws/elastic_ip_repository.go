@@ -0,0 +1,100 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/infrastructure/awsutil"
+)
+
+// Ensure ElasticIPRepository implements the ElasticIPRepository interface
+var _ repositories.ElasticIPRepository = (*ElasticIPRepository)(nil)
+
+// ElasticIPRepository reads Elastic IP addresses from AWS EC2. The addresses
+// of the region are described once and cached, however many instances are
+// checked against them.
+type ElasticIPRepository struct {
+	client awsutil.EC2DescribeAddressesAPI
+	retry  awsutil.RetryOptions
+
+	mu    sync.Mutex
+	cache []*models.ElasticIP
+}
+
+// ElasticIPRepositoryOption configures an ElasticIPRepository
+type ElasticIPRepositoryOption func(*ElasticIPRepository)
+
+// WithElasticIPRetryOptions sets the retry and timeout behaviour for
+// DescribeAddresses calls
+func WithElasticIPRetryOptions(opts awsutil.RetryOptions) ElasticIPRepositoryOption {
+	return func(r *ElasticIPRepository) {
+		r.retry = opts
+	}
+}
+
+// NewElasticIPRepository creates a new ElasticIPRepository
+func NewElasticIPRepository(client awsutil.EC2DescribeAddressesAPI, opts ...ElasticIPRepositoryOption) *ElasticIPRepository {
+	if client == nil {
+		panic("EC2 address client cannot be nil")
+	}
+	repo := &ElasticIPRepository{
+		client: client,
+		retry:  awsutil.DefaultRetryOptions(),
+	}
+	for _, opt := range opts {
+		opt(repo)
+	}
+	return repo
+}
+
+// FindAll retrieves every Elastic IP address of the region, ordered by
+// allocation ID. Addresses without an allocation ID, which only EC2-Classic
+// had, are left out.
+func (r *ElasticIPRepository) FindAll(ctx context.Context) ([]*models.ElasticIP, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cache != nil {
+		return r.cache, nil
+	}
+
+	var output *ec2.DescribeAddressesOutput
+	err := r.retry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		output, err = r.client.DescribeAddresses(ctx, &ec2.DescribeAddressesInput{})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe addresses: %w", awsutil.WrapError(err))
+	}
+
+	eips := make([]*models.ElasticIP, 0, len(output.Addresses))
+	for _, address := range output.Addresses {
+		if aws.ToString(address.AllocationId) == "" {
+			continue
+		}
+		eips = append(eips, convertAddress(address))
+	}
+	sort.Slice(eips, func(i, j int) bool {
+		return eips[i].AllocationID < eips[j].AllocationID
+	})
+	r.cache = eips
+	return eips, nil
+}
+
+// convertAddress converts an EC2 address into the domain model
+func convertAddress(address types.Address) *models.ElasticIP {
+	return &models.ElasticIP{
+		AllocationID: aws.ToString(address.AllocationId),
+		PublicIP:     aws.ToString(address.PublicIp),
+		InstanceID:   aws.ToString(address.InstanceId),
+	}
+}
@@ -0,0 +1,55 @@
+package aws_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	awsrepo "driftdetector/infrastructure/aws"
+)
+
+func TestElasticIPRepository_FindAll(t *testing.T) {
+	t.Run("converts and caches addresses", func(t *testing.T) {
+		// Given
+		mockClient := new(MockEC2API)
+		mockClient.On("DescribeAddresses", mock.Anything, mock.Anything).Return(&ec2.DescribeAddressesOutput{
+			Addresses: []types.Address{
+				{AllocationId: aws.String("eipalloc-b"), PublicIp: aws.String("198.51.100.7")},
+				{AllocationId: aws.String("eipalloc-a"), PublicIp: aws.String("203.0.113.10"), InstanceId: aws.String("i-1")},
+				{PublicIp: aws.String("192.0.2.1"), InstanceId: aws.String("i-classic")},
+			},
+		}, nil).Once()
+		repo := awsrepo.NewElasticIPRepository(mockClient)
+
+		// When
+		eips, err := repo.FindAll(context.Background())
+		require.NoError(t, err)
+		again, err := repo.FindAll(context.Background())
+		require.NoError(t, err)
+
+		// Then
+		assert.Equal(t, []*models.ElasticIP{
+			{AllocationID: "eipalloc-a", PublicIP: "203.0.113.10", InstanceID: "i-1"},
+			{AllocationID: "eipalloc-b", PublicIP: "198.51.100.7"},
+		}, eips, "addresses without an allocation ID are left out")
+		assert.Equal(t, eips, again)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("access denied is an error", func(t *testing.T) {
+		mockClient := new(MockEC2API)
+		mockClient.On("DescribeAddresses", mock.Anything, mock.Anything).Return(nil, &smithy.GenericAPIError{Code: "UnauthorizedOperation"}).Once()
+
+		_, err := awsrepo.NewElasticIPRepository(mockClient).FindAll(context.Background())
+
+		assert.ErrorContains(t, err, "failed to describe addresses")
+	})
+}
@@ -0,0 +1,228 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/infrastructure/awsutil"
+	"driftdetector/infrastructure/terraform"
+)
+
+// Ensure ImageRepository implements the ImageRepository interface and can
+// resolve the data "aws_ami" blocks of Terraform configuration
+var (
+	_ repositories.ImageRepository = (*ImageRepository)(nil)
+	_ terraform.AMIResolver        = (*ImageRepository)(nil)
+)
+
+// ImageRepository reads AMIs from AWS EC2. Images are cached, including those
+// that do not exist, so each ID is described at most once.
+type ImageRepository struct {
+	client awsutil.EC2DescribeImagesAPI
+	retry  awsutil.RetryOptions
+
+	mu    sync.Mutex
+	cache map[string]*models.Image
+}
+
+// ImageRepositoryOption configures an ImageRepository
+type ImageRepositoryOption func(*ImageRepository)
+
+// WithImageRetryOptions sets the retry and timeout behaviour for
+// DescribeImages calls
+func WithImageRetryOptions(opts awsutil.RetryOptions) ImageRepositoryOption {
+	return func(r *ImageRepository) {
+		r.retry = opts
+	}
+}
+
+// NewImageRepository creates a new ImageRepository
+func NewImageRepository(client awsutil.EC2DescribeImagesAPI, opts ...ImageRepositoryOption) *ImageRepository {
+	if client == nil {
+		panic("EC2 image client cannot be nil")
+	}
+	repo := &ImageRepository{
+		client: client,
+		retry:  awsutil.DefaultRetryOptions(),
+		cache:  make(map[string]*models.Image),
+	}
+	for _, opt := range opts {
+		opt(repo)
+	}
+	return repo
+}
+
+// GetByIDs retrieves the given images, describing only those not looked up
+// before. Images that no longer exist are left out.
+func (r *ImageRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.Image, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var missing []string
+	for _, id := range ids {
+		if _, ok := r.cache[id]; !ok && id != "" {
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		described, err := r.describe(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range missing {
+			r.cache[id] = nil
+		}
+		for _, image := range described {
+			converted := convertImage(image)
+			r.cache[converted.ID] = converted
+		}
+	}
+
+	var images []*models.Image
+	for _, id := range ids {
+		if image := r.cache[id]; image != nil {
+			images = append(images, image)
+		}
+	}
+	return images, nil
+}
+
+// describe describes the images with ids. AWS fails the whole call when one
+// of them does not exist, so the images are then described one at a time.
+func (r *ImageRepository) describe(ctx context.Context, ids []string) ([]types.Image, error) {
+	input := &ec2.DescribeImagesInput{ImageIds: ids}
+	var output *ec2.DescribeImagesOutput
+	err := r.retry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		output, err = r.client.DescribeImages(ctx, input)
+		return err
+	})
+	err = awsutil.WrapError(err)
+
+	switch {
+	case err == nil:
+		return output.Images, nil
+	case !errors.Is(err, awsutil.ErrNotFound):
+		return nil, fmt.Errorf("failed to describe images: %w", err)
+	case len(ids) == 1:
+		return nil, nil
+	}
+
+	var images []types.Image
+	for _, id := range ids {
+		found, err := r.describe(ctx, []string{id})
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, found...)
+	}
+	return images, nil
+}
+
+// ResolveAMI returns the ID of the image query selects, the way the aws_ami
+// data source does: name_regex is matched against the images the other
+// arguments select, and several matches are an error unless most_recent is set
+func (r *ImageRepository) ResolveAMI(ctx context.Context, query terraform.AMIQuery) (string, error) {
+	var nameRegex *regexp.Regexp
+	if query.NameRegex != "" {
+		var err error
+		if nameRegex, err = regexp.Compile(query.NameRegex); err != nil {
+			return "", fmt.Errorf("invalid name_regex: %w", err)
+		}
+	}
+
+	input := &ec2.DescribeImagesInput{
+		Owners:          query.Owners,
+		ExecutableUsers: query.ExecutableUsers,
+	}
+	if query.IncludeDeprecated {
+		input.IncludeDeprecated = aws.Bool(true)
+	}
+	names := make([]string, 0, len(query.Filters))
+	for name := range query.Filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		input.Filters = append(input.Filters, types.Filter{Name: aws.String(name), Values: query.Filters[name]})
+	}
+
+	var matches []types.Image
+	paginator := ec2.NewDescribeImagesPaginator(r.client, input)
+	for paginator.HasMorePages() {
+		var output *ec2.DescribeImagesOutput
+		err := r.retry.Do(ctx, func(ctx context.Context) error {
+			var err error
+			output, err = paginator.NextPage(ctx)
+			return err
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to describe images: %w", awsutil.WrapError(err))
+		}
+		for _, image := range output.Images {
+			if nameRegex == nil || nameRegex.MatchString(aws.ToString(image.Name)) {
+				matches = append(matches, image)
+			}
+		}
+	}
+
+	switch {
+	case len(matches) == 0:
+		return "", errors.New("no image matches the data source")
+	case len(matches) > 1 && !query.MostRecent:
+		return "", fmt.Errorf("%d images match the data source; set most_recent or narrow the filters", len(matches))
+	}
+
+	// Creation dates are RFC 3339 timestamps in UTC, so they sort as strings
+	sort.SliceStable(matches, func(i, j int) bool {
+		return aws.ToString(matches[i].CreationDate) > aws.ToString(matches[j].CreationDate)
+	})
+	return aws.ToString(matches[0].ImageId), nil
+}
+
+// convertImage converts an EC2 image into the domain model
+func convertImage(image types.Image) *models.Image {
+	converted := &models.Image{
+		ID:              aws.ToString(image.ImageId),
+		Name:            aws.ToString(image.Name),
+		OwnerID:         aws.ToString(image.OwnerId),
+		Architecture:    string(image.Architecture),
+		CreationDate:    parseImageTime(image.CreationDate),
+		DeprecationTime: parseImageTime(image.DeprecationTime),
+	}
+	for _, tag := range image.Tags {
+		if tag.Key != nil && tag.Value != nil {
+			if converted.Tags == nil {
+				converted.Tags = make(map[string]string, len(image.Tags))
+			}
+			converted.Tags[*tag.Key] = *tag.Value
+		}
+	}
+	return converted
+}
+
+// parseImageTime parses a time DescribeImages returns as text, such as
+// 2024-05-01T10:00:00.000Z; nil when it is unset or malformed
+func parseImageTime(value *string) *time.Time {
+	if value == nil {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
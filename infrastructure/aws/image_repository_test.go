@@ -0,0 +1,139 @@
+package aws_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	awsrepo "driftdetector/infrastructure/aws"
+	"driftdetector/infrastructure/terraform"
+)
+
+// describeImagesFor matches DescribeImages calls for exactly ids
+func describeImagesFor(ids ...string) interface{} {
+	return mock.MatchedBy(func(in *ec2.DescribeImagesInput) bool {
+		return assert.ObjectsAreEqual(ids, in.ImageIds)
+	})
+}
+
+func TestImageRepository_GetByIDs(t *testing.T) {
+	t.Run("converts and caches images", func(t *testing.T) {
+		// Given
+		mockClient := new(MockEC2API)
+		mockClient.On("DescribeImages", mock.Anything, describeImagesFor("ami-1", "ami-2")).Return(&ec2.DescribeImagesOutput{
+			Images: []types.Image{{
+				ImageId:         aws.String("ami-1"),
+				Name:            aws.String("web-2024-05-01"),
+				OwnerId:         aws.String("123456789012"),
+				Architecture:    types.ArchitectureValuesArm64,
+				Tags:            []types.Tag{{Key: aws.String("app_version"), Value: aws.String("1.4.2")}},
+				CreationDate:    aws.String("2024-05-01T10:30:00.000Z"),
+				DeprecationTime: aws.String("2026-05-01T00:00:00.000Z"),
+			}},
+		}, nil).Once()
+		repo := awsrepo.NewImageRepository(mockClient)
+
+		// When
+		images, err := repo.GetByIDs(context.Background(), []string{"ami-2", "ami-1"})
+		require.NoError(t, err)
+		again, err := repo.GetByIDs(context.Background(), []string{"ami-1", "ami-2"})
+		require.NoError(t, err)
+
+		// Then
+		assert.Equal(t, []*models.Image{{
+			ID:              "ami-1",
+			Name:            "web-2024-05-01",
+			OwnerID:         "123456789012",
+			Architecture:    "arm64",
+			Tags:            map[string]string{"app_version": "1.4.2"},
+			CreationDate:    aws.Time(time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)),
+			DeprecationTime: aws.Time(time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)),
+		}}, images, "images that do not exist are left out")
+		assert.Equal(t, images, again)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("describes images one at a time when one is not found", func(t *testing.T) {
+		// Given
+		notFound := &smithy.GenericAPIError{Code: "InvalidAMIID.NotFound", Message: "The image id '[ami-gone]' does not exist"}
+		mockClient := new(MockEC2API)
+		mockClient.On("DescribeImages", mock.Anything, describeImagesFor("ami-1", "ami-gone")).Return(nil, notFound).Once()
+		mockClient.On("DescribeImages", mock.Anything, describeImagesFor("ami-1")).Return(&ec2.DescribeImagesOutput{
+			Images: []types.Image{{ImageId: aws.String("ami-1")}},
+		}, nil).Once()
+		mockClient.On("DescribeImages", mock.Anything, describeImagesFor("ami-gone")).Return(nil, notFound).Once()
+		repo := awsrepo.NewImageRepository(mockClient)
+
+		// When
+		images, err := repo.GetByIDs(context.Background(), []string{"ami-gone", "ami-1"})
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, images, 1)
+		assert.Equal(t, "ami-1", images[0].ID)
+		mockClient.AssertExpectations(t)
+	})
+}
+
+func TestImageRepository_ResolveAMI(t *testing.T) {
+	ubuntuImages := []types.Image{
+		{ImageId: aws.String("ami-old"), Name: aws.String("ubuntu-jammy-22.04-20240101"), CreationDate: aws.String("2024-01-01T10:00:00.000Z")},
+		{ImageId: aws.String("ami-new"), Name: aws.String("ubuntu-jammy-22.04-20240501"), CreationDate: aws.String("2024-05-01T10:00:00.000Z")},
+		{ImageId: aws.String("ami-pro"), Name: aws.String("ubuntu-pro-jammy-22.04-20240601"), CreationDate: aws.String("2024-06-01T10:00:00.000Z")},
+	}
+	query := terraform.AMIQuery{
+		Owners:     []string{"099720109477"},
+		MostRecent: true,
+		NameRegex:  `^ubuntu-jammy`,
+		Filters:    map[string][]string{"virtualization-type": {"hvm"}, "name": {"ubuntu-*"}},
+	}
+
+	t.Run("most recent image matching the query", func(t *testing.T) {
+		// Given
+		mockClient := new(MockEC2API)
+		mockClient.On("DescribeImages", mock.Anything, mock.MatchedBy(func(in *ec2.DescribeImagesInput) bool {
+			return assert.ObjectsAreEqual([]string{"099720109477"}, in.Owners) &&
+				assert.ObjectsAreEqual([]types.Filter{
+					{Name: aws.String("name"), Values: []string{"ubuntu-*"}},
+					{Name: aws.String("virtualization-type"), Values: []string{"hvm"}},
+				}, in.Filters)
+		})).Return(&ec2.DescribeImagesOutput{Images: ubuntuImages}, nil).Once()
+
+		// When
+		id, err := awsrepo.NewImageRepository(mockClient).ResolveAMI(context.Background(), query)
+
+		// Then
+		require.NoError(t, err)
+		assert.Equal(t, "ami-new", id, "name_regex leaves out the newer pro image")
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("several images without most_recent", func(t *testing.T) {
+		mockClient := new(MockEC2API)
+		mockClient.On("DescribeImages", mock.Anything, mock.Anything).Return(&ec2.DescribeImagesOutput{Images: ubuntuImages}, nil).Once()
+		ambiguous := query
+		ambiguous.MostRecent = false
+
+		_, err := awsrepo.NewImageRepository(mockClient).ResolveAMI(context.Background(), ambiguous)
+
+		assert.ErrorContains(t, err, "2 images match")
+	})
+
+	t.Run("no matching image", func(t *testing.T) {
+		mockClient := new(MockEC2API)
+		mockClient.On("DescribeImages", mock.Anything, mock.Anything).Return(&ec2.DescribeImagesOutput{}, nil).Once()
+
+		_, err := awsrepo.NewImageRepository(mockClient).ResolveAMI(context.Background(), query)
+
+		assert.ErrorContains(t, err, "no image matches")
+	})
+}
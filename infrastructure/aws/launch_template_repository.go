@@ -0,0 +1,183 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/awsutil"
+	"driftdetector/infrastructure/terraform"
+)
+
+// Ensure LaunchTemplateRepository can resolve the launch templates of Terraform state
+var _ terraform.LaunchTemplateResolver = (*LaunchTemplateRepository)(nil)
+
+// LaunchTemplateRepository reads launch template versions from AWS EC2
+type LaunchTemplateRepository struct {
+	client awsutil.EC2DescribeLaunchTemplateVersionsAPI
+	retry  awsutil.RetryOptions
+}
+
+// NewLaunchTemplateRepository creates a new LaunchTemplateRepository
+func NewLaunchTemplateRepository(client awsutil.EC2DescribeLaunchTemplateVersionsAPI) *LaunchTemplateRepository {
+	if client == nil {
+		panic("EC2 launch template client cannot be nil")
+	}
+	return &LaunchTemplateRepository{
+		client: client,
+		retry:  awsutil.DefaultRetryOptions(),
+	}
+}
+
+// ResolveLaunchTemplate returns the instance settings of the launch template
+// version spec refers to. An empty version is the template's default version.
+func (r *LaunchTemplateRepository) ResolveLaunchTemplate(ctx context.Context, spec models.LaunchTemplateSpecification) (*models.Instance, error) {
+	version := spec.Version
+	if version == "" {
+		version = models.LaunchTemplateVersionDefault
+	}
+
+	input := &ec2.DescribeLaunchTemplateVersionsInput{Versions: []string{version}}
+	if spec.ID != "" {
+		input.LaunchTemplateId = aws.String(spec.ID)
+	} else {
+		input.LaunchTemplateName = aws.String(spec.Name)
+	}
+
+	var output *ec2.DescribeLaunchTemplateVersionsOutput
+	err := r.retry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		output, err = r.client.DescribeLaunchTemplateVersions(ctx, input)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe launch template %s: %w", spec, err)
+	}
+	if len(output.LaunchTemplateVersions) == 0 || output.LaunchTemplateVersions[0].LaunchTemplateData == nil {
+		return nil, fmt.Errorf("launch template %s not found", spec)
+	}
+
+	return convertLaunchTemplateData(output.LaunchTemplateVersions[0].LaunchTemplateData), nil
+}
+
+// convertLaunchTemplateData converts the settings of a launch template
+// version into the instance they would configure
+func convertLaunchTemplateData(data *types.ResponseLaunchTemplateData) *models.Instance {
+	instance := &models.Instance{
+		AMI:                               aws.ToString(data.ImageId),
+		Type:                              string(data.InstanceType),
+		KeyName:                           aws.ToString(data.KeyName),
+		UserData:                          aws.ToString(data.UserData),
+		EBSOptimized:                      data.EbsOptimized,
+		DisableAPITermination:             data.DisableApiTermination,
+		InstanceInitiatedShutdownBehavior: string(data.InstanceInitiatedShutdownBehavior),
+	}
+
+	for _, id := range data.SecurityGroupIds {
+		instance.SecurityGroups = append(instance.SecurityGroups, models.SecurityGroup{GroupID: id})
+	}
+	for _, name := range data.SecurityGroups {
+		instance.SecurityGroups = append(instance.SecurityGroups, models.SecurityGroup{GroupName: name})
+	}
+
+	if profile := data.IamInstanceProfile; profile != nil {
+		instance.IAMInstanceProfile = aws.ToString(profile.Name)
+		if instance.IAMInstanceProfile == "" {
+			instance.IAMInstanceProfile = awsutil.InstanceProfileName(aws.ToString(profile.Arn))
+		}
+	}
+
+	if data.Monitoring != nil {
+		instance.Monitoring = data.Monitoring.Enabled
+	}
+
+	if placement := data.Placement; placement != nil {
+		instance.AvailabilityZone = aws.ToString(placement.AvailabilityZone)
+		instance.Tenancy = string(placement.Tenancy)
+		instance.HostID = aws.ToString(placement.HostId)
+		instance.PlacementGroup = aws.ToString(placement.GroupName)
+		instance.PartitionNumber = int(aws.ToInt32(placement.PartitionNumber))
+	}
+
+	if cpu := data.CpuOptions; cpu != nil {
+		instance.CPUCoreCount = int(aws.ToInt32(cpu.CoreCount))
+		instance.CPUThreadsPerCore = int(aws.ToInt32(cpu.ThreadsPerCore))
+	}
+
+	if data.HibernationOptions != nil && data.HibernationOptions.Configured != nil {
+		instance.Hibernation = &models.HibernationOptions{Configured: *data.HibernationOptions.Configured}
+	}
+
+	if data.EnclaveOptions != nil && data.EnclaveOptions.Enabled != nil {
+		instance.EnclaveOptions = &models.EnclaveOptions{Enabled: *data.EnclaveOptions.Enabled}
+	}
+
+	if opts := data.MetadataOptions; opts != nil {
+		instance.MetadataOptions = &models.MetadataOptions{
+			HTTPEndpoint:            string(opts.HttpEndpoint),
+			HTTPTokens:              string(opts.HttpTokens),
+			HTTPPutResponseHopLimit: int(aws.ToInt32(opts.HttpPutResponseHopLimit)),
+			InstanceMetadataTags:    string(opts.InstanceMetadataTags),
+		}
+	}
+
+	if market := data.InstanceMarketOptions; market != nil && market.MarketType != "" {
+		instance.InstanceLifecycle = string(market.MarketType)
+		instance.InstanceMarketOptions = &models.InstanceMarketOptions{MarketType: string(market.MarketType)}
+		if spot := market.SpotOptions; spot != nil {
+			instance.InstanceMarketOptions.MaxPrice = aws.ToString(spot.MaxPrice)
+			instance.InstanceMarketOptions.InstanceInterruptionBehavior = string(spot.InstanceInterruptionBehavior)
+		}
+	}
+
+	// Only tags for instances apply to the instance itself
+	for _, spec := range data.TagSpecifications {
+		if spec.ResourceType != types.ResourceTypeInstance {
+			continue
+		}
+		for _, tag := range spec.Tags {
+			if tag.Key != nil && tag.Value != nil {
+				instance.AddTag(*tag.Key, *tag.Value)
+			}
+		}
+	}
+
+	for _, mapping := range data.BlockDeviceMappings {
+		ebs := mapping.Ebs
+		if ebs == nil {
+			continue
+		}
+		deviceName := aws.ToString(mapping.DeviceName)
+
+		if awsutil.IsCommonRootDeviceName(deviceName) {
+			instance.RootVolumeSize = int(aws.ToInt32(ebs.VolumeSize))
+			instance.RootVolumeType = string(ebs.VolumeType)
+			instance.RootVolumeIops = int(aws.ToInt32(ebs.Iops))
+			instance.RootVolumeThroughput = int(aws.ToInt32(ebs.Throughput))
+			instance.RootVolumeEncrypted = ebs.Encrypted
+			instance.RootVolumeKMSKeyID = aws.ToString(ebs.KmsKeyId)
+			continue
+		}
+
+		instance.EBSBlockDevices = append(instance.EBSBlockDevices, models.EBSBlockDevice{
+			DeviceName:          deviceName,
+			VolumeSize:          int(aws.ToInt32(ebs.VolumeSize)),
+			VolumeType:          string(ebs.VolumeType),
+			Iops:                int(aws.ToInt32(ebs.Iops)),
+			Throughput:          int(aws.ToInt32(ebs.Throughput)),
+			Encrypted:           ebs.Encrypted,
+			KMSKeyID:            aws.ToString(ebs.KmsKeyId),
+			DeleteOnTermination: ebs.DeleteOnTermination,
+		})
+	}
+	sort.Slice(instance.EBSBlockDevices, func(i, j int) bool {
+		return instance.EBSBlockDevices[i].DeviceName < instance.EBSBlockDevices[j].DeviceName
+	})
+
+	return instance
+}
@@ -0,0 +1,82 @@
+package aws_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	awsrepo "driftdetector/infrastructure/aws"
+)
+
+func TestLaunchTemplateRepository_ResolveLaunchTemplate(t *testing.T) {
+	t.Run("converts the template data", func(t *testing.T) {
+		// Given
+		mockClient := new(MockEC2API)
+		mockClient.On("DescribeLaunchTemplateVersions", mock.Anything, mock.MatchedBy(func(in *ec2.DescribeLaunchTemplateVersionsInput) bool {
+			return aws.ToString(in.LaunchTemplateId) == "lt-1" && in.LaunchTemplateName == nil &&
+				len(in.Versions) == 1 && in.Versions[0] == "$Latest"
+		})).Return(&ec2.DescribeLaunchTemplateVersionsOutput{
+			LaunchTemplateVersions: []types.LaunchTemplateVersion{{
+				LaunchTemplateData: &types.ResponseLaunchTemplateData{
+					ImageId:            aws.String("ami-123"),
+					InstanceType:       types.InstanceTypeT3Micro,
+					SecurityGroupIds:   []string{"sg-1"},
+					IamInstanceProfile: &types.LaunchTemplateIamInstanceProfileSpecification{Arn: aws.String("arn:aws:iam::123456789012:instance-profile/web")},
+					TagSpecifications: []types.LaunchTemplateTagSpecification{
+						{ResourceType: types.ResourceTypeInstance, Tags: []types.Tag{{Key: aws.String("Name"), Value: aws.String("web")}}},
+						{ResourceType: types.ResourceTypeVolume, Tags: []types.Tag{{Key: aws.String("Backup"), Value: aws.String("daily")}}},
+					},
+					BlockDeviceMappings: []types.LaunchTemplateBlockDeviceMapping{
+						{DeviceName: aws.String("/dev/xvda"), Ebs: &types.LaunchTemplateEbsBlockDevice{VolumeSize: aws.Int32(20), VolumeType: types.VolumeTypeGp3}},
+						{DeviceName: aws.String("/dev/sdf"), Ebs: &types.LaunchTemplateEbsBlockDevice{VolumeSize: aws.Int32(50)}},
+					},
+				},
+			}},
+		}, nil)
+		repo := awsrepo.NewLaunchTemplateRepository(mockClient)
+
+		// When
+		instance, err := repo.ResolveLaunchTemplate(context.Background(), models.LaunchTemplateSpecification{ID: "lt-1", Name: "web", Version: "$Latest"})
+
+		// Then
+		require.NoError(t, err)
+		assert.Equal(t, "ami-123", instance.AMI)
+		assert.Equal(t, "t3.micro", instance.Type)
+		assert.Equal(t, []models.SecurityGroup{{GroupID: "sg-1"}}, instance.SecurityGroups)
+		assert.Equal(t, "web", instance.IAMInstanceProfile)
+		assert.Equal(t, map[string]string{"Name": "web"}, instance.Tags)
+		assert.Equal(t, 20, instance.RootVolumeSize)
+		assert.Equal(t, "gp3", instance.RootVolumeType)
+		assert.Equal(t, []models.EBSBlockDevice{{DeviceName: "/dev/sdf", VolumeSize: 50}}, instance.EBSBlockDevices)
+	})
+
+	t.Run("looks up by name at the default version", func(t *testing.T) {
+		mockClient := new(MockEC2API)
+		mockClient.On("DescribeLaunchTemplateVersions", mock.Anything, mock.MatchedBy(func(in *ec2.DescribeLaunchTemplateVersionsInput) bool {
+			return aws.ToString(in.LaunchTemplateName) == "web" && in.Versions[0] == "$Default"
+		})).Return(&ec2.DescribeLaunchTemplateVersionsOutput{}, nil)
+		repo := awsrepo.NewLaunchTemplateRepository(mockClient)
+
+		_, err := repo.ResolveLaunchTemplate(context.Background(), models.LaunchTemplateSpecification{Name: "web"})
+
+		assert.ErrorContains(t, err, "web@$Default not found")
+	})
+
+	t.Run("api error", func(t *testing.T) {
+		mockClient := new(MockEC2API)
+		mockClient.On("DescribeLaunchTemplateVersions", mock.Anything, mock.Anything).Return(nil, errors.New("boom"))
+		repo := awsrepo.NewLaunchTemplateRepository(mockClient)
+
+		_, err := repo.ResolveLaunchTemplate(context.Background(), models.LaunchTemplateSpecification{ID: "lt-1", Version: "3"})
+
+		assert.ErrorContains(t, err, "lt-1@3")
+	})
+}
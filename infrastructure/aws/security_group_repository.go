@@ -0,0 +1,127 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/infrastructure/awsutil"
+)
+
+// Ensure SecurityGroupRepository implements the SecurityGroupRepository interface
+var _ repositories.SecurityGroupRepository = (*SecurityGroupRepository)(nil)
+
+// SecurityGroupRepository reads security groups and their rules from AWS EC2
+type SecurityGroupRepository struct {
+	client awsutil.EC2DescribeSecurityGroupsAPI
+	retry  awsutil.RetryOptions
+}
+
+// SecurityGroupRepositoryOption configures a SecurityGroupRepository
+type SecurityGroupRepositoryOption func(*SecurityGroupRepository)
+
+// WithSecurityGroupRetryOptions sets the retry and timeout behaviour for
+// DescribeSecurityGroups calls
+func WithSecurityGroupRetryOptions(opts awsutil.RetryOptions) SecurityGroupRepositoryOption {
+	return func(r *SecurityGroupRepository) {
+		r.retry = opts
+	}
+}
+
+// NewSecurityGroupRepository creates a new SecurityGroupRepository
+func NewSecurityGroupRepository(client awsutil.EC2DescribeSecurityGroupsAPI, opts ...SecurityGroupRepositoryOption) *SecurityGroupRepository {
+	if client == nil {
+		panic("EC2 security group client cannot be nil")
+	}
+	repo := &SecurityGroupRepository{
+		client: client,
+		retry:  awsutil.DefaultRetryOptions(),
+	}
+	for _, opt := range opts {
+		opt(repo)
+	}
+	return repo
+}
+
+// GetByIDs retrieves the configuration and rules of the given security groups
+func (r *SecurityGroupRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.SecurityGroupConfig, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	input := &ec2.DescribeSecurityGroupsInput{GroupIds: ids}
+
+	var groups []*models.SecurityGroupConfig
+	paginator := ec2.NewDescribeSecurityGroupsPaginator(r.client, input)
+	for paginator.HasMorePages() {
+		var output *ec2.DescribeSecurityGroupsOutput
+		err := r.retry.Do(ctx, func(ctx context.Context) error {
+			var err error
+			output, err = paginator.NextPage(ctx)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe security groups: %w", err)
+		}
+
+		for _, sg := range output.SecurityGroups {
+			groups = append(groups, convertSecurityGroup(sg))
+		}
+	}
+
+	return groups, nil
+}
+
+// convertSecurityGroup converts an EC2 security group into the domain model
+func convertSecurityGroup(sg types.SecurityGroup) *models.SecurityGroupConfig {
+	config := &models.SecurityGroupConfig{
+		GroupID:     aws.ToString(sg.GroupId),
+		Name:        aws.ToString(sg.GroupName),
+		Description: aws.ToString(sg.Description),
+		VPCID:       aws.ToString(sg.VpcId),
+		Tags:        make(map[string]string, len(sg.Tags)),
+	}
+
+	for _, tag := range sg.Tags {
+		if tag.Key != nil && tag.Value != nil {
+			config.Tags[*tag.Key] = *tag.Value
+		}
+	}
+
+	for _, perm := range sg.IpPermissions {
+		config.Ingress = append(config.Ingress, convertPermission(perm))
+	}
+	for _, perm := range sg.IpPermissionsEgress {
+		config.Egress = append(config.Egress, convertPermission(perm))
+	}
+
+	return config
+}
+
+// convertPermission converts an EC2 IP permission into a rule
+func convertPermission(perm types.IpPermission) models.SecurityGroupRule {
+	rule := models.SecurityGroupRule{
+		Protocol: aws.ToString(perm.IpProtocol),
+		FromPort: int(aws.ToInt32(perm.FromPort)),
+		ToPort:   int(aws.ToInt32(perm.ToPort)),
+	}
+
+	for _, r := range perm.IpRanges {
+		rule.CIDRBlocks = append(rule.CIDRBlocks, aws.ToString(r.CidrIp))
+	}
+	for _, r := range perm.Ipv6Ranges {
+		rule.IPv6CIDRBlocks = append(rule.IPv6CIDRBlocks, aws.ToString(r.CidrIpv6))
+	}
+	for _, p := range perm.PrefixListIds {
+		rule.PrefixListIDs = append(rule.PrefixListIDs, aws.ToString(p.PrefixListId))
+	}
+	for _, pair := range perm.UserIdGroupPairs {
+		rule.SecurityGroups = append(rule.SecurityGroups, aws.ToString(pair.GroupId))
+	}
+
+	return rule
+}
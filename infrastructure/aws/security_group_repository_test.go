@@ -0,0 +1,85 @@
+package aws_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	awsrepo "driftdetector/infrastructure/aws"
+)
+
+func TestSecurityGroupRepository_GetByIDs(t *testing.T) {
+	t.Run("converts rules", func(t *testing.T) {
+		// Given
+		mockClient := new(MockEC2API)
+		mockClient.On("DescribeSecurityGroups", mock.Anything, mock.MatchedBy(func(in *ec2.DescribeSecurityGroupsInput) bool {
+			return len(in.GroupIds) == 1 && in.GroupIds[0] == "sg-123"
+		})).Return(&ec2.DescribeSecurityGroupsOutput{
+			SecurityGroups: []types.SecurityGroup{{
+				GroupId:     aws.String("sg-123"),
+				GroupName:   aws.String("web"),
+				Description: aws.String("web servers"),
+				Tags:        []types.Tag{{Key: aws.String("Name"), Value: aws.String("web")}},
+				IpPermissions: []types.IpPermission{{
+					IpProtocol:       aws.String("tcp"),
+					FromPort:         aws.Int32(443),
+					ToPort:           aws.Int32(443),
+					IpRanges:         []types.IpRange{{CidrIp: aws.String("0.0.0.0/0")}},
+					UserIdGroupPairs: []types.UserIdGroupPair{{GroupId: aws.String("sg-lb")}},
+				}},
+				IpPermissionsEgress: []types.IpPermission{{
+					IpProtocol: aws.String("-1"),
+					Ipv6Ranges: []types.Ipv6Range{{CidrIpv6: aws.String("::/0")}},
+				}},
+			}},
+		}, nil)
+		repo := awsrepo.NewSecurityGroupRepository(mockClient)
+
+		// When
+		groups, err := repo.GetByIDs(context.Background(), []string{"sg-123"})
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, groups, 1)
+		assert.Equal(t, "web servers", groups[0].Description)
+		assert.Equal(t, map[string]string{"Name": "web"}, groups[0].Tags)
+		assert.Equal(t, []models.SecurityGroupRule{{
+			Protocol:       "tcp",
+			FromPort:       443,
+			ToPort:         443,
+			CIDRBlocks:     []string{"0.0.0.0/0"},
+			SecurityGroups: []string{"sg-lb"},
+		}}, groups[0].Ingress)
+		assert.Equal(t, "all", groups[0].Egress[0].Key())
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("no IDs", func(t *testing.T) {
+		mockClient := new(MockEC2API)
+		repo := awsrepo.NewSecurityGroupRepository(mockClient)
+
+		groups, err := repo.GetByIDs(context.Background(), nil)
+
+		assert.NoError(t, err)
+		assert.Empty(t, groups)
+		mockClient.AssertNotCalled(t, "DescribeSecurityGroups", mock.Anything, mock.Anything)
+	})
+
+	t.Run("API error", func(t *testing.T) {
+		mockClient := new(MockEC2API)
+		mockClient.On("DescribeSecurityGroups", mock.Anything, mock.Anything).Return(nil, errors.New("boom"))
+		repo := awsrepo.NewSecurityGroupRepository(mockClient)
+
+		_, err := repo.GetByIDs(context.Background(), []string{"sg-123"})
+
+		assert.Error(t, err)
+	})
+}
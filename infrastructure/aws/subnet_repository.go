@@ -0,0 +1,135 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/infrastructure/awsutil"
+)
+
+// Ensure SubnetRepository implements the SubnetRepository interface
+var _ repositories.SubnetRepository = (*SubnetRepository)(nil)
+
+// SubnetRepository reads VPC subnets from AWS EC2. Subnets are cached,
+// including those that do not exist, so each ID is described at most once.
+type SubnetRepository struct {
+	client awsutil.EC2DescribeSubnetsAPI
+	retry  awsutil.RetryOptions
+
+	mu    sync.Mutex
+	cache map[string]*models.Subnet
+}
+
+// SubnetRepositoryOption configures a SubnetRepository
+type SubnetRepositoryOption func(*SubnetRepository)
+
+// WithSubnetRetryOptions sets the retry and timeout behaviour for
+// DescribeSubnets calls
+func WithSubnetRetryOptions(opts awsutil.RetryOptions) SubnetRepositoryOption {
+	return func(r *SubnetRepository) {
+		r.retry = opts
+	}
+}
+
+// NewSubnetRepository creates a new SubnetRepository
+func NewSubnetRepository(client awsutil.EC2DescribeSubnetsAPI, opts ...SubnetRepositoryOption) *SubnetRepository {
+	if client == nil {
+		panic("EC2 subnet client cannot be nil")
+	}
+	repo := &SubnetRepository{
+		client: client,
+		retry:  awsutil.DefaultRetryOptions(),
+		cache:  make(map[string]*models.Subnet),
+	}
+	for _, opt := range opts {
+		opt(repo)
+	}
+	return repo
+}
+
+// GetByIDs retrieves the given subnets, describing only those not looked up
+// before. Subnets that no longer exist are left out.
+func (r *SubnetRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.Subnet, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var missing []string
+	for _, id := range ids {
+		if _, ok := r.cache[id]; !ok && id != "" {
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		described, err := r.describe(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range missing {
+			r.cache[id] = nil
+		}
+		for _, subnet := range described {
+			converted := convertSubnet(subnet)
+			r.cache[converted.ID] = converted
+		}
+	}
+
+	var subnets []*models.Subnet
+	for _, id := range ids {
+		if subnet := r.cache[id]; subnet != nil {
+			subnets = append(subnets, subnet)
+		}
+	}
+	return subnets, nil
+}
+
+// describe describes the subnets with ids. AWS fails the whole call when one
+// of them does not exist, so the subnets are then described one at a time.
+func (r *SubnetRepository) describe(ctx context.Context, ids []string) ([]types.Subnet, error) {
+	input := &ec2.DescribeSubnetsInput{SubnetIds: ids}
+	var output *ec2.DescribeSubnetsOutput
+	err := r.retry.Do(ctx, func(ctx context.Context) error {
+		var err error
+		output, err = r.client.DescribeSubnets(ctx, input)
+		return err
+	})
+	err = awsutil.WrapError(err)
+
+	switch {
+	case err == nil:
+		return output.Subnets, nil
+	case !errors.Is(err, awsutil.ErrNotFound):
+		return nil, fmt.Errorf("failed to describe subnets: %w", err)
+	case len(ids) == 1:
+		return nil, nil
+	}
+
+	var subnets []types.Subnet
+	for _, id := range ids {
+		found, err := r.describe(ctx, []string{id})
+		if err != nil {
+			return nil, err
+		}
+		subnets = append(subnets, found...)
+	}
+	return subnets, nil
+}
+
+// convertSubnet converts an EC2 subnet into the domain model
+func convertSubnet(subnet types.Subnet) *models.Subnet {
+	return &models.Subnet{
+		ID:               aws.ToString(subnet.SubnetId),
+		AvailabilityZone: aws.ToString(subnet.AvailabilityZone),
+		VPCID:            aws.ToString(subnet.VpcId),
+	}
+}
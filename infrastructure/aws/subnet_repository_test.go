@@ -0,0 +1,81 @@
+package aws_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	awsrepo "driftdetector/infrastructure/aws"
+)
+
+// describeSubnetsFor matches DescribeSubnets calls for exactly ids
+func describeSubnetsFor(ids ...string) interface{} {
+	return mock.MatchedBy(func(in *ec2.DescribeSubnetsInput) bool {
+		return assert.ObjectsAreEqual(ids, in.SubnetIds)
+	})
+}
+
+func TestSubnetRepository_GetByIDs(t *testing.T) {
+	t.Run("converts and caches subnets", func(t *testing.T) {
+		// Given
+		mockClient := new(MockEC2API)
+		mockClient.On("DescribeSubnets", mock.Anything, describeSubnetsFor("subnet-1", "subnet-2")).Return(&ec2.DescribeSubnetsOutput{
+			Subnets: []types.Subnet{{
+				SubnetId:         aws.String("subnet-1"),
+				AvailabilityZone: aws.String("us-east-1b"),
+				VpcId:            aws.String("vpc-1"),
+			}},
+		}, nil).Once()
+		repo := awsrepo.NewSubnetRepository(mockClient)
+
+		// When
+		subnets, err := repo.GetByIDs(context.Background(), []string{"subnet-2", "subnet-1"})
+		require.NoError(t, err)
+		again, err := repo.GetByIDs(context.Background(), []string{"subnet-1"})
+		require.NoError(t, err)
+
+		// Then
+		assert.Equal(t, []*models.Subnet{{ID: "subnet-1", AvailabilityZone: "us-east-1b", VPCID: "vpc-1"}}, subnets,
+			"subnets that do not exist are left out")
+		assert.Equal(t, subnets, again)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("describes subnets one at a time when one is not found", func(t *testing.T) {
+		// Given
+		notFound := &smithy.GenericAPIError{Code: "InvalidSubnetID.NotFound", Message: "The subnet ID 'subnet-gone' does not exist"}
+		mockClient := new(MockEC2API)
+		mockClient.On("DescribeSubnets", mock.Anything, describeSubnetsFor("subnet-1", "subnet-gone")).Return(nil, notFound).Once()
+		mockClient.On("DescribeSubnets", mock.Anything, describeSubnetsFor("subnet-1")).Return(&ec2.DescribeSubnetsOutput{
+			Subnets: []types.Subnet{{SubnetId: aws.String("subnet-1"), AvailabilityZone: aws.String("us-east-1a")}},
+		}, nil).Once()
+		mockClient.On("DescribeSubnets", mock.Anything, describeSubnetsFor("subnet-gone")).Return(nil, notFound).Once()
+		repo := awsrepo.NewSubnetRepository(mockClient)
+
+		// When
+		subnets, err := repo.GetByIDs(context.Background(), []string{"subnet-gone", "subnet-1"})
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, subnets, 1)
+		assert.Equal(t, "subnet-1", subnets[0].ID)
+		mockClient.AssertExpectations(t)
+	})
+
+	t.Run("access denied is an error", func(t *testing.T) {
+		mockClient := new(MockEC2API)
+		mockClient.On("DescribeSubnets", mock.Anything, mock.Anything).Return(nil, &smithy.GenericAPIError{Code: "UnauthorizedOperation"}).Once()
+
+		_, err := awsrepo.NewSubnetRepository(mockClient).GetByIDs(context.Background(), []string{"subnet-1"})
+
+		assert.ErrorContains(t, err, "failed to describe subnets")
+	})
+}
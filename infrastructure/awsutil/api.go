@@ -0,0 +1,90 @@
+package awsutil
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// EC2DescribeInstancesAPI is the subset of the EC2 client used to read instances
+type EC2DescribeInstancesAPI interface {
+	DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)
+}
+
+// EC2DescribeVolumesAPI is the subset of the EC2 client used to read EBS volumes
+type EC2DescribeVolumesAPI interface {
+	DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error)
+}
+
+// EC2DescribeSecurityGroupsAPI is the subset of the EC2 client used to read security groups
+type EC2DescribeSecurityGroupsAPI interface {
+	DescribeSecurityGroups(ctx context.Context, params *ec2.DescribeSecurityGroupsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSecurityGroupsOutput, error)
+}
+
+// EC2DescribeInstanceAttributeAPI is the subset of the EC2 client used to read
+// attributes DescribeInstances does not return, such as user data
+type EC2DescribeInstanceAttributeAPI interface {
+	DescribeInstanceAttribute(ctx context.Context, params *ec2.DescribeInstanceAttributeInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceAttributeOutput, error)
+}
+
+// EC2DescribeLaunchTemplateVersionsAPI is the subset of the EC2 client used to
+// resolve the launch template versions instances are created from
+type EC2DescribeLaunchTemplateVersionsAPI interface {
+	DescribeLaunchTemplateVersions(ctx context.Context, params *ec2.DescribeLaunchTemplateVersionsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeLaunchTemplateVersionsOutput, error)
+}
+
+// EC2DescribeIamInstanceProfileAssociationsAPI is the subset of the EC2 client
+// used to read the instance profile currently associated with an instance
+type EC2DescribeIamInstanceProfileAssociationsAPI interface {
+	DescribeIamInstanceProfileAssociations(ctx context.Context, params *ec2.DescribeIamInstanceProfileAssociationsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeIamInstanceProfileAssociationsOutput, error)
+}
+
+// EC2DescribeImagesAPI is the subset of the EC2 client used to read the AMIs
+// instances are launched from
+type EC2DescribeImagesAPI interface {
+	DescribeImages(ctx context.Context, params *ec2.DescribeImagesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeImagesOutput, error)
+}
+
+// EC2DescribeSubnetsAPI is the subset of the EC2 client used to read the
+// subnets instances are launched into
+type EC2DescribeSubnetsAPI interface {
+	DescribeSubnets(ctx context.Context, params *ec2.DescribeSubnetsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSubnetsOutput, error)
+}
+
+// EC2DescribeAddressesAPI is the subset of the EC2 client used to read
+// Elastic IP addresses and their associations
+type EC2DescribeAddressesAPI interface {
+	DescribeAddresses(ctx context.Context, params *ec2.DescribeAddressesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeAddressesOutput, error)
+}
+
+// EC2DescribeSpotInstanceRequestsAPI is the subset of the EC2 client used to
+// read the market options of spot instances
+type EC2DescribeSpotInstanceRequestsAPI interface {
+	DescribeSpotInstanceRequests(ctx context.Context, params *ec2.DescribeSpotInstanceRequestsInput, optFns ...func(*ec2.Options)) (*ec2.DescribeSpotInstanceRequestsOutput, error)
+}
+
+// EC2API defines every EC2 operation the drift detector needs.
+// It is the single interface definition shared by all AWS layers.
+type EC2API interface {
+	EC2DescribeInstancesAPI
+	EC2DescribeVolumesAPI
+	EC2DescribeSecurityGroupsAPI
+	EC2DescribeInstanceAttributeAPI
+	EC2DescribeLaunchTemplateVersionsAPI
+	EC2DescribeIamInstanceProfileAssociationsAPI
+	EC2DescribeImagesAPI
+	EC2DescribeSubnetsAPI
+	EC2DescribeAddressesAPI
+	EC2DescribeSpotInstanceRequestsAPI
+}
+
+// S3GetObjectAPI is the subset of the S3 client used to read remote Terraform state
+type S3GetObjectAPI interface {
+	GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error)
+}
+
+// S3PutObjectAPI is the subset of the S3 client used to upload drift reports
+type S3PutObjectAPI interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
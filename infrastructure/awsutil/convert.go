@@ -0,0 +1,545 @@
+package awsutil
+
+import (
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	domain "driftdetector/domain/models"
+)
+
+// Field identifies a model attribute populated from EC2 data
+type Field string
+
+const (
+	FieldInstanceID           Field = "instance_id"
+	FieldInstanceType         Field = "instance_type"
+	FieldAMI                  Field = "ami"
+	FieldKeyName              Field = "key_name"
+	FieldTags                 Field = "tags"
+	FieldVPCID                Field = "vpc_id"
+	FieldSubnetID             Field = "subnet_id"
+	FieldPrivateIPAddress     Field = "private_ip_address"
+	FieldPublicIPAddress      Field = "public_ip_address"
+	FieldPrivateDNSName       Field = "private_dns_name"
+	FieldPublicDNSName        Field = "public_dns_name"
+	FieldSecurityGroups       Field = "security_groups"
+	FieldNetworkInterfaces    Field = "network_interfaces"
+	FieldRootVolumeSize       Field = "root_volume_size"
+	FieldRootVolumeType       Field = "root_volume_type"
+	FieldRootVolumeIops       Field = "root_volume_iops"
+	FieldRootVolumeEncrypted  Field = "root_volume_encrypted"
+	FieldRootVolumeThroughput Field = "root_volume_throughput"
+	FieldRootVolumeKMSKeyID   Field = "root_volume_kms_key_id"
+	FieldEBSBlockDevices      Field = "ebs_block_devices"
+	FieldMetadataOptions      Field = "metadata_options"
+	FieldMonitoring           Field = "monitoring"
+	FieldEBSOptimized         Field = "ebs_optimized"
+	FieldIAMInstanceProfile   Field = "iam_instance_profile"
+	FieldAvailabilityZone     Field = "availability_zone"
+	FieldTenancy              Field = "tenancy"
+	FieldHostID               Field = "host_id"
+	FieldPlacementGroup       Field = "placement_group"
+	FieldPartitionNumber      Field = "partition_number"
+	FieldCPUCoreCount         Field = "cpu_core_count"
+	FieldCPUThreadsPerCore    Field = "cpu_threads_per_core"
+	FieldHibernation          Field = "hibernation"
+	FieldEnclaveOptions       Field = "enclave_options"
+	FieldState                Field = "state"
+	FieldLaunchTime           Field = "launch_time"
+	FieldInstanceLifecycle    Field = "instance_lifecycle"
+	FieldSpotRequestID        Field = "spot_instance_request_id"
+
+	// FieldDisableAPITermination and FieldShutdownBehavior are not part of
+	// DescribeInstances output and are read with DescribeInstanceAttribute
+	FieldDisableAPITermination Field = "disable_api_termination"
+	FieldShutdownBehavior      Field = "instance_initiated_shutdown_behavior"
+
+	// FieldInstanceMarketOptions is not part of DescribeInstances output and
+	// is read from the instance's spot request
+	FieldInstanceMarketOptions Field = "instance_market_options"
+)
+
+// MarketOptionsRef is the value passed for FieldInstanceMarketOptions
+type MarketOptionsRef struct {
+	MarketType                   string
+	MaxPrice                     string
+	InstanceInterruptionBehavior string
+}
+
+// MetadataOptionsRef is the value passed for FieldMetadataOptions
+type MetadataOptionsRef struct {
+	HTTPEndpoint            string
+	HTTPTokens              string
+	HTTPPutResponseHopLimit int
+	InstanceMetadataTags    string
+}
+
+// EBSBlockDeviceRef is one element of the value passed for FieldEBSBlockDevices
+type EBSBlockDeviceRef struct {
+	DeviceName          string
+	VolumeSize          int
+	VolumeType          string
+	Iops                int
+	Throughput          int
+	Encrypted           *bool
+	KMSKeyID            string
+	DeleteOnTermination *bool
+	Tags                map[string]string
+}
+
+// SecurityGroupRef is the value passed for FieldSecurityGroups
+type SecurityGroupRef struct {
+	GroupID   string
+	GroupName string
+}
+
+// NetworkInterfaceRef is one element of the value passed for FieldNetworkInterfaces
+type NetworkInterfaceRef struct {
+	DeviceIndex         int
+	NetworkInterfaceID  string
+	DeleteOnTermination *bool
+	PrivateIPAddresses  []string
+	Groups              []SecurityGroupRef
+}
+
+// InstanceSetter receives converted values for a target model.
+// Values are string, int, bool, map[string]string, []SecurityGroupRef,
+// []EBSBlockDeviceRef, []NetworkInterfaceRef, MetadataOptionsRef or
+// MarketOptionsRef depending on the field. Set returns false if the model has no such field.
+type InstanceSetter interface {
+	Set(field Field, value interface{}) bool
+}
+
+// instanceMapping extracts one field from an EC2 instance
+type instanceMapping struct {
+	field   Field
+	extract func(types.Instance) (interface{}, bool)
+}
+
+// volumeMapping extracts one field from an EBS root volume
+type volumeMapping struct {
+	field   Field
+	extract func(types.Volume) (interface{}, bool)
+}
+
+// attributeMapping extracts one field from DescribeInstanceAttribute output
+type attributeMapping struct {
+	field     Field
+	attribute types.InstanceAttributeName
+	extract   func(*ec2.DescribeInstanceAttributeOutput) (interface{}, bool)
+}
+
+// stringValue returns an extractor for an optional string pointer
+func stringValue(get func(types.Instance) *string) func(types.Instance) (interface{}, bool) {
+	return func(i types.Instance) (interface{}, bool) {
+		v := get(i)
+		if v == nil {
+			return nil, false
+		}
+		return *v, true
+	}
+}
+
+// stringVolumeValue returns a volume extractor for an optional string pointer
+func stringVolumeValue(get func(types.Volume) *string) func(types.Volume) (interface{}, bool) {
+	return func(v types.Volume) (interface{}, bool) {
+		s := get(v)
+		if s == nil || *s == "" {
+			return nil, false
+		}
+		return *s, true
+	}
+}
+
+// instanceMappings is the conversion registry for DescribeInstances data
+var instanceMappings = []instanceMapping{
+	{FieldInstanceID, stringValue(func(i types.Instance) *string { return i.InstanceId })},
+	{FieldInstanceType, func(i types.Instance) (interface{}, bool) {
+		return string(i.InstanceType), i.InstanceType != ""
+	}},
+	{FieldAMI, stringValue(func(i types.Instance) *string { return i.ImageId })},
+	{FieldKeyName, stringValue(func(i types.Instance) *string { return i.KeyName })},
+	{FieldTags, func(i types.Instance) (interface{}, bool) {
+		tags := make(map[string]string, len(i.Tags))
+		for _, tag := range i.Tags {
+			if tag.Key != nil && tag.Value != nil {
+				tags[*tag.Key] = *tag.Value
+			}
+		}
+		return tags, true
+	}},
+	{FieldVPCID, stringValue(func(i types.Instance) *string { return i.VpcId })},
+	{FieldSubnetID, stringValue(func(i types.Instance) *string { return i.SubnetId })},
+	{FieldPrivateIPAddress, stringValue(func(i types.Instance) *string { return i.PrivateIpAddress })},
+	{FieldPublicIPAddress, stringValue(func(i types.Instance) *string { return i.PublicIpAddress })},
+	{FieldPrivateDNSName, stringValue(func(i types.Instance) *string { return i.PrivateDnsName })},
+	{FieldPublicDNSName, stringValue(func(i types.Instance) *string { return i.PublicDnsName })},
+	{FieldSecurityGroups, func(i types.Instance) (interface{}, bool) {
+		if len(i.SecurityGroups) == 0 {
+			return nil, false
+		}
+		groups := make([]SecurityGroupRef, 0, len(i.SecurityGroups))
+		for _, sg := range i.SecurityGroups {
+			if sg.GroupId != nil {
+				groups = append(groups, SecurityGroupRef{
+					GroupID:   *sg.GroupId,
+					GroupName: aws.ToString(sg.GroupName),
+				})
+			}
+		}
+		return groups, true
+	}},
+	{FieldNetworkInterfaces, func(i types.Instance) (interface{}, bool) {
+		interfaces := convertNetworkInterfaces(i.NetworkInterfaces)
+		return interfaces, len(interfaces) > 0
+	}},
+	{FieldMetadataOptions, func(i types.Instance) (interface{}, bool) {
+		if i.MetadataOptions == nil {
+			return nil, false
+		}
+		return MetadataOptionsRef{
+			HTTPEndpoint:            string(i.MetadataOptions.HttpEndpoint),
+			HTTPTokens:              string(i.MetadataOptions.HttpTokens),
+			HTTPPutResponseHopLimit: int(aws.ToInt32(i.MetadataOptions.HttpPutResponseHopLimit)),
+			InstanceMetadataTags:    string(i.MetadataOptions.InstanceMetadataTags),
+		}, true
+	}},
+	{FieldMonitoring, func(i types.Instance) (interface{}, bool) {
+		if i.Monitoring == nil || i.Monitoring.State == "" {
+			return nil, false
+		}
+		state := i.Monitoring.State
+		return state == types.MonitoringStateEnabled || state == types.MonitoringStatePending, true
+	}},
+	{FieldEBSOptimized, func(i types.Instance) (interface{}, bool) {
+		if i.EbsOptimized == nil {
+			return nil, false
+		}
+		return *i.EbsOptimized, true
+	}},
+	{FieldIAMInstanceProfile, func(i types.Instance) (interface{}, bool) {
+		if i.IamInstanceProfile == nil || i.IamInstanceProfile.Arn == nil {
+			return nil, false
+		}
+		return InstanceProfileName(*i.IamInstanceProfile.Arn), true
+	}},
+	{FieldAvailabilityZone, func(i types.Instance) (interface{}, bool) {
+		if i.Placement == nil || i.Placement.AvailabilityZone == nil {
+			return nil, false
+		}
+		return *i.Placement.AvailabilityZone, true
+	}},
+	{FieldTenancy, func(i types.Instance) (interface{}, bool) {
+		if i.Placement == nil || i.Placement.Tenancy == "" {
+			return nil, false
+		}
+		return string(i.Placement.Tenancy), true
+	}},
+	{FieldHostID, func(i types.Instance) (interface{}, bool) {
+		if i.Placement == nil || aws.ToString(i.Placement.HostId) == "" {
+			return nil, false
+		}
+		return *i.Placement.HostId, true
+	}},
+	{FieldPlacementGroup, func(i types.Instance) (interface{}, bool) {
+		if i.Placement == nil || aws.ToString(i.Placement.GroupName) == "" {
+			return nil, false
+		}
+		return *i.Placement.GroupName, true
+	}},
+	{FieldPartitionNumber, func(i types.Instance) (interface{}, bool) {
+		if i.Placement == nil || aws.ToInt32(i.Placement.PartitionNumber) == 0 {
+			return nil, false
+		}
+		return int(*i.Placement.PartitionNumber), true
+	}},
+	{FieldCPUCoreCount, func(i types.Instance) (interface{}, bool) {
+		if i.CpuOptions == nil || i.CpuOptions.CoreCount == nil {
+			return nil, false
+		}
+		return int(*i.CpuOptions.CoreCount), true
+	}},
+	{FieldCPUThreadsPerCore, func(i types.Instance) (interface{}, bool) {
+		if i.CpuOptions == nil || i.CpuOptions.ThreadsPerCore == nil {
+			return nil, false
+		}
+		return int(*i.CpuOptions.ThreadsPerCore), true
+	}},
+	{FieldHibernation, func(i types.Instance) (interface{}, bool) {
+		if i.HibernationOptions == nil || i.HibernationOptions.Configured == nil {
+			return nil, false
+		}
+		return *i.HibernationOptions.Configured, true
+	}},
+	{FieldEnclaveOptions, func(i types.Instance) (interface{}, bool) {
+		if i.EnclaveOptions == nil || i.EnclaveOptions.Enabled == nil {
+			return nil, false
+		}
+		return *i.EnclaveOptions.Enabled, true
+	}},
+	{FieldState, func(i types.Instance) (interface{}, bool) {
+		if i.State == nil || i.State.Name == "" {
+			return nil, false
+		}
+		return string(i.State.Name), true
+	}},
+	{FieldLaunchTime, func(i types.Instance) (interface{}, bool) {
+		if i.LaunchTime == nil {
+			return nil, false
+		}
+		return *i.LaunchTime, true
+	}},
+	{FieldInstanceLifecycle, func(i types.Instance) (interface{}, bool) {
+		return string(i.InstanceLifecycle), i.InstanceLifecycle != ""
+	}},
+	{FieldSpotRequestID, stringValue(func(i types.Instance) *string { return i.SpotInstanceRequestId })},
+}
+
+// volumeMappings is the conversion registry for DescribeVolumes data
+var volumeMappings = []volumeMapping{
+	{FieldRootVolumeSize, func(v types.Volume) (interface{}, bool) {
+		if v.Size == nil {
+			return nil, false
+		}
+		return int(*v.Size), true
+	}},
+	{FieldRootVolumeType, func(v types.Volume) (interface{}, bool) {
+		return string(v.VolumeType), v.VolumeType != ""
+	}},
+	{FieldRootVolumeIops, func(v types.Volume) (interface{}, bool) {
+		if v.Iops == nil {
+			return nil, false
+		}
+		return int(*v.Iops), true
+	}},
+	{FieldRootVolumeEncrypted, func(v types.Volume) (interface{}, bool) {
+		if v.Encrypted == nil {
+			return nil, false
+		}
+		return *v.Encrypted, true
+	}},
+	{FieldRootVolumeThroughput, func(v types.Volume) (interface{}, bool) {
+		if v.Throughput == nil {
+			return nil, false
+		}
+		return int(*v.Throughput), true
+	}},
+	{FieldRootVolumeKMSKeyID, stringVolumeValue(func(v types.Volume) *string { return v.KmsKeyId })},
+}
+
+// attributeMappings is the conversion registry for DescribeInstanceAttribute
+// data; each entry costs one extra API call per instance
+var attributeMappings = []attributeMapping{
+	{FieldDisableAPITermination, types.InstanceAttributeNameDisableApiTermination, func(o *ec2.DescribeInstanceAttributeOutput) (interface{}, bool) {
+		if o.DisableApiTermination == nil || o.DisableApiTermination.Value == nil {
+			return nil, false
+		}
+		return *o.DisableApiTermination.Value, true
+	}},
+	{FieldShutdownBehavior, types.InstanceAttributeNameInstanceInitiatedShutdownBehavior, func(o *ec2.DescribeInstanceAttributeOutput) (interface{}, bool) {
+		if o.InstanceInitiatedShutdownBehavior == nil || o.InstanceInitiatedShutdownBehavior.Value == nil {
+			return nil, false
+		}
+		return *o.InstanceInitiatedShutdownBehavior.Value, true
+	}},
+}
+
+// MappedFields returns every field the conversion registry can populate
+func MappedFields() []Field {
+	fields := make([]Field, 0, len(instanceMappings)+len(volumeMappings)+len(attributeMappings)+1)
+	for _, m := range instanceMappings {
+		fields = append(fields, m.field)
+	}
+	for _, m := range volumeMappings {
+		fields = append(fields, m.field)
+	}
+	for _, m := range attributeMappings {
+		fields = append(fields, m.field)
+	}
+	return append(fields, FieldEBSBlockDevices, FieldInstanceMarketOptions)
+}
+
+// InstanceAttributes returns the DescribeInstanceAttribute attributes needed
+// to populate fields that DescribeInstances does not return
+func InstanceAttributes() []types.InstanceAttributeName {
+	attrs := make([]types.InstanceAttributeName, 0, len(attributeMappings))
+	for _, m := range attributeMappings {
+		attrs = append(attrs, m.attribute)
+	}
+	return attrs
+}
+
+// ConvertInstance copies every mapped attribute of an EC2 instance into setter
+func ConvertInstance(instance types.Instance, setter InstanceSetter) {
+	for _, m := range instanceMappings {
+		if v, ok := m.extract(instance); ok {
+			setter.Set(m.field, v)
+		}
+	}
+}
+
+// ConvertRootVolume copies every mapped attribute of a root EBS volume into setter
+func ConvertRootVolume(volume types.Volume, setter InstanceSetter) {
+	for _, m := range volumeMappings {
+		if v, ok := m.extract(volume); ok {
+			setter.Set(m.field, v)
+		}
+	}
+}
+
+// ConvertInstanceAttribute copies the fields read from one
+// DescribeInstanceAttribute response into setter
+func ConvertInstanceAttribute(attribute types.InstanceAttributeName, output *ec2.DescribeInstanceAttributeOutput, setter InstanceSetter) {
+	if output == nil {
+		return
+	}
+	for _, m := range attributeMappings {
+		if m.attribute != attribute {
+			continue
+		}
+		if v, ok := m.extract(output); ok {
+			setter.Set(m.field, v)
+		}
+	}
+}
+
+// ConvertSpotInstanceRequest copies the market options of the spot request
+// an instance was launched by into setter
+func ConvertSpotInstanceRequest(request types.SpotInstanceRequest, setter InstanceSetter) {
+	setter.Set(FieldInstanceMarketOptions, MarketOptionsRef{
+		MarketType:                   string(types.MarketTypeSpot),
+		MaxPrice:                     aws.ToString(request.SpotPrice),
+		InstanceInterruptionBehavior: string(request.InstanceInterruptionBehavior),
+	})
+}
+
+// InstanceProfileName returns the name of an IAM instance profile from its
+// ARN, which is how Terraform refers to it
+func InstanceProfileName(arn string) string {
+	return domain.InstanceProfileName(arn)
+}
+
+// ConvertBlockDevices copies the non-root EBS volumes of an instance into
+// setter, ordered by device name. volumes holds DescribeVolumes results keyed
+// by volume ID; attachments without details, and volumes being detached,
+// are skipped. DeleteOnTermination is a setting of the attachment, so it is
+// read from the mapping rather than the volume.
+func ConvertBlockDevices(instance types.Instance, volumes map[string]types.Volume, setter InstanceSetter) {
+	rootID, _ := RootVolumeID(instance)
+
+	var devices []EBSBlockDeviceRef
+	for _, bd := range instance.BlockDeviceMappings {
+		if bd.Ebs == nil || bd.Ebs.VolumeId == nil || *bd.Ebs.VolumeId == rootID {
+			continue
+		}
+		if status := bd.Ebs.Status; status == types.AttachmentStatusDetaching || status == types.AttachmentStatusDetached {
+			continue
+		}
+		volume, ok := volumes[*bd.Ebs.VolumeId]
+		if !ok {
+			continue
+		}
+
+		device := EBSBlockDeviceRef{
+			DeviceName:          aws.ToString(bd.DeviceName),
+			VolumeSize:          int(aws.ToInt32(volume.Size)),
+			VolumeType:          string(volume.VolumeType),
+			Iops:                int(aws.ToInt32(volume.Iops)),
+			Throughput:          int(aws.ToInt32(volume.Throughput)),
+			Encrypted:           volume.Encrypted,
+			KMSKeyID:            aws.ToString(volume.KmsKeyId),
+			DeleteOnTermination: bd.Ebs.DeleteOnTermination,
+		}
+		for _, tag := range volume.Tags {
+			if tag.Key == nil || tag.Value == nil {
+				continue
+			}
+			if device.Tags == nil {
+				device.Tags = make(map[string]string, len(volume.Tags))
+			}
+			device.Tags[*tag.Key] = *tag.Value
+		}
+		devices = append(devices, device)
+	}
+
+	if len(devices) == 0 {
+		return
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].DeviceName < devices[j].DeviceName })
+	setter.Set(FieldEBSBlockDevices, devices)
+}
+
+// convertNetworkInterfaces converts the interfaces attached to an instance,
+// ordered by device index. Interfaces being detached are skipped.
+func convertNetworkInterfaces(nis []types.InstanceNetworkInterface) []NetworkInterfaceRef {
+	var interfaces []NetworkInterfaceRef
+	for _, ni := range nis {
+		if ni.Attachment == nil || ni.Attachment.DeviceIndex == nil {
+			continue
+		}
+		if status := ni.Attachment.Status; status == types.AttachmentStatusDetaching || status == types.AttachmentStatusDetached {
+			continue
+		}
+
+		ref := NetworkInterfaceRef{
+			DeviceIndex:         int(*ni.Attachment.DeviceIndex),
+			NetworkInterfaceID:  aws.ToString(ni.NetworkInterfaceId),
+			DeleteOnTermination: ni.Attachment.DeleteOnTermination,
+		}
+		for _, ip := range ni.PrivateIpAddresses {
+			if ip.PrivateIpAddress != nil {
+				ref.PrivateIPAddresses = append(ref.PrivateIPAddresses, *ip.PrivateIpAddress)
+			}
+		}
+		sort.Strings(ref.PrivateIPAddresses)
+		for _, sg := range ni.Groups {
+			if sg.GroupId != nil {
+				ref.Groups = append(ref.Groups, SecurityGroupRef{GroupID: *sg.GroupId, GroupName: aws.ToString(sg.GroupName)})
+			}
+		}
+		interfaces = append(interfaces, ref)
+	}
+	sort.Slice(interfaces, func(a, b int) bool { return interfaces[a].DeviceIndex < interfaces[b].DeviceIndex })
+	return interfaces
+}
+
+// VolumeIDs returns the IDs of every EBS volume attached to the instance
+func VolumeIDs(instance types.Instance) []string {
+	var ids []string
+	for _, bd := range instance.BlockDeviceMappings {
+		if bd.Ebs != nil && bd.Ebs.VolumeId != nil {
+			ids = append(ids, *bd.Ebs.VolumeId)
+		}
+	}
+	return ids
+}
+
+// RootVolumeID returns the EBS volume ID backing the instance's root device
+func RootVolumeID(instance types.Instance) (string, bool) {
+	if instance.RootDeviceName == nil {
+		return "", false
+	}
+	for _, bd := range instance.BlockDeviceMappings {
+		if bd.DeviceName != nil && *bd.DeviceName == *instance.RootDeviceName && bd.Ebs != nil && bd.Ebs.VolumeId != nil {
+			return *bd.Ebs.VolumeId, true
+		}
+	}
+	return "", false
+}
+
+// commonRootDeviceNames are the root device names of Amazon Linux, Ubuntu and
+// most other public AMIs
+var commonRootDeviceNames = map[string]bool{
+	"/dev/xvda": true,
+	"/dev/sda1": true,
+}
+
+// IsCommonRootDeviceName reports whether name is a usual root device name.
+// Launch templates do not say which block device is the root volume, so
+// their mappings for these names are read as the root volume.
+func IsCommonRootDeviceName(name string) bool {
+	return commonRootDeviceNames[name]
+}
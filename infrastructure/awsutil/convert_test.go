@@ -0,0 +1,328 @@
+package awsutil_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domain "driftdetector/domain/models"
+	"driftdetector/infrastructure/awsutil"
+	legacy "driftdetector/models"
+)
+
+// sampleValue returns a value of the type the registry passes for a field
+func sampleValue(field awsutil.Field) interface{} {
+	switch field {
+	case awsutil.FieldTags:
+		return map[string]string{"Name": "web"}
+	case awsutil.FieldSecurityGroups:
+		return []awsutil.SecurityGroupRef{{GroupID: "sg-1", GroupName: "web"}}
+	case awsutil.FieldEBSBlockDevices:
+		return []awsutil.EBSBlockDeviceRef{{DeviceName: "/dev/sdh", VolumeSize: 50}}
+	case awsutil.FieldNetworkInterfaces:
+		return []awsutil.NetworkInterfaceRef{{DeviceIndex: 1, NetworkInterfaceID: "eni-1", Groups: []awsutil.SecurityGroupRef{{GroupID: "sg-1"}}}}
+	case awsutil.FieldRootVolumeSize, awsutil.FieldRootVolumeIops, awsutil.FieldRootVolumeThroughput, awsutil.FieldCPUCoreCount, awsutil.FieldCPUThreadsPerCore,
+		awsutil.FieldPartitionNumber:
+		return 8
+	case awsutil.FieldRootVolumeEncrypted, awsutil.FieldMonitoring, awsutil.FieldEBSOptimized,
+		awsutil.FieldHibernation, awsutil.FieldEnclaveOptions, awsutil.FieldDisableAPITermination:
+		return true
+	case awsutil.FieldLaunchTime:
+		return time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	case awsutil.FieldMetadataOptions:
+		return awsutil.MetadataOptionsRef{HTTPTokens: "required", HTTPPutResponseHopLimit: 1}
+	case awsutil.FieldInstanceMarketOptions:
+		return awsutil.MarketOptionsRef{MarketType: "spot", MaxPrice: "0.05"}
+	default:
+		return "value"
+	}
+}
+
+// TestMappedFieldsIdenticalForBothModels guards against the two target models
+// diverging: every field in the registry must be accepted by both setters
+func TestMappedFieldsIdenticalForBothModels(t *testing.T) {
+	setters := map[string]awsutil.InstanceSetter{
+		"domain.Instance":       awsutil.NewDomainInstanceSetter(&domain.Instance{}),
+		"models.InstanceConfig": awsutil.NewInstanceConfigSetter(&legacy.InstanceConfig{}),
+	}
+
+	fields := awsutil.MappedFields()
+	require.NotEmpty(t, fields)
+
+	for name, setter := range setters {
+		var handled []awsutil.Field
+		for _, field := range fields {
+			if setter.Set(field, sampleValue(field)) {
+				handled = append(handled, field)
+			}
+		}
+		assert.Equal(t, fields, handled, "%s must handle every mapped field", name)
+	}
+}
+
+func TestConvertInstance(t *testing.T) {
+	instance := types.Instance{
+		InstanceId:       aws.String("i-123"),
+		InstanceType:     types.InstanceTypeT2Micro,
+		ImageId:          aws.String("ami-123"),
+		KeyName:          aws.String("key"),
+		VpcId:            aws.String("vpc-1"),
+		SubnetId:         aws.String("subnet-1"),
+		PrivateIpAddress: aws.String("10.0.0.1"),
+		Tags: []types.Tag{
+			{Key: aws.String("Name"), Value: aws.String("web")},
+			{Key: aws.String("Incomplete")},
+		},
+		SecurityGroups: []types.GroupIdentifier{
+			{GroupId: aws.String("sg-1"), GroupName: aws.String("web")},
+		},
+		Monitoring:         &types.Monitoring{State: types.MonitoringStateDisabled},
+		EbsOptimized:       aws.Bool(true),
+		IamInstanceProfile: &types.IamInstanceProfile{Arn: aws.String("arn:aws:iam::123456789012:instance-profile/app/web-profile")},
+		Placement:          &types.Placement{AvailabilityZone: aws.String("us-east-1a"), Tenancy: types.TenancyDedicated, GroupName: aws.String("")},
+		CpuOptions:         &types.CpuOptions{CoreCount: aws.Int32(2), ThreadsPerCore: aws.Int32(1)},
+		HibernationOptions: &types.HibernationOptions{Configured: aws.Bool(true)},
+		EnclaveOptions:     &types.EnclaveOptions{Enabled: aws.Bool(false)},
+		State:              &types.InstanceState{Name: types.InstanceStateNameStopped},
+		LaunchTime:         aws.Time(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)),
+		RootDeviceName:     aws.String("/dev/xvda"),
+		BlockDeviceMappings: []types.InstanceBlockDeviceMapping{
+			{DeviceName: aws.String("/dev/xvdb"), Ebs: &types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-data")}},
+			{DeviceName: aws.String("/dev/xvda"), Ebs: &types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-root")}},
+		},
+	}
+	volume := types.Volume{
+		Size:       aws.Int32(8),
+		VolumeType: types.VolumeTypeGp3,
+		Encrypted:  aws.Bool(true),
+	}
+
+	var domainInstance domain.Instance
+	var config legacy.InstanceConfig
+	for _, setter := range []awsutil.InstanceSetter{
+		awsutil.NewDomainInstanceSetter(&domainInstance),
+		awsutil.NewInstanceConfigSetter(&config),
+	} {
+		awsutil.ConvertInstance(instance, setter)
+		awsutil.ConvertRootVolume(volume, setter)
+	}
+
+	assert.Equal(t, "i-123", domainInstance.ID)
+	assert.Equal(t, "t2.micro", domainInstance.Type)
+	assert.Equal(t, map[string]string{"Name": "web"}, domainInstance.Tags)
+	assert.Equal(t, []domain.SecurityGroup{{GroupID: "sg-1", GroupName: "web"}}, domainInstance.SecurityGroups)
+	assert.Equal(t, 8, domainInstance.RootVolumeSize)
+	assert.Equal(t, "gp3", domainInstance.RootVolumeType)
+	assert.Equal(t, 0, domainInstance.RootVolumeIops, "Missing IOPS should not be set")
+	require.NotNil(t, domainInstance.RootVolumeEncrypted)
+	require.NotNil(t, domainInstance.Monitoring)
+	assert.False(t, *domainInstance.Monitoring)
+	require.NotNil(t, domainInstance.EBSOptimized)
+	assert.True(t, *domainInstance.EBSOptimized)
+	assert.Equal(t, "web-profile", domainInstance.IAMInstanceProfile, "Profile ARN should be reduced to its name")
+	assert.Equal(t, "us-east-1a", domainInstance.AvailabilityZone)
+	assert.Equal(t, "dedicated", domainInstance.Tenancy)
+	assert.Empty(t, domainInstance.PlacementGroup, "Empty placement group should not be set")
+	assert.Equal(t, 2, domainInstance.CPUCoreCount)
+	assert.Equal(t, 1, domainInstance.CPUThreadsPerCore)
+	assert.True(t, domainInstance.HibernationConfigured())
+	require.NotNil(t, domainInstance.EnclaveOptions)
+	assert.False(t, domainInstance.EnclaveEnabled())
+	assert.True(t, domainInstance.IsStopped())
+	require.NotNil(t, domainInstance.LaunchTime)
+	assert.Equal(t, time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC), *domainInstance.LaunchTime)
+
+	assert.Equal(t, domainInstance.ID, config.InstanceID)
+	assert.Equal(t, domainInstance.Type, config.InstanceType)
+	assert.Equal(t, domainInstance.Tags, config.Tags)
+	assert.Equal(t, domainInstance.RootVolumeSize, config.RootVolumeSize)
+	require.NotNil(t, config.CPUCoreCount)
+	assert.Equal(t, domainInstance.CPUCoreCount, *config.CPUCoreCount)
+	assert.Equal(t, domainInstance.IAMInstanceProfile, config.IAMInstanceProfile)
+	assert.Equal(t, "stopped", config.State)
+	assert.Equal(t, domainInstance.LaunchTime, config.LaunchTime)
+	assert.Nil(t, config.PartitionNumber, "Missing partition should not be set")
+
+	volumeID, ok := awsutil.RootVolumeID(instance)
+	assert.True(t, ok)
+	assert.Equal(t, "vol-root", volumeID)
+}
+
+func TestConvertPlacement(t *testing.T) {
+	instance := types.Instance{
+		Placement: &types.Placement{
+			AvailabilityZone: aws.String("us-east-1b"),
+			Tenancy:          types.TenancyHost,
+			HostId:           aws.String("h-0123456789abcdef0"),
+			GroupName:        aws.String("kafka"),
+			PartitionNumber:  aws.Int32(2),
+		},
+	}
+
+	var domainInstance domain.Instance
+	var config legacy.InstanceConfig
+	awsutil.ConvertInstance(instance, awsutil.NewDomainInstanceSetter(&domainInstance))
+	awsutil.ConvertInstance(instance, awsutil.NewInstanceConfigSetter(&config))
+
+	assert.Equal(t, "us-east-1b", domainInstance.AvailabilityZone)
+	assert.Equal(t, "host", domainInstance.Tenancy)
+	assert.Equal(t, "h-0123456789abcdef0", domainInstance.HostID)
+	assert.Equal(t, "kafka", domainInstance.PlacementGroup)
+	assert.Equal(t, 2, domainInstance.PartitionNumber)
+
+	assert.Equal(t, "host", config.Tenancy)
+	assert.Equal(t, "h-0123456789abcdef0", config.HostID)
+	assert.Equal(t, "kafka", config.PlacementGroup)
+	require.NotNil(t, config.PartitionNumber)
+	assert.Equal(t, 2, *config.PartitionNumber)
+}
+
+func TestConvertInstanceAttribute(t *testing.T) {
+	output := &ec2.DescribeInstanceAttributeOutput{
+		DisableApiTermination: &types.AttributeBooleanValue{Value: aws.Bool(true)},
+	}
+
+	var instance domain.Instance
+	setter := awsutil.NewDomainInstanceSetter(&instance)
+
+	awsutil.ConvertInstanceAttribute(types.InstanceAttributeNameUserData, output, setter)
+	assert.Nil(t, instance.DisableAPITermination, "Only fields of the requested attribute should be set")
+
+	assert.Contains(t, awsutil.InstanceAttributes(), types.InstanceAttributeNameDisableApiTermination)
+	awsutil.ConvertInstanceAttribute(types.InstanceAttributeNameDisableApiTermination, output, setter)
+	require.NotNil(t, instance.DisableAPITermination)
+	assert.True(t, *instance.DisableAPITermination)
+}
+
+func TestConvertInstanceAttribute_ShutdownBehavior(t *testing.T) {
+	output := &ec2.DescribeInstanceAttributeOutput{
+		InstanceInitiatedShutdownBehavior: &types.AttributeValue{Value: aws.String("terminate")},
+	}
+
+	var instance domain.Instance
+	var config legacy.InstanceConfig
+	assert.Contains(t, awsutil.InstanceAttributes(), types.InstanceAttributeNameInstanceInitiatedShutdownBehavior)
+	awsutil.ConvertInstanceAttribute(types.InstanceAttributeNameInstanceInitiatedShutdownBehavior, output, awsutil.NewDomainInstanceSetter(&instance))
+	awsutil.ConvertInstanceAttribute(types.InstanceAttributeNameInstanceInitiatedShutdownBehavior, output, awsutil.NewInstanceConfigSetter(&config))
+
+	assert.Equal(t, "terminate", instance.InstanceInitiatedShutdownBehavior)
+	assert.Equal(t, "terminate", config.InstanceInitiatedShutdownBehavior)
+}
+
+func TestConvertBlockDevices(t *testing.T) {
+	instance := types.Instance{
+		RootDeviceName: aws.String("/dev/xvda"),
+		BlockDeviceMappings: []types.InstanceBlockDeviceMapping{
+			{DeviceName: aws.String("/dev/xvdc"), Ebs: &types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-logs")}},
+			{DeviceName: aws.String("/dev/xvda"), Ebs: &types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-root")}},
+			{DeviceName: aws.String("/dev/xvdb"), Ebs: &types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-data"), DeleteOnTermination: aws.Bool(false)}},
+			{DeviceName: aws.String("/dev/xvdd"), Ebs: &types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-unknown")}},
+			{DeviceName: aws.String("/dev/xvde"), Ebs: &types.EbsInstanceBlockDevice{VolumeId: aws.String("vol-old"), Status: types.AttachmentStatusDetaching}},
+		},
+	}
+	volumes := map[string]types.Volume{
+		"vol-root": {Size: aws.Int32(8)},
+		"vol-data": {
+			Size: aws.Int32(100), VolumeType: types.VolumeTypeGp3, Throughput: aws.Int32(250), KmsKeyId: aws.String("arn:aws:kms:us-east-1:123456789012:key/abc"),
+			Tags: []types.Tag{{Key: aws.String("Name"), Value: aws.String("data")}},
+		},
+		"vol-logs": {Size: aws.Int32(20), VolumeType: types.VolumeTypeSt1},
+		"vol-old":  {Size: aws.Int32(10)},
+	}
+
+	assert.ElementsMatch(t, []string{"vol-logs", "vol-root", "vol-data", "vol-unknown", "vol-old"}, awsutil.VolumeIDs(instance))
+
+	var instanceModel domain.Instance
+	awsutil.ConvertBlockDevices(instance, volumes, awsutil.NewDomainInstanceSetter(&instanceModel))
+
+	require.Len(t, instanceModel.EBSBlockDevices, 2, "Root, undescribed and detaching volumes should be skipped")
+	data := instanceModel.EBSBlockDevices[0]
+	assert.Equal(t, "/dev/xvdb", data.DeviceName, "Devices should be ordered by name")
+	assert.Equal(t, 100, data.VolumeSize)
+	assert.Equal(t, 250, data.Throughput)
+	assert.Equal(t, "arn:aws:kms:us-east-1:123456789012:key/abc", data.KMSKeyID)
+	require.NotNil(t, data.DeleteOnTermination)
+	assert.False(t, *data.DeleteOnTermination)
+	assert.Equal(t, map[string]string{"Name": "data"}, data.Tags)
+	assert.Equal(t, "/dev/xvdc", instanceModel.EBSBlockDevices[1].DeviceName)
+	assert.Nil(t, instanceModel.EBSBlockDevices[1].Tags)
+}
+
+func TestConvertNetworkInterfaces(t *testing.T) {
+	instance := types.Instance{
+		NetworkInterfaces: []types.InstanceNetworkInterface{
+			{
+				NetworkInterfaceId: aws.String("eni-secondary"),
+				Attachment:         &types.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int32(1), DeleteOnTermination: aws.Bool(false)},
+				PrivateIpAddresses: []types.InstancePrivateIpAddress{{PrivateIpAddress: aws.String("10.0.1.9")}, {PrivateIpAddress: aws.String("10.0.1.5")}},
+				Groups:             []types.GroupIdentifier{{GroupId: aws.String("sg-app"), GroupName: aws.String("app")}},
+			},
+			{
+				NetworkInterfaceId: aws.String("eni-primary"),
+				Attachment:         &types.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int32(0), DeleteOnTermination: aws.Bool(true)},
+				PrivateIpAddresses: []types.InstancePrivateIpAddress{{PrivateIpAddress: aws.String("10.0.0.1")}},
+				Groups:             []types.GroupIdentifier{{GroupId: aws.String("sg-web")}},
+			},
+			{
+				NetworkInterfaceId: aws.String("eni-old"),
+				Attachment:         &types.InstanceNetworkInterfaceAttachment{DeviceIndex: aws.Int32(2), Status: types.AttachmentStatusDetaching},
+			},
+		},
+	}
+
+	var domainInstance domain.Instance
+	var config legacy.InstanceConfig
+	awsutil.ConvertInstance(instance, awsutil.NewDomainInstanceSetter(&domainInstance))
+	awsutil.ConvertInstance(instance, awsutil.NewInstanceConfigSetter(&config))
+
+	require.Len(t, domainInstance.NetworkInterfaces, 2, "Detaching interfaces should be skipped")
+	primary, secondary := domainInstance.NetworkInterfaces[0], domainInstance.NetworkInterfaces[1]
+	assert.Equal(t, 0, primary.DeviceIndex, "Interfaces should be ordered by device index")
+	assert.Equal(t, "eni-primary", primary.NetworkInterfaceID)
+	assert.Equal(t, []domain.SecurityGroup{{GroupID: "sg-web"}}, primary.Groups)
+	assert.Equal(t, 1, secondary.DeviceIndex)
+	assert.Equal(t, []string{"10.0.1.5", "10.0.1.9"}, secondary.PrivateIPAddresses, "Private IPs should be sorted")
+	require.NotNil(t, secondary.DeleteOnTermination)
+	assert.False(t, *secondary.DeleteOnTermination)
+	assert.Equal(t, []domain.SecurityGroup{{GroupID: "sg-app", GroupName: "app"}}, secondary.Groups)
+
+	require.Len(t, config.NetworkInterfaces, 2)
+	assert.Equal(t, "eni-secondary", config.NetworkInterfaces[1].NetworkInterfaceID)
+	assert.Equal(t, []legacy.SecurityGroup{{GroupID: "sg-app", GroupName: "app"}}, config.NetworkInterfaces[1].Groups)
+}
+
+func TestConvertSpotInstanceRequest(t *testing.T) {
+	instance := types.Instance{
+		InstanceId:            aws.String("i-spot"),
+		InstanceLifecycle:     types.InstanceLifecycleTypeSpot,
+		SpotInstanceRequestId: aws.String("sir-1"),
+	}
+	request := types.SpotInstanceRequest{
+		SpotInstanceRequestId:        aws.String("sir-1"),
+		SpotPrice:                    aws.String("0.050000"),
+		InstanceInterruptionBehavior: types.InstanceInterruptionBehaviorHibernate,
+	}
+
+	var domainInstance domain.Instance
+	var config legacy.InstanceConfig
+	for _, setter := range []awsutil.InstanceSetter{
+		awsutil.NewDomainInstanceSetter(&domainInstance),
+		awsutil.NewInstanceConfigSetter(&config),
+	} {
+		awsutil.ConvertInstance(instance, setter)
+		awsutil.ConvertSpotInstanceRequest(request, setter)
+	}
+
+	assert.Equal(t, "spot", domainInstance.InstanceLifecycle)
+	assert.Equal(t, "sir-1", domainInstance.SpotInstanceRequestID)
+	assert.Equal(t, &domain.InstanceMarketOptions{MarketType: "spot", MaxPrice: "0.050000", InstanceInterruptionBehavior: "hibernate"},
+		domainInstance.InstanceMarketOptions)
+	assert.Equal(t, "spot", config.InstanceLifecycle)
+	assert.Equal(t, &legacy.InstanceMarketOptions{MarketType: "spot", MaxPrice: "0.050000", InstanceInterruptionBehavior: "hibernate"},
+		config.InstanceMarketOptions)
+}
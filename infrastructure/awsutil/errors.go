@@ -0,0 +1,190 @@
+package awsutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+// ErrorClass groups AWS errors by how callers should react to them
+type ErrorClass int
+
+const (
+	// ErrorClassUnknown is any error not covered by another class
+	ErrorClassUnknown ErrorClass = iota
+	// ErrorClassNotFound means the requested resource does not exist
+	ErrorClassNotFound
+	// ErrorClassAccessDenied means the caller lacks permission
+	ErrorClassAccessDenied
+	// ErrorClassThrottling means the request was rate limited and may be retried
+	ErrorClassThrottling
+	// ErrorClassTransient means a temporary service or network failure
+	ErrorClassTransient
+)
+
+// String returns a readable name for the class
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorClassNotFound:
+		return "not_found"
+	case ErrorClassAccessDenied:
+		return "access_denied"
+	case ErrorClassThrottling:
+		return "throttling"
+	case ErrorClassTransient:
+		return "transient"
+	default:
+		return "unknown"
+	}
+}
+
+// Retryable reports whether an error of this class may succeed on retry
+func (c ErrorClass) Retryable() bool {
+	return c == ErrorClassThrottling || c == ErrorClassTransient
+}
+
+// ClassifyError determines the ErrorClass of an AWS SDK error
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassUnknown
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassTransient
+	}
+
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return ErrorClassUnknown
+	}
+
+	code := apiErr.ErrorCode()
+	switch {
+	case strings.HasSuffix(code, ".NotFound") || code == "NoSuchKey" || code == "NoSuchBucket" || code == "NotFound":
+		return ErrorClassNotFound
+	case code == "UnauthorizedOperation" || code == "AccessDenied" || code == "AccessDeniedException" || code == "AuthFailure" || code == "Forbidden":
+		return ErrorClassAccessDenied
+	case code == "Throttling" || code == "ThrottlingException" || code == "RequestLimitExceeded" || code == "SlowDown":
+		return ErrorClassThrottling
+	case code == "InternalError" || code == "ServiceUnavailable" || code == "Unavailable":
+		return ErrorClassTransient
+	}
+
+	if apiErr.ErrorFault() == smithy.FaultServer {
+		return ErrorClassTransient
+	}
+
+	return ErrorClassUnknown
+}
+
+// IsNotFound reports whether err means the resource does not exist
+func IsNotFound(err error) bool {
+	return ClassifyError(err) == ErrorClassNotFound
+}
+
+var (
+	// ErrNotFound marks errors for AWS resources that do not exist
+	ErrNotFound = errors.New("AWS resource not found")
+	// ErrThrottled marks requests AWS rate limited after every retry
+	ErrThrottled = errors.New("AWS request throttled")
+	// ErrAccessDenied marks requests the credentials may not make
+	ErrAccessDenied = errors.New("AWS access denied")
+)
+
+// WrapError returns err marked with the sentinel error of its class, so
+// callers can test it with errors.Is instead of inspecting AWS error codes.
+// Errors of other classes are returned unchanged.
+func WrapError(err error) error {
+	var sentinel error
+	switch ClassifyError(err) {
+	case ErrorClassNotFound:
+		sentinel = ErrNotFound
+	case ErrorClassThrottling:
+		sentinel = ErrThrottled
+	case ErrorClassAccessDenied:
+		sentinel = ErrAccessDenied
+	default:
+		return err
+	}
+	if errors.Is(err, sentinel) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", sentinel, err)
+}
+
+// RetryOptions controls retries and per-call timeouts for EC2 requests
+type RetryOptions struct {
+	// MaxAttempts is the total number of attempts, including the first
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry; it doubles on each retry
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between retries
+	MaxDelay time.Duration
+	// Timeout bounds each individual call; zero means no per-call timeout
+	Timeout time.Duration
+}
+
+// DefaultRetryOptions returns conservative defaults for EC2 describe calls
+func DefaultRetryOptions() RetryOptions {
+	return RetryOptions{
+		MaxAttempts: 3,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    5 * time.Second,
+	}
+}
+
+// Do runs fn, retrying retryable errors with jittered exponential backoff
+func (o RetryOptions) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	attempts := o.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	delay := o.BaseDelay
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = o.call(ctx, fn)
+		if err == nil || attempt == attempts || !ClassifyError(err).Retryable() {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(jitter(delay)):
+		}
+
+		delay *= 2
+		if o.MaxDelay > 0 && delay > o.MaxDelay {
+			delay = o.MaxDelay
+		}
+	}
+
+	return err
+}
+
+// jitter returns a wait between half of delay and delay, so that concurrent
+// callers throttled together do not retry in lockstep
+func jitter(delay time.Duration) time.Duration {
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + time.Duration(rand.Int64N(int64(delay-half)+1))
+}
+
+// call runs fn with the per-call timeout applied
+func (o RetryOptions) call(ctx context.Context, fn func(ctx context.Context) error) error {
+	if o.Timeout <= 0 {
+		return fn(ctx)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, o.Timeout)
+	defer cancel()
+	return fn(callCtx)
+}
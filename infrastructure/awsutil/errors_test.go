@@ -0,0 +1,107 @@
+package awsutil_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+
+	"driftdetector/infrastructure/awsutil"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected awsutil.ErrorClass
+	}{
+		{"nil", nil, awsutil.ErrorClassUnknown},
+		{"plain error", errors.New("boom"), awsutil.ErrorClassUnknown},
+		{"instance not found", &smithy.GenericAPIError{Code: "InvalidInstanceID.NotFound"}, awsutil.ErrorClassNotFound},
+		{"volume not found", &smithy.GenericAPIError{Code: "InvalidVolume.NotFound"}, awsutil.ErrorClassNotFound},
+		{"s3 object not found", &smithy.GenericAPIError{Code: "NoSuchKey"}, awsutil.ErrorClassNotFound},
+		{"unauthorized", &smithy.GenericAPIError{Code: "UnauthorizedOperation"}, awsutil.ErrorClassAccessDenied},
+		{"s3 access denied", &smithy.GenericAPIError{Code: "AccessDenied"}, awsutil.ErrorClassAccessDenied},
+		{"throttled", &smithy.GenericAPIError{Code: "RequestLimitExceeded"}, awsutil.ErrorClassThrottling},
+		{"s3 slow down", &smithy.GenericAPIError{Code: "SlowDown"}, awsutil.ErrorClassThrottling},
+		{"server fault", &smithy.GenericAPIError{Code: "Whatever", Fault: smithy.FaultServer}, awsutil.ErrorClassTransient},
+		{"deadline", context.DeadlineExceeded, awsutil.ErrorClassTransient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, awsutil.ClassifyError(tt.err))
+		})
+	}
+}
+
+func TestRetryOptions_Do(t *testing.T) {
+	opts := awsutil.RetryOptions{MaxAttempts: 3, BaseDelay: time.Millisecond}
+
+	t.Run("retries throttling until success", func(t *testing.T) {
+		calls := 0
+		err := opts.Do(context.Background(), func(ctx context.Context) error {
+			calls++
+			if calls < 3 {
+				return &smithy.GenericAPIError{Code: "Throttling"}
+			}
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("does not retry permanent errors", func(t *testing.T) {
+		calls := 0
+		err := opts.Do(context.Background(), func(ctx context.Context) error {
+			calls++
+			return &smithy.GenericAPIError{Code: "InvalidInstanceID.NotFound"}
+		})
+
+		assert.True(t, awsutil.IsNotFound(err))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		calls := 0
+		err := opts.Do(context.Background(), func(ctx context.Context) error {
+			calls++
+			return &smithy.GenericAPIError{Code: "InternalError"}
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, 3, calls)
+	})
+}
+
+func TestWrapError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		sentinel error
+	}{
+		{"not found", &smithy.GenericAPIError{Code: "InvalidInstanceID.NotFound"}, awsutil.ErrNotFound},
+		{"throttled", &smithy.GenericAPIError{Code: "Throttling"}, awsutil.ErrThrottled},
+		{"access denied", &smithy.GenericAPIError{Code: "UnauthorizedOperation"}, awsutil.ErrAccessDenied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := awsutil.WrapError(tt.err)
+
+			assert.ErrorIs(t, err, tt.sentinel)
+			assert.ErrorIs(t, err, tt.err, "the AWS error is kept")
+			assert.Same(t, err, awsutil.WrapError(err), "wrapping twice adds nothing")
+		})
+	}
+
+	t.Run("other errors are unchanged", func(t *testing.T) {
+		err := errors.New("boom")
+		assert.Same(t, err, awsutil.WrapError(err))
+		assert.NoError(t, awsutil.WrapError(nil))
+	})
+}
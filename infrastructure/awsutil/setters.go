@@ -0,0 +1,305 @@
+package awsutil
+
+import (
+	"time"
+
+	domain "driftdetector/domain/models"
+	legacy "driftdetector/models"
+)
+
+// domainInstanceSetter populates a domain Instance
+type domainInstanceSetter struct {
+	instance *domain.Instance
+}
+
+// NewDomainInstanceSetter returns an InstanceSetter that writes into a domain Instance
+func NewDomainInstanceSetter(instance *domain.Instance) InstanceSetter {
+	return &domainInstanceSetter{instance: instance}
+}
+
+// Set implements InstanceSetter
+func (s *domainInstanceSetter) Set(field Field, value interface{}) bool {
+	i := s.instance
+	switch field {
+	case FieldInstanceID:
+		i.ID = value.(string)
+	case FieldInstanceType:
+		i.Type = value.(string)
+	case FieldAMI:
+		i.AMI = value.(string)
+	case FieldKeyName:
+		i.KeyName = value.(string)
+	case FieldTags:
+		i.Tags = value.(map[string]string)
+	case FieldVPCID:
+		i.VPCID = value.(string)
+	case FieldSubnetID:
+		i.SubnetID = value.(string)
+	case FieldPrivateIPAddress:
+		i.PrivateIPAddress = value.(string)
+	case FieldPublicIPAddress:
+		i.PublicIPAddress = value.(string)
+	case FieldPrivateDNSName:
+		i.PrivateDNSName = value.(string)
+	case FieldPublicDNSName:
+		i.PublicDNSName = value.(string)
+	case FieldSecurityGroups:
+		refs := value.([]SecurityGroupRef)
+		i.SecurityGroups = make([]domain.SecurityGroup, 0, len(refs))
+		for _, ref := range refs {
+			i.SecurityGroups = append(i.SecurityGroups, domain.SecurityGroup{GroupID: ref.GroupID, GroupName: ref.GroupName})
+		}
+	case FieldNetworkInterfaces:
+		refs := value.([]NetworkInterfaceRef)
+		i.NetworkInterfaces = make([]domain.NetworkInterface, 0, len(refs))
+		for _, ref := range refs {
+			ni := domain.NetworkInterface{
+				DeviceIndex:         ref.DeviceIndex,
+				NetworkInterfaceID:  ref.NetworkInterfaceID,
+				DeleteOnTermination: ref.DeleteOnTermination,
+				PrivateIPAddresses:  ref.PrivateIPAddresses,
+			}
+			for _, sg := range ref.Groups {
+				ni.Groups = append(ni.Groups, domain.SecurityGroup{GroupID: sg.GroupID, GroupName: sg.GroupName})
+			}
+			i.NetworkInterfaces = append(i.NetworkInterfaces, ni)
+		}
+	case FieldRootVolumeSize:
+		i.RootVolumeSize = value.(int)
+	case FieldRootVolumeType:
+		i.RootVolumeType = value.(string)
+	case FieldRootVolumeIops:
+		i.RootVolumeIops = value.(int)
+	case FieldRootVolumeEncrypted:
+		encrypted := value.(bool)
+		i.RootVolumeEncrypted = &encrypted
+	case FieldRootVolumeThroughput:
+		i.RootVolumeThroughput = value.(int)
+	case FieldRootVolumeKMSKeyID:
+		i.RootVolumeKMSKeyID = value.(string)
+	case FieldEBSBlockDevices:
+		refs := value.([]EBSBlockDeviceRef)
+		i.EBSBlockDevices = make([]domain.EBSBlockDevice, 0, len(refs))
+		for _, ref := range refs {
+			i.EBSBlockDevices = append(i.EBSBlockDevices, domain.EBSBlockDevice{
+				DeviceName:          ref.DeviceName,
+				VolumeSize:          ref.VolumeSize,
+				VolumeType:          ref.VolumeType,
+				Iops:                ref.Iops,
+				Throughput:          ref.Throughput,
+				Encrypted:           ref.Encrypted,
+				KMSKeyID:            ref.KMSKeyID,
+				DeleteOnTermination: ref.DeleteOnTermination,
+				Tags:                ref.Tags,
+			})
+		}
+	case FieldMetadataOptions:
+		ref := value.(MetadataOptionsRef)
+		i.MetadataOptions = &domain.MetadataOptions{
+			HTTPEndpoint:            ref.HTTPEndpoint,
+			HTTPTokens:              ref.HTTPTokens,
+			HTTPPutResponseHopLimit: ref.HTTPPutResponseHopLimit,
+			InstanceMetadataTags:    ref.InstanceMetadataTags,
+		}
+	case FieldMonitoring:
+		monitoring := value.(bool)
+		i.Monitoring = &monitoring
+	case FieldEBSOptimized:
+		optimized := value.(bool)
+		i.EBSOptimized = &optimized
+	case FieldIAMInstanceProfile:
+		i.IAMInstanceProfile = value.(string)
+	case FieldAvailabilityZone:
+		i.AvailabilityZone = value.(string)
+	case FieldTenancy:
+		i.Tenancy = value.(string)
+	case FieldHostID:
+		i.HostID = value.(string)
+	case FieldPlacementGroup:
+		i.PlacementGroup = value.(string)
+	case FieldPartitionNumber:
+		i.PartitionNumber = value.(int)
+	case FieldCPUCoreCount:
+		i.CPUCoreCount = value.(int)
+	case FieldCPUThreadsPerCore:
+		i.CPUThreadsPerCore = value.(int)
+	case FieldHibernation:
+		i.Hibernation = &domain.HibernationOptions{Configured: value.(bool)}
+	case FieldEnclaveOptions:
+		i.EnclaveOptions = &domain.EnclaveOptions{Enabled: value.(bool)}
+	case FieldState:
+		i.State = value.(string)
+	case FieldLaunchTime:
+		launched := value.(time.Time)
+		i.LaunchTime = &launched
+	case FieldDisableAPITermination:
+		disabled := value.(bool)
+		i.DisableAPITermination = &disabled
+	case FieldShutdownBehavior:
+		i.InstanceInitiatedShutdownBehavior = value.(string)
+	case FieldInstanceLifecycle:
+		i.InstanceLifecycle = value.(string)
+	case FieldSpotRequestID:
+		i.SpotInstanceRequestID = value.(string)
+	case FieldInstanceMarketOptions:
+		ref := value.(MarketOptionsRef)
+		i.InstanceMarketOptions = &domain.InstanceMarketOptions{
+			MarketType:                   ref.MarketType,
+			MaxPrice:                     ref.MaxPrice,
+			InstanceInterruptionBehavior: ref.InstanceInterruptionBehavior,
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// instanceConfigSetter populates a legacy InstanceConfig
+type instanceConfigSetter struct {
+	config *legacy.InstanceConfig
+}
+
+// NewInstanceConfigSetter returns an InstanceSetter that writes into an InstanceConfig
+func NewInstanceConfigSetter(config *legacy.InstanceConfig) InstanceSetter {
+	return &instanceConfigSetter{config: config}
+}
+
+// Set implements InstanceSetter
+func (s *instanceConfigSetter) Set(field Field, value interface{}) bool {
+	c := s.config
+	switch field {
+	case FieldInstanceID:
+		c.InstanceID = value.(string)
+	case FieldInstanceType:
+		c.InstanceType = value.(string)
+	case FieldAMI:
+		c.AMI = value.(string)
+	case FieldKeyName:
+		c.KeyName = value.(string)
+	case FieldTags:
+		c.Tags = value.(map[string]string)
+	case FieldVPCID:
+		c.VPCID = value.(string)
+	case FieldSubnetID:
+		c.SubnetID = value.(string)
+	case FieldPrivateIPAddress:
+		c.PrivateIPAddress = value.(string)
+	case FieldPublicIPAddress:
+		c.PublicIPAddress = value.(string)
+	case FieldPrivateDNSName:
+		c.PrivateDNSName = value.(string)
+	case FieldPublicDNSName:
+		c.PublicDNSName = value.(string)
+	case FieldSecurityGroups:
+		refs := value.([]SecurityGroupRef)
+		c.SecurityGroups = make([]legacy.SecurityGroup, 0, len(refs))
+		for _, ref := range refs {
+			c.SecurityGroups = append(c.SecurityGroups, legacy.SecurityGroup{GroupID: ref.GroupID, GroupName: ref.GroupName})
+		}
+	case FieldNetworkInterfaces:
+		refs := value.([]NetworkInterfaceRef)
+		c.NetworkInterfaces = make([]*legacy.NetworkInterface, 0, len(refs))
+		for _, ref := range refs {
+			ni := domain.NetworkInterface{
+				DeviceIndex:         ref.DeviceIndex,
+				NetworkInterfaceID:  ref.NetworkInterfaceID,
+				DeleteOnTermination: ref.DeleteOnTermination,
+				PrivateIPAddresses:  ref.PrivateIPAddresses,
+			}
+			for _, sg := range ref.Groups {
+				ni.Groups = append(ni.Groups, domain.SecurityGroup{GroupID: sg.GroupID, GroupName: sg.GroupName})
+			}
+			c.NetworkInterfaces = append(c.NetworkInterfaces, &legacy.NetworkInterface{NetworkInterface: ni})
+		}
+	case FieldRootVolumeSize:
+		c.RootVolumeSize = value.(int)
+	case FieldRootVolumeType:
+		c.RootVolumeType = value.(string)
+	case FieldRootVolumeIops:
+		c.RootVolumeIops = value.(int)
+	case FieldRootVolumeEncrypted:
+		encrypted := value.(bool)
+		c.RootVolumeEncrypted = &encrypted
+	case FieldRootVolumeThroughput:
+		c.RootVolumeThroughput = value.(int)
+	case FieldRootVolumeKMSKeyID:
+		c.RootVolumeKMSKeyID = value.(string)
+	case FieldEBSBlockDevices:
+		refs := value.([]EBSBlockDeviceRef)
+		c.EBSBlockDevices = make([]*legacy.EBSBlockDevice, 0, len(refs))
+		for _, ref := range refs {
+			c.EBSBlockDevices = append(c.EBSBlockDevices, &legacy.EBSBlockDevice{EBSBlockDevice: domain.EBSBlockDevice{
+				DeviceName:          ref.DeviceName,
+				VolumeSize:          ref.VolumeSize,
+				VolumeType:          ref.VolumeType,
+				Iops:                ref.Iops,
+				Throughput:          ref.Throughput,
+				Encrypted:           ref.Encrypted,
+				KMSKeyID:            ref.KMSKeyID,
+				DeleteOnTermination: ref.DeleteOnTermination,
+				Tags:                ref.Tags,
+			}})
+		}
+	case FieldMetadataOptions:
+		ref := value.(MetadataOptionsRef)
+		c.MetadataOptions = &legacy.MetadataOptions{
+			HTTPEndpoint:            ref.HTTPEndpoint,
+			HTTPTokens:              ref.HTTPTokens,
+			HTTPPutResponseHopLimit: ref.HTTPPutResponseHopLimit,
+			InstanceMetadataTags:    ref.InstanceMetadataTags,
+		}
+	case FieldMonitoring:
+		monitoring := value.(bool)
+		c.Monitoring = &monitoring
+	case FieldEBSOptimized:
+		optimized := value.(bool)
+		c.EBSOptimized = &optimized
+	case FieldIAMInstanceProfile:
+		c.IAMInstanceProfile = value.(string)
+	case FieldAvailabilityZone:
+		c.AvailabilityZone = value.(string)
+	case FieldTenancy:
+		c.Tenancy = value.(string)
+	case FieldHostID:
+		c.HostID = value.(string)
+	case FieldPlacementGroup:
+		c.PlacementGroup = value.(string)
+	case FieldPartitionNumber:
+		partition := value.(int)
+		c.PartitionNumber = &partition
+	case FieldCPUCoreCount:
+		coreCount := value.(int)
+		c.CPUCoreCount = &coreCount
+	case FieldCPUThreadsPerCore:
+		threadsPerCore := value.(int)
+		c.CPUThreadsPerCore = &threadsPerCore
+	case FieldHibernation:
+		c.Hibernation = &legacy.HibernationOptions{Configured: value.(bool)}
+	case FieldEnclaveOptions:
+		c.EnclaveOptions = &legacy.EnclaveOptions{Enabled: value.(bool)}
+	case FieldState:
+		c.State = value.(string)
+	case FieldLaunchTime:
+		launched := value.(time.Time)
+		c.LaunchTime = &launched
+	case FieldDisableAPITermination:
+		disabled := value.(bool)
+		c.DisableAPITermination = &disabled
+	case FieldShutdownBehavior:
+		c.InstanceInitiatedShutdownBehavior = value.(string)
+	case FieldInstanceLifecycle:
+		c.InstanceLifecycle = value.(string)
+	case FieldSpotRequestID:
+		c.SpotInstanceRequestID = value.(string)
+	case FieldInstanceMarketOptions:
+		ref := value.(MarketOptionsRef)
+		c.InstanceMarketOptions = &legacy.InstanceMarketOptions{
+			MarketType:                   ref.MarketType,
+			MaxPrice:                     ref.MaxPrice,
+			InstanceInterruptionBehavior: ref.InstanceInterruptionBehavior,
+		}
+	default:
+		return false
+	}
+	return true
+}
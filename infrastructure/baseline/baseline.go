@@ -0,0 +1,244 @@
+// Package baseline records drift findings that have been accepted, such as a
+// volume resized by hand while the Terraform change is in review, so later
+// runs acknowledge them instead of reporting them as drift
+package baseline
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"driftdetector/domain/models"
+)
+
+// Version is the version of the baseline file format written by Write
+const Version = 1
+
+// Baseline is a set of accepted findings
+type Baseline struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"entries"`
+}
+
+// Entry accepts the finding at Path of an instance for as long as the
+// drifted value is unchanged
+type Entry struct {
+	InstanceID string `json:"instance_id"`
+	// Path is empty for a finding about the whole instance, such as an
+	// instance missing in AWS
+	Path string `json:"path"`
+	// ActualHash fingerprints the value found in AWS, as returned by Hash
+	ActualHash string `json:"actual_hash"`
+	// Expires is the date, as YYYY-MM-DD or an RFC 3339 time, from which
+	// the entry no longer acknowledges its finding; empty never expires
+	Expires string `json:"expires,omitempty"`
+	// Note says why the drift was accepted, e.g. the pull request fixing it
+	Note string `json:"note,omitempty"`
+}
+
+// ParseExpiry parses the expiry of an entry, a date or an RFC 3339 time
+func ParseExpiry(value string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry %q: expected a date such as 2024-07-01 or an RFC 3339 time", value)
+	}
+	return t, nil
+}
+
+// expired reports whether the entry no longer applies at now
+func (e Entry) expired(now time.Time) bool {
+	if e.Expires == "" {
+		return false
+	}
+	// Load rejects expiries that do not parse
+	expiry, _ := ParseExpiry(e.Expires)
+	return !now.Before(expiry)
+}
+
+// Hash fingerprints a finding's value. Values are hashed in their JSON
+// encoding, so the fingerprint does not depend on map order or on pointers.
+func Hash(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		data = []byte(fmt.Sprintf("%#v", value))
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Load reads a baseline file written by Write, or edited by hand to add
+// expiry dates and notes
+func Load(path string) (*Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading baseline: %w", err)
+	}
+
+	var b Baseline
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&b); err != nil {
+		return nil, fmt.Errorf("parsing baseline %s: %w", path, err)
+	}
+	if b.Version > Version {
+		return nil, fmt.Errorf("baseline %s has version %d; this version of driftdetector reads up to %d", path, b.Version, Version)
+	}
+
+	var problems []string
+	for i, entry := range b.Entries {
+		if entry.InstanceID == "" || entry.ActualHash == "" {
+			problems = append(problems, fmt.Sprintf("entry %d: instance_id and actual_hash are required", i+1))
+		}
+		if entry.Expires != "" {
+			if _, err := ParseExpiry(entry.Expires); err != nil {
+				problems = append(problems, fmt.Sprintf("entry %d: %v", i+1, err))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("baseline %s: %s", path, strings.Join(problems, "; "))
+	}
+	return &b, nil
+}
+
+// Write saves the baseline to path as indented JSON
+func (b *Baseline) Write(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding baseline: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("writing baseline: %w", err)
+	}
+	return nil
+}
+
+// entryKey identifies the finding an entry accepts
+type entryKey struct {
+	instanceID, path string
+}
+
+// index returns the entries by the finding they accept. Paths written with
+// a leading dot, as map entries such as .Tags.Name once were, match the same
+// finding without it.
+func (b *Baseline) index() map[entryKey][]Entry {
+	byKey := make(map[entryKey][]Entry, len(b.Entries))
+	for _, entry := range b.Entries {
+		key := entryKey{entry.InstanceID, strings.TrimPrefix(entry.Path, ".")}
+		byKey[key] = append(byKey[key], entry)
+	}
+	return byKey
+}
+
+// errNotListed is returned by match for a finding the baseline has no entry for
+var errNotListed = errors.New("not in the baseline")
+
+// match returns the entry acknowledging d at now. A finding whose entries
+// have all expired, or whose value has changed since it was accepted, is
+// not acknowledged, and the error says why.
+func match(entries []Entry, d models.Drift, now time.Time) (Entry, error) {
+	if len(entries) == 0 {
+		return Entry{}, errNotListed
+	}
+
+	hash := Hash(d.Actual)
+	var err error
+	for _, entry := range entries {
+		switch {
+		case entry.ActualHash != hash:
+			if err == nil {
+				err = errors.New("value changed since it was accepted in the baseline")
+			}
+		case entry.expired(now):
+			err = fmt.Errorf("baseline entry expired on %s", entry.Expires)
+		default:
+			return entry, nil
+		}
+	}
+	return Entry{}, err
+}
+
+// Apply returns a copy of report whose findings the baseline accepts are
+// moved from Drifts to Acknowledged. A listed finding whose value has
+// changed since, or whose entry has expired, is kept as drift with a warning
+// saying so.
+func (b *Baseline) Apply(report *models.DriftReport, now time.Time) *models.DriftReport {
+	if b == nil || report == nil {
+		return report
+	}
+
+	byKey := b.index()
+	applied := *report
+	applied.Drifts = make([]models.Drift, 0, len(report.Drifts))
+	applied.Acknowledged = append([]models.Drift(nil), report.Acknowledged...)
+	applied.Warnings = append([]string(nil), report.Warnings...)
+	for _, d := range report.Drifts {
+		_, err := match(byKey[entryKey{report.InstanceID, strings.TrimPrefix(d.Path, ".")}], d, now)
+		switch {
+		case err == nil:
+			applied.Acknowledged = append(applied.Acknowledged, d)
+		case errors.Is(err, errNotListed):
+			applied.Drifts = append(applied.Drifts, d)
+		default:
+			applied.Drifts = append(applied.Drifts, d)
+			applied.Warnings = append(applied.Warnings, fmt.Sprintf("%s: %v", d.Path, err))
+		}
+	}
+	applied.HasDrift = models.ContainsDrift(applied.Drifts)
+	return &applied
+}
+
+// Record returns a baseline accepting every finding of reports, including
+// those already acknowledged. A finding previous acknowledges, when previous
+// is not nil, keeps its entry with its expiry and note; the others expire on
+// expires, unless it is empty.
+func Record(reports []*models.DriftReport, previous *Baseline, expires string, now time.Time) *Baseline {
+	var byKey map[entryKey][]Entry
+	if previous != nil {
+		byKey = previous.index()
+	}
+
+	b := &Baseline{Version: Version, Entries: []Entry{}}
+	// Findings of several kinds at one path, e.g. a change that also breaks
+	// a policy, share an entry
+	seen := make(map[Entry]bool)
+	for _, report := range reports {
+		if report == nil {
+			continue
+		}
+		for _, d := range append(append([]models.Drift(nil), report.Drifts...), report.Acknowledged...) {
+			entry, err := match(byKey[entryKey{report.InstanceID, strings.TrimPrefix(d.Path, ".")}], d, now)
+			if err != nil {
+				entry = Entry{
+					InstanceID: report.InstanceID,
+					Path:       d.Path,
+					ActualHash: Hash(d.Actual),
+					Expires:    expires,
+				}
+			}
+			if !seen[entry] {
+				seen[entry] = true
+				b.Entries = append(b.Entries, entry)
+			}
+		}
+	}
+
+	sort.SliceStable(b.Entries, func(i, j int) bool {
+		a, c := b.Entries[i], b.Entries[j]
+		if a.InstanceID != c.InstanceID {
+			return a.InstanceID < c.InstanceID
+		}
+		return models.ComparePaths(a.Path, c.Path) < 0
+	})
+	return b
+}
@@ -0,0 +1,190 @@
+package baseline_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/baseline"
+)
+
+var now = time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+// driftedReport returns a report of i-1 with a resized root volume and an
+// extra tag
+func driftedReport() *models.DriftReport {
+	report := models.NewDriftReport("i-1")
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, "RootVolumeSize", 100, 50, "Value mismatch"))
+	report.AddDrift(models.NewDrift(models.DriftTypeRemoved, "Tags.Owner", "ops", nil, "Field removed"))
+	return report
+}
+
+func paths(drifts []models.Drift) []string {
+	var paths []string
+	for _, d := range drifts {
+		paths = append(paths, d.Path)
+	}
+	return paths
+}
+
+func TestBaseline_Apply(t *testing.T) {
+	volume := baseline.Entry{InstanceID: "i-1", Path: "RootVolumeSize", ActualHash: baseline.Hash(100)}
+
+	tests := []struct {
+		name         string
+		entries      []baseline.Entry
+		drifts       []string
+		acknowledged []string
+		warnings     []string
+	}{
+		{
+			name:         "matching entry",
+			entries:      []baseline.Entry{volume},
+			drifts:       []string{"Tags.Owner"},
+			acknowledged: []string{"RootVolumeSize"},
+		},
+		{
+			name:         "entry not yet expired",
+			entries:      []baseline.Entry{{InstanceID: "i-1", Path: "RootVolumeSize", ActualHash: volume.ActualHash, Expires: "2024-06-16"}},
+			drifts:       []string{"Tags.Owner"},
+			acknowledged: []string{"RootVolumeSize"},
+		},
+		{
+			name:     "expired entry",
+			entries:  []baseline.Entry{{InstanceID: "i-1", Path: "RootVolumeSize", ActualHash: volume.ActualHash, Expires: "2024-06-15"}},
+			drifts:   []string{"RootVolumeSize", "Tags.Owner"},
+			warnings: []string{"RootVolumeSize: baseline entry expired on 2024-06-15"},
+		},
+		{
+			name:     "value changed again",
+			entries:  []baseline.Entry{{InstanceID: "i-1", Path: "RootVolumeSize", ActualHash: baseline.Hash(80)}},
+			drifts:   []string{"RootVolumeSize", "Tags.Owner"},
+			warnings: []string{"RootVolumeSize: value changed since it was accepted in the baseline"},
+		},
+		{
+			name:         "entry written with a leading dot",
+			entries:      []baseline.Entry{{InstanceID: "i-1", Path: ".Tags.Owner", ActualHash: baseline.Hash("ops")}},
+			drifts:       []string{"RootVolumeSize"},
+			acknowledged: []string{"Tags.Owner"},
+		},
+		{
+			name:    "other instance",
+			entries: []baseline.Entry{{InstanceID: "i-2", Path: "RootVolumeSize", ActualHash: volume.ActualHash}},
+			drifts:  []string{"RootVolumeSize", "Tags.Owner"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := driftedReport()
+			b := &baseline.Baseline{Version: baseline.Version, Entries: tt.entries}
+
+			applied := b.Apply(report, now)
+
+			assert.Equal(t, tt.drifts, paths(applied.Drifts))
+			assert.Equal(t, tt.acknowledged, paths(applied.Acknowledged))
+			assert.Equal(t, tt.warnings, applied.Warnings)
+			assert.True(t, applied.HasDrifts())
+			assert.Len(t, report.Drifts, 2, "the report itself is unchanged")
+		})
+	}
+
+	t.Run("every finding acknowledged", func(t *testing.T) {
+		b := baseline.Record([]*models.DriftReport{driftedReport()}, nil, "", now)
+
+		applied := b.Apply(driftedReport(), now)
+
+		assert.Empty(t, applied.Drifts)
+		assert.Len(t, applied.Acknowledged, 2)
+		assert.False(t, applied.HasDrifts())
+	})
+
+	t.Run("no baseline", func(t *testing.T) {
+		var b *baseline.Baseline
+		report := driftedReport()
+
+		assert.Same(t, report, b.Apply(report, now))
+	})
+}
+
+func TestRecord(t *testing.T) {
+	previous := &baseline.Baseline{Entries: []baseline.Entry{
+		{InstanceID: "i-1", Path: "RootVolumeSize", ActualHash: baseline.Hash(100), Expires: "2024-07-01", Note: "PR #42"},
+		{InstanceID: "i-1", Path: ".Tags.Owner", ActualHash: baseline.Hash("old"), Note: "stale"},
+	}}
+	other := models.NewDriftReport("i-0")
+	other.AddDrift(models.NewDrift(models.DriftTypeModified, "Type", "t3.large", "t3.micro", "Value mismatch"))
+	other.AddDrift(models.NewDrift(models.DriftTypePolicyViolation, "Type", "t3.large", nil, "large instances need approval"))
+
+	b := baseline.Record([]*models.DriftReport{previous.Apply(driftedReport(), now), other}, previous, "2024-09-01", now)
+
+	assert.Equal(t, baseline.Version, b.Version)
+	assert.Equal(t, []baseline.Entry{
+		{InstanceID: "i-0", Path: "Type", ActualHash: baseline.Hash("t3.large"), Expires: "2024-09-01"},
+		{InstanceID: "i-1", Path: "RootVolumeSize", ActualHash: baseline.Hash(100), Expires: "2024-07-01", Note: "PR #42"},
+		{InstanceID: "i-1", Path: "Tags.Owner", ActualHash: baseline.Hash("ops"), Expires: "2024-09-01"},
+	}, b.Entries, "acknowledged findings keep their entry, findings at one path share one")
+}
+
+func TestHash(t *testing.T) {
+	yes := true
+	assert.Equal(t, baseline.Hash(map[string]string{"a": "1", "b": "2"}), baseline.Hash(map[string]string{"b": "2", "a": "1"}))
+	assert.Equal(t, baseline.Hash(&models.MetadataOptions{HTTPTokens: "optional"}), baseline.Hash(&models.MetadataOptions{HTTPTokens: "optional"}), "pointers are hashed by value")
+	assert.Equal(t, baseline.Hash(true), baseline.Hash(&yes))
+	assert.NotEqual(t, baseline.Hash("1"), baseline.Hash(1))
+	assert.Regexp(t, `^sha256:[0-9a-f]{64}$`, baseline.Hash(nil))
+}
+
+func TestLoad(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "baseline.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "baseline.json")
+		written := baseline.Record([]*models.DriftReport{driftedReport()}, nil, "2024-07-01", now)
+		require.NoError(t, written.Write(path))
+
+		loaded, err := baseline.Load(path)
+
+		require.NoError(t, err)
+		assert.Equal(t, written, loaded)
+	})
+
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{
+			name:    "unknown field",
+			content: `{"version": 1, "entries": [{"instance_id": "i-1", "path": "Type", "actual_hash": "sha256:00", "expiry": "2024-07-01"}]}`,
+			err:     `unknown field "expiry"`,
+		},
+		{
+			name:    "invalid entries",
+			content: `{"version": 1, "entries": [{"path": "Type", "actual_hash": "sha256:00"}, {"instance_id": "i-1", "path": "AMI", "actual_hash": "sha256:00", "expires": "July"}]}`,
+			err:     `entry 1: instance_id and actual_hash are required; entry 2: invalid expiry "July"`,
+		},
+		{
+			name:    "newer version",
+			content: `{"version": 2, "entries": []}`,
+			err:     "has version 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := baseline.Load(write(t, tt.content))
+
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
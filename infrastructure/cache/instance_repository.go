@@ -0,0 +1,280 @@
+// Package cache keeps instances read from the cloud provider on disk, so that
+// repeated local runs within a TTL do not describe them again
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/infrastructure/logger"
+	legacy "driftdetector/models"
+)
+
+// DefaultTTL is how long cached instances are used when no TTL is set
+const DefaultTTL = 10 * time.Minute
+
+// Ensure InstanceRepository implements the InstanceRepository interface
+var _ repositories.InstanceRepository = (*InstanceRepository)(nil)
+
+// InstanceRepository decorates another InstanceRepository, writing every
+// instance it returns to <dir>/<instance-id>.json and serving GetByID and
+// GetByIDs from those files while they are younger than the TTL. Find,
+// FindAll and FindByTag always call the decorated repository, since the set
+// of matching instances may have changed, and refresh the cache with the
+// instances they return.
+type InstanceRepository struct {
+	next    repositories.InstanceRepository
+	dir     string
+	ttl     time.Duration
+	refresh bool
+	now     func() time.Time
+}
+
+// InstanceRepositoryOption configures an InstanceRepository
+type InstanceRepositoryOption func(*InstanceRepository)
+
+// WithTTL sets how long cached instances are used
+func WithTTL(ttl time.Duration) InstanceRepositoryOption {
+	return func(r *InstanceRepository) {
+		r.ttl = ttl
+	}
+}
+
+// WithRefresh ignores cached instances, reading every instance from the
+// decorated repository and caching it again
+func WithRefresh() InstanceRepositoryOption {
+	return func(r *InstanceRepository) {
+		r.refresh = true
+	}
+}
+
+// WithClock sets the function returning the current time, for tests
+func WithClock(now func() time.Time) InstanceRepositoryOption {
+	return func(r *InstanceRepository) {
+		r.now = now
+	}
+}
+
+// cacheEntry is the content of a cache file: the instance as written by
+// snapshot, and when it was read
+type cacheEntry struct {
+	CachedAt time.Time              `json:"cached_at"`
+	Instance *legacy.InstanceConfig `json:"instance"`
+}
+
+// NewInstanceRepository creates an InstanceRepository caching the instances
+// of next in dir, creating the directory if needed
+func NewInstanceRepository(next repositories.InstanceRepository, dir string, opts ...InstanceRepositoryOption) (*InstanceRepository, error) {
+	if next == nil {
+		return nil, errors.New("instance repository cannot be nil")
+	}
+	r := &InstanceRepository{next: next, dir: dir, ttl: DefaultTTL, now: time.Now}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.ttl <= 0 {
+		return nil, fmt.Errorf("cache TTL must be positive, got %s", r.ttl)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return r, nil
+}
+
+// GetByID returns the cached instance while it is fresh, else reads and caches it
+func (r *InstanceRepository) GetByID(ctx context.Context, id string) (*models.Instance, error) {
+	if instance := r.load(id); instance != nil {
+		return instance, nil
+	}
+	instance, err := r.next.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	r.store(instance)
+	return instance, nil
+}
+
+// GetByIDs returns the fresh cached instances and reads the others in one
+// call to the decorated repository, in the order of ids
+func (r *InstanceRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.Instance, error) {
+	if len(ids) == 0 {
+		return r.next.GetByIDs(ctx, ids)
+	}
+
+	found := make(map[string]*models.Instance, len(ids))
+	var missing []string
+	for _, id := range ids {
+		if _, ok := found[id]; ok {
+			continue
+		}
+		if instance := r.load(id); instance != nil {
+			found[id] = instance
+			continue
+		}
+		found[id] = nil
+		missing = append(missing, id)
+	}
+
+	if len(missing) > 0 {
+		read, err := r.next.GetByIDs(ctx, missing)
+		if err != nil {
+			return nil, err
+		}
+		r.storeAll(read)
+		for _, instance := range read {
+			found[instance.ID] = instance
+		}
+	}
+
+	instances := make([]*models.Instance, 0, len(found))
+	for _, id := range ids {
+		if instance := found[id]; instance != nil {
+			instances = append(instances, instance)
+			delete(found, id)
+		}
+	}
+	return instances, nil
+}
+
+// FindAll reads all instances from the decorated repository and caches them
+func (r *InstanceRepository) FindAll(ctx context.Context) ([]*models.Instance, error) {
+	instances, err := r.next.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r.storeAll(instances)
+	return instances, nil
+}
+
+// Find reads the matching instances from the decorated repository and caches them
+func (r *InstanceRepository) Find(ctx context.Context, filter repositories.InstanceFilter) ([]*models.Instance, error) {
+	instances, err := r.next.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	r.storeAll(instances)
+	return instances, nil
+}
+
+// FindByTag reads the matching instances from the decorated repository and caches them
+func (r *InstanceRepository) FindByTag(ctx context.Context, key, value string) ([]*models.Instance, error) {
+	instances, err := r.next.FindByTag(ctx, key, value)
+	if err != nil {
+		return nil, err
+	}
+	r.storeAll(instances)
+	return instances, nil
+}
+
+// Save saves the instance in the decorated repository and caches it
+func (r *InstanceRepository) Save(ctx context.Context, instance *models.Instance) error {
+	if err := r.next.Save(ctx, instance); err != nil {
+		return err
+	}
+	r.store(instance)
+	return nil
+}
+
+// Delete deletes the instance from the decorated repository and the cache
+func (r *InstanceRepository) Delete(ctx context.Context, id string) error {
+	if err := r.next.Delete(ctx, id); err != nil {
+		return err
+	}
+	if err := os.Remove(r.path(id)); err != nil && !os.IsNotExist(err) {
+		logger.Warn("failed to remove cached instance", "instance", id, "error", err)
+	}
+	return nil
+}
+
+// path returns the cache file of the instance
+func (r *InstanceRepository) path(id string) string {
+	return filepath.Join(r.dir, id+".json")
+}
+
+// load returns the cached instance, or nil when it is missing, stale,
+// unreadable or refresh is set
+func (r *InstanceRepository) load(id string) *models.Instance {
+	if r.refresh || id == "" || filepath.Base(id) != id {
+		return nil
+	}
+	data, err := os.ReadFile(r.path(id))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Warn("failed to read cached instance", "instance", id, "error", err)
+		}
+		return nil
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Instance == nil || entry.Instance.InstanceID != id {
+		logger.Warn("ignoring invalid cached instance", "instance", id, "path", r.path(id))
+		return nil
+	}
+	age := r.now().Sub(entry.CachedAt)
+	if age < 0 || age >= r.ttl {
+		logger.Debug("cached instance expired", "instance", id, "cached_at", entry.CachedAt)
+		return nil
+	}
+
+	logger.Warn("using cached instance instead of reading it from AWS",
+		"instance", id, "age", age.Round(time.Second).String(), "path", r.path(id))
+	return entry.Instance.ToInstance()
+}
+
+// store writes the instance to its cache file. Failing to cache is logged
+// and otherwise ignored, since the instance was read. Instances with settings
+// AWS did not allow reading are not cached, as the cache file cannot record
+// which settings are missing.
+func (r *InstanceRepository) store(instance *models.Instance) {
+	if instance == nil || instance.ID == "" || filepath.Base(instance.ID) != instance.ID {
+		return
+	}
+	if len(instance.UnavailableFields) > 0 {
+		return
+	}
+	entry := cacheEntry{CachedAt: r.now().UTC(), Instance: legacy.NewInstanceConfig(instance)}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(r.path(instance.ID), data)
+	}
+	if err != nil {
+		logger.Warn("failed to cache instance", "instance", instance.ID, "error", err)
+	}
+}
+
+// storeAll caches every instance
+func (r *InstanceRepository) storeAll(instances []*models.Instance) {
+	for _, instance := range instances {
+		r.store(instance)
+	}
+}
+
+// writeFileAtomic writes data to a temporary file renamed to path, so that
+// a concurrent run never reads a partly written cache file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
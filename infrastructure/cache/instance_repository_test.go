@@ -0,0 +1,199 @@
+package cache_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/infrastructure/cache"
+	"driftdetector/infrastructure/mock"
+	legacy "driftdetector/models"
+)
+
+// countingRepository counts the calls that reach the decorated repository
+type countingRepository struct {
+	*mock.InstanceRepository
+	calls int
+}
+
+func (r *countingRepository) GetByID(ctx context.Context, id string) (*models.Instance, error) {
+	r.calls++
+	return r.InstanceRepository.GetByID(ctx, id)
+}
+
+func (r *countingRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.Instance, error) {
+	r.calls++
+	return r.InstanceRepository.GetByIDs(ctx, ids)
+}
+
+func (r *countingRepository) Find(ctx context.Context, filter repositories.InstanceFilter) ([]*models.Instance, error) {
+	r.calls++
+	return r.InstanceRepository.Find(ctx, filter)
+}
+
+// fakeClock is a settable clock
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newCachedRepository(t *testing.T, opts ...cache.InstanceRepositoryOption) (*cache.InstanceRepository, *countingRepository, *fakeClock, string) {
+	t.Helper()
+	next := &countingRepository{InstanceRepository: mock.NewInstanceRepository(
+		&legacy.InstanceConfig{InstanceID: "i-1", InstanceType: "t3.micro", Tags: map[string]string{"Environment": "prod"}},
+		&legacy.InstanceConfig{InstanceID: "i-2", InstanceType: "t3.small"},
+	)}
+	clock := &fakeClock{now: time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)}
+	dir := filepath.Join(t.TempDir(), "cache")
+	repo, err := cache.NewInstanceRepository(next, dir, append([]cache.InstanceRepositoryOption{cache.WithClock(clock.Now)}, opts...)...)
+	require.NoError(t, err)
+	return repo, next, clock, dir
+}
+
+func TestInstanceRepository_GetByID(t *testing.T) {
+	ctx := context.Background()
+	repo, next, clock, dir := newCachedRepository(t)
+
+	instance, err := repo.GetByID(ctx, "i-1")
+	require.NoError(t, err)
+	assert.Equal(t, "t3.micro", instance.Type)
+	assert.FileExists(t, filepath.Join(dir, "i-1.json"))
+
+	clock.now = clock.now.Add(9 * time.Minute)
+	cached, err := repo.GetByID(ctx, "i-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, next.calls, "a fresh cached instance is not read again")
+	assert.Equal(t, instance.Type, cached.Type)
+	assert.Equal(t, "prod", cached.Tags["Environment"])
+
+	clock.now = clock.now.Add(time.Minute)
+	_, err = repo.GetByID(ctx, "i-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.calls, "an instance cached for the whole TTL is read again")
+}
+
+func TestInstanceRepository_GetByIDNotFound(t *testing.T) {
+	repo, _, _, dir := newCachedRepository(t)
+
+	_, err := repo.GetByID(context.Background(), "i-3")
+
+	assert.ErrorIs(t, err, repositories.ErrInstanceNotFound)
+	assert.NoFileExists(t, filepath.Join(dir, "i-3.json"))
+}
+
+func TestInstanceRepository_Refresh(t *testing.T) {
+	ctx := context.Background()
+	repo, next, _, dir := newCachedRepository(t, cache.WithRefresh())
+
+	_, err := repo.GetByID(ctx, "i-1")
+	require.NoError(t, err)
+	_, err = repo.GetByID(ctx, "i-1")
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, next.calls)
+	assert.FileExists(t, filepath.Join(dir, "i-1.json"), "refreshed instances are still cached")
+}
+
+func TestInstanceRepository_GetByIDs(t *testing.T) {
+	ctx := context.Background()
+	repo, next, _, _ := newCachedRepository(t)
+
+	_, err := repo.GetByID(ctx, "i-2")
+	require.NoError(t, err)
+
+	instances, err := repo.GetByIDs(ctx, []string{"i-1", "i-2", "i-1"})
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+	assert.Equal(t, "i-1", instances[0].ID)
+	assert.Equal(t, "i-2", instances[1].ID)
+	assert.Equal(t, 2, next.calls, "only the uncached instance is read")
+
+	_, err = repo.GetByIDs(ctx, []string{"i-2", "i-1"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.calls)
+
+	_, err = repo.GetByIDs(ctx, []string{"i-1", "i-3"})
+	assert.ErrorIs(t, err, repositories.ErrInstanceNotFound)
+}
+
+func TestInstanceRepository_FindRefreshesCache(t *testing.T) {
+	ctx := context.Background()
+	repo, next, _, _ := newCachedRepository(t)
+
+	prod, err := repo.Find(ctx, repositories.InstanceFilter{Tags: map[string]string{"Environment": "prod"}})
+	require.NoError(t, err)
+	require.Len(t, prod, 1)
+	_, err = repo.Find(ctx, repositories.InstanceFilter{Tags: map[string]string{"Environment": "prod"}})
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.calls, "listing always reads the decorated repository")
+
+	_, err = repo.GetByID(ctx, "i-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.calls, "instances found by a listing are cached")
+}
+
+func TestInstanceRepository_InvalidCacheFile(t *testing.T) {
+	ctx := context.Background()
+	repo, next, _, dir := newCachedRepository(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "i-1.json"), []byte("{"), 0o644))
+
+	instance, err := repo.GetByID(ctx, "i-1")
+
+	require.NoError(t, err)
+	assert.Equal(t, "t3.micro", instance.Type)
+	assert.Equal(t, 1, next.calls)
+}
+
+// partialRepository returns instances whose volumes could not be read
+type partialRepository struct {
+	*mock.InstanceRepository
+}
+
+func (r *partialRepository) GetByID(ctx context.Context, id string) (*models.Instance, error) {
+	instance, err := r.InstanceRepository.GetByID(ctx, id)
+	if err == nil {
+		instance.MarkUnavailable("ec2:DescribeVolumes", "RootVolumeSize")
+	}
+	return instance, err
+}
+
+func TestInstanceRepository_UnavailableFieldsNotCached(t *testing.T) {
+	next := &partialRepository{InstanceRepository: mock.NewInstanceRepository(&legacy.InstanceConfig{InstanceID: "i-1"})}
+	dir := t.TempDir()
+	repo, err := cache.NewInstanceRepository(next, dir)
+	require.NoError(t, err)
+
+	instance, err := repo.GetByID(context.Background(), "i-1")
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, instance.UnavailableFields)
+	assert.NoFileExists(t, filepath.Join(dir, "i-1.json"), "a cached copy would lose which fields are unavailable")
+}
+
+func TestInstanceRepository_Delete(t *testing.T) {
+	ctx := context.Background()
+	repo, _, _, dir := newCachedRepository(t)
+	_, err := repo.GetByID(ctx, "i-1")
+	require.NoError(t, err)
+
+	require.NoError(t, repo.Delete(ctx, "i-1"))
+
+	assert.NoFileExists(t, filepath.Join(dir, "i-1.json"))
+	_, err = repo.GetByID(ctx, "i-1")
+	assert.ErrorIs(t, err, repositories.ErrInstanceNotFound)
+}
+
+func TestNewInstanceRepository_InvalidTTL(t *testing.T) {
+	_, err := cache.NewInstanceRepository(mock.NewInstanceRepository(), t.TempDir(), cache.WithTTL(0))
+	assert.Error(t, err)
+}
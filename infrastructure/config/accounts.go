@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"gopkg.in/yaml.v3"
+)
+
+// Account is an AWS account scanned by assuming a role in it
+type Account struct {
+	ID         string `yaml:"id"`
+	RoleARN    string `yaml:"role_arn"`
+	ExternalID string `yaml:"external_id"`
+	// Region overrides the region of the run for this account
+	Region string `yaml:"region"`
+}
+
+// accountMapFile is the on-disk layout of an account map
+type accountMapFile struct {
+	Accounts []Account `yaml:"accounts"`
+}
+
+// LoadAccountMap reads the accounts listed in an account map file. An
+// account's ID defaults to the account of its role; an ID given explicitly
+// must match it.
+func LoadAccountMap(path string) ([]Account, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading account map: %w", err)
+	}
+
+	var file accountMapFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing account map: %w", err)
+	}
+	if len(file.Accounts) == 0 {
+		return nil, fmt.Errorf("account map %s lists no accounts", path)
+	}
+
+	seen := make(map[string]bool)
+	for i := range file.Accounts {
+		account := &file.Accounts[i]
+		if account.RoleARN == "" {
+			return nil, fmt.Errorf("account map entry %d has no role_arn", i+1)
+		}
+		role, err := arn.Parse(account.RoleARN)
+		if err != nil {
+			return nil, fmt.Errorf("account map entry %d: invalid role_arn %q: %w", i+1, account.RoleARN, err)
+		}
+		if account.ID == "" {
+			account.ID = role.AccountID
+		} else if account.ID != role.AccountID {
+			return nil, fmt.Errorf("account %s: role_arn %s belongs to account %s", account.ID, account.RoleARN, role.AccountID)
+		}
+		if seen[account.ID] {
+			return nil, fmt.Errorf("account %s is listed more than once", account.ID)
+		}
+		seen[account.ID] = true
+	}
+
+	return file.Accounts, nil
+}
@@ -0,0 +1,77 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/infrastructure/config"
+)
+
+func TestLoadAccountMap(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("valid map", func(t *testing.T) {
+		path := writeFile(t, dir, "accounts.yaml", `accounts:
+  - role_arn: arn:aws:iam::111111111111:role/drift-reader
+  - id: "222222222222"
+    role_arn: arn:aws:iam::222222222222:role/drift-reader
+    external_id: ext-2
+    region: eu-west-1
+`)
+
+		accounts, err := config.LoadAccountMap(path)
+
+		require.NoError(t, err)
+		assert.Equal(t, []config.Account{
+			{ID: "111111111111", RoleARN: "arn:aws:iam::111111111111:role/drift-reader"},
+			{ID: "222222222222", RoleARN: "arn:aws:iam::222222222222:role/drift-reader", ExternalID: "ext-2", Region: "eu-west-1"},
+		}, accounts)
+	})
+
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{
+			name:    "no accounts",
+			content: "accounts: []\n",
+			err:     "lists no accounts",
+		},
+		{
+			name:    "missing role",
+			content: "accounts:\n  - id: \"111111111111\"\n",
+			err:     "entry 1 has no role_arn",
+		},
+		{
+			name:    "invalid role",
+			content: "accounts:\n  - role_arn: drift-reader\n",
+			err:     "invalid role_arn",
+		},
+		{
+			name:    "role in another account",
+			content: "accounts:\n  - id: \"111111111111\"\n    role_arn: arn:aws:iam::222222222222:role/drift-reader\n",
+			err:     "belongs to account 222222222222",
+		},
+		{
+			name:    "duplicate account",
+			content: "accounts:\n  - role_arn: arn:aws:iam::111111111111:role/a\n  - role_arn: arn:aws:iam::111111111111:role/b\n",
+			err:     "listed more than once",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := config.LoadAccountMap(writeFile(t, dir, "invalid.yaml", tt.content))
+
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := config.LoadAccountMap(dir + "/missing.yaml")
+
+		assert.ErrorContains(t, err, "reading account map")
+	})
+}
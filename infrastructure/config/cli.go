@@ -0,0 +1,233 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"gopkg.in/yaml.v3"
+
+	"driftdetector/infrastructure/logger"
+)
+
+// CLIConfigFileName is the file CLI defaults are read from, in the working
+// directory or the home directory
+const CLIConfigFileName = ".driftdetector.yaml"
+
+// CLIEnvPrefix prefixes the environment variables that set CLI defaults,
+// e.g. DRIFTDETECTOR_REGION for the region key
+const CLIEnvPrefix = "DRIFTDETECTOR_"
+
+// NoCLIDefaultsAnnotation marks a flag that shares its name with a setting
+// but not its meaning, so neither the environment nor the config file set it
+const NoCLIDefaultsAnnotation = "driftdetector_no_cli_defaults"
+
+// cliSetting maps a config file key onto a flag
+type cliSetting struct {
+	key string
+	// flags are the names the flag goes by; commands define at most one
+	flags []string
+	// env lists variables read after DRIFTDETECTOR_<KEY>
+	env []string
+	// conflicts are flags that, once given, stop this key from applying
+	conflicts []string
+}
+
+// cliSettings are the keys accepted in the config file. A state source from
+// the file only applies when no other source was given.
+var cliSettings = []cliSetting{
+	{key: "region", flags: []string{"region"}, env: []string{"AWS_REGION", "AWS_DEFAULT_REGION"}},
+	{key: "profile", flags: []string{"profile"}, env: []string{"AWS_PROFILE"}},
+	{key: "output", flags: []string{"output"}},
+	{key: "tf_state", flags: []string{"state-file", "tf-state"}, conflicts: []string{"tf-dir", "tf-plan"}},
+	{key: "tf_dir", flags: []string{"tf-dir"}, conflicts: []string{"state-file", "tf-state", "tf-plan"}},
+	{key: "ignore", flags: []string{"ignore"}},
+	{key: "ignore_file", flags: []string{"ignore-file"}},
+	{key: "only", flags: []string{"only"}},
+	{key: "fail_on_drift", flags: []string{"fail-on-drift"}},
+	{key: "severity_config", flags: []string{"severity-config"}},
+	{key: "min_severity", flags: []string{"min-severity"}},
+	{key: "fail_on_severity", flags: []string{"fail-on-severity"}},
+	{key: "log_level", flags: []string{"log-level"}},
+	{key: "log_format", flags: []string{"log-format"}},
+	{key: "max_attempts", flags: []string{"max-attempts"}},
+	{key: "require_full_access", flags: []string{"require-full-access"}},
+	{key: "assume_role_arn", flags: []string{"assume-role-arn"}},
+	{key: "external_id", flags: []string{"external-id"}},
+	{key: "cache_dir", flags: []string{"cache-dir"}},
+	{key: "cache_ttl", flags: []string{"cache-ttl"}},
+}
+
+// CLIConfigKeys returns the keys accepted in the config file
+func CLIConfigKeys() []string {
+	keys := make([]string, len(cliSettings))
+	for i, s := range cliSettings {
+		keys[i] = s.key
+	}
+	return keys
+}
+
+// CLIConfig holds flag defaults read from a config file
+type CLIConfig struct {
+	Path   string
+	values map[string][]string
+}
+
+// FindCLIConfig returns the first CLIConfigFileName found in dirs, or "" when
+// there is none
+func FindCLIConfig(dirs ...string) string {
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		path := filepath.Join(dir, CLIConfigFileName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// LoadCLIConfig reads a config file of flag defaults. Values are scalars, or
+// lists for repeatable flags such as ignore. Unknown keys are logged as a
+// warning listing the accepted ones.
+func LoadCLIConfig(path string) (*CLIConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+
+	accepted := make(map[string]bool, len(cliSettings))
+	for _, s := range cliSettings {
+		accepted[s.key] = true
+	}
+
+	cfg := &CLIConfig{Path: path, values: make(map[string][]string)}
+	var unknown []string
+	for key, value := range raw {
+		if !accepted[key] {
+			unknown = append(unknown, key)
+			continue
+		}
+		values, err := configValues(value)
+		if err != nil {
+			return nil, fmt.Errorf("config file %s: %s: %w", path, key, err)
+		}
+		cfg.values[key] = values
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		logger.Warn("ignoring unknown keys in config file",
+			"path", path,
+			"keys", strings.Join(unknown, ", "),
+			"accepted", strings.Join(CLIConfigKeys(), ", "))
+	}
+	return cfg, nil
+}
+
+// configValues converts a YAML scalar or list of scalars to flag values
+func configValues(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case []interface{}, map[string]interface{}:
+				return nil, errors.New("list items must be scalars")
+			}
+			values = append(values, fmt.Sprint(item))
+		}
+		return values, nil
+	case map[string]interface{}:
+		return nil, errors.New("expected a value or a list, not a mapping")
+	default:
+		return []string{fmt.Sprint(v)}, nil
+	}
+}
+
+// ApplyCLIDefaults sets the flags that were not given on the command line,
+// from DRIFTDETECTOR_<KEY> and the other environment variables of each key
+// first, then from cfg, which may be nil. Values of repeatable flags are
+// separated by commas in the environment.
+func ApplyCLIDefaults(flags *pflag.FlagSet, cfg *CLIConfig, lookupEnv func(string) (string, bool)) error {
+	for _, setting := range cliSettings {
+		name := setting.flagName(flags)
+		if name == "" || flags.Changed(name) || anyChanged(flags, setting.conflicts) {
+			continue
+		}
+		if _, skip := flags.Lookup(name).Annotations[NoCLIDefaultsAnnotation]; skip {
+			continue
+		}
+
+		_, repeatable := flags.Lookup(name).Value.(pflag.SliceValue)
+		values, source := setting.envValues(lookupEnv, repeatable)
+		if values == nil && cfg != nil {
+			values, source = cfg.values[setting.key], cfg.Path
+		}
+		if len(values) > 1 && !repeatable {
+			return fmt.Errorf("%s from %s: expected a single value", setting.key, source)
+		}
+		for _, value := range values {
+			if err := flags.Set(name, value); err != nil {
+				return fmt.Errorf("%s from %s: %w", setting.key, source, err)
+			}
+		}
+	}
+	return nil
+}
+
+// flagName returns the name the setting's flag goes by in flags, or "" when
+// the command has no such flag
+func (s cliSetting) flagName(flags *pflag.FlagSet) string {
+	for _, name := range s.flags {
+		if flags.Lookup(name) != nil {
+			return name
+		}
+	}
+	return ""
+}
+
+// envValues returns the value of the first of the setting's environment
+// variables that is set, split on commas for repeatable flags, and its name
+func (s cliSetting) envValues(lookupEnv func(string) (string, bool), repeatable bool) ([]string, string) {
+	names := append([]string{CLIEnvPrefix + strings.ToUpper(s.key)}, s.env...)
+	for _, name := range names {
+		value, ok := lookupEnv(name)
+		if !ok || value == "" {
+			continue
+		}
+		if !repeatable {
+			return []string{value}, "$" + name
+		}
+		var values []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				values = append(values, item)
+			}
+		}
+		return values, "$" + name
+	}
+	return nil, ""
+}
+
+// anyChanged reports whether any of the named flags was set
+func anyChanged(flags *pflag.FlagSet, names []string) bool {
+	for _, name := range names {
+		if flags.Lookup(name) != nil && flags.Changed(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package config_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/infrastructure/config"
+	"driftdetector/infrastructure/logger"
+)
+
+// detectFlags returns flags shaped like those of detect-ddd, parsed from args
+func detectFlags(t *testing.T, args ...string) *pflag.FlagSet {
+	t.Helper()
+	flags := pflag.NewFlagSet("detect", pflag.ContinueOnError)
+	flags.StringP("region", "r", "", "")
+	flags.StringP("output", "o", "text", "")
+	flags.StringP("state-file", "s", "", "")
+	flags.StringP("tf-dir", "d", "", "")
+	flags.StringArray("ignore", nil, "")
+	flags.Bool("fail-on-drift", false, "")
+	flags.String("min-severity", "INFO", "")
+	require.NoError(t, flags.Parse(args))
+	return flags
+}
+
+// env returns a lookup function over vars
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+}
+
+const cliConfig = `region: eu-west-1
+output: json
+tf_state: prod.tfstate
+ignore:
+  - PublicIPAddress
+  - Tags[aws:*]
+fail_on_drift: true
+min_severity: WARNING
+`
+
+func TestApplyCLIDefaults(t *testing.T) {
+	dir := t.TempDir()
+	cfg, err := config.LoadCLIConfig(writeFile(t, dir, config.CLIConfigFileName, cliConfig))
+	require.NoError(t, err)
+
+	t.Run("config file fills unset flags", func(t *testing.T) {
+		flags := detectFlags(t)
+
+		require.NoError(t, config.ApplyCLIDefaults(flags, cfg, env(nil)))
+
+		region, _ := flags.GetString("region")
+		output, _ := flags.GetString("output")
+		state, _ := flags.GetString("state-file")
+		ignore, _ := flags.GetStringArray("ignore")
+		failOnDrift, _ := flags.GetBool("fail-on-drift")
+		minSeverity, _ := flags.GetString("min-severity")
+		assert.Equal(t, "eu-west-1", region)
+		assert.Equal(t, "json", output)
+		assert.Equal(t, "prod.tfstate", state)
+		assert.Equal(t, []string{"PublicIPAddress", "Tags[aws:*]"}, ignore)
+		assert.True(t, failOnDrift)
+		assert.Equal(t, "WARNING", minSeverity)
+	})
+
+	t.Run("flag beats environment beats config file", func(t *testing.T) {
+		flags := detectFlags(t, "--output", "yaml")
+
+		require.NoError(t, config.ApplyCLIDefaults(flags, cfg, env(map[string]string{
+			"DRIFTDETECTOR_OUTPUT": "markdown",
+			"AWS_REGION":           "us-east-2",
+			"DRIFTDETECTOR_IGNORE": "AMI, Tags.Owner",
+		})))
+
+		output, _ := flags.GetString("output")
+		region, _ := flags.GetString("region")
+		ignore, _ := flags.GetStringArray("ignore")
+		assert.Equal(t, "yaml", output)
+		assert.Equal(t, "us-east-2", region)
+		assert.Equal(t, []string{"AMI", "Tags.Owner"}, ignore)
+	})
+
+	t.Run("explicit ignore replaces the configured list", func(t *testing.T) {
+		flags := detectFlags(t, "--ignore", "KeyName")
+
+		require.NoError(t, config.ApplyCLIDefaults(flags, cfg, env(nil)))
+
+		ignore, _ := flags.GetStringArray("ignore")
+		assert.Equal(t, []string{"KeyName"}, ignore)
+	})
+
+	t.Run("another state source skips the configured one", func(t *testing.T) {
+		flags := detectFlags(t, "--tf-dir", "./infra")
+
+		require.NoError(t, config.ApplyCLIDefaults(flags, cfg, env(nil)))
+
+		assert.False(t, flags.Changed("state-file"))
+	})
+
+	t.Run("environment without a config file", func(t *testing.T) {
+		flags := detectFlags(t)
+
+		require.NoError(t, config.ApplyCLIDefaults(flags, nil, env(map[string]string{"DRIFTDETECTOR_REGION": "ap-south-1"})))
+
+		region, _ := flags.GetString("region")
+		output, _ := flags.GetString("output")
+		assert.Equal(t, "ap-south-1", region)
+		assert.Equal(t, "text", output)
+	})
+
+	t.Run("annotated flag is left alone", func(t *testing.T) {
+		flags := detectFlags(t)
+		require.NoError(t, flags.SetAnnotation("output", config.NoCLIDefaultsAnnotation, []string{"true"}))
+
+		require.NoError(t, config.ApplyCLIDefaults(flags, cfg, env(map[string]string{"DRIFTDETECTOR_OUTPUT": "yaml"})))
+
+		assert.False(t, flags.Changed("output"))
+	})
+
+	t.Run("invalid value names its source", func(t *testing.T) {
+		bad, err := config.LoadCLIConfig(writeFile(t, dir, "bad.yaml", "fail_on_drift: sometimes\n"))
+		require.NoError(t, err)
+
+		err = config.ApplyCLIDefaults(detectFlags(t), bad, env(nil))
+
+		assert.ErrorContains(t, err, "fail_on_drift from "+bad.Path)
+	})
+
+	t.Run("list for a single-valued flag", func(t *testing.T) {
+		bad, err := config.LoadCLIConfig(writeFile(t, dir, "list.yaml", "region: [us-east-1, us-west-2]\n"))
+		require.NoError(t, err)
+
+		err = config.ApplyCLIDefaults(detectFlags(t), bad, env(nil))
+
+		assert.ErrorContains(t, err, "expected a single value")
+	})
+}
+
+func TestLoadCLIConfig_UnknownKeys(t *testing.T) {
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	t.Cleanup(func() { logger.SetOutput(os.Stderr) })
+
+	cfg, err := config.LoadCLIConfig(writeFile(t, t.TempDir(), "config.yaml", "region: eu-west-1\nregoin: us-east-1\nformat: json\n"))
+
+	require.NoError(t, err)
+	assert.NotNil(t, cfg)
+	assert.Contains(t, buf.String(), `keys="format, regoin"`)
+	assert.Contains(t, buf.String(), "region, profile, output, tf_state")
+}
+
+func TestFindCLIConfig(t *testing.T) {
+	work, home := t.TempDir(), t.TempDir()
+	homeConfig := writeFile(t, home, config.CLIConfigFileName, "region: eu-west-1\n")
+
+	assert.Equal(t, homeConfig, config.FindCLIConfig(work, home))
+
+	workConfig := writeFile(t, work, config.CLIConfigFileName, "region: us-east-1\n")
+	assert.Equal(t, workConfig, config.FindCLIConfig(work, home), "the working directory comes first")
+
+	assert.Empty(t, config.FindCLIConfig(t.TempDir()))
+}
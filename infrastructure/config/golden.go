@@ -0,0 +1,117 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"driftdetector/domain/models"
+	"gopkg.in/yaml.v3"
+)
+
+// goldenConfigFile is the on-disk layout of the golden template configuration
+type goldenConfigFile struct {
+	GoldenTemplates []goldenTemplateEntry `yaml:"golden_templates"`
+}
+
+// goldenTemplateEntry references a template file and the instances it applies to
+type goldenTemplateEntry struct {
+	Name     string                `yaml:"name"`
+	Template string                `yaml:"template"`
+	Selector models.GoldenSelector `yaml:"selector"`
+}
+
+// LoadGoldenTemplates reads a golden template configuration file. Template
+// paths are resolved relative to the configuration file. Templates whose
+// selectors could match the same instance are rejected.
+func LoadGoldenTemplates(path string) ([]*models.GoldenTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading golden config: %w", err)
+	}
+
+	var file goldenConfigFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing golden config: %w", err)
+	}
+
+	baseDir := filepath.Dir(path)
+	seen := make(map[string]bool)
+	var templates []*models.GoldenTemplate
+
+	for _, entry := range file.GoldenTemplates {
+		if entry.Name == "" {
+			return nil, fmt.Errorf("golden template without a name")
+		}
+		if seen[entry.Name] {
+			return nil, fmt.Errorf("duplicate golden template %q", entry.Name)
+		}
+		seen[entry.Name] = true
+
+		if entry.Selector.IsEmpty() {
+			return nil, fmt.Errorf("golden template %q has an empty selector", entry.Name)
+		}
+
+		templatePath := entry.Template
+		if !filepath.IsAbs(templatePath) {
+			templatePath = filepath.Join(baseDir, templatePath)
+		}
+
+		config, fields, err := loadTemplateFile(templatePath)
+		if err != nil {
+			return nil, fmt.Errorf("loading golden template %q: %w", entry.Name, err)
+		}
+
+		templates = append(templates, &models.GoldenTemplate{
+			Name:     entry.Name,
+			Selector: entry.Selector,
+			Config:   config,
+			Fields:   fields,
+		})
+	}
+
+	for i := range templates {
+		for j := i + 1; j < len(templates); j++ {
+			if templates[i].Selector.Overlaps(templates[j].Selector) {
+				return nil, fmt.Errorf("golden templates %q and %q have overlapping selectors", templates[i].Name, templates[j].Name)
+			}
+		}
+	}
+
+	return templates, nil
+}
+
+// loadTemplateFile reads a JSON or YAML instance template and returns the
+// parsed instance together with the field names it declares
+func loadTemplateFile(path string) (*models.Instance, []string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// YAML is a superset of JSON, so one decoder handles both formats
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, nil, fmt.Errorf("parsing template: %w", err)
+	}
+
+	fields := make([]string, 0, len(raw))
+	for k := range raw {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+
+	normalized, err := json.Marshal(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("normalizing template: %w", err)
+	}
+
+	var instance models.Instance
+	if err := json.Unmarshal(normalized, &instance); err != nil {
+		return nil, nil, fmt.Errorf("decoding template: %w", err)
+	}
+
+	return &instance, fields, nil
+}
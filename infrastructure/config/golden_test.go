@@ -0,0 +1,97 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/infrastructure/config"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadGoldenTemplates(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "batch.json", `{
+  "ami": "ami-batch",
+  "security_groups": [{"id": "sg-batch"}],
+  "tags": {"Role": "batch"}
+}`)
+	writeFile(t, dir, "web.yaml", "instance_type: t3.micro\n")
+
+	t.Run("valid configuration", func(t *testing.T) {
+		path := writeFile(t, dir, "golden.yaml", `golden_templates:
+  - name: batch
+    template: batch.json
+    selector:
+      tags:
+        Role: batch
+  - name: web
+    template: web.yaml
+    selector:
+      name_pattern: "web-*"
+      tags:
+        Role: web
+`)
+
+		templates, err := config.LoadGoldenTemplates(path)
+		require.NoError(t, err)
+		require.Len(t, templates, 2)
+
+		assert.Equal(t, "batch", templates[0].Name)
+		assert.Equal(t, []string{"ami", "security_groups", "tags"}, templates[0].Fields)
+		assert.Equal(t, "ami-batch", templates[0].Config.AMI)
+		assert.Equal(t, "sg-batch", templates[0].Config.SecurityGroups[0].GroupID)
+
+		assert.Equal(t, []string{"instance_type"}, templates[1].Fields)
+		assert.Equal(t, "t3.micro", templates[1].Config.Type)
+	})
+
+	t.Run("overlapping selectors are rejected", func(t *testing.T) {
+		path := writeFile(t, dir, "overlap.yaml", `golden_templates:
+  - name: batch
+    template: batch.json
+    selector:
+      tags:
+        Role: batch
+  - name: batch-large
+    template: web.yaml
+    selector:
+      name_pattern: "batch-large-*"
+`)
+
+		_, err := config.LoadGoldenTemplates(path)
+		assert.ErrorContains(t, err, "overlapping selectors")
+	})
+
+	t.Run("empty selector is rejected", func(t *testing.T) {
+		path := writeFile(t, dir, "empty.yaml", `golden_templates:
+  - name: everything
+    template: batch.json
+`)
+
+		_, err := config.LoadGoldenTemplates(path)
+		assert.ErrorContains(t, err, "empty selector")
+	})
+
+	t.Run("missing template file", func(t *testing.T) {
+		path := writeFile(t, dir, "missing.yaml", `golden_templates:
+  - name: ghost
+    template: ghost.json
+    selector:
+      tags:
+        Role: ghost
+`)
+
+		_, err := config.LoadGoldenTemplates(path)
+		assert.ErrorContains(t, err, "ghost")
+	})
+}
@@ -0,0 +1,27 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/infrastructure/config"
+)
+
+func TestLoadIgnoreFile(t *testing.T) {
+	path := writeFile(t, t.TempDir(), ".driftignore", `# addresses change on every restart
+PublicIPAddress
+
+PrivateIPAddress
+  Tags[aws:*]  
+`)
+
+	paths, err := config.LoadIgnoreFile(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PublicIPAddress", "PrivateIPAddress", "Tags[aws:*]"}, paths)
+
+	_, err = config.LoadIgnoreFile("/non/existent/.driftignore")
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"os"
+
+	"driftdetector/domain/models"
+	"gopkg.in/yaml.v3"
+)
+
+// severityConfigFile is the on-disk layout of the severity configuration
+type severityConfigFile struct {
+	Severities []models.SeverityRule `yaml:"severities"`
+}
+
+// LoadSeverityConfig reads severity rules that override the default
+// classification. Each rule names a drift path prefix and a severity.
+func LoadSeverityConfig(path string) ([]models.SeverityRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading severity config: %w", err)
+	}
+
+	var file severityConfigFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing severity config: %w", err)
+	}
+
+	rules := make([]models.SeverityRule, 0, len(file.Severities))
+	for _, rule := range file.Severities {
+		if rule.Path == "" {
+			return nil, fmt.Errorf("severity rule without a path")
+		}
+		severity, err := models.ParseSeverity(string(rule.Severity))
+		if err != nil {
+			return nil, fmt.Errorf("severity rule for %s: %w", rule.Path, err)
+		}
+		rules = append(rules, models.SeverityRule{Path: rule.Path, Severity: severity})
+	}
+
+	return rules, nil
+}
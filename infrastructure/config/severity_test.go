@@ -0,0 +1,40 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/config"
+)
+
+func TestLoadSeverityConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("valid rules", func(t *testing.T) {
+		path := writeFile(t, dir, "severity.yaml", `severities:
+  - path: Tags.Owner
+    severity: warning
+  - path: AMI
+    severity: CRITICAL
+`)
+
+		rules, err := config.LoadSeverityConfig(path)
+
+		require.NoError(t, err)
+		assert.Equal(t, []models.SeverityRule{
+			{Path: "Tags.Owner", Severity: models.SeverityWarning},
+			{Path: "AMI", Severity: models.SeverityCritical},
+		}, rules)
+	})
+
+	t.Run("unknown severity", func(t *testing.T) {
+		path := writeFile(t, dir, "bad.yaml", "severities:\n  - path: AMI\n    severity: urgent\n")
+
+		_, err := config.LoadSeverityConfig(path)
+
+		assert.ErrorContains(t, err, "unknown severity")
+	})
+}
@@ -0,0 +1,172 @@
+// Package logger provides the diagnostic logger shared by the CLI and the
+// infrastructure adapters. It writes to stderr so stdout carries only reports.
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Format selects how log entries are written
+type Format string
+
+const (
+	// FormatText writes key=value lines without timestamps, for terminals
+	FormatText Format = "text"
+	// FormatJSON writes one JSON object per line with timestamp, level,
+	// caller, msg and the entry's attributes, for log pipelines
+	FormatJSON Format = "json"
+)
+
+// level is the minimum level logged, shared by every handler this package creates
+var level = func() *slog.LevelVar {
+	v := new(slog.LevelVar)
+	v.Set(slog.LevelWarn)
+	return v
+}()
+
+// current is the logger used by the package-level functions
+var current atomic.Pointer[slog.Logger]
+
+// output and format are what current was built from
+var (
+	mu     sync.Mutex
+	output io.Writer = os.Stderr
+	format           = FormatText
+)
+
+func init() {
+	SetOutput(os.Stderr)
+}
+
+// ParseLevel parses a level name (debug, info, warn, error)
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q (expected debug, info, warn or error)", name)
+	}
+}
+
+// ParseFormat parses a format name (text, json)
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(name))); f {
+	case FormatText, FormatJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown log format %q (expected text or json)", name)
+	}
+}
+
+// SetLevel sets the minimum level logged
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+// SetOutput directs log output to w
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	output = w
+	current.Store(slog.New(newHandler(output, format)))
+}
+
+// SetFormat sets how log entries are written
+func SetFormat(f Format) {
+	mu.Lock()
+	defer mu.Unlock()
+	format = f
+	current.Store(slog.New(newHandler(output, format)))
+}
+
+// newHandler creates the handler writing entries to w in format f
+func newHandler(w io.Writer, f Format) slog.Handler {
+	if f == FormatJSON {
+		return slog.NewJSONHandler(w, &slog.HandlerOptions{
+			Level:     level,
+			AddSource: true,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) > 0 {
+					return a
+				}
+				switch a.Key {
+				case slog.TimeKey:
+					a.Key = "timestamp"
+				case slog.SourceKey:
+					// file:line, with the package directory to tell apart
+					// files of the same name
+					if src, ok := a.Value.Any().(*slog.Source); ok {
+						file := filepath.Join(filepath.Base(filepath.Dir(src.File)), filepath.Base(src.File))
+						return slog.String("caller", filepath.ToSlash(file)+":"+strconv.Itoa(src.Line))
+					}
+				}
+				return a
+			},
+		})
+	}
+
+	return slog.NewTextHandler(w, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			// Timestamps add noise to CLI output
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+}
+
+// log writes an entry attributed to the caller of the package-level
+// function, rather than to this package
+func log(l slog.Level, msg string, args ...any) {
+	logger := current.Load()
+	ctx := context.Background()
+	if !logger.Enabled(ctx, l) {
+		return
+	}
+
+	var pcs [1]uintptr
+	// Skip runtime.Callers, log and the package-level function
+	runtime.Callers(3, pcs[:])
+	record := slog.NewRecord(time.Now(), l, msg, pcs[0])
+	record.Add(args...)
+	_ = logger.Handler().Handle(ctx, record)
+}
+
+// Debug logs a diagnostic message
+func Debug(msg string, args ...any) {
+	log(slog.LevelDebug, msg, args...)
+}
+
+// Info logs an informational message
+func Info(msg string, args ...any) {
+	log(slog.LevelInfo, msg, args...)
+}
+
+// Warn logs a problem that did not stop the command
+func Warn(msg string, args ...any) {
+	log(slog.LevelWarn, msg, args...)
+}
+
+// Error logs a failure
+func Error(msg string, args ...any) {
+	log(slog.LevelError, msg, args...)
+}
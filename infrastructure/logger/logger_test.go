@@ -0,0 +1,103 @@
+package logger_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/infrastructure/logger"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected slog.Level
+	}{
+		{"debug", slog.LevelDebug},
+		{"INFO", slog.LevelInfo},
+		{"warn", slog.LevelWarn},
+		{"warning", slog.LevelWarn},
+		{"error", slog.LevelError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, err := logger.ParseLevel(tt.name)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, level)
+		})
+	}
+
+	_, err := logger.ParseLevel("trace")
+	assert.Error(t, err)
+}
+
+func TestSetLevel(t *testing.T) {
+	var out bytes.Buffer
+	logger.SetOutput(&out)
+	t.Cleanup(func() {
+		logger.SetOutput(os.Stderr)
+		logger.SetLevel(slog.LevelWarn)
+	})
+
+	logger.SetLevel(slog.LevelWarn)
+	logger.Debug("hidden")
+	logger.Warn("shown", "instance", "i-1")
+	assert.NotContains(t, out.String(), "hidden")
+	assert.Contains(t, out.String(), "level=WARN msg=shown instance=i-1")
+	assert.NotContains(t, out.String(), "time=")
+
+	logger.SetLevel(slog.LevelDebug)
+	logger.Debug("now shown")
+	assert.Contains(t, out.String(), "level=DEBUG msg=\"now shown\"")
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := logger.ParseFormat("JSON")
+	require.NoError(t, err)
+	assert.Equal(t, logger.FormatJSON, format)
+
+	_, err = logger.ParseFormat("logfmt")
+	assert.Error(t, err)
+}
+
+func TestSetFormat_JSON(t *testing.T) {
+	var out bytes.Buffer
+	logger.SetOutput(&out)
+	logger.SetFormat(logger.FormatJSON)
+	t.Cleanup(func() {
+		logger.SetFormat(logger.FormatText)
+		logger.SetOutput(os.Stderr)
+		logger.SetLevel(slog.LevelWarn)
+	})
+	logger.SetLevel(slog.LevelDebug)
+
+	logger.Debug("parsed state", "path", "prod.tfstate", "bytes", 512)
+	logger.Warn("skipping instance resource", "error", "value \"quoted\"\nand a second line")
+
+	var entries []map[string]any
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry), "each line is a JSON object: %s", scanner.Text())
+		entries = append(entries, entry)
+	}
+	require.Len(t, entries, 2, "each entry is a single line")
+
+	first := entries[0]
+	assert.Equal(t, "DEBUG", first["level"])
+	assert.Equal(t, "parsed state", first["msg"])
+	assert.Equal(t, "prod.tfstate", first["path"], "attributes are top-level properties")
+	assert.Equal(t, float64(512), first["bytes"])
+	assert.NotEmpty(t, first["timestamp"])
+	assert.True(t, strings.HasPrefix(first["caller"].(string), "logger/logger_test.go:"), "caller is the code that logged: %v", first["caller"])
+
+	assert.Equal(t, "value \"quoted\"\nand a second line", entries[1]["error"], "quotes and newlines survive escaping")
+}
@@ -0,0 +1,75 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"driftdetector/infrastructure/awsutil"
+	legacy "driftdetector/models"
+)
+
+// describeInstancesOutput is the part of `aws ec2 describe-instances` output
+// a mock file may hold. The SDK types decode the CLI's JSON as is, since
+// encoding/json matches field names case-insensitively.
+type describeInstancesOutput struct {
+	Reservations []struct {
+		Instances []types.Instance
+	}
+}
+
+// isAWSInstanceDocument reports whether data holds describe-instances output,
+// or a single instance from it, rather than an instance configuration
+func isAWSInstanceDocument(data []byte) bool {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return false
+	}
+	_, reservations := keys["Reservations"]
+	_, instanceID := keys["InstanceId"]
+	return reservations || instanceID
+}
+
+// decodeAWSInstance decodes describe-instances output holding exactly one
+// instance, or that instance alone, into an instance configuration
+func decodeAWSInstance(data []byte) (*legacy.InstanceConfig, error) {
+	configs, err := decodeAWSInstances(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(configs) != 1 {
+		return nil, fmt.Errorf(": describe-instances output must hold exactly one instance, found %d", len(configs))
+	}
+	return configs[0], nil
+}
+
+// decodeAWSInstances decodes describe-instances output, or a single instance
+// from it, into instance configurations. Fields the tool does not read, such
+// as AmiLaunchIndex, are ignored.
+func decodeAWSInstances(data []byte) ([]*legacy.InstanceConfig, error) {
+	var output describeInstancesOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, positionedError(data, err)
+	}
+
+	var instances []types.Instance
+	for _, reservation := range output.Reservations {
+		instances = append(instances, reservation.Instances...)
+	}
+	if len(output.Reservations) == 0 {
+		var instance types.Instance
+		if err := json.Unmarshal(data, &instance); err != nil {
+			return nil, positionedError(data, err)
+		}
+		instances = append(instances, instance)
+	}
+
+	configs := make([]*legacy.InstanceConfig, 0, len(instances))
+	for _, instance := range instances {
+		var config legacy.InstanceConfig
+		awsutil.ConvertInstance(instance, awsutil.NewInstanceConfigSetter(&config))
+		configs = append(configs, &config)
+	}
+	return configs, nil
+}
@@ -0,0 +1,273 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	legacy "driftdetector/models"
+)
+
+// Format is the encoding of a mock file
+type Format string
+
+const (
+	// FormatJSON is the default encoding, written by snapshot
+	FormatJSON Format = "json"
+	// FormatYAML is YAML, whose comments and anchors suit hand-kept fixtures
+	FormatYAML Format = "yaml"
+)
+
+// ParseFormat returns the format called name: json, or yaml or yml
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "json":
+		return FormatJSON, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	default:
+		return "", fmt.Errorf("unknown mock format %q: expected json or yaml", name)
+	}
+}
+
+// FormatOf returns the format of the file at path by its extension: .yaml
+// and .yml files are YAML, any other file JSON
+func FormatOf(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	default:
+		return FormatJSON
+	}
+}
+
+// Extension returns the file extension written for the format
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// decodeError is an error decoding a mock file, at the line and column it
+// refers to when known
+type decodeError struct {
+	line, column int
+	// field is the key the error concerns, if known, so that it can be found
+	// in the YAML a JSON document was converted from
+	field string
+	err   error
+}
+
+// Error starts with the position, e.g. ":3:5: unknown field", to follow the
+// file name
+func (e *decodeError) Error() string {
+	if e.line == 0 {
+		return ": " + e.err.Error()
+	}
+	return fmt.Sprintf(":%d:%d: %v", e.line, e.column, e.err)
+}
+
+// Unwrap returns the underlying error
+func (e *decodeError) Unwrap() error {
+	return e.err
+}
+
+// fieldTypeError is a field whose value has the wrong type
+type fieldTypeError struct {
+	field, format, value, typ string
+}
+
+// Error names the field and the kind of value found
+func (e *fieldTypeError) Error() string {
+	return fmt.Sprintf("field %q: cannot use a %s %s as %s", e.field, e.format, e.value, e.typ)
+}
+
+// decodeYAMLInstanceConfigs decodes a YAML document holding an instance
+// configuration, a sequence of them, or describe-instances output. Each
+// element is converted to JSON and decoded as a JSON file would be, so tags
+// are normalized and unknown fields rejected the same way; errors give the
+// line and column of the YAML.
+func decodeYAMLInstanceConfigs(data []byte) ([]*legacy.InstanceConfig, error) {
+	root, err := yamlDocument(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if root.Kind != yaml.SequenceNode {
+		jsonData, err := yamlToJSON(root)
+		if err != nil {
+			return nil, err
+		}
+		if isAWSInstanceDocument(jsonData) {
+			configs, err := decodeAWSInstances(jsonData)
+			if err != nil {
+				return nil, yamlPositionedError(root, err)
+			}
+			return configs, nil
+		}
+	}
+
+	elements := []*yaml.Node{root}
+	if root.Kind == yaml.SequenceNode {
+		elements = root.Content
+	}
+	configs := make([]*legacy.InstanceConfig, 0, len(elements))
+	for _, node := range elements {
+		config, err := decodeYAMLInstanceConfig(node)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	return configs, nil
+}
+
+// decodeYAMLInstanceConfig decodes a YAML node holding one instance
+// configuration, or describe-instances output with exactly one instance
+func decodeYAMLInstanceConfig(node *yaml.Node) (*legacy.InstanceConfig, error) {
+	jsonData, err := yamlToJSON(node)
+	if err != nil {
+		return nil, err
+	}
+	config, err := decodeInstanceConfig(jsonData)
+	if err != nil {
+		return nil, yamlPositionedError(node, err)
+	}
+	return config, nil
+}
+
+// yamlDocument parses data as a single YAML document and returns its root
+func yamlDocument(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, &decodeError{err: err}
+	}
+	if len(doc.Content) == 0 {
+		return nil, &decodeError{err: errors.New("empty YAML document")}
+	}
+	return doc.Content[0], nil
+}
+
+// yamlToJSON converts a YAML node, with its aliases and merge keys resolved,
+// to JSON
+func yamlToJSON(node *yaml.Node) ([]byte, error) {
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return nil, &decodeError{line: node.Line, column: node.Column, err: err}
+	}
+	data, err := json.Marshal(jsonValue(value))
+	if err != nil {
+		return nil, &decodeError{line: node.Line, column: node.Column, err: err}
+	}
+	return data, nil
+}
+
+// jsonValue returns value with the keys of its mappings converted to
+// strings, as JSON requires; YAML reads a key such as 2024 as a number
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, elem := range v {
+			v[key] = jsonValue(elem)
+		}
+		return v
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, elem := range v {
+			m[fmt.Sprint(key)] = jsonValue(elem)
+		}
+		return m
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = jsonValue(elem)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// yamlPositionedError gives an error decoding the JSON converted from node
+// the position in the YAML of the field it concerns. Positions in the
+// converted JSON mean nothing to the reader, so they are dropped otherwise.
+func yamlPositionedError(node *yaml.Node, err error) error {
+	var decodeErr *decodeError
+	if !errors.As(err, &decodeErr) {
+		return err
+	}
+
+	inner := decodeErr.err
+	var typeErr *fieldTypeError
+	if errors.As(inner, &typeErr) {
+		yamlTypeErr := *typeErr
+		yamlTypeErr.format = "YAML"
+		inner = &yamlTypeErr
+	}
+
+	located := &decodeError{field: decodeErr.field, err: inner}
+	if key := findYAMLKey(node, decodeErr.field); key != nil {
+		located.line, located.column = key.Line, key.Column
+	}
+	return located
+}
+
+// findYAMLKey returns the first mapping key called field under node, in
+// document order, or nil
+func findYAMLKey(node *yaml.Node, field string) *yaml.Node {
+	if node == nil || field == "" {
+		return nil
+	}
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == field {
+				return node.Content[i]
+			}
+			if key := findYAMLKey(node.Content[i+1], field); key != nil {
+				return key
+			}
+		}
+		return nil
+	}
+	for _, child := range node.Content {
+		if key := findYAMLKey(child, field); key != nil {
+			return key
+		}
+	}
+	return nil
+}
+
+// encodeYAML writes v to w as YAML, with the fields in the order
+// encoding/json writes them
+func encodeYAML(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// JSON is YAML, so it parses into nodes keeping the field order; its
+	// flow style and quoting are then left to the encoder
+	var doc yaml.Node
+	if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		return err
+	}
+	clearYAMLStyle(&doc)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// clearYAMLStyle resets the style of node and its children to the default
+func clearYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		clearYAMLStyle(child)
+	}
+}
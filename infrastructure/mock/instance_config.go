@@ -0,0 +1,371 @@
+// Package mock reads and writes instance configurations captured from AWS, so
+// drift can be detected against a file instead of a live instance
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	legacy "driftdetector/models"
+)
+
+// LoadInstanceConfig reads an instance configuration file written by
+// WriteInstanceConfig or by hand, as YAML if its extension is .yaml or .yml
+// and JSON otherwise. Tags may be a map or AWS's Key/Value list. Fields the
+// configuration does not have are rejected, naming the field and its
+// position, and the result is checked with ValidateInstanceConfig. The output
+// of `aws ec2 describe-instances` for a single instance is accepted too.
+func LoadInstanceConfig(path string) (*legacy.InstanceConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading mock file: %w", err)
+	}
+
+	var config *legacy.InstanceConfig
+	if FormatOf(path) == FormatYAML {
+		var root *yaml.Node
+		if root, err = yamlDocument(data); err == nil {
+			config, err = decodeYAMLInstanceConfig(root)
+		}
+	} else {
+		config, err = decodeInstanceConfig(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mock file %s%w", path, err)
+	}
+	if err := ValidateInstanceConfig(config); err != nil {
+		return nil, fmt.Errorf("mock file %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// LoadInstanceConfigs reads the instance configurations at path: a file
+// holding one of them, an array of them or describe-instances output with
+// any number of instances, or a directory whose *.json, *.yaml and *.yml
+// files hold one instance each, read in lexical order. Files are read in the
+// format of their extension, as by LoadInstanceConfig. Every configuration is
+// checked as by LoadInstanceConfig, and two instances with the same ID are
+// rejected.
+func LoadInstanceConfigs(path string) ([]*legacy.InstanceConfig, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading mock file: %w", err)
+	}
+
+	var configs []*legacy.InstanceConfig
+	if info.IsDir() {
+		configs, err = loadInstanceConfigDir(path)
+	} else {
+		var data []byte
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("reading mock file: %w", err)
+		}
+		configs, err = decodeInstanceConfigFile(path, data, FormatOf(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return configs, checkUniqueInstances(path, configs)
+}
+
+// ReadInstanceConfigs reads instance configurations in the given format from
+// r, such as stdin, as LoadInstanceConfigs reads a file. name stands for the
+// input in errors.
+func ReadInstanceConfigs(r io.Reader, name string, format Format) ([]*legacy.InstanceConfig, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading mock file: %w", err)
+	}
+	configs, err := decodeInstanceConfigFile(name, data, format)
+	if err != nil {
+		return nil, err
+	}
+	return configs, checkUniqueInstances(name, configs)
+}
+
+// checkUniqueInstances rejects two configurations of the same instance
+func checkUniqueInstances(name string, configs []*legacy.InstanceConfig) error {
+	seen := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		if seen[config.InstanceID] {
+			return fmt.Errorf("mock file %s: instance %s appears more than once", name, config.InstanceID)
+		}
+		seen[config.InstanceID] = true
+	}
+	return nil
+}
+
+// loadInstanceConfigDir reads the JSON and YAML files directly inside dir,
+// one instance each
+func loadInstanceConfigDir(dir string) ([]*legacy.InstanceConfig, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading mock directory: %w", err)
+	}
+
+	var configs []*legacy.InstanceConfig
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		config, err := LoadInstanceConfig(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("mock directory %s holds no .json, .yaml or .yml files", dir)
+	}
+	return configs, nil
+}
+
+// decodeInstanceConfigFile decodes the contents of the file named path,
+// holding one or more instances
+func decodeInstanceConfigFile(path string, data []byte, format Format) ([]*legacy.InstanceConfig, error) {
+	var configs []*legacy.InstanceConfig
+	var err error
+	switch {
+	case format == FormatYAML:
+		configs, err = decodeYAMLInstanceConfigs(data)
+	case bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")):
+		configs, err = decodeInstanceConfigArray(data)
+	case isAWSInstanceDocument(data):
+		configs, err = decodeAWSInstances(data)
+	default:
+		var config *legacy.InstanceConfig
+		config, err = decodeInstanceConfig(data)
+		configs = []*legacy.InstanceConfig{config}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("mock file %s%w", path, err)
+	}
+
+	for i, config := range configs {
+		if err := ValidateInstanceConfig(config); err != nil {
+			if len(configs) == 1 {
+				return nil, fmt.Errorf("mock file %s: %w", path, err)
+			}
+			return nil, fmt.Errorf("mock file %s: instance %d: %w", path, i+1, err)
+		}
+	}
+	return configs, nil
+}
+
+// decodeInstanceConfigArray decodes a JSON array of instance configurations.
+// Each element is decoded in place of the text before it, blanked out, so
+// errors give the line and column in the whole file.
+func decodeInstanceConfigArray(data []byte) ([]*legacy.InstanceConfig, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return nil, positionedError(data, err)
+	}
+
+	var configs []*legacy.InstanceConfig
+	for dec.More() {
+		var element json.RawMessage
+		if err := dec.Decode(&element); err != nil {
+			return nil, positionedError(data, err)
+		}
+		end := int(dec.InputOffset())
+		start := end - len(element)
+
+		blanked := make([]byte, start, start+len(element))
+		for i, b := range data[:start] {
+			blanked[i] = ' '
+			if b == '\n' {
+				blanked[i] = b
+			}
+		}
+		config, err := decodeInstanceConfig(append(blanked, element...))
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, positionedError(data, err)
+	}
+	return configs, nil
+}
+
+// instanceConfigFields has the fields of an InstanceConfig without its
+// UnmarshalJSON, so a strict decoder can check every field name
+type instanceConfigFields legacy.InstanceConfig
+
+// decodeInstanceConfig decodes data, rejecting unknown fields. Errors start
+// with the line and column they refer to, e.g. ":3:5: unknown field".
+func decodeInstanceConfig(data []byte) (*legacy.InstanceConfig, error) {
+	if isAWSInstanceDocument(data) {
+		return decodeAWSInstance(data)
+	}
+
+	// Tags are checked by InstanceConfig.UnmarshalJSON, which accepts two forms
+	strict := struct {
+		*instanceConfigFields
+		Tags json.RawMessage `json:"tags"`
+	}{instanceConfigFields: &instanceConfigFields{}}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&strict); err != nil {
+		return nil, positionedError(data, err)
+	}
+
+	var config legacy.InstanceConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, positionedError(data, err)
+	}
+	return &config, nil
+}
+
+// unknownFieldPattern matches the error encoding/json returns for a field
+// rejected by DisallowUnknownFields
+var unknownFieldPattern = regexp.MustCompile(`^json: unknown field "(.*)"$`)
+
+// positionedError returns a decoding error as a *decodeError, with the line
+// and column of data it refers to when known
+func positionedError(data []byte, err error) error {
+	offset := int64(-1)
+	var field string
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		// Offset counts the bytes read, including the offending one
+		offset = syntaxErr.Offset - 1
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		field = typeErr.Field[strings.LastIndex(typeErr.Field, ".")+1:]
+		offset = keyOffset(data, field)
+		err = &fieldTypeError{field: field, format: "JSON", value: typeErr.Value, typ: typeErr.Type.String()}
+	default:
+		if m := unknownFieldPattern.FindStringSubmatch(err.Error()); m != nil {
+			field = m[1]
+			offset = keyOffset(data, field)
+			err = fmt.Errorf("unknown field %q", field)
+		}
+	}
+
+	if offset < 0 {
+		return &decodeError{field: field, err: err}
+	}
+	line, column := lineColumn(data, offset)
+	return &decodeError{line: line, column: column, field: field, err: err}
+}
+
+// keyOffset returns the offset of the first object key named field in data,
+// or -1. Decoding errors do not always say where a field is, so its key is
+// searched for.
+func keyOffset(data []byte, field string) int64 {
+	key := regexp.MustCompile(regexp.QuoteMeta(`"`+field+`"`) + `\s*:`)
+	if loc := key.FindIndex(data); loc != nil {
+		return int64(loc[0])
+	}
+	return -1
+}
+
+// lineColumn converts a byte offset in data to a 1-based line and column
+func lineColumn(data []byte, offset int64) (int, int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// Accepted values of the enumerated instance settings. AWS reports the
+// magnetic volume type as standard.
+var (
+	validVolumeTypes = []string{"gp2", "gp3", "io1", "io2", "st1", "sc1", "standard"}
+	validTenancies   = []string{"default", "dedicated", "host"}
+	validHTTPTokens  = []string{"optional", "required"}
+
+	validInstanceLifecycles    = []string{"spot", "scheduled", "capacity-block"}
+	validInterruptionBehaviors = []string{"hibernate", "stop", "terminate"}
+)
+
+// ValidateInstanceConfig checks that config names an instance and that its
+// enumerated settings, such as volume types, hold values AWS accepts. Every
+// problem found is reported.
+func ValidateInstanceConfig(config *legacy.InstanceConfig) error {
+	var problems []string
+	check := func(field, value string, valid []string) {
+		if value == "" {
+			return
+		}
+		for _, v := range valid {
+			if value == v {
+				return
+			}
+		}
+		problems = append(problems, fmt.Sprintf("%s %q is not one of %s", field, value, strings.Join(valid, ", ")))
+	}
+
+	if config.InstanceID == "" {
+		problems = append(problems, "instance_id is required")
+	}
+	check("root_volume_type", config.RootVolumeType, validVolumeTypes)
+	for i, device := range config.EBSBlockDevices {
+		if device != nil {
+			check(fmt.Sprintf("ebs_block_devices[%d].volume_type", i), device.VolumeType, validVolumeTypes)
+		}
+	}
+	check("tenancy", config.Tenancy, validTenancies)
+	if config.MetadataOptions != nil {
+		check("metadata_options.http_tokens", config.MetadataOptions.HTTPTokens, validHTTPTokens)
+	}
+	check("instance_lifecycle", config.InstanceLifecycle, validInstanceLifecycles)
+	if config.InstanceMarketOptions != nil {
+		check("instance_market_options.market_type", config.InstanceMarketOptions.MarketType, validInstanceLifecycles)
+		check("instance_market_options.instance_interruption_behavior",
+			config.InstanceMarketOptions.InstanceInterruptionBehavior, validInterruptionBehaviors)
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return errors.New(strings.Join(problems, "; "))
+}
+
+// EncodeInstanceConfig writes config to w as indented JSON, or as YAML
+func EncodeInstanceConfig(w io.Writer, config *legacy.InstanceConfig, format Format) error {
+	var err error
+	if format == FormatYAML {
+		err = encodeYAML(w, config)
+	} else {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(config)
+	}
+	if err != nil {
+		return fmt.Errorf("encoding instance %s: %w", config.InstanceID, err)
+	}
+	return nil
+}
+
+// WriteInstanceConfig writes config to path in the given format
+func WriteInstanceConfig(path string, config *legacy.InstanceConfig, format Format) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create mock file: %w", err)
+	}
+	if err := EncodeInstanceConfig(f, config, format); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,389 @@
+package mock_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domain "driftdetector/domain/models"
+	"driftdetector/infrastructure/mock"
+	legacy "driftdetector/models"
+)
+
+// liveInstance returns an instance populated the way EC2Repository reads one
+func liveInstance() *domain.Instance {
+	yes, no := true, false
+	instance := domain.NewInstance("i-0123456789abcdef0", "m5.large", "ami-0c55b159cbfafe1f0")
+	instance.KeyName = "ops"
+	instance.AddTag("Name", "web")
+	instance.AddTag("Environment", "prod")
+	instance.VPCID = "vpc-1"
+	instance.SubnetID = "subnet-1"
+	instance.SecurityGroups = []domain.SecurityGroup{{GroupID: "sg-1", GroupName: "web"}, {GroupID: "sg-2"}}
+	instance.PublicIPAddress = "203.0.113.10"
+	instance.PrivateIPAddress = "10.0.1.5"
+	instance.PrivateDNSName = "ip-10-0-1-5.ec2.internal"
+	instance.RootVolumeSize = 20
+	instance.RootVolumeType = "gp3"
+	instance.RootVolumeIops = 3000
+	instance.RootVolumeThroughput = 125
+	instance.RootVolumeEncrypted = &yes
+	instance.RootVolumeKMSKeyID = "arn:aws:kms:us-east-1:123456789012:key/abc"
+	instance.EBSOptimized = &yes
+	instance.EBSBlockDevices = []domain.EBSBlockDevice{{DeviceName: "/dev/sdf", VolumeSize: 100, VolumeType: "io2", Iops: 5000, Encrypted: &yes, DeleteOnTermination: &no}}
+	instance.IAMInstanceProfile = "web-profile"
+	instance.Monitoring = &no
+	instance.AvailabilityZone = "us-east-1a"
+	instance.Tenancy = "default"
+	instance.CPUCoreCount = 1
+	instance.CPUThreadsPerCore = 2
+	instance.Hibernation = &domain.HibernationOptions{Configured: false}
+	instance.EnclaveOptions = &domain.EnclaveOptions{Enabled: false}
+	instance.MetadataOptions = &domain.MetadataOptions{HTTPEndpoint: "enabled", HTTPTokens: "required", HTTPPutResponseHopLimit: 2, InstanceMetadataTags: "disabled"}
+	instance.DisableAPITermination = &yes
+	instance.UserData = "#!/bin/bash\necho hello\n"
+	return instance
+}
+
+func TestInstanceConfig_RoundTrip(t *testing.T) {
+	for _, format := range []mock.Format{mock.FormatJSON, mock.FormatYAML} {
+		t.Run(string(format), func(t *testing.T) {
+			snapshot := legacy.NewInstanceConfig(liveInstance())
+			path := filepath.Join(t.TempDir(), "instance"+format.Extension())
+
+			require.NoError(t, mock.WriteInstanceConfig(path, snapshot, format))
+			loaded, err := mock.LoadInstanceConfig(path)
+
+			require.NoError(t, err)
+			assert.Equal(t, snapshot, loaded)
+			assert.Equal(t, liveInstance(), loaded.ToInstance(), "the loaded file describes the live instance")
+
+			configs, err := mock.LoadInstanceConfigs(path)
+			require.NoError(t, err)
+			assert.Equal(t, []*legacy.InstanceConfig{snapshot}, configs)
+		})
+	}
+}
+
+func TestEncodeInstanceConfig_YAML(t *testing.T) {
+	var out strings.Builder
+	config := &legacy.InstanceConfig{InstanceID: "i-1", InstanceType: "t3.micro", Tags: map[string]string{"Version": "2", "Name": "web"}}
+
+	require.NoError(t, mock.EncodeInstanceConfig(&out, config, mock.FormatYAML))
+
+	// Fields keep the order of the JSON, and strings that would read back as
+	// another type are quoted
+	assert.True(t, strings.HasPrefix(out.String(), "instance_id: i-1\ninstance_type: t3.micro\n"), out.String())
+	assert.Contains(t, out.String(), "  Name: web\n  Version: \"2\"\n")
+}
+
+func TestInstanceConfig_MarshalTags(t *testing.T) {
+	var config legacy.InstanceConfig
+	require.NoError(t, json.Unmarshal([]byte(`{"instance_id": "i-1", "tags": [{"Key": "Name", "Value": "web"}]}`), &config))
+
+	data, err := json.Marshal(config)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"tags":{"Name":"web"}`, "tags are written as a map whatever form they were read in")
+
+	data, err = json.Marshal(&legacy.InstanceConfig{InstanceID: "i-2"})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"tags":{}`)
+}
+
+func TestLoadInstanceConfig(t *testing.T) {
+	t.Run("AWS tag list", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "instance.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"instance_id": "i-1", "tags": [{"Key": "Name", "Value": "web"}]}`), 0o644))
+
+		config, err := mock.LoadInstanceConfig(path)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"Name": "web"}, config.Tags)
+	})
+
+	t.Run("lowercase tag list", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "instance.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"instance_id": "i-1", "tags": [{"key": "Name", "value": "web"}]}`), 0o644))
+
+		config, err := mock.LoadInstanceConfig(path)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"Name": "web"}, config.Tags)
+	})
+
+	t.Run("describe-instances output", func(t *testing.T) {
+		config, err := mock.LoadInstanceConfig("../../testdata/mock_tests/describe_instances.json")
+
+		require.NoError(t, err)
+		assert.Equal(t, "i-0abcd1234efgh5678", config.InstanceID)
+		assert.Equal(t, "t3.micro", config.InstanceType)
+		assert.Equal(t, map[string]string{"Name": "web-server", "Environment": "production"}, config.Tags)
+		assert.Equal(t, []legacy.SecurityGroup{{GroupID: "sg-0a1b2c3d", GroupName: "web"}}, config.SecurityGroups)
+		require.NotNil(t, config.MetadataOptions)
+		assert.Equal(t, "required", config.MetadataOptions.HTTPTokens)
+	})
+
+	t.Run("single instance from describe-instances", func(t *testing.T) {
+		config, err := mock.LoadInstanceConfig("../../testdata/mock_tests/tag_drift.json")
+
+		require.NoError(t, err)
+		assert.Equal(t, "i-1234567890abcdef3", config.InstanceID)
+		assert.Equal(t, map[string]string{"Name": "different-name", "Environment": "production", "NewTag": "added-in-aws"}, config.Tags)
+	})
+
+	t.Run("describe-instances output with several instances", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "instance.json")
+		content := `{"Reservations": [{"Instances": [{"InstanceId": "i-1"}, {"InstanceId": "i-2"}]}]}`
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+		_, err := mock.LoadInstanceConfig(path)
+
+		assert.ErrorContains(t, err, "exactly one instance, found 2")
+	})
+
+	t.Run("missing instance ID", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "instance.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{"instance_type": "t3.micro"}`), 0o644))
+
+		_, err := mock.LoadInstanceConfig(path)
+
+		assert.ErrorContains(t, err, "instance_id is required")
+	})
+
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{
+			name:    "unknown field",
+			content: "{\n  \"instance_id\": \"i-1\",\n  \"instance_typ\": \"t3.micro\"\n}",
+			err:     `instance.json:3:3: unknown field "instance_typ"`,
+		},
+		{
+			name:    "unknown nested field",
+			content: "{\"instance_id\": \"i-1\",\n\"metadata_options\": {\"http_token\": \"required\"}}",
+			err:     `instance.json:2:22: unknown field "http_token"`,
+		},
+		{
+			name:    "wrong type",
+			content: "{\"instance_id\": \"i-1\",\n\"root_volume_size\": \"20\"}",
+			err:     `instance.json:2:1: field "root_volume_size": cannot use a JSON string as int`,
+		},
+		{
+			name:    "invalid JSON",
+			content: "{\"instance_id\": \"i-1\",\n}",
+			err:     "instance.json:2:1: invalid character '}'",
+		},
+		{
+			name:    "invalid settings are all reported",
+			content: `{"instance_id": "i-1", "root_volume_type": "gp4", "tenancy": "shared", "metadata_options": {"http_tokens": "always"}, "ebs_block_devices": [{"device_name": "/dev/sdf", "volume_type": "magnetic"}]}`,
+			err: `ebs_block_devices[0].volume_type "magnetic" is not one of gp2, gp3, io1, io2, st1, sc1, standard; ` +
+				`metadata_options.http_tokens "always" is not one of optional, required; ` +
+				`root_volume_type "gp4" is not one of gp2, gp3, io1, io2, st1, sc1, standard; ` +
+				`tenancy "shared" is not one of default, dedicated, host`,
+		},
+		{
+			name:    "invalid market options",
+			content: `{"instance_id": "i-1", "instance_lifecycle": "reserved", "instance_market_options": {"market_type": "spot", "instance_interruption_behavior": "pause"}}`,
+			err: `instance_lifecycle "reserved" is not one of spot, scheduled, capacity-block; ` +
+				`instance_market_options.instance_interruption_behavior "pause" is not one of hibernate, stop, terminate`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "instance.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o644))
+
+			_, err := mock.LoadInstanceConfig(path)
+
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestLoadInstanceConfigs(t *testing.T) {
+	write := func(t *testing.T, content string) string {
+		path := filepath.Join(t.TempDir(), "instances.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+	ids := func(configs []*legacy.InstanceConfig) []string {
+		var ids []string
+		for _, config := range configs {
+			ids = append(ids, config.InstanceID)
+		}
+		return ids
+	}
+
+	t.Run("directory", func(t *testing.T) {
+		configs, err := mock.LoadInstanceConfigs("../../testdata/mock_tests/batch")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"i-0batch00000000app", "i-0batch00000000web"}, ids(configs), "files are read in lexical order")
+	})
+
+	t.Run("array", func(t *testing.T) {
+		configs, err := mock.LoadInstanceConfigs(write(t, `[{"instance_id": "i-1"}, {"instance_id": "i-2", "tags": [{"Key": "Name", "Value": "web"}]}]`))
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"i-1", "i-2"}, ids(configs))
+		assert.Equal(t, map[string]string{"Name": "web"}, configs[1].Tags)
+	})
+
+	t.Run("single instance", func(t *testing.T) {
+		configs, err := mock.LoadInstanceConfigs("../../testdata/mock_tests/tag_drift.json")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"i-1234567890abcdef3"}, ids(configs))
+	})
+
+	t.Run("describe-instances output with several instances", func(t *testing.T) {
+		configs, err := mock.LoadInstanceConfigs(write(t, `{"Reservations": [{"Instances": [{"InstanceId": "i-1"}, {"InstanceId": "i-2"}]}]}`))
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"i-1", "i-2"}, ids(configs))
+	})
+
+	t.Run("error position in array", func(t *testing.T) {
+		_, err := mock.LoadInstanceConfigs(write(t, "[\n  {\"instance_id\": \"i-1\"},\n  {\"instance_id\": \"i-2\", \"instance_typ\": \"t3.micro\"}\n]"))
+
+		assert.ErrorContains(t, err, `instances.json:3:26: unknown field "instance_typ"`)
+	})
+
+	t.Run("invalid instance in array", func(t *testing.T) {
+		_, err := mock.LoadInstanceConfigs(write(t, `[{"instance_id": "i-1"}, {"instance_type": "t3.micro"}]`))
+
+		assert.ErrorContains(t, err, "instance 2: instance_id is required")
+	})
+
+	t.Run("duplicate instance", func(t *testing.T) {
+		_, err := mock.LoadInstanceConfigs(write(t, `[{"instance_id": "i-1"}, {"instance_id": "i-1"}]`))
+
+		assert.ErrorContains(t, err, "instance i-1 appears more than once")
+	})
+
+	t.Run("directory without instances", func(t *testing.T) {
+		_, err := mock.LoadInstanceConfigs(t.TempDir())
+
+		assert.ErrorContains(t, err, "holds no .json, .yaml or .yml files")
+	})
+}
+
+func TestLoadInstanceConfigs_YAML(t *testing.T) {
+	write := func(t *testing.T, name, content string) string {
+		path := filepath.Join(t.TempDir(), name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+		return path
+	}
+
+	t.Run("anchors, comments and tag lists", func(t *testing.T) {
+		path := write(t, "instances.yaml", `# Web servers share their settings
+- &web
+  instance_id: i-1
+  instance_type: t3.micro
+  tags: &tags
+    Environment: prod
+- <<: *web
+  instance_id: i-2
+  # The AWS form of tags is accepted too
+  tags:
+    - Key: Name
+      Value: web
+- instance_id: i-3
+  tags: *tags
+`)
+
+		configs, err := mock.LoadInstanceConfigs(path)
+
+		require.NoError(t, err)
+		require.Len(t, configs, 3)
+		assert.Equal(t, "t3.micro", configs[1].InstanceType)
+		assert.Equal(t, map[string]string{"Name": "web"}, configs[1].Tags)
+		assert.Equal(t, map[string]string{"Environment": "prod"}, configs[2].Tags)
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "a.json"), []byte(`{"instance_id": "i-1"}`), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "b.yml"), []byte("instance_id: i-2\n"), 0o644))
+
+		configs, err := mock.LoadInstanceConfigs(dir)
+
+		require.NoError(t, err)
+		require.Len(t, configs, 2)
+		assert.Equal(t, "i-2", configs[1].InstanceID)
+	})
+
+	t.Run("describe-instances output", func(t *testing.T) {
+		configs, err := mock.LoadInstanceConfigs(write(t, "aws.yaml", `Reservations:
+  - Instances:
+      - InstanceId: i-1
+        InstanceType: t3.micro
+      - InstanceId: i-2
+`))
+
+		require.NoError(t, err)
+		require.Len(t, configs, 2)
+		assert.Equal(t, "t3.micro", configs[0].InstanceType)
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		configs, err := mock.ReadInstanceConfigs(strings.NewReader("instance_id: i-1\ntenancy: default\n"), "stdin", mock.FormatYAML)
+
+		require.NoError(t, err)
+		require.Len(t, configs, 1)
+		assert.Equal(t, "default", configs[0].Tenancy)
+	})
+
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{
+			name:    "unknown field",
+			content: "instance_id: i-1\n# a comment\ninstance_typ: t3.micro\n",
+			err:     `instance.yaml:3:1: unknown field "instance_typ"`,
+		},
+		{
+			name:    "unknown nested field",
+			content: "instance_id: i-1\nmetadata_options:\n  http_token: required\n",
+			err:     `instance.yaml:3:3: unknown field "http_token"`,
+		},
+		{
+			name:    "wrong type",
+			content: "instance_id: i-1\nroot_volume_size: twenty\n",
+			err:     `instance.yaml:2:1: field "root_volume_size": cannot use a YAML string as int`,
+		},
+		{
+			name:    "unknown field in a sequence",
+			content: "- instance_id: i-1\n- instance_id: i-2\n  instance_typ: t3.micro\n",
+			err:     `instance.yaml:3:3: unknown field "instance_typ"`,
+		},
+		{
+			name:    "invalid YAML",
+			content: "instance_id: i-1\n  tenancy: default\n",
+			err:     "instance.yaml: yaml: line 2",
+		},
+		{
+			name:    "invalid settings",
+			content: "instance_id: i-1\ntenancy: shared\n",
+			err:     `instance.yaml: tenancy "shared" is not one of default, dedicated, host`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mock.LoadInstanceConfigs(write(t, "instance.yaml", tt.content))
+
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package mock
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	legacy "driftdetector/models"
+)
+
+// Ensure InstanceRepository implements the InstanceRepository interface
+var _ repositories.InstanceRepository = (*InstanceRepository)(nil)
+
+// InstanceRepository serves instances from configurations held in memory,
+// in place of EC2
+type InstanceRepository struct {
+	mu        sync.RWMutex
+	instances map[string]*models.Instance
+}
+
+// NewInstanceRepository creates an InstanceRepository holding configs
+func NewInstanceRepository(configs ...*legacy.InstanceConfig) *InstanceRepository {
+	r := &InstanceRepository{instances: make(map[string]*models.Instance, len(configs))}
+	for _, config := range configs {
+		instance := config.ToInstance()
+		r.instances[instance.ID] = instance
+	}
+	return r
+}
+
+// GetByID returns the instance with the given ID
+func (r *InstanceRepository) GetByID(_ context.Context, id string) (*models.Instance, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	instance, ok := r.instances[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", repositories.ErrInstanceNotFound, id)
+	}
+	return copyInstance(instance), nil
+}
+
+// GetByIDs returns the instances with the given IDs, failing if any is
+// missing as EC2 does
+func (r *InstanceRepository) GetByIDs(ctx context.Context, ids []string) ([]*models.Instance, error) {
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("at least one instance ID is required")
+	}
+
+	instances := make([]*models.Instance, 0, len(ids))
+	for _, id := range ids {
+		instance, err := r.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// FindAll returns every instance, ordered by ID
+func (r *InstanceRepository) FindAll(ctx context.Context) ([]*models.Instance, error) {
+	return r.Find(ctx, repositories.InstanceFilter{})
+}
+
+// Find returns the instances matching filter, ordered by ID. Instances
+// without a state count as running.
+func (r *InstanceRepository) Find(_ context.Context, filter repositories.InstanceFilter) ([]*models.Instance, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var instances []*models.Instance
+	for _, instance := range r.instances {
+		if filter.Matches(instance) {
+			instances = append(instances, copyInstance(instance))
+		}
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].ID < instances[j].ID })
+	return instances, nil
+}
+
+// FindByTag returns the running instances whose tag key has value, ordered by ID
+func (r *InstanceRepository) FindByTag(ctx context.Context, key, value string) ([]*models.Instance, error) {
+	return r.Find(ctx, repositories.InstanceFilter{
+		Tags:   map[string]string{key: value},
+		States: []string{models.InstanceStateRunning},
+	})
+}
+
+// Save stores the instance, replacing any with the same ID
+func (r *InstanceRepository) Save(_ context.Context, instance *models.Instance) error {
+	if instance == nil || instance.ID == "" {
+		return fmt.Errorf("instance ID cannot be empty")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.instances[instance.ID] = copyInstance(instance)
+	return nil
+}
+
+// Delete removes the instance with the given ID
+func (r *InstanceRepository) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.instances[id]; !ok {
+		return fmt.Errorf("%w: %s", repositories.ErrInstanceNotFound, id)
+	}
+	delete(r.instances, id)
+	return nil
+}
+
+// copyInstance returns a copy of instance whose tags and lists can be
+// changed without affecting the stored instance
+func copyInstance(instance *models.Instance) *models.Instance {
+	return legacy.NewInstanceConfig(instance).ToInstance()
+}
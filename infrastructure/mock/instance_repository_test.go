@@ -0,0 +1,55 @@
+package mock_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/repositories"
+	"driftdetector/infrastructure/mock"
+	legacy "driftdetector/models"
+)
+
+func TestInstanceRepository(t *testing.T) {
+	ctx := context.Background()
+	repo := mock.NewInstanceRepository(
+		&legacy.InstanceConfig{InstanceID: "i-2", InstanceType: "t3.micro", Tags: map[string]string{"Environment": "prod"}},
+		&legacy.InstanceConfig{InstanceID: "i-1", InstanceType: "t3.small", Tags: map[string]string{"Environment": "dev"}},
+	)
+
+	t.Run("by ID", func(t *testing.T) {
+		instance, err := repo.GetByID(ctx, "i-1")
+		require.NoError(t, err)
+		assert.Equal(t, "t3.small", instance.Type)
+
+		_, err = repo.GetByID(ctx, "i-3")
+		assert.ErrorIs(t, err, repositories.ErrInstanceNotFound)
+
+		_, err = repo.GetByIDs(ctx, []string{"i-1", "i-3"})
+		assert.ErrorIs(t, err, repositories.ErrInstanceNotFound)
+	})
+
+	t.Run("by tag", func(t *testing.T) {
+		all, err := repo.FindAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, all, 2)
+		assert.Equal(t, "i-1", all[0].ID, "instances are ordered by ID")
+
+		prod, err := repo.Find(ctx, repositories.InstanceFilter{Tags: map[string]string{"Environment": "prod"}})
+		require.NoError(t, err)
+		require.Len(t, prod, 1)
+		assert.Equal(t, "i-2", prod[0].ID)
+	})
+
+	t.Run("returned instances are copies", func(t *testing.T) {
+		instance, err := repo.GetByID(ctx, "i-1")
+		require.NoError(t, err)
+		instance.Tags["Environment"] = "changed"
+
+		again, err := repo.GetByID(ctx, "i-1")
+		require.NoError(t, err)
+		assert.Equal(t, "dev", again.Tags["Environment"])
+	})
+}
@@ -0,0 +1,184 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"driftdetector/domain/models"
+)
+
+// Notifier delivers drift reports to an external system
+type Notifier interface {
+	Notify(ctx context.Context, report *models.DriftReport) error
+}
+
+// PayloadFormat selects how a report is rendered in the webhook request body
+type PayloadFormat string
+
+const (
+	// PayloadJSON posts the drift report as JSON
+	PayloadJSON PayloadFormat = "json"
+	// PayloadSlack posts a Slack incoming-webhook message listing the findings
+	PayloadSlack PayloadFormat = "slack"
+)
+
+const (
+	// defaultWebhookTimeout bounds each request to the webhook
+	defaultWebhookTimeout = 10 * time.Second
+	// defaultWebhookAttempts is how often a request is tried on server errors
+	defaultWebhookAttempts = 3
+	// defaultWebhookBackoff is the wait before the first retry; it doubles after each one
+	defaultWebhookBackoff = time.Second
+)
+
+// WebhookNotifier posts drift reports to an HTTP endpoint
+type WebhookNotifier struct {
+	url      string
+	format   PayloadFormat
+	headers  map[string]string
+	client   *http.Client
+	attempts int
+	backoff  time.Duration
+}
+
+// WebhookOption configures a WebhookNotifier
+type WebhookOption func(*WebhookNotifier)
+
+// WithHeaders adds headers to every webhook request
+func WithHeaders(headers map[string]string) WebhookOption {
+	return func(n *WebhookNotifier) {
+		for k, v := range headers {
+			n.headers[k] = v
+		}
+	}
+}
+
+// WithPayloadFormat selects how reports are rendered
+func WithPayloadFormat(format PayloadFormat) WebhookOption {
+	return func(n *WebhookNotifier) {
+		n.format = format
+	}
+}
+
+// WithHTTPClient sets the client used to send requests
+func WithHTTPClient(client *http.Client) WebhookOption {
+	return func(n *WebhookNotifier) {
+		n.client = client
+	}
+}
+
+// WithRetry sets how often a request is tried when the endpoint returns a
+// server error, and the wait before the first retry
+func WithRetry(attempts int, backoff time.Duration) WebhookOption {
+	return func(n *WebhookNotifier) {
+		n.attempts = attempts
+		n.backoff = backoff
+	}
+}
+
+// NewWebhookNotifier creates a WebhookNotifier for url
+func NewWebhookNotifier(url string, opts ...WebhookOption) (*WebhookNotifier, error) {
+	n := &WebhookNotifier{
+		url:      url,
+		format:   PayloadJSON,
+		headers:  make(map[string]string),
+		client:   &http.Client{Timeout: defaultWebhookTimeout},
+		attempts: defaultWebhookAttempts,
+		backoff:  defaultWebhookBackoff,
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	if n.format != PayloadJSON && n.format != PayloadSlack {
+		return nil, fmt.Errorf("unsupported webhook format %q: expected json or slack", n.format)
+	}
+	if n.attempts < 1 {
+		n.attempts = 1
+	}
+	return n, nil
+}
+
+// Notify posts the report, retrying with exponential backoff while the
+// endpoint answers with a server error
+func (n *WebhookNotifier) Notify(ctx context.Context, report *models.DriftReport) error {
+	body, err := n.payload(report)
+	if err != nil {
+		return err
+	}
+
+	wait := n.backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := n.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= n.attempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// post sends one request and reports whether a failure is worth retrying
+func (n *WebhookNotifier) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range n.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("posting to webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 500 {
+		return true, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	if resp.StatusCode >= 300 {
+		return false, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return false, nil
+}
+
+// payload renders the request body for the notifier's format
+func (n *WebhookNotifier) payload(report *models.DriftReport) ([]byte, error) {
+	if n.format == PayloadSlack {
+		return json.Marshal(map[string]string{"text": SlackText(report)})
+	}
+	return json.Marshal(report)
+}
+
+// SlackText renders a report as a Slack message with the findings in a code block
+func SlackText(report *models.DriftReport) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("*Drift detected on %s* (%d finding(s))\n", report.InstanceID, len(report.Drifts)))
+	sb.WriteString("```\n")
+	for _, d := range report.Drifts {
+		sb.WriteString(fmt.Sprintf("[%s] %s", d.Type, d.Path))
+		if d.Type == models.DriftTypeModified {
+			sb.WriteString(fmt.Sprintf(": %v -> %v", d.Expected, d.Actual))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("```")
+	return sb.String()
+}
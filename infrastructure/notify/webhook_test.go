@@ -0,0 +1,97 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/notify"
+)
+
+func driftedReport() *models.DriftReport {
+	report := models.NewDriftReport("i-1")
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, "Type", "t3.large", "t3.micro", "Value mismatch"))
+	return report
+}
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	t.Run("posts the report as JSON with custom headers", func(t *testing.T) {
+		var got models.DriftReport
+		var auth string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			auth = r.Header.Get("Authorization")
+			assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		}))
+		defer srv.Close()
+
+		n, err := notify.NewWebhookNotifier(srv.URL, notify.WithHeaders(map[string]string{"Authorization": "Bearer token"}))
+		require.NoError(t, err)
+
+		require.NoError(t, n.Notify(context.Background(), driftedReport()))
+		assert.Equal(t, "Bearer token", auth)
+		assert.Equal(t, "i-1", got.InstanceID)
+		require.Len(t, got.Drifts, 1)
+		assert.Equal(t, "Type", got.Drifts[0].Path)
+	})
+
+	t.Run("slack payload", func(t *testing.T) {
+		var got map[string]string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		}))
+		defer srv.Close()
+
+		n, err := notify.NewWebhookNotifier(srv.URL, notify.WithPayloadFormat(notify.PayloadSlack))
+		require.NoError(t, err)
+
+		require.NoError(t, n.Notify(context.Background(), driftedReport()))
+		assert.Contains(t, got["text"], "Drift detected on i-1")
+		assert.Contains(t, got["text"], "[MODIFIED] Type: t3.micro -> t3.large")
+	})
+
+	t.Run("retries server errors", func(t *testing.T) {
+		var calls int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+			}
+		}))
+		defer srv.Close()
+
+		n, err := notify.NewWebhookNotifier(srv.URL, notify.WithRetry(3, time.Millisecond))
+		require.NoError(t, err)
+
+		require.NoError(t, n.Notify(context.Background(), driftedReport()))
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		var calls int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusForbidden)
+		}))
+		defer srv.Close()
+
+		n, err := notify.NewWebhookNotifier(srv.URL, notify.WithRetry(3, time.Millisecond))
+		require.NoError(t, err)
+
+		err = n.Notify(context.Background(), driftedReport())
+		assert.ErrorContains(t, err, "403")
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestNewWebhookNotifier_UnsupportedFormat(t *testing.T) {
+	_, err := notify.NewWebhookNotifier("http://example.com", notify.WithPayloadFormat("teams"))
+	assert.Error(t, err)
+}
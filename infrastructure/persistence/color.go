@@ -0,0 +1,91 @@
+package persistence
+
+import (
+	"driftdetector/domain/models"
+)
+
+// ANSI escape sequences used by colored text output
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiRed     = "\x1b[31m"
+	ansiGreen   = "\x1b[32m"
+	ansiYellow  = "\x1b[33m"
+	ansiMagenta = "\x1b[35m"
+	ansiCyan    = "\x1b[36m"
+)
+
+// WithColor colors text output with ANSI escape sequences: drift types,
+// severities and summary lines. The colored text holds the same characters
+// as the plain text once the escape sequences are removed. Other formats
+// ignore it.
+func WithColor(enabled bool) FormatterOption {
+	return func(o *formatterOptions) {
+		o.color = enabled
+	}
+}
+
+// Palette colors the parts of text output. The zero Palette leaves text
+// plain.
+type Palette struct {
+	enabled bool
+}
+
+// NewPalette returns a Palette that colors text when enabled
+func NewPalette(enabled bool) Palette {
+	return Palette{enabled: enabled}
+}
+
+// paint wraps s in the escape sequence code, unless the palette is plain
+func (p Palette) paint(code, s string) string {
+	if !p.enabled || s == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// DriftType returns the name of t: ADDED in green, REMOVED in red, MODIFIED
+// in yellow and violations in magenta
+func (p Palette) DriftType(t models.DriftType) string {
+	switch t {
+	case models.DriftTypeAdded:
+		return p.paint(ansiGreen, string(t))
+	case models.DriftTypeRemoved:
+		return p.paint(ansiRed, string(t))
+	case models.DriftTypeModified:
+		return p.paint(ansiYellow, string(t))
+	case models.DriftTypePrerequisiteViolation, models.DriftTypePolicyViolation, models.DriftTypeGoldenMismatch, models.DriftTypeStaleAMI:
+		return p.paint(ansiMagenta, string(t))
+	default:
+		return string(t)
+	}
+}
+
+// Severity returns s as a badge: CRITICAL in bold red, WARNING in yellow and
+// INFO in cyan
+func (p Palette) Severity(s models.Severity) string {
+	switch s {
+	case models.SeverityCritical:
+		return p.paint(ansiBold+ansiRed, string(s))
+	case models.SeverityWarning:
+		return p.paint(ansiYellow, string(s))
+	case models.SeverityInfo:
+		return p.paint(ansiCyan, string(s))
+	default:
+		return string(s)
+	}
+}
+
+// Summary returns a summary line in bold, red when drift was found and
+// green otherwise
+func (p Palette) Summary(line string, drifted bool) string {
+	if drifted {
+		return p.paint(ansiBold+ansiRed, line)
+	}
+	return p.paint(ansiBold+ansiGreen, line)
+}
+
+// TextPalette returns the palette text output is colored with under opts
+func TextPalette(opts ...FormatterOption) Palette {
+	return NewPalette(newFormatterOptions(opts).color)
+}
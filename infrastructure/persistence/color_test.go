@@ -0,0 +1,94 @@
+package persistence
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+)
+
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+func colorTestAggregate() *models.AggregateReport {
+	drifted := &models.DriftReport{
+		InstanceID: "i-abc123",
+		HasDrift:   true,
+		Drifts: []models.Drift{
+			{Type: models.DriftTypeModified, Path: "Type", Description: "Value mismatch", Severity: models.SeverityWarning, Actual: "t3.large", Expected: "t3.micro"},
+			{Type: models.DriftTypeAdded, Path: "SecurityGroups[sg-2]", Description: "Element declared in Terraform is missing in AWS", Severity: models.SeverityCritical, Actual: "sg-2"},
+			{Type: models.DriftTypeRemoved, Path: ".Tags.Owner", Description: "Element exists in AWS but not in Terraform", Severity: models.SeverityInfo, Expected: "ops"},
+		},
+		Acknowledged: []models.Drift{
+			{Type: models.DriftTypeModified, Path: "RootVolumeSize", Actual: 100, Expected: 50},
+		},
+	}
+	clean := models.NewDriftReport("i-def456")
+	return models.NewAggregateReport([]*models.DriftReport{drifted, clean}, []string{"i-ghi789: instance not found"})
+}
+
+func TestFormatAggregate_TextColor(t *testing.T) {
+	// Given an aggregate with every drift type and severity
+	aggregate := colorTestAggregate()
+
+	// When it is formatted as text with and without color
+	plain, err := FormatAggregate(FormatText, aggregate)
+	require.NoError(t, err)
+	uncolored, err := FormatAggregate(FormatText, aggregate, WithColor(false))
+	require.NoError(t, err)
+	colored, err := FormatAggregate(FormatText, aggregate, WithColor(true))
+	require.NoError(t, err)
+
+	// Then plain output is unchanged and colored output only adds escape sequences
+	assertGolden(t, "text_color_plain.golden", plain)
+	assert.Equal(t, plain, uncolored)
+	assert.NotContains(t, plain, "\x1b[")
+	assert.Contains(t, colored, ansiYellow+"MODIFIED"+ansiReset)
+	assert.Contains(t, colored, ansiGreen+"ADDED"+ansiReset)
+	assert.Contains(t, colored, ansiRed+"REMOVED"+ansiReset)
+	assert.Contains(t, colored, ansiBold+ansiRed+"CRITICAL"+ansiReset)
+	assert.Contains(t, colored, ansiCyan+"INFO"+ansiReset)
+	assert.Contains(t, colored, ansiBold+ansiRed+aggregate.Summary().String()+ansiReset)
+	assert.Equal(t, plain, ansiEscape.ReplaceAllString(colored, ""))
+}
+
+func TestFormatter_TextColor(t *testing.T) {
+	tests := []struct {
+		name   string
+		report *models.DriftReport
+		want   string
+	}{
+		{
+			name:   "no drift is green",
+			report: models.NewDriftReport("i-abc123"),
+			want:   ansiBold + ansiGreen + "No configuration drift detected." + ansiReset,
+		},
+		{
+			name:   "drift count is red",
+			report: colorTestAggregate().Reports[0],
+			want:   ansiBold + ansiRed + "Found 3 drift(s):" + ansiReset,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given a colored and a plain text formatter
+			colorFormatter, err := NewFormatter(FormatText, WithColor(true))
+			require.NoError(t, err)
+			plainFormatter, err := NewFormatter(FormatText)
+			require.NoError(t, err)
+
+			// When the report is formatted by both
+			colored, err := colorFormatter.Format(tt.report)
+			require.NoError(t, err)
+			plain, err := plainFormatter.Format(tt.report)
+			require.NoError(t, err)
+
+			// Then only the colored output holds escape sequences
+			assert.Contains(t, colored, tt.want)
+			assert.Equal(t, plain, ansiEscape.ReplaceAllString(colored, ""))
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package persistence
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"driftdetector/domain/models"
+)
+
+// csvHeader names the columns of CSV output, one row per finding
+var csvHeader = []string{"instance_id", "path", "type", "severity", "expected", "actual", "description"}
+
+// csvErrorType marks the rows of CSV output that record an instance that
+// could not be compared rather than a finding
+const csvErrorType = "ERROR"
+
+type csvFormatter struct{}
+
+func (f *csvFormatter) Format(report *models.DriftReport) (string, error) {
+	if report == nil {
+		return "", fmt.Errorf("cannot format nil report")
+	}
+	return renderCSV([]*models.DriftReport{report}, nil)
+}
+
+// renderCSV writes a header and one row per finding of every report, followed
+// by one ERROR row per failure. Values holding commas, quotes or newlines,
+// such as user data, are quoted.
+func renderCSV(reports []*models.DriftReport, failures []string) (string, error) {
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+
+	if err := w.Write(csvHeader); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	for _, report := range reports {
+		for _, d := range report.Drifts {
+			row := []string{
+				report.InstanceID,
+				d.Path,
+				string(d.Type),
+				string(d.Severity),
+				csvValue(d.Expected),
+				csvValue(d.Actual),
+				d.Description,
+			}
+			if err := w.Write(row); err != nil {
+				return "", fmt.Errorf("failed to write CSV: %w", err)
+			}
+		}
+	}
+	for _, failure := range failures {
+		if err := w.Write([]string{"", "", csvErrorType, "", "", "", failure}); err != nil {
+			return "", fmt.Errorf("failed to write CSV: %w", err)
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// csvValue renders a drift value for a spreadsheet cell: unset values are
+// empty, strings are kept as they are and structured values are compact JSON
+func csvValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
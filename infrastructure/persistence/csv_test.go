@@ -0,0 +1,65 @@
+package persistence
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+)
+
+func readCSV(t *testing.T, data string) [][]string {
+	t.Helper()
+	rows, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	require.NoError(t, err)
+	return rows
+}
+
+func TestFormatter_CSV(t *testing.T) {
+	report := models.NewDriftReport("i-1")
+	userData := models.NewDrift(models.DriftTypeModified, "UserData", "#!/bin/bash\necho \"a, b\"", "#!/bin/bash", "User data content differs")
+	userData.Severity = models.SeverityWarning
+	report.AddDrift(userData)
+	report.AddDrift(models.NewDrift(models.DriftTypeAdded, ".Tags.Owner", nil, "platform", "Field added"))
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, "MetadataOptions", &models.MetadataOptions{HTTPTokens: "optional"}, nil, "Value mismatch"))
+
+	formatter, err := NewFormatter(FormatCSV)
+	require.NoError(t, err)
+	out, err := formatter.Format(report)
+	require.NoError(t, err)
+
+	rows := readCSV(t, out)
+	require.Len(t, rows, 4, "a header and one row per finding")
+	assert.Equal(t, []string{"instance_id", "path", "type", "severity", "expected", "actual", "description"}, rows[0])
+	assert.Equal(t, []string{"i-1", "UserData", "MODIFIED", "WARNING", "#!/bin/bash", "#!/bin/bash\necho \"a, b\"", "User data content differs"}, rows[1],
+		"commas, quotes and newlines survive quoting")
+	assert.Equal(t, []string{"i-1", ".Tags.Owner", "ADDED", "", "platform", "", "Field added"}, rows[2], "unset values are empty")
+	assert.Contains(t, rows[3][5], `"http_tokens":"optional"`, "structured values are JSON")
+}
+
+func TestFormatAggregate_CSV(t *testing.T) {
+	drifted := models.NewDriftReport("i-1")
+	drifted.AddDrift(models.NewDrift(models.DriftTypeModified, "Type", "t3.large", "t3.micro", "Value mismatch"))
+	drifted.AddDrift(models.NewDrift(models.DriftTypeModified, "AMI", "ami-2", "ami-1", "Value mismatch"))
+	other := models.NewDriftReport("i-2")
+	other.AddDrift(models.NewDrift(models.DriftTypeRemoved, "", nil, nil, "Instance exists in Terraform state but was not found in AWS"))
+	clean := models.NewDriftReport("i-3")
+
+	aggregate := models.NewAggregateReport([]*models.DriftReport{drifted, other, clean}, []string{"i-4: access denied"})
+	out, err := FormatAggregate(FormatCSV, aggregate)
+	require.NoError(t, err)
+
+	rows := readCSV(t, out)
+	require.Len(t, rows, 5, "a header, a row per finding across instances and a row per failure")
+	assert.Equal(t, "i-1", rows[1][0])
+	assert.Equal(t, "i-1", rows[2][0])
+	assert.Equal(t, "i-2", rows[3][0])
+	assert.Equal(t, []string{"", "", "ERROR", "", "", "", "i-4: access denied"}, rows[4])
+
+	fromReports, err := FormatReports(FormatCSV, []*models.DriftReport{drifted, other, clean})
+	require.NoError(t, err)
+	assert.Len(t, readCSV(t, fromReports), 4)
+}
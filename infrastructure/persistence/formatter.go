@@ -24,22 +24,168 @@ const (
 	FormatYAML FormatType = "yaml"
 	// FormatText outputs the report in human-readable text format
 	FormatText FormatType = "text"
+	// FormatHTML outputs the report as a standalone HTML page
+	FormatHTML FormatType = "html"
+	// FormatMarkdown outputs the report as markdown, e.g. for pull request comments
+	FormatMarkdown FormatType = "markdown"
+	// FormatCSV outputs one row per finding, e.g. for spreadsheets
+	FormatCSV FormatType = "csv"
+	// FormatSARIF outputs the findings as SARIF 2.1.0, e.g. for code scanning
+	FormatSARIF FormatType = "sarif"
 )
 
 // NewFormatter creates a new formatter based on the specified format
-func NewFormatter(format FormatType) (Formatter, error) {
+func NewFormatter(format FormatType, opts ...FormatterOption) (Formatter, error) {
+	o := newFormatterOptions(opts)
 	switch format {
 	case FormatJSON:
 		return &jsonFormatter{}, nil
 	case FormatYAML:
 		return &yamlFormatter{}, nil
 	case FormatText:
-		return &textFormatter{}, nil
+		return &textFormatter{palette: NewPalette(o.color)}, nil
+	case FormatHTML:
+		return &htmlFormatter{}, nil
+	case FormatMarkdown:
+		return &markdownFormatter{maxValueLength: o.maxValueLength}, nil
+	case FormatCSV:
+		return &csvFormatter{}, nil
+	case FormatSARIF:
+		return &sarifFormatter{}, nil
+	case FormatTemplate:
+		return newTemplateFormatter(o)
 	default:
 		return nil, fmt.Errorf("unsupported format: %s", format)
 	}
 }
 
+// FormatReports formats several drift reports as one document: a JSON or YAML
+// list, one HTML page, one CSV table, one SARIF run, one rendering of the
+// template, or the text or markdown reports one after another
+func FormatReports(format FormatType, reports []*models.DriftReport, opts ...FormatterOption) (string, error) {
+	switch format {
+	case FormatTemplate:
+		formatter, err := newTemplateFormatter(newFormatterOptions(opts))
+		if err != nil {
+			return "", err
+		}
+		return formatter.template.Render(models.NewAggregateReport(reports, nil))
+	case FormatJSON:
+		data, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal reports to JSON: %v", err)
+		}
+		return string(data), nil
+	case FormatYAML:
+		data, err := marshalYAML(reports)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal reports to YAML: %v", err)
+		}
+		return string(data), nil
+	case FormatText:
+		var sb strings.Builder
+		formatter := &textFormatter{palette: NewPalette(newFormatterOptions(opts).color)}
+		for i, report := range reports {
+			if i > 0 {
+				sb.WriteString("\n")
+			}
+			out, err := formatter.Format(report)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(out)
+		}
+		return sb.String(), nil
+	case FormatHTML:
+		return renderHTML(reports)
+	case FormatCSV:
+		return renderCSV(reports, nil)
+	case FormatSARIF:
+		return renderSARIF(reports, nil)
+	case FormatMarkdown:
+		var sb strings.Builder
+		formatter := &markdownFormatter{maxValueLength: newFormatterOptions(opts).maxValueLength}
+		for i, report := range reports {
+			if i > 0 {
+				sb.WriteString("\n")
+			}
+			out, err := formatter.Format(report)
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(out)
+		}
+		return sb.String(), nil
+	default:
+		return "", fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// FormatAggregate formats the reports of several instances under a summary
+// of the counts: a JSON or YAML object holding the counts and the reports, a
+// CSV table with a row per failure after the findings, a SARIF run noting
+// the failures, or a summary header followed by the reports in the other
+// formats
+func FormatAggregate(format FormatType, aggregate *models.AggregateReport, opts ...FormatterOption) (string, error) {
+	if aggregate == nil {
+		return "", fmt.Errorf("cannot format nil aggregate report")
+	}
+
+	switch format {
+	case FormatJSON:
+		data, err := json.MarshalIndent(aggregate, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal reports to JSON: %v", err)
+		}
+		return string(data), nil
+	case FormatYAML:
+		data, err := marshalYAML(aggregate)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal reports to YAML: %v", err)
+		}
+		return string(data), nil
+	case FormatHTML:
+		return renderHTMLPage(aggregate.Reports, aggregate.Failures)
+	case FormatCSV:
+		return renderCSV(aggregate.Reports, aggregate.Failures)
+	case FormatSARIF:
+		return renderSARIF(aggregate.Reports, aggregate.Failures)
+	case FormatTemplate:
+		formatter, err := newTemplateFormatter(newFormatterOptions(opts))
+		if err != nil {
+			return "", err
+		}
+		return formatter.template.Render(aggregate)
+	case FormatText, FormatMarkdown:
+		reports, err := FormatReports(format, aggregate.Reports, opts...)
+		if err != nil {
+			return "", err
+		}
+
+		var sb strings.Builder
+		if format == FormatMarkdown {
+			sb.WriteString(fmt.Sprintf("**%s**\n\n", aggregate.Summary()))
+			for _, failure := range aggregate.Failures {
+				sb.WriteString(fmt.Sprintf("- ❌ %s\n", failure))
+			}
+		} else {
+			summary := aggregate.Summary()
+			palette := NewPalette(newFormatterOptions(opts).color)
+			sb.WriteString(palette.Summary(summary.String(), summary.Drifted > 0 || summary.Errors > 0) + "\n")
+			for _, failure := range aggregate.Failures {
+				sb.WriteString(fmt.Sprintf("Error: %s\n", failure))
+			}
+		}
+		if len(aggregate.Reports) > 0 {
+			sb.WriteString("\n")
+			sb.WriteString(reports)
+		}
+		return sb.String(), nil
+	default:
+		return "", fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
 type jsonFormatter struct{}
 
 func (f *jsonFormatter) Format(report *models.DriftReport) (string, error) {
@@ -57,14 +203,32 @@ func (f *jsonFormatter) Format(report *models.DriftReport) (string, error) {
 type yamlFormatter struct{}
 
 func (f *yamlFormatter) Format(report *models.DriftReport) (string, error) {
-	data, err := yaml.Marshal(report)
+	data, err := marshalYAML(report)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal report to YAML: %v", err)
 	}
 	return string(data), nil
 }
 
-type textFormatter struct{}
+// marshalYAML encodes v as YAML using its JSON field names, so both formats
+// share one schema. Going through JSON also drops omitempty fields.
+func marshalYAML(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+
+	return yaml.Marshal(generic)
+}
+
+type textFormatter struct {
+	palette Palette
+}
 
 func (f *textFormatter) Format(report *models.DriftReport) (string, error) {
 	if report == nil {
@@ -76,24 +240,64 @@ func (f *textFormatter) Format(report *models.DriftReport) (string, error) {
 	sb.WriteString(fmt.Sprintf("Drift Detection Report\n"))
 	sb.WriteString(fmt.Sprintf("Instance ID: %s\n", report.InstanceID))
 	sb.WriteString(fmt.Sprintf("Drift Detected: %t\n", report.HasDrift))
+	if report.Metadata != nil && report.Metadata.EffectiveConfig != nil {
+		sb.WriteString(fmt.Sprintf("Config: %s\n", report.Metadata.EffectiveConfig.Summary()))
+	}
+
+	for _, warning := range report.Warnings {
+		sb.WriteString(fmt.Sprintf("Warning: %s\n", warning))
+	}
+	if report.SuppressedByLifecycle > 0 {
+		sb.WriteString(fmt.Sprintf("Suppressed: %d finding(s) in fields listed in lifecycle ignore_changes\n", report.SuppressedByLifecycle))
+	}
+	if len(report.Acknowledged) > 0 {
+		sb.WriteString(fmt.Sprintf("Acknowledged: %d finding(s) accepted in the baseline\n", len(report.Acknowledged)))
+		for _, drift := range report.Acknowledged {
+			// Findings about the whole instance have no path
+			subject := drift.Path
+			if subject == "" {
+				subject = drift.Description
+			}
+			sb.WriteString(fmt.Sprintf("   [%s] %s\n", f.palette.DriftType(drift.Type), subject))
+		}
+	}
 
 	if !report.HasDrift {
-		sb.WriteString("\nNo configuration drift detected.\n")
+		sb.WriteString("\n" + f.palette.Summary("No configuration drift detected.", false) + "\n")
+		// Advisory findings, such as a stale AMI, are listed without drift
+		for _, drift := range report.Drifts {
+			sb.WriteString(fmt.Sprintf("   [%s] %s\n", f.palette.DriftType(drift.Type), drift.Description))
+		}
 		return sb.String(), nil
 	}
 
-	sb.WriteString(fmt.Sprintf("\nFound %d drift(s):\n\n", len(report.Drifts)))
+	sb.WriteString("\n" + f.palette.Summary(fmt.Sprintf("Found %d drift(s):", len(report.Drifts)), true) + "\n\n")
 
 	for i, drift := range report.Drifts {
-		sb.WriteString(fmt.Sprintf("%d. [%s] %s\n", i+1, drift.Type, drift.Path))
+		sb.WriteString(fmt.Sprintf("%d. [%s] %s\n", i+1, f.palette.DriftType(drift.Type), drift.Path))
 		sb.WriteString(fmt.Sprintf("   Description: %s\n", drift.Description))
+		if drift.Severity != "" {
+			sb.WriteString(fmt.Sprintf("   Severity: %s\n", f.palette.Severity(drift.Severity)))
+		}
+		if drift.Policy != nil {
+			sb.WriteString(fmt.Sprintf("   Policy: %s (%s)\n", drift.Policy.File, drift.Policy.Rule))
+		}
+		if drift.PlanStatus != "" {
+			sb.WriteString(fmt.Sprintf("   Plan: %s\n", drift.PlanStatus))
+		}
+		if drift.Source != nil {
+			sb.WriteString(fmt.Sprintf("   Defined at %s\n", drift.Source))
+		}
+		if drift.Remediation != "" {
+			sb.WriteString(fmt.Sprintf("   Suggestion: %s\n", drift.Remediation))
+		}
 
 		switch drift.Type {
 		case models.DriftTypeAdded:
 			sb.WriteString(fmt.Sprintf("   Actual: %v\n", formatValue(drift.Actual)))
 		case models.DriftTypeRemoved:
 			sb.WriteString(fmt.Sprintf("   Expected: %v\n", formatValue(drift.Expected)))
-		case models.DriftTypeModified:
+		case models.DriftTypeModified, models.DriftTypePrerequisiteViolation:
 			sb.WriteString(fmt.Sprintf("   Actual: %v\n", formatValue(drift.Actual)))
 			sb.WriteString(fmt.Sprintf("   Expected: %v\n", formatValue(drift.Expected)))
 		}
@@ -103,6 +307,9 @@ func (f *textFormatter) Format(report *models.DriftReport) (string, error) {
 	return sb.String(), nil
 }
 
+// formatValue renders a drift value on one line. Nested values, which
+// drifts hold as plain maps and slices, are written as compact JSON so their
+// keys are named and ordered.
 func formatValue(v interface{}) string {
 	switch val := v.(type) {
 	case nil:
@@ -112,6 +319,11 @@ func formatValue(v interface{}) string {
 			return "<empty>"
 		}
 		return val
+	case map[string]interface{}, []interface{}:
+		if data, err := json.Marshal(val); err == nil {
+			return string(data)
+		}
+		return fmt.Sprintf("%v", val)
 	default:
 		return fmt.Sprintf("%v", val)
 	}
@@ -1,6 +1,7 @@
 package persistence
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -44,6 +45,7 @@ func TestFormatter_JSON(t *testing.T) {
 						Actual:      "t2.micro",
 						Expected:    "t2.medium",
 						Description: "Instance type has changed",
+						Severity:    models.SeverityWarning,
 					},
 				},
 			},
@@ -56,7 +58,8 @@ func TestFormatter_JSON(t *testing.T) {
       "path": "InstanceType",
       "actual": "t2.micro",
       "expected": "t2.medium",
-      "description": "Instance type has changed"
+      "description": "Instance type has changed",
+      "severity": "WARNING"
     }
   ]
 }`,
@@ -102,6 +105,55 @@ func TestFormatter_Text(t *testing.T) {
 Instance ID: i-1234567890abcdef0
 Drift Detected: false
 
+No configuration drift detected.
+`,
+		},
+		{
+			name: "advisory findings only",
+			report: &models.DriftReport{
+				InstanceID: "i-1234567890abcdef0",
+				Drifts: []models.Drift{
+					{Type: models.DriftTypeStaleAMI, Path: "AMI", Actual: "2024-01-01", Description: "AMI ami-1 is 244 days old"},
+				},
+			},
+			expected: `Drift Detection Report
+Instance ID: i-1234567890abcdef0
+Drift Detected: false
+
+No configuration drift detected.
+   [STALE_AMI] AMI ami-1 is 244 days old
+`,
+		},
+		{
+			name: "findings suppressed by lifecycle",
+			report: &models.DriftReport{
+				InstanceID:            "i-1234567890abcdef0",
+				Drifts:                []models.Drift{},
+				SuppressedByLifecycle: 2,
+			},
+			expected: `Drift Detection Report
+Instance ID: i-1234567890abcdef0
+Drift Detected: false
+Suppressed: 2 finding(s) in fields listed in lifecycle ignore_changes
+
+No configuration drift detected.
+`,
+		},
+		{
+			name: "findings acknowledged in the baseline",
+			report: &models.DriftReport{
+				InstanceID: "i-1234567890abcdef0",
+				Drifts:     []models.Drift{},
+				Acknowledged: []models.Drift{
+					{Type: models.DriftTypeModified, Path: "RootVolumeSize", Actual: 100, Expected: 50},
+				},
+			},
+			expected: `Drift Detection Report
+Instance ID: i-1234567890abcdef0
+Drift Detected: false
+Acknowledged: 1 finding(s) accepted in the baseline
+   [MODIFIED] RootVolumeSize
+
 No configuration drift detected.
 `,
 		},
@@ -117,6 +169,7 @@ No configuration drift detected.
 						Actual:      "t2.micro",
 						Expected:    "t2.medium",
 						Description: "Instance type has changed",
+						Source:      &models.SourceLocation{File: "main.tf", Line: 42},
 					},
 					{
 						Type:        models.DriftTypeAdded,
@@ -134,6 +187,7 @@ Found 2 drift(s):
 
 1. [MODIFIED] InstanceType
    Description: Instance type has changed
+   Defined at main.tf:42
    Actual: t2.micro
    Expected: t2.medium
 
@@ -141,6 +195,36 @@ Found 2 drift(s):
    Description: Tag Environment was added
    Actual: production
 
+`,
+		},
+		{
+			name: "with a suggested remediation",
+			report: &models.DriftReport{
+				InstanceID: "i-1234567890abcdef0",
+				HasDrift:   true,
+				Drifts: []models.Drift{
+					{
+						Type:        models.DriftTypeModified,
+						Path:        "Type",
+						Actual:      "t2.micro",
+						Expected:    "t2.medium",
+						Description: "Value modified",
+						Remediation: "terraform apply will stop the instance, change its type to t2.medium and start it again",
+					},
+				},
+			},
+			expected: `Drift Detection Report
+Instance ID: i-1234567890abcdef0
+Drift Detected: true
+
+Found 1 drift(s):
+
+1. [MODIFIED] Type
+   Description: Value modified
+   Suggestion: terraform apply will stop the instance, change its type to t2.medium and start it again
+   Actual: t2.micro
+   Expected: t2.medium
+
 `,
 		},
 	}
@@ -162,3 +246,93 @@ func TestNewFormatter_UnsupportedFormat(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "unsupported format")
 }
+
+func TestFormatReports(t *testing.T) {
+	reports := []*models.DriftReport{
+		{InstanceID: "i-1", Drifts: []models.Drift{}},
+		{InstanceID: "i-2", HasDrift: true, Drifts: []models.Drift{
+			{Type: models.DriftTypeModified, Path: "Type", Expected: "t2.micro", Actual: "t2.small"},
+		}},
+	}
+
+	t.Run("json list", func(t *testing.T) {
+		result, err := FormatReports(FormatJSON, reports)
+		assert.NoError(t, err)
+		assert.Contains(t, result, `"instance_id": "i-1"`)
+		assert.Contains(t, result, `"instance_id": "i-2"`)
+		assert.Equal(t, "[", result[:1])
+	})
+
+	t.Run("yaml list", func(t *testing.T) {
+		result, err := FormatReports(FormatYAML, reports)
+		assert.NoError(t, err)
+		assert.Contains(t, result, "instance_id: i-1")
+		assert.Contains(t, result, "instance_id: i-2")
+		assert.Contains(t, result, "has_drift: true")
+	})
+
+	t.Run("text", func(t *testing.T) {
+		result, err := FormatReports(FormatText, reports)
+		assert.NoError(t, err)
+		assert.Contains(t, result, "Instance ID: i-1")
+		assert.Contains(t, result, "Instance ID: i-2")
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := FormatReports("xml", reports)
+		assert.Error(t, err)
+	})
+}
+
+func TestFormatAggregate(t *testing.T) {
+	aggregate := models.NewAggregateReport([]*models.DriftReport{
+		{InstanceID: "i-1", Drifts: []models.Drift{}},
+		{InstanceID: "i-2", HasDrift: true, Drifts: []models.Drift{
+			{Type: models.DriftTypeModified, Path: "Type", Expected: "t2.micro", Actual: "t2.small"},
+		}},
+	}, []string{"i-3: access denied"})
+
+	t.Run("json", func(t *testing.T) {
+		result, err := FormatAggregate(FormatJSON, aggregate)
+		assert.NoError(t, err)
+		assert.Contains(t, result, `"total_instances": 3`)
+		assert.Contains(t, result, `"drifted": 1`)
+		assert.Contains(t, result, `"errors": 1`)
+		assert.Contains(t, result, `"i-3: access denied"`)
+		assert.Contains(t, result, `"instance_id": "i-2"`)
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		result, err := FormatAggregate(FormatYAML, aggregate)
+		assert.NoError(t, err)
+		assert.Contains(t, result, "total_instances: 3")
+		assert.Contains(t, result, "instance_id: i-1")
+	})
+
+	t.Run("text", func(t *testing.T) {
+		result, err := FormatAggregate(FormatText, aggregate)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(result, "Checked 3 instance(s), 1 with drift, 1 error(s)\nError: i-3: access denied\n"))
+		assert.Contains(t, result, "Instance ID: i-2")
+	})
+
+	t.Run("markdown", func(t *testing.T) {
+		result, err := FormatAggregate(FormatMarkdown, aggregate)
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(result, "**Checked 3 instance(s), 1 with drift, 1 error(s)**"))
+		assert.Contains(t, result, "- ❌ i-3: access denied")
+	})
+
+	t.Run("html", func(t *testing.T) {
+		result, err := FormatAggregate(FormatHTML, aggregate)
+		assert.NoError(t, err)
+		assert.Contains(t, result, "<strong>3</strong>instance(s)")
+		assert.Contains(t, result, "<strong>1</strong>error(s)")
+		assert.Contains(t, result, "Error: i-3: access denied")
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		_, err := FormatAggregate(FormatJSON, nil)
+		assert.Error(t, err)
+	})
+}
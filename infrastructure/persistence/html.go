@@ -0,0 +1,137 @@
+package persistence
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"driftdetector/domain/models"
+)
+
+//go:embed templates/report.html.tmpl
+var templateFS embed.FS
+
+// reportTemplate renders one or more drift reports as a standalone HTML page
+var reportTemplate = template.Must(template.ParseFS(templateFS, "templates/report.html.tmpl"))
+
+// htmlPage is the data passed to reportTemplate. A summary page lists
+// Instances instead of Reports.
+type htmlPage struct {
+	Reports   []htmlReport
+	Instances []models.ReportSummary
+	Failures  []string
+	Total     int
+	Drifted   int
+	Findings  int
+}
+
+// htmlReport is one instance section of the page
+type htmlReport struct {
+	InstanceID string
+	Config     string
+	Warnings   []string
+	Drifts     []htmlDrift
+}
+
+// htmlDrift is one table row, with its values already rendered as text
+type htmlDrift struct {
+	Class       string
+	Type        models.DriftType
+	Path        string
+	Severity    models.Severity
+	Description string
+	Expected    string
+	Actual      string
+}
+
+type htmlFormatter struct{}
+
+func (f *htmlFormatter) Format(report *models.DriftReport) (string, error) {
+	var reports []*models.DriftReport
+	if report != nil {
+		reports = append(reports, report)
+	}
+	return renderHTML(reports)
+}
+
+// renderHTML renders the reports as one page, skipping nil reports
+func renderHTML(reports []*models.DriftReport) (string, error) {
+	return renderHTMLPage(reports, nil)
+}
+
+// renderHTMLPage renders the reports as one page, listing failures, the
+// instances that could not be checked, below the summary
+func renderHTMLPage(reports []*models.DriftReport, failures []string) (string, error) {
+	page := htmlPage{Failures: failures}
+	for _, report := range reports {
+		if report == nil {
+			continue
+		}
+
+		section := htmlReport{InstanceID: report.InstanceID, Warnings: report.Warnings}
+		if report.Metadata != nil && report.Metadata.EffectiveConfig != nil {
+			section.Config = report.Metadata.EffectiveConfig.Summary()
+		}
+		for _, d := range report.Drifts {
+			section.Drifts = append(section.Drifts, htmlDrift{
+				Class:       strings.ToLower(string(d.Type)),
+				Type:        d.Type,
+				Path:        d.Path,
+				Severity:    d.Severity,
+				Description: d.Description,
+				Expected:    htmlValue(d.Expected),
+				Actual:      htmlValue(d.Actual),
+			})
+		}
+
+		if report.HasDrifts() {
+			page.Drifted++
+		}
+		page.Findings += len(report.Drifts)
+		page.Reports = append(page.Reports, section)
+	}
+	page.Total = len(page.Reports) + len(failures)
+
+	var sb strings.Builder
+	if err := reportTemplate.Execute(&sb, page); err != nil {
+		return "", fmt.Errorf("failed to render HTML report: %v", err)
+	}
+	return sb.String(), nil
+}
+
+// renderHTMLSummary renders a page with one table row per instance
+func renderHTMLSummary(summary models.AggregateSummary) (string, error) {
+	page := htmlPage{
+		Instances: summary.Instances,
+		Failures:  summary.Failures,
+		Total:     summary.TotalInstances,
+		Drifted:   summary.Drifted,
+		Findings:  summary.Findings,
+	}
+
+	var sb strings.Builder
+	if err := reportTemplate.Execute(&sb, page); err != nil {
+		return "", fmt.Errorf("failed to render HTML summary: %v", err)
+	}
+	return sb.String(), nil
+}
+
+// htmlValue renders a drift value, pretty-printing structured values as JSON
+func htmlValue(v interface{}) string {
+	switch v.(type) {
+	case nil, string:
+		return formatValue(v)
+	}
+
+	// The template escapes HTML, so the JSON encoder need not
+	var sb strings.Builder
+	enc := json.NewEncoder(&sb)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return formatValue(v)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
@@ -0,0 +1,89 @@
+package persistence
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files with the current output")
+
+// assertGolden compares got with testdata/<name>, rewriting the file when -update is set
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		require.NoError(t, os.MkdirAll("testdata", 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err, "missing golden file; run go test with -update")
+	assert.Equal(t, string(want), got)
+}
+
+func TestFormatter_HTML(t *testing.T) {
+	encrypted := true
+
+	tests := []struct {
+		name   string
+		report *models.DriftReport
+		golden string
+	}{
+		{
+			name:   "nil report",
+			report: nil,
+			golden: "html_nil.golden",
+		},
+		{
+			name:   "empty report",
+			report: models.NewDriftReport("i-1234567890abcdef0"),
+			golden: "html_empty.golden",
+		},
+		{
+			name: "report with drifts",
+			report: &models.DriftReport{
+				InstanceID: "i-1234567890abcdef0",
+				HasDrift:   true,
+				Drifts: []models.Drift{
+					{Type: models.DriftTypeModified, Path: "Type", Actual: "t2.micro", Expected: "t2.medium", Description: "Value mismatch", Severity: models.SeverityWarning},
+					{Type: models.DriftTypeAdded, Path: "EBSBlockDevices[/dev/sdg]", Expected: models.EBSBlockDevice{DeviceName: "/dev/sdg", VolumeSize: 20, Encrypted: &encrypted}, Description: "Element declared in Terraform is missing in AWS", Severity: models.SeverityWarning},
+					{Type: models.DriftTypeRemoved, Path: "SecurityGroups[sg-1]", Actual: models.SecurityGroup{GroupID: "sg-1", GroupName: "<admin>"}, Description: "Element exists in AWS but not in Terraform", Severity: models.SeverityCritical},
+				},
+			},
+			golden: "html_drifts.golden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter, err := NewFormatter(FormatHTML)
+			require.NoError(t, err)
+
+			result, err := formatter.Format(tt.report)
+
+			require.NoError(t, err)
+			assertGolden(t, tt.golden, result)
+		})
+	}
+}
+
+func TestFormatReports_HTML(t *testing.T) {
+	reports := []*models.DriftReport{
+		models.NewDriftReport("i-1"),
+		{InstanceID: "i-2", HasDrift: true, Drifts: []models.Drift{
+			{Type: models.DriftTypeModified, Path: "AMI", Actual: "ami-2", Expected: "ami-1", Description: "Value mismatch"},
+		}},
+	}
+
+	result, err := FormatReports(FormatHTML, reports)
+
+	require.NoError(t, err)
+	assertGolden(t, "html_reports.golden", result)
+}
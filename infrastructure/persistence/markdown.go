@@ -0,0 +1,162 @@
+package persistence
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"driftdetector/domain/models"
+)
+
+// defaultMaxValueLength is the longest value shown in a markdown report before truncation
+const defaultMaxValueLength = 200
+
+// truncatedMarker is appended to values cut at the maximum length
+const truncatedMarker = "… (truncated)"
+
+// FormatterOption configures a Formatter
+type FormatterOption func(*formatterOptions)
+
+// formatterOptions holds the settings shared by formatters
+type formatterOptions struct {
+	maxValueLength int
+	color          bool
+	template       *ReportTemplate
+}
+
+// WithMaxValueLength truncates values longer than n characters in markdown
+// reports. Zero or less disables truncation.
+func WithMaxValueLength(n int) FormatterOption {
+	return func(o *formatterOptions) {
+		o.maxValueLength = n
+	}
+}
+
+// newFormatterOptions applies opts over the defaults
+func newFormatterOptions(opts []FormatterOption) formatterOptions {
+	o := formatterOptions{maxValueLength: defaultMaxValueLength}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+type markdownFormatter struct {
+	maxValueLength int
+}
+
+func (f *markdownFormatter) Format(report *models.DriftReport) (string, error) {
+	if report == nil {
+		return "No report data available\n", nil
+	}
+
+	var sb strings.Builder
+	if !report.HasDrifts() {
+		sb.WriteString(fmt.Sprintf("✅ No drift detected on %s\n", report.InstanceID))
+		if len(report.Acknowledged) > 0 {
+			sb.WriteString(fmt.Sprintf("\n> %d finding(s) acknowledged in the baseline\n", len(report.Acknowledged)))
+		}
+		// Advisory findings, such as a stale AMI, are noted without drift
+		for _, d := range report.Drifts {
+			sb.WriteString(fmt.Sprintf("\n> Note: %s\n", d.Description))
+		}
+		return sb.String(), nil
+	}
+
+	sb.WriteString(fmt.Sprintf("⚠️ %d drift(s) detected on %s\n\n", len(report.Drifts), report.InstanceID))
+	for _, warning := range report.Warnings {
+		sb.WriteString(fmt.Sprintf("> Warning: %s\n\n", warning))
+	}
+	if report.SuppressedByLifecycle > 0 {
+		sb.WriteString(fmt.Sprintf("> %d finding(s) suppressed by lifecycle ignore_changes\n\n", report.SuppressedByLifecycle))
+	}
+	if len(report.Acknowledged) > 0 {
+		sb.WriteString(fmt.Sprintf("> %d finding(s) acknowledged in the baseline\n\n", len(report.Acknowledged)))
+	}
+
+	sb.WriteString("| Path | Type | Terraform | AWS |\n")
+	sb.WriteString("|------|------|-----------|-----|\n")
+
+	// Multi-line values do not fit in a table cell, so they follow the table
+	var blocks strings.Builder
+	for _, d := range report.Drifts {
+		path := fmt.Sprintf("`%s`", escapeMarkdownCell(d.Path))
+		if d.Source != nil {
+			path += fmt.Sprintf(" (defined at %s)", escapeMarkdownCell(d.Source.String()))
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n",
+			path, d.Type,
+			f.cell(d.Expected, d.Path, "Terraform", &blocks),
+			f.cell(d.Actual, d.Path, "AWS", &blocks)))
+	}
+
+	if blocks.Len() > 0 {
+		sb.WriteString("\n")
+		sb.WriteString(blocks.String())
+	}
+
+	// Suggestions are too long for a table column
+	var suggestions strings.Builder
+	for _, d := range report.Drifts {
+		if d.Remediation != "" {
+			suggestions.WriteString(fmt.Sprintf("- `%s`: %s\n", d.Path, d.Remediation))
+		}
+	}
+	if suggestions.Len() > 0 {
+		sb.WriteString("\n**Suggested fixes**\n\n")
+		sb.WriteString(suggestions.String())
+	}
+
+	return sb.String(), nil
+}
+
+// cell renders a value for a table cell. A multi-line value is written to
+// blocks as a fenced code block and the cell refers to it.
+func (f *markdownFormatter) cell(v interface{}, path, side string, blocks *strings.Builder) string {
+	switch val := v.(type) {
+	case nil:
+		return "_none_"
+	case string:
+		if val == "" {
+			return "_empty_"
+		}
+	}
+
+	value := f.value(v)
+	if !strings.Contains(value, "\n") {
+		return escapeMarkdownCell(value)
+	}
+
+	fence := "```"
+	for strings.Contains(value, fence) {
+		fence += "`"
+	}
+	blocks.WriteString(fmt.Sprintf("**%s** (%s):\n\n%s\n%s\n%s\n\n", escapeMarkdownCell(path), side, fence, strings.TrimSuffix(value, "\n"), fence))
+	return "_see below_"
+}
+
+// value renders a drift value as text, truncated to the maximum length
+func (f *markdownFormatter) value(v interface{}) string {
+	var s string
+	if str, ok := v.(string); ok {
+		s = str
+	} else if data, err := json.Marshal(v); err == nil {
+		s = string(data)
+	} else {
+		s = formatValue(v)
+	}
+
+	if runes := []rune(s); f.maxValueLength > 0 && len(runes) > f.maxValueLength {
+		s = string(runes[:f.maxValueLength]) + truncatedMarker
+	}
+	return s
+}
+
+// markdownCellEscaper keeps a single-line value from breaking the table or
+// being read as HTML
+var markdownCellEscaper = strings.NewReplacer("|", `\|`, "<", "&lt;", ">", "&gt;")
+
+// escapeMarkdownCell escapes a value for a table cell
+func escapeMarkdownCell(s string) string {
+	return markdownCellEscaper.Replace(s)
+}
@@ -0,0 +1,117 @@
+package persistence
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+)
+
+func TestFormatter_Markdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		report *models.DriftReport
+		opts   []FormatterOption
+		golden string
+	}{
+		{
+			name:   "empty report",
+			report: models.NewDriftReport("i-abc123"),
+			golden: "markdown_empty.golden",
+		},
+		{
+			name: "report with drifts",
+			report: &models.DriftReport{
+				InstanceID: "i-abc123",
+				HasDrift:   true,
+				Drifts: []models.Drift{
+					{Type: models.DriftTypeModified, Path: "Type", Actual: "t3.large", Expected: "t3.micro", Source: &models.SourceLocation{File: "main.tf", Line: 42}},
+					{Type: models.DriftTypeModified, Path: ".Tags.Owner", Actual: "team|ops", Expected: "<unset>"},
+					{Type: models.DriftTypeAdded, Path: "SecurityGroups[sg-1]", Expected: models.SecurityGroup{GroupID: "sg-1", GroupName: "web"}},
+					{Type: models.DriftTypeModified, Path: "UserData", Actual: "#!/bin/bash\necho hello\n", Expected: ""},
+				},
+			},
+			golden: "markdown_drifts.golden",
+		},
+		{
+			name: "long values are truncated",
+			report: &models.DriftReport{
+				InstanceID: "i-abc123",
+				HasDrift:   true,
+				Drifts: []models.Drift{
+					{Type: models.DriftTypeModified, Path: "IAMInstanceProfile", Actual: strings.Repeat("a", 30), Expected: "short"},
+				},
+			},
+			opts:   []FormatterOption{WithMaxValueLength(10)},
+			golden: "markdown_truncated.golden",
+		},
+		{
+			name: "suggested fixes follow the table",
+			report: &models.DriftReport{
+				InstanceID: "i-abc123",
+				HasDrift:   true,
+				Drifts: []models.Drift{
+					{Type: models.DriftTypeModified, Path: "Type", Actual: "t3.large", Expected: "t3.micro", Remediation: "terraform apply will stop the instance, change its type to t3.micro and start it again"},
+					{Type: models.DriftTypeRemoved, Path: ".Tags.Owner", Actual: "ops", Remediation: "terraform apply will remove the tag, or remove it now with: aws ec2 delete-tags --resources i-abc123 --tags Key=Owner"},
+					{Type: models.DriftTypePolicyViolation, Path: "Monitoring", Actual: false},
+				},
+			},
+			golden: "markdown_remediation.golden",
+		},
+		{
+			name: "acknowledged findings are counted",
+			report: &models.DriftReport{
+				InstanceID: "i-abc123",
+				HasDrift:   true,
+				Drifts: []models.Drift{
+					{Type: models.DriftTypeModified, Path: "Type", Actual: "t3.large", Expected: "t3.micro"},
+				},
+				Acknowledged: []models.Drift{
+					{Type: models.DriftTypeModified, Path: "RootVolumeSize", Actual: 100, Expected: 50},
+					{Type: models.DriftTypeRemoved, Path: ".Tags.Owner", Actual: "ops"},
+				},
+			},
+			golden: "markdown_acknowledged.golden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatter, err := NewFormatter(FormatMarkdown, tt.opts...)
+			require.NoError(t, err)
+
+			result, err := formatter.Format(tt.report)
+
+			require.NoError(t, err)
+			assertGolden(t, tt.golden, result)
+		})
+	}
+}
+
+func TestFormatter_MarkdownNilReport(t *testing.T) {
+	formatter, err := NewFormatter(FormatMarkdown)
+	require.NoError(t, err)
+
+	result, err := formatter.Format(nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "No report data available\n", result)
+}
+
+func TestFormatter_MarkdownAdvisoryOnly(t *testing.T) {
+	// Given a report whose only finding is a stale AMI
+	report := models.NewDriftReport("i-abc123")
+	report.AddAdvisory(models.Drift{Type: models.DriftTypeStaleAMI, Path: "AMI", Description: "AMI ami-1 is 244 days old"})
+	formatter, err := NewFormatter(FormatMarkdown)
+	require.NoError(t, err)
+
+	// When it is formatted
+	result, err := formatter.Format(report)
+
+	// Then it reads as no drift, with the finding noted
+	require.NoError(t, err)
+	assert.Equal(t, "✅ No drift detected on i-abc123\n\n> Note: AMI ami-1 is 244 days old\n", result)
+}
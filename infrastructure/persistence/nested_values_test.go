@@ -0,0 +1,51 @@
+package persistence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+)
+
+// nestedValuesReport holds drifts whose values are a struct, a slice of
+// structs and pointers, as the detector reports for fields set on one side
+func nestedValuesReport() *models.DriftReport {
+	encrypted := true
+	report := models.NewDriftReport("i-1234567890abcdef0")
+	report.AddDrift(models.NewDrift(models.DriftTypeRemoved, "MetadataOptions",
+		&models.MetadataOptions{HTTPEndpoint: "enabled", HTTPTokens: "optional", HTTPPutResponseHopLimit: 2}, nil,
+		"Value set in AWS is not declared in Terraform"))
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, "NetworkInterfaces[1].Groups",
+		[]models.SecurityGroup{{GroupID: "sg-1", GroupName: "web"}, {GroupID: "sg-2"}},
+		[]models.SecurityGroup{{GroupID: "sg-1", GroupName: "web"}},
+		"Length mismatch"))
+	report.AddDrift(models.NewDrift(models.DriftTypeAdded, "EBSBlockDevices[/dev/sdf]", nil,
+		&models.EBSBlockDevice{DeviceName: "/dev/sdf", VolumeSize: 20, Encrypted: &encrypted},
+		"Value declared in Terraform is not set in AWS"))
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, "Monitoring", &encrypted, new(bool), "Value mismatch"))
+	return report
+}
+
+func TestFormatter_NestedValues(t *testing.T) {
+	tests := []struct {
+		format FormatType
+		golden string
+	}{
+		{format: FormatJSON, golden: "nested_values_json.golden"},
+		{format: FormatYAML, golden: "nested_values_yaml.golden"},
+		{format: FormatText, golden: "nested_values_text.golden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			formatter, err := NewFormatter(tt.format)
+			require.NoError(t, err)
+
+			result, err := formatter.Format(nestedValuesReport())
+
+			require.NoError(t, err)
+			assertGolden(t, tt.golden, result)
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package persistence
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"driftdetector/infrastructure/awsutil"
+	"driftdetector/infrastructure/logger"
+)
+
+// Writer delivers rendered output, such as a formatted report, to where it is kept
+type Writer interface {
+	// Write stores data under name. Destinations that hold a single
+	// output, such as stdout or a file, ignore name.
+	Write(ctx context.Context, name string, data []byte) error
+}
+
+// Ensure the writers implement the Writer interface
+var (
+	_ Writer = (*StreamWriter)(nil)
+	_ Writer = (*FileWriter)(nil)
+	_ Writer = (*DirWriter)(nil)
+	_ Writer = (*S3Writer)(nil)
+	_ Writer = (*FallbackWriter)(nil)
+)
+
+// StreamWriter writes output to a stream such as stdout
+type StreamWriter struct {
+	out io.Writer
+}
+
+// NewStreamWriter creates a StreamWriter writing to out
+func NewStreamWriter(out io.Writer) *StreamWriter {
+	return &StreamWriter{out: out}
+}
+
+// Write writes data to the stream
+func (w *StreamWriter) Write(_ context.Context, _ string, data []byte) error {
+	_, err := w.out.Write(data)
+	return err
+}
+
+// FileWriter writes output to a single file, replacing its content
+type FileWriter struct {
+	path string
+}
+
+// NewFileWriter creates a FileWriter for path
+func NewFileWriter(path string) *FileWriter {
+	return &FileWriter{path: path}
+}
+
+// Write writes data to the file, creating its parent directories if needed
+func (w *FileWriter) Write(_ context.Context, _ string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(w.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	return nil
+}
+
+// DirWriter writes each output to its own file in a directory
+type DirWriter struct {
+	dir string
+}
+
+// NewDirWriter creates a DirWriter for dir, creating the directory if needed
+func NewDirWriter(dir string) (*DirWriter, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create report directory: %w", err)
+	}
+	return &DirWriter{dir: dir}, nil
+}
+
+// Write writes data to the file name in the directory
+func (w *DirWriter) Write(_ context.Context, name string, data []byte) error {
+	if err := os.WriteFile(filepath.Join(w.dir, name), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+	return nil
+}
+
+// S3Writer uploads each output as an object under an S3 prefix
+type S3Writer struct {
+	client awsutil.S3PutObjectAPI
+	bucket string
+	prefix string
+	retry  awsutil.RetryOptions
+}
+
+// S3WriterOption configures an S3Writer
+type S3WriterOption func(*S3Writer)
+
+// WithS3RetryOptions sets the retry and timeout behaviour for uploads
+func WithS3RetryOptions(opts awsutil.RetryOptions) S3WriterOption {
+	return func(w *S3Writer) {
+		w.retry = opts
+	}
+}
+
+// NewS3Writer creates an S3Writer uploading to location, an
+// s3://bucket/prefix/ URL; the prefix may be empty
+func NewS3Writer(client awsutil.S3PutObjectAPI, location string, opts ...S3WriterOption) (*S3Writer, error) {
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(location, "s3://"), "/")
+	if !strings.HasPrefix(location, "s3://") || bucket == "" {
+		return nil, fmt.Errorf("invalid S3 output location %q: expected s3://bucket/prefix/", location)
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+
+	w := &S3Writer{client: client, bucket: bucket, prefix: prefix, retry: awsutil.DefaultRetryOptions()}
+	for _, opt := range opts {
+		opt(w)
+	}
+	return w, nil
+}
+
+// Write uploads data as the object <prefix><name>, retrying throttled and
+// transient failures
+func (w *S3Writer) Write(ctx context.Context, name string, data []byte) error {
+	key := w.prefix + name
+	err := w.retry.Do(ctx, func(ctx context.Context) error {
+		_, err := w.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(w.bucket),
+			Key:    aws.String(key),
+			Body:   bytes.NewReader(data),
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload s3://%s/%s: %w", w.bucket, key, awsutil.WrapError(err))
+	}
+	return nil
+}
+
+// FallbackWriter writes to a primary writer and, when that fails, to a
+// fallback, so output that has been computed is not lost
+type FallbackWriter struct {
+	primary  Writer
+	fallback Writer
+}
+
+// NewFallbackWriter creates a FallbackWriter
+func NewFallbackWriter(primary, fallback Writer) *FallbackWriter {
+	return &FallbackWriter{primary: primary, fallback: fallback}
+}
+
+// Write writes data to the primary writer, else logs a warning and writes it
+// to the fallback. Only a failure of both is returned.
+func (w *FallbackWriter) Write(ctx context.Context, name string, data []byte) error {
+	err := w.primary.Write(ctx, name, data)
+	if err == nil {
+		return nil
+	}
+	logger.Warn("failed to write output, writing it to the fallback instead", "name", name, "error", err)
+	if fallbackErr := w.fallback.Write(ctx, name, data); fallbackErr != nil {
+		return fmt.Errorf("%w; fallback also failed: %w", err, fallbackErr)
+	}
+	return nil
+}
+
+// ReportFileName returns the name a report of the instance is written under:
+// <instance-id>, followed by -<UTC timestamp> unless at is zero, and the
+// extension of format
+func ReportFileName(instanceID string, at time.Time, format FormatType) string {
+	name := instanceID
+	if !at.IsZero() {
+		name += "-" + at.UTC().Format(reportTimestampLayout)
+	}
+	return name + reportFileExtensions[format]
+}
@@ -0,0 +1,144 @@
+package persistence
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/infrastructure/awsutil"
+)
+
+// fakeS3 records uploaded objects, failing the first failures uploads
+type fakeS3 struct {
+	objects  map[string]string
+	failures int
+	err      error
+	calls    int
+}
+
+func (f *fakeS3) PutObject(_ context.Context, params *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, f.err
+	}
+	data, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	if f.objects == nil {
+		f.objects = make(map[string]string)
+	}
+	f.objects[aws.ToString(params.Bucket)+"/"+aws.ToString(params.Key)] = string(data)
+	return &s3.PutObjectOutput{}, nil
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write(context.Context, string, []byte) error {
+	return errors.New("unavailable")
+}
+
+func noWaitRetry(attempts int) S3WriterOption {
+	return WithS3RetryOptions(awsutil.RetryOptions{MaxAttempts: attempts, BaseDelay: time.Nanosecond, MaxDelay: time.Nanosecond})
+}
+
+func TestFileWriter_CreatesParentDirectories(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out", "nested", "report.json")
+
+	require.NoError(t, NewFileWriter(path).Write(context.Background(), "ignored.json", []byte("{}")))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(data))
+}
+
+func TestStreamWriter_Write(t *testing.T) {
+	var out bytes.Buffer
+
+	require.NoError(t, NewStreamWriter(&out).Write(context.Background(), "ignored", []byte("report\n")))
+
+	assert.Equal(t, "report\n", out.String())
+}
+
+func TestNewS3Writer_Location(t *testing.T) {
+	tests := []struct {
+		location string
+		key      string
+		wantErr  bool
+	}{
+		{location: "s3://bucket/drift/", key: "bucket/drift/i-1.json"},
+		{location: "s3://bucket/drift", key: "bucket/drift/i-1.json"},
+		{location: "s3://bucket", key: "bucket/i-1.json"},
+		{location: "s3://bucket/", key: "bucket/i-1.json"},
+		{location: "bucket/drift/", wantErr: true},
+		{location: "s3:///drift/", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			client := &fakeS3{}
+			w, err := NewS3Writer(client, tt.location)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			require.NoError(t, w.Write(context.Background(), "i-1.json", []byte("{}")))
+			assert.Equal(t, map[string]string{tt.key: "{}"}, client.objects)
+		})
+	}
+}
+
+func TestS3Writer_RetriesTransientFailures(t *testing.T) {
+	client := &fakeS3{failures: 2, err: &smithy.GenericAPIError{Code: "SlowDown"}}
+	w, err := NewS3Writer(client, "s3://bucket/drift/", noWaitRetry(3))
+	require.NoError(t, err)
+
+	require.NoError(t, w.Write(context.Background(), "aggregate.json", []byte("{}")))
+
+	assert.Equal(t, 3, client.calls)
+	assert.Contains(t, client.objects, "bucket/drift/aggregate.json")
+}
+
+func TestS3Writer_GivesUp(t *testing.T) {
+	client := &fakeS3{failures: 5, err: &smithy.GenericAPIError{Code: "ServiceUnavailable"}}
+	w, err := NewS3Writer(client, "s3://bucket/drift/", noWaitRetry(2))
+	require.NoError(t, err)
+
+	err = w.Write(context.Background(), "i-1.json", []byte("{}"))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "s3://bucket/drift/i-1.json")
+	assert.Equal(t, 2, client.calls)
+}
+
+func TestFallbackWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := NewFallbackWriter(failingWriter{}, NewStreamWriter(&out))
+
+	require.NoError(t, w.Write(context.Background(), "i-1.json", []byte("report")))
+	assert.Equal(t, "report", out.String())
+
+	err := NewFallbackWriter(failingWriter{}, failingWriter{}).Write(context.Background(), "i-1.json", nil)
+	assert.Error(t, err)
+}
+
+func TestReportFileName(t *testing.T) {
+	at := time.Date(2025, 7, 3, 14, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	assert.Equal(t, "i-1-20250703T123000Z.yaml", ReportFileName("i-1", at, FormatYAML))
+	assert.Equal(t, "i-1.md", ReportFileName("i-1", time.Time{}, FormatMarkdown))
+}
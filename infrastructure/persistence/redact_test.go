@@ -0,0 +1,41 @@
+package persistence
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+func TestFormatter_RedactedReport(t *testing.T) {
+	const secret = "export DB_PASSWORD=hunter2"
+	report := models.NewDriftReport("i-1")
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, "UserData", secret, "export DB_PASSWORD=changeme", "User data differs"))
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, "RootVolumeKMSKeyID", "arn:aws:kms:us-east-1:123456789012:key/abc", nil, "Value mismatch"))
+
+	redactor, err := services.NewRedactor()
+	require.NoError(t, err)
+	redacted := redactor.Redact(report)
+	fingerprint := services.RedactValue(secret).(string)
+
+	for _, format := range []FormatType{FormatText, FormatJSON, FormatYAML, FormatHTML, FormatMarkdown, FormatCSV, FormatSARIF} {
+		t.Run(string(format), func(t *testing.T) {
+			formatter, err := NewFormatter(format)
+			require.NoError(t, err)
+			out, err := formatter.Format(redacted)
+			require.NoError(t, err)
+
+			assert.NotContains(t, out, "hunter2")
+			assert.NotContains(t, out, "key/abc")
+			assert.Contains(t, out, fingerprint[:len("sha256:")+8])
+		})
+	}
+
+	// Only the presentation changes: the report still records the drift
+	assert.True(t, redacted.HasDrifts())
+	assert.Len(t, redacted.Drifts, 2)
+	assert.Equal(t, secret, report.Drifts[0].Actual)
+}
@@ -0,0 +1,87 @@
+package persistence
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"driftdetector/domain/models"
+)
+
+// reportTimestampLayout is the UTC timestamp ReportWriter puts in file names
+const reportTimestampLayout = "20060102T150405Z"
+
+// ReportHistory reads the JSON reports a ReportWriter saved in a directory
+type ReportHistory struct {
+	dir string
+}
+
+// NewReportHistory creates a ReportHistory for the reports in dir
+func NewReportHistory(dir string) *ReportHistory {
+	return &ReportHistory{dir: dir}
+}
+
+// GetDriftHistory returns up to limit of the instance's most recent reports,
+// oldest first; a limit of 0 or less returns all of them. Reports without
+// checked_at take the timestamp from their file name. A missing directory
+// has no history.
+func (h *ReportHistory) GetDriftHistory(instanceID string, limit int) ([]*models.DriftReport, error) {
+	entries, err := os.ReadDir(h.dir)
+	if os.IsNotExist(err) {
+		return []*models.DriftReport{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report directory: %w", err)
+	}
+
+	prefix := instanceID + "-"
+	reports := make([]*models.DriftReport, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) || filepath.Ext(name) != reportFileExtensions[FormatJSON] {
+			continue
+		}
+		at, err := time.Parse(reportTimestampLayout, strings.TrimSuffix(strings.TrimPrefix(name, prefix), filepath.Ext(name)))
+		if err != nil {
+			// Another instance whose ID starts with instanceID
+			continue
+		}
+
+		report, err := readReport(filepath.Join(h.dir, name))
+		if err != nil {
+			return nil, err
+		}
+		if report.InstanceID != instanceID {
+			continue
+		}
+		if report.CheckedAt.IsZero() {
+			report.CheckedAt = at
+		}
+		reports = append(reports, report)
+	}
+
+	sort.SliceStable(reports, func(i, j int) bool {
+		return reports[i].CheckedAt.Before(reports[j].CheckedAt)
+	})
+	if limit > 0 && len(reports) > limit {
+		reports = reports[len(reports)-limit:]
+	}
+	return reports, nil
+}
+
+// readReport decodes the JSON report at path
+func readReport(path string) (*models.DriftReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	var report models.DriftReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %w", path, err)
+	}
+	return &report, nil
+}
@@ -0,0 +1,81 @@
+package persistence
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+)
+
+func TestReportHistory_GetDriftHistory(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	writer, err := NewReportWriter(dir, FormatJSON)
+	require.NoError(t, err)
+
+	start := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	for _, hours := range []int{2, 0, 1} {
+		report := models.NewDriftReport("i-1")
+		report.AddDrift(models.NewDrift(models.DriftTypeModified, "Type", "t3.large", "t3.micro", ""))
+		_, err := writer.Write(ctx, report, start.Add(time.Duration(hours)*time.Hour))
+		require.NoError(t, err)
+	}
+	_, err = writer.Write(ctx, models.NewDriftReport("i-10"), start)
+	require.NoError(t, err)
+	textWriter, err := NewReportWriter(dir, FormatText)
+	require.NoError(t, err)
+	_, err = textWriter.Write(ctx, models.NewDriftReport("i-1"), start.Add(5*time.Hour))
+	require.NoError(t, err)
+
+	history := NewReportHistory(dir)
+	reports, err := history.GetDriftHistory("i-1", 0)
+
+	require.NoError(t, err)
+	require.Len(t, reports, 3)
+	for i, report := range reports {
+		assert.Equal(t, "i-1", report.InstanceID)
+		assert.Equal(t, start.Add(time.Duration(i)*time.Hour), report.CheckedAt)
+		require.Len(t, report.Drifts, 1)
+		assert.Equal(t, "Type", report.Drifts[0].Path)
+	}
+
+	recent, err := history.GetDriftHistory("i-1", 2)
+	require.NoError(t, err)
+	require.Len(t, recent, 2)
+	assert.Equal(t, start.Add(time.Hour), recent[0].CheckedAt)
+}
+
+func TestReportHistory_CheckedAtFromFileName(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "i-1-20250703T123000Z.json"),
+		[]byte(`{"instance_id": "i-1", "has_drift": false, "drifts": []}`), 0o644))
+
+	reports, err := NewReportHistory(dir).GetDriftHistory("i-1", 0)
+
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Equal(t, time.Date(2025, 7, 3, 12, 30, 0, 0, time.UTC), reports[0].CheckedAt)
+}
+
+func TestReportHistory_MissingDirectory(t *testing.T) {
+	reports, err := NewReportHistory(filepath.Join(t.TempDir(), "missing")).GetDriftHistory("i-1", 0)
+
+	require.NoError(t, err)
+	assert.Empty(t, reports)
+}
+
+func TestReportHistory_InvalidReport(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "i-1-20250703T123000Z.json"), []byte(`{`), 0o644))
+
+	_, err := NewReportHistory(dir).GetDriftHistory("i-1", 0)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "i-1-20250703T123000Z.json")
+}
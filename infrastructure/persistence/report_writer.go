@@ -0,0 +1,67 @@
+package persistence
+
+import (
+	"context"
+	"time"
+
+	"driftdetector/domain/models"
+)
+
+// reportFileExtensions maps output formats to the extension of report files
+var reportFileExtensions = map[FormatType]string{
+	FormatJSON:     ".json",
+	FormatYAML:     ".yaml",
+	FormatText:     ".txt",
+	FormatHTML:     ".html",
+	FormatMarkdown: ".md",
+	FormatCSV:      ".csv",
+	FormatSARIF:    ".sarif",
+	FormatTemplate: ".txt",
+}
+
+// ReportWriter saves drift reports as timestamped outputs of a Writer, such
+// as files in a directory or objects under an S3 prefix
+type ReportWriter struct {
+	sink      Writer
+	format    FormatType
+	formatter Formatter
+}
+
+// NewReportWriter creates a ReportWriter for dir, creating the directory if needed
+func NewReportWriter(dir string, format FormatType) (*ReportWriter, error) {
+	formatter, err := NewFormatter(format)
+	if err != nil {
+		return nil, err
+	}
+	sink, err := NewDirWriter(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &ReportWriter{sink: sink, format: format, formatter: formatter}, nil
+}
+
+// NewReportWriterTo creates a ReportWriter writing reports to sink
+func NewReportWriterTo(sink Writer, format FormatType) (*ReportWriter, error) {
+	formatter, err := NewFormatter(format)
+	if err != nil {
+		return nil, err
+	}
+	return &ReportWriter{sink: sink, format: format, formatter: formatter}, nil
+}
+
+// Write saves the report, checked at at, as <instance-id>-<UTC timestamp>
+// with the extension of the writer's format and returns that name
+func (w *ReportWriter) Write(ctx context.Context, report *models.DriftReport, at time.Time) (string, error) {
+	stamped := *report
+	stamped.CheckedAt = at.UTC()
+	out, err := w.formatter.Format(&stamped)
+	if err != nil {
+		return "", err
+	}
+
+	name := ReportFileName(report.InstanceID, at, w.format)
+	if err := w.sink.Write(ctx, name, []byte(out)); err != nil {
+		return "", err
+	}
+	return name, nil
+}
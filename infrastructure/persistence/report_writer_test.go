@@ -0,0 +1,50 @@
+package persistence
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+)
+
+func TestReportWriter_Write(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "reports")
+	writer, err := NewReportWriter(dir, FormatJSON)
+	require.NoError(t, err)
+
+	at := time.Date(2025, 7, 3, 14, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+	name, err := writer.Write(context.Background(), models.NewDriftReport("i-1"), at)
+
+	require.NoError(t, err)
+	assert.Equal(t, "i-1-20250703T123000Z.json", name)
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"instance_id": "i-1"`)
+}
+
+func TestNewReportWriter_UnsupportedFormat(t *testing.T) {
+	_, err := NewReportWriter(t.TempDir(), "xml")
+	assert.Error(t, err)
+}
+
+func TestReportWriter_WriteStampsCheckedAt(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := NewReportWriter(dir, FormatJSON)
+	require.NoError(t, err)
+
+	report := models.NewDriftReport("i-1")
+	at := time.Date(2025, 7, 3, 12, 30, 0, 0, time.UTC)
+	name, err := writer.Write(context.Background(), report, at)
+
+	require.NoError(t, err)
+	assert.True(t, report.CheckedAt.IsZero(), "the caller's report is left as it was")
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"checked_at": "2025-07-03T12:30:00Z"`)
+}
@@ -0,0 +1,230 @@
+package persistence
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"driftdetector/domain/models"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	// sarifToolName names the tool in SARIF output, as code scanning shows it
+	sarifToolName = "driftdetector"
+)
+
+// sarifLog is the root of a SARIF 2.1.0 document
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations,omitempty"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string      `json:"name"`
+	Rules []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+// sarifInvocation records whether every instance could be compared
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level   string       `json:"level"`
+	Message sarifMessage `json:"message"`
+}
+
+type sarifResult struct {
+	RuleID           string                 `json:"ruleId"`
+	Level            string                 `json:"level"`
+	Message          sarifMessage           `json:"message"`
+	Locations        []sarifLocation        `json:"locations,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+	Properties       map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+type sarifLogicalLocation struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+}
+
+type sarifFormatter struct{}
+
+func (f *sarifFormatter) Format(report *models.DriftReport) (string, error) {
+	if report == nil {
+		return "", fmt.Errorf("cannot format nil report")
+	}
+	return renderSARIF([]*models.DriftReport{report}, nil)
+}
+
+// renderSARIF writes the findings of every report as the results of one SARIF
+// run. Failures are recorded as error notifications of the run's invocation.
+func renderSARIF(reports []*models.DriftReport, failures []string) (string, error) {
+	run := sarifRun{
+		Tool:    sarifTool{Driver: sarifDriver{Name: sarifToolName, Rules: []sarifRule{}}},
+		Results: []sarifResult{},
+	}
+
+	rules := make(map[string]string)
+	for _, report := range reports {
+		for _, d := range report.Drifts {
+			ruleID, field := sarifRuleID(d.Path)
+			rules[ruleID] = field
+			run.Results = append(run.Results, newSARIFResult(report.InstanceID, ruleID, d))
+		}
+	}
+
+	ids := make([]string, 0, len(rules))
+	for id := range rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		description := "Drift in " + rules[id]
+		if rules[id] == "" {
+			description = "Instance recorded in Terraform is missing or not running in AWS"
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: id, ShortDescription: sarifMessage{Text: description}})
+	}
+
+	if len(failures) > 0 {
+		run.Invocations = []sarifInvocation{failureInvocation(failures)}
+	}
+
+	data, err := json.MarshalIndent(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal report to SARIF: %v", err)
+	}
+	return string(data), nil
+}
+
+// failureInvocation records an unsuccessful run with an error notification
+// per instance that could not be compared
+func failureInvocation(failures []string) sarifInvocation {
+	invocation := sarifInvocation{ExecutionSuccessful: false}
+	for _, failure := range failures {
+		invocation.ToolExecutionNotifications = append(invocation.ToolExecutionNotifications,
+			sarifNotification{Level: "error", Message: sarifMessage{Text: failure}})
+	}
+	return invocation
+}
+
+// newSARIFResult converts a finding of instanceID into a SARIF result, located
+// at the resource declaration when it is known and at the instance otherwise
+func newSARIFResult(instanceID, ruleID string, d models.Drift) sarifResult {
+	text := d.Description
+	if d.Path != "" {
+		text = fmt.Sprintf("%s: %s", d.Path, d.Description)
+	}
+	if d.Expected != nil || d.Actual != nil {
+		text += fmt.Sprintf(" (expected %s, actual %s)", formatValue(d.Expected), formatValue(d.Actual))
+	}
+
+	result := sarifResult{
+		RuleID:           ruleID,
+		Level:            sarifLevel(d.Severity),
+		Message:          sarifMessage{Text: fmt.Sprintf("%s: %s", instanceID, text)},
+		LogicalLocations: []sarifLogicalLocation{{Name: instanceID, Kind: "resource"}},
+		Properties: map[string]interface{}{
+			"instanceId": instanceID,
+			"path":       d.Path,
+			"driftType":  string(d.Type),
+		},
+	}
+	if d.Source != nil && d.Source.File != "" {
+		location := sarifLocation{PhysicalLocation: sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: d.Source.File},
+		}}
+		if d.Source.Line > 0 {
+			location.PhysicalLocation.Region = &sarifRegion{StartLine: d.Source.Line}
+		}
+		result.Locations = []sarifLocation{location}
+	}
+	return result
+}
+
+// sarifRuleID derives a rule ID from the first field of a drift path, e.g.
+// drift/security-groups for SecurityGroups[sg-1], and returns the field too.
+// Findings about the instance as a whole, with an empty path, use drift/instance.
+func sarifRuleID(path string) (string, string) {
+	field := strings.TrimPrefix(path, ".")
+	if i := strings.IndexAny(field, ".["); i >= 0 {
+		field = field[:i]
+	}
+	if field == "" {
+		return "drift/instance", ""
+	}
+	return "drift/" + kebabCase(field), field
+}
+
+// kebabCase converts a Go field name to lower case words joined by hyphens,
+// keeping acronyms together: EBSBlockDevices becomes ebs-block-devices
+func kebabCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				sb.WriteByte('-')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
+
+// sarifLevel maps a finding's severity to a SARIF result level
+func sarifLevel(severity models.Severity) string {
+	switch severity {
+	case models.SeverityCritical:
+		return "error"
+	case models.SeverityInfo:
+		return "note"
+	default:
+		return "warning"
+	}
+}
@@ -0,0 +1,117 @@
+package persistence
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+)
+
+// assertValidSARIF validates doc against the SARIF 2.1.0 schema in testdata
+func assertValidSARIF(t *testing.T, doc string) {
+	t.Helper()
+	schemaData, err := os.ReadFile("testdata/sarif-schema-2.1.0.json")
+	require.NoError(t, err)
+
+	var document, schema interface{}
+	require.NoError(t, json.Unmarshal([]byte(doc), &document))
+	require.NoError(t, json.Unmarshal(schemaData, &schema))
+
+	rs, err := rego.New(
+		rego.Query("result := json.match_schema(input.document, input.schema)"),
+		rego.Input(map[string]interface{}{"document": document, "schema": schema}),
+	).Eval(context.Background())
+	require.NoError(t, err)
+	require.Len(t, rs, 1)
+
+	result := rs[0].Bindings["result"].([]interface{})
+	assert.Equal(t, true, result[0], "output does not match the SARIF schema: %v", result[1])
+}
+
+func TestFormatter_SARIF(t *testing.T) {
+	report := models.NewDriftReport("i-1")
+	source := &models.SourceLocation{File: "infra/main.tf", Line: 12}
+	sg := models.NewDrift(models.DriftTypeModified, "SecurityGroups[sg-1]", "sg-2", "sg-1", "Value mismatch")
+	sg.Severity = models.SeverityCritical
+	sg.Source = source
+	report.AddDrift(sg)
+	tag := models.NewDrift(models.DriftTypeAdded, ".Tags.Owner", "platform", nil, "Field added")
+	tag.Severity = models.SeverityInfo
+	tag.Source = source
+	report.AddDrift(tag)
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, "EBSBlockDevices[/dev/sdf].VolumeSize", 60, 50, "Value mismatch"))
+
+	formatter, err := NewFormatter(FormatSARIF)
+	require.NoError(t, err)
+	out, err := formatter.Format(report)
+	require.NoError(t, err)
+	assertValidSARIF(t, out)
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal([]byte(out), &log))
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	assert.Equal(t, "driftdetector", run.Tool.Driver.Name)
+
+	var ruleIDs []string
+	for _, rule := range run.Tool.Driver.Rules {
+		ruleIDs = append(ruleIDs, rule.ID)
+	}
+	assert.Equal(t, []string{"drift/ebs-block-devices", "drift/security-groups", "drift/tags"}, ruleIDs)
+
+	require.Len(t, run.Results, 3)
+	first := run.Results[0]
+	assert.Equal(t, "drift/security-groups", first.RuleID)
+	assert.Equal(t, "error", first.Level)
+	assert.Equal(t, "i-1: SecurityGroups[sg-1]: Value mismatch (expected sg-1, actual sg-2)", first.Message.Text)
+	require.Len(t, first.Locations, 1)
+	assert.Equal(t, "infra/main.tf", first.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	assert.Equal(t, 12, first.Locations[0].PhysicalLocation.Region.StartLine)
+
+	assert.Equal(t, "note", run.Results[1].Level)
+	assert.Equal(t, "warning", run.Results[2].Level, "findings without a severity are warnings")
+	assert.Empty(t, run.Results[2].Locations, "findings from state have no source file")
+	assert.Equal(t, "i-1", run.Results[2].LogicalLocations[0].Name)
+}
+
+func TestFormatAggregate_SARIF(t *testing.T) {
+	gone := models.NewDriftReport("i-2")
+	gone.AddDrift(models.NewDrift(models.DriftTypeRemoved, "", nil, nil, "Instance exists in Terraform state but was not found in AWS"))
+	aggregate := models.NewAggregateReport([]*models.DriftReport{models.NewDriftReport("i-1"), gone}, []string{"i-3: access denied"})
+
+	out, err := FormatAggregate(FormatSARIF, aggregate)
+	require.NoError(t, err)
+	assertValidSARIF(t, out)
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal([]byte(out), &log))
+	run := log.Runs[0]
+	require.Len(t, run.Results, 1)
+	assert.Equal(t, "drift/instance", run.Results[0].RuleID)
+	require.Len(t, run.Invocations, 1)
+	assert.False(t, run.Invocations[0].ExecutionSuccessful)
+	assert.Equal(t, "i-3: access denied", run.Invocations[0].ToolExecutionNotifications[0].Message.Text)
+
+	empty, err := FormatReports(FormatSARIF, nil)
+	require.NoError(t, err)
+	assertValidSARIF(t, empty)
+}
+
+func TestKebabCase(t *testing.T) {
+	tests := map[string]string{
+		"SecurityGroups":     "security-groups",
+		"EBSBlockDevices":    "ebs-block-devices",
+		"RootVolumeKMSKeyID": "root-volume-kms-key-id",
+		"VPCID":              "vpcid",
+		"Type":               "type",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, kebabCase(in), in)
+	}
+}
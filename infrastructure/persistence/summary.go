@@ -0,0 +1,168 @@
+package persistence
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"driftdetector/domain/models"
+)
+
+// csvSummaryHeader names the columns of CSV summary output, one row per instance
+var csvSummaryHeader = []string{"instance_id", "drifted", "findings", "by_type", "highest_severity", "error"}
+
+// sarifSummaryRuleID identifies the result reported for each drifted instance
+// in a SARIF summary
+const sarifSummaryRuleID = "drift/summary"
+
+// FormatSummary formats the counts of an aggregate report without its
+// findings: a compact JSON object, a YAML document, one CSV row or table row
+// per instance, one SARIF result per drifted instance, or one line per
+// instance followed by the totals in text
+func FormatSummary(format FormatType, aggregate *models.AggregateReport) (string, error) {
+	if aggregate == nil {
+		return "", fmt.Errorf("cannot format nil aggregate report")
+	}
+	summary := aggregate.Summary()
+
+	switch format {
+	case FormatJSON:
+		data, err := json.Marshal(summary)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal summary to JSON: %v", err)
+		}
+		return string(data), nil
+	case FormatYAML:
+		data, err := marshalYAML(summary)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal summary to YAML: %v", err)
+		}
+		return string(data), nil
+	case FormatText:
+		var sb strings.Builder
+		for _, instance := range summary.Instances {
+			sb.WriteString(instance.String() + "\n")
+		}
+		for _, failure := range summary.Failures {
+			sb.WriteString(fmt.Sprintf("Error: %s\n", failure))
+		}
+		sb.WriteString(totalsLine(summary))
+		return sb.String(), nil
+	case FormatMarkdown:
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("**%s**\n\n", totalsLine(summary)))
+		for _, failure := range summary.Failures {
+			sb.WriteString(fmt.Sprintf("- ❌ %s\n", failure))
+		}
+		if len(summary.Instances) > 0 {
+			if len(summary.Failures) > 0 {
+				sb.WriteString("\n")
+			}
+			sb.WriteString("| Instance | Drift | Findings | Highest severity |\n")
+			sb.WriteString("|---|---|---|---|\n")
+			for _, instance := range summary.Instances {
+				sb.WriteString(fmt.Sprintf("| `%s` | %s | %s | %s |\n",
+					instance.InstanceID, yesNo(instance.Drifted), escapeMarkdownCell(instance.Counts()), instance.HighestSeverity))
+			}
+		}
+		return sb.String(), nil
+	case FormatCSV:
+		return renderCSVSummary(summary)
+	case FormatHTML:
+		return renderHTMLSummary(summary)
+	case FormatSARIF:
+		return renderSARIFSummary(summary)
+	default:
+		return "", fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// totalsLine describes the totals of summary, with the findings by type and
+// the highest severity when there are any
+func totalsLine(summary models.AggregateSummary) string {
+	line := summary.String()
+	if summary.Findings > 0 {
+		totals := models.ReportSummary{ByType: summary.ByType}
+		line += fmt.Sprintf(": %d finding(s), %s", summary.Findings, totals.Counts())
+		if summary.HighestSeverity != "" {
+			line += ", highest severity " + string(summary.HighestSeverity)
+		}
+	}
+	return line
+}
+
+// renderCSVSummary writes a header and one row per instance, followed by one
+// ERROR row per failure
+func renderCSVSummary(summary models.AggregateSummary) (string, error) {
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+
+	rows := [][]string{csvSummaryHeader}
+	for _, instance := range summary.Instances {
+		rows = append(rows, []string{
+			instance.InstanceID,
+			strconv.FormatBool(instance.Drifted),
+			strconv.Itoa(instance.Findings),
+			instance.Counts(),
+			string(instance.HighestSeverity),
+			"",
+		})
+	}
+	for _, failure := range summary.Failures {
+		rows = append(rows, []string{"", csvErrorType, "", "", "", failure})
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return "", fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// renderSARIFSummary writes one result per drifted instance, at the level of
+// its highest severity. Failures are recorded as error notifications.
+func renderSARIFSummary(summary models.AggregateSummary) (string, error) {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{Name: sarifToolName, Rules: []sarifRule{
+			{ID: sarifSummaryRuleID, ShortDescription: sarifMessage{Text: "Instance has drifted from its Terraform configuration"}},
+		}}},
+		Results: []sarifResult{},
+	}
+	for _, instance := range summary.Instances {
+		if !instance.Drifted {
+			continue
+		}
+		byType := make(map[string]int, len(instance.ByType))
+		for driftType, n := range instance.ByType {
+			byType[string(driftType)] = n
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:           sarifSummaryRuleID,
+			Level:            sarifLevel(instance.HighestSeverity),
+			Message:          sarifMessage{Text: instance.String()},
+			LogicalLocations: []sarifLogicalLocation{{Name: instance.InstanceID, Kind: "resource"}},
+			Properties: map[string]interface{}{
+				"instanceId": instance.InstanceID,
+				"findings":   instance.Findings,
+				"byType":     byType,
+			},
+		})
+	}
+	if len(summary.Failures) > 0 {
+		run.Invocations = []sarifInvocation{failureInvocation(summary.Failures)}
+	}
+
+	data, err := json.MarshalIndent(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}}, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal summary to SARIF: %v", err)
+	}
+	return string(data), nil
+}
+
+// yesNo renders a flag for a table cell
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,97 @@
+package persistence
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+)
+
+// summaryAggregate holds a drifted instance, a clean one and a failure
+func summaryAggregate() *models.AggregateReport {
+	drifted := models.NewDriftReport("i-1")
+	instanceType := models.NewDrift(models.DriftTypeModified, "Type", "t3.large", "t3.micro", "Value mismatch")
+	instanceType.Severity = models.SeverityWarning
+	drifted.AddDrift(instanceType)
+	drifted.AddDrift(models.NewDrift(models.DriftTypeAdded, ".Tags.Owner", nil, "platform", "Field added"))
+	clean := models.NewDriftReport("i-2")
+
+	return models.NewAggregateReport([]*models.DriftReport{drifted, clean}, []string{"i-3: access denied"})
+}
+
+func TestFormatSummary_Text(t *testing.T) {
+	out, err := FormatSummary(FormatText, summaryAggregate())
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"i-1: drifted, ADDED=1 MODIFIED=1, highest severity WARNING",
+		"i-2: no drift",
+		"Error: i-3: access denied",
+		"Checked 3 instance(s), 1 with drift, 1 error(s): 2 finding(s), ADDED=1 MODIFIED=1, highest severity WARNING",
+	}, strings.Split(out, "\n"))
+}
+
+func TestFormatSummary_JSON(t *testing.T) {
+	out, err := FormatSummary(FormatJSON, summaryAggregate())
+
+	require.NoError(t, err)
+	assert.NotContains(t, out, "\n", "compact, on one line")
+	assert.NotContains(t, out, "t3.large", "findings are left out")
+
+	var summary models.AggregateSummary
+	require.NoError(t, json.Unmarshal([]byte(out), &summary))
+	assert.Equal(t, 3, summary.TotalInstances)
+	assert.Equal(t, 1, summary.Drifted)
+	assert.Equal(t, map[models.DriftType]int{models.DriftTypeModified: 1, models.DriftTypeAdded: 1}, summary.ByType)
+	require.Len(t, summary.Instances, 2)
+	assert.Equal(t, models.SeverityWarning, summary.Instances[0].HighestSeverity)
+	assert.Equal(t, []string{"i-3: access denied"}, summary.Failures)
+}
+
+func TestFormatSummary_CSV(t *testing.T) {
+	out, err := FormatSummary(FormatCSV, summaryAggregate())
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{
+		{"instance_id", "drifted", "findings", "by_type", "highest_severity", "error"},
+		{"i-1", "true", "2", "ADDED=1 MODIFIED=1", "WARNING", ""},
+		{"i-2", "false", "0", "", "", ""},
+		{"", "ERROR", "", "", "", "i-3: access denied"},
+	}, readCSV(t, out))
+}
+
+func TestFormatSummary_SARIF(t *testing.T) {
+	out, err := FormatSummary(FormatSARIF, summaryAggregate())
+	require.NoError(t, err)
+
+	var log sarifLog
+	require.NoError(t, json.Unmarshal([]byte(out), &log))
+	require.Len(t, log.Runs, 1)
+	run := log.Runs[0]
+	require.Len(t, run.Results, 1, "one result per drifted instance")
+	assert.Equal(t, sarifSummaryRuleID, run.Results[0].RuleID)
+	assert.Equal(t, "warning", run.Results[0].Level)
+	assert.Equal(t, "i-1", run.Results[0].LogicalLocations[0].Name)
+	require.Len(t, run.Invocations, 1)
+	assert.False(t, run.Invocations[0].ExecutionSuccessful)
+}
+
+func TestFormatSummary_OtherFormats(t *testing.T) {
+	for _, format := range []FormatType{FormatYAML, FormatMarkdown, FormatHTML} {
+		t.Run(string(format), func(t *testing.T) {
+			out, err := FormatSummary(format, summaryAggregate())
+
+			require.NoError(t, err)
+			assert.Contains(t, out, "i-1")
+			assert.Contains(t, out, "i-3: access denied")
+			assert.NotContains(t, out, "t3.large", "findings are left out")
+		})
+	}
+
+	_, err := FormatSummary("xml", summaryAggregate())
+	assert.Error(t, err)
+}
@@ -0,0 +1,225 @@
+package persistence
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"driftdetector/domain/models"
+)
+
+// FormatTemplate renders reports through a user-supplied text/template, set
+// with WithTemplate
+const FormatTemplate FormatType = "template"
+
+// ReportTemplate is a parsed report template. Templates are executed with a
+// *models.AggregateReport, also when a single report is formatted, so one
+// template serves both; TemplateSchema lists its fields.
+type ReportTemplate struct {
+	tmpl *template.Template
+}
+
+// templateFuncs are the helper functions available to report templates
+var templateFuncs = template.FuncMap{
+	"json":         templateJSON,
+	"upper":        templateUpper,
+	"severityIcon": severityIcon,
+	"truncate":     templateTruncate,
+}
+
+// templateFuncDocs describes templateFuncs for TemplateSchema
+var templateFuncDocs = []string{
+	"json VALUE           VALUE as compact JSON, e.g. {{ json .Actual }}",
+	"upper VALUE          VALUE as text in upper case",
+	"severityIcon SEV     an icon for a severity: 🔴 CRITICAL, 🟡 WARNING, 🔵 INFO, ⚪ none",
+	"truncate N VALUE     VALUE as text, cut to N characters with a marker, e.g. {{ truncate 40 .Description }}",
+}
+
+// ParseTemplateFile parses the report template at path. Syntax errors name
+// the file and line.
+func ParseTemplateFile(path string) (*ReportTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template: %w", err)
+	}
+	return ParseTemplate(filepath.Base(path), string(data))
+}
+
+// ParseTemplate parses text as a report template named name
+func ParseTemplate(name, text string) (*ReportTemplate, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		// text/template errors read "template: name:line: message"
+		return nil, fmt.Errorf("invalid template: %w", err)
+	}
+	return &ReportTemplate{tmpl: tmpl}, nil
+}
+
+// WithTemplate sets the template FormatTemplate renders reports with
+func WithTemplate(t *ReportTemplate) FormatterOption {
+	return func(o *formatterOptions) {
+		o.template = t
+	}
+}
+
+// Render executes the template with aggregate. Execution errors name the
+// line and the field path that failed, e.g. <.Reports.Nope>.
+func (t *ReportTemplate) Render(aggregate *models.AggregateReport) (string, error) {
+	var sb strings.Builder
+	if err := t.tmpl.Execute(&sb, aggregate); err != nil {
+		return "", fmt.Errorf("failed to render template: %w", err)
+	}
+	return sb.String(), nil
+}
+
+// templateFormatter renders each report as an aggregate of one
+type templateFormatter struct {
+	template *ReportTemplate
+}
+
+func (f *templateFormatter) Format(report *models.DriftReport) (string, error) {
+	if report == nil {
+		return "", fmt.Errorf("cannot format nil report")
+	}
+	return f.template.Render(models.NewAggregateReport([]*models.DriftReport{report}, nil))
+}
+
+// newTemplateFormatter returns the formatter for FormatTemplate, which
+// cannot be used without a template
+func newTemplateFormatter(o formatterOptions) (*templateFormatter, error) {
+	if o.template == nil {
+		return nil, fmt.Errorf("the %s format requires a template", FormatTemplate)
+	}
+	return &templateFormatter{template: o.template}, nil
+}
+
+// templateJSON renders v as compact JSON
+func templateJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// templateTruncate renders v as text cut to n characters, marking the cut
+func templateTruncate(n int, v interface{}) string {
+	s, ok := v.(string)
+	if !ok {
+		s = formatValue(v)
+	}
+	if runes := []rune(s); n > 0 && len(runes) > n {
+		s = string(runes[:n]) + truncatedMarker
+	}
+	return s
+}
+
+// templateUpper renders v, such as a string or a drift type, in upper case
+func templateUpper(v interface{}) string {
+	return strings.ToUpper(fmt.Sprint(v))
+}
+
+// severityIcon returns an icon standing for severity, a Severity or its name
+func severityIcon(severity interface{}) string {
+	switch models.Severity(strings.ToUpper(fmt.Sprint(severity))) {
+	case models.SeverityCritical:
+		return "🔴"
+	case models.SeverityWarning:
+		return "🟡"
+	case models.SeverityInfo:
+		return "🔵"
+	default:
+		return "⚪"
+	}
+}
+
+// TemplateSchema describes the data report templates are executed with and
+// the helper functions they may call, one field path and type per line.
+// Elements of lists are written path[], to be read with range.
+func TemplateSchema() string {
+	var sb strings.Builder
+	sb.WriteString("Templates are executed with the aggregate report of every instance checked,\n")
+	sb.WriteString("also when a single instance is checked. Fields:\n\n")
+
+	var lines [][2]string
+	describeTemplateFields(reflect.TypeOf(models.AggregateReport{}), "", map[reflect.Type]bool{}, &lines)
+	width := 0
+	for _, line := range lines {
+		if len(line[0]) > width {
+			width = len(line[0])
+		}
+	}
+	for _, line := range lines {
+		sb.WriteString(fmt.Sprintf("  %-*s  %s\n", width, line[0], line[1]))
+	}
+
+	sb.WriteString("\nFunctions:\n\n")
+	funcs := append([]string(nil), templateFuncDocs...)
+	sort.Strings(funcs)
+	for _, doc := range funcs {
+		sb.WriteString("  " + doc + "\n")
+	}
+	return sb.String()
+}
+
+// timeType is described as a value rather than walked into
+var timeType = reflect.TypeOf(time.Time{})
+
+// describeTemplateFields appends the exported fields of struct type t, and
+// those of the structs they hold, under prefix. seen keeps recursive types
+// from being walked forever.
+func describeTemplateFields(t reflect.Type, prefix string, seen map[reflect.Type]bool, lines *[][2]string) {
+	if seen[t] {
+		return
+	}
+	seen[t] = true
+	defer delete(seen, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		path := prefix + "." + field.Name
+		*lines = append(*lines, [2]string{path, templateTypeName(field.Type)})
+
+		elem, elemPath := field.Type, path
+		for elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Slice {
+			if elem.Kind() == reflect.Slice {
+				elemPath += "[]"
+			}
+			elem = elem.Elem()
+		}
+		if elem.Kind() == reflect.Struct && elem != timeType {
+			describeTemplateFields(elem, elemPath, seen, lines)
+		}
+	}
+}
+
+// templateTypeName names t without package qualifiers
+func templateTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return "*" + templateTypeName(t.Elem())
+	case reflect.Slice:
+		return "[]" + templateTypeName(t.Elem())
+	case reflect.Map:
+		return "map[" + templateTypeName(t.Key()) + "]" + templateTypeName(t.Elem())
+	case reflect.Interface:
+		return "any"
+	}
+	if t.Name() != "" && t.PkgPath() != "" && t.Kind() != reflect.Struct {
+		// Named values such as Severity read like their underlying type
+		return t.Kind().String()
+	}
+	if t == timeType {
+		return "time"
+	}
+	return t.Name()
+}
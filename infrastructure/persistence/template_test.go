@@ -0,0 +1,90 @@
+package persistence
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+)
+
+func TestFormatAggregate_Template(t *testing.T) {
+	// Given a template looping over the reports and their drifts with every helper
+	tmpl, err := ParseTemplateFile(filepath.Join("testdata", "report.tmpl"))
+	require.NoError(t, err)
+
+	// When an aggregate is rendered with it
+	out, err := FormatAggregate(FormatTemplate, colorTestAggregate(), WithTemplate(tmpl))
+
+	// Then
+	require.NoError(t, err)
+	assertGolden(t, "template_report.golden", out)
+}
+
+func TestFormatter_Template(t *testing.T) {
+	tmpl, err := ParseTemplate("single", `{{ len .Reports }} {{ range .Reports }}{{ .InstanceID }} {{ len .Drifts }}{{ end }}`)
+	require.NoError(t, err)
+	report := models.NewDriftReport("i-1")
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, "Type", "t3.large", "t3.micro", "Value mismatch"))
+
+	t.Run("a single report is rendered as an aggregate of one", func(t *testing.T) {
+		formatter, err := NewFormatter(FormatTemplate, WithTemplate(tmpl))
+		require.NoError(t, err)
+
+		out, err := formatter.Format(report)
+
+		require.NoError(t, err)
+		assert.Equal(t, "1 i-1 1", out)
+	})
+
+	t.Run("several reports are rendered once", func(t *testing.T) {
+		out, err := FormatReports(FormatTemplate, []*models.DriftReport{report, models.NewDriftReport("i-2")}, WithTemplate(tmpl))
+
+		require.NoError(t, err)
+		assert.Equal(t, "2 i-1 1i-2 0", out)
+	})
+
+	t.Run("the format requires a template", func(t *testing.T) {
+		_, err := NewFormatter(FormatTemplate)
+
+		assert.ErrorContains(t, err, "requires a template")
+	})
+}
+
+func TestParseTemplate_Errors(t *testing.T) {
+	t.Run("syntax errors name the line", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "broken.tmpl")
+		require.NoError(t, os.WriteFile(path, []byte("{{ .Drifted }}\n{{ range .Reports }}\n{{ .InstanceID }\n"), 0o644))
+
+		_, err := ParseTemplateFile(path)
+
+		assert.ErrorContains(t, err, "broken.tmpl:3")
+	})
+
+	t.Run("unknown functions are rejected when parsing", func(t *testing.T) {
+		_, err := ParseTemplate("report", "{{ lower .Drifted }}")
+
+		assert.ErrorContains(t, err, `function "lower" not defined`)
+	})
+
+	t.Run("execution errors name the failing field", func(t *testing.T) {
+		tmpl, err := ParseTemplate("report", "{{ range .Reports }}\n{{ .Nope }}{{ end }}")
+		require.NoError(t, err)
+
+		_, err = tmpl.Render(colorTestAggregate())
+
+		assert.ErrorContains(t, err, "report:2")
+		assert.ErrorContains(t, err, "<.Nope>")
+	})
+}
+
+func TestTemplateSchema(t *testing.T) {
+	schema := TemplateSchema()
+
+	for _, line := range []string{".TotalInstances", ".Reports[].Drifts[].Path", ".Reports[].Drifts[].Source.Line", "severityIcon", "truncate"} {
+		assert.Contains(t, schema, line)
+	}
+}
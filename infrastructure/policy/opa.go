@@ -0,0 +1,195 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"driftdetector/domain/models"
+)
+
+// conventionalRules are the rule names whose results are treated as violations
+var conventionalRules = []string{"deny", "violation"}
+
+// Input is the document passed to Rego policies as `input`.
+// Its shape is part of the public contract for policy authors; see
+// TestInputDocumentShape before changing it.
+type Input struct {
+	Report   *models.DriftReport `json:"report"`
+	Actual   *models.Instance    `json:"actual"`
+	Expected *models.Instance    `json:"expected"`
+}
+
+// compiledPolicy is a single Rego file compiled in isolation so that
+// failures can be attributed to the file that caused them
+type compiledPolicy struct {
+	file     string
+	pkg      string
+	compiler *ast.Compiler
+}
+
+// OPAEvaluator evaluates Rego policies against drift reports
+type OPAEvaluator struct {
+	policies []compiledPolicy
+}
+
+// LoadOPAPolicies compiles every .rego file in dir. Any compile error is
+// returned immediately so callers can fail before contacting AWS.
+func LoadOPAPolicies(dir string) (*OPAEvaluator, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.rego"))
+	if err != nil {
+		return nil, fmt.Errorf("listing policies in %s: %w", dir, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .rego policies found in %s", dir)
+	}
+	sort.Strings(files)
+
+	evaluator := &OPAEvaluator{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading policy %s: %w", file, err)
+		}
+
+		module, err := ast.ParseModule(file, string(data))
+		if err != nil {
+			return nil, fmt.Errorf("parsing policy %s: %w", file, err)
+		}
+
+		compiler, err := ast.CompileModules(map[string]string{file: string(data)})
+		if err != nil {
+			return nil, fmt.Errorf("compiling policy %s: %w", file, err)
+		}
+
+		evaluator.policies = append(evaluator.policies, compiledPolicy{
+			file:     filepath.Base(file),
+			pkg:      module.Package.Path.String(),
+			compiler: compiler,
+		})
+	}
+
+	return evaluator, nil
+}
+
+// Evaluate runs every policy against the input and returns the violations as
+// POLICY_VIOLATION drifts. Evaluation errors are collected per policy and do
+// not prevent the remaining policies from running.
+func (e *OPAEvaluator) Evaluate(ctx context.Context, input Input) ([]models.Drift, []error) {
+	doc, err := toDocument(input)
+	if err != nil {
+		return nil, []error{fmt.Errorf("building policy input: %w", err)}
+	}
+
+	var drifts []models.Drift
+	var errs []error
+	for _, p := range e.policies {
+		found, err := p.evaluate(ctx, doc)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("evaluating policy %s: %w", p.file, err))
+			continue
+		}
+		drifts = append(drifts, found...)
+	}
+
+	return drifts, errs
+}
+
+// ApplyToReport evaluates policies and appends any violations to the report
+func (e *OPAEvaluator) ApplyToReport(ctx context.Context, report *models.DriftReport, actual, expected *models.Instance) []error {
+	drifts, errs := e.Evaluate(ctx, Input{Report: report, Actual: actual, Expected: expected})
+	for _, d := range drifts {
+		report.AddDrift(d)
+	}
+	return errs
+}
+
+// evaluate queries the conventional rules of a single policy package
+func (p compiledPolicy) evaluate(ctx context.Context, doc interface{}) ([]models.Drift, error) {
+	var drifts []models.Drift
+
+	for _, rule := range conventionalRules {
+		query := fmt.Sprintf("%s.%s", p.pkg, rule)
+		rs, err := rego.New(
+			rego.Query(query),
+			rego.Compiler(p.compiler),
+			rego.Input(doc),
+		).Eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, result := range rs {
+			for _, expr := range result.Expressions {
+				for _, msg := range violationMessages(expr.Value) {
+					drift := models.NewDrift(
+						models.DriftTypePolicyViolation,
+						fmt.Sprintf("policy.%s.%s", p.file, rule),
+						nil,
+						nil,
+						msg,
+					)
+					drift.Policy = &models.PolicyReference{File: p.file, Rule: rule}
+					drifts = append(drifts, drift)
+				}
+			}
+		}
+	}
+
+	return drifts, nil
+}
+
+// violationMessages extracts messages from a rule value. Rules may produce
+// strings, or objects carrying a "msg" field.
+func violationMessages(value interface{}) []string {
+	var items []interface{}
+	switch v := value.(type) {
+	case []interface{}:
+		items = v
+	case map[string]interface{}:
+		items = []interface{}{v}
+	case string:
+		items = []interface{}{v}
+	default:
+		return nil
+	}
+
+	var msgs []string
+	for _, item := range items {
+		switch v := item.(type) {
+		case string:
+			msgs = append(msgs, v)
+		case map[string]interface{}:
+			if msg, ok := v["msg"].(string); ok {
+				msgs = append(msgs, msg)
+			} else {
+				data, _ := json.Marshal(v)
+				msgs = append(msgs, string(data))
+			}
+		}
+	}
+	sort.Strings(msgs)
+
+	return msgs
+}
+
+// toDocument converts the input into plain JSON values so Rego sees the
+// same field names that appear in JSON reports
+func toDocument(input Input) (interface{}, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
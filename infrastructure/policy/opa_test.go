@@ -0,0 +1,137 @@
+package policy_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/policy"
+)
+
+const samplePolicyDir = "../../testdata/policies"
+
+func fixtureInput() policy.Input {
+	actual := models.NewInstance("i-1234567890abcdef0", "t2.small", "ami-0c55b159cbfafe1f0")
+	actual.PublicIPAddress = "203.0.113.12"
+	expected := models.NewInstance("i-1234567890abcdef0", "t2.micro", "ami-0c55b159cbfafe1f0")
+
+	report := models.NewDriftReport(actual.ID)
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, "Type", "t2.small", "t2.micro", "Value mismatch"))
+
+	return policy.Input{Report: report, Actual: actual, Expected: expected}
+}
+
+func keysOf(t *testing.T, v interface{}) []string {
+	t.Helper()
+
+	m, ok := v.(map[string]interface{})
+	require.True(t, ok, "Expected a JSON object")
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// TestInputDocumentShape freezes the input contract that policy authors rely on
+func TestInputDocumentShape(t *testing.T) {
+	data, err := json.Marshal(fixtureInput())
+	require.NoError(t, err)
+
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &doc))
+
+	assert.Equal(t, []string{"actual", "expected", "report"}, keysOf(t, doc))
+	assert.Equal(t, []string{"drifts", "has_drift", "instance_id"}, keysOf(t, doc["report"]))
+
+	drifts := doc["report"].(map[string]interface{})["drifts"].([]interface{})
+	require.Len(t, drifts, 1)
+	assert.Equal(t, []string{"actual", "description", "expected", "path", "type"}, keysOf(t, drifts[0]))
+
+	actual := doc["actual"].(map[string]interface{})
+	for _, key := range []string{"instance_id", "instance_type", "ami", "tags", "public_ip_address", "private_ip_address", "security_groups", "subnet_id", "vpc_id"} {
+		assert.Contains(t, actual, key, "Instance documents must expose %s", key)
+	}
+}
+
+func TestOPAEvaluator_SamplePolicy(t *testing.T) {
+	evaluator, err := policy.LoadOPAPolicies(samplePolicyDir)
+	require.NoError(t, err)
+
+	input := fixtureInput()
+	errs := evaluator.ApplyToReport(context.Background(), input.Report, input.Actual, input.Expected)
+	require.Empty(t, errs)
+
+	require.Len(t, input.Report.Drifts, 2)
+	violation := input.Report.Drifts[1]
+	assert.Equal(t, models.DriftTypePolicyViolation, violation.Type)
+	assert.Equal(t, "instance i-1234567890abcdef0 has public IP address 203.0.113.12", violation.Description)
+	require.NotNil(t, violation.Policy)
+	assert.Equal(t, "deny_public_ip.rego", violation.Policy.File)
+	assert.Equal(t, "deny", violation.Policy.Rule)
+
+	t.Run("no violation without public IP", func(t *testing.T) {
+		input := fixtureInput()
+		input.Actual.PublicIPAddress = ""
+
+		drifts, errs := evaluator.Evaluate(context.Background(), input)
+
+		assert.Empty(t, errs)
+		assert.Empty(t, drifts)
+	})
+}
+
+func TestLoadOPAPolicies_CompileError(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.rego"), []byte("package broken\n\ndeny contains msg if {\n"), 0644))
+
+	_, err := policy.LoadOPAPolicies(dir)
+
+	assert.ErrorContains(t, err, "broken.rego")
+}
+
+func TestOPAEvaluator_EvaluationErrorDoesNotAbort(t *testing.T) {
+	dir := t.TempDir()
+	conflicting := `package conflicting
+
+level := "low" if input.report.has_drift
+level := "high" if input.report.has_drift
+
+violation contains {"msg": level} if level == "high"
+`
+	objects := `package objects
+
+violation contains {"msg": "type drifted", "severity": "high"} if {
+	some d in input.report.drifts
+	d.path == "Type"
+}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a_conflicting.rego"), []byte(conflicting), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b_objects.rego"), []byte(objects), 0644))
+
+	evaluator, err := policy.LoadOPAPolicies(dir)
+	require.NoError(t, err)
+
+	drifts, errs := evaluator.Evaluate(context.Background(), fixtureInput())
+
+	require.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[0], "a_conflicting.rego")
+	require.Len(t, drifts, 1)
+	assert.Equal(t, "type drifted", drifts[0].Description)
+	assert.Equal(t, "violation", drifts[0].Policy.Rule)
+}
+
+func TestLoadOPAPolicies_EmptyDir(t *testing.T) {
+	_, err := policy.LoadOPAPolicies(t.TempDir())
+
+	assert.Error(t, err)
+}
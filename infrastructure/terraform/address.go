@@ -0,0 +1,31 @@
+package terraform
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// FormatResourceAddress builds a Terraform resource address such as
+// module.app.aws_instance.web[0] or aws_instance.web["a.b"].
+// An integer index comes from count and a string key from for_each; string keys
+// are always quoted so that keys containing dots or brackets stay unambiguous.
+// A nil index yields the unindexed address.
+func FormatResourceAddress(module, resourceType, name string, index interface{}) string {
+	address := resourceType + "." + name
+	if module != "" {
+		address = module + "." + address
+	}
+
+	switch idx := index.(type) {
+	case nil:
+		return address
+	case string:
+		return address + "[" + strconv.Quote(idx) + "]"
+	case float64:
+		return fmt.Sprintf("%s[%d]", address, int64(idx))
+	case int:
+		return fmt.Sprintf("%s[%d]", address, idx)
+	default:
+		return fmt.Sprintf("%s[%v]", address, idx)
+	}
+}
@@ -0,0 +1,59 @@
+package terraform_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tfrepo "driftdetector/infrastructure/terraform"
+)
+
+func TestFormatResourceAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		module   string
+		index    interface{}
+		expected string
+	}{
+		{"no index", "", nil, "aws_instance.web"},
+		{"count index", "", float64(2), "aws_instance.web[2]"},
+		{"integer index", "", 0, "aws_instance.web[0]"},
+		{"for_each key", "", "blue", `aws_instance.web["blue"]`},
+		{"for_each key with dots", "", "eu-west-1.a", `aws_instance.web["eu-west-1.a"]`},
+		{"for_each key with quotes", "", `say "hi"`, `aws_instance.web["say \"hi\""]`},
+		{"child module", "module.app", float64(1), "module.app.aws_instance.web[1]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tfrepo.FormatResourceAddress(tt.module, "aws_instance", "web", tt.index))
+		})
+	}
+}
+
+func TestTerraformStateRepository_IndexedResources(t *testing.T) {
+	// Given a state with a counted resource and a for_each resource in a child module
+	repo := tfrepo.NewTerraformStateRepository()
+
+	// When reading its instances
+	instances, err := repo.GetInstanceConfigs(context.Background(), filepath.Join(terraformFixtureDir, "state", "indexed_instances.json"))
+
+	// Then every index becomes its own instance with its full address
+	require.NoError(t, err)
+
+	addresses := make(map[string]string, len(instances))
+	for _, instance := range instances {
+		addresses[instance.ID] = instance.ResourceAddress
+	}
+
+	assert.Equal(t, map[string]string{
+		"i-0a0a0a0a0a0a0a0a0": "aws_instance.web[0]",
+		"i-0b0b0b0b0b0b0b0b0": "aws_instance.web[1]",
+		"i-0c0c0c0c0c0c0c0c0": "aws_instance.web[2]",
+		"i-0d0d0d0d0d0d0d0d0": `module.workers.aws_instance.node["eu-west-1.a"]`,
+		"i-0e0e0e0e0e0e0e0e0": `module.workers.aws_instance.node["eu-west-1.b"]`,
+	}, addresses)
+}
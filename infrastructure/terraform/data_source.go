@@ -0,0 +1,289 @@
+package terraform
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/logger"
+)
+
+// AMIQuery holds the arguments of a data "aws_ami" block that select an image
+type AMIQuery struct {
+	Owners            []string
+	ExecutableUsers   []string
+	NameRegex         string
+	MostRecent        bool
+	IncludeDeprecated bool
+	// Filters maps each filter name to the values it accepts
+	Filters map[string][]string
+}
+
+// AMIResolver finds the image a data "aws_ami" block selects in AWS
+type AMIResolver interface {
+	ResolveAMI(ctx context.Context, query AMIQuery) (string, error)
+}
+
+// WithAMIResolver looks up data "aws_ami" blocks in AWS with resolver when
+// the state of the configuration directory does not record them
+func WithAMIResolver(resolver AMIResolver) HCLParserOption {
+	return func(p *HCLParser) {
+		p.amis = resolver
+	}
+}
+
+// amiDataSchema selects the data "aws_ami" arguments that select an image
+var amiDataSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "owners"},
+		{Name: "executable_users"},
+		{Name: "name_regex"},
+		{Name: "most_recent"},
+		{Name: "include_deprecated"},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "filter"},
+	},
+}
+
+// amiFilterSchema selects the arguments of a filter block
+var amiFilterSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "name", Required: true},
+		{Name: "values", Required: true},
+	},
+}
+
+// dataSourceValues returns the value of every data block, for references
+// such as data.aws_ami.ubuntu.id. Values come from the data resources in the
+// local state of dir, then from AWS for data "aws_ami" blocks when an
+// AMIResolver is configured. The rest are unknown, which leaves the arguments
+// that use them unset.
+func (p *HCLParser) dataSourceValues(ctx context.Context, dir string, blocks hcl.Blocks, evalCtx *hcl.EvalContext) cty.Value {
+	state := p.stateDataSources(dir)
+
+	byType := make(map[string]map[string]cty.Value)
+	for _, block := range blocks {
+		if block.Type != "data" {
+			continue
+		}
+		dataType, name := block.Labels[0], block.Labels[1]
+		if byType[dataType] == nil {
+			byType[dataType] = make(map[string]cty.Value)
+		}
+
+		val, ok := state[dataType+"."+name]
+		if !ok && dataType == "aws_ami" && p.amis != nil {
+			val, ok = p.resolveAMIData(ctx, block, evalCtx)
+		}
+		if !ok {
+			val = cty.DynamicVal
+		}
+		byType[dataType][name] = val
+	}
+
+	types := make(map[string]cty.Value, len(byType))
+	for dataType, names := range byType {
+		types[dataType] = cty.ObjectVal(names)
+	}
+	return cty.ObjectVal(types)
+}
+
+// stateDataSources reads the data resources of the root module from the local
+// state of dir, keyed by type.name. Data sources with count or for_each are
+// left out. Without readable state the result is empty.
+func (p *HCLParser) stateDataSources(dir string) map[string]cty.Value {
+	values := make(map[string]cty.Value)
+	path, err := ResolveWorkspaceState(dir, p.workspace)
+	if err != nil || path == "" {
+		return values
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logger.Debug("data sources not read from state", "path", path, "error", err)
+		return values
+	}
+	var state models.TerraformState
+	if err := json.Unmarshal(data, &state); err != nil {
+		logger.Debug("data sources not read from state", "path", path, "error", err)
+		return values
+	}
+
+	for _, resource := range state.Resources {
+		if resource.Mode != "data" || resource.Module != "" || len(resource.Instances) != 1 || resource.Instances[0].IndexKey != nil {
+			continue
+		}
+		val, err := attributesValue(resource.Instances[0].Attributes)
+		if err != nil {
+			logger.Debug("data source not read from state", "address", "data."+resource.Type+"."+resource.Name, "error", err)
+			continue
+		}
+		values[resource.Type+"."+resource.Name] = val
+	}
+	return values
+}
+
+// attributesValue converts the attributes of a state resource to a cty object
+func attributesValue(attrs map[string]interface{}) (cty.Value, error) {
+	data, err := json.Marshal(attrs)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	ty, err := ctyjson.ImpliedType(data)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return ctyjson.Unmarshal(data, ty)
+}
+
+// resolveAMIData looks up the image a data "aws_ami" block selects. Only its
+// id and image_id are known afterwards.
+func (p *HCLParser) resolveAMIData(ctx context.Context, block *hcl.Block, evalCtx *hcl.EvalContext) (cty.Value, bool) {
+	address := "data." + block.Labels[0] + "." + block.Labels[1]
+	query, err := parseAMIQuery(block, evalCtx)
+	if err != nil {
+		logger.Warn("data source not resolved", "address", address, "error", err)
+		return cty.NilVal, false
+	}
+
+	id, err := p.amis.ResolveAMI(ctx, query)
+	if err != nil {
+		logger.Warn("data source not resolved", "address", address, "error", err)
+		return cty.NilVal, false
+	}
+	logger.Debug("resolved data source", "address", address, "id", id)
+	return cty.ObjectVal(map[string]cty.Value{
+		"id":       cty.StringVal(id),
+		"image_id": cty.StringVal(id),
+	}), true
+}
+
+// parseAMIQuery reads the arguments of a data "aws_ami" block. Arguments that
+// cannot be evaluated statically make the query fail, since leaving them out
+// could select another image.
+func parseAMIQuery(block *hcl.Block, evalCtx *hcl.EvalContext) (AMIQuery, error) {
+	content, _, diags := block.Body.PartialContent(amiDataSchema)
+	if diags.HasErrors() {
+		return AMIQuery{}, fmt.Errorf("%s", diags.Error())
+	}
+	if err := requireKnown(content.Attributes, evalCtx); err != nil {
+		return AMIQuery{}, err
+	}
+	attrs := evalAttributes(content.Attributes, evalCtx)
+
+	query := AMIQuery{
+		Owners:          stringListAttr(attrs, "owners"),
+		ExecutableUsers: stringListAttr(attrs, "executable_users"),
+		NameRegex:       stringAttr(attrs, "name_regex"),
+	}
+	if mostRecent := boolAttr(attrs, "most_recent"); mostRecent != nil {
+		query.MostRecent = *mostRecent
+	}
+	if includeDeprecated := boolAttr(attrs, "include_deprecated"); includeDeprecated != nil {
+		query.IncludeDeprecated = *includeDeprecated
+	}
+
+	for _, filter := range content.Blocks {
+		filterContent, diags := filter.Body.Content(amiFilterSchema)
+		if diags.HasErrors() {
+			return AMIQuery{}, fmt.Errorf("%s", diags.Error())
+		}
+		if err := requireKnown(filterContent.Attributes, evalCtx); err != nil {
+			return AMIQuery{}, err
+		}
+		filterAttrs := evalAttributes(filterContent.Attributes, evalCtx)
+		if query.Filters == nil {
+			query.Filters = make(map[string][]string)
+		}
+		name := stringAttr(filterAttrs, "name")
+		query.Filters[name] = append(query.Filters[name], stringListAttr(filterAttrs, "values")...)
+	}
+	return query, nil
+}
+
+// requireKnown fails when an attribute cannot be evaluated to a known value
+func requireKnown(attributes hcl.Attributes, evalCtx *hcl.EvalContext) error {
+	for name, attr := range attributes {
+		val, diags := attr.Expr.Value(evalCtx)
+		if diags.HasErrors() {
+			return fmt.Errorf("%s: %s", name, diags.Error())
+		}
+		if !val.IsWhollyKnown() {
+			return fmt.Errorf("%s cannot be evaluated statically", name)
+		}
+	}
+	return nil
+}
+
+// stringListAttr returns the known string elements of a list, set or tuple attribute
+func stringListAttr(attrs map[string]cty.Value, name string) []string {
+	v, ok := attrs[name]
+	if !ok || !v.CanIterateElements() || v.Type().IsMapType() || v.Type().IsObjectType() {
+		return nil
+	}
+	var values []string
+	for it := v.ElementIterator(); it.Next(); {
+		_, elem := it.Element()
+		if elem.IsKnown() && !elem.IsNull() && elem.Type() == cty.String {
+			values = append(values, elem.AsString())
+		}
+	}
+	return values
+}
+
+// unresolvedDataReferences returns the domain fields set by attributes whose
+// values depend on data sources that could not be read, mapped to the first
+// such data source, e.g. AMI: data.aws_ami.ubuntu
+func unresolvedDataReferences(attributes hcl.Attributes, evalCtx *hcl.EvalContext) map[string]string {
+	var fields map[string]string
+	for name, attr := range attributes {
+		val, diags := attr.Expr.Value(evalCtx)
+		if !diags.HasErrors() && val.IsWhollyKnown() {
+			continue
+		}
+		reference := unknownDataReference(attr.Expr, evalCtx)
+		field, ok := instanceAttributeFields[name]
+		if reference == "" || !ok {
+			continue
+		}
+		if fields == nil {
+			fields = make(map[string]string)
+		}
+		fields[field] = reference
+	}
+	return fields
+}
+
+// unknownDataReference returns the first data source expr refers to whose
+// value is unknown, such as data.aws_ami.ubuntu, or "" when there is none
+func unknownDataReference(expr hcl.Expression, evalCtx *hcl.EvalContext) string {
+	var references []string
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() != "data" || len(traversal) < 3 {
+			continue
+		}
+		dataType, ok1 := traversal[1].(hcl.TraverseAttr)
+		name, ok2 := traversal[2].(hcl.TraverseAttr)
+		if !ok1 || !ok2 {
+			continue
+		}
+		val, diags := traversal[:3].TraverseAbs(evalCtx)
+		if diags.HasErrors() || !val.IsWhollyKnown() {
+			references = append(references, strings.Join([]string{"data", dataType.Name, name.Name}, "."))
+		}
+	}
+	if len(references) == 0 {
+		return ""
+	}
+	sort.Strings(references)
+	return references[0]
+}
@@ -0,0 +1,125 @@
+package terraform_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tfrepo "driftdetector/infrastructure/terraform"
+)
+
+// amiDataConfig looks up the AMI of an instance with a data "aws_ami" block
+const amiDataConfig = `
+variable "owner" {
+  default = "099720109477"
+}
+
+data "aws_ami" "ubuntu" {
+  most_recent = true
+  owners      = [var.owner]
+  name_regex  = "^ubuntu-jammy"
+
+  filter {
+    name   = "name"
+    values = ["ubuntu/images/hvm-ssd/ubuntu-jammy-22.04-amd64-server-*"]
+  }
+
+  filter {
+    name   = "virtualization-type"
+    values = ["hvm"]
+  }
+}
+
+resource "aws_instance" "web" {
+  ami           = data.aws_ami.ubuntu.id
+  instance_type = "t3.micro"
+}
+`
+
+// amiDataState records the data source as the last apply read it
+const amiDataState = `{
+  "version": 4,
+  "terraform_version": "1.6.0",
+  "resources": [
+    {
+      "mode": "data",
+      "type": "aws_ami",
+      "name": "ubuntu",
+      "instances": [{"attributes": {"id": "ami-0state", "image_id": "ami-0state", "most_recent": true}}]
+    }
+  ]
+}`
+
+// fakeAMIResolver returns id for every query and records the last one
+type fakeAMIResolver struct {
+	id    string
+	err   error
+	query *tfrepo.AMIQuery
+}
+
+func (r *fakeAMIResolver) ResolveAMI(_ context.Context, query tfrepo.AMIQuery) (string, error) {
+	r.query = &query
+	return r.id, r.err
+}
+
+func TestHCLParser_DataSources(t *testing.T) {
+	t.Run("read from state", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "main.tf"), amiDataConfig)
+		writeFile(t, filepath.Join(dir, "terraform.tfstate"), amiDataState)
+		resolver := &fakeAMIResolver{id: "ami-0aws"}
+
+		instances, err := tfrepo.NewHCLParser(tfrepo.WithAMIResolver(resolver)).ParseDirectory(dir)
+
+		require.NoError(t, err)
+		require.Len(t, instances, 1)
+		assert.Equal(t, "ami-0state", instances[0].AMI)
+		assert.Empty(t, instances[0].UnresolvedFields)
+		assert.Nil(t, resolver.query, "AWS is not asked about data sources the state records")
+	})
+
+	t.Run("resolved in AWS", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "main.tf"), amiDataConfig)
+		resolver := &fakeAMIResolver{id: "ami-0aws"}
+
+		instances, err := tfrepo.NewHCLParser(tfrepo.WithAMIResolver(resolver)).ParseDirectory(dir)
+
+		require.NoError(t, err)
+		require.Len(t, instances, 1)
+		assert.Equal(t, "ami-0aws", instances[0].AMI)
+		assert.Empty(t, instances[0].UnresolvedFields)
+		assert.Equal(t, &tfrepo.AMIQuery{
+			Owners:     []string{"099720109477"},
+			NameRegex:  "^ubuntu-jammy",
+			MostRecent: true,
+			Filters: map[string][]string{
+				"name":                {"ubuntu/images/hvm-ssd/ubuntu-jammy-22.04-amd64-server-*"},
+				"virtualization-type": {"hvm"},
+			},
+		}, resolver.query)
+	})
+
+	t.Run("unresolved", func(t *testing.T) {
+		for name, opts := range map[string][]tfrepo.HCLParserOption{
+			"without a resolver":    nil,
+			"when the lookup fails": {tfrepo.WithAMIResolver(&fakeAMIResolver{err: errors.New("access denied")})},
+		} {
+			t.Run(name, func(t *testing.T) {
+				dir := t.TempDir()
+				writeFile(t, filepath.Join(dir, "main.tf"), amiDataConfig)
+
+				instances, err := tfrepo.NewHCLParser(opts...).ParseDirectory(dir)
+
+				require.NoError(t, err)
+				require.Len(t, instances, 1)
+				assert.Empty(t, instances[0].AMI)
+				assert.Equal(t, map[string]string{"AMI": "data.aws_ami.ubuntu"}, instances[0].UnresolvedFields)
+			})
+		}
+	})
+}
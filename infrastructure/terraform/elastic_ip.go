@@ -0,0 +1,121 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+)
+
+// Ensure both state repositories can extract Elastic IPs
+var (
+	_ repositories.ElasticIPStateRepository = (*TerraformRepository)(nil)
+	_ repositories.ElasticIPStateRepository = (*TerraformStateRepository)(nil)
+)
+
+// elasticIPIndex holds the Elastic IPs of a state by allocation ID
+type elasticIPIndex map[string]*models.ElasticIP
+
+// GetElasticIPConfigs extracts aws_eip and aws_eip_association resources from a Terraform state file
+func (r *TerraformRepository) GetElasticIPConfigs(ctx context.Context, statePath string) ([]*models.ElasticIP, error) {
+	state, err := r.parser.ParseState(ctx, statePath)
+	if err != nil {
+		return nil, fmt.Errorf("parsing Terraform state: %w", err)
+	}
+
+	// Associations name the instance of an address, whichever is read first
+	index := make(elasticIPIndex)
+	for _, resourceType := range []string{"aws_eip", "aws_eip_association"} {
+		for _, resource := range state.Resources {
+			if resource.Mode != "managed" || resource.Type != resourceType {
+				continue
+			}
+			for _, instance := range resource.Instances {
+				if instance.Attributes == nil {
+					continue
+				}
+				address := FormatResourceAddress(resource.Module, resource.Type, resource.Name, instance.IndexKey)
+				index.add(resource.Type, address, instance.Attributes)
+			}
+		}
+	}
+	return index.sorted(), nil
+}
+
+// GetElasticIPConfigs extracts aws_eip and aws_eip_association resources from a Terraform state file
+func (r *TerraformStateRepository) GetElasticIPConfigs(ctx context.Context, statePath string) ([]*models.ElasticIP, error) {
+	state, err := readState(ctx, r.reader, statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	index := make(elasticIPIndex)
+	if state.Values != nil {
+		collectElasticIPs(state.Values.RootModule, index, "aws_eip")
+		collectElasticIPs(state.Values.RootModule, index, "aws_eip_association")
+	}
+	return index.sorted(), nil
+}
+
+// collectElasticIPs adds the resources of resourceType in module and its children
+func collectElasticIPs(module *tfjson.StateModule, index elasticIPIndex, resourceType string) {
+	if module == nil {
+		return
+	}
+
+	for _, resource := range module.Resources {
+		if resource.Mode != tfjson.ManagedResourceMode || resource.Type != resourceType || resource.AttributeValues == nil {
+			continue
+		}
+		index.add(resource.Type, resource.Address, resource.AttributeValues)
+	}
+
+	for _, child := range module.ChildModules {
+		collectElasticIPs(child, index, resourceType)
+	}
+}
+
+// add indexes an aws_eip or aws_eip_association resource. An association
+// sets the instance of the address it names, which need not be declared in
+// the same state.
+func (idx elasticIPIndex) add(resourceType, address string, attrs map[string]interface{}) {
+	allocationID, _ := attrs["allocation_id"].(string)
+	if allocationID == "" && resourceType == "aws_eip" {
+		allocationID, _ = attrs["id"].(string)
+	}
+	if allocationID == "" {
+		return
+	}
+
+	eip := idx[allocationID]
+	if eip == nil {
+		eip = &models.ElasticIP{AllocationID: allocationID, ResourceAddress: address}
+		idx[allocationID] = eip
+	}
+	if publicIP, _ := attrs["public_ip"].(string); publicIP != "" {
+		eip.PublicIP = publicIP
+	}
+
+	switch resourceType {
+	case "aws_eip":
+		eip.InstanceID, _ = attrs["instance"].(string)
+	case "aws_eip_association":
+		eip.InstanceID, _ = attrs["instance_id"].(string)
+		eip.ResourceAddress = address
+	}
+}
+
+// sorted returns the Elastic IPs ordered by allocation ID
+func (idx elasticIPIndex) sorted() []*models.ElasticIP {
+	eips := make([]*models.ElasticIP, 0, len(idx))
+	for _, eip := range idx {
+		eips = append(eips, eip)
+	}
+	sort.Slice(eips, func(i, j int) bool {
+		return eips[i].AllocationID < eips[j].AllocationID
+	})
+	return eips
+}
@@ -0,0 +1,57 @@
+package terraform_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	tfrepo "driftdetector/infrastructure/terraform"
+)
+
+func TestTerraformStateRepository_GetElasticIPConfigs(t *testing.T) {
+	repo := tfrepo.NewTerraformStateRepository()
+
+	eips, err := repo.GetElasticIPConfigs(context.Background(), filepath.Join(terraformFixtureDir, "state", "elastic_ips.json"))
+
+	require.NoError(t, err)
+	assert.Equal(t, []*models.ElasticIP{
+		{AllocationID: "eipalloc-0bastion", PublicIP: "198.51.100.7", InstanceID: "i-0bastion", ResourceAddress: "module.bastion.aws_eip.this"},
+		{AllocationID: "eipalloc-0web", PublicIP: "203.0.113.10", InstanceID: "i-0web", ResourceAddress: "aws_eip_association.web"},
+	}, eips, "associations name the instance of the address they reference")
+}
+
+func TestTerraformRepository_GetElasticIPConfigs(t *testing.T) {
+	state := &models.TerraformState{
+		Resources: []models.TerraformResource{
+			// Listed before the address so the association must still win
+			{Mode: "managed", Type: "aws_eip_association", Name: "app", Instances: []models.TerraformResourceInstance{
+				{Attributes: map[string]interface{}{"allocation_id": "eipalloc-app", "instance_id": "i-app"}},
+			}},
+			{Mode: "managed", Type: "aws_eip", Name: "app", Instances: []models.TerraformResourceInstance{
+				{Attributes: map[string]interface{}{"id": "eipalloc-app", "public_ip": "203.0.113.20", "instance": ""}},
+			}},
+			{Mode: "managed", Type: "aws_eip", Name: "spare", Instances: []models.TerraformResourceInstance{
+				{IndexKey: float64(0), Attributes: map[string]interface{}{"allocation_id": "eipalloc-spare", "public_ip": "203.0.113.30"}},
+			}},
+			{Mode: "data", Type: "aws_eip", Name: "shared", Instances: []models.TerraformResourceInstance{
+				{Attributes: map[string]interface{}{"id": "eipalloc-shared", "instance": "i-shared"}},
+			}},
+		},
+	}
+	parser := &MockStateParser{ParseStateFunc: func(ctx context.Context, path string) (*models.TerraformState, error) {
+		return state, nil
+	}}
+	repo := tfrepo.NewTerraformRepository(parser).(*tfrepo.TerraformRepository)
+
+	eips, err := repo.GetElasticIPConfigs(context.Background(), "terraform.tfstate")
+
+	require.NoError(t, err)
+	assert.Equal(t, []*models.ElasticIP{
+		{AllocationID: "eipalloc-app", PublicIP: "203.0.113.20", InstanceID: "i-app", ResourceAddress: "aws_eip_association.app"},
+		{AllocationID: "eipalloc-spare", PublicIP: "203.0.113.30", ResourceAddress: "aws_eip.spare[0]"},
+	}, eips, "data sources are not managed by this configuration")
+}
@@ -0,0 +1,757 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/zclconf/go-cty/cty"
+	"driftdetector/domain/models"
+)
+
+// configFileSchema selects the top-level blocks relevant to instance configuration
+var configFileSchema = &hcl.BodySchema{
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "resource", LabelNames: []string{"type", "name"}},
+		{Type: "variable", LabelNames: []string{"name"}},
+		{Type: "locals"},
+		{Type: "data", LabelNames: []string{"type", "name"}},
+		{Type: "provider", LabelNames: []string{"name"}},
+	},
+}
+
+// providerSchema selects the provider arguments that affect instance configuration
+var providerSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "alias"},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "default_tags"},
+	},
+}
+
+// defaultTagsSchema selects the tags of a provider's default_tags block
+var defaultTagsSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "tags"},
+	},
+}
+
+// variableSchema selects the default value and type of a variable block
+var variableSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "default"},
+		{Name: "type"},
+	},
+}
+
+// instanceSchema selects the aws_instance and aws_spot_instance_request
+// arguments mapped to the domain model
+var instanceSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "ami"},
+		{Name: "instance_type"},
+		{Name: "key_name"},
+		{Name: "subnet_id"},
+		{Name: "vpc_security_group_ids"},
+		{Name: "security_groups"},
+		{Name: "private_ip"},
+		{Name: "associate_public_ip_address"},
+		{Name: "iam_instance_profile"},
+		{Name: "monitoring"},
+		{Name: "availability_zone"},
+		{Name: "tenancy"},
+		{Name: "host_id"},
+		{Name: "placement_group"},
+		{Name: "placement_partition_number"},
+		{Name: "cpu_core_count"},
+		{Name: "cpu_threads_per_core"},
+		{Name: "ebs_optimized"},
+		{Name: "disable_api_termination"},
+		{Name: "instance_initiated_shutdown_behavior"},
+		{Name: "hibernation"},
+		{Name: "tags"},
+		{Name: "user_data"},
+		{Name: "user_data_base64"},
+		{Name: "spot_price"},
+		{Name: "instance_interruption_behavior"},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "root_block_device"},
+		{Type: "ebs_block_device"},
+		{Type: "network_interface"},
+		{Type: "enclave_options"},
+		{Type: "metadata_options"},
+		{Type: "instance_market_options"},
+		{Type: "cpu_options"},
+		{Type: "lifecycle"},
+	},
+}
+
+// rootBlockDeviceSchema selects the root_block_device arguments mapped to the domain model
+var rootBlockDeviceSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "volume_size"},
+		{Name: "volume_type"},
+		{Name: "iops"},
+		{Name: "throughput"},
+		{Name: "encrypted"},
+		{Name: "kms_key_id"},
+	},
+}
+
+// ebsBlockDeviceSchema selects the ebs_block_device arguments mapped to the domain model
+var ebsBlockDeviceSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "device_name"},
+		{Name: "volume_size"},
+		{Name: "volume_type"},
+		{Name: "iops"},
+		{Name: "throughput"},
+		{Name: "encrypted"},
+		{Name: "kms_key_id"},
+		{Name: "delete_on_termination"},
+		{Name: "tags"},
+	},
+}
+
+// networkInterfaceSchema selects the network_interface arguments mapped to the domain model
+var networkInterfaceSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "device_index"},
+		{Name: "network_interface_id"},
+		{Name: "delete_on_termination"},
+	},
+}
+
+// enclaveOptionsSchema selects the enclave_options arguments
+var enclaveOptionsSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "enabled"},
+	},
+}
+
+// cpuOptionsSchema selects the cpu_options arguments
+var cpuOptionsSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "core_count"},
+		{Name: "threads_per_core"},
+	},
+}
+
+// metadataOptionsSchema selects the metadata_options arguments
+var metadataOptionsSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "http_endpoint"},
+		{Name: "http_tokens"},
+		{Name: "http_put_response_hop_limit"},
+		{Name: "instance_metadata_tags"},
+	},
+}
+
+// HCLParser reads aws_instance resources from Terraform configuration files,
+// in either native (.tf) or JSON (.tf.json) syntax
+type HCLParser struct {
+	varFiles    []string
+	vars        map[string]string
+	concurrency int
+	workspace   string
+	amis        AMIResolver
+
+	// varFileCache holds the variable files already read, since every
+	// directory of a large repository loads the same --var-file files
+	varFileMu    sync.Mutex
+	varFileCache map[string]varFileEntry
+}
+
+// NewHCLParser creates a new HCLParser
+func NewHCLParser(opts ...HCLParserOption) *HCLParser {
+	p := &HCLParser{}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// ParseHCLAll parses a Terraform configuration file and returns every
+// aws_instance resource it declares, each tagged with its resource address.
+// Files ending in .tf.json are read with the JSON syntax parser; both syntaxes
+// share the same decoding so equivalent files produce identical instances.
+// Arguments that cannot be evaluated statically (for example references to
+// other resources) are left unset.
+func (p *HCLParser) ParseHCLAll(path string) ([]*models.Instance, error) {
+	file, err := parseConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.parseBody(context.Background(), file.Body, filepath.Dir(path))
+}
+
+// ParseDirectory parses every .tf and .tf.json file directly inside dir as a
+// single configuration, the way Terraform loads a module, and returns every
+// aws_instance resource it declares. Variables, locals and data sources may be
+// declared in any of the files. Data sources are read from the directory's
+// local state, or looked up in AWS with WithAMIResolver; arguments that depend
+// on the others are left unset and recorded in UnresolvedFields. Each file is
+// parsed once, several at a time (see WithParseConcurrency).
+func (p *HCLParser) ParseDirectory(dir string) ([]*models.Instance, error) {
+	return p.parseDirectory(context.Background(), dir)
+}
+
+// parseDirectory parses the configuration in dir, looking up data sources
+// with ctx
+func (p *HCLParser) parseDirectory(ctx context.Context, dir string) ([]*models.Instance, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", dir, err)
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if !entry.IsDir() && IsConfigFile(path) {
+			paths = append(paths, path)
+		}
+	}
+
+	if len(paths) == 0 {
+		return []*models.Instance{}, nil
+	}
+
+	files, err := p.parseConfigFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.parseBody(ctx, hcl.MergeFiles(files), dir)
+}
+
+// parseConfigFiles parses the files at paths with at most p.concurrency
+// files in flight, returning them in the order of paths. When several files
+// fail to parse, the error names the first of them.
+func (p *HCLParser) parseConfigFiles(paths []string) ([]*hcl.File, error) {
+	files := make([]*hcl.File, len(paths))
+	errs := make([]error, len(paths))
+
+	workers := p.concurrency
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(paths) {
+		workers = len(paths)
+	}
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				files[i], errs[i] = parseConfigFile(paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// parseConfigFile parses a configuration file in the syntax its name implies.
+// Unlike an hclparse.Parser, it keeps no state, so files can be parsed
+// concurrently.
+func parseConfigFile(path string) (*hcl.File, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	if IsJSONConfigFile(path) {
+		file, diags = hcljson.Parse(src, path)
+	} else {
+		file, diags = hclsyntax.ParseConfig(src, path, hcl.Pos{Line: 1, Column: 1, Byte: 0})
+	}
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing %s: %s", path, diags.Error())
+	}
+	return file, nil
+}
+
+// IsConfigFile reports whether path is a Terraform configuration file in
+// either syntax. Extensions are matched in any case, as on case-insensitive
+// filesystems.
+func IsConfigFile(path string) bool {
+	return hasExtension(path, ".tf") || IsJSONConfigFile(path)
+}
+
+// IsJSONConfigFile reports whether path is a Terraform configuration file in JSON syntax
+func IsJSONConfigFile(path string) bool {
+	return hasExtension(path, ".json") && hasExtension(strings.TrimSuffix(path, filepath.Ext(path)), ".tf")
+}
+
+// hasExtension reports whether the extension of path is ext in any case
+func hasExtension(path, ext string) bool {
+	return strings.EqualFold(filepath.Ext(path), ext)
+}
+
+// parseBody extracts instances from the top-level body of the configuration
+// in dir, which is also where variable files and state are loaded from
+func (p *HCLParser) parseBody(ctx context.Context, body hcl.Body, dir string) ([]*models.Instance, error) {
+	content, _, diags := body.PartialContent(configFileSchema)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("reading configuration: %s", diags.Error())
+	}
+
+	vars, err := p.variableValues(dir, content.Blocks)
+	if err != nil {
+		return nil, err
+	}
+
+	evalCtx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"var": cty.ObjectVal(vars),
+			"terraform": cty.ObjectVal(map[string]cty.Value{
+				"workspace": cty.StringVal(p.workspaceName(dir)),
+			}),
+		},
+	}
+	evalCtx.Variables["data"] = p.dataSourceValues(ctx, dir, content.Blocks, evalCtx)
+	evalCtx.Variables["local"] = cty.ObjectVal(localValues(content.Blocks, evalCtx))
+
+	defaultTags := providerDefaultTags(content.Blocks, evalCtx)
+
+	instances := make([]*models.Instance, 0)
+	for _, block := range content.Blocks {
+		if block.Type != "resource" || !isInstanceResource(block.Labels[0]) {
+			continue
+		}
+
+		instance, err := parseInstanceBlock(block, evalCtx)
+		if err != nil {
+			return nil, err
+		}
+
+		// Tags on the resource take precedence over the provider's defaults
+		for k, v := range defaultTags {
+			if _, ok := instance.Tags[k]; !ok {
+				instance.AddTag(k, v)
+			}
+		}
+		instances = append(instances, instance)
+	}
+
+	return instances, nil
+}
+
+// providerDefaultTags returns the default_tags of the default aws provider
+// configuration. Aliased providers are skipped, since resources only use
+// them when they say so.
+func providerDefaultTags(blocks hcl.Blocks, evalCtx *hcl.EvalContext) map[string]string {
+	tags := make(map[string]string)
+	for _, block := range blocks {
+		if block.Type != "provider" || block.Labels[0] != "aws" {
+			continue
+		}
+
+		content, _, _ := block.Body.PartialContent(providerSchema)
+		if _, aliased := content.Attributes["alias"]; aliased {
+			continue
+		}
+
+		for _, nested := range content.Blocks {
+			nestedContent, _, _ := nested.Body.PartialContent(defaultTagsSchema)
+			attrs := evalAttributes(nestedContent.Attributes, evalCtx)
+			for k, v := range stringMapAttr(attrs, "tags") {
+				tags[k] = v
+			}
+		}
+	}
+	return tags
+}
+
+// variableDefaults collects the default value of every variable block
+func variableDefaults(blocks hcl.Blocks) map[string]cty.Value {
+	vars := make(map[string]cty.Value)
+	for _, block := range blocks {
+		if block.Type != "variable" {
+			continue
+		}
+
+		content, _, diags := block.Body.PartialContent(variableSchema)
+		if diags.HasErrors() {
+			continue
+		}
+
+		if attr, ok := content.Attributes["default"]; ok {
+			if val, diags := attr.Expr.Value(nil); !diags.HasErrors() {
+				vars[block.Labels[0]] = val
+			}
+		}
+	}
+	return vars
+}
+
+// localValues evaluates every locals block. Locals may refer to each other, so
+// they are evaluated in passes until no more can be resolved; the rest are
+// unknown, which leaves the arguments that use them unset.
+func localValues(blocks hcl.Blocks, evalCtx *hcl.EvalContext) map[string]cty.Value {
+	pending := make(hcl.Attributes)
+	for _, block := range blocks {
+		if block.Type != "locals" {
+			continue
+		}
+		attrs, diags := block.Body.JustAttributes()
+		if diags.HasErrors() {
+			continue
+		}
+		for name, attr := range attrs {
+			pending[name] = attr
+		}
+	}
+
+	locals := make(map[string]cty.Value, len(pending))
+	for len(pending) > 0 {
+		ctx := evalCtx.NewChild()
+		ctx.Variables = map[string]cty.Value{"local": cty.ObjectVal(locals)}
+
+		resolved := 0
+		for name, attr := range pending {
+			val, diags := attr.Expr.Value(ctx)
+			if diags.HasErrors() {
+				continue
+			}
+			locals[name] = val
+			delete(pending, name)
+			resolved++
+		}
+		if resolved == 0 {
+			break
+		}
+	}
+
+	for name := range pending {
+		locals[name] = cty.DynamicVal
+	}
+	return locals
+}
+
+// parseInstanceBlock converts an aws_instance resource block into a domain Instance
+func parseInstanceBlock(block *hcl.Block, evalCtx *hcl.EvalContext) (*models.Instance, error) {
+	address := fmt.Sprintf("%s.%s", block.Labels[0], block.Labels[1])
+
+	content, _, diags := block.Body.PartialContent(instanceSchema)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("reading %s: %s", address, diags.Error())
+	}
+
+	attrs := evalAttributes(content.Attributes, evalCtx)
+
+	instance := models.NewInstance("", stringAttr(attrs, "instance_type"), stringAttr(attrs, "ami"))
+	instance.ResourceAddress = address
+	instance.UnresolvedFields = unresolvedDataReferences(content.Attributes, evalCtx)
+	instance.Source = sourceLocation(block.DefRange)
+	instance.SourceMap = attributeSources(content, evalCtx)
+	instance.KeyName = stringAttr(attrs, "key_name")
+	instance.SubnetID = stringAttr(attrs, "subnet_id")
+	instance.PrivateIPAddress = stringAttr(attrs, "private_ip")
+	instance.IAMInstanceProfile = stringAttr(attrs, "iam_instance_profile")
+	instance.AvailabilityZone = stringAttr(attrs, "availability_zone")
+	instance.Tenancy = stringAttr(attrs, "tenancy")
+	instance.HostID = stringAttr(attrs, "host_id")
+	instance.PlacementGroup = stringAttr(attrs, "placement_group")
+	if partition, ok := intAttr(attrs, "placement_partition_number"); ok {
+		instance.PartitionNumber = partition
+	}
+	instance.AssociatePublicIPAddress = boolAttr(attrs, "associate_public_ip_address")
+	instance.Monitoring = boolAttr(attrs, "monitoring")
+	instance.EBSOptimized = boolAttr(attrs, "ebs_optimized")
+	instance.DisableAPITermination = boolAttr(attrs, "disable_api_termination")
+	instance.InstanceInitiatedShutdownBehavior = stringAttr(attrs, "instance_initiated_shutdown_behavior")
+	if coreCount, ok := intAttr(attrs, "cpu_core_count"); ok {
+		instance.CPUCoreCount = coreCount
+	}
+	if threadsPerCore, ok := intAttr(attrs, "cpu_threads_per_core"); ok {
+		instance.CPUThreadsPerCore = threadsPerCore
+	}
+
+	// user_data_base64 is decoded when compared, so it can be kept as written
+	instance.UserData = stringAttr(attrs, "user_data")
+	if instance.UserData == "" {
+		instance.UserData = stringAttr(attrs, "user_data_base64")
+	}
+
+	if hibernation := boolAttr(attrs, "hibernation"); hibernation != nil {
+		instance.Hibernation = &models.HibernationOptions{Configured: *hibernation}
+	}
+	if block.Labels[0] == spotInstanceRequestType {
+		parseSpotRequestArguments(attrs, instance)
+	}
+
+	for k, v := range stringMapAttr(attrs, "tags") {
+		instance.AddTag(k, v)
+	}
+
+	if sgs, ok := attrs["vpc_security_group_ids"]; ok && sgs.CanIterateElements() {
+		for it := sgs.ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			if v.IsKnown() && !v.IsNull() && v.Type() == cty.String {
+				instance.SecurityGroups = append(instance.SecurityGroups, models.SecurityGroup{GroupID: v.AsString()})
+			}
+		}
+	} else if attr, ok := content.Attributes["vpc_security_group_ids"]; ok {
+		parseSecurityGroupRefs(attr, evalCtx, instance)
+	}
+	if names, ok := attrs["security_groups"]; ok && names.CanIterateElements() {
+		for it := names.ElementIterator(); it.Next(); {
+			_, v := it.Element()
+			if v.IsKnown() && !v.IsNull() && v.Type() == cty.String {
+				instance.SecurityGroups = append(instance.SecurityGroups, models.SecurityGroup{GroupName: v.AsString()})
+			}
+		}
+	}
+	instance.ParseWarnings = evaluationWarnings(content.Attributes, evalCtx, instance)
+
+	for _, nested := range content.Blocks {
+		switch nested.Type {
+		case "root_block_device":
+			parseRootBlockDevice(nested, evalCtx, instance)
+		case "ebs_block_device":
+			instance.EBSBlockDevices = append(instance.EBSBlockDevices, parseEBSBlockDevice(nested, evalCtx))
+		case "network_interface":
+			instance.NetworkInterfaces = append(instance.NetworkInterfaces, parseNetworkInterface(nested, evalCtx))
+		case "enclave_options":
+			nestedContent, _, _ := nested.Body.PartialContent(enclaveOptionsSchema)
+			if enabled := boolAttr(evalAttributes(nestedContent.Attributes, evalCtx), "enabled"); enabled != nil {
+				instance.EnclaveOptions = &models.EnclaveOptions{Enabled: *enabled}
+			}
+		case "metadata_options":
+			parseMetadataOptions(nested, evalCtx, instance)
+		case "instance_market_options":
+			parseInstanceMarketOptions(nested, evalCtx, instance)
+		case "cpu_options":
+			nestedContent, _, _ := nested.Body.PartialContent(cpuOptionsSchema)
+			cpuAttrs := evalAttributes(nestedContent.Attributes, evalCtx)
+			if coreCount, ok := intAttr(cpuAttrs, "core_count"); ok {
+				instance.CPUCoreCount = coreCount
+			}
+			if threadsPerCore, ok := intAttr(cpuAttrs, "threads_per_core"); ok {
+				instance.CPUThreadsPerCore = threadsPerCore
+			}
+		case "lifecycle":
+			paths, warnings := parseIgnoreChanges(nested, address)
+			instance.IgnoreChanges = paths
+			instance.ParseWarnings = append(instance.ParseWarnings, warnings...)
+		}
+	}
+
+	sort.Slice(instance.EBSBlockDevices, func(i, j int) bool {
+		return instance.EBSBlockDevices[i].DeviceName < instance.EBSBlockDevices[j].DeviceName
+	})
+	sortNetworkInterfaces(instance.NetworkInterfaces)
+
+	return instance, nil
+}
+
+// parseRootBlockDevice copies root_block_device arguments onto the instance
+func parseRootBlockDevice(block *hcl.Block, evalCtx *hcl.EvalContext, instance *models.Instance) {
+	content, _, _ := block.Body.PartialContent(rootBlockDeviceSchema)
+	attrs := evalAttributes(content.Attributes, evalCtx)
+
+	if size, ok := intAttr(attrs, "volume_size"); ok {
+		instance.RootVolumeSize = size
+	}
+	instance.RootVolumeType = stringAttr(attrs, "volume_type")
+	if iops, ok := intAttr(attrs, "iops"); ok {
+		instance.RootVolumeIops = iops
+	}
+	if throughput, ok := intAttr(attrs, "throughput"); ok {
+		instance.RootVolumeThroughput = throughput
+	}
+	instance.RootVolumeEncrypted = boolAttr(attrs, "encrypted")
+	instance.RootVolumeKMSKeyID = stringAttr(attrs, "kms_key_id")
+}
+
+// parseEBSBlockDevice converts an ebs_block_device block
+func parseEBSBlockDevice(block *hcl.Block, evalCtx *hcl.EvalContext) models.EBSBlockDevice {
+	content, _, _ := block.Body.PartialContent(ebsBlockDeviceSchema)
+	attrs := evalAttributes(content.Attributes, evalCtx)
+
+	device := models.EBSBlockDevice{
+		DeviceName:          stringAttr(attrs, "device_name"),
+		VolumeType:          stringAttr(attrs, "volume_type"),
+		Encrypted:           boolAttr(attrs, "encrypted"),
+		KMSKeyID:            stringAttr(attrs, "kms_key_id"),
+		DeleteOnTermination: boolAttr(attrs, "delete_on_termination"),
+	}
+	if size, ok := intAttr(attrs, "volume_size"); ok {
+		device.VolumeSize = size
+	}
+	if iops, ok := intAttr(attrs, "iops"); ok {
+		device.Iops = iops
+	}
+	if throughput, ok := intAttr(attrs, "throughput"); ok {
+		device.Throughput = throughput
+	}
+	if tags := stringMapAttr(attrs, "tags"); len(tags) > 0 {
+		device.Tags = tags
+	}
+	return device
+}
+
+// parseNetworkInterface converts a network_interface block. The addresses and
+// security groups of the interface belong to its aws_network_interface.
+func parseNetworkInterface(block *hcl.Block, evalCtx *hcl.EvalContext) models.NetworkInterface {
+	content, _, _ := block.Body.PartialContent(networkInterfaceSchema)
+	attrs := evalAttributes(content.Attributes, evalCtx)
+
+	ni := models.NetworkInterface{
+		NetworkInterfaceID:  stringAttr(attrs, "network_interface_id"),
+		DeleteOnTermination: boolAttr(attrs, "delete_on_termination"),
+	}
+	if index, ok := intAttr(attrs, "device_index"); ok {
+		ni.DeviceIndex = index
+	}
+	return ni
+}
+
+// parseMetadataOptions copies metadata_options arguments onto the instance
+func parseMetadataOptions(block *hcl.Block, evalCtx *hcl.EvalContext, instance *models.Instance) {
+	content, _, _ := block.Body.PartialContent(metadataOptionsSchema)
+	attrs := evalAttributes(content.Attributes, evalCtx)
+
+	options := &models.MetadataOptions{
+		HTTPEndpoint:         stringAttr(attrs, "http_endpoint"),
+		HTTPTokens:           stringAttr(attrs, "http_tokens"),
+		InstanceMetadataTags: stringAttr(attrs, "instance_metadata_tags"),
+	}
+	if hopLimit, ok := intAttr(attrs, "http_put_response_hop_limit"); ok {
+		options.HTTPPutResponseHopLimit = hopLimit
+	}
+	instance.MetadataOptions = options
+}
+
+// evalAttributes evaluates every attribute, dropping those that cannot be
+// resolved statically or evaluate to null or unknown values
+func evalAttributes(attributes hcl.Attributes, evalCtx *hcl.EvalContext) map[string]cty.Value {
+	values := make(map[string]cty.Value, len(attributes))
+	for name, attr := range attributes {
+		val, diags := attr.Expr.Value(evalCtx)
+		if diags.HasErrors() || val.IsNull() || !val.IsWhollyKnown() {
+			continue
+		}
+		values[name] = val
+	}
+	return values
+}
+
+// evaluationWarnings returns a warning for each attribute whose expression
+// fails to evaluate, such as one referring to an undeclared variable. Data
+// sources that could not be read and security group references are reported
+// elsewhere, so attributes depending on them are skipped.
+func evaluationWarnings(attributes hcl.Attributes, evalCtx *hcl.EvalContext, instance *models.Instance) []models.ParseWarning {
+	var warnings []models.ParseWarning
+	for name, attr := range attributes {
+		_, diags := attr.Expr.Value(evalCtx)
+		if !diags.HasErrors() {
+			continue
+		}
+		if _, ok := instance.UnresolvedFields[instanceAttributeFields[name]]; ok {
+			continue
+		}
+		if name == "vpc_security_group_ids" && len(instance.UnresolvedRefs["SecurityGroups"]) > 0 {
+			continue
+		}
+		warnings = append(warnings, parseWarning(attr.Expr.Range(), name, diags))
+	}
+	sort.Slice(warnings, func(i, j int) bool {
+		return warnings[i].Line < warnings[j].Line
+	})
+	return warnings
+}
+
+// parseWarning describes the first error of diags, raised reading attribute
+// at rng
+func parseWarning(rng hcl.Range, attribute string, diags hcl.Diagnostics) models.ParseWarning {
+	location := sourceLocation(rng)
+	warning := models.ParseWarning{File: location.File, Line: location.Line, Attribute: attribute}
+	for _, diag := range diags {
+		if diag.Severity != hcl.DiagError {
+			continue
+		}
+		warning.Reason = diag.Summary
+		if diag.Detail != "" {
+			warning.Reason += ": " + diag.Detail
+		}
+		break
+	}
+	return warning
+}
+
+// stringAttr returns a string attribute, or "" if absent or not a string
+func stringAttr(attrs map[string]cty.Value, name string) string {
+	val, ok := attrs[name]
+	if !ok || val.Type() != cty.String {
+		return ""
+	}
+	return val.AsString()
+}
+
+// boolAttr returns a bool attribute, or nil if absent or not a bool
+func boolAttr(attrs map[string]cty.Value, name string) *bool {
+	val, ok := attrs[name]
+	if !ok || val.Type() != cty.Bool {
+		return nil
+	}
+	b := val.True()
+	return &b
+}
+
+// stringMapAttr returns the known string values of a map or object attribute
+func stringMapAttr(attrs map[string]cty.Value, name string) map[string]string {
+	values := make(map[string]string)
+	v, ok := attrs[name]
+	if !ok || !v.IsKnown() || v.IsNull() || !v.CanIterateElements() {
+		return values
+	}
+	for it := v.ElementIterator(); it.Next(); {
+		k, elem := it.Element()
+		if elem.IsKnown() && !elem.IsNull() && elem.Type() == cty.String {
+			values[k.AsString()] = elem.AsString()
+		}
+	}
+	return values
+}
+
+// intAttr returns a whole-number attribute
+func intAttr(attrs map[string]cty.Value, name string) (int, bool) {
+	val, ok := attrs[name]
+	if !ok || val.Type() != cty.Number {
+		return 0, false
+	}
+	i, accuracy := val.AsBigFloat().Int64()
+	if accuracy != big.Exact {
+		return 0, false
+	}
+	return int(i), true
+}
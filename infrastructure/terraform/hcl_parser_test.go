@@ -0,0 +1,375 @@
+package terraform_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	tfrepo "driftdetector/infrastructure/terraform"
+)
+
+const terraformFixtureDir = "../../testdata/terraform"
+
+func instancesByAddress(instances []*models.Instance) map[string]*models.Instance {
+	byAddress := make(map[string]*models.Instance, len(instances))
+	for _, instance := range instances {
+		byAddress[instance.ResourceAddress] = instance
+	}
+	return byAddress
+}
+
+// withoutSource returns copies of instances without their source locations.
+// The fields of the SourceMap are kept, so both syntaxes must map the same ones.
+func withoutSource(instances []*models.Instance) []*models.Instance {
+	stripped := make([]*models.Instance, 0, len(instances))
+	for _, instance := range instances {
+		c := *instance
+		c.Source = nil
+		if c.SourceMap != nil {
+			c.SourceMap = make(map[string]*models.SourceLocation, len(instance.SourceMap))
+			for field := range instance.SourceMap {
+				c.SourceMap[field] = nil
+			}
+		}
+		stripped = append(stripped, &c)
+	}
+	return stripped
+}
+
+func TestHCLParser_ParseHCLAll(t *testing.T) {
+	parser := tfrepo.NewHCLParser()
+
+	t.Run("multiple instances in one file", func(t *testing.T) {
+		// Given a file declaring three aws_instance resources and an unrelated resource
+		path := filepath.Join(terraformFixtureDir, "hcl", "multi_instance.tf")
+
+		// When parsing the file
+		instances, err := parser.ParseHCLAll(path)
+
+		// Then every instance is returned in declaration order
+		require.NoError(t, err)
+		require.Len(t, instances, 3)
+		assert.Equal(t, "aws_instance.web", instances[0].ResourceAddress)
+		assert.Equal(t, "aws_instance.worker", instances[1].ResourceAddress)
+		assert.Equal(t, "aws_instance.bastion", instances[2].ResourceAddress)
+	})
+
+	t.Run("identical resources are not collapsed", func(t *testing.T) {
+		instances, err := parser.ParseHCLAll(filepath.Join(terraformFixtureDir, "hcl", "multi_instance.tf"))
+		require.NoError(t, err)
+
+		byAddress := instancesByAddress(instances)
+		web, worker := byAddress["aws_instance.web"], byAddress["aws_instance.worker"]
+		require.NotNil(t, web)
+		require.NotNil(t, worker)
+
+		assert.Equal(t, web.AMI, worker.AMI)
+		assert.Equal(t, web.Type, worker.Type)
+		assert.Equal(t, "frontend", web.Tags["Role"])
+		assert.Equal(t, "backend", worker.Tags["Role"])
+	})
+
+	t.Run("unresolvable references are left unset", func(t *testing.T) {
+		instances, err := parser.ParseHCLAll(filepath.Join(terraformFixtureDir, "hcl", "multi_instance.tf"))
+		require.NoError(t, err)
+
+		bastion := instancesByAddress(instances)["aws_instance.bastion"]
+		require.NotNil(t, bastion)
+
+		assert.Empty(t, bastion.SecurityGroups)
+		require.NotNil(t, bastion.AssociatePublicIPAddress)
+		assert.True(t, *bastion.AssociatePublicIPAddress)
+		assert.Equal(t, 16, bastion.RootVolumeSize)
+		assert.Equal(t, "gp3", bastion.RootVolumeType)
+		require.NotNil(t, bastion.RootVolumeEncrypted)
+		assert.True(t, *bastion.RootVolumeEncrypted)
+	})
+
+	t.Run("variable defaults are resolved", func(t *testing.T) {
+		instances, err := parser.ParseHCLAll(filepath.Join(terraformFixtureDir, "complex_instance.tf"))
+		require.NoError(t, err)
+		require.Len(t, instances, 1)
+
+		web := instances[0]
+		assert.Equal(t, "aws_instance.web", web.ResourceAddress)
+		assert.Equal(t, "t3.medium", web.Type)
+		assert.Equal(t, "ami-0c55b159cbfafe1f0", web.AMI)
+		assert.Equal(t, "test-web-server", web.Tags["Name"])
+		assert.Equal(t, "test", web.Tags["Environment"])
+	})
+
+	t.Run("secondary ebs block devices", func(t *testing.T) {
+		instances, err := parser.ParseHCLAll(filepath.Join(terraformFixtureDir, "complex_instance.tf"))
+		require.NoError(t, err)
+		require.Len(t, instances, 1)
+
+		devices := instances[0].EBSBlockDevices
+		require.Len(t, devices, 1)
+		assert.Equal(t, "/dev/sdh", devices[0].DeviceName)
+		assert.Equal(t, 50, devices[0].VolumeSize)
+		assert.Equal(t, 3000, devices[0].Iops)
+		assert.Equal(t, 150, devices[0].Throughput)
+		require.NotNil(t, devices[0].Encrypted)
+		assert.True(t, *devices[0].Encrypted)
+	})
+
+	t.Run("shutdown behavior and hibernation", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "main.tf")
+		require.NoError(t, os.WriteFile(path, []byte(`resource "aws_instance" "web" {
+  ami                                  = "ami-1"
+  instance_type                        = "m5.large"
+  hibernation                          = true
+  instance_initiated_shutdown_behavior = "terminate"
+}`), 0644))
+
+		instances, err := parser.ParseHCLAll(path)
+
+		require.NoError(t, err)
+		require.Len(t, instances, 1)
+		assert.Equal(t, "terminate", instances[0].InstanceInitiatedShutdownBehavior)
+		assert.Equal(t, &models.HibernationOptions{Configured: true}, instances[0].Hibernation)
+		assert.Equal(t, 5, instances[0].SourceMap["InstanceInitiatedShutdownBehavior"].Line)
+	})
+
+	t.Run("invalid syntax", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "broken.tf")
+		require.NoError(t, os.WriteFile(path, []byte(`resource "aws_instance" "web" {`), 0644))
+
+		_, err := parser.ParseHCLAll(path)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestHCLParser_ParseDirectory(t *testing.T) {
+	parser := tfrepo.NewHCLParser()
+
+	t.Run("variables, locals and data sources from sibling files", func(t *testing.T) {
+		instances, err := parser.ParseDirectory(filepath.Join(terraformFixtureDir, "hcl", "multi_file"))
+
+		require.NoError(t, err)
+		require.Len(t, instances, 1)
+
+		web := instances[0]
+		assert.Equal(t, "aws_instance.web", web.ResourceAddress)
+		assert.Equal(t, "t3.medium", web.Type, "variable defaults come from variables.tf")
+		assert.Equal(t, map[string]string{
+			"Name":        "app-staging-web",
+			"Environment": "staging",
+		}, web.Tags, "locals may refer to later locals")
+		assert.Empty(t, web.AMI, "data source references are left unset")
+		assert.Equal(t, "subnet-0123456789abcdef0", web.SubnetID)
+		assert.Empty(t, web.ParseWarnings, "unresolved data sources are reported as unverifiable instead")
+	})
+
+	t.Run("attributes that fail to evaluate", func(t *testing.T) {
+		// Given an instance whose key name refers to an undeclared variable
+		dir := t.TempDir()
+		content := `resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+  key_name      = var.missing
+}`
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte(content), 0644))
+
+		// When parsing the directory
+		instances, err := parser.ParseDirectory(dir)
+
+		// Then the attribute is left unset with a parse warning
+		require.NoError(t, err)
+		require.Len(t, instances, 1)
+		assert.Empty(t, instances[0].KeyName)
+		require.Len(t, instances[0].ParseWarnings, 1)
+		warning := instances[0].ParseWarnings[0]
+		assert.Equal(t, filepath.ToSlash(filepath.Join(dir, "main.tf")), warning.File)
+		assert.Equal(t, 3, warning.Line)
+		assert.Equal(t, "key_name", warning.Attribute)
+		assert.Contains(t, warning.Reason, "missing")
+	})
+
+	t.Run("provider default tags", func(t *testing.T) {
+		instances, err := parser.ParseDirectory(filepath.Join(terraformFixtureDir, "hcl", "default_tags"))
+
+		require.NoError(t, err)
+		require.Len(t, instances, 1)
+		assert.Equal(t, map[string]string{
+			"Name":        "web",
+			"Team":        "web",
+			"Environment": "prod",
+			"ManagedBy":   "terraform",
+		}, instances[0].Tags, "resource tags win and aliased providers are skipped")
+	})
+
+	t.Run("uppercase extensions", func(t *testing.T) {
+		// Given files named as on a case-insensitive filesystem
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "MAIN.TF"), []byte(`resource "aws_instance" "web" {
+  instance_type = "t3.micro"
+}`), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "Worker.TF.JSON"), []byte(`{"resource": {"aws_instance": {"worker": {"instance_type": "c5.large"}}}}`), 0644))
+
+		// When parsing the directory
+		instances, err := parser.ParseDirectory(dir)
+
+		// Then both files are read
+		require.NoError(t, err)
+		var addresses []string
+		for _, instance := range instances {
+			addresses = append(addresses, instance.ResourceAddress)
+		}
+		assert.ElementsMatch(t, []string{"aws_instance.web", "aws_instance.worker"}, addresses)
+	})
+
+	t.Run("directory without configuration files", func(t *testing.T) {
+		instances, err := parser.ParseDirectory(t.TempDir())
+
+		require.NoError(t, err)
+		assert.Empty(t, instances)
+	})
+}
+
+func TestHCLParser_SyntaxEquivalence(t *testing.T) {
+	parser := tfrepo.NewHCLParser()
+
+	native, err := parser.ParseHCLAll(filepath.Join(terraformFixtureDir, "hcl", "syntax_equivalence.tf"))
+	require.NoError(t, err)
+	require.Len(t, native, 2)
+
+	tests := []struct {
+		name string
+		file string
+	}{
+		{"native syntax", "syntax_equivalence.tf"},
+		{"JSON syntax", "syntax_equivalence.tf.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instances, err := parser.ParseHCLAll(filepath.Join(terraformFixtureDir, "hcl", tt.file))
+			require.NoError(t, err)
+			require.Len(t, instances, 2)
+
+			app := instancesByAddress(instances)["aws_instance.app"]
+			require.NotNil(t, app)
+			assert.Equal(t, "m5.large", app.Type)
+			assert.Equal(t, "staging-app", app.Tags["Name"])
+			assert.Equal(t, "staging", app.Tags["Environment"])
+			assert.Len(t, app.SecurityGroups, 2)
+			assert.Equal(t, 40, app.RootVolumeSize)
+			assert.Equal(t, 3000, app.RootVolumeIops)
+			assert.True(t, app.HibernationConfigured())
+			require.NotNil(t, app.EnclaveOptions)
+			assert.False(t, app.EnclaveOptions.Enabled)
+			assert.Equal(t, &models.MetadataOptions{
+				HTTPEndpoint:            "enabled",
+				HTTPTokens:              "required",
+				HTTPPutResponseHopLimit: 2,
+				InstanceMetadataTags:    "disabled",
+			}, app.MetadataOptions)
+
+			// Only the declaration's position depends on the syntax
+			require.NotNil(t, app.Source)
+			assert.Equal(t, tt.file, filepath.Base(app.Source.File))
+			assert.Positive(t, app.Source.Line)
+
+			assert.ElementsMatch(t, withoutSource(native), withoutSource(instances), "both syntaxes must decode to identical instances")
+		})
+	}
+}
+
+func TestIsConfigFile(t *testing.T) {
+	tests := []struct {
+		path     string
+		config   bool
+		jsonFile bool
+	}{
+		{"main.tf", true, false},
+		{"main.tf.json", true, true},
+		{"MAIN.TF", true, false},
+		{"Main.Tf.Json", true, true},
+		{filepath.Join("modules", "web.tf", "README.md"), false, false},
+		{"main.json", false, false},
+		{"terraform.tfstate", false, false},
+		{"plan.json", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.config, tfrepo.IsConfigFile(tt.path))
+			assert.Equal(t, tt.jsonFile, tfrepo.IsJSONConfigFile(tt.path))
+		})
+	}
+}
+
+// writeLargeModule writes a module of files .tf files, each declaring a
+// variable and an instance using it, plus a terraform.tfvars
+func writeLargeModule(tb testing.TB, files int) string {
+	dir := tb.TempDir()
+	var tfvars string
+	for i := 0; i < files; i++ {
+		content := fmt.Sprintf(`variable "type_%[1]d" {
+  type    = string
+  default = "t3.micro"
+}
+
+locals {
+  name_%[1]d = "web-%[1]d"
+}
+
+resource "aws_instance" "web_%[1]d" {
+  ami           = "ami-0c55b159cbfafe1f0"
+  instance_type = var.type_%[1]d
+  tags = {
+    Name = local.name_%[1]d
+  }
+  root_block_device {
+    volume_size = 20
+    volume_type = "gp3"
+  }
+}
+`, i)
+		require.NoError(tb, os.WriteFile(filepath.Join(dir, fmt.Sprintf("web_%03d.tf", i)), []byte(content), 0644))
+		tfvars += fmt.Sprintf("type_%d = \"m5.large\"\n", i)
+	}
+	require.NoError(tb, os.WriteFile(filepath.Join(dir, "terraform.tfvars"), []byte(tfvars), 0644))
+	return dir
+}
+
+func TestHCLParser_ParseDirectoryConcurrently(t *testing.T) {
+	dir := writeLargeModule(t, 50)
+
+	sequential, err := tfrepo.NewHCLParser(tfrepo.WithParseConcurrency(1)).ParseDirectory(dir)
+	require.NoError(t, err)
+	concurrent, err := tfrepo.NewHCLParser(tfrepo.WithParseConcurrency(8)).ParseDirectory(dir)
+	require.NoError(t, err)
+
+	require.Len(t, concurrent, 50)
+	assert.Equal(t, sequential, concurrent, "files are merged in the same order however they are parsed")
+	web := instancesByAddress(concurrent)["aws_instance.web_7"]
+	require.NotNil(t, web)
+	assert.Equal(t, "m5.large", web.Type)
+	assert.Equal(t, "web-7", web.Tags["Name"])
+}
+
+func BenchmarkHCLParser_ParseDirectory(b *testing.B) {
+	dir := writeLargeModule(b, 300)
+
+	for _, concurrency := range []int{1, 0} {
+		name := "sequential"
+		if concurrency == 0 {
+			name = "concurrent"
+		}
+		b.Run(name, func(b *testing.B) {
+			parser := tfrepo.NewHCLParser(tfrepo.WithParseConcurrency(concurrency))
+			for i := 0; i < b.N; i++ {
+				if _, err := parser.ParseDirectory(dir); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
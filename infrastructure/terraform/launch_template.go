@@ -0,0 +1,322 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	tfjson "github.com/hashicorp/terraform-json"
+
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/awsutil"
+	"driftdetector/infrastructure/logger"
+)
+
+// LaunchTemplateResolver looks up the instance settings of a launch template
+// version in AWS
+type LaunchTemplateResolver interface {
+	ResolveLaunchTemplate(ctx context.Context, spec models.LaunchTemplateSpecification) (*models.Instance, error)
+}
+
+// TerraformStateRepositoryOption configures a TerraformStateRepository
+type TerraformStateRepositoryOption func(*TerraformStateRepository)
+
+// WithLaunchTemplateResolver resolves the launch templates instances reference
+// in AWS, so $Latest and $Default are the versions AWS would launch today.
+// Templates the resolver cannot find fall back to the state.
+func WithLaunchTemplateResolver(resolver LaunchTemplateResolver) TerraformStateRepositoryOption {
+	return func(r *TerraformStateRepository) {
+		r.templates = resolver
+	}
+}
+
+// launchTemplateIndex holds the aws_launch_template resources of a state by
+// both ID and name
+type launchTemplateIndex map[string]*models.LaunchTemplate
+
+// collectLaunchTemplates adds the launch templates of module and its children
+func collectLaunchTemplates(module *tfjson.StateModule, index launchTemplateIndex) {
+	if module == nil {
+		return
+	}
+
+	for _, resource := range module.Resources {
+		if resource.Type != "aws_launch_template" || resource.AttributeValues == nil {
+			continue
+		}
+		index.add(parseLaunchTemplateResource(resource.AttributeValues))
+	}
+
+	for _, child := range module.ChildModules {
+		collectLaunchTemplates(child, index)
+	}
+}
+
+// find returns the template spec refers to, or nil
+func (idx launchTemplateIndex) find(spec models.LaunchTemplateSpecification) *models.LaunchTemplate {
+	if template, ok := idx[spec.ID]; ok && spec.ID != "" {
+		return template
+	}
+	if template, ok := idx[spec.Name]; ok && spec.Name != "" {
+		return template
+	}
+	return nil
+}
+
+// collectStateLaunchTemplates adds the launch templates of a raw state file
+func collectStateLaunchTemplates(state *models.TerraformState, index launchTemplateIndex) {
+	for _, resource := range state.Resources {
+		if resource.Mode != "managed" || resource.Type != "aws_launch_template" {
+			continue
+		}
+		for _, instance := range resource.Instances {
+			if instance.Attributes == nil {
+				continue
+			}
+			index.add(parseLaunchTemplateResource(instance.Attributes))
+		}
+	}
+}
+
+// add indexes template by its ID and name
+func (idx launchTemplateIndex) add(template *models.LaunchTemplate) {
+	if template.ID != "" {
+		idx[template.ID] = template
+	}
+	if template.Name != "" {
+		idx[template.Name] = template
+	}
+}
+
+// applyLaunchTemplates merges the settings of each instance's launch template
+// into the instance, resolving templates with resolver when it is not nil.
+// Templates that cannot be resolved are skipped with a parse warning on the
+// instance.
+func applyLaunchTemplates(ctx context.Context, resolver LaunchTemplateResolver, instances []*models.Instance, index launchTemplateIndex) {
+	for _, instance := range instances {
+		if instance.LaunchTemplate == nil {
+			continue
+		}
+
+		settings, err := resolveLaunchTemplate(ctx, resolver, instance.LaunchTemplate, index)
+		if err != nil {
+			logger.Debug("launch template settings not merged", "address", instance.ResourceAddress, "error", err)
+			warning := models.ParseWarning{Attribute: "launch_template", Reason: fmt.Sprintf("settings not merged: %v", err)}
+			if instance.Source != nil {
+				warning.File, warning.Line = instance.Source.File, instance.Source.Line
+			}
+			instance.ParseWarnings = append(instance.ParseWarnings, warning)
+			continue
+		}
+		logger.Debug("merged launch template", "address", instance.ResourceAddress, "template", instance.LaunchTemplate.String())
+		instance.MergeLaunchTemplate(settings)
+	}
+}
+
+// resolveLaunchTemplate returns the settings of the template version spec
+// refers to, filling in the template's ID or name when spec lacks it
+func resolveLaunchTemplate(ctx context.Context, resolver LaunchTemplateResolver, spec *models.LaunchTemplateSpecification, index launchTemplateIndex) (*models.Instance, error) {
+	template := index.find(*spec)
+	if template != nil {
+		if spec.ID == "" {
+			spec.ID = template.ID
+		}
+		if spec.Name == "" {
+			spec.Name = template.Name
+		}
+	}
+
+	if resolver != nil {
+		settings, err := resolver.ResolveLaunchTemplate(ctx, *spec)
+		if err == nil {
+			return settings, nil
+		}
+		if template == nil {
+			return nil, err
+		}
+		logger.Warn("falling back to the launch template in state", "template", spec.String(), "error", err)
+	}
+
+	if template == nil {
+		return nil, fmt.Errorf("launch template %s is not in the state", spec)
+	}
+	// The state only records the template's latest version
+	if !template.Supplies(spec.Version) {
+		return nil, fmt.Errorf("launch template %s: the state only holds version %d", spec, template.LatestVersion)
+	}
+	return template.Instance, nil
+}
+
+// parseLaunchTemplateSpecification reads the launch_template block of an
+// aws_instance
+func parseLaunchTemplateSpecification(attrs map[string]interface{}) *models.LaunchTemplateSpecification {
+	block := firstBlock(attrs, "launch_template")
+	if block == nil {
+		return nil
+	}
+
+	var spec models.LaunchTemplateSpecification
+	spec.ID, _ = block["id"].(string)
+	spec.Name, _ = block["name"].(string)
+	spec.Version, _ = block["version"].(string)
+	if spec.ID == "" && spec.Name == "" {
+		return nil
+	}
+	return &spec
+}
+
+// parseLaunchTemplateResource reads the settings of an aws_launch_template.
+// The state holds the template's latest version.
+func parseLaunchTemplateResource(attrs map[string]interface{}) *models.LaunchTemplate {
+	template := &models.LaunchTemplate{Instance: &models.Instance{}}
+	template.ID, _ = attrs["id"].(string)
+	template.Name, _ = attrs["name"].(string)
+	if v, ok := attrs["latest_version"].(float64); ok {
+		template.LatestVersion = int(v)
+	}
+	if v, ok := attrs["default_version"].(float64); ok {
+		template.DefaultVersion = int(v)
+	}
+
+	instance := template.Instance
+	instance.AMI, _ = attrs["image_id"].(string)
+	instance.Type, _ = attrs["instance_type"].(string)
+	instance.KeyName, _ = attrs["key_name"].(string)
+	instance.UserData, _ = attrs["user_data"].(string)
+	instance.EBSOptimized = stateBool(attrs["ebs_optimized"])
+	instance.DisableAPITermination = stateBool(attrs["disable_api_termination"])
+	instance.InstanceInitiatedShutdownBehavior, _ = attrs["instance_initiated_shutdown_behavior"].(string)
+
+	for _, id := range stringList(attrs["vpc_security_group_ids"]) {
+		instance.SecurityGroups = append(instance.SecurityGroups, models.SecurityGroup{GroupID: id})
+	}
+	for _, name := range stringList(attrs["security_group_names"]) {
+		instance.SecurityGroups = append(instance.SecurityGroups, models.SecurityGroup{GroupName: name})
+	}
+
+	if profile := firstBlock(attrs, "iam_instance_profile"); profile != nil {
+		instance.IAMInstanceProfile, _ = profile["name"].(string)
+		if arn, ok := profile["arn"].(string); ok && instance.IAMInstanceProfile == "" {
+			instance.IAMInstanceProfile = awsutil.InstanceProfileName(arn)
+		}
+	}
+
+	if monitoring := firstBlock(attrs, "monitoring"); monitoring != nil {
+		instance.Monitoring = stateBool(monitoring["enabled"])
+	}
+
+	if placement := firstBlock(attrs, "placement"); placement != nil {
+		instance.AvailabilityZone, _ = placement["availability_zone"].(string)
+		instance.Tenancy, _ = placement["tenancy"].(string)
+		instance.HostID, _ = placement["host_id"].(string)
+		instance.PlacementGroup, _ = placement["group_name"].(string)
+		if v, ok := placement["partition_number"].(float64); ok {
+			instance.PartitionNumber = int(v)
+		}
+	}
+
+	if cpu := firstBlock(attrs, "cpu_options"); cpu != nil {
+		if v, ok := cpu["core_count"].(float64); ok {
+			instance.CPUCoreCount = int(v)
+		}
+		if v, ok := cpu["threads_per_core"].(float64); ok {
+			instance.CPUThreadsPerCore = int(v)
+		}
+	}
+
+	if hibernation := firstBlock(attrs, "hibernation_options"); hibernation != nil {
+		if configured, ok := hibernation["configured"].(bool); ok {
+			instance.Hibernation = &models.HibernationOptions{Configured: configured}
+		}
+	}
+
+	if enclave := firstBlock(attrs, "enclave_options"); enclave != nil {
+		if enabled, ok := enclave["enabled"].(bool); ok {
+			instance.EnclaveOptions = &models.EnclaveOptions{Enabled: enabled}
+		}
+	}
+
+	if opts := firstBlock(attrs, "metadata_options"); opts != nil {
+		instance.MetadataOptions = &models.MetadataOptions{}
+		instance.MetadataOptions.HTTPEndpoint, _ = opts["http_endpoint"].(string)
+		instance.MetadataOptions.HTTPTokens, _ = opts["http_tokens"].(string)
+		instance.MetadataOptions.InstanceMetadataTags, _ = opts["instance_metadata_tags"].(string)
+		if v, ok := opts["http_put_response_hop_limit"].(float64); ok {
+			instance.MetadataOptions.HTTPPutResponseHopLimit = int(v)
+		}
+	}
+
+	parseStateMarketOptions(attrs, instance)
+
+	// Only tags for instances apply to the instance itself
+	if specs, ok := attrs["tag_specifications"].([]interface{}); ok {
+		for _, item := range specs {
+			spec, ok := item.(map[string]interface{})
+			if !ok || spec["resource_type"] != "instance" {
+				continue
+			}
+			if tags, ok := spec["tags"].(map[string]interface{}); ok {
+				for k, v := range tags {
+					if s, ok := v.(string); ok {
+						instance.AddTag(k, s)
+					}
+				}
+			}
+		}
+	}
+
+	if mappings, ok := attrs["block_device_mappings"].([]interface{}); ok {
+		for _, item := range mappings {
+			mapping, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			ebs := firstBlock(mapping, "ebs")
+			if ebs == nil {
+				continue
+			}
+			device := parseStateEBSBlockDevice(ebs)
+			device.DeviceName, _ = mapping["device_name"].(string)
+			// The provider stores these as "true", "false" or ""
+			device.Encrypted = stateBool(ebs["encrypted"])
+			device.DeleteOnTermination = stateBool(ebs["delete_on_termination"])
+
+			if awsutil.IsCommonRootDeviceName(device.DeviceName) {
+				instance.RootVolumeSize = device.VolumeSize
+				instance.RootVolumeType = device.VolumeType
+				instance.RootVolumeIops = device.Iops
+				instance.RootVolumeThroughput = device.Throughput
+				instance.RootVolumeEncrypted = device.Encrypted
+				instance.RootVolumeKMSKeyID = device.KMSKeyID
+				continue
+			}
+			instance.EBSBlockDevices = append(instance.EBSBlockDevices, device)
+		}
+	}
+
+	return template
+}
+
+// firstBlock returns the first element of a nested block attribute
+func firstBlock(attrs map[string]interface{}, name string) map[string]interface{} {
+	blocks, ok := attrs[name].([]interface{})
+	if !ok || len(blocks) == 0 {
+		return nil
+	}
+	block, _ := blocks[0].(map[string]interface{})
+	return block
+}
+
+// stateBool reads a boolean that the provider may store as a bool or as the
+// strings "true" and "false"; anything else is unset
+func stateBool(value interface{}) *bool {
+	switch v := value.(type) {
+	case bool:
+		return &v
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return &b
+		}
+	}
+	return nil
+}
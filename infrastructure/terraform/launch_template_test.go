@@ -0,0 +1,107 @@
+package terraform_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	tfrepo "driftdetector/infrastructure/terraform"
+)
+
+// fakeTemplateResolver returns fixed settings for every launch template
+type fakeTemplateResolver struct {
+	settings *models.Instance
+	err      error
+	specs    []models.LaunchTemplateSpecification
+}
+
+func (f *fakeTemplateResolver) ResolveLaunchTemplate(ctx context.Context, spec models.LaunchTemplateSpecification) (*models.Instance, error) {
+	f.specs = append(f.specs, spec)
+	return f.settings, f.err
+}
+
+func launchTemplateInstances(t *testing.T, repo *tfrepo.TerraformStateRepository) map[string]*models.Instance {
+	t.Helper()
+
+	instances, err := repo.GetInstanceConfigs(context.Background(), filepath.Join(terraformFixtureDir, "state", "launch_template.json"))
+	require.NoError(t, err)
+
+	byID := make(map[string]*models.Instance, len(instances))
+	for _, instance := range instances {
+		byID[instance.ID] = instance
+	}
+	return byID
+}
+
+func TestTerraformStateRepository_LaunchTemplates(t *testing.T) {
+	t.Run("merges the template from state", func(t *testing.T) {
+		// Given an instance launched from the latest version of a template in the same state
+		instances := launchTemplateInstances(t, tfrepo.NewTerraformStateRepository())
+
+		// Then the template fills the settings the instance leaves unset
+		instance := instances["i-0a0a0a0a0a0a0a0a0"]
+		require.NotNil(t, instance)
+		assert.Equal(t, "t3.large", instance.Type, "instance settings override the template")
+		assert.Equal(t, "ami-0c55b159cbfafe1f0", instance.AMI)
+		assert.Equal(t, "deploy", instance.KeyName)
+		assert.Equal(t, []models.SecurityGroup{{GroupID: "sg-web"}}, instance.SecurityGroups)
+		assert.Equal(t, map[string]string{"Name": "web-latest", "Team": "platform"}, instance.Tags)
+		assert.Equal(t, 30, instance.RootVolumeSize)
+		assert.Equal(t, "gp3", instance.RootVolumeType)
+		require.NotNil(t, instance.RootVolumeEncrypted)
+		assert.True(t, *instance.RootVolumeEncrypted)
+		require.NotNil(t, instance.EBSOptimized)
+		assert.True(t, *instance.EBSOptimized)
+		require.NotNil(t, instance.Monitoring)
+		assert.True(t, *instance.Monitoring)
+
+		require.Len(t, instance.EBSBlockDevices, 1)
+		device := instance.EBSBlockDevices[0]
+		assert.Equal(t, "/dev/sdf", device.DeviceName)
+		assert.Equal(t, 100, device.VolumeSize)
+		assert.Nil(t, device.Encrypted, "an empty string leaves the setting unset")
+		require.NotNil(t, device.DeleteOnTermination)
+		assert.False(t, *device.DeleteOnTermination)
+
+		assert.Equal(t, &models.LaunchTemplateSpecification{ID: "lt-0123456789abcdef0", Name: "web", Version: "$Latest"}, instance.LaunchTemplate)
+	})
+
+	t.Run("skips versions the state does not hold", func(t *testing.T) {
+		// Given an instance pinned to an older version of the template
+		instances := launchTemplateInstances(t, tfrepo.NewTerraformStateRepository())
+
+		// Then nothing is merged, with a parse warning saying why
+		instance := instances["i-0b0b0b0b0b0b0b0b0"]
+		require.NotNil(t, instance)
+		assert.Empty(t, instance.Type)
+		assert.Empty(t, instance.AMI)
+		require.Len(t, instance.ParseWarnings, 1)
+		assert.Equal(t, "launch_template", instance.ParseWarnings[0].Attribute)
+		assert.Contains(t, instance.ParseWarnings[0].Reason, "the state only holds version")
+	})
+
+	t.Run("resolves templates with the resolver", func(t *testing.T) {
+		// Given a resolver that knows every version
+		resolver := &fakeTemplateResolver{settings: &models.Instance{Type: "m5.large", AMI: "ami-live"}}
+		instances := launchTemplateInstances(t, tfrepo.NewTerraformStateRepository(tfrepo.WithLaunchTemplateResolver(resolver)))
+
+		// Then its settings are used, including for pinned versions
+		assert.Equal(t, "t3.large", instances["i-0a0a0a0a0a0a0a0a0"].Type)
+		assert.Equal(t, "ami-live", instances["i-0a0a0a0a0a0a0a0a0"].AMI)
+		assert.Equal(t, "m5.large", instances["i-0b0b0b0b0b0b0b0b0"].Type)
+		assert.Contains(t, resolver.specs, models.LaunchTemplateSpecification{ID: "lt-0123456789abcdef0", Name: "web", Version: "2"})
+	})
+
+	t.Run("falls back to state when the resolver fails", func(t *testing.T) {
+		resolver := &fakeTemplateResolver{err: errors.New("access denied")}
+		instances := launchTemplateInstances(t, tfrepo.NewTerraformStateRepository(tfrepo.WithLaunchTemplateResolver(resolver)))
+
+		assert.Equal(t, "ami-0c55b159cbfafe1f0", instances["i-0a0a0a0a0a0a0a0a0"].AMI)
+		assert.Empty(t, instances["i-0b0b0b0b0b0b0b0b0"].AMI)
+	})
+}
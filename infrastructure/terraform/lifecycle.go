@@ -0,0 +1,191 @@
+package terraform
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/logger"
+)
+
+// lifecycleSchema selects the lifecycle arguments that affect drift detection
+var lifecycleSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "ignore_changes"},
+	},
+}
+
+// ignoreChangesFields maps aws_instance and aws_spot_instance_request
+// arguments to the domain fields they set. Arguments that are blocks map their nested arguments too.
+var ignoreChangesFields = map[string][]string{
+	"ami":                                  {"AMI"},
+	"instance_type":                        {"Type"},
+	"key_name":                             {"KeyName"},
+	"subnet_id":                            {"SubnetID"},
+	"vpc_security_group_ids":               {"SecurityGroups"},
+	"security_groups":                      {"SecurityGroups"},
+	"private_ip":                           {"PrivateIPAddress"},
+	"associate_public_ip_address":          {"AssociatePublicIPAddress"},
+	"iam_instance_profile":                 {"IAMInstanceProfile"},
+	"monitoring":                           {"Monitoring"},
+	"availability_zone":                    {"AvailabilityZone"},
+	"tenancy":                              {"Tenancy"},
+	"host_id":                              {"HostID"},
+	"placement_group":                      {"PlacementGroup"},
+	"placement_partition_number":           {"PartitionNumber"},
+	"cpu_core_count":                       {"CPUCoreCount"},
+	"cpu_threads_per_core":                 {"CPUThreadsPerCore"},
+	"ebs_optimized":                        {"EBSOptimized"},
+	"disable_api_termination":              {"DisableAPITermination"},
+	"instance_initiated_shutdown_behavior": {"InstanceInitiatedShutdownBehavior"},
+	"hibernation":                          {"Hibernation"},
+	"user_data":                            {"UserData"},
+	"user_data_base64":                     {"UserData"},
+	"tags":                                 {"Tags"},
+	"tags_all":                             {"Tags"},
+	"ebs_block_device":                     {"EBSBlockDevices"},
+	"network_interface":                    {"NetworkInterfaces"},
+	"enclave_options":                      {"EnclaveOptions"},
+	"metadata_options":                     {"MetadataOptions"},
+	"instance_market_options":              {"InstanceLifecycle", "InstanceMarketOptions"},
+	"spot_price":                           {"InstanceMarketOptions.MaxPrice"},
+	"instance_interruption_behavior":       {"InstanceMarketOptions.InstanceInterruptionBehavior"},
+	"cpu_options":                          {"CPUCoreCount", "CPUThreadsPerCore"},
+	"root_block_device": {
+		"RootVolumeSize", "RootVolumeType", "RootVolumeIops",
+		"RootVolumeThroughput", "RootVolumeEncrypted", "RootVolumeKMSKeyID",
+	},
+}
+
+// ignoreChangesNestedFields maps the arguments of nested blocks to domain fields
+var ignoreChangesNestedFields = map[string]map[string]string{
+	"root_block_device": {
+		"volume_size": "RootVolumeSize",
+		"volume_type": "RootVolumeType",
+		"iops":        "RootVolumeIops",
+		"throughput":  "RootVolumeThroughput",
+		"encrypted":   "RootVolumeEncrypted",
+		"kms_key_id":  "RootVolumeKMSKeyID",
+	},
+	"cpu_options": {
+		"core_count":       "CPUCoreCount",
+		"threads_per_core": "CPUThreadsPerCore",
+	},
+	"metadata_options": {
+		"http_endpoint":               "MetadataOptions.HTTPEndpoint",
+		"http_tokens":                 "MetadataOptions.HTTPTokens",
+		"http_put_response_hop_limit": "MetadataOptions.HTTPPutResponseHopLimit",
+		"instance_metadata_tags":      "MetadataOptions.InstanceMetadataTags",
+	},
+}
+
+// parseIgnoreChanges returns the field paths, in the form the drift detector
+// ignores, of the ignore_changes argument of a lifecycle block, and warnings
+// for the entries it cannot read. ignore_changes = all ignores every field.
+// Arguments that are not compared are skipped.
+func parseIgnoreChanges(block *hcl.Block, address string) ([]string, []models.ParseWarning) {
+	content, _, _ := block.Body.PartialContent(lifecycleSchema)
+	attr, ok := content.Attributes["ignore_changes"]
+	if !ok {
+		return nil, nil
+	}
+
+	if hcl.ExprAsKeyword(attr.Expr) == "all" {
+		return []string{"*"}, nil
+	}
+
+	exprs, diags := hcl.ExprList(attr.Expr)
+	if diags.HasErrors() {
+		logger.Debug("lifecycle ignore_changes is not a list", "address", address, "error", diags.Error())
+		return nil, []models.ParseWarning{parseWarning(attr.Expr.Range(), "lifecycle.ignore_changes", diags)}
+	}
+
+	var paths []string
+	var warnings []models.ParseWarning
+	for _, expr := range exprs {
+		traversal, diags := hcl.AbsTraversalForExpr(expr)
+		if diags.HasErrors() {
+			logger.Debug("lifecycle ignore_changes entry is not an attribute reference", "address", address, "error", diags.Error())
+			warnings = append(warnings, parseWarning(expr.Range(), "lifecycle.ignore_changes", diags))
+			continue
+		}
+		fields := ignoreChangesPaths(traversalSteps(traversal))
+		if len(fields) == 0 {
+			logger.Debug("lifecycle ignore_changes entry does not name a compared field", "address", address, "entry", strings.Join(traversalSteps(traversal), "."))
+			continue
+		}
+		paths = append(paths, fields...)
+	}
+	return paths, warnings
+}
+
+// traversalSteps flattens a traversal such as tags["Name"] or
+// root_block_device[0].volume_size into its names and keys
+func traversalSteps(traversal hcl.Traversal) []string {
+	var steps []string
+	for _, step := range traversal {
+		switch s := step.(type) {
+		case hcl.TraverseRoot:
+			steps = append(steps, s.Name)
+		case hcl.TraverseAttr:
+			steps = append(steps, s.Name)
+		case hcl.TraverseIndex:
+			switch {
+			case s.Key.Type() == cty.String:
+				steps = append(steps, s.Key.AsString())
+			case s.Key.Type() == cty.Number:
+				steps = append(steps, s.Key.AsBigFloat().String())
+			}
+		}
+	}
+	return steps
+}
+
+// ignoreChangesPaths maps the steps of an ignore_changes entry to domain field
+// paths, e.g. ami to AMI, tags["X"] to Tags[X] and
+// root_block_device[0].volume_size to RootVolumeSize
+func ignoreChangesPaths(steps []string) []string {
+	if len(steps) == 0 {
+		return nil
+	}
+	name, rest := steps[0], steps[1:]
+
+	switch name {
+	case "tags", "tags_all":
+		if len(rest) > 0 {
+			return []string{"Tags[" + escapeFieldPattern(rest[0]) + "]"}
+		}
+	case "root_block_device", "cpu_options", "metadata_options":
+		// Blocks that hold one element may be indexed, as in root_block_device[0]
+		if len(rest) > 0 && isIndex(rest[0]) {
+			rest = rest[1:]
+		}
+		if len(rest) > 0 {
+			if field, ok := ignoreChangesNestedFields[name][rest[0]]; ok {
+				return []string{field}
+			}
+			return nil
+		}
+	}
+	return ignoreChangesFields[name]
+}
+
+// isIndex reports whether a traversal step is a list index
+func isIndex(step string) bool {
+	return step != "" && strings.Trim(step, "0123456789") == ""
+}
+
+// escapeFieldPattern escapes the wildcard characters of a map key, so an
+// ignored tag named "a*" does not match every tag starting with "a"
+func escapeFieldPattern(key string) string {
+	var sb strings.Builder
+	for _, r := range key {
+		if strings.ContainsRune(`*?[\`, r) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
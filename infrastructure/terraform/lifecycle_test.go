@@ -0,0 +1,102 @@
+package terraform_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	tfrepo "driftdetector/infrastructure/terraform"
+)
+
+func TestHCLParser_LifecycleIgnoreChanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected []string
+	}{
+		{
+			name: "attributes, tags and nested block arguments",
+			file: "main.tf",
+			content: `resource "aws_instance" "web" {
+  ami = "ami-1"
+  lifecycle {
+    ignore_changes = [ami, tags["LastPatched"], tags.Owner, root_block_device[0].volume_size, metadata_options, user_data, launch_template]
+  }
+}`,
+			expected: []string{"AMI", "Tags[LastPatched]", "Tags[Owner]", "RootVolumeSize", "MetadataOptions", "UserData"},
+		},
+		{
+			name: "legacy index syntax and whole blocks",
+			file: "main.tf",
+			content: `resource "aws_instance" "web" {
+  lifecycle {
+    ignore_changes = [root_block_device.0.volume_type, cpu_options]
+  }
+}`,
+			expected: []string{"RootVolumeType", "CPUCoreCount", "CPUThreadsPerCore"},
+		},
+		{
+			name: "all",
+			file: "main.tf",
+			content: `resource "aws_instance" "web" {
+  lifecycle {
+    ignore_changes = all
+  }
+}`,
+			expected: []string{"*"},
+		},
+		{
+			name:     "JSON syntax",
+			file:     "main.tf.json",
+			content:  `{"resource": {"aws_instance": {"web": {"lifecycle": {"ignore_changes": ["ami", "tags[\"Name\"]"]}}}}}`,
+			expected: []string{"AMI", "Tags[Name]"},
+		},
+		{
+			name:     "no lifecycle block",
+			file:     "main.tf",
+			content:  `resource "aws_instance" "web" {}`,
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+
+			instances, err := tfrepo.NewHCLParser().ParseHCLAll(path)
+
+			require.NoError(t, err)
+			require.Len(t, instances, 1)
+			assert.Equal(t, tt.expected, instances[0].IgnoreChanges)
+		})
+	}
+}
+
+func TestHCLParser_LifecycleIgnoreChangesWarnings(t *testing.T) {
+	// Given an ignore_changes entry that is not an attribute reference
+	path := filepath.Join(t.TempDir(), "main.tf")
+	content := `resource "aws_instance" "web" {
+  lifecycle {
+    ignore_changes = [ami, "tags"]
+  }
+}`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	// When parsing the file
+	instances, err := tfrepo.NewHCLParser().ParseHCLAll(path)
+
+	// Then the readable entries are kept and the other one is a parse warning
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	assert.Equal(t, []string{"AMI"}, instances[0].IgnoreChanges)
+	require.Len(t, instances[0].ParseWarnings, 1)
+	warning := instances[0].ParseWarnings[0]
+	assert.Equal(t, models.ParseWarning{File: filepath.ToSlash(path), Line: 3, Attribute: "lifecycle.ignore_changes", Reason: warning.Reason}, warning)
+	assert.NotEmpty(t, warning.Reason)
+}
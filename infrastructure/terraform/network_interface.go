@@ -0,0 +1,142 @@
+package terraform
+
+import (
+	"sort"
+
+	tfjson "github.com/hashicorp/terraform-json"
+
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/logger"
+)
+
+// networkInterfaceIndex holds the aws_network_interface resources of a state
+// by ID
+type networkInterfaceIndex map[string]*networkInterfaceResource
+
+// networkInterfaceResource is an aws_network_interface and the instance its
+// attachment block attaches it to, if any
+type networkInterfaceResource struct {
+	networkInterface models.NetworkInterface
+	instanceID       string
+}
+
+// collectNetworkInterfaces adds the network interfaces of module and its children
+func collectNetworkInterfaces(module *tfjson.StateModule, index networkInterfaceIndex) {
+	if module == nil {
+		return
+	}
+
+	for _, resource := range module.Resources {
+		if resource.Type != "aws_network_interface" || resource.AttributeValues == nil {
+			continue
+		}
+		index.add(parseNetworkInterfaceResource(resource.AttributeValues))
+	}
+
+	for _, child := range module.ChildModules {
+		collectNetworkInterfaces(child, index)
+	}
+}
+
+// collectStateNetworkInterfaces adds the network interfaces of a raw state file
+func collectStateNetworkInterfaces(state *models.TerraformState, index networkInterfaceIndex) {
+	for _, resource := range state.Resources {
+		if resource.Mode != "managed" || resource.Type != "aws_network_interface" {
+			continue
+		}
+		for _, instance := range resource.Instances {
+			if instance.Attributes == nil {
+				continue
+			}
+			index.add(parseNetworkInterfaceResource(instance.Attributes))
+		}
+	}
+}
+
+// add indexes resource by its ID
+func (idx networkInterfaceIndex) add(resource *networkInterfaceResource) {
+	if resource.networkInterface.NetworkInterfaceID != "" {
+		idx[resource.networkInterface.NetworkInterfaceID] = resource
+	}
+}
+
+// applyNetworkInterfaces fills in the addresses and security groups of the
+// interfaces each instance declares from the matching aws_network_interface,
+// and adds the interfaces whose attachment block names the instance
+func applyNetworkInterfaces(instances []*models.Instance, index networkInterfaceIndex) {
+	if len(index) == 0 {
+		return
+	}
+
+	for _, instance := range instances {
+		declared := make(map[string]bool, len(instance.NetworkInterfaces))
+		for i := range instance.NetworkInterfaces {
+			ni := &instance.NetworkInterfaces[i]
+			declared[ni.NetworkInterfaceID] = true
+			if resource, ok := index[ni.NetworkInterfaceID]; ok {
+				ni.PrivateIPAddresses = resource.networkInterface.PrivateIPAddresses
+				ni.Groups = resource.networkInterface.Groups
+			}
+		}
+
+		if instance.ID == "" {
+			continue
+		}
+		for id, resource := range index {
+			if resource.instanceID != instance.ID || declared[id] {
+				continue
+			}
+			logger.Debug("found attached network interface", "address", instance.ResourceAddress, "id", id)
+			instance.NetworkInterfaces = append(instance.NetworkInterfaces, resource.networkInterface)
+		}
+		sortNetworkInterfaces(instance.NetworkInterfaces)
+	}
+}
+
+// parseStateNetworkInterface converts one network_interface entry of an
+// aws_instance from state
+func parseStateNetworkInterface(attrs map[string]interface{}) models.NetworkInterface {
+	var ni models.NetworkInterface
+	if v, ok := attrs["device_index"].(float64); ok {
+		ni.DeviceIndex = int(v)
+	}
+	ni.NetworkInterfaceID, _ = attrs["network_interface_id"].(string)
+	ni.DeleteOnTermination = stateBool(attrs["delete_on_termination"])
+	return ni
+}
+
+// parseNetworkInterfaceResource reads an aws_network_interface
+func parseNetworkInterfaceResource(attrs map[string]interface{}) *networkInterfaceResource {
+	resource := &networkInterfaceResource{}
+	ni := &resource.networkInterface
+	ni.NetworkInterfaceID, _ = attrs["id"].(string)
+
+	ni.PrivateIPAddresses = stringList(attrs["private_ips"])
+	if len(ni.PrivateIPAddresses) == 0 {
+		if ip, ok := attrs["private_ip"].(string); ok && ip != "" {
+			ni.PrivateIPAddresses = []string{ip}
+		}
+	}
+	sort.Strings(ni.PrivateIPAddresses)
+
+	groups := stringList(attrs["security_groups"])
+	sort.Strings(groups)
+	for _, id := range groups {
+		ni.Groups = append(ni.Groups, models.SecurityGroup{GroupID: id})
+	}
+
+	if attachment := firstBlock(attrs, "attachment"); attachment != nil {
+		resource.instanceID, _ = attachment["instance"].(string)
+		if v, ok := attachment["device_index"].(float64); ok {
+			ni.DeviceIndex = int(v)
+		}
+	}
+	return resource
+}
+
+// sortNetworkInterfaces orders interfaces by device index
+func sortNetworkInterfaces(interfaces []models.NetworkInterface) {
+	sort.SliceStable(interfaces, func(i, j int) bool {
+		return interfaces[i].DeviceIndex < interfaces[j].DeviceIndex
+	})
+}
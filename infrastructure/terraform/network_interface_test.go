@@ -0,0 +1,120 @@
+package terraform_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	tfrepo "driftdetector/infrastructure/terraform"
+)
+
+// networkInterfaceState is a raw state with an instance launched with one
+// interface and a second interface attached through its attachment block
+const networkInterfaceState = `{
+  "version": 4,
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "instances": [{"attributes": {
+        "id": "i-web",
+        "instance_type": "t3.micro",
+        "network_interface": [{"device_index": 0, "network_interface_id": "eni-primary", "delete_on_termination": false}]
+      }}]
+    },
+    {
+      "mode": "managed",
+      "type": "aws_network_interface",
+      "name": "primary",
+      "instances": [{"attributes": {
+        "id": "eni-primary",
+        "private_ips": ["10.0.0.5"],
+        "security_groups": ["sg-web"],
+        "attachment": []
+      }}]
+    },
+    {
+      "mode": "managed",
+      "type": "aws_network_interface",
+      "name": "management",
+      "instances": [{"attributes": {
+        "id": "eni-management",
+        "private_ips": ["10.0.1.9", "10.0.1.5"],
+        "security_groups": ["sg-ssh", "sg-admin"],
+        "attachment": [{"instance": "i-web", "device_index": 1, "attachment_id": "eni-attach-1"}]
+      }}]
+    },
+    {
+      "mode": "managed",
+      "type": "aws_network_interface",
+      "name": "spare",
+      "instances": [{"attributes": {"id": "eni-spare", "private_ip": "10.0.2.1", "attachment": []}}]
+    }
+  ]
+}`
+
+func TestTerraformRepository_NetworkInterfaces(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "terraform.tfstate")
+	writeFile(t, path, networkInterfaceState)
+	repo := tfrepo.NewTerraformRepository(tfrepo.NewStateFileParser(nil))
+
+	instances, err := repo.GetInstanceConfigs(context.Background(), path)
+
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	interfaces := instances[0].NetworkInterfaces
+	require.Len(t, interfaces, 2, "interfaces attached to no instance are left out")
+
+	primary := interfaces[0]
+	assert.Equal(t, 0, primary.DeviceIndex)
+	assert.Equal(t, "eni-primary", primary.NetworkInterfaceID)
+	require.NotNil(t, primary.DeleteOnTermination)
+	assert.False(t, *primary.DeleteOnTermination)
+	assert.Equal(t, []string{"10.0.0.5"}, primary.PrivateIPAddresses)
+	assert.Equal(t, []models.SecurityGroup{{GroupID: "sg-web"}}, primary.Groups)
+
+	management := interfaces[1]
+	assert.Equal(t, 1, management.DeviceIndex)
+	assert.Equal(t, "eni-management", management.NetworkInterfaceID)
+	assert.Equal(t, []string{"10.0.1.5", "10.0.1.9"}, management.PrivateIPAddresses)
+	assert.Equal(t, []models.SecurityGroup{{GroupID: "sg-admin"}, {GroupID: "sg-ssh"}}, management.Groups)
+}
+
+func TestTerraformStateRepository_NetworkInterfaces(t *testing.T) {
+	// terraform show -json output with the interface in a child module
+	path := filepath.Join(t.TempDir(), "state.json")
+	writeFile(t, path, `{
+  "format_version": "1.0",
+  "values": {
+    "root_module": {
+      "resources": [
+        {"address": "aws_instance.web", "mode": "managed", "type": "aws_instance", "name": "web",
+         "values": {"id": "i-web", "instance_type": "t3.micro"}}
+      ],
+      "child_modules": [
+        {"address": "module.network", "resources": [
+          {"address": "module.network.aws_network_interface.management", "mode": "managed", "type": "aws_network_interface", "name": "management",
+           "values": {"id": "eni-management", "private_ips": ["10.0.1.5"], "security_groups": ["sg-ssh"],
+                      "attachment": [{"instance": "i-web", "device_index": 1}]}}
+        ]}
+      ]
+    }
+  }
+}`)
+
+	instances, err := tfrepo.NewTerraformStateRepository().GetInstanceConfigs(context.Background(), path)
+
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	assert.Equal(t, []models.NetworkInterface{{
+		DeviceIndex:        1,
+		NetworkInterfaceID: "eni-management",
+		PrivateIPAddresses: []string{"10.0.1.5"},
+		Groups:             []models.SecurityGroup{{GroupID: "sg-ssh"}},
+	}}, instances[0].NetworkInterfaces)
+}
@@ -0,0 +1,103 @@
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"driftdetector/domain/models"
+)
+
+// partialState is the top level of a state file, with resources and
+// outputs left undecoded so each can fail on its own
+type partialState struct {
+	Version          int                        `json:"version"`
+	TerraformVersion string                     `json:"terraform_version"`
+	Serial           int64                      `json:"serial"`
+	Lineage          string                     `json:"lineage"`
+	Outputs          map[string]json.RawMessage `json:"outputs"`
+	Resources        []json.RawMessage          `json:"resources"`
+}
+
+// partialResource is a resource whose instances are left undecoded
+type partialResource struct {
+	Module    string            `json:"module"`
+	Mode      string            `json:"mode"`
+	Type      string            `json:"type"`
+	Name      string            `json:"name"`
+	Provider  string            `json:"provider"`
+	Instances []json.RawMessage `json:"instances"`
+}
+
+// decodePartialState decodes a state resource by resource and instance by
+// instance, leaving out those that cannot be decoded and naming them in the
+// state's Warnings. It fails only when the top level of the state is
+// malformed, e.g. when the data is not JSON at all.
+func decodePartialState(data []byte) (*models.TerraformState, error) {
+	var raw partialState
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	state := &models.TerraformState{
+		Version:          raw.Version,
+		TerraformVersion: raw.TerraformVersion,
+		Serial:           raw.Serial,
+		Lineage:          raw.Lineage,
+	}
+
+	if raw.Outputs != nil {
+		state.Outputs = make(map[string]models.TerraformOutput, len(raw.Outputs))
+		for name, data := range raw.Outputs {
+			var output models.TerraformOutput
+			if err := json.Unmarshal(data, &output); err != nil {
+				state.Warnings = append(state.Warnings, fmt.Sprintf("output %s: %v", name, err))
+				continue
+			}
+			state.Outputs[name] = output
+		}
+	}
+
+	for i, data := range raw.Resources {
+		var resource models.TerraformResource
+		if err := json.Unmarshal(data, &resource); err == nil {
+			state.Resources = append(state.Resources, resource)
+			continue
+		}
+
+		// Keep the instances that decode, so one bad index of a count
+		// resource does not hide the others
+		var header partialResource
+		if err := json.Unmarshal(data, &header); err != nil {
+			state.Warnings = append(state.Warnings, fmt.Sprintf("resources[%d]: %v", i, err))
+			continue
+		}
+		resource = models.TerraformResource{
+			Module:   header.Module,
+			Mode:     header.Mode,
+			Type:     header.Type,
+			Name:     header.Name,
+			Provider: header.Provider,
+		}
+		for _, instanceData := range header.Instances {
+			var instance models.TerraformResourceInstance
+			if err := json.Unmarshal(instanceData, &instance); err != nil {
+				state.Warnings = append(state.Warnings, fmt.Sprintf("%s: %v", partialInstanceAddress(header, instanceData), err))
+				continue
+			}
+			resource.Instances = append(resource.Instances, instance)
+		}
+		state.Resources = append(state.Resources, resource)
+	}
+
+	return state, nil
+}
+
+// partialInstanceAddress returns the address of an instance that could not
+// be decoded, with its index key when that can still be read
+func partialInstanceAddress(resource partialResource, data json.RawMessage) string {
+	var key struct {
+		IndexKey interface{} `json:"index_key"`
+	}
+	_ = json.Unmarshal(data, &key)
+	return FormatResourceAddress(resource.Module, resource.Type, resource.Name, key.IndexKey)
+}
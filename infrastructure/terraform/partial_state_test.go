@@ -0,0 +1,57 @@
+package terraform_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tfrepo "driftdetector/infrastructure/terraform"
+)
+
+const partialStateFixture = "../../testdata/terraform/state/partial.tfstate"
+
+func TestStateFileParser_ParseState_Partial(t *testing.T) {
+	t.Run("resources that cannot be decoded are left out", func(t *testing.T) {
+		parser := tfrepo.NewStateFileParser(tfrepo.NewStateReader(nil))
+
+		state, err := parser.ParseState(context.Background(), partialStateFixture)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), state.Serial)
+		require.Len(t, state.Warnings, 2)
+		assert.Contains(t, state.Warnings[0], "aws_instance.corrupt")
+		assert.Contains(t, state.Warnings[1], "aws_instance.worker[1]")
+	})
+
+	t.Run("the other instances are still read", func(t *testing.T) {
+		repo := tfrepo.NewTerraformRepository(tfrepo.NewStateFileParser(tfrepo.NewStateReader(nil)))
+
+		instances, err := repo.GetInstanceConfigs(context.Background(), partialStateFixture)
+
+		require.NoError(t, err)
+		var addresses []string
+		for _, instance := range instances {
+			addresses = append(addresses, instance.ResourceAddress)
+		}
+		assert.Equal(t, []string{"aws_instance.web", "aws_instance.worker[0]"}, addresses)
+	})
+
+	t.Run("strict state fails", func(t *testing.T) {
+		parser := tfrepo.NewStateFileParser(tfrepo.NewStateReader(nil), tfrepo.WithStrictState())
+
+		_, err := parser.ParseState(context.Background(), partialStateFixture)
+
+		assert.ErrorContains(t, err, "unmarshaling Terraform state")
+	})
+
+	t.Run("data that is not a state still fails", func(t *testing.T) {
+		parser := tfrepo.NewStateFileParser(tfrepo.NewStateReader(nil))
+
+		_, err := parser.ParseStateReader(strings.NewReader(`{"resources": "none"}`), "broken.tfstate")
+
+		assert.ErrorContains(t, err, "unmarshaling Terraform state")
+	})
+}
@@ -0,0 +1,92 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// PlanRunner defines the interface for producing a structured Terraform plan
+type PlanRunner interface {
+	Plan(ctx context.Context, dir string) (*tfjson.Plan, error)
+}
+
+// CLIPlanRunner implements PlanRunner by invoking the terraform binary
+type CLIPlanRunner struct {
+	// Binary is the terraform executable to run
+	Binary string
+}
+
+// NewCLIPlanRunner creates a new CLIPlanRunner using the given terraform binary
+func NewCLIPlanRunner(binary string) *CLIPlanRunner {
+	if binary == "" {
+		binary = "terraform"
+	}
+	return &CLIPlanRunner{Binary: binary}
+}
+
+// Plan runs terraform plan in dir and returns the structured plan output.
+// The plan is written to a temporary file and rendered with terraform show -json,
+// since plan -json only streams UI events rather than the plan representation.
+func (r *CLIPlanRunner) Plan(ctx context.Context, dir string) (*tfjson.Plan, error) {
+	tmpDir, err := os.MkdirTemp("", "driftdetector-plan-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating plan directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	planFile := filepath.Join(tmpDir, "drift.tfplan")
+	if _, err := r.run(ctx, dir, "plan", "-input=false", "-lock=false", "-refresh=true", "-out="+planFile); err != nil {
+		return nil, err
+	}
+
+	out, err := r.run(ctx, dir, "show", "-json", planFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParsePlan(out)
+}
+
+// run executes a terraform subcommand in dir and returns its stdout
+func (r *CLIPlanRunner) run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, r.Binary, args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "TF_IN_AUTOMATION=1")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("running terraform %s: %w: %s", args[0], err, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// ParsePlan parses the JSON representation of a Terraform plan
+func ParsePlan(data []byte) (*tfjson.Plan, error) {
+	var plan tfjson.Plan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("unmarshaling Terraform plan: %w", err)
+	}
+
+	return &plan, nil
+}
+
+// ParsePlanFile reads and parses a Terraform plan JSON file
+func ParsePlanFile(path string) (*tfjson.Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading plan file: %w", err)
+	}
+
+	return ParsePlan(data)
+}
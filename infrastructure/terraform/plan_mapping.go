@@ -0,0 +1,276 @@
+package terraform
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"driftdetector/domain/models"
+	tfjson "github.com/hashicorp/terraform-json"
+)
+
+// instanceAttributePaths maps domain Instance fields to aws_instance attribute paths
+var instanceAttributePaths = map[string]string{
+	"ID":                                "id",
+	"Type":                              "instance_type",
+	"AMI":                               "ami",
+	"KeyName":                           "key_name",
+	"Tags":                              "tags",
+	"SubnetID":                          "subnet_id",
+	"SecurityGroups":                    "vpc_security_group_ids",
+	"PublicIPAddress":                   "public_ip",
+	"PrivateIPAddress":                  "private_ip",
+	"AssociatePublicIPAddress":          "associate_public_ip_address",
+	"PrivateDNSName":                    "private_dns",
+	"PublicDNSName":                     "public_dns",
+	"RootVolumeSize":                    "root_block_device.volume_size",
+	"RootVolumeType":                    "root_block_device.volume_type",
+	"RootVolumeIops":                    "root_block_device.iops",
+	"RootVolumeEncrypted":               "root_block_device.encrypted",
+	"RootVolumeThroughput":              "root_block_device.throughput",
+	"RootVolumeKMSKeyID":                "root_block_device.kms_key_id",
+	"EBSBlockDevices":                   "ebs_block_device",
+	"NetworkInterfaces":                 "network_interface",
+	"IAMInstanceProfile":                "iam_instance_profile",
+	"Monitoring":                        "monitoring",
+	"AvailabilityZone":                  "availability_zone",
+	"Tenancy":                           "tenancy",
+	"HostID":                            "host_id",
+	"PlacementGroup":                    "placement_group",
+	"PartitionNumber":                   "placement_partition_number",
+	"CPUCoreCount":                      "cpu_core_count",
+	"CPUThreadsPerCore":                 "cpu_threads_per_core",
+	"EBSOptimized":                      "ebs_optimized",
+	"DisableAPITermination":             "disable_api_termination",
+	"InstanceInitiatedShutdownBehavior": "instance_initiated_shutdown_behavior",
+	"Hibernation":                       "hibernation",
+	"EnclaveOptions":                    "enclave_options",
+	"MetadataOptions":                   "metadata_options",
+	"InstanceLifecycle":                 "instance_lifecycle",
+	"InstanceMarketOptions":             "instance_market_options",
+}
+
+// instanceAttributeFields maps top-level aws_instance arguments to the domain
+// Instance fields they set
+var instanceAttributeFields = func() map[string]string {
+	fields := make(map[string]string, len(instanceAttributePaths))
+	for field, attr := range instanceAttributePaths {
+		if !strings.Contains(attr, ".") {
+			fields[attr] = field
+		}
+	}
+	fields["user_data"] = "UserData"
+	fields["user_data_base64"] = "UserData"
+	fields["security_groups"] = "SecurityGroups"
+	return fields
+}()
+
+// sliceKeyPattern matches element keys in drift paths such as SecurityGroups[sg-123]
+var sliceKeyPattern = regexp.MustCompile(`\[[^\]]*\]`)
+
+// PlanAttributePath converts a drift path produced by the detector into the
+// equivalent aws_instance attribute path used in Terraform plans.
+// The second return value is false when the field has no Terraform counterpart.
+func PlanAttributePath(driftPath string) (string, bool) {
+	path := strings.TrimPrefix(driftPath, ".")
+	path = sliceKeyPattern.ReplaceAllString(path, "")
+	if path == "" {
+		return "", false
+	}
+
+	field, rest, _ := strings.Cut(path, ".")
+	attr, ok := instanceAttributePaths[field]
+	if !ok {
+		return "", false
+	}
+
+	// Only map keys (tags) carry a meaningful remainder; struct members of a
+	// list-valued field like SecurityGroups collapse onto the list itself.
+	if rest != "" && field == "Tags" {
+		attr += "." + rest
+	}
+
+	return attr, true
+}
+
+// PlanChangedPaths returns the normalized attribute paths that a resource change
+// will modify, with list indexes removed. Unknown-after-apply values count as changes.
+func PlanChangedPaths(change *tfjson.Change) []string {
+	if change == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	collectChangedPaths("", change.Before, change.After, seen)
+	collectUnknownPaths("", change.AfterUnknown, seen)
+
+	paths := make([]string, 0, len(seen))
+	for p := range seen {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	return paths
+}
+
+// UnknownInstanceFields returns the domain Instance fields whose planned values
+// are only known after apply, given a change's after_unknown value
+func UnknownInstanceFields(afterUnknown interface{}) []string {
+	unknown := make(map[string]bool)
+	collectUnknownPaths("", afterUnknown, unknown)
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	var fields []string
+	for field, attr := range instanceAttributePaths {
+		for path := range unknown {
+			// An unknown block makes every attribute inside it unknown
+			if attr == path || strings.HasPrefix(attr, path+".") {
+				fields = append(fields, field)
+				break
+			}
+		}
+	}
+	sort.Strings(fields)
+
+	return fields
+}
+
+// collectChangedPaths records every path where before and after differ
+func collectChangedPaths(prefix string, before, after interface{}, seen map[string]bool) {
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+	if beforeIsMap && afterIsMap {
+		for key := range beforeMap {
+			collectChangedPaths(joinAttributePath(prefix, key), beforeMap[key], afterMap[key], seen)
+		}
+		for key := range afterMap {
+			if _, ok := beforeMap[key]; !ok {
+				collectChangedPaths(joinAttributePath(prefix, key), nil, afterMap[key], seen)
+			}
+		}
+		return
+	}
+
+	beforeList, beforeIsList := before.([]interface{})
+	afterList, afterIsList := after.([]interface{})
+	if beforeIsList && afterIsList && len(beforeList) == len(afterList) {
+		for i := range beforeList {
+			collectChangedPaths(joinAttributePath(prefix, strconv.Itoa(i)), beforeList[i], afterList[i], seen)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(before, after) && prefix != "" {
+		seen[normalizeAttributePath(prefix)] = true
+	}
+}
+
+// collectUnknownPaths records every path marked as known only after apply
+func collectUnknownPaths(prefix string, unknown interface{}, seen map[string]bool) {
+	switch v := unknown.(type) {
+	case bool:
+		if v && prefix != "" {
+			seen[normalizeAttributePath(prefix)] = true
+		}
+	case map[string]interface{}:
+		for key, val := range v {
+			collectUnknownPaths(joinAttributePath(prefix, key), val, seen)
+		}
+	case []interface{}:
+		for i, val := range v {
+			collectUnknownPaths(joinAttributePath(prefix, strconv.Itoa(i)), val, seen)
+		}
+	}
+}
+
+// joinAttributePath appends a segment to a dotted attribute path
+func joinAttributePath(prefix, segment string) string {
+	if prefix == "" {
+		return segment
+	}
+	return prefix + "." + segment
+}
+
+// normalizeAttributePath removes numeric list indexes from an attribute path
+func normalizeAttributePath(path string) string {
+	segments := strings.Split(path, ".")
+	kept := segments[:0]
+	for _, s := range segments {
+		if _, err := strconv.Atoi(s); err == nil {
+			continue
+		}
+		kept = append(kept, s)
+	}
+	return strings.Join(kept, ".")
+}
+
+// pathCovered reports whether a planned change at changed reconciles the attribute at target
+func pathCovered(target, changed string) bool {
+	return target == changed ||
+		strings.HasPrefix(changed, target+".") ||
+		strings.HasPrefix(target, changed+".")
+}
+
+// findInstanceChange locates the aws_instance or aws_spot_instance_request
+// resource change for an instance ID
+func findInstanceChange(plan *tfjson.Plan, instanceID string) *tfjson.ResourceChange {
+	if plan == nil {
+		return nil
+	}
+
+	for _, rc := range plan.ResourceChanges {
+		if rc == nil || !isInstanceResource(rc.Type) || rc.Change == nil {
+			continue
+		}
+		idAttribute := "id"
+		if rc.Type == spotInstanceRequestType {
+			idAttribute = "spot_instance_id"
+		}
+		if before, ok := rc.Change.Before.(map[string]interface{}); ok {
+			if id, _ := before[idAttribute].(string); id == instanceID {
+				return rc
+			}
+		}
+	}
+
+	return nil
+}
+
+// ApplyPlanCoverage marks each drift in the report with whether the plan reconciles it
+func ApplyPlanCoverage(report *models.DriftReport, plan *tfjson.Plan) {
+	if report == nil {
+		return
+	}
+
+	rc := findInstanceChange(plan, report.InstanceID)
+
+	var replaced bool
+	var changed []string
+	if rc != nil {
+		replaced = rc.Change.Actions.Replace()
+		changed = PlanChangedPaths(rc.Change)
+	}
+
+	for i := range report.Drifts {
+		// Advisory findings, such as a stale AMI, are not for apply to fix
+		if report.Drifts[i].Type.Advisory() {
+			continue
+		}
+		status := models.PlanStatusNotAddressed
+		if replaced {
+			status = models.PlanStatusFixedByApply
+		} else if target, ok := PlanAttributePath(report.Drifts[i].Path); ok {
+			for _, c := range changed {
+				if pathCovered(target, c) {
+					status = models.PlanStatusFixedByApply
+					break
+				}
+			}
+		}
+		report.Drifts[i].PlanStatus = status
+	}
+}
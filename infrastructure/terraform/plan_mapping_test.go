@@ -0,0 +1,127 @@
+package terraform_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+	tfrepo "driftdetector/infrastructure/terraform"
+)
+
+const planFixtureDir = "../../testdata/terraform/plans"
+
+func loadPlanFixture(t *testing.T, name string) *models.DriftReport {
+	t.Helper()
+
+	plan, err := tfrepo.ParsePlanFile(filepath.Join(planFixtureDir, name))
+	require.NoError(t, err, "Failed to parse plan fixture")
+
+	report := models.NewDriftReport("i-1234567890abcdef0")
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, "Type", "t2.small", "t2.micro", "Value mismatch"))
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, ".Tags.Name", "renamed-in-console", "test-instance", "Value modified"))
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, "SecurityGroups[0].GroupID", "sg-654321", "sg-123456", "Value mismatch"))
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, "VPCID", "vpc-2", "vpc-1", "Value mismatch"))
+	report.AddDrift(models.NewDrift(models.DriftTypeModified, "RootVolumeSize", 16, 8, "Value mismatch"))
+
+	tfrepo.ApplyPlanCoverage(report, plan)
+	return report
+}
+
+func TestPlanAttributePath(t *testing.T) {
+	tests := []struct {
+		driftPath string
+		expected  string
+		ok        bool
+	}{
+		{"Type", "instance_type", true},
+		{".Tags.Name", "tags.Name", true},
+		{"Tags.team.name", "tags.team.name", true},
+		{".SecurityGroups", "vpc_security_group_ids", true},
+		{"SecurityGroups[1].GroupID", "vpc_security_group_ids", true},
+		{"SecurityGroups[sg-123456]", "vpc_security_group_ids", true},
+		{"EBSBlockDevices[/dev/sdf].VolumeSize", "ebs_block_device", true},
+		{"RootVolumeEncrypted", "root_block_device.encrypted", true},
+		{"VPCID", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driftPath, func(t *testing.T) {
+			path, ok := tfrepo.PlanAttributePath(tt.driftPath)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, path)
+		})
+	}
+}
+
+func TestPlanChangedPaths(t *testing.T) {
+	plan, err := tfrepo.ParsePlanFile(filepath.Join(planFixtureDir, "update_in_place.json"))
+	require.NoError(t, err)
+	require.Len(t, plan.ResourceChanges, 1)
+
+	paths := tfrepo.PlanChangedPaths(plan.ResourceChanges[0].Change)
+
+	assert.Equal(t, []string{"instance_type", "public_dns", "tags.Name", "vpc_security_group_ids"}, paths)
+}
+
+func TestApplyPlanCoverage(t *testing.T) {
+	t.Run("update in place", func(t *testing.T) {
+		report := loadPlanFixture(t, "update_in_place.json")
+
+		statuses := make(map[string]models.PlanStatus)
+		for _, d := range report.Drifts {
+			statuses[d.Path] = d.PlanStatus
+		}
+
+		assert.Equal(t, models.PlanStatusFixedByApply, statuses["Type"])
+		assert.Equal(t, models.PlanStatusFixedByApply, statuses[".Tags.Name"])
+		assert.Equal(t, models.PlanStatusFixedByApply, statuses["SecurityGroups[0].GroupID"])
+		assert.Equal(t, models.PlanStatusNotAddressed, statuses["VPCID"], "Unmapped fields are never covered")
+		assert.Equal(t, models.PlanStatusNotAddressed, statuses["RootVolumeSize"], "Unchanged attributes are not covered")
+		assert.Len(t, report.UnaddressedDrifts(), 2)
+	})
+
+	t.Run("replacement covers everything", func(t *testing.T) {
+		report := loadPlanFixture(t, "replace.json")
+
+		assert.Empty(t, report.UnaddressedDrifts())
+	})
+
+	t.Run("no-op plan covers nothing", func(t *testing.T) {
+		report := loadPlanFixture(t, "no_changes.json")
+
+		assert.Len(t, report.UnaddressedDrifts(), len(report.Drifts))
+	})
+
+	t.Run("advisory findings are left out", func(t *testing.T) {
+		// Given drift the plan fixes and a stale AMI noted by --ami-max-age
+		plan, err := tfrepo.ParsePlanFile(filepath.Join(planFixtureDir, "update_in_place.json"))
+		require.NoError(t, err)
+		report := models.NewDriftReport("i-1234567890abcdef0")
+		report.AddDrift(models.NewDrift(models.DriftTypeModified, "Type", "t2.small", "t2.micro", "Value mismatch"))
+		created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		services.CheckAMIAge(report, &models.Image{ID: "ami-1", CreationDate: &created}, 90*24*time.Hour, time.Now())
+		require.Len(t, report.StaleAMIs(), 1)
+
+		// When --verify-plan cross-references the findings
+		tfrepo.ApplyPlanCoverage(report, plan)
+
+		// Then the stale AMI is not marked and does not fail the check
+		assert.Empty(t, report.StaleAMIs()[0].PlanStatus)
+		assert.Empty(t, report.UnaddressedDrifts())
+	})
+
+	t.Run("nil plan", func(t *testing.T) {
+		report := models.NewDriftReport("i-1234567890abcdef0")
+		report.AddDrift(models.NewDrift(models.DriftTypeModified, "Type", "t2.small", "t2.micro", "Value mismatch"))
+
+		tfrepo.ApplyPlanCoverage(report, nil)
+
+		assert.Equal(t, models.PlanStatusNotAddressed, report.Drifts[0].PlanStatus)
+	})
+}
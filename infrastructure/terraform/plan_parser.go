@@ -0,0 +1,74 @@
+package terraform
+
+import (
+	"context"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+)
+
+// Ensure both state repositories can read planned configurations
+var (
+	_ repositories.PlanRepository = (*TerraformRepository)(nil)
+	_ repositories.PlanRepository = (*TerraformStateRepository)(nil)
+)
+
+// GetInstanceConfigsFromPlan extracts instance configurations from the planned
+// values of a terraform show -json plan file
+func (r *TerraformRepository) GetInstanceConfigsFromPlan(ctx context.Context, planPath string) ([]*models.Instance, error) {
+	return NewTerraformStateRepository().GetInstanceConfigsFromPlan(ctx, planPath)
+}
+
+// GetInstanceConfigsFromPlan extracts instance configurations from the planned
+// values of a terraform show -json plan file. Instances the plan destroys are
+// skipped, and attributes that are unknown until apply are recorded in
+// UnknownFields so they are not reported as drift.
+func (r *TerraformStateRepository) GetInstanceConfigsFromPlan(ctx context.Context, planPath string) ([]*models.Instance, error) {
+	plan, err := ParsePlanFile(planPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.extractInstancesFromPlan(plan), nil
+}
+
+// extractInstancesFromPlan reads aws_instance resources from the planned values
+// of every module in the plan
+func (r *TerraformStateRepository) extractInstancesFromPlan(plan *tfjson.Plan) []*models.Instance {
+	var instances []*models.Instance
+	if plan == nil || plan.PlannedValues == nil {
+		return instances
+	}
+
+	changes := make(map[string]*tfjson.Change, len(plan.ResourceChanges))
+	for _, rc := range plan.ResourceChanges {
+		if rc != nil && rc.Change != nil {
+			changes[rc.Address] = rc.Change
+		}
+	}
+
+	var walk func(module *tfjson.StateModule)
+	walk = func(module *tfjson.StateModule) {
+		if module == nil {
+			return
+		}
+
+		for _, instance := range r.extractInstancesFromModule(module) {
+			if change, ok := changes[instance.ResourceAddress]; ok {
+				if change.Actions.Delete() {
+					continue
+				}
+				instance.UnknownFields = UnknownInstanceFields(change.AfterUnknown)
+			}
+			instances = append(instances, instance)
+		}
+
+		for _, child := range module.ChildModules {
+			walk(child)
+		}
+	}
+	walk(plan.PlannedValues.RootModule)
+
+	return instances
+}
@@ -0,0 +1,50 @@
+package terraform_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tfrepo "driftdetector/infrastructure/terraform"
+)
+
+func TestTerraformStateRepository_GetInstanceConfigsFromPlan(t *testing.T) {
+	// Given a plan that updates one instance, destroys another and creates one in a module
+	repo := tfrepo.NewTerraformStateRepository()
+
+	// When reading its planned values
+	instances, err := repo.GetInstanceConfigsFromPlan(context.Background(), filepath.Join(planFixtureDir, "planned_values.json"))
+
+	// Then destroyed instances are skipped and unknown attributes are recorded
+	require.NoError(t, err)
+	require.Len(t, instances, 2)
+
+	web := instances[0]
+	assert.Equal(t, "aws_instance.web", web.ResourceAddress)
+	assert.Equal(t, "i-1234567890abcdef0", web.ID)
+	assert.Equal(t, "t3.small", web.Type, "planned values are used, not the prior state")
+	assert.Equal(t, []string{
+		"PublicDNSName",
+		"PublicIPAddress",
+		"RootVolumeEncrypted",
+		"RootVolumeIops",
+		"RootVolumeKMSKeyID",
+		"RootVolumeSize",
+		"RootVolumeThroughput",
+		"RootVolumeType",
+	}, web.UnknownFields)
+
+	node := instances[1]
+	assert.Equal(t, "module.workers.aws_instance.node", node.ResourceAddress)
+	assert.Equal(t, []string{"ID", "PrivateIPAddress"}, node.UnknownFields)
+}
+
+func TestUnknownInstanceFields(t *testing.T) {
+	assert.Nil(t, tfrepo.UnknownInstanceFields(map[string]interface{}{"tags": map[string]interface{}{}}))
+	assert.Equal(t, []string{"RootVolumeSize"}, tfrepo.UnknownInstanceFields(map[string]interface{}{
+		"root_block_device": []interface{}{map[string]interface{}{"volume_size": true, "volume_id": true}},
+	}), "unknown attributes without a domain field are ignored")
+}
@@ -0,0 +1,152 @@
+package terraform
+
+import (
+	"context"
+	"fmt"
+
+	tfjson "github.com/hashicorp/terraform-json"
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+)
+
+// Ensure both state repositories can extract security groups
+var (
+	_ repositories.SecurityGroupStateRepository = (*TerraformRepository)(nil)
+	_ repositories.SecurityGroupStateRepository = (*TerraformStateRepository)(nil)
+)
+
+// GetSecurityGroupConfigs extracts aws_security_group resources from a Terraform state file
+func (r *TerraformRepository) GetSecurityGroupConfigs(ctx context.Context, statePath string) ([]*models.SecurityGroupConfig, error) {
+	state, err := r.parser.ParseState(ctx, statePath)
+	if err != nil {
+		return nil, fmt.Errorf("parsing Terraform state: %w", err)
+	}
+
+	var groups []*models.SecurityGroupConfig
+	for _, resource := range state.Resources {
+		if resource.Mode != "managed" || resource.Type != "aws_security_group" {
+			continue
+		}
+		for _, instance := range resource.Instances {
+			if group := parseSecurityGroupAttributes(instance.Attributes); group != nil {
+				group.ResourceAddress = FormatResourceAddress(resource.Module, resource.Type, resource.Name, instance.IndexKey)
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	return groups, nil
+}
+
+// GetSecurityGroupConfigs extracts aws_security_group resources from a Terraform state file
+func (r *TerraformStateRepository) GetSecurityGroupConfigs(ctx context.Context, statePath string) ([]*models.SecurityGroupConfig, error) {
+	state, err := readState(ctx, r.reader, statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []*models.SecurityGroupConfig
+	if state.Values == nil || state.Values.RootModule == nil {
+		return groups, nil
+	}
+
+	modules := append([]*tfjson.StateModule{state.Values.RootModule}, state.Values.RootModule.ChildModules...)
+	for _, module := range modules {
+		for _, resource := range module.Resources {
+			if resource.Type != "aws_security_group" {
+				continue
+			}
+			if group := parseSecurityGroupAttributes(resource.AttributeValues); group != nil {
+				group.ResourceAddress = resource.Address
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	return groups, nil
+}
+
+// parseSecurityGroupAttributes converts aws_security_group attributes into a
+// SecurityGroupConfig. Rules with self = true reference the group itself,
+// which is how EC2 reports them.
+func parseSecurityGroupAttributes(attrs map[string]interface{}) *models.SecurityGroupConfig {
+	id, _ := attrs["id"].(string)
+	if id == "" {
+		return nil
+	}
+
+	group := &models.SecurityGroupConfig{
+		GroupID: id,
+		Tags:    make(map[string]string),
+	}
+	group.Name, _ = attrs["name"].(string)
+	group.Description, _ = attrs["description"].(string)
+	group.VPCID, _ = attrs["vpc_id"].(string)
+
+	if tags, ok := attrs["tags"].(map[string]interface{}); ok {
+		for k, v := range tags {
+			if s, ok := v.(string); ok {
+				group.Tags[k] = s
+			}
+		}
+	}
+
+	group.Ingress = parseSecurityGroupRules(attrs["ingress"], id)
+	group.Egress = parseSecurityGroupRules(attrs["egress"], id)
+
+	return group
+}
+
+// parseSecurityGroupRules converts a list of ingress or egress rule objects
+func parseSecurityGroupRules(value interface{}, groupID string) []models.SecurityGroupRule {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var rules []models.SecurityGroupRule
+	for _, item := range items {
+		attrs, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		rule := models.SecurityGroupRule{
+			CIDRBlocks:     stringList(attrs["cidr_blocks"]),
+			IPv6CIDRBlocks: stringList(attrs["ipv6_cidr_blocks"]),
+			PrefixListIDs:  stringList(attrs["prefix_list_ids"]),
+			SecurityGroups: stringList(attrs["security_groups"]),
+		}
+		rule.Protocol, _ = attrs["protocol"].(string)
+		rule.Description, _ = attrs["description"].(string)
+		if from, ok := attrs["from_port"].(float64); ok {
+			rule.FromPort = int(from)
+		}
+		if to, ok := attrs["to_port"].(float64); ok {
+			rule.ToPort = int(to)
+		}
+		if self, ok := attrs["self"].(bool); ok && self {
+			rule.SecurityGroups = append(rule.SecurityGroups, groupID)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// stringList converts a JSON array of strings, skipping other element types
+func stringList(value interface{}) []string {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var out []string
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package terraform_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	tfrepo "driftdetector/infrastructure/terraform"
+)
+
+func TestTerraformStateRepository_GetSecurityGroupConfigs(t *testing.T) {
+	repo := tfrepo.NewTerraformStateRepository()
+
+	groups, err := repo.GetSecurityGroupConfigs(context.Background(), filepath.Join(terraformFixtureDir, "state", "security_groups.json"))
+
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+
+	web := groups[0]
+	assert.Equal(t, "sg-0123456789abcdef0", web.GroupID)
+	assert.Equal(t, "aws_security_group.web", web.ResourceAddress)
+	assert.Equal(t, "Web servers", web.Description)
+	assert.Equal(t, map[string]string{"Name": "web"}, web.Tags)
+	assert.Equal(t, map[string][]string{
+		"tcp/443":  {"cidr:0.0.0.0/0"},
+		"tcp/8080": {"sg:sg-0123456789abcdef0"},
+	}, models.RuleSet(web.Ingress), "self rules reference the group itself")
+	assert.Equal(t, map[string][]string{"all": {"cidr:0.0.0.0/0"}}, models.RuleSet(web.Egress))
+}
+
+func TestTerraformRepository_GetSecurityGroupConfigs(t *testing.T) {
+	state := &models.TerraformState{
+		Resources: []models.TerraformResource{
+			{Mode: "managed", Type: "aws_security_group", Name: "db", Instances: []models.TerraformResourceInstance{
+				{Attributes: map[string]interface{}{
+					"id":          "sg-db",
+					"description": "Database",
+					"ingress": []interface{}{
+						map[string]interface{}{"protocol": "tcp", "from_port": float64(5432), "to_port": float64(5432), "security_groups": []interface{}{"sg-app"}},
+					},
+				}},
+			}},
+			{Mode: "data", Type: "aws_security_group", Name: "default", Instances: []models.TerraformResourceInstance{
+				{Attributes: map[string]interface{}{"id": "sg-default"}},
+			}},
+		},
+	}
+	parser := &MockStateParser{ParseStateFunc: func(ctx context.Context, path string) (*models.TerraformState, error) {
+		return state, nil
+	}}
+	repo := tfrepo.NewTerraformRepository(parser).(*tfrepo.TerraformRepository)
+
+	groups, err := repo.GetSecurityGroupConfigs(context.Background(), "terraform.tfstate")
+
+	require.NoError(t, err)
+	require.Len(t, groups, 1, "data sources are not managed by this configuration")
+	assert.Equal(t, "sg-db", groups[0].GroupID)
+	assert.Equal(t, "aws_security_group.db", groups[0].ResourceAddress)
+	assert.Equal(t, map[string][]string{"tcp/5432": {"sg:sg-app"}}, models.RuleSet(groups[0].Ingress))
+}
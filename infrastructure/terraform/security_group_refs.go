@@ -0,0 +1,69 @@
+package terraform
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"driftdetector/domain/models"
+)
+
+// parseSecurityGroupRefs reads a vpc_security_group_ids list that cannot be
+// evaluated as a whole, such as [aws_security_group.web.id, "sg-0abc"]. The
+// IDs written out are kept, and references to aws_security_group resources
+// are recorded in UnresolvedRefs so they can be resolved from state.
+func parseSecurityGroupRefs(attr *hcl.Attribute, evalCtx *hcl.EvalContext, instance *models.Instance) {
+	exprs, diags := hcl.ExprList(attr.Expr)
+	if diags.HasErrors() {
+		// A list built by a function or a splat has no elements to read
+		exprs = []hcl.Expression{attr.Expr}
+	}
+
+	var refs []string
+	for _, expr := range exprs {
+		val, diags := expr.Value(evalCtx)
+		if !diags.HasErrors() && val.IsWhollyKnown() && !val.IsNull() && val.Type() == cty.String {
+			instance.SecurityGroups = append(instance.SecurityGroups, models.SecurityGroup{GroupID: val.AsString()})
+			continue
+		}
+		for _, traversal := range expr.Variables() {
+			if traversal.RootName() == "aws_security_group" {
+				refs = append(refs, traversalString(traversal))
+			}
+		}
+	}
+
+	if len(refs) > 0 {
+		if instance.UnresolvedRefs == nil {
+			instance.UnresolvedRefs = make(map[string][]string)
+		}
+		instance.UnresolvedRefs["SecurityGroups"] = refs
+	}
+}
+
+// traversalString renders a traversal the way it is written, e.g.
+// aws_security_group.web["a"].id, quoting keys like FormatResourceAddress
+func traversalString(traversal hcl.Traversal) string {
+	var b strings.Builder
+	for _, step := range traversal {
+		switch step := step.(type) {
+		case hcl.TraverseRoot:
+			b.WriteString(step.Name)
+		case hcl.TraverseAttr:
+			b.WriteString("." + step.Name)
+		case hcl.TraverseIndex:
+			switch {
+			case step.Key.Type() == cty.String:
+				b.WriteString("[" + strconv.Quote(step.Key.AsString()) + "]")
+			case step.Key.Type() == cty.Number:
+				index, _ := step.Key.AsBigFloat().Int(new(big.Int))
+				fmt.Fprintf(&b, "[%s]", index)
+			}
+		}
+	}
+	return b.String()
+}
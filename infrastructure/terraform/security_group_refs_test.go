@@ -0,0 +1,159 @@
+package terraform_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	tfrepo "driftdetector/infrastructure/terraform"
+)
+
+func TestHCLParser_SecurityGroupRefs(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		groups   []models.SecurityGroup
+		expected map[string][]string
+	}{
+		{
+			name: "references and IDs",
+			file: "main.tf",
+			content: `resource "aws_instance" "web" {
+  vpc_security_group_ids = [aws_security_group.web.id, "sg-shared", aws_security_group.app[0].id, aws_security_group.db["a"].id]
+}`,
+			groups:   []models.SecurityGroup{{GroupID: "sg-shared"}},
+			expected: map[string][]string{"SecurityGroups": {"aws_security_group.web.id", `aws_security_group.app[0].id`, `aws_security_group.db["a"].id`}},
+		},
+		{
+			name:     "JSON syntax",
+			file:     "main.tf.json",
+			content:  `{"resource": {"aws_instance": {"web": {"vpc_security_group_ids": ["${aws_security_group.web.id}"]}}}}`,
+			expected: map[string][]string{"SecurityGroups": {"aws_security_group.web.id"}},
+		},
+		{
+			name:     "splat",
+			file:     "main.tf",
+			content:  `resource "aws_instance" "web" { vpc_security_group_ids = aws_security_group.web[*].id }`,
+			expected: map[string][]string{"SecurityGroups": {"aws_security_group.web"}},
+		},
+		{
+			name:    "IDs only",
+			file:    "main.tf",
+			content: `resource "aws_instance" "web" { vpc_security_group_ids = ["sg-1"] }`,
+			groups:  []models.SecurityGroup{{GroupID: "sg-1"}},
+		},
+		{
+			name:    "names only",
+			file:    "main.tf",
+			content: `resource "aws_instance" "web" { security_groups = ["web", "ssh"] }`,
+			groups:  []models.SecurityGroup{{GroupName: "web"}, {GroupName: "ssh"}},
+		},
+		{
+			name: "IDs and names",
+			file: "main.tf",
+			content: `resource "aws_instance" "web" {
+  vpc_security_group_ids = ["sg-1"]
+  security_groups        = ["ssh"]
+}`,
+			groups: []models.SecurityGroup{{GroupID: "sg-1"}, {GroupName: "ssh"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0644))
+
+			instances, err := tfrepo.NewHCLParser().ParseHCLAll(path)
+
+			require.NoError(t, err)
+			require.Len(t, instances, 1)
+			assert.Equal(t, tt.groups, instances[0].SecurityGroups)
+			assert.Equal(t, tt.expected, instances[0].UnresolvedRefs)
+		})
+	}
+}
+
+func TestInstance_ResolveSecurityGroupRefs(t *testing.T) {
+	groups := []*models.SecurityGroupConfig{
+		{GroupID: "sg-web", ResourceAddress: "aws_security_group.web"},
+		{GroupID: "sg-app", ResourceAddress: "aws_security_group.app[0]"},
+		{GroupID: "sg-aws"},
+	}
+
+	t.Run("all resolved", func(t *testing.T) {
+		instance := &models.Instance{
+			SecurityGroups: []models.SecurityGroup{{GroupID: "sg-shared"}},
+			UnresolvedRefs: map[string][]string{"SecurityGroups": {"aws_security_group.web.id", "aws_security_group.app[0].id"}},
+		}
+
+		instance.ResolveSecurityGroupRefs(groups)
+
+		assert.Equal(t, []models.SecurityGroup{{GroupID: "sg-shared"}, {GroupID: "sg-web"}, {GroupID: "sg-app"}}, instance.SecurityGroups)
+		assert.Nil(t, instance.UnresolvedRefs)
+	})
+
+	t.Run("some unresolved", func(t *testing.T) {
+		instance := &models.Instance{
+			UnresolvedRefs: map[string][]string{"SecurityGroups": {"aws_security_group.web.id", "aws_security_group.db.id"}},
+		}
+
+		instance.ResolveSecurityGroupRefs(groups)
+
+		assert.Equal(t, []models.SecurityGroup{{GroupID: "sg-web"}}, instance.SecurityGroups)
+		assert.Equal(t, map[string][]string{"SecurityGroups": {"aws_security_group.db.id"}}, instance.UnresolvedRefs)
+	})
+}
+
+func TestTerraformRepository_SecurityGroupNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		attrs    map[string]interface{}
+		expected []models.SecurityGroup
+	}{
+		{
+			name:     "IDs only",
+			attrs:    map[string]interface{}{"vpc_security_group_ids": []interface{}{"sg-1"}, "security_groups": []interface{}{}},
+			expected: []models.SecurityGroup{{GroupID: "sg-1"}},
+		},
+		{
+			name:     "names only",
+			attrs:    map[string]interface{}{"security_groups": []interface{}{"default", "ssh"}},
+			expected: []models.SecurityGroup{{GroupName: "default"}, {GroupName: "ssh"}},
+		},
+		{
+			name:     "IDs and names",
+			attrs:    map[string]interface{}{"vpc_security_group_ids": []interface{}{"sg-1"}, "security_groups": []interface{}{"ssh"}},
+			expected: []models.SecurityGroup{{GroupID: "sg-1"}, {GroupName: "ssh"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given an instance listing its groups by ID, by name or both
+			tt.attrs["id"] = "i-1"
+			state := &models.TerraformState{
+				Resources: []models.TerraformResource{
+					{Mode: "managed", Type: "aws_instance", Name: "web", Instances: []models.TerraformResourceInstance{{Attributes: tt.attrs}}},
+				},
+			}
+			parser := &MockStateParser{ParseStateFunc: func(ctx context.Context, path string) (*models.TerraformState, error) {
+				return state, nil
+			}}
+
+			// When
+			instances, err := tfrepo.NewTerraformRepository(parser).GetInstanceConfigs(context.Background(), "terraform.tfstate")
+
+			// Then names are kept apart from IDs
+			require.NoError(t, err)
+			require.Len(t, instances, 1)
+			assert.Equal(t, tt.expected, instances[0].SecurityGroups)
+		})
+	}
+}
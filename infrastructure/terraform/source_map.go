@@ -0,0 +1,74 @@
+package terraform
+
+import (
+	"path/filepath"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"driftdetector/domain/models"
+)
+
+// attributeSources maps the field paths an aws_instance block sets, in the
+// form lifecycle ignore_changes entries take, to the line setting them. Tags
+// written as an object map each key to its own line. Nested blocks map their
+// fields to the block, and the arguments they set to their own lines.
+func attributeSources(content *hcl.BodyContent, evalCtx *hcl.EvalContext) map[string]*models.SourceLocation {
+	sources := make(map[string]*models.SourceLocation)
+	for name, attr := range content.Attributes {
+		for _, field := range ignoreChangesFields[name] {
+			sources[field] = sourceLocation(attr.Expr.Range())
+		}
+		if name == "tags" {
+			tagSources(attr, evalCtx, sources)
+		}
+	}
+
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "lifecycle":
+			continue
+		case "ebs_block_device":
+			nestedContent, _, _ := block.Body.PartialContent(ebsBlockDeviceSchema)
+			if device := stringAttr(evalAttributes(nestedContent.Attributes, evalCtx), "device_name"); device != "" {
+				sources["EBSBlockDevices["+device+"]"] = sourceLocation(block.DefRange)
+			}
+			continue
+		}
+
+		for _, field := range ignoreChangesFields[block.Type] {
+			sources[field] = sourceLocation(block.DefRange)
+		}
+		attrs, _ := block.Body.JustAttributes()
+		for name, attr := range attrs {
+			if field, ok := ignoreChangesNestedFields[block.Type][name]; ok {
+				sources[field] = sourceLocation(attr.Expr.Range())
+			}
+		}
+	}
+
+	if len(sources) == 0 {
+		return nil
+	}
+	return sources
+}
+
+// tagSources maps each key of a tags object to the line setting it
+func tagSources(attr *hcl.Attribute, evalCtx *hcl.EvalContext, sources map[string]*models.SourceLocation) {
+	pairs, diags := hcl.ExprMap(attr.Expr)
+	if diags.HasErrors() {
+		return
+	}
+	for _, pair := range pairs {
+		key, diags := pair.Key.Value(evalCtx)
+		if diags.HasErrors() || !key.IsKnown() || key.IsNull() || key.Type() != cty.String {
+			continue
+		}
+		sources["Tags["+key.AsString()+"]"] = sourceLocation(pair.Value.Range())
+	}
+}
+
+// sourceLocation returns the file and first line of rng
+func sourceLocation(rng hcl.Range) *models.SourceLocation {
+	return &models.SourceLocation{File: filepath.ToSlash(rng.Filename), Line: rng.Start.Line}
+}
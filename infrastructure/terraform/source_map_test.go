@@ -0,0 +1,59 @@
+package terraform_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tfrepo "driftdetector/infrastructure/terraform"
+)
+
+func TestHCLParser_SourceMap(t *testing.T) {
+	content := `resource "aws_instance" "web" {
+  ami           = "ami-1"
+  instance_type = "t3.micro"
+
+  root_block_device {
+    volume_size = 20
+  }
+
+  ebs_block_device {
+    device_name = "/dev/sdf"
+  }
+
+  metadata_options {
+    http_tokens = "required"
+  }
+
+  tags = {
+    Name = "web"
+    "Team" = "platform"
+  }
+}`
+	path := filepath.Join(t.TempDir(), "main.tf")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	instances, err := tfrepo.NewHCLParser().ParseHCLAll(path)
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+
+	lines := make(map[string]int)
+	for field, location := range instances[0].SourceMap {
+		assert.Equal(t, filepath.ToSlash(path), location.File)
+		lines[field] = location.Line
+	}
+	assert.Equal(t, 2, lines["AMI"])
+	assert.Equal(t, 3, lines["Type"])
+	assert.Equal(t, 6, lines["RootVolumeSize"], "nested arguments map to their own line")
+	assert.Equal(t, 5, lines["RootVolumeType"], "fields of the block it does not set map to the block")
+	assert.Equal(t, 9, lines["EBSBlockDevices[/dev/sdf]"])
+	assert.Equal(t, 13, lines["MetadataOptions"])
+	assert.Equal(t, 14, lines["MetadataOptions.HTTPTokens"])
+	assert.Equal(t, 17, lines["Tags"])
+	assert.Equal(t, 18, lines["Tags[Name]"])
+	assert.Equal(t, 19, lines["Tags[Team]"])
+	assert.NotContains(t, lines, "KeyName", "arguments not written have no source")
+}
@@ -0,0 +1,135 @@
+package terraform
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"driftdetector/domain/models"
+)
+
+// Resource types that declare EC2 instances. An aws_spot_instance_request
+// launches its instance through a spot request and records it as
+// spot_instance_id.
+const (
+	instanceResourceType    = "aws_instance"
+	spotInstanceRequestType = "aws_spot_instance_request"
+)
+
+// isInstanceResource reports whether resources of resourceType declare an
+// EC2 instance
+func isInstanceResource(resourceType string) bool {
+	return resourceType == instanceResourceType || resourceType == spotInstanceRequestType
+}
+
+// instanceMarketOptionsSchema selects the instance_market_options arguments
+var instanceMarketOptionsSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "market_type"},
+	},
+	Blocks: []hcl.BlockHeaderSchema{
+		{Type: "spot_options"},
+	},
+}
+
+// spotOptionsSchema selects the spot_options arguments
+var spotOptionsSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{
+		{Name: "max_price"},
+		{Name: "instance_interruption_behavior"},
+	},
+}
+
+// parseResourceAttributes converts the state attributes of a resource of a
+// type isInstanceResource accepts into an Instance
+func parseResourceAttributes(resourceType string, attrs map[string]interface{}) (*models.Instance, error) {
+	if resourceType == spotInstanceRequestType {
+		return parseSpotInstanceRequestAttributes(attrs)
+	}
+	return parseInstanceAttributes(attrs), nil
+}
+
+// parseSpotInstanceRequestAttributes converts the attributes of an
+// aws_spot_instance_request into the instance it launched. A request that
+// has not been fulfilled has no instance and is an error.
+func parseSpotInstanceRequestAttributes(attrs map[string]interface{}) (*models.Instance, error) {
+	instanceID, _ := attrs["spot_instance_id"].(string)
+	if instanceID == "" {
+		return nil, fmt.Errorf("spot request has not launched an instance")
+	}
+
+	instance := parseInstanceAttributes(attrs)
+	instance.SpotInstanceRequestID = instance.ID
+	instance.ID = instanceID
+	instance.InstanceLifecycle = models.InstanceLifecycleSpot
+	instance.InstanceMarketOptions = &models.InstanceMarketOptions{MarketType: models.InstanceLifecycleSpot}
+	instance.InstanceMarketOptions.MaxPrice, _ = attrs["spot_price"].(string)
+	instance.InstanceMarketOptions.InstanceInterruptionBehavior, _ = attrs["instance_interruption_behavior"].(string)
+	return instance, nil
+}
+
+// parseStateMarketOptions copies the instance_market_options of an
+// aws_instance or aws_launch_template, and the lifecycle the provider
+// records for instances, onto the instance
+func parseStateMarketOptions(attrs map[string]interface{}, instance *models.Instance) {
+	instance.InstanceLifecycle, _ = attrs["instance_lifecycle"].(string)
+	instance.SpotInstanceRequestID, _ = attrs["spot_instance_request_id"].(string)
+
+	marketOptions, ok := attrs["instance_market_options"].([]interface{})
+	if !ok || len(marketOptions) == 0 {
+		return
+	}
+	opts, ok := marketOptions[0].(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	instance.InstanceMarketOptions = &models.InstanceMarketOptions{}
+	instance.InstanceMarketOptions.MarketType, _ = opts["market_type"].(string)
+	if spotOptions, ok := opts["spot_options"].([]interface{}); ok && len(spotOptions) > 0 {
+		if spot, ok := spotOptions[0].(map[string]interface{}); ok {
+			instance.InstanceMarketOptions.MaxPrice, _ = spot["max_price"].(string)
+			instance.InstanceMarketOptions.InstanceInterruptionBehavior, _ = spot["instance_interruption_behavior"].(string)
+		}
+		// spot_options are only accepted for spot instances
+		if instance.InstanceMarketOptions.MarketType == "" {
+			instance.InstanceMarketOptions.MarketType = models.InstanceLifecycleSpot
+		}
+	}
+	if instance.InstanceLifecycle == "" {
+		instance.InstanceLifecycle = instance.InstanceMarketOptions.MarketType
+	}
+}
+
+// parseInstanceMarketOptions copies an instance_market_options block onto
+// the instance; the market type is the lifecycle the instance runs in
+func parseInstanceMarketOptions(block *hcl.Block, evalCtx *hcl.EvalContext, instance *models.Instance) {
+	content, _, _ := block.Body.PartialContent(instanceMarketOptionsSchema)
+	attrs := evalAttributes(content.Attributes, evalCtx)
+
+	options := &models.InstanceMarketOptions{MarketType: stringAttr(attrs, "market_type")}
+	for _, nested := range content.Blocks {
+		spotContent, _, _ := nested.Body.PartialContent(spotOptionsSchema)
+		spotAttrs := evalAttributes(spotContent.Attributes, evalCtx)
+		options.MaxPrice = stringAttr(spotAttrs, "max_price")
+		options.InstanceInterruptionBehavior = stringAttr(spotAttrs, "instance_interruption_behavior")
+		// spot_options are only accepted for spot instances
+		if options.MarketType == "" {
+			options.MarketType = models.InstanceLifecycleSpot
+		}
+	}
+	instance.InstanceMarketOptions = options
+	instance.InstanceLifecycle = options.MarketType
+}
+
+// parseSpotRequestArguments sets the market options of the instance an
+// aws_spot_instance_request block launches
+func parseSpotRequestArguments(attrs map[string]cty.Value, instance *models.Instance) {
+	instance.InstanceLifecycle = models.InstanceLifecycleSpot
+	instance.InstanceMarketOptions = &models.InstanceMarketOptions{
+		MarketType:                   models.InstanceLifecycleSpot,
+		MaxPrice:                     stringAttr(attrs, "spot_price"),
+		InstanceInterruptionBehavior: stringAttr(attrs, "instance_interruption_behavior"),
+	}
+}
@@ -0,0 +1,109 @@
+package terraform_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	tfrepo "driftdetector/infrastructure/terraform"
+)
+
+func TestTerraformStateRepository_SpotInstances(t *testing.T) {
+	repo := tfrepo.NewTerraformStateRepository()
+
+	instances, err := repo.GetInstanceConfigs(context.Background(), filepath.Join(terraformFixtureDir, "state", "spot_instances.json"))
+
+	require.NoError(t, err)
+	require.Len(t, instances, 3, "unfulfilled spot requests have no instance")
+	byID := make(map[string]*models.Instance, len(instances))
+	for _, instance := range instances {
+		byID[instance.ID] = instance
+	}
+
+	web := byID["i-0web"]
+	require.NotNil(t, web)
+	assert.Equal(t, models.InstanceLifecycleOnDemand, web.Lifecycle())
+	assert.Nil(t, web.InstanceMarketOptions)
+
+	batch := byID["i-0batch"]
+	require.NotNil(t, batch)
+	assert.Equal(t, "spot", batch.InstanceLifecycle)
+	assert.Equal(t, "sir-0batch", batch.SpotInstanceRequestID)
+	assert.Equal(t, &models.InstanceMarketOptions{MarketType: "spot", MaxPrice: "0.0500", InstanceInterruptionBehavior: "stop"}, batch.InstanceMarketOptions)
+
+	worker := byID["i-0worker"]
+	require.NotNil(t, worker, "spot requests are compared as the instance they launched")
+	assert.Equal(t, "aws_spot_instance_request.worker", worker.ResourceAddress)
+	assert.Equal(t, "m5.large", worker.Type)
+	assert.Equal(t, "spot", worker.InstanceLifecycle)
+	assert.Equal(t, "sir-0worker", worker.SpotInstanceRequestID)
+	assert.Equal(t, &models.InstanceMarketOptions{MarketType: "spot", MaxPrice: "0.0300", InstanceInterruptionBehavior: "terminate"}, worker.InstanceMarketOptions)
+}
+
+func TestTerraformRepository_SpotInstanceRequests(t *testing.T) {
+	state := &models.TerraformState{
+		Resources: []models.TerraformResource{
+			{Mode: "managed", Type: "aws_spot_instance_request", Name: "worker", Instances: []models.TerraformResourceInstance{
+				{IndexKey: float64(0), Attributes: map[string]interface{}{"id": "sir-1", "spot_instance_id": "i-1", "spot_price": "0.03"}},
+				{IndexKey: float64(1), Attributes: map[string]interface{}{"id": "sir-2", "spot_instance_id": ""}},
+			}},
+		},
+	}
+	parser := &MockStateParser{ParseStateFunc: func(ctx context.Context, path string) (*models.TerraformState, error) {
+		return state, nil
+	}}
+	repo := tfrepo.NewTerraformRepository(parser)
+
+	instances, err := repo.GetInstanceConfigs(context.Background(), "terraform.tfstate")
+
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	assert.Equal(t, "i-1", instances[0].ID)
+	assert.Equal(t, "aws_spot_instance_request.worker[0]", instances[0].ResourceAddress)
+	assert.Equal(t, "sir-1", instances[0].SpotInstanceRequestID)
+	assert.Equal(t, "0.03", instances[0].InstanceMarketOptions.MaxPrice)
+}
+
+func TestHCLParser_MarketOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "main.tf")
+	require.NoError(t, os.WriteFile(path, []byte(`resource "aws_instance" "batch" {
+  ami           = "ami-1"
+  instance_type = "c5.large"
+
+  instance_market_options {
+    market_type = "spot"
+    spot_options {
+      max_price                      = "0.05"
+      instance_interruption_behavior = "stop"
+    }
+  }
+}
+
+resource "aws_spot_instance_request" "worker" {
+  ami           = "ami-1"
+  instance_type = "m5.large"
+  spot_price    = "0.03"
+}
+
+resource "aws_instance" "web" {
+  ami           = "ami-1"
+  instance_type = "t3.micro"
+}`), 0644))
+
+	instances, err := tfrepo.NewHCLParser().ParseHCLAll(path)
+
+	require.NoError(t, err)
+	require.Len(t, instances, 3)
+	assert.Equal(t, "spot", instances[0].InstanceLifecycle)
+	assert.Equal(t, &models.InstanceMarketOptions{MarketType: "spot", MaxPrice: "0.05", InstanceInterruptionBehavior: "stop"}, instances[0].InstanceMarketOptions)
+	assert.Equal(t, "aws_spot_instance_request.worker", instances[1].ResourceAddress)
+	assert.Equal(t, "spot", instances[1].InstanceLifecycle)
+	assert.Equal(t, &models.InstanceMarketOptions{MarketType: "spot", MaxPrice: "0.03"}, instances[1].InstanceMarketOptions)
+	assert.Empty(t, instances[2].InstanceLifecycle)
+	assert.Nil(t, instances[2].InstanceMarketOptions)
+}
@@ -4,13 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"os"
-	"path/filepath"
+	"io"
+	"sort"
 
 	tfjson "github.com/hashicorp/terraform-json"
 	"driftdetector/domain/models"
 	"driftdetector/domain/repositories"
+	"driftdetector/infrastructure/logger"
 )
 
 // Ensure TerraformStateRepository implements the TerraformStateRepository interface
@@ -18,76 +18,123 @@ var _ repositories.TerraformStateRepository = (*TerraformStateRepository)(nil)
 
 // TerraformStateRepository implements the TerraformStateRepository interface
 type TerraformStateRepository struct {
-	// Add any dependencies here (e.g., logger, config)
+	reader    *StateReader
+	templates LaunchTemplateResolver
+	workspace string
 }
 
 // NewTerraformStateRepository creates a new TerraformStateRepository
-func NewTerraformStateRepository() *TerraformStateRepository {
-	return &TerraformStateRepository{}
+func NewTerraformStateRepository(opts ...TerraformStateRepositoryOption) *TerraformStateRepository {
+	r := &TerraformStateRepository{}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// NewRemoteTerraformStateRepository creates a TerraformStateRepository that
+// can also read s3:// state locations through reader
+func NewRemoteTerraformStateRepository(reader *StateReader, opts ...TerraformStateRepositoryOption) *TerraformStateRepository {
+	r := NewTerraformStateRepository(opts...)
+	r.reader = reader
+	return r
 }
 
 // GetInstanceConfigs extracts instance configurations from a Terraform state file
 func (r *TerraformStateRepository) GetInstanceConfigs(ctx context.Context, statePath string) ([]*models.Instance, error) {
+	state, err := readState(ctx, r.reader, statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Extract instance configurations
+	return r.extractInstancesFromState(ctx, state)
+}
+
+// GetInstanceConfigsFromReader extracts instance configurations from a state
+// in terraform show -json format read from in; name identifies it in logs
+func (r *TerraformStateRepository) GetInstanceConfigsFromReader(ctx context.Context, in io.Reader, name string) ([]*models.Instance, error) {
+	stateData, err := io.ReadAll(in)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+	state, err := decodeState(stateData, name)
+	if err != nil {
+		return nil, err
+	}
+	return r.extractInstancesFromState(ctx, state)
+}
+
+// readState reads and parses a state file in terraform show -json format
+func readState(ctx context.Context, reader *StateReader, statePath string) (*tfjson.State, error) {
 	// Read the state file
-	stateData, err := ioutil.ReadFile(statePath)
+	stateData, err := reader.Read(ctx, statePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read state file: %w", err)
 	}
+	return decodeState(stateData, statePath)
+}
 
-	// Parse the state file
+// decodeState parses state data in terraform show -json format read from statePath
+func decodeState(stateData []byte, statePath string) (*tfjson.State, error) {
 	var state tfjson.State
 	if err := json.Unmarshal(stateData, &state); err != nil {
 		return nil, fmt.Errorf("failed to parse state file: %w", err)
 	}
 
-	// Extract instance configurations
-	return r.extractInstancesFromState(&state)
+	logger.Debug("parsed terraform state",
+		"path", statePath,
+		"bytes", len(stateData),
+		"terraform_version", state.TerraformVersion,
+		"outputs", stateOutputNames(&state))
+
+	return &state, nil
 }
 
-// GetInstanceConfigsFromDir extracts instance configurations from a Terraform directory
-func (r *TerraformStateRepository) GetInstanceConfigsFromDir(ctx context.Context, dir string) ([]*models.Instance, error) {
-	// Look for terraform.tfstate or terraform.tfstate.d directory
-	statePath := filepath.Join(dir, "terraform.tfstate")
-	if _, err := os.Stat(statePath); err == nil {
-		return r.GetInstanceConfigs(ctx, statePath)
+// stateOutputNames lists the outputs of a terraform show -json state for logging
+func stateOutputNames(state *tfjson.State) []string {
+	if state.Values == nil {
+		return nil
 	}
 
-	// Check for terraform.tfstate.d directory
-	tfStateDir := filepath.Join(dir, "terraform.tfstate.d")
-	if _, err := os.Stat(tfStateDir); err == nil {
-		// Get the most recent state file
-		files, err := ioutil.ReadDir(tfStateDir)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read terraform.tfstate.d directory: %w", err)
-		}
-
-		if len(files) == 0 {
-			return nil, fmt.Errorf("no state files found in terraform.tfstate.d")
-		}
-
-		// Sort by modification time (newest first)
-		var latestFile os.FileInfo
-		for _, file := range files {
-			if file.IsDir() {
-				continue
-			}
-			if latestFile == nil || file.ModTime().After(latestFile.ModTime()) {
-				latestFile = file
-			}
-		}
+	sensitive := make(map[string]bool, len(state.Values.Outputs))
+	for name, output := range state.Values.Outputs {
+		sensitive[name] = output != nil && output.Sensitive
+	}
+	return outputNames(sensitive)
+}
 
-		if latestFile == nil {
-			return nil, fmt.Errorf("no valid state files found in terraform.tfstate.d")
+// outputNames lists output names for logging, given whether each is
+// sensitive. Output values can hold secrets, so they are never logged and
+// sensitive outputs are marked as such.
+func outputNames(sensitive map[string]bool) []string {
+	names := make([]string, 0, len(sensitive))
+	for name, isSensitive := range sensitive {
+		if isSensitive {
+			name += " (sensitive)"
 		}
-
-		return r.GetInstanceConfigs(ctx, filepath.Join(tfStateDir, latestFile.Name()))
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
+}
 
-	return nil, fmt.Errorf("no terraform state file found in %s", dir)
+// GetInstanceConfigsFromDir extracts instance configurations from the state
+// of a Terraform directory. With several workspaces, the state of the one set
+// WithStateWorkspace, else the one selected in dir, is read.
+func (r *TerraformStateRepository) GetInstanceConfigsFromDir(ctx context.Context, dir string) ([]*models.Instance, error) {
+	statePath, err := ResolveWorkspaceState(dir, r.workspace)
+	if err != nil {
+		return nil, err
+	}
+	if statePath == "" {
+		return nil, fmt.Errorf("no terraform state file found in %s", dir)
+	}
+	return r.GetInstanceConfigs(ctx, statePath)
 }
 
 // extractInstancesFromState extracts instance configurations from a parsed Terraform state
-func (r *TerraformStateRepository) extractInstancesFromState(state *tfjson.State) ([]*models.Instance, error) {
+func (r *TerraformStateRepository) extractInstancesFromState(ctx context.Context, state *tfjson.State) ([]*models.Instance, error) {
 	var instances []*models.Instance
 
 	if state == nil || state.Values == nil || state.Values.RootModule == nil {
@@ -104,6 +151,19 @@ func (r *TerraformStateRepository) extractInstancesFromState(state *tfjson.State
 		}
 	}
 
+	// Instances may take most of their settings from a launch template
+	templates := make(launchTemplateIndex)
+	collectLaunchTemplates(state.Values.RootModule, templates)
+	applyLaunchTemplates(ctx, r.templates, instances, templates)
+
+	interfaces := make(networkInterfaceIndex)
+	collectNetworkInterfaces(state.Values.RootModule, interfaces)
+	applyNetworkInterfaces(instances, interfaces)
+
+	volumes := newVolumeIndex()
+	collectVolumeAttachments(state.Values.RootModule, volumes)
+	applyVolumeAttachments(instances, volumes)
+
 	return instances, nil
 }
 
@@ -116,7 +176,7 @@ func (r *TerraformStateRepository) extractInstancesFromModule(module *tfjson.Sta
 	}
 
 	for _, resource := range module.Resources {
-		if resource.Type != "aws_instance" {
+		if !isInstanceResource(resource.Type) {
 			continue
 		}
 
@@ -124,9 +184,18 @@ func (r *TerraformStateRepository) extractInstancesFromModule(module *tfjson.Sta
 		instance, err := r.parseInstanceResource(resource)
 		if err != nil {
 			// Log the error but continue with other instances
+			logger.Warn("skipping instance resource", "address", resource.Address, "error", err)
 			continue
 		}
 
+		// Resources created with count or for_each appear once per index,
+		// so the address is what tells web[0] and web[1] apart
+		instance.ResourceAddress = resource.Address
+		if instance.ResourceAddress == "" {
+			instance.ResourceAddress = FormatResourceAddress(module.Address, resource.Type, resource.Name, resource.Index)
+		}
+		logger.Debug("found instance resource", "address", instance.ResourceAddress, "id", instance.ID)
+
 		instances = append(instances, instance)
 	}
 
@@ -139,8 +208,13 @@ func (r *TerraformStateRepository) parseInstanceResource(resource *tfjson.StateR
 		return nil, fmt.Errorf("invalid resource")
 	}
 
+	return parseResourceAttributes(resource.Type, resource.AttributeValues)
+}
+
+// parseInstanceAttributes converts the attributes of an aws_instance, which
+// raw state and terraform show -json record alike, into an Instance
+func parseInstanceAttributes(attrs map[string]interface{}) *models.Instance {
 	// Extract basic instance information
-	attrs := resource.AttributeValues
 	instanceID, _ := attrs["id"].(string)
 	instanceType, _ := attrs["instance_type"].(string)
 	ami, _ := attrs["ami"].(string)
@@ -186,6 +260,18 @@ func (r *TerraformStateRepository) parseInstanceResource(resource *tfjson.StateR
 		}
 	}
 
+	// tags_all adds the provider's default_tags; resource tags take precedence
+	if tagsAll, ok := attrs["tags_all"].(map[string]interface{}); ok {
+		for k, v := range tagsAll {
+			if _, set := instance.Tags[k]; set {
+				continue
+			}
+			if strVal, ok := v.(string); ok {
+				instance.AddTag(k, strVal)
+			}
+		}
+	}
+
 	// Extract security groups
 	if sgs, ok := attrs["vpc_security_group_ids"].([]interface{}); ok {
 		for _, sg := range sgs {
@@ -196,6 +282,11 @@ func (r *TerraformStateRepository) parseInstanceResource(resource *tfjson.StateR
 			}
 		}
 	}
+	// security_groups lists groups by name, e.g. in a default VPC; the
+	// detector matches them to the IDs AWS reports
+	for _, name := range stringList(attrs["security_groups"]) {
+		instance.SecurityGroups = append(instance.SecurityGroups, models.SecurityGroup{GroupName: name})
+	}
 
 	// Extract root block device configuration
 	if rootBlockDevice, ok := attrs["root_block_device"].([]interface{}); ok && len(rootBlockDevice) > 0 {
@@ -216,7 +307,38 @@ func (r *TerraformStateRepository) parseInstanceResource(resource *tfjson.StateR
 				encryptedVal := encrypted
 				instance.RootVolumeEncrypted = &encryptedVal
 			}
+
+			if throughput, ok := rootDevice["throughput"].(float64); ok {
+				instance.RootVolumeThroughput = int(throughput)
+			}
+
+			if kmsKeyID, ok := rootDevice["kms_key_id"].(string); ok {
+				instance.RootVolumeKMSKeyID = kmsKeyID
+			}
+		}
+	}
+
+	// Extract secondary EBS volumes
+	if ebsBlockDevices, ok := attrs["ebs_block_device"].([]interface{}); ok {
+		for _, item := range ebsBlockDevices {
+			if device, ok := item.(map[string]interface{}); ok {
+				instance.EBSBlockDevices = append(instance.EBSBlockDevices, parseStateEBSBlockDevice(device))
+			}
 		}
+		sort.Slice(instance.EBSBlockDevices, func(i, j int) bool {
+			return instance.EBSBlockDevices[i].DeviceName < instance.EBSBlockDevices[j].DeviceName
+		})
+	}
+
+	// Network interfaces attached at launch; their addresses and security
+	// groups come from the aws_network_interface resources later
+	if interfaces, ok := attrs["network_interface"].([]interface{}); ok {
+		for _, item := range interfaces {
+			if ni, ok := item.(map[string]interface{}); ok {
+				instance.NetworkInterfaces = append(instance.NetworkInterfaces, parseStateNetworkInterface(ni))
+			}
+		}
+		sortNetworkInterfaces(instance.NetworkInterfaces)
 	}
 
 	// Extract monitoring configuration
@@ -225,10 +347,149 @@ func (r *TerraformStateRepository) parseInstanceResource(resource *tfjson.StateR
 		instance.Monitoring = &monitoringVal
 	}
 
+	if optimized, ok := attrs["ebs_optimized"].(bool); ok {
+		optimizedVal := optimized
+		instance.EBSOptimized = &optimizedVal
+	}
+
+	if disabled, ok := attrs["disable_api_termination"].(bool); ok {
+		disabledVal := disabled
+		instance.DisableAPITermination = &disabledVal
+	}
+	if v, ok := attrs["instance_initiated_shutdown_behavior"].(string); ok {
+		instance.InstanceInitiatedShutdownBehavior = v
+	}
+
+	// Extract placement
+	if v, ok := attrs["availability_zone"].(string); ok {
+		instance.AvailabilityZone = v
+	}
+
+	if v, ok := attrs["tenancy"].(string); ok {
+		instance.Tenancy = v
+	}
+
+	if v, ok := attrs["host_id"].(string); ok {
+		instance.HostID = v
+	}
+
+	if v, ok := attrs["placement_group"].(string); ok {
+		instance.PlacementGroup = v
+	}
+
+	if v, ok := attrs["placement_partition_number"].(float64); ok {
+		instance.PartitionNumber = int(v)
+	}
+
+	// Extract CPU options; older providers store them as top-level attributes
+	if v, ok := attrs["cpu_core_count"].(float64); ok {
+		instance.CPUCoreCount = int(v)
+	}
+
+	if v, ok := attrs["cpu_threads_per_core"].(float64); ok {
+		instance.CPUThreadsPerCore = int(v)
+	}
+
+	if cpuOptions, ok := attrs["cpu_options"].([]interface{}); ok && len(cpuOptions) > 0 {
+		if opts, ok := cpuOptions[0].(map[string]interface{}); ok {
+			if v, ok := opts["core_count"].(float64); ok {
+				instance.CPUCoreCount = int(v)
+			}
+			if v, ok := opts["threads_per_core"].(float64); ok {
+				instance.CPUThreadsPerCore = int(v)
+			}
+		}
+	}
+
+	// Extract hibernation and enclave configuration
+	if hibernation, ok := attrs["hibernation"].(bool); ok {
+		instance.Hibernation = &models.HibernationOptions{Configured: hibernation}
+	}
+
+	if enclaveOptions, ok := attrs["enclave_options"].([]interface{}); ok && len(enclaveOptions) > 0 {
+		if opts, ok := enclaveOptions[0].(map[string]interface{}); ok {
+			if enabled, ok := opts["enabled"].(bool); ok {
+				instance.EnclaveOptions = &models.EnclaveOptions{Enabled: enabled}
+			}
+		}
+	}
+
+	if metadataOptions, ok := attrs["metadata_options"].([]interface{}); ok && len(metadataOptions) > 0 {
+		if opts, ok := metadataOptions[0].(map[string]interface{}); ok {
+			instance.MetadataOptions = &models.MetadataOptions{}
+			if endpoint, ok := opts["http_endpoint"].(string); ok {
+				instance.MetadataOptions.HTTPEndpoint = endpoint
+			}
+			if tokens, ok := opts["http_tokens"].(string); ok {
+				instance.MetadataOptions.HTTPTokens = tokens
+			}
+			if hopLimit, ok := opts["http_put_response_hop_limit"].(float64); ok {
+				instance.MetadataOptions.HTTPPutResponseHopLimit = int(hopLimit)
+			}
+			if metadataTags, ok := opts["instance_metadata_tags"].(string); ok {
+				instance.MetadataOptions.InstanceMetadataTags = metadataTags
+			}
+		}
+	}
+
+	// Extract user data; the provider stores user_data as a SHA-1 digest
+	if v, ok := attrs["user_data"].(string); ok && v != "" {
+		instance.UserData = v
+	} else if v, ok := attrs["user_data_base64"].(string); ok {
+		instance.UserData = v
+	}
+
 	// Extract IAM instance profile
 	if iamProfile, ok := attrs["iam_instance_profile"].(string); ok {
 		instance.IAMInstanceProfile = iamProfile
 	}
 
-	return instance, nil
+	parseStateMarketOptions(attrs, instance)
+
+	// Settings left unset are merged from the launch template later
+	instance.LaunchTemplate = parseLaunchTemplateSpecification(attrs)
+
+	return instance
+}
+
+// parseStateEBSBlockDevice converts one ebs_block_device entry from state
+func parseStateEBSBlockDevice(device map[string]interface{}) models.EBSBlockDevice {
+	var result models.EBSBlockDevice
+	if v, ok := device["device_name"].(string); ok {
+		result.DeviceName = v
+	}
+	if v, ok := device["volume_size"].(float64); ok {
+		result.VolumeSize = int(v)
+	}
+	if v, ok := device["volume_type"].(string); ok {
+		result.VolumeType = v
+	}
+	if v, ok := device["iops"].(float64); ok {
+		result.Iops = int(v)
+	}
+	if v, ok := device["throughput"].(float64); ok {
+		result.Throughput = int(v)
+	}
+	if v, ok := device["encrypted"].(bool); ok {
+		encrypted := v
+		result.Encrypted = &encrypted
+	}
+	if v, ok := device["kms_key_id"].(string); ok {
+		result.KMSKeyID = v
+	}
+	if v, ok := device["delete_on_termination"].(bool); ok {
+		deleteOnTermination := v
+		result.DeleteOnTermination = &deleteOnTermination
+	}
+	if tags, ok := device["tags"].(map[string]interface{}); ok {
+		for k, v := range tags {
+			if s, ok := v.(string); ok {
+				if result.Tags == nil {
+					result.Tags = make(map[string]string, len(tags))
+				}
+				result.Tags[k] = s
+			}
+		}
+	}
+	return result
 }
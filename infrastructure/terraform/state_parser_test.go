@@ -0,0 +1,93 @@
+package terraform_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/infrastructure/logger"
+	tfrepo "driftdetector/infrastructure/terraform"
+)
+
+const sensitiveOutputState = `{
+  "format_version": "1.0",
+  "terraform_version": "1.5.7",
+  "values": {
+    "outputs": {
+      "db_password": {"sensitive": true, "value": "hunter2-secret"},
+      "web_ip": {"sensitive": false, "value": "10.0.0.5"}
+    },
+    "root_module": {
+      "resources": [
+        {
+          "address": "aws_instance.web",
+          "mode": "managed",
+          "type": "aws_instance",
+          "name": "web",
+          "values": {"id": "i-0a0a0a0a0a0a0a0a0", "instance_type": "t3.small"}
+        }
+      ]
+    }
+  }
+}`
+
+func TestTerraformStateRepository_DebugLogging(t *testing.T) {
+	// Given debug logging and a state whose outputs include a secret
+	var logs bytes.Buffer
+	logger.SetOutput(&logs)
+	logger.SetLevel(slog.LevelDebug)
+	t.Cleanup(func() {
+		logger.SetOutput(os.Stderr)
+		logger.SetLevel(slog.LevelWarn)
+	})
+
+	statePath := filepath.Join(t.TempDir(), "terraform.tfstate")
+	require.NoError(t, os.WriteFile(statePath, []byte(sensitiveOutputState), 0o644))
+
+	// When reading its instances
+	instances, err := tfrepo.NewTerraformStateRepository().GetInstanceConfigs(context.Background(), statePath)
+
+	// Then the parse is logged without any output values
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+
+	out := logs.String()
+	assert.Contains(t, out, "parsed terraform state")
+	assert.Contains(t, out, "db_password (sensitive)")
+	assert.Contains(t, out, "address=aws_instance.web")
+	assert.NotContains(t, out, "hunter2-secret")
+	assert.NotContains(t, out, "10.0.0.5")
+}
+
+func TestStateFileParser_DebugLogging(t *testing.T) {
+	// Given debug logging and a raw state file whose outputs include a secret
+	var logs bytes.Buffer
+	logger.SetOutput(&logs)
+	logger.SetLevel(slog.LevelDebug)
+	t.Cleanup(func() {
+		logger.SetOutput(os.Stderr)
+		logger.SetLevel(slog.LevelWarn)
+	})
+
+	statePath := filepath.Join(t.TempDir(), "terraform.tfstate")
+	require.NoError(t, os.WriteFile(statePath, []byte(`{
+  "version": 4,
+  "terraform_version": "1.5.7",
+  "outputs": {"db_password": {"sensitive": true, "type": "string", "value": "hunter2-secret"}},
+  "resources": []
+}`), 0o644))
+
+	// When parsing it
+	_, err := (&tfrepo.StateFileParser{}).ParseState(context.Background(), statePath)
+
+	// Then the output is named but its value is not logged
+	require.NoError(t, err)
+	assert.Contains(t, logs.String(), "db_password (sensitive)")
+	assert.NotContains(t, logs.String(), "hunter2-secret")
+}
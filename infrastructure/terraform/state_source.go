@@ -0,0 +1,158 @@
+package terraform
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"driftdetector/infrastructure/awsutil"
+)
+
+// s3Scheme prefixes state locations stored in an S3 backend
+const s3Scheme = "s3://"
+
+// StdinState is the state location that reads the state from stdin, e.g.
+// piped from terraform state pull
+const StdinState = "-"
+
+var (
+	// ErrStateNotFound is returned when a remote state object or bucket does not exist
+	ErrStateNotFound = errors.New("terraform state not found")
+	// ErrStateAccessDenied is returned when the credentials may not read a remote state
+	ErrStateAccessDenied = errors.New("access to terraform state denied")
+)
+
+// StateReader reads raw state data from a local path, an s3://bucket/key URL
+// or stdin
+type StateReader struct {
+	s3Client awsutil.S3GetObjectAPI
+	stdin    io.Reader
+
+	// Stdin can only be read once, but the state is parsed for instances
+	// and again for security groups
+	stdinOnce sync.Once
+	stdinData []byte
+	stdinErr  error
+}
+
+// StateReaderOption configures a StateReader
+type StateReaderOption func(*StateReader)
+
+// WithStdin reads the StdinState location from in instead of os.Stdin
+func WithStdin(in io.Reader) StateReaderOption {
+	return func(r *StateReader) {
+		r.stdin = in
+	}
+}
+
+// NewStateReader creates a StateReader that fetches s3:// locations with the given client
+func NewStateReader(s3Client awsutil.S3GetObjectAPI, opts ...StateReaderOption) *StateReader {
+	r := &StateReader{s3Client: s3Client}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// IsRemoteState reports whether location refers to a remote state rather than a local file
+func IsRemoteState(location string) bool {
+	return strings.HasPrefix(location, s3Scheme)
+}
+
+// IsStateDocument reports whether data looks like a Terraform state rather
+// than another JSON document, such as an instance snapshot
+func IsStateDocument(data []byte) bool {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return false
+	}
+	_, version := keys["terraform_version"]
+	_, resources := keys["resources"]
+	return version || resources
+}
+
+// Read returns the contents of the state at location. Local paths are read
+// from disk and StdinState from stdin; a nil reader can only read local
+// paths and os.Stdin.
+func (r *StateReader) Read(ctx context.Context, location string) ([]byte, error) {
+	if location == StdinState {
+		return r.readStdin()
+	}
+	if !IsRemoteState(location) {
+		return os.ReadFile(location)
+	}
+
+	bucket, key, err := parseS3Location(location)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil || r.s3Client == nil {
+		return nil, fmt.Errorf("no S3 client configured to read %s", location)
+	}
+
+	out, err := r.s3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		switch awsutil.ClassifyError(err) {
+		case awsutil.ErrorClassNotFound:
+			return nil, fmt.Errorf("%w: %s: %v", ErrStateNotFound, location, err)
+		case awsutil.ErrorClassAccessDenied:
+			return nil, fmt.Errorf("%w: %s: %v", ErrStateAccessDenied, location, err)
+		}
+		return nil, fmt.Errorf("fetching %s: %w", location, err)
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", location, err)
+	}
+	return data, nil
+}
+
+// readStdin returns the state read from stdin, reading it on first use
+func (r *StateReader) readStdin() ([]byte, error) {
+	if r == nil {
+		return readStdinState(os.Stdin)
+	}
+	r.stdinOnce.Do(func() {
+		in := r.stdin
+		if in == nil {
+			in = os.Stdin
+		}
+		r.stdinData, r.stdinErr = readStdinState(in)
+	})
+	return r.stdinData, r.stdinErr
+}
+
+// readStdinState reads a state piped to stdin
+func readStdinState(in io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, fmt.Errorf("reading state from stdin: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, errors.New("no state on stdin; pipe one in, e.g. terraform state pull | driftdetector ...")
+	}
+	return data, nil
+}
+
+// parseS3Location splits an s3://bucket/key URL into its bucket and key
+func parseS3Location(location string) (string, string, error) {
+	bucket, key, ok := strings.Cut(strings.TrimPrefix(location, s3Scheme), "/")
+	if !ok || bucket == "" || key == "" {
+		return "", "", fmt.Errorf("invalid S3 state location %q: expected s3://bucket/key", location)
+	}
+	return bucket, key, nil
+}
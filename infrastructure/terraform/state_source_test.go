@@ -0,0 +1,120 @@
+package terraform_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tfrepo "driftdetector/infrastructure/terraform"
+)
+
+// fakeS3 serves objects from memory, keyed by bucket/key
+type fakeS3 struct {
+	objects map[string][]byte
+	err     error
+}
+
+func (f *fakeS3) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	data, ok := f.objects[aws.ToString(params.Bucket)+"/"+aws.ToString(params.Key)]
+	if !ok {
+		return nil, &smithy.GenericAPIError{Code: "NoSuchKey"}
+	}
+	return &s3.GetObjectOutput{Body: io.NopCloser(bytes.NewReader(data))}, nil
+}
+
+func TestTerraformStateRepository_RemoteState(t *testing.T) {
+	// Given a state object stored in S3
+	data, err := os.ReadFile(filepath.Join(terraformFixtureDir, "state", "indexed_instances.json"))
+	require.NoError(t, err)
+
+	client := &fakeS3{objects: map[string][]byte{"tf-state/prod/terraform.tfstate": data}}
+	repo := tfrepo.NewRemoteTerraformStateRepository(tfrepo.NewStateReader(client))
+
+	// When reading it by URL
+	instances, err := repo.GetInstanceConfigs(context.Background(), "s3://tf-state/prod/terraform.tfstate")
+
+	// Then it is parsed like a local state file
+	require.NoError(t, err)
+	assert.Len(t, instances, 5)
+}
+
+func TestStateReader_Read(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("local paths are read from disk", func(t *testing.T) {
+		reader := tfrepo.NewStateReader(nil)
+
+		data, err := reader.Read(ctx, filepath.Join(terraformFixtureDir, "state", "indexed_instances.json"))
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, data)
+	})
+
+	t.Run("missing object", func(t *testing.T) {
+		reader := tfrepo.NewStateReader(&fakeS3{})
+
+		_, err := reader.Read(ctx, "s3://tf-state/missing.tfstate")
+
+		assert.ErrorIs(t, err, tfrepo.ErrStateNotFound)
+	})
+
+	t.Run("access denied", func(t *testing.T) {
+		reader := tfrepo.NewStateReader(&fakeS3{err: &smithy.GenericAPIError{Code: "AccessDenied"}})
+
+		_, err := reader.Read(ctx, "s3://tf-state/prod.tfstate")
+
+		assert.ErrorIs(t, err, tfrepo.ErrStateAccessDenied)
+	})
+
+	t.Run("stdin is read once", func(t *testing.T) {
+		// Given a state piped to stdin
+		state := []byte(`{"version": 4, "resources": []}`)
+		reader := tfrepo.NewStateReader(nil, tfrepo.WithStdin(bytes.NewReader(state)))
+
+		// When it is read for instances and again for security groups
+		first, err := reader.Read(ctx, tfrepo.StdinState)
+		require.NoError(t, err)
+		second, err := reader.Read(ctx, tfrepo.StdinState)
+		require.NoError(t, err)
+
+		// Then both reads see the whole state
+		assert.Equal(t, state, first)
+		assert.Equal(t, state, second)
+	})
+
+	t.Run("nothing on stdin", func(t *testing.T) {
+		reader := tfrepo.NewStateReader(nil, tfrepo.WithStdin(bytes.NewReader(nil)))
+
+		_, err := reader.Read(ctx, tfrepo.StdinState)
+
+		assert.ErrorContains(t, err, "no state on stdin")
+	})
+
+	t.Run("URL without a key", func(t *testing.T) {
+		reader := tfrepo.NewStateReader(&fakeS3{})
+
+		_, err := reader.Read(ctx, "s3://tf-state")
+
+		assert.ErrorContains(t, err, "expected s3://bucket/key")
+	})
+}
+
+func TestIsStateDocument(t *testing.T) {
+	assert.True(t, tfrepo.IsStateDocument([]byte(`{"version": 4, "terraform_version": "1.7.5", "resources": []}`)))
+	assert.True(t, tfrepo.IsStateDocument([]byte(`{"format_version": "1.0", "terraform_version": "1.3.0", "values": {}}`)))
+	assert.False(t, tfrepo.IsStateDocument([]byte(`{"instance_id": "i-1", "instance_type": "t3.micro"}`)))
+	assert.False(t, tfrepo.IsStateDocument([]byte(`{"Reservations": []}`)))
+	assert.False(t, tfrepo.IsStateDocument([]byte(`not json`)))
+}
@@ -4,12 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"driftdetector/domain/models"
 	repositories "driftdetector/domain/repositories"
+	"driftdetector/infrastructure/logger"
 )
 
 // StateParser defines the interface for parsing Terraform state files
@@ -17,21 +19,51 @@ type StateParser interface {
 	ParseState(ctx context.Context, path string) (*models.TerraformState, error)
 }
 
-// StateFileParser implements StateParser for actual file system operations
-type StateFileParser struct{}
+// StateFileParser implements StateParser for local state files and, when
+// created with a StateReader, remote state in S3
+type StateFileParser struct {
+	reader *StateReader
+
+	// strict fails on any resource that cannot be decoded instead of
+	// leaving it out
+	strict bool
+}
+
+// StateFileParserOption configures a StateFileParser
+type StateFileParserOption func(*StateFileParser)
+
+// WithStrictState makes a state with any resource that cannot be decoded an
+// error, instead of leaving the resource out with a warning
+func WithStrictState() StateFileParserOption {
+	return func(p *StateFileParser) {
+		p.strict = true
+	}
+}
+
+// NewStateFileParser creates a StateFileParser that reads state through reader
+func NewStateFileParser(reader *StateReader, opts ...StateFileParserOption) *StateFileParser {
+	p := &StateFileParser{reader: reader}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
 
 // TerraformRepository implements the TerraformStateRepository interface
 type TerraformRepository struct {
-	parser StateParser
+	parser    StateParser
+	hclParser *HCLParser
 }
 
-// NewTerraformRepository creates a new TerraformRepository with the given parser
-func NewTerraformRepository(parser StateParser) repositories.TerraformStateRepository {
+// NewTerraformRepository creates a new TerraformRepository with the given parser.
+// hclOpts configure how Terraform configuration files are evaluated.
+func NewTerraformRepository(parser StateParser, hclOpts ...HCLParserOption) repositories.TerraformStateRepository {
 	if parser == nil {
 		parser = &StateFileParser{}
 	}
 	return &TerraformRepository{
-		parser: parser,
+		parser:    parser,
+		hclParser: NewHCLParser(hclOpts...),
 	}
 }
 
@@ -42,20 +74,62 @@ func (r *TerraformRepository) GetInstanceConfigs(ctx context.Context, statePath
 		return nil, fmt.Errorf("parsing Terraform state: %w", err)
 	}
 
-	return r.extractInstances(state), nil
+	return r.extractInstances(ctx, state), nil
 }
 
-// GetInstanceConfigsFromDir extracts instance configurations from all Terraform state files in a directory
+// GetInstanceConfigsFromDir extracts instance configurations from all Terraform state
+// and configuration (.tf and .tf.json) files in a directory and its subdirectories.
+// Directories with state for several workspaces contribute the state of one
+// workspace only (see WithWorkspace and ResolveWorkspaceState).
 func (r *TerraformRepository) GetInstanceConfigsFromDir(ctx context.Context, dir string) ([]*models.Instance, error) {
-	var instances []*models.Instance
+	instances := []*models.Instance{}
+	// workspaceRoots are the directories whose state was chosen by workspace
+	workspaceRoots := make(map[string]bool)
 
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// The configuration files of each directory are read together, so
+		// resources may use variables and locals declared in sibling files
+		if info.IsDir() {
+			if info.Name() == workspaceStateDir {
+				return filepath.SkipDir
+			}
 
-		// Skip directories and non-json files
-		if info.IsDir() || filepath.Ext(path) != ".json" {
+			configInstances, err := r.hclParser.parseDirectory(ctx, path)
+			if err != nil {
+				return fmt.Errorf("parsing Terraform configuration: %w", err)
+			}
+			instances = append(instances, configInstances...)
+
+			// A workspace given for a top directory with state must have state there
+			if HasWorkspaces(path) || (path == dir && r.hclParser.workspace != "" && fileExists(filepath.Join(path, stateFileName))) {
+				workspaceRoots[path] = true
+				stateInstances, err := r.workspaceInstances(ctx, path)
+				if err != nil {
+					return err
+				}
+				instances = append(instances, stateInstances...)
+			}
+			return nil
+		}
+
+		// The default workspace's state was read with the directory, if chosen
+		if info.Name() == stateFileName && workspaceRoots[filepath.Dir(path)] {
+			return nil
+		}
+
+		if IsConfigFile(path) {
+			return nil
+		}
+
+		// Skip files that are neither state files nor JSON
+		if ext := strings.ToLower(filepath.Ext(path)); ext != ".json" && ext != ".tfstate" {
 			return nil
 		}
 
@@ -77,28 +151,116 @@ func (r *TerraformRepository) GetInstanceConfigsFromDir(ctx context.Context, dir
 	return instances, nil
 }
 
-// extractInstances converts Terraform state resources to domain models
-func (r *TerraformRepository) extractInstances(state *models.TerraformState) []*models.Instance {
-	var instances []*models.Instance
+// workspaceInstances reads the state of the configured workspace in dir
+func (r *TerraformRepository) workspaceInstances(ctx context.Context, dir string) ([]*models.Instance, error) {
+	statePath, err := ResolveWorkspaceState(dir, r.hclParser.workspace)
+	if err != nil || statePath == "" {
+		return nil, err
+	}
+	logger.Debug("reading workspace state", "path", statePath)
+	return r.GetInstanceConfigs(ctx, statePath)
+}
+
+// extractInstances converts the managed aws_instance and
+// aws_spot_instance_request resources of a state, in the root module and
+// child modules alike, to domain models. Launch templates and network
+// interfaces the instances reference are merged from the same state.
+func (r *TerraformRepository) extractInstances(ctx context.Context, state *models.TerraformState) []*models.Instance {
+	instances := []*models.Instance{}
+	if state == nil {
+		return instances
+	}
+
+	for _, resource := range state.Resources {
+		if resource.Mode != "managed" || !isInstanceResource(resource.Type) {
+			continue
+		}
+
+		for _, inst := range resource.Instances {
+			// Resources created with count or for_each appear once per index,
+			// so the address is what tells web[0] and web[1] apart
+			address := FormatResourceAddress(resource.Module, resource.Type, resource.Name, inst.IndexKey)
+			if inst.Attributes == nil {
+				logger.Warn("skipping instance resource", "address", address, "error", "no attributes")
+				continue
+			}
+
+			instance, err := parseResourceAttributes(resource.Type, inst.Attributes)
+			if err != nil {
+				logger.Warn("skipping instance resource", "address", address, "error", err)
+				continue
+			}
+			instance.ResourceAddress = address
+			logger.Debug("found instance resource", "address", address, "id", instance.ID)
+
+			instances = append(instances, instance)
+		}
+	}
+
+	templates := make(launchTemplateIndex)
+	collectStateLaunchTemplates(state, templates)
+	applyLaunchTemplates(ctx, nil, instances, templates)
 
-	// TODO: Implement actual extraction of instances from Terraform state
-	// This is a placeholder implementation
-	// You'll need to parse the state resources and convert them to your domain model
+	interfaces := make(networkInterfaceIndex)
+	collectStateNetworkInterfaces(state, interfaces)
+	applyNetworkInterfaces(instances, interfaces)
+
+	volumes := newVolumeIndex()
+	collectStateVolumeAttachments(state, volumes)
+	applyVolumeAttachments(instances, volumes)
 
 	return instances
 }
 
-// ParseState reads and parses a Terraform state file
+// ParseState reads and parses a Terraform state file, s3:// state object or,
+// for StdinState, the state piped to stdin
 func (p *StateFileParser) ParseState(ctx context.Context, path string) (*models.TerraformState, error) {
-	data, err := ioutil.ReadFile(path)
+	data, err := p.reader.Read(ctx, path)
 	if err != nil {
 		return nil, fmt.Errorf("reading state file: %w", err)
 	}
+	return decodeFileState(data, path, p.strict)
+}
+
+// ParseStateReader parses the Terraform state read from in; name identifies
+// it in logs
+func (p *StateFileParser) ParseStateReader(in io.Reader, name string) (*models.TerraformState, error) {
+	data, err := io.ReadAll(in)
+	if err != nil {
+		return nil, fmt.Errorf("reading state: %w", err)
+	}
+	return decodeFileState(data, name, p.strict)
+}
 
+// decodeFileState parses state data read from path. Unless strict is set, a
+// resource that cannot be decoded is left out with a warning rather than
+// failing the whole state.
+func decodeFileState(data []byte, path string, strict bool) (*models.TerraformState, error) {
 	var state models.TerraformState
 	if err := json.Unmarshal(data, &state); err != nil {
-		return nil, fmt.Errorf("unmarshaling Terraform state: %w", err)
+		if strict {
+			return nil, fmt.Errorf("unmarshaling Terraform state: %w", err)
+		}
+		partial, partialErr := decodePartialState(data)
+		if partialErr != nil {
+			return nil, fmt.Errorf("unmarshaling Terraform state: %w", err)
+		}
+		state = *partial
+		for _, warning := range state.Warnings {
+			logger.Warn("skipping state resource", "path", path, "error", warning)
+		}
+	}
+
+	sensitive := make(map[string]bool, len(state.Outputs))
+	for name, output := range state.Outputs {
+		sensitive[name] = output.Sensitive
 	}
+	logger.Debug("parsed terraform state",
+		"path", path,
+		"bytes", len(data),
+		"terraform_version", state.TerraformVersion,
+		"resources", len(state.Resources),
+		"outputs", outputNames(sensitive))
 
 	return &state, nil
 }
@@ -1,9 +1,11 @@
 package terraform_test
 
 import (
+	"bytes"
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -129,7 +131,7 @@ func TestTerraformRepository_GetInstanceConfigsFromDir(t *testing.T) {
 		// Then
 		assert.NoError(t, err, "Should not return an error")
 		assert.NotNil(t, instances, "Should return instances slice")
-		// Note: The actual extraction of instances is not implemented yet, so we can't test the count
+		assert.Empty(t, instances, "The state holds no resources")
 	})
 
 	t.Run("non-existent directory", func(t *testing.T) {
@@ -167,3 +169,162 @@ func TestTerraformRepository_GetInstanceConfigsFromDir(t *testing.T) {
 		assert.Empty(t, instances, "Should return empty slice for invalid files")
 	})
 }
+
+func TestTerraformRepository_GetInstanceConfigs_RawState(t *testing.T) {
+	repo := tfrepo.NewTerraformRepository(tfrepo.NewStateFileParser(tfrepo.NewStateReader(nil)))
+
+	instances, err := repo.GetInstanceConfigs(context.Background(), "../../testdata/terraform/state/raw_state.tfstate")
+	require.NoError(t, err)
+
+	byAddress := make(map[string]*models.Instance, len(instances))
+	for _, instance := range instances {
+		byAddress[instance.ResourceAddress] = instance
+	}
+	require.Len(t, byAddress, 4, "data sources and other resource types are skipped")
+
+	tests := []struct {
+		address        string
+		id             string
+		instanceType   string
+		tags           map[string]string
+		securityGroups []string
+		rootVolumeSize int
+		monitoring     bool
+		iamProfile     string
+	}{
+		{
+			address:        "aws_instance.bastion",
+			id:             "i-0bastion000000001",
+			instanceType:   "t3.micro",
+			tags:           map[string]string{"Name": "bastion", "Environment": "prod", "Team": "platform"},
+			securityGroups: []string{"sg-0bastion"},
+			rootVolumeSize: 8,
+			monitoring:     true,
+			iamProfile:     "bastion-profile",
+		},
+		{
+			address:        "module.app.aws_instance.web[0]",
+			id:             "i-0web0000000000000",
+			instanceType:   "m5.large",
+			tags:           map[string]string{"Name": "web-0"},
+			securityGroups: []string{"sg-0web", "sg-0common"},
+			rootVolumeSize: 20,
+			iamProfile:     "web-profile",
+		},
+		{
+			address:        "module.app.aws_instance.web[1]",
+			id:             "i-0web0000000000001",
+			instanceType:   "m5.large",
+			tags:           map[string]string{"Name": "web-1"},
+			securityGroups: []string{"sg-0web", "sg-0common"},
+			rootVolumeSize: 20,
+			iamProfile:     "web-profile",
+		},
+		{
+			address:      `module.app.module.workers.aws_instance.worker["blue"]`,
+			id:           "i-0worker00000blue0",
+			instanceType: "c5.xlarge",
+			tags:         map[string]string{"Name": "worker-blue"},
+			monitoring:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			instance := byAddress[tt.address]
+			require.NotNil(t, instance, "instance not extracted")
+
+			assert.Equal(t, tt.id, instance.ID)
+			assert.Equal(t, tt.instanceType, instance.Type)
+			assert.Equal(t, tt.tags, instance.Tags)
+			var groups []string
+			for _, sg := range instance.SecurityGroups {
+				groups = append(groups, sg.GroupID)
+			}
+			assert.Equal(t, tt.securityGroups, groups)
+			assert.Equal(t, tt.rootVolumeSize, instance.RootVolumeSize)
+			require.NotNil(t, instance.Monitoring)
+			assert.Equal(t, tt.monitoring, *instance.Monitoring)
+			assert.Equal(t, tt.iamProfile, instance.IAMInstanceProfile)
+		})
+	}
+}
+
+func TestStateFileParser_ParseStateReader(t *testing.T) {
+	data, err := os.ReadFile("../../testdata/terraform/state/raw_state.tfstate")
+	require.NoError(t, err)
+
+	t.Run("from a reader", func(t *testing.T) {
+		parser := tfrepo.NewStateFileParser(tfrepo.NewStateReader(nil))
+
+		state, err := parser.ParseStateReader(bytes.NewReader(data), "stdin")
+
+		require.NoError(t, err)
+		assert.NotEmpty(t, state.Resources)
+	})
+
+	t.Run("from stdin", func(t *testing.T) {
+		// Given the state piped to stdin, as from terraform state pull
+		parser := tfrepo.NewStateFileParser(tfrepo.NewStateReader(nil, tfrepo.WithStdin(bytes.NewReader(data))))
+		repo := tfrepo.NewTerraformRepository(parser).(*tfrepo.TerraformRepository)
+
+		// When reading instances and security groups from -
+		instances, err := repo.GetInstanceConfigs(context.Background(), tfrepo.StdinState)
+		require.NoError(t, err)
+		_, err = repo.GetSecurityGroupConfigs(context.Background(), tfrepo.StdinState)
+
+		// Then both come from the piped state
+		require.NoError(t, err)
+		assert.Len(t, instances, 4)
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		parser := tfrepo.NewStateFileParser(tfrepo.NewStateReader(nil))
+
+		_, err := parser.ParseStateReader(strings.NewReader("not json"), "stdin")
+
+		assert.ErrorContains(t, err, "unmarshaling Terraform state")
+	})
+}
+
+func TestTerraformRepository_GetInstanceConfigsFromDir_UppercaseExtension(t *testing.T) {
+	// Given a state file with an uppercase extension
+	data, err := os.ReadFile("../../testdata/terraform/state/raw_state.tfstate")
+	require.NoError(t, err)
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "PROD.TFSTATE"), data, 0644))
+	repo := tfrepo.NewTerraformRepository(tfrepo.NewStateFileParser(tfrepo.NewStateReader(nil)))
+
+	// When reading the directory
+	instances, err := repo.GetInstanceConfigsFromDir(context.Background(), dir)
+
+	// Then the state is found
+	require.NoError(t, err)
+	assert.Len(t, instances, 4)
+}
+
+func TestTerraformRepository_GetInstanceConfigs_SkipsInstancesWithoutAttributes(t *testing.T) {
+	parser := &MockStateParser{
+		ParseStateFunc: func(_ context.Context, _ string) (*models.TerraformState, error) {
+			return &models.TerraformState{
+				Version: 4,
+				Resources: []models.TerraformResource{{
+					Mode: "managed",
+					Type: "aws_instance",
+					Name: "web",
+					Instances: []models.TerraformResourceInstance{
+						{IndexKey: float64(0)},
+						{IndexKey: float64(1), Attributes: map[string]interface{}{"id": "i-1", "instance_type": "t3.micro"}},
+					},
+				}},
+			}, nil
+		},
+	}
+
+	instances, err := tfrepo.NewTerraformRepository(parser).GetInstanceConfigs(context.Background(), "test.tfstate")
+
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	assert.Equal(t, "aws_instance.web[1]", instances[0].ResourceAddress)
+	assert.Equal(t, "i-1", instances[0].ID)
+}
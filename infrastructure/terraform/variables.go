@@ -0,0 +1,230 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// HCLParserOption configures an HCLParser
+type HCLParserOption func(*HCLParser)
+
+// WithVarFiles assigns variables from .tfvars or .tfvars.json files, like
+// terraform -var-file. Later files take precedence over earlier ones.
+func WithVarFiles(paths ...string) HCLParserOption {
+	return func(p *HCLParser) {
+		p.varFiles = append(p.varFiles, paths...)
+	}
+}
+
+// WithVars assigns variables from name=value pairs, like terraform -var.
+// They take precedence over every variable file.
+func WithVars(vars map[string]string) HCLParserOption {
+	return func(p *HCLParser) {
+		if p.vars == nil {
+			p.vars = make(map[string]string, len(vars))
+		}
+		for name, value := range vars {
+			p.vars[name] = value
+		}
+	}
+}
+
+// WithParseConcurrency sets the maximum number of configuration files parsed
+// at once; by default it is the number of CPUs
+func WithParseConcurrency(n int) HCLParserOption {
+	return func(p *HCLParser) {
+		if n > 0 {
+			p.concurrency = n
+		}
+	}
+}
+
+// ParseVarFlags splits name=value pairs given with --var. A name given more
+// than once keeps its last value, as in Terraform.
+func ParseVarFlags(values []string) (map[string]string, error) {
+	vars := make(map[string]string, len(values))
+	for _, v := range values {
+		name, value, ok := strings.Cut(v, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid variable %q: expected name=value", v)
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}
+
+// variableValues resolves input variables for the configuration in dir using
+// Terraform's precedence: declared defaults, then terraform.tfvars,
+// terraform.tfvars.json, *.auto.tfvars and *.auto.tfvars.json in lexical
+// order, then the parser's var files, then its vars. Values are converted to
+// the declared type of their variable where one is given.
+func (p *HCLParser) variableValues(dir string, blocks hcl.Blocks) (map[string]cty.Value, error) {
+	vars := variableDefaults(blocks)
+	types := variableTypes(blocks)
+
+	files := autoVarFiles(dir)
+	files = append(files, p.varFiles...)
+	for _, path := range files {
+		values, err := p.cachedVarFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for name, val := range values {
+			vars[name] = val
+		}
+	}
+
+	for name, raw := range p.vars {
+		vars[name] = parseVarValue(raw, types[name])
+	}
+
+	for name, val := range vars {
+		ty, ok := types[name]
+		if !ok {
+			continue
+		}
+		if converted, err := convert.Convert(val, ty); err == nil {
+			vars[name] = converted
+		}
+	}
+
+	return vars, nil
+}
+
+// varFileEntry is a variable file read earlier, with the modification time
+// and size it had then
+type varFileEntry struct {
+	modTime time.Time
+	size    int64
+	values  map[string]cty.Value
+}
+
+// cachedVarFile returns the values of the variable file at path, reading it
+// again only when it has changed since it was last read. Callers must not
+// modify the map returned.
+func (p *HCLParser) cachedVarFile(path string) (map[string]cty.Value, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading variable file %s: %w", path, err)
+	}
+
+	p.varFileMu.Lock()
+	defer p.varFileMu.Unlock()
+
+	if entry, ok := p.varFileCache[path]; ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+		return entry.values, nil
+	}
+	values, err := readVarFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if p.varFileCache == nil {
+		p.varFileCache = make(map[string]varFileEntry)
+	}
+	p.varFileCache[path] = varFileEntry{modTime: info.ModTime(), size: info.Size(), values: values}
+	return values, nil
+}
+
+// autoVarFiles lists the variable files Terraform loads automatically from dir
+func autoVarFiles(dir string) []string {
+	var files []string
+	for _, name := range []string{"terraform.tfvars", "terraform.tfvars.json"} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+
+	var auto []string
+	for _, pattern := range []string{"*.auto.tfvars", "*.auto.tfvars.json"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		auto = append(auto, matches...)
+	}
+	sort.Strings(auto)
+
+	return append(files, auto...)
+}
+
+// readVarFile evaluates every assignment in a .tfvars or .tfvars.json file
+func readVarFile(path string) (map[string]cty.Value, error) {
+	parser := hclparse.NewParser()
+
+	var file *hcl.File
+	var diags hcl.Diagnostics
+	if hasExtension(path, ".json") {
+		file, diags = parser.ParseJSONFile(path)
+	} else {
+		file, diags = parser.ParseHCLFile(path)
+	}
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("parsing variable file %s: %s", path, diags.Error())
+	}
+
+	attrs, diags := file.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("reading variable file %s: %s", path, diags.Error())
+	}
+
+	values := make(map[string]cty.Value, len(attrs))
+	for name, attr := range attrs {
+		val, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("evaluating %s in %s: %s", name, path, diags.Error())
+		}
+		values[name] = val
+	}
+	return values, nil
+}
+
+// parseVarValue interprets a --var value. As in Terraform, values of string
+// variables are taken literally and any other value is parsed as an HCL
+// expression, falling back to a string when it is not a constant expression.
+func parseVarValue(raw string, ty cty.Type) cty.Value {
+	if ty == cty.String {
+		return cty.StringVal(raw)
+	}
+
+	expr, diags := hclsyntax.ParseExpression([]byte(raw), "<var>", hcl.InitialPos)
+	if diags.HasErrors() {
+		return cty.StringVal(raw)
+	}
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		return cty.StringVal(raw)
+	}
+	return val
+}
+
+// variableTypes collects the declared type of every variable block that has one
+func variableTypes(blocks hcl.Blocks) map[string]cty.Type {
+	types := make(map[string]cty.Type)
+	for _, block := range blocks {
+		if block.Type != "variable" {
+			continue
+		}
+
+		content, _, diags := block.Body.PartialContent(variableSchema)
+		if diags.HasErrors() {
+			continue
+		}
+
+		if attr, ok := content.Attributes["type"]; ok {
+			if ty, diags := typeexpr.TypeConstraint(attr.Expr); !diags.HasErrors() {
+				types[block.Labels[0]] = ty
+			}
+		}
+	}
+	return types
+}
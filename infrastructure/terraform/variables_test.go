@@ -0,0 +1,137 @@
+package terraform_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tfrepo "driftdetector/infrastructure/terraform"
+)
+
+const variablesConfig = `
+variable "instance_type" {
+  type = string
+}
+
+variable "environment" {
+  default = "dev"
+}
+
+variable "monitoring" {
+  type    = bool
+  default = false
+}
+
+variable "cpu_core_count" {
+  type = number
+}
+
+variable "security_groups" {
+  type    = list(string)
+  default = []
+}
+
+variable "tags" {
+  type    = map(string)
+  default = {}
+}
+
+variable "key_name" {
+  type = string
+}
+
+resource "aws_instance" "web" {
+  ami                    = "ami-0123456789abcdef0"
+  instance_type          = var.instance_type
+  monitoring             = var.monitoring
+  cpu_core_count         = var.cpu_core_count
+  vpc_security_group_ids = var.security_groups
+  key_name               = var.key_name
+
+  tags = {
+    Environment = var.environment
+    Team        = var.tags["team"]
+  }
+}
+`
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+}
+
+func TestHCLParser_Variables(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"main.tf":            variablesConfig,
+		"terraform.tfvars":   "instance_type = \"t3.small\"\nenvironment = \"staging\"\ncpu_core_count = 2\n",
+		"a.auto.tfvars":      "environment = \"qa\"\n",
+		"b.auto.tfvars.json": `{"security_groups": ["sg-1", "sg-2"], "tags": {"team": "platform"}}`,
+		"prod.tfvars":        "environment = \"prod\"\nkey_name = 12345\n",
+	})
+
+	t.Run("variable files in precedence order", func(t *testing.T) {
+		parser := tfrepo.NewHCLParser(tfrepo.WithVarFiles(filepath.Join(dir, "prod.tfvars")))
+
+		instances, err := parser.ParseDirectory(dir)
+
+		require.NoError(t, err)
+		require.Len(t, instances, 1)
+		web := instances[0]
+		assert.Equal(t, "t3.small", web.Type)
+		assert.Equal(t, map[string]string{"Environment": "prod", "Team": "platform"}, web.Tags)
+		assert.Equal(t, 2, web.CPUCoreCount)
+		assert.Equal(t, "12345", web.KeyName, "values are converted to the declared type")
+		require.Len(t, web.SecurityGroups, 2)
+		assert.Equal(t, "sg-2", web.SecurityGroups[1].GroupID)
+		require.NotNil(t, web.Monitoring)
+		assert.False(t, *web.Monitoring)
+	})
+
+	t.Run("command line variables override files", func(t *testing.T) {
+		vars, err := tfrepo.ParseVarFlags([]string{
+			"instance_type=m5.large",
+			"monitoring=true",
+			"cpu_core_count=4",
+			`security_groups=["sg-9"]`,
+			"key_name=007",
+		})
+		require.NoError(t, err)
+		parser := tfrepo.NewHCLParser(tfrepo.WithVars(vars))
+
+		instances, err := parser.ParseDirectory(dir)
+
+		require.NoError(t, err)
+		web := instances[0]
+		assert.Equal(t, "m5.large", web.Type)
+		assert.Equal(t, "qa", web.Tags["Environment"], "auto.tfvars still apply")
+		assert.Equal(t, 4, web.CPUCoreCount)
+		assert.Equal(t, "007", web.KeyName, "string variables are taken literally")
+		require.NotNil(t, web.Monitoring)
+		assert.True(t, *web.Monitoring)
+		require.Len(t, web.SecurityGroups, 1)
+		assert.Equal(t, "sg-9", web.SecurityGroups[0].GroupID)
+	})
+
+	t.Run("missing variable file", func(t *testing.T) {
+		parser := tfrepo.NewHCLParser(tfrepo.WithVarFiles(filepath.Join(dir, "missing.tfvars")))
+
+		_, err := parser.ParseDirectory(dir)
+
+		assert.Error(t, err)
+	})
+}
+
+func TestParseVarFlags(t *testing.T) {
+	vars, err := tfrepo.ParseVarFlags([]string{"a=1", "b=x=y", "a=2"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a": "2", "b": "x=y"}, vars)
+
+	_, err = tfrepo.ParseVarFlags([]string{"novalue"})
+	assert.Error(t, err)
+}
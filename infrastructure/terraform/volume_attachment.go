@@ -0,0 +1,143 @@
+package terraform
+
+import (
+	"sort"
+
+	tfjson "github.com/hashicorp/terraform-json"
+
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/logger"
+)
+
+// volumeIndex holds the aws_ebs_volume resources of a state by ID and the
+// aws_volume_attachment resources attaching them to instances
+type volumeIndex struct {
+	volumes     map[string]models.EBSBlockDevice
+	attachments []volumeAttachment
+}
+
+// volumeAttachment is an aws_volume_attachment
+type volumeAttachment struct {
+	deviceName string
+	instanceID string
+	volumeID   string
+}
+
+// newVolumeIndex returns an empty volumeIndex
+func newVolumeIndex() *volumeIndex {
+	return &volumeIndex{volumes: make(map[string]models.EBSBlockDevice)}
+}
+
+// collectVolumeAttachments adds the volumes and volume attachments of module
+// and its children
+func collectVolumeAttachments(module *tfjson.StateModule, index *volumeIndex) {
+	if module == nil {
+		return
+	}
+
+	for _, resource := range module.Resources {
+		if resource.Mode != tfjson.ManagedResourceMode || resource.AttributeValues == nil {
+			continue
+		}
+		index.add(resource.Type, resource.AttributeValues)
+	}
+
+	for _, child := range module.ChildModules {
+		collectVolumeAttachments(child, index)
+	}
+}
+
+// collectStateVolumeAttachments adds the volumes and volume attachments of a
+// raw state file
+func collectStateVolumeAttachments(state *models.TerraformState, index *volumeIndex) {
+	for _, resource := range state.Resources {
+		if resource.Mode != "managed" {
+			continue
+		}
+		for _, instance := range resource.Instances {
+			if instance.Attributes == nil {
+				continue
+			}
+			index.add(resource.Type, instance.Attributes)
+		}
+	}
+}
+
+// add indexes an aws_ebs_volume or aws_volume_attachment resource; other
+// resource types are ignored
+func (idx *volumeIndex) add(resourceType string, attrs map[string]interface{}) {
+	switch resourceType {
+	case "aws_ebs_volume":
+		if id, _ := attrs["id"].(string); id != "" {
+			idx.volumes[id] = parseEBSVolumeResource(attrs)
+		}
+	case "aws_volume_attachment":
+		var attachment volumeAttachment
+		attachment.deviceName, _ = attrs["device_name"].(string)
+		attachment.instanceID, _ = attrs["instance_id"].(string)
+		attachment.volumeID, _ = attrs["volume_id"].(string)
+		if attachment.deviceName != "" && attachment.instanceID != "" {
+			idx.attachments = append(idx.attachments, attachment)
+		}
+	}
+}
+
+// applyVolumeAttachments adds to each instance the volumes that an
+// aws_volume_attachment attaches to it, as block devices compared like those
+// declared inline. A device name the instance already declares keeps its
+// inline settings, and an attachment whose volume is not in the state is
+// left out, since its settings are unknown.
+func applyVolumeAttachments(instances []*models.Instance, index *volumeIndex) {
+	if len(index.attachments) == 0 {
+		return
+	}
+
+	for _, instance := range instances {
+		if instance.ID == "" {
+			continue
+		}
+		declared := make(map[string]bool, len(instance.EBSBlockDevices))
+		for _, device := range instance.EBSBlockDevices {
+			declared[device.DeviceName] = true
+		}
+
+		attached := false
+		for _, attachment := range index.attachments {
+			if attachment.instanceID != instance.ID || declared[attachment.deviceName] {
+				continue
+			}
+			volume, ok := index.volumes[attachment.volumeID]
+			if !ok {
+				logger.Debug("attached volume not in state", "address", instance.ResourceAddress, "volume", attachment.volumeID)
+				continue
+			}
+			logger.Debug("found attached volume", "address", instance.ResourceAddress, "volume", attachment.volumeID, "device", attachment.deviceName)
+			volume.DeviceName = attachment.deviceName
+			instance.EBSBlockDevices = append(instance.EBSBlockDevices, volume)
+			declared[attachment.deviceName] = true
+			attached = true
+		}
+		if attached {
+			sort.Slice(instance.EBSBlockDevices, func(i, j int) bool {
+				return instance.EBSBlockDevices[i].DeviceName < instance.EBSBlockDevices[j].DeviceName
+			})
+		}
+	}
+}
+
+// parseEBSVolumeResource reads an aws_ebs_volume as a block device without
+// a device name. Volumes attached this way are not deleted with the instance.
+func parseEBSVolumeResource(attrs map[string]interface{}) models.EBSBlockDevice {
+	device := parseStateEBSBlockDevice(map[string]interface{}{
+		"volume_size": attrs["size"],
+		"volume_type": attrs["type"],
+		"iops":        attrs["iops"],
+		"throughput":  attrs["throughput"],
+		"encrypted":   attrs["encrypted"],
+		"kms_key_id":  attrs["kms_key_id"],
+		"tags":        attrs["tags"],
+	})
+	deleteOnTermination := false
+	device.DeleteOnTermination = &deleteOnTermination
+	return device
+}
@@ -0,0 +1,81 @@
+package terraform_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+	tfrepo "driftdetector/infrastructure/terraform"
+)
+
+func TestTerraformStateRepository_VolumeAttachments(t *testing.T) {
+	repo := tfrepo.NewTerraformStateRepository()
+
+	instances, err := repo.GetInstanceConfigs(context.Background(), filepath.Join(terraformFixtureDir, "state", "volume_attachments.json"))
+
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	yes, no := true, false
+	assert.Equal(t, []models.EBSBlockDevice{
+		{DeviceName: "/dev/sdf", VolumeSize: 20, VolumeType: "gp3", Iops: 3000, Throughput: 125, Encrypted: &no, DeleteOnTermination: &yes},
+		{DeviceName: "/dev/sdg", VolumeSize: 50, VolumeType: "gp3", Iops: 3000, Throughput: 250, Encrypted: &no, DeleteOnTermination: &no},
+		{DeviceName: "/dev/sdh", VolumeSize: 500, VolumeType: "io2", Iops: 10000, Encrypted: &yes,
+			KMSKeyID: "arn:aws:kms:us-east-1:123456789012:key/data", DeleteOnTermination: &no, Tags: map[string]string{"Name": "db-data"}},
+	}, instances[0].EBSBlockDevices, "inline devices keep their settings over an attachment to the same device name")
+}
+
+func TestTerraformRepository_VolumeAttachments(t *testing.T) {
+	state := &models.TerraformState{
+		Resources: []models.TerraformResource{
+			{Mode: "managed", Type: "aws_instance", Name: "db", Instances: []models.TerraformResourceInstance{
+				{Attributes: map[string]interface{}{"id": "i-db", "instance_type": "r5.large"}},
+			}},
+			{Mode: "managed", Type: "aws_volume_attachment", Name: "data", Instances: []models.TerraformResourceInstance{
+				{Attributes: map[string]interface{}{"device_name": "/dev/sdh", "instance_id": "i-db", "volume_id": "vol-data"}},
+			}},
+			{Mode: "managed", Type: "aws_volume_attachment", Name: "external", Instances: []models.TerraformResourceInstance{
+				{Attributes: map[string]interface{}{"device_name": "/dev/sdi", "instance_id": "i-db", "volume_id": "vol-external"}},
+			}},
+			{Mode: "managed", Type: "aws_ebs_volume", Name: "data", Instances: []models.TerraformResourceInstance{
+				{Attributes: map[string]interface{}{"id": "vol-data", "size": float64(100), "type": "gp3"}},
+			}},
+		},
+	}
+	parser := &MockStateParser{ParseStateFunc: func(ctx context.Context, path string) (*models.TerraformState, error) {
+		return state, nil
+	}}
+
+	instances, err := tfrepo.NewTerraformRepository(parser).GetInstanceConfigs(context.Background(), "terraform.tfstate")
+
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	no := false
+	assert.Equal(t, []models.EBSBlockDevice{
+		{DeviceName: "/dev/sdh", VolumeSize: 100, VolumeType: "gp3", DeleteOnTermination: &no},
+	}, instances[0].EBSBlockDevices, "attachments of volumes outside the state are left out")
+}
+
+func TestVolumeAttachments_Drift(t *testing.T) {
+	// Given the configuration of an instance with an attached volume
+	instances, err := tfrepo.NewTerraformStateRepository().GetInstanceConfigs(context.Background(), filepath.Join(terraformFixtureDir, "state", "volume_attachments.json"))
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	desired := instances[0]
+
+	// And the instance in AWS, with the attached volume resized
+	actual := *desired
+	actual.EBSBlockDevices = append([]models.EBSBlockDevice(nil), desired.EBSBlockDevices...)
+	actual.EBSBlockDevices[2].VolumeSize = 750
+
+	// When
+	report := services.NewDriftDetector().CompareInstances(&actual, desired)
+
+	// Then the attached volume is compared by device name like an inline one
+	require.Len(t, report.Drifts, 1)
+	assert.Equal(t, "EBSBlockDevices[/dev/sdh].VolumeSize", report.Drifts[0].Path)
+}
@@ -0,0 +1,152 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultWorkspace is the workspace Terraform uses until another is
+// selected. Its local state is terraform.tfstate in the root directory.
+const DefaultWorkspace = "default"
+
+// workspaceStateDir holds one subdirectory per workspace other than the
+// default, each with its own terraform.tfstate
+const workspaceStateDir = "terraform.tfstate.d"
+
+// stateFileName is the local state file of a workspace
+const stateFileName = "terraform.tfstate"
+
+// WorkspaceChoiceError is returned when a directory holds state for several
+// workspaces and none was given or selected with terraform workspace select
+type WorkspaceChoiceError struct {
+	Dir        string
+	Workspaces []string
+}
+
+func (e *WorkspaceChoiceError) Error() string {
+	return fmt.Sprintf("%s holds state for several workspaces (%s); choose one with --workspace",
+		e.Dir, strings.Join(e.Workspaces, ", "))
+}
+
+// WithWorkspace reads the state of the named workspace from configuration
+// directories, and evaluates terraform.workspace to it. By default the
+// workspace selected in the directory is used.
+func WithWorkspace(name string) HCLParserOption {
+	return func(p *HCLParser) {
+		p.workspace = name
+	}
+}
+
+// WithStateWorkspace reads the state of the named workspace from directories
+// holding state for several workspaces
+func WithStateWorkspace(name string) TerraformStateRepositoryOption {
+	return func(r *TerraformStateRepository) {
+		r.workspace = name
+	}
+}
+
+// HasWorkspaces reports whether dir holds state for workspaces other than
+// the default one
+func HasWorkspaces(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, workspaceStateDir))
+	return err == nil && info.IsDir()
+}
+
+// Workspaces lists the workspaces with local state in dir, sorted by name.
+// The default workspace is included when dir has a terraform.tfstate.
+func Workspaces(dir string) ([]string, error) {
+	var workspaces []string
+	if fileExists(filepath.Join(dir, stateFileName)) {
+		workspaces = append(workspaces, DefaultWorkspace)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(dir, workspaceStateDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading %s: %w", workspaceStateDir, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && fileExists(WorkspaceStatePath(dir, entry.Name())) {
+			workspaces = append(workspaces, entry.Name())
+		}
+	}
+
+	sort.Strings(workspaces)
+	return workspaces, nil
+}
+
+// SelectedWorkspace returns the workspace last chosen in dir with terraform
+// workspace select, as recorded in .terraform/environment, or "" when none is
+func SelectedWorkspace(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, ".terraform", "environment"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// WorkspaceStatePath returns where the local state of workspace is kept in dir
+func WorkspaceStatePath(dir, workspace string) string {
+	if workspace == DefaultWorkspace {
+		return filepath.Join(dir, stateFileName)
+	}
+	return filepath.Join(dir, workspaceStateDir, workspace, stateFileName)
+}
+
+// ResolveWorkspaceState returns the state file to read from dir for
+// workspace. When workspace is empty the workspace selected in dir is used,
+// then the only workspace with state. It returns "" when dir has no local
+// state, and a *WorkspaceChoiceError when several workspaces could apply.
+func ResolveWorkspaceState(dir, workspace string) (string, error) {
+	if workspace == "" {
+		workspace = SelectedWorkspace(dir)
+	}
+
+	if workspace != "" {
+		if workspace == "." || workspace == ".." || strings.ContainsAny(workspace, `/\`) {
+			return "", fmt.Errorf("invalid workspace name %q", workspace)
+		}
+		path := WorkspaceStatePath(dir, workspace)
+		if !fileExists(path) {
+			available, _ := Workspaces(dir)
+			if len(available) == 0 {
+				return "", fmt.Errorf("no state for workspace %q in %s", workspace, dir)
+			}
+			return "", fmt.Errorf("no state for workspace %q in %s (found: %s)", workspace, dir, strings.Join(available, ", "))
+		}
+		return path, nil
+	}
+
+	workspaces, err := Workspaces(dir)
+	if err != nil {
+		return "", err
+	}
+	switch len(workspaces) {
+	case 0:
+		return "", nil
+	case 1:
+		return WorkspaceStatePath(dir, workspaces[0]), nil
+	default:
+		return "", &WorkspaceChoiceError{Dir: dir, Workspaces: workspaces}
+	}
+}
+
+// workspaceName returns the value of terraform.workspace for the
+// configuration in dir
+func (p *HCLParser) workspaceName(dir string) string {
+	if p.workspace != "" {
+		return p.workspace
+	}
+	if selected := SelectedWorkspace(dir); selected != "" {
+		return selected
+	}
+	return DefaultWorkspace
+}
+
+// fileExists reports whether path is an existing regular file
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,231 @@
+package terraform_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tfrepo "driftdetector/infrastructure/terraform"
+)
+
+// workspaceState is a raw state holding one instance, as terraform writes it
+const workspaceState = `{
+  "version": 4,
+  "terraform_version": "1.6.0",
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_instance",
+      "name": "web",
+      "instances": [{"attributes": {"id": %q, "instance_type": "t3.micro"}}]
+    }
+  ]
+}`
+
+// newWorkspaceDir lays out a Terraform directory with local state for the
+// default, staging and prod workspaces, whose instance IDs are i-<workspace>
+func newWorkspaceDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "terraform.tfstate"), fmt.Sprintf(workspaceState, "i-default"))
+	writeFile(t, filepath.Join(dir, "terraform.tfstate.backup"), fmt.Sprintf(workspaceState, "i-backup"))
+	for _, workspace := range []string{"staging", "prod"} {
+		writeFile(t, filepath.Join(dir, "terraform.tfstate.d", workspace, "terraform.tfstate"), fmt.Sprintf(workspaceState, "i-"+workspace))
+	}
+	// A workspace that was created but never applied has no state
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "terraform.tfstate.d", "empty"), 0o755))
+	return dir
+}
+
+// selectWorkspace records workspace as selected, like terraform workspace select
+func selectWorkspace(t *testing.T, dir, workspace string) {
+	t.Helper()
+	writeFile(t, filepath.Join(dir, ".terraform", "environment"), workspace)
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestWorkspaces(t *testing.T) {
+	dir := newWorkspaceDir(t)
+
+	workspaces, err := tfrepo.Workspaces(dir)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"default", "prod", "staging"}, workspaces, "workspaces without state are left out")
+	assert.True(t, tfrepo.HasWorkspaces(dir))
+	assert.False(t, tfrepo.HasWorkspaces(t.TempDir()))
+}
+
+func TestResolveWorkspaceState(t *testing.T) {
+	t.Run("named workspace", func(t *testing.T) {
+		dir := newWorkspaceDir(t)
+
+		path, err := tfrepo.ResolveWorkspaceState(dir, "prod")
+
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "terraform.tfstate.d", "prod", "terraform.tfstate"), path)
+	})
+
+	t.Run("default workspace", func(t *testing.T) {
+		dir := newWorkspaceDir(t)
+
+		path, err := tfrepo.ResolveWorkspaceState(dir, tfrepo.DefaultWorkspace)
+
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "terraform.tfstate"), path)
+	})
+
+	t.Run("selected workspace", func(t *testing.T) {
+		dir := newWorkspaceDir(t)
+		selectWorkspace(t, dir, "staging\n")
+
+		path, err := tfrepo.ResolveWorkspaceState(dir, "")
+
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "terraform.tfstate.d", "staging", "terraform.tfstate"), path)
+	})
+
+	t.Run("several workspaces and none selected", func(t *testing.T) {
+		dir := newWorkspaceDir(t)
+
+		_, err := tfrepo.ResolveWorkspaceState(dir, "")
+
+		var choice *tfrepo.WorkspaceChoiceError
+		require.ErrorAs(t, err, &choice)
+		assert.Equal(t, []string{"default", "prod", "staging"}, choice.Workspaces)
+		assert.Contains(t, err.Error(), "default, prod, staging")
+	})
+
+	t.Run("only workspace", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "terraform.tfstate.d", "prod", "terraform.tfstate"), "{}")
+
+		path, err := tfrepo.ResolveWorkspaceState(dir, "")
+
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "terraform.tfstate.d", "prod", "terraform.tfstate"), path)
+	})
+
+	t.Run("workspace without state", func(t *testing.T) {
+		dir := newWorkspaceDir(t)
+
+		_, err := tfrepo.ResolveWorkspaceState(dir, "empty")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "found: default, prod, staging")
+	})
+
+	t.Run("invalid workspace name", func(t *testing.T) {
+		_, err := tfrepo.ResolveWorkspaceState(newWorkspaceDir(t), "../prod")
+
+		assert.ErrorContains(t, err, "invalid workspace name")
+	})
+
+	t.Run("no local state", func(t *testing.T) {
+		path, err := tfrepo.ResolveWorkspaceState(t.TempDir(), "")
+
+		require.NoError(t, err)
+		assert.Empty(t, path)
+	})
+}
+
+func TestTerraformRepository_GetInstanceConfigsFromDir_Workspaces(t *testing.T) {
+	instanceIDs := func(t *testing.T, dir string, opts ...tfrepo.HCLParserOption) []string {
+		t.Helper()
+		repo := tfrepo.NewTerraformRepository(tfrepo.NewStateFileParser(nil), opts...)
+		instances, err := repo.GetInstanceConfigsFromDir(context.Background(), dir)
+		require.NoError(t, err)
+		var ids []string
+		for _, instance := range instances {
+			ids = append(ids, instance.ID)
+		}
+		return ids
+	}
+
+	t.Run("named workspace", func(t *testing.T) {
+		assert.Equal(t, []string{"i-prod"}, instanceIDs(t, newWorkspaceDir(t), tfrepo.WithWorkspace("prod")))
+	})
+
+	t.Run("default workspace", func(t *testing.T) {
+		assert.Equal(t, []string{"i-default"}, instanceIDs(t, newWorkspaceDir(t), tfrepo.WithWorkspace("default")))
+	})
+
+	t.Run("selected workspace", func(t *testing.T) {
+		dir := newWorkspaceDir(t)
+		selectWorkspace(t, dir, "staging")
+		assert.Equal(t, []string{"i-staging"}, instanceIDs(t, dir))
+	})
+
+	t.Run("several workspaces and none selected", func(t *testing.T) {
+		repo := tfrepo.NewTerraformRepository(tfrepo.NewStateFileParser(nil))
+
+		_, err := repo.GetInstanceConfigsFromDir(context.Background(), newWorkspaceDir(t))
+
+		var choice *tfrepo.WorkspaceChoiceError
+		assert.ErrorAs(t, err, &choice)
+	})
+
+	t.Run("workspace missing from the directory", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "terraform.tfstate"), fmt.Sprintf(workspaceState, "i-default"))
+		repo := tfrepo.NewTerraformRepository(tfrepo.NewStateFileParser(nil), tfrepo.WithWorkspace("prod"))
+
+		_, err := repo.GetInstanceConfigsFromDir(context.Background(), dir)
+
+		assert.ErrorContains(t, err, `no state for workspace "prod"`)
+	})
+
+	t.Run("terraform.workspace in configuration", func(t *testing.T) {
+		dir := t.TempDir()
+		writeFile(t, filepath.Join(dir, "main.tf"), `
+resource "aws_instance" "web" {
+  ami           = "ami-1"
+  instance_type = "t3.micro"
+  tags = {
+    Environment = terraform.workspace
+  }
+}
+`)
+		repo := tfrepo.NewTerraformRepository(tfrepo.NewStateFileParser(nil), tfrepo.WithWorkspace("prod"))
+
+		instances, err := repo.GetInstanceConfigsFromDir(context.Background(), dir)
+
+		require.NoError(t, err)
+		require.Len(t, instances, 1)
+		assert.Equal(t, "prod", instances[0].Tags["Environment"])
+	})
+}
+
+func TestTerraformStateRepository_GetInstanceConfigsFromDir_Workspaces(t *testing.T) {
+	// terraform show -json state of the prod workspace, next to a newer one of staging
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "terraform.tfstate.d", "prod", "terraform.tfstate"), sensitiveOutputState)
+	writeFile(t, filepath.Join(dir, "terraform.tfstate.d", "staging", "terraform.tfstate"), `{"format_version": "1.0"}`)
+
+	t.Run("named workspace", func(t *testing.T) {
+		repo := tfrepo.NewTerraformStateRepository(tfrepo.WithStateWorkspace("prod"))
+
+		instances, err := repo.GetInstanceConfigsFromDir(context.Background(), dir)
+
+		require.NoError(t, err)
+		require.Len(t, instances, 1)
+		assert.Equal(t, "i-0a0a0a0a0a0a0a0a0", instances[0].ID)
+	})
+
+	t.Run("several workspaces and none selected", func(t *testing.T) {
+		_, err := tfrepo.NewTerraformStateRepository().GetInstanceConfigsFromDir(context.Background(), dir)
+
+		var choice *tfrepo.WorkspaceChoiceError
+		require.ErrorAs(t, err, &choice)
+		assert.Equal(t, []string{"prod", "staging"}, choice.Workspaces)
+	})
+}
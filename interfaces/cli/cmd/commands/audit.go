@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"driftdetector/application"
+	appcommands "driftdetector/application/commands"
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/persistence"
+)
+
+// NewAuditCmd creates the parent command of the security audits
+func NewAuditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Audit security settings of the instances in Terraform state",
+	}
+	cmd.AddCommand(NewAuditIMDSCmd())
+	return cmd
+}
+
+// NewAuditIMDSCmd creates a command that checks every instance in Terraform
+// state for IMDSv1 access and metadata endpoint drift
+func NewAuditIMDSCmd() *cobra.Command {
+	var (
+		tfState     string
+		tfDir       string
+		workspace   workspaceFlags
+		stateRegion string
+		jsonOutput  bool
+		outputFile  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "imds",
+		Short: "Find instances that allow IMDSv1",
+		Long: `Compare the instance metadata service settings of every instance recorded in
+Terraform state with AWS. An instance whose http_tokens is not "required" allows
+IMDSv1 and is flagged, as is one whose http_endpoint differs from Terraform.
+
+The command fails when an instance allows IMDSv1 although Terraform requires
+tokens or disables the endpoint.`,
+		Example: `  driftdetector audit imds --tf-state terraform.tfstate
+  driftdetector audit imds --tf-state s3://bucket/prod.tfstate --json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format := persistence.FormatType(outputFmt)
+			if jsonOutput {
+				format = persistence.FormatJSON
+			}
+			if format != persistence.FormatText && format != persistence.FormatJSON {
+				return fmt.Errorf("invalid --output: audit imds supports text and json, not %s", format)
+			}
+
+			awsConfig, err := awsConfigOption(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			tfWorkspace, err := workspace.option(cmd, tfDir)
+			if err != nil {
+				return err
+			}
+
+			container, err := application.NewContainer(cmd.Context(), awsConfig, application.WithStateRegion(stateRegion), tfWorkspace, application.WithStdin(cmd.InOrStdin()))
+			if err != nil {
+				return fmt.Errorf("failed to initialize application container: %w", err)
+			}
+
+			handler := appcommands.NewAuditIMDSHandler(container.GetInstanceRepository(), container.GetTerraformRepository())
+			audits, err := handler.Handle(cmd.Context(), appcommands.AuditIMDSCommand{
+				TerraformStateFile: tfState,
+				TerraformDir:       tfDir,
+			})
+			if err != nil {
+				return err
+			}
+
+			err = writeOutput(cmd.Context(), outputFileWriter(outputFile), "", func(out io.Writer) error {
+				return printIMDSAudits(out, audits, format)
+			})
+			if err != nil {
+				return err
+			}
+
+			violations := 0
+			for _, audit := range audits {
+				if audit.Status == models.IMDSViolation {
+					violations++
+				}
+			}
+			if violations > 0 {
+				return fmt.Errorf("%d instance(s) allow IMDSv1 contrary to Terraform", violations)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&tfState, "tf-state", "s", "", "Path to Terraform state file or s3://bucket/key URL; - reads stdin, e.g. from terraform state pull")
+	cmd.Flags().StringVar(&stateRegion, "tf-state-region", "", "Region of the S3 bucket holding remote state (default: the AWS region)")
+	cmd.Flags().StringVarP(&tfDir, "tf-dir", "d", "", "Path to a Terraform directory whose local state is audited")
+	workspace.register(cmd)
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print results as JSON (same as -o json)")
+	cmd.Flags().StringVar(&outputFile, "output-file", "", "Write the results to this file instead of stdout")
+
+	cmd.MarkFlagsOneRequired("tf-state", "tf-dir")
+	cmd.MarkFlagsMutuallyExclusive("tf-state", "tf-dir")
+
+	return cmd
+}
+
+// printIMDSAudits writes the audits to out as a table or as JSON
+func printIMDSAudits(out io.Writer, audits []*models.IMDSAudit, format persistence.FormatType) error {
+	if format == persistence.FormatJSON {
+		data, err := json.MarshalIndent(audits, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit results: %w", err)
+		}
+		fmt.Fprintln(out, string(data))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "INSTANCE ID\tRESOURCE\tTOKENS (TF/AWS)\tENDPOINT (TF/AWS)\tSTATUS\tFINDINGS")
+	for _, audit := range audits {
+		var expected, actual models.MetadataOptions
+		if audit.Expected != nil {
+			expected = *audit.Expected
+		}
+		if audit.Actual != nil {
+			actual = *audit.Actual
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s/%s\t%s/%s\t%s\t%s\n",
+			audit.InstanceID, orDash(audit.ResourceAddress),
+			orDash(expected.HTTPTokens), orDash(actual.HTTPTokens),
+			orDash(expected.HTTPEndpoint), orDash(actual.HTTPEndpoint),
+			audit.Status, orDash(strings.Join(audit.Findings, "; ")))
+	}
+	return w.Flush()
+}
+
+// orDash returns s, or "-" for an empty table cell
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
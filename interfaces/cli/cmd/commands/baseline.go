@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/baseline"
+)
+
+// baselineFlags holds the flags that acknowledge accepted drift listed in a
+// baseline file
+type baselineFlags struct {
+	path    string
+	write   string
+	expires string
+
+	loaded *baseline.Baseline
+}
+
+// register adds the baseline flags to cmd
+func (f *baselineFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.path, "baseline", "", "Baseline file of accepted findings, reported as acknowledged instead of drift while their value is unchanged")
+	cmd.Flags().StringVar(&f.write, "write-baseline", "", "Write every finding of this run to a baseline file; entries of --baseline still matching keep their expiry and note")
+	cmd.Flags().StringVar(&f.expires, "baseline-expires", "", "Date, as YYYY-MM-DD or an RFC 3339 time, from which findings written by --write-baseline are reported again")
+}
+
+// load reads the --baseline file and checks --baseline-expires, so mistakes
+// fail before any AWS calls
+func (f *baselineFlags) load() error {
+	if f.expires != "" {
+		if f.write == "" {
+			return errors.New("--baseline-expires requires --write-baseline")
+		}
+		if _, err := baseline.ParseExpiry(f.expires); err != nil {
+			return fmt.Errorf("invalid --baseline-expires: %w", err)
+		}
+	}
+	if f.path == "" {
+		return nil
+	}
+	loaded, err := baseline.Load(f.path)
+	if err != nil {
+		return err
+	}
+	f.loaded = loaded
+	return nil
+}
+
+// entries returns the number of entries of the --baseline file
+func (f *baselineFlags) entries() int {
+	if f.loaded == nil {
+		return 0
+	}
+	return len(f.loaded.Entries)
+}
+
+// apply writes the findings of reports to the --write-baseline file, if any,
+// and returns the reports with the findings the baseline accepts moved to
+// their acknowledged section. The written baseline replaces --baseline, so a
+// run that writes one reports no drift.
+func (f *baselineFlags) apply(cmd *cobra.Command, reports []*models.DriftReport) ([]*models.DriftReport, error) {
+	now := time.Now()
+	active := f.loaded
+	if f.write != "" {
+		active = baseline.Record(reports, f.loaded, f.expires, now)
+		if err := active.Write(f.write); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Wrote %d finding(s) to %s\n", len(active.Entries), f.write)
+	}
+	if active == nil {
+		return reports, nil
+	}
+
+	applied := make([]*models.DriftReport, 0, len(reports))
+	for _, report := range reports {
+		applied = append(applied, active.Apply(report, now))
+	}
+	return applied, nil
+}
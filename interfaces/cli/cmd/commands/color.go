@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+	"driftdetector/pkg/driftdetector"
+)
+
+// colorFlag holds the flag that turns colored text output off
+type colorFlag struct {
+	disabled bool
+}
+
+// register adds --no-color to cmd
+func (f *colorFlag) register(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.disabled, "no-color", false, "Print text output without colors; they are also left out when NO_COLOR is set or stdout is not a terminal")
+}
+
+// option returns the formatter option that colors text output, which it
+// does only when the output goes to stdout, stdout is a terminal and
+// neither --no-color nor NO_COLOR asks for plain text
+func (f *colorFlag) option(toStdout bool) driftdetector.FormatterOption {
+	return driftdetector.WithColor(!f.disabled && toStdout && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout))
+}
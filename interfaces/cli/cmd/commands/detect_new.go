@@ -1,25 +1,91 @@
 package cmd
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
+	tfjson "github.com/hashicorp/terraform-json"
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"driftdetector/application"
+	appcommands "driftdetector/application/commands"
 	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/domain/services"
+	"driftdetector/infrastructure/config"
+	"driftdetector/infrastructure/mock"
+	"driftdetector/infrastructure/persistence"
+	"driftdetector/infrastructure/policy"
+	"driftdetector/infrastructure/terraform"
+	"driftdetector/pkg/driftdetector"
 )
 
 // NewDetectDDDCmd creates a new detect command with the new DDD structure
 func NewDetectDDDCmd() *cobra.Command {
 	var (
-		instanceID    string
-		stateFile     string
-		tfDir         string
-		outputFormat  string
-		showAll       bool
-		showOnlyDrift bool
+		instanceID      string
+		instanceName    string
+		stateFile       string
+		stateRegion     string
+		planFile        string
+		varFiles        []string
+		vars            []string
+		tfDir           string
+		outputFormat    string
+		showAll         bool
+		showOnlyDrift   bool
+		verifyPlan      bool
+		opaPolicyDir    string
+		goldenConfig    string
+		failOnGolden    bool
+		resourceAddress string
+		ignorePaths     []string
+		ignoreFile      string
+		onlyPaths       []string
+		userDataDiff    bool
+		severityConfig  string
+		minSeverity     string
+		failOnSeverity  string
+		strictNil       bool
+		defaultTags     []string
+		comparers       []string
+		includeAWSTags  bool
+		resolveIAM      bool
+		resolveAMI      bool
+		resolveSubnets  bool
+		resolveData     bool
+		amiTag          string
+		amiMaxAge       string
+		failOnAMIAge    bool
+		failOnDrift     bool
+		strictParse     bool
+		suggest         bool
+		webhook         webhookFlags
+		redact          redactFlags
+		outputMode      outputModeFlags
+		browser         tuiFlags
+		strict          strictFlags
+		workspace       workspaceFlags
+		strictState     strictStateFlag
+		baseline        baselineFlags
+		maxConcurrency  int
+		output          outputFlags
+		color           colorFlag
+		tmpl            templateFlags
+		maxValueLength  int
+		fuzzyMatch      bool
+		pickFirst       bool
+		includeStopped  bool
+		mockFile        string
+		mockFormat      string
+		timeout         time.Duration
 	)
 
 	cmd := &cobra.Command{
@@ -27,101 +93,926 @@ func NewDetectDDDCmd() *cobra.Command {
 		Short: "Detect configuration drift using DDD structure",
 		Long: `Detect configuration drift between AWS EC2 instances and their Terraform configuration
 using the new Domain-Driven Design structure.`,
-		RunE: func(cmd *cobra.Command, args []string) error {
+		RunE: withTimeout(&timeout, func(cmd *cobra.Command, args []string) error {
+			if tmpl.printSchema(cmd) {
+				return nil
+			}
+
+			// Reject unknown output formats and invalid templates before any AWS calls
+			formatOpts, err := tmpl.options(outputFormat)
+			if err != nil {
+				return err
+			}
+			formatOpts = append(formatOpts, driftdetector.WithMaxValueLength(maxValueLength))
+			if _, err := driftdetector.NewFormatter(driftdetector.FormatType(outputFormat), formatOpts...); err != nil {
+				return fmt.Errorf("invalid --output: %w", err)
+			}
+			formatted := func() []driftdetector.FormatterOption {
+				return append(formatOpts[:len(formatOpts):len(formatOpts)], color.option(output.toStdout()))
+			}
+
+			notifier, err := webhook.notifier()
+			if err != nil {
+				return err
+			}
+			redactor, err := redact.redactor()
+			if err != nil {
+				return err
+			}
+			if err := baseline.load(); err != nil {
+				return err
+			}
+			sink, err := output.writer(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			// Compile policies first so syntax errors fail before any AWS calls
+			var evaluator *policy.OPAEvaluator
+			if opaPolicyDir != "" {
+				evaluator, err = policy.LoadOPAPolicies(opaPolicyDir)
+				if err != nil {
+					return fmt.Errorf("failed to load OPA policies: %w", err)
+				}
+			}
+
+			var goldenTemplates []*models.GoldenTemplate
+			if goldenConfig != "" {
+				var err error
+				goldenTemplates, err = config.LoadGoldenTemplates(goldenConfig)
+				if err != nil {
+					return fmt.Errorf("failed to load golden templates: %w", err)
+				}
+			}
+
+			// Severity rules extend the defaults; configured rules win on equal paths
+			var configuredRules []models.SeverityRule
+			if severityConfig != "" {
+				var err error
+				configuredRules, err = config.LoadSeverityConfig(severityConfig)
+				if err != nil {
+					return fmt.Errorf("failed to load severity config: %w", err)
+				}
+			}
+
+			minLevel, err := models.ParseSeverity(minSeverity)
+			if err != nil {
+				return fmt.Errorf("invalid --min-severity: %w", err)
+			}
+
+			var maxAMIAge time.Duration
+			if amiMaxAge != "" {
+				if maxAMIAge, err = parseMaxAge(amiMaxAge); err != nil {
+					return err
+				}
+			} else if failOnAMIAge {
+				return fmt.Errorf("--fail-on-ami-age requires --ami-max-age")
+			}
+
+			// terraform plan runs in the configuration that was compared;
+			// without one it would plan the working directory
+			if verifyPlan && tfDir == "" {
+				return fmt.Errorf("--verify-plan requires --tf-dir")
+			}
+
+			var failLevel models.Severity
+			if failOnSeverity != "" {
+				failLevel, err = models.ParseSeverity(failOnSeverity)
+				if err != nil {
+					return fmt.Errorf("invalid --fail-on-severity: %w", err)
+				}
+			}
+
+			// Combine --ignore with paths listed in --ignore-file
+			ignored := append([]string{}, ignorePaths...)
+			if ignoreFile != "" {
+				filePaths, err := config.LoadIgnoreFile(ignoreFile)
+				if err != nil {
+					return err
+				}
+				ignored = append(ignored, filePaths...)
+			}
+
+			detectorOptions := []driftdetector.Option{
+				driftdetector.WithIgnoredFields(ignored...),
+				driftdetector.WithOnlyFields(onlyPaths...),
+				driftdetector.WithSeverityRules(configuredRules...),
+				driftdetector.WithMinSeverity(minLevel),
+			}
+			if strictNil {
+				detectorOptions = append(detectorOptions, driftdetector.WithStrictNil())
+			}
+			if len(defaultTags) > 0 {
+				tags, err := parseDefaultTags(defaultTags)
+				if err != nil {
+					return err
+				}
+				detectorOptions = append(detectorOptions, driftdetector.WithDefaultTags(tags))
+			}
+			if includeAWSTags {
+				detectorOptions = append(detectorOptions, driftdetector.WithAWSTags())
+			}
+			comparerOptions, err := parseComparers(comparers)
+			if err != nil {
+				return err
+			}
+			detectorOptions = append(detectorOptions, comparerOptions...)
+			detectorOptions = append(detectorOptions, strict.options()...)
+			detector, err := driftdetector.New(detectorOptions...)
+			if err != nil {
+				return err
+			}
+
+			tfVars, err := terraformVariablesOption(varFiles, vars)
+			if err != nil {
+				return err
+			}
+
+			tfWorkspace, err := workspace.option(cmd, tfDir)
+			if err != nil {
+				return err
+			}
+
+			containerOpts := []application.ContainerOption{
+				application.WithStateRegion(stateRegion),
+				tfVars,
+				tfWorkspace,
+				application.WithDetectionService(detector.Service()),
+				application.WithStdin(cmd.InOrStdin()),
+			}
+			containerOpts = append(containerOpts, strictState.options()...)
+			if mockFile != "" {
+				if mockFile == stdinMockFile && stateFile == terraform.StdinState {
+					return errors.New("--mock-file and --state-file cannot both read stdin")
+				}
+				// The instances come from the file, so AWS is only needed for remote state
+				mockConfigs, err := loadMockConfigs(cmd, mockFile, mockFormat)
+				if err != nil {
+					return err
+				}
+				// A file with several instances checks all of them by default
+				if instanceID == "" && instanceName == "" && len(mockConfigs) == 1 {
+					instanceID = mockConfigs[0].InstanceID
+				}
+				containerOpts = append(containerOpts, application.WithInstanceRepository(mock.NewInstanceRepository(mockConfigs...)))
+				if !terraform.IsRemoteState(stateFile) {
+					containerOpts = append(containerOpts, application.WithoutAWS())
+				}
+			} else {
+				awsConfig, err := awsConfigOption(cmd.Context())
+				if err != nil {
+					return err
+				}
+				containerOpts = append(containerOpts, awsConfig)
+			}
+			if resolveIAM {
+				containerOpts = append(containerOpts, application.WithIAMProfileResolution())
+			}
+			if resolveData {
+				containerOpts = append(containerOpts, application.WithDataSourceResolution())
+			}
+
 			// Initialize application container
-			container, err := application.NewContainer(cmd.Context())
+			container, err := application.NewContainer(cmd.Context(), containerOpts...)
 			if err != nil {
 				return fmt.Errorf("failed to initialize application container: %w", err)
 			}
 
-			detectionSvc := container.GetDetectionService()
+			if stateFile == "" && tfDir == "" && planFile == "" {
+				return fmt.Errorf("one of --state-file, --tf-dir or --tf-plan must be specified")
+			}
+
+			// Run terraform plan once, whether checking one instance or all of them
+			var plan *tfjson.Plan
+			if verifyPlan {
+				plan, err = container.GetPlanRunner().Plan(cmd.Context(), tfDir)
+				if err != nil {
+					return fmt.Errorf("failed to run terraform plan: %w", err)
+				}
+			}
 
-			// Get the instance from AWS
-			instance, err := container.GetInstanceRepository().GetByID(cmd.Context(), instanceID)
+			// Security groups declared in the state are compared rule by rule,
+			// which needs their current rules from AWS
+			desiredGroups, err := application.LoadSecurityGroupConfigs(cmd.Context(), container.GetTerraformRepository(), stateFile)
 			if err != nil {
-				return fmt.Errorf("failed to fetch instance from AWS: %w", err)
+				return err
+			}
+			desiredEIPs, err := application.LoadElasticIPConfigs(cmd.Context(), container.GetTerraformRepository(), stateFile)
+			if err != nil {
+				return err
 			}
 
-			// Get desired state from Terraform
-			var instances []*models.Instance
-			if stateFile != "" {
-				instances, err = container.GetTerraformRepository().GetInstanceConfigs(cmd.Context(), stateFile)
-			} else if tfDir != "" {
-				instances, err = container.GetTerraformRepository().GetInstanceConfigsFromDir(cmd.Context(), tfDir)
-			} else {
-				return fmt.Errorf("either --state-file or --tf-dir must be specified")
+			// Instances come from --tf-dir when it is given; the state then
+			// resolves references such as aws_security_group.web.id
+			sourceState := stateFile
+			if tfDir != "" {
+				sourceState = ""
 			}
+			source := &countingSource{Source: appcommands.WithSecurityGroupRefs(
+				appcommands.NewTerraformSource(container.GetTerraformRepository(), sourceState, tfDir, planFile), desiredGroups)}
+
+			// finalize enriches a report with security group, Elastic IP,
+			// golden, plan, config and policy results and returns it classified
+			// by severity
+			finalize := func(report *models.DriftReport, actual, desired *models.Instance) (*models.DriftReport, error) {
+				// Rebaked images are compared by their attributes, before
+				// anything else looks at the AMI finding
+				if resolveAMI {
+					err := application.ApplyAMIResolution(cmd.Context(), container.GetImageRepository(), report, amiTag)
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				// Stale images are noted without making the report drifted
+				if maxAMIAge > 0 {
+					err := application.ApplyAMIAgeCheck(cmd.Context(), container.GetImageRepository(), report, actual, maxAMIAge, time.Now())
+					if err != nil {
+						return nil, err
+					}
+				}
 
+				if actual != nil {
+					// A subnet in another availability zone is one finding
+					if resolveSubnets {
+						err := application.ApplySubnetResolution(cmd.Context(), container.GetSubnetRepository(), report, actual, desired)
+						if err != nil {
+							return nil, err
+						}
+					}
+
+					// A mocked instance has no security groups or Elastic IPs in
+					// AWS to compare
+					if mockFile == "" {
+						err := application.ApplySecurityGroupDrift(cmd.Context(), container.GetDetectionService(),
+							container.GetSecurityGroupRepository(), report, actual, desiredGroups)
+						if err != nil {
+							return nil, err
+						}
+						err = application.ApplyElasticIPDrift(cmd.Context(), container.GetDetectionService(),
+							container.GetElasticIPRepository(), report, desiredEIPs)
+						if err != nil {
+							return nil, err
+						}
+					}
+
+					// Compare against the golden template selected for this instance
+					application.ApplyGoldenTemplates(report, actual, goldenTemplates)
+				}
+
+				// Cross-reference findings with what terraform apply would change
+				if verifyPlan {
+					terraform.ApplyPlanCoverage(report, plan)
+				}
+
+				// Record the options that shaped this report
+				effectiveConfig, err := application.ResolveEffectiveConfig(application.DetectOptions{
+					IgnoredPaths: ignored,
+					OnlyPaths:    onlyPaths,
+					Flags: map[string]bool{
+						"verify_plan":      verifyPlan,
+						"fail_on_golden":   failOnGolden,
+						"strict_nil":       strictNil,
+						"strict":           strict.enabled,
+						"include_aws_tags": includeAWSTags,
+						"resolve_iam":      resolveIAM,
+						"resolve_ami":      resolveAMI,
+						"check_ami_age":    maxAMIAge > 0,
+						"fail_on_ami_age":  failOnAMIAge,
+						"resolve_subnets":  resolveSubnets,
+						"resolve_data":     resolveData,
+						"fail_on_drift":    failOnDrift,
+						"strict_parse":     strictParse,
+						"strict_state":     strictState.enabled,
+						"suggest":          suggest,
+					},
+					Files: append(sourceFiles(stateFile, tfDir, planFile, source.read),
+						application.ReferencedFile{Role: "opa_policy", Path: opaPolicyDir},
+						application.ReferencedFile{Role: "golden_config", Path: goldenConfig, Entries: len(goldenTemplates)},
+						application.ReferencedFile{Role: "ignore_file", Path: ignoreFile},
+						application.ReferencedFile{Role: "mock_file", Path: mockFile},
+						application.ReferencedFile{Role: "severity_config", Path: severityConfig, Entries: len(configuredRules)},
+						application.ReferencedFile{Role: "baseline", Path: baseline.path, Entries: baseline.entries()},
+					),
+				})
+				if err != nil {
+					return nil, fmt.Errorf("failed to resolve effective config: %w", err)
+				}
+				report.Metadata = &models.ReportMetadata{EffectiveConfig: effectiveConfig}
+
+				// Evaluate user policies against the complete report
+				if evaluator != nil && actual != nil {
+					for _, err := range evaluator.ApplyToReport(cmd.Context(), report, actual, desired) {
+						fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+					}
+				}
+
+				// Suggest fixes once every finding has been added
+				if suggest {
+					services.SuggestRemediations(report, desired)
+				}
+
+				// Classify findings added after detection, such as golden and policy results
+				return detector.Classify(report), nil
+			}
+
+			// Without an instance ID or name, check every instance recorded in Terraform state
+			if instanceID == "" && instanceName == "" {
+				handler := appcommands.NewDetectAllDriftHandler(
+					container.GetDetectionService(),
+					container.GetInstanceRepository(),
+					container.GetTerraformRepository(),
+					appcommands.WithFetchConcurrency(maxConcurrency),
+					appcommands.WithIncludeStopped(includeStopped),
+				)
+				results, err := handler.Handle(cmd.Context(), appcommands.DetectAllDriftCommand{Desired: source})
+				// Instances that failed to compare are reported alongside the others
+				var failures []string
+				var batchErr *services.BatchError
+				if errors.As(err, &batchErr) {
+					for _, failure := range batchErr.Unwrap() {
+						failures = append(failures, failure.Error())
+					}
+				} else if err != nil {
+					return err
+				}
+
+				reports := make([]*models.DriftReport, 0, len(results))
+				for _, result := range results {
+					report, err := finalize(result.Report, result.Actual, result.Desired)
+					if err != nil {
+						return err
+					}
+					reports = append(reports, report)
+				}
+
+				// Accepted values are matched before redaction hides them
+				reports, err = baseline.apply(cmd, reports)
+				if err != nil {
+					return err
+				}
+				for i, report := range reports {
+					reports[i] = redactor.Redact(report)
+				}
+				aggregate := models.NewAggregateReport(reports, failures)
+
+				// The browser replaces the report on stdout, not in --output-file
+				// or --output-s3
+				browsing := browser.active(cmd)
+				if !outputMode.quiet && (!browsing || !output.toStdout()) {
+					name := persistence.ReportFileName(aggregateReportName, time.Time{}, persistence.FormatType(outputFormat))
+					err = writeOutput(cmd.Context(), sink, name, func(w io.Writer) error {
+						if outputMode.summary {
+							return writeSummary(w, aggregate, outputFormat)
+						}
+						return outputAllResults(w, aggregate, outputFormat, showAll, showOnlyDrift, formatted()...)
+					})
+					if err == nil && output.s3 != "" {
+						err = writeInstanceReports(cmd.Context(), sink, reports, outputFormat, time.Time{}, formatOpts...)
+					}
+					if err != nil {
+						return err
+					}
+				}
+				if browsing {
+					if err := browser.browse(cmd, reports); err != nil {
+						return err
+					}
+				}
+				for _, report := range reports {
+					notifyDrift(cmd.Context(), notifier, report)
+				}
+
+				if batchErr != nil {
+					return batchErr
+				}
+				if strictParse {
+					desired := make([]*models.Instance, 0, len(results))
+					for _, result := range results {
+						desired = append(desired, result.Desired)
+					}
+					if err := parseWarningsError(desired); err != nil {
+						return outputMode.outcome(cmd, err)
+					}
+				}
+				if failOnGolden {
+					if err := goldenMismatchError(reports); err != nil {
+						return outputMode.outcome(cmd, err)
+					}
+				}
+				if failOnAMIAge {
+					if err := staleAMIError(reports); err != nil {
+						return outputMode.outcome(cmd, err)
+					}
+				}
+				if verifyPlan {
+					if err := unaddressedDriftError(reports); err != nil {
+						return outputMode.outcome(cmd, err)
+					}
+				}
+				if failLevel != "" {
+					return outputMode.outcome(cmd, failOnSeverityLevel(reports, failLevel))
+				}
+				// With --verify-plan, drift that apply fixes passes
+				if aggregate.Drifted > 0 && !verifyPlan {
+					return outputMode.outcome(cmd, fmt.Errorf("drift detected in %d of %d instance(s)", aggregate.Drifted, aggregate.TotalInstances))
+				}
+				return nil
+			}
+
+			detectionSvc := container.GetDetectionService()
+
+			// Get desired state from Terraform
+			instances, err := source.Instances(cmd.Context())
 			if err != nil {
-				return fmt.Errorf("failed to get desired state from Terraform: %w", err)
+				return err
 			}
+			matcher := appcommands.NewConfigMatcher(instances)
+			matcher.PickFirst = pickFirst
+
+			// Get the instance from AWS, by ID or by its Name tag
+			var instance *models.Instance
+			if instanceName != "" {
+				instance, err = matcher.FindInstanceByName(cmd.Context(), container.GetInstanceRepository(), instanceName)
+				if err != nil {
+					return err
+				}
+				instanceID = instance.ID
+			} else {
+				instance, err = container.GetInstanceRepository().GetByID(cmd.Context(), instanceID)
+				// An instance terminated long ago is unknown to AWS; it is
+				// reported as removed when Terraform still records it
+				if err != nil && !errors.Is(err, repositories.ErrInstanceNotFound) {
+					return fmt.Errorf("failed to fetch instance from AWS: %w", err)
+				}
+			}
+			fetchErr := err
 
 			// Find the specific instance in the results
 			var desiredInstance *models.Instance
-			for _, inst := range instances {
-				if inst.ID == instanceID {
-					desiredInstance = inst
-					break
+			if instance == nil {
+				desiredInstance = appcommands.FindMatchingConfig(instances, instanceID, resourceAddress)
+				if desiredInstance == nil {
+					return fmt.Errorf("failed to fetch instance from AWS: %w", fetchErr)
 				}
+			} else if candidate, err := matcher.Match(instance, resourceAddress); err == nil {
+				desiredInstance = candidate.Config
+			} else {
+				if !fuzzyMatch || !errors.Is(err, appcommands.ErrNoMatchingConfig) || resourceAddress != "" || len(instances) == 0 {
+					return err
+				}
+				desiredInstance = instances[0]
+				fmt.Fprintf(os.Stderr, "Warning: %v; comparing against %s because of --fuzzy-match\n", err, desiredInstance.ResourceAddress)
+			}
+
+			// Configurations parsed from .tf files carry no instance ID
+			if desiredInstance.ID == "" {
+				desiredInstance.ID = instanceID
 			}
 
-			if desiredInstance == nil {
-				return fmt.Errorf("instance %s not found in Terraform state", instanceID)
+			// Missing, terminated and stopped instances get a single finding
+			// instead of a field-by-field comparison
+			compared := instance
+			var report *models.DriftReport
+			if instance == nil {
+				report = appcommands.MissingInstanceReport(instanceID)
+			} else if report = appcommands.InactiveInstanceReport(instance, includeStopped); report != nil {
+				compared = nil
+			} else {
+				report, err = detectionSvc.DetectDrift(cmd.Context(), instance, desiredInstance)
+				if err != nil {
+					return fmt.Errorf("failed to detect drift: %w", err)
+				}
 			}
 
-			// Detect drift
-			report, err := detectionSvc.DetectDrift(cmd.Context(), instance, desiredInstance)
+			report, err = finalize(report, compared, desiredInstance)
+			if err != nil {
+				return err
+			}
+			applied, err := baseline.apply(cmd, []*models.DriftReport{report})
 			if err != nil {
-				return fmt.Errorf("failed to detect drift: %w", err)
+				return err
 			}
+			report = redactor.Redact(applied[0])
 
 			// Output results
-			return outputResults(report, outputFormat, showAll, showOnlyDrift)
-		},
+			browsing := browser.active(cmd)
+			if !outputMode.quiet && (!browsing || !output.toStdout()) {
+				name := persistence.ReportFileName(report.InstanceID, time.Time{}, persistence.FormatType(outputFormat))
+				err = writeOutput(cmd.Context(), sink, name, func(w io.Writer) error {
+					if outputMode.summary {
+						return writeSummary(w, models.NewAggregateReport([]*models.DriftReport{report}, nil), outputFormat)
+					}
+					if err := outputResults(w, report, outputFormat, showAll, showOnlyDrift, formatted()...); err != nil {
+						return err
+					}
+
+					// Keep structured output parseable by writing the diff to stderr
+					if userDataDiff {
+						diffOut := w
+						if outputFormat != string(driftdetector.FormatText) {
+							diffOut = os.Stderr
+						}
+						return printUserDataDiff(diffOut, report, instance, desiredInstance, redactor)
+					}
+					return nil
+				})
+				if err != nil {
+					return err
+				}
+			}
+			if browsing {
+				if err := browser.browse(cmd, []*models.DriftReport{report}); err != nil {
+					return err
+				}
+			}
+			notifyDrift(cmd.Context(), notifier, report)
+
+			if strictParse {
+				if err := parseWarningsError([]*models.Instance{desiredInstance}); err != nil {
+					return outputMode.outcome(cmd, err)
+				}
+			}
+
+			if failOnGolden {
+				if err := goldenMismatchError([]*models.DriftReport{report}); err != nil {
+					return outputMode.outcome(cmd, err)
+				}
+			}
+
+			if failOnAMIAge {
+				if err := staleAMIError([]*models.DriftReport{report}); err != nil {
+					return outputMode.outcome(cmd, err)
+				}
+			}
+
+			if verifyPlan {
+				if err := unaddressedDriftError([]*models.DriftReport{report}); err != nil {
+					return outputMode.outcome(cmd, err)
+				}
+			}
+
+			if failLevel != "" {
+				return outputMode.outcome(cmd, failOnSeverityLevel([]*models.DriftReport{report}, failLevel))
+			}
+
+			if failOnDrift && report.HasDrifts() {
+				return outputMode.outcome(cmd, fmt.Errorf("drift detected on %s", report.InstanceID))
+			}
+
+			return nil
+		}),
 	}
 
 	// Add flags
-	cmd.Flags().StringVarP(&instanceID, "instance", "i", "", "EC2 instance ID to check for drift (required)")
-	cmd.Flags().StringVarP(&stateFile, "state-file", "s", "", "Path to Terraform state file")
+	cmd.Flags().StringVarP(&instanceID, "instance", "i", "", "EC2 instance ID to check for drift (default: every instance in the state)")
+	cmd.Flags().StringVar(&instanceName, "name", "", "Name tag of the running EC2 instance to check, instead of its ID")
+	cmd.Flags().StringVarP(&stateFile, "state-file", "s", "", "Path to Terraform state file or s3://bucket/key URL, or - to read stdin, e.g. from terraform state pull; with --tf-dir, only used to resolve security group references")
+	cmd.Flags().StringVar(&stateRegion, "tf-state-region", "", "Region of the S3 bucket holding remote state (default: the AWS region)")
 	cmd.Flags().StringVarP(&tfDir, "tf-dir", "d", "", "Path to Terraform configuration directory")
-	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format (text, json)")
+	workspace.register(cmd)
+	strictState.register(cmd)
+	cmd.Flags().StringArrayVar(&varFiles, "var-file", nil, "Terraform variable file used to evaluate --tf-dir, after terraform.tfvars and *.auto.tfvars (repeatable)")
+	cmd.Flags().StringArrayVar(&vars, "var", nil, "Terraform variable as name=value, overriding variable files (repeatable)")
+	cmd.Flags().StringVar(&planFile, "tf-plan", "", "Path to a plan rendered with terraform show -json, compared by its planned values")
+	cmd.Flags().StringVar(&mockFile, "mock-file", "", "Instance configuration written by snapshot, an array of them or a directory of them, as JSON or YAML, compared instead of live instances; - reads stdin (default --instance: the file's only instance)")
+	cmd.Flags().StringVar(&mockFormat, "mock-format", "", mockFormatUsage)
+	cmd.Flags().StringVar(&resourceAddress, "resource", "", "Terraform resource address to compare against (e.g. aws_instance.web); also accepted as --resource-address")
+	cmd.Flags().BoolVar(&includeStopped, "include-stopped", false, "Compare stopped instances field by field instead of reporting them as removed")
+	cmd.Flags().BoolVar(&pickFirst, "pick-first", false, "Compare against the first candidate when several Terraform resources or running instances match, e.g. resources sharing a Name tag, instead of failing")
+	cmd.Flags().BoolVar(&fuzzyMatch, "fuzzy-match", false, "Compare against the first configuration when none matches the instance, instead of failing")
+	cmd.Flags().StringVarP(&outputFormat, "output", "o", "text", "Output format (text, json, yaml, html, markdown, csv, sarif, template)")
+	tmpl.register(cmd)
+	cmd.Flags().IntVar(&maxValueLength, "max-value-length", 200, "Truncate longer values in markdown output (0 disables truncation)")
+	output.register(cmd, "report")
 	cmd.Flags().BoolVar(&showAll, "all", false, "Show all fields, even those without drift")
 	cmd.Flags().BoolVar(&showOnlyDrift, "only-drift", false, "Show only fields with drift")
+	cmd.Flags().DurationVar(&timeout, "timeout", 2*time.Minute, "Give up when the command has not finished after this long (0 disables the limit)")
+	cmd.Flags().IntVar(&maxConcurrency, "max-concurrency", 10, "Maximum number of AWS requests in flight when checking every instance")
+	cmd.Flags().StringArrayVar(&ignorePaths, "ignore", nil, "Field path to exclude from drift detection, e.g. PublicIPAddress or Tags[aws:*] (repeatable)")
+	cmd.Flags().StringVar(&ignoreFile, "ignore-file", "", "File listing field paths to exclude from drift detection, one per line")
+	cmd.Flags().StringArrayVar(&onlyPaths, "only", nil, "Field path to restrict drift detection to, e.g. Type or Tags[Environment]; --ignore still applies within it (repeatable)")
+	cmd.Flags().BoolVar(&strictNil, "strict-nil", false, "Report drift between an unset value and a zero value, such as monitoring unset versus false")
+	cmd.Flags().StringArrayVar(&defaultTags, "default-tags", nil, "Provider default tag as key=value, expected on every instance unless its resource sets the key (repeatable)")
+	cmd.Flags().StringArrayVar(&comparers, "comparer", nil, "Compare a field path with a built-in comparer as Path=name, e.g. AvailabilityZone=ci or RootVolumeSize=tolerance:1 (repeatable)")
+	cmd.Flags().BoolVar(&includeAWSTags, "include-aws-tags", false, "Compare tags with the aws: prefix, which AWS manages and are skipped by default")
+	cmd.Flags().BoolVar(&resolveIAM, "resolve-iam", false, "Read each instance's IAM instance profile from its current association (one extra API call per instance)")
+	cmd.Flags().BoolVar(&resolveAMI, "resolve-ami", false, "Compare a changed AMI by the name, owner and architecture of both images instead of by ID (one DescribeImages call per pair of AMIs)")
+	cmd.Flags().BoolVar(&resolveSubnets, "resolve-subnets", false, "Check that the subnet of an instance in an unexpected availability zone is in the expected one, reporting a subnet in another zone as one finding (one DescribeSubnets call per subnet)")
+	cmd.Flags().BoolVar(&resolveData, "resolve-data-sources", false, "Look up data \"aws_ami\" blocks of --tf-dir in AWS when the directory's state does not record them")
+	cmd.Flags().StringVar(&amiTag, "ami-tag", "", "Image tag also compared by --resolve-ami, e.g. app_version")
+	cmd.Flags().StringVar(&amiMaxAge, "ami-max-age", "", "Note when the AMI an instance runs is older than this, e.g. 90d, or deprecated (one DescribeImages call per AMI); the notes are not drift")
+	cmd.Flags().BoolVar(&failOnAMIAge, "fail-on-ami-age", false, "Exit with an error when --ami-max-age finds an AMI that is too old or deprecated")
+	cmd.Flags().BoolVar(&suggest, "suggest", false, "Suggest how to reconcile each finding, such as terraform apply or the AWS CLI command restoring the expected value")
+	cmd.Flags().BoolVar(&userDataDiff, "user-data-diff", false, "Show a unified diff of the decoded user data when it differs")
+	cmd.Flags().StringVar(&opaPolicyDir, "opa-policy", "", "Directory of Rego policies to evaluate against the report")
+	cmd.Flags().StringVar(&goldenConfig, "golden-config", "", "YAML file listing golden templates and the instances they apply to")
+	cmd.Flags().BoolVar(&failOnGolden, "fail-on-golden", false, "Exit with an error when golden template mismatches are found")
+	cmd.Flags().StringVar(&severityConfig, "severity-config", "", "YAML file assigning severities to drift path prefixes, overriding the defaults")
+	cmd.Flags().StringVar(&minSeverity, "min-severity", string(models.SeverityInfo), "Only report findings at or above this severity (INFO, WARNING, CRITICAL)")
+	cmd.Flags().StringVar(&failOnSeverity, "fail-on-severity", "", "Exit with an error only when a finding at or above this severity is found")
+	cmd.Flags().BoolVar(&strictParse, "strict-parse", false, "Exit with an error when part of the Terraform configuration could not be read; the parts are listed as report warnings")
+	cmd.Flags().BoolVar(&failOnDrift, "fail-on-drift", false, "Exit with an error when the instance has drifted (always the case when checking every instance)")
+	cmd.Flags().BoolVar(&verifyPlan, "verify-plan", false, "Run terraform plan in --tf-dir and fail unless apply would fix all drift")
 
-	// Mark required flags
-	if err := cmd.MarkFlagRequired("instance"); err != nil {
-		return nil
-	}
+	webhook.register(cmd)
+	redact.register(cmd)
+	baseline.register(cmd)
+	outputMode.register(cmd)
+	color.register(cmd)
+	browser.register(cmd)
+	strict.register(cmd)
+	cmd.MarkFlagsMutuallyExclusive("tui", "quiet")
+
+	// Accept --resource-address as a spelling of --resource
+	cmd.Flags().SetNormalizeFunc(func(f *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "resource-address" {
+			name = "resource"
+		}
+		return pflag.NormalizedName(name)
+	})
 
 	// Mark mutually exclusive flags
-	cmd.MarkFlagsOneRequired("state-file", "tf-dir")
-	cmd.MarkFlagsMutuallyExclusive("state-file", "tf-dir")
+	cmd.MarkFlagsOneRequired("state-file", "tf-dir", "tf-plan")
+	cmd.MarkFlagsMutuallyExclusive("state-file", "tf-plan")
+	cmd.MarkFlagsMutuallyExclusive("tf-dir", "tf-plan")
+	cmd.MarkFlagsMutuallyExclusive("verify-plan", "state-file")
+	cmd.MarkFlagsMutuallyExclusive("verify-plan", "tf-plan")
+	cmd.MarkFlagsMutuallyExclusive("instance", "name")
+	cmd.MarkFlagsMutuallyExclusive("resolve-ami", "mock-file")
+	cmd.MarkFlagsMutuallyExclusive("ami-max-age", "mock-file")
+	cmd.MarkFlagsMutuallyExclusive("resolve-subnets", "mock-file")
 
 	return cmd
 }
 
-// outputResults prints the drift report in the specified format
-func outputResults(report *models.DriftReport, format string, showAll, showOnlyDrift bool) error {
-	switch format {
-	case "json":
-		encoder := json.NewEncoder(os.Stdout)
-		encoder.SetIndent("", "  ")
-		return encoder.Encode(report)
-	case "text":
-		return printTextReport(report, showAll, showOnlyDrift)
+// parseMaxAge parses --ami-max-age: a number of days such as 90d, or a
+// duration such as 2160h
+func parseMaxAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d > 0 {
+		return d, nil
+	}
+	return 0, fmt.Errorf("invalid --ami-max-age %q: expected a number of days such as 90d or a duration such as 2160h", value)
+}
+
+// countingSource records how many instance configurations its Source read,
+// for the effective config
+type countingSource struct {
+	appcommands.Source
+	read int
+}
+
+func (s *countingSource) Instances(ctx context.Context) ([]*models.Instance, error) {
+	instances, err := s.Source.Instances(ctx)
+	s.read = len(instances)
+	return instances, err
+}
+
+// sourceFiles lists the Terraform inputs of a run, with the number of
+// instance configurations on the one they were read from: the configuration
+// directory, else the state file, else the plan. A state file given with
+// --tf-dir only resolves references.
+func sourceFiles(stateFile, tfDir, planFile string, entries int) []application.ReferencedFile {
+	files := []application.ReferencedFile{
+		{Role: "state_file", Path: stateFile},
+		{Role: "tf_dir", Path: tfDir},
+		{Role: "tf_plan", Path: planFile},
+	}
+	switch {
+	case tfDir != "":
+		files[1].Entries = entries
+	case stateFile != "":
+		files[0].Entries = entries
 	default:
-		return fmt.Errorf("unsupported output format: %s", format)
+		files[2].Entries = entries
 	}
+	return files
 }
 
-// printTextReport prints the drift report in a human-readable text format
-func printTextReport(report *models.DriftReport, showAll, showOnlyDrift bool) error {
-	fmt.Printf("Drift Report for Instance: %s\n", report.InstanceID)
-	fmt.Printf("Drift Detected: %v\n", report.HasDrifts())
-	fmt.Println(strings.Repeat("-", 80))
+// unaddressedDriftError fails --verify-plan when a report has drift that
+// terraform apply would not fix
+func unaddressedDriftError(reports []*models.DriftReport) error {
+	findings := 0
+	for _, report := range reports {
+		findings += len(report.UnaddressedDrifts())
+	}
+	if findings > 0 {
+		return fmt.Errorf("%d drift finding(s) not addressed by Terraform", findings)
+	}
+	return nil
+}
+
+// goldenMismatchError fails --fail-on-golden when a report differs from its
+// golden template
+func goldenMismatchError(reports []*models.DriftReport) error {
+	findings := 0
+	for _, report := range reports {
+		findings += len(report.GoldenMismatches())
+	}
+	if findings > 0 {
+		return fmt.Errorf("%d golden template mismatch(es) found", findings)
+	}
+	return nil
+}
+
+// staleAMIError fails --fail-on-ami-age when a report notes a stale AMI
+func staleAMIError(reports []*models.DriftReport) error {
+	findings := 0
+	for _, report := range reports {
+		findings += len(report.StaleAMIs())
+	}
+	if findings > 0 {
+		return fmt.Errorf("%d stale AMI finding(s) found", findings)
+	}
+	return nil
+}
+
+// parseDefaultTags splits the key=value pairs given with --default-tags
+func parseDefaultTags(values []string) (map[string]string, error) {
+	tags := make(map[string]string, len(values))
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --default-tags %q: expected key=value", v)
+		}
+		tags[key] = value
+	}
+	return tags, nil
+}
+
+// parseComparers converts --comparer values of the form Path=name into
+// detector options
+func parseComparers(values []string) ([]driftdetector.Option, error) {
+	opts := make([]driftdetector.Option, 0, len(values))
+	for _, v := range values {
+		path, name, ok := strings.Cut(v, "=")
+		path = strings.TrimSpace(path)
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid --comparer %q: expected Path=name", v)
+		}
+		comparer, err := driftdetector.ComparerByName(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --comparer %q: %w", v, err)
+		}
+		opts = append(opts, driftdetector.WithComparer(path, comparer))
+	}
+	return opts, nil
+}
+
+// parseWarningsError returns an error counting the parse warnings of the
+// desired instances, or nil when there are none
+func parseWarningsError(desired []*models.Instance) error {
+	count := 0
+	for _, instance := range desired {
+		if instance != nil {
+			count += len(instance.ParseWarnings)
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d Terraform configuration parse warning(s) found", count)
+}
+
+// failOnSeverityLevel returns an error if any report has a finding at or above level
+func failOnSeverityLevel(reports []*models.DriftReport, level models.Severity) error {
+	findings := 0
+	for _, report := range reports {
+		for _, d := range report.FilterBySeverity(level).Drifts {
+			// Advisory findings fail only with their own flag, such as
+			// --fail-on-golden or --fail-on-ami-age
+			if !d.Type.Advisory() {
+				findings++
+			}
+		}
+	}
+	if findings > 0 {
+		return fmt.Errorf("%d drift finding(s) at or above %s severity", findings, level)
+	}
+	return nil
+}
+
+// printUserDataDiff writes a unified diff of the decoded user data if the report
+// contains user data drift and redactor does not hide user data
+func printUserDataDiff(w io.Writer, report *models.DriftReport, actual, desired *models.Instance, redactor *services.Redactor) error {
+	drifted := false
+	for _, d := range report.Drifts {
+		if d.Path == "UserData" {
+			drifted = true
+			break
+		}
+	}
+	if !drifted {
+		return nil
+	}
+
+	if redactor.Redacts("UserData") {
+		fmt.Fprintln(w, "User data diff hidden: UserData is redacted (use --no-redact to show it)")
+		return nil
+	}
+
+	if models.IsUserDataHash(desired.UserData) {
+		fmt.Fprintln(w, "User data diff unavailable: Terraform state only records a hash of user_data")
+		return nil
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(desired.NormalizedUserData() + "\n"),
+		B:        difflib.SplitLines(actual.NormalizedUserData() + "\n"),
+		FromFile: "terraform",
+		ToFile:   "aws",
+		Context:  3,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to diff user data: %w", err)
+	}
+
+	fmt.Fprint(w, diff)
+	return nil
+}
+
+// outputResults writes the drift report to w in the specified format
+func outputResults(w io.Writer, report *models.DriftReport, format string, showAll, showOnlyDrift bool, opts ...driftdetector.FormatterOption) error {
+	if format == string(driftdetector.FormatText) {
+		return printTextReport(w, report, persistence.TextPalette(opts...), showAll, showOnlyDrift)
+	}
+
+	formatter, err := driftdetector.NewFormatter(driftdetector.FormatType(format), opts...)
+	if err != nil {
+		return err
+	}
+
+	out, err := formatter.Format(report)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, out)
+	return nil
+}
+
+// outputAllResults writes the drift reports for several instances to w,
+// grouped by instance ID under a summary of the counts
+func outputAllResults(w io.Writer, aggregate *models.AggregateReport, format string, showAll, showOnlyDrift bool, opts ...driftdetector.FormatterOption) error {
+	if format != string(driftdetector.FormatText) {
+		out, err := driftdetector.FormatAggregate(driftdetector.FormatType(format), aggregate, opts...)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w, out)
+		return nil
+	}
+
+	palette := persistence.TextPalette(opts...)
+	summary := aggregate.Summary()
+	fmt.Fprintln(w, palette.Summary(summary.String(), summary.Drifted > 0 || summary.Errors > 0))
+	for _, failure := range aggregate.Failures {
+		fmt.Fprintf(w, "Error: %s\n", failure)
+	}
+	fmt.Fprintln(w)
+	for _, report := range aggregate.Reports {
+		if err := printTextReport(w, report, palette, showAll, showOnlyDrift); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// printTextReport writes the drift report to w in a human-readable text
+// format, colored with palette
+func printTextReport(w io.Writer, report *models.DriftReport, palette persistence.Palette, showAll, showOnlyDrift bool) error {
+	fmt.Fprintf(w, "Drift Report for Instance: %s\n", report.InstanceID)
+	fmt.Fprintf(w, "Drift Detected: %v\n", report.HasDrifts())
+	if report.Metadata != nil && report.Metadata.EffectiveConfig != nil {
+		fmt.Fprintf(w, "Config: %s\n", report.Metadata.EffectiveConfig.Summary())
+	}
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+	if report.SuppressedByLifecycle > 0 {
+		fmt.Fprintf(w, "Suppressed: %d finding(s) in fields listed in lifecycle ignore_changes\n", report.SuppressedByLifecycle)
+	}
+	if len(report.Acknowledged) > 0 {
+		fmt.Fprintf(w, "Acknowledged: %d finding(s) accepted in the baseline\n", len(report.Acknowledged))
+		for _, d := range report.Acknowledged {
+			// Findings about the whole instance have no path
+			subject := d.Path
+			if subject == "" {
+				subject = d.Description
+			}
+			fmt.Fprintf(w, "  %s (%s)\n", subject, palette.DriftType(d.Type))
+		}
+	}
+	fmt.Fprintln(w, strings.Repeat("-", 80))
 
 	if len(report.Drifts) == 0 {
-		fmt.Println("No configuration drift detected.")
+		fmt.Fprintln(w, palette.Summary("No configuration drift detected.", false))
 		return nil
 	}
 
@@ -137,22 +1028,34 @@ func printTextReport(report *models.DriftReport, showAll, showOnlyDrift bool) er
 		}
 
 		// Print drift details
-		fmt.Printf("Path: %s\n", d.Path)
+		fmt.Fprintf(w, "Path: %s\n", d.Path)
 		if d.Type != "" {
-			fmt.Printf("Type: %s\n", d.Type)
+			fmt.Fprintf(w, "Type: %s\n", palette.DriftType(d.Type))
+		}
+		if d.Severity != "" {
+			fmt.Fprintf(w, "Severity: %s\n", palette.Severity(d.Severity))
 		}
 
 		// Print expected/actual values if available
 		if d.Expected != nil {
-			fmt.Printf("Expected: %v\n", d.Expected)
+			fmt.Fprintf(w, "Expected: %v\n", d.Expected)
 		}
 		if d.Actual != nil {
-			fmt.Printf("Actual:   %v\n", d.Actual)
+			fmt.Fprintf(w, "Actual:   %v\n", d.Actual)
 		}
 		if d.Description != "" {
-			fmt.Printf("Details:  %s\n", d.Description)
+			fmt.Fprintf(w, "Details:  %s\n", d.Description)
+		}
+		if d.PlanStatus != "" {
+			fmt.Fprintf(w, "Plan:     %s\n", d.PlanStatus)
+		}
+		if d.Source != nil {
+			fmt.Fprintf(w, "Defined at %s\n", d.Source)
+		}
+		if d.Remediation != "" {
+			fmt.Fprintf(w, "Suggest:  %s\n", d.Remediation)
 		}
-		fmt.Println(strings.Repeat("-", 40))
+		fmt.Fprintln(w, strings.Repeat("-", 40))
 	}
 
 	return nil
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectDDD_VerifyPlanFlags(t *testing.T) {
+	tests := map[string]struct {
+		args     []string
+		expected string
+	}{
+		"with a rendered plan": {
+			args:     []string{"--verify-plan", "--tf-plan", mockTestsDir + "plan.json"},
+			expected: "[tf-plan verify-plan] were all set",
+		},
+		"with a state file": {
+			args:     []string{"--verify-plan", "--state-file", mockTestsDir + "batch.tfstate"},
+			expected: "[state-file verify-plan] were all set",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			// When --verify-plan is given without a configuration directory
+			_, _, err := execute(t, append([]string{"detect-ddd", "-i", "i-1"}, tt.args...)...)
+
+			// Then the run is refused before terraform plan could run
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expected)
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"driftdetector/application"
+	appcommands "driftdetector/application/commands"
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/config"
+	"driftdetector/infrastructure/terraform"
+	"driftdetector/pkg/driftdetector"
+)
+
+// NewDiffCmd creates a command that compares the instances of two Terraform
+// states or instance snapshots with each other, without calling AWS
+func NewDiffCmd() *cobra.Command {
+	var (
+		left          string
+		right         string
+		stateRegion   string
+		outputFile    string
+		showAll       bool
+		showOnlyDrift bool
+		ignorePaths   []string
+		ignoreFile    string
+		onlyPaths     []string
+		failOnDrift   bool
+		redact        redactFlags
+		outputMode    outputModeFlags
+		strict        strictFlags
+		strictState   strictStateFlag
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare two Terraform states or instance snapshots",
+		Long: `Compare the EC2 instances of two Terraform state files, or of a state file and
+an instance JSON written by snapshot, without calling AWS. Each side may be
+either kind of file; they are told apart by content.
+
+Instances are paired by ID, then by resource address, and compared with the
+left side as expected and the right side as actual. Instances found on one
+side only are reported as added or removed.`,
+		Example: `  driftdetector diff --left terraform.old.tfstate --right terraform.tfstate
+  driftdetector diff --left terraform.tfstate --right snapshots/i-1234567890abcdef0.json -o json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Reject unknown output formats before reading any files
+			if _, err := driftdetector.NewFormatter(driftdetector.FormatType(outputFmt)); err != nil {
+				return fmt.Errorf("invalid --output: %w", err)
+			}
+
+			if left == terraform.StdinState && right == terraform.StdinState {
+				return errors.New("--left and --right cannot both read stdin")
+			}
+
+			redactor, err := redact.redactor()
+			if err != nil {
+				return err
+			}
+
+			ignored := append([]string{}, ignorePaths...)
+			if ignoreFile != "" {
+				filePaths, err := config.LoadIgnoreFile(ignoreFile)
+				if err != nil {
+					return err
+				}
+				ignored = append(ignored, filePaths...)
+			}
+
+			detector, err := driftdetector.New(append(strict.options(),
+				driftdetector.WithIgnoredFields(ignored...),
+				driftdetector.WithOnlyFields(onlyPaths...),
+			)...)
+			if err != nil {
+				return err
+			}
+
+			containerOpts := []application.ContainerOption{
+				application.WithRegion(awsRegion),
+				application.WithProfile(awsProfile),
+				application.WithStateRegion(stateRegion),
+				application.WithDetectionService(detector.Service()),
+				application.WithStdin(cmd.InOrStdin()),
+			}
+			containerOpts = append(containerOpts, strictState.options()...)
+			// AWS is only needed to download remote state
+			if !terraform.IsRemoteState(left) && !terraform.IsRemoteState(right) {
+				containerOpts = append(containerOpts, application.WithoutAWS())
+			}
+
+			container, err := application.NewContainer(cmd.Context(), containerOpts...)
+			if err != nil {
+				return fmt.Errorf("failed to initialize application container: %w", err)
+			}
+
+			leftSource, err := application.NewFileSource(container.GetTerraformRepository(), left)
+			if err != nil {
+				return fmt.Errorf("invalid --left: %w", err)
+			}
+			rightSource, err := application.NewFileSource(container.GetTerraformRepository(), right)
+			if err != nil {
+				return fmt.Errorf("invalid --right: %w", err)
+			}
+
+			handler := appcommands.NewDiffHandler(container.GetDetectionService())
+			results, err := handler.Handle(cmd.Context(), appcommands.DiffCommand{Left: leftSource, Right: rightSource})
+			if err != nil {
+				return err
+			}
+			if len(results) == 0 {
+				return errors.New("no EC2 instances found on either side")
+			}
+
+			reports := make([]*models.DriftReport, 0, len(results))
+			for _, result := range results {
+				reports = append(reports, redactor.Redact(detector.Classify(result.Report)))
+			}
+			aggregate := models.NewAggregateReport(reports, nil)
+
+			if !outputMode.quiet {
+				err = writeOutput(cmd.Context(), outputFileWriter(outputFile), "", func(w io.Writer) error {
+					if outputMode.summary {
+						return writeSummary(w, aggregate, outputFmt)
+					}
+					return outputAllResults(w, aggregate, outputFmt, showAll, showOnlyDrift)
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			if failOnDrift && aggregate.Drifted > 0 {
+				return outputMode.outcome(cmd, fmt.Errorf("differences found in %d of %d instance(s)", aggregate.Drifted, aggregate.TotalInstances))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&left, "left", "", "Terraform state or instance snapshot holding the expected configuration (local path or s3://bucket/key), or - for a state on stdin")
+	cmd.Flags().StringVar(&right, "right", "", "Terraform state or instance snapshot compared against --left (local path or s3://bucket/key), or - for a state on stdin")
+	strictState.register(cmd)
+	cmd.Flags().StringVar(&stateRegion, "tf-state-region", "", "Region of the S3 bucket holding remote state (default: the AWS region)")
+	cmd.Flags().StringVar(&outputFile, "output-file", "", "Write the report to this file instead of stdout")
+	cmd.Flags().BoolVar(&showAll, "all", false, "Show all fields, even those without differences")
+	cmd.Flags().BoolVar(&showOnlyDrift, "only-drift", false, "Show only fields with differences")
+	cmd.Flags().StringArrayVar(&ignorePaths, "ignore", nil, "Field path to exclude from the comparison, e.g. PublicIPAddress or Tags[aws:*] (repeatable)")
+	cmd.Flags().StringVar(&ignoreFile, "ignore-file", "", "File listing field paths to exclude from the comparison, one per line")
+	cmd.Flags().StringArrayVar(&onlyPaths, "only", nil, "Field path to restrict the comparison to, e.g. Type or Tags[Environment]; --ignore still applies within it (repeatable)")
+	cmd.Flags().BoolVar(&failOnDrift, "fail-on-drift", false, "Exit with an error when the two sides differ")
+	redact.register(cmd)
+	outputMode.register(cmd)
+	strict.register(cmd)
+	_ = cmd.MarkFlagRequired("left")
+	_ = cmd.MarkFlagRequired("right")
+
+	return cmd
+}
@@ -1,20 +1,35 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"text/tabwriter"
 
 	"github.com/spf13/cobra"
 	"driftdetector/application"
+	appcommands "driftdetector/application/commands"
 	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/infrastructure/persistence"
+	"driftdetector/infrastructure/terraform"
 )
 
 // NewListDDDCmd creates a new list command using the DDD structure
 func NewListDDDCmd() *cobra.Command {
 	var (
-		tfState string
-		tfDir   string
+		tfState        string
+		tfDir          string
+		workspace      workspaceFlags
+		strictState    strictStateFlag
+		stateRegion    string
+		varFiles       []string
+		vars           []string
+		filters        []string
+		withStatus     bool
+		maxConcurrency int
 	)
 
 	cmd := &cobra.Command{
@@ -23,40 +38,102 @@ func NewListDDDCmd() *cobra.Command {
 		Long: `List all EC2 instances that are managed by Terraform in the specified
 state file or directory. This helps identify which instances can be checked for drift.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Initialize application container
-			container, err := application.NewContainer(cmd.Context())
+			format := persistence.FormatType(outputFmt)
+			if format != persistence.FormatText && format != persistence.FormatJSON {
+				return fmt.Errorf("invalid --output: list supports text and json, not %s", format)
+			}
+
+			filter, err := configFilter(filters)
 			if err != nil {
-				return fmt.Errorf("failed to initialize application container: %w", err)
+				return err
 			}
 
-			tfRepo := container.GetTerraformRepository()
-			ctx := cmd.Context()
+			tfVars, err := terraformVariablesOption(varFiles, vars)
+			if err != nil {
+				return err
+			}
 
-			var instances []*models.Instance
+			// --tf-dir defaults to the working directory, which is only read without --tf-state
+			workspaceDir := tfDir
 			if tfState != "" {
-				instances, err = tfRepo.GetInstanceConfigs(ctx, tfState)
-			} else {
-				instances, err = tfRepo.GetInstanceConfigsFromDir(ctx, tfDir)
+				workspaceDir = ""
+			}
+			tfWorkspace, err := workspace.option(cmd, workspaceDir)
+			if err != nil {
+				return err
+			}
+
+			containerOpts := []application.ContainerOption{
+				application.WithRegion(awsRegion),
+				application.WithProfile(awsProfile),
+				application.WithStateRegion(stateRegion),
+				tfVars,
+				tfWorkspace,
+				application.WithStdin(cmd.InOrStdin()),
+			}
+			containerOpts = append(containerOpts, strictState.options()...)
+			// Listing only needs AWS to download remote state, unless the
+			// instances are compared
+			if withStatus {
+				awsConfig, err := awsConfigOption(cmd.Context())
+				if err != nil {
+					return err
+				}
+				containerOpts = append(containerOpts, awsConfig)
+			} else if !terraform.IsRemoteState(tfState) {
+				containerOpts = append(containerOpts, application.WithoutAWS())
 			}
 
+			// Initialize application container
+			container, err := application.NewContainer(cmd.Context(), containerOpts...)
+			if err != nil {
+				return fmt.Errorf("failed to initialize application container: %w", err)
+			}
+
+			source := appcommands.NewTerraformSource(container.GetTerraformRepository(), tfState, tfDir, "")
+			instances, err := source.Instances(cmd.Context())
 			if err != nil {
 				return fmt.Errorf("failed to list instances from Terraform: %w", err)
 			}
 
+			matching := instances[:0]
+			for _, instance := range instances {
+				if instance != nil && filter.Matches(instance) {
+					matching = append(matching, instance)
+				}
+			}
+			instances = matching
+
+			// Instances are only read from AWS to compare them; a failure
+			// is listed as the instance's status instead of stopping the list
+			var statuses []*appcommands.InstanceStatus
+			if withStatus && len(instances) > 0 {
+				handler := appcommands.NewInstanceStatusHandler(container.GetDetectionService(), container.GetInstanceRepository(), maxConcurrency)
+				statuses = handler.Handle(cmd.Context(), instances)
+			}
+
+			if format == persistence.FormatJSON {
+				return printListedInstancesJSON(os.Stdout, instances, statuses)
+			}
+
 			// Display results
+			if len(instances) == 0 && len(filters) > 0 {
+				fmt.Println("No EC2 instance configurations in the Terraform files match the filters.")
+				return nil
+			}
 			if len(instances) == 0 {
 				fmt.Println("No EC2 instance configurations found in the Terraform files.")
 				return nil
 			}
 
 			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-			fmt.Fprintln(w, "INSTANCE ID\tINSTANCE TYPE\tAMI\tTAGS")
-
-			for _, instance := range instances {
-				if instance == nil {
-					continue
-				}
+			header := "INSTANCE ID\tRESOURCE\tINSTANCE TYPE\tAMI\tTAGS"
+			if statuses != nil {
+				header += "\tSTATUS"
+			}
+			fmt.Fprintln(w, header)
 
+			for i, instance := range instances {
 				// Get tags as a string
 				tagsStr := ""
 				for k, v := range instance.Tags {
@@ -72,8 +149,11 @@ state file or directory. This helps identify which instances can be checked for
 				if instanceType == "" {
 					instanceType = "-"
 				}
+				resource := instance.ResourceAddress
+				if resource == "" {
+					resource = "-"
+				}
 				ami := instance.AMI
-				ami = "-"
 				if ami == "" {
 					ami = "-"
 				}
@@ -81,25 +161,96 @@ state file or directory. This helps identify which instances can be checked for
 					tagsStr = "-"
 				}
 
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", 
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s", 
 					instanceID,
+					resource,
 					instanceType,
 					ami,
 					tagsStr,
 				)
+				if statuses != nil {
+					fmt.Fprintf(w, "\t%s", statuses[i])
+				}
+				fmt.Fprintln(w)
 			}
 
 			w.Flush()
+
+			// Errors are explained below the table, where they do not widen it
+			for i, status := range statuses {
+				if status.Status == appcommands.StatusError {
+					fmt.Fprintf(os.Stderr, "Error: %s: %s\n", instances[i].ID, status.Error)
+				}
+			}
 			return nil
 		},
 	}
 
 	// Add flags
-	cmd.Flags().StringVarP(&tfState, "tf-state", "s", "", "Path to Terraform state file")
+	cmd.Flags().StringVarP(&tfState, "tf-state", "s", "", "Path to Terraform state file or s3://bucket/key URL; - reads stdin, e.g. from terraform state pull")
+	cmd.Flags().StringVar(&stateRegion, "tf-state-region", "", "Region of the S3 bucket holding remote state (default: the AWS region)")
 	cmd.Flags().StringVarP(&tfDir, "tf-dir", "d", ".", "Path to Terraform configuration directory")
+	workspace.register(cmd)
+	strictState.register(cmd)
+
+	cmd.Flags().StringArrayVar(&varFiles, "var-file", nil, "Terraform variable file used to evaluate --tf-dir, after terraform.tfvars and *.auto.tfvars (repeatable)")
+	cmd.Flags().StringArrayVar(&vars, "var", nil, "Terraform variable as name=value, overriding variable files (repeatable)")
+	cmd.Flags().StringArrayVar(&filters, "filter", nil, "Configuration filter as key=value, e.g. instance-type=t3.*, subnet-id=subnet-abc or tag:Environment=prod; all must match (repeatable)")
+
+	cmd.Flags().BoolVar(&withStatus, "with-status", false, "Compare each instance with an ID in the state with AWS and add a STATUS column: IN_SYNC, DRIFTED(n), NOT_FOUND, ERROR, or UNKNOWN without an ID")
+	cmd.Flags().IntVar(&maxConcurrency, "max-concurrency", 10, "Maximum number of AWS requests in flight with --with-status")
 
 	// Mark flags as mutually exclusive
 	cmd.MarkFlagsMutuallyExclusive("tf-state", "tf-dir")
 
 	return cmd
 }
+
+// configFilter parses --filter expressions for Terraform configurations,
+// which have no state or launch time to filter on
+func configFilter(expressions []string) (repositories.InstanceFilter, error) {
+	filter, err := repositories.ParseInstanceFilter(expressions)
+	if err != nil {
+		return filter, fmt.Errorf("invalid --filter: %w", err)
+	}
+	if len(filter.States) > 0 || !filter.LaunchedBefore.IsZero() || !filter.LaunchedAfter.IsZero() {
+		return filter, errors.New("invalid --filter: Terraform configurations have no instance state or launch time to filter on")
+	}
+	return filter, nil
+}
+
+// listedInstance is one configuration listed as JSON, with its status when
+// the instances were compared
+type listedInstance struct {
+	InstanceID      string            `json:"instance_id,omitempty"`
+	ResourceAddress string            `json:"resource_address,omitempty"`
+	InstanceType    string            `json:"instance_type,omitempty"`
+	AMI             string            `json:"ami,omitempty"`
+	Tags            map[string]string `json:"tags,omitempty"`
+	*appcommands.InstanceStatus
+}
+
+// printListedInstancesJSON writes instances to out as a JSON array; statuses,
+// when not nil, hold the status of each instance
+func printListedInstancesJSON(out io.Writer, instances []*models.Instance, statuses []*appcommands.InstanceStatus) error {
+	listed := make([]listedInstance, len(instances))
+	for i, instance := range instances {
+		listed[i] = listedInstance{
+			InstanceID:      instance.ID,
+			ResourceAddress: instance.ResourceAddress,
+			InstanceType:    instance.Type,
+			AMI:             instance.AMI,
+			Tags:            instance.Tags,
+		}
+		if statuses != nil {
+			listed[i].InstanceStatus = statuses[i]
+		}
+	}
+
+	data, err := json.MarshalIndent(listed, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal instances: %w", err)
+	}
+	fmt.Fprintln(out, string(data))
+	return nil
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"driftdetector/infrastructure/mock"
+	legacy "driftdetector/models"
+)
+
+// stdinMockFile is the --mock-file path that reads instances from stdin
+const stdinMockFile = "-"
+
+// mockFormatUsage describes the --mock-format flag
+const mockFormatUsage = "Format of the mock file, json or yaml (default: by extension, json for stdin)"
+
+// loadMockConfigs reads the instance configurations of a --mock-file, which
+// is - for stdin. format, when set, overrides the format otherwise chosen by
+// the file's extension.
+func loadMockConfigs(cmd *cobra.Command, path, format string) ([]*legacy.InstanceConfig, error) {
+	if path != stdinMockFile && format == "" {
+		return mock.LoadInstanceConfigs(path)
+	}
+
+	parsed := mock.FormatJSON
+	if format != "" {
+		var err error
+		if parsed, err = mock.ParseFormat(format); err != nil {
+			return nil, fmt.Errorf("invalid --mock-format: %w", err)
+		}
+	}
+
+	if path == stdinMockFile {
+		return mock.ReadInstanceConfigs(cmd.InOrStdin(), "stdin", parsed)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading mock file: %w", err)
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.IsDir() {
+		return nil, fmt.Errorf("--mock-format cannot be used with the mock directory %s, whose files are read by extension", path)
+	}
+	return mock.ReadInstanceConfigs(f, path, parsed)
+}
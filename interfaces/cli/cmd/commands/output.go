@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"driftdetector/application"
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/awsutil"
+	"driftdetector/infrastructure/persistence"
+	"driftdetector/pkg/driftdetector"
+)
+
+// aggregateReportName is the name, before its extension, of the object
+// holding the results of every instance when uploading with --output-s3
+const aggregateReportName = "aggregate"
+
+// outputFlags holds the flags that choose where a command writes its report
+type outputFlags struct {
+	file string
+	s3   string
+}
+
+// register adds the output flags to cmd; what names the output in their help
+func (f *outputFlags) register(cmd *cobra.Command, what string) {
+	cmd.Flags().StringVar(&f.file, "output-file", "", "Write the "+what+" to this file instead of stdout, creating its directory if needed")
+	cmd.Flags().StringVar(&f.s3, "output-s3", "", "Upload the "+what+" to this s3://bucket/prefix/, as an object per instance and an aggregate")
+	cmd.MarkFlagsMutuallyExclusive("output-file", "output-s3")
+}
+
+// toStdout reports whether the report is printed on stdout
+func (f *outputFlags) toStdout() bool {
+	return f.file == "" && f.s3 == ""
+}
+
+// writer builds the writer the report is delivered with. Uploads to
+// --output-s3 that still fail after retries are printed on stdout instead,
+// with a warning, so that the report is not lost.
+func (f *outputFlags) writer(ctx context.Context) (persistence.Writer, error) {
+	if f.s3 == "" {
+		return outputFileWriter(f.file), nil
+	}
+	uploads, err := s3OutputWriter(ctx, f.s3)
+	if err != nil {
+		return nil, err
+	}
+	return persistence.NewFallbackWriter(uploads, persistence.NewStreamWriter(os.Stdout)), nil
+}
+
+// outputFileWriter writes to the file at path, or to stdout when it is empty
+func outputFileWriter(path string) persistence.Writer {
+	if path == "" {
+		return persistence.NewStreamWriter(os.Stdout)
+	}
+	return persistence.NewFileWriter(path)
+}
+
+// s3OutputWriter uploads to location with the AWS config of --region and
+// --profile, loaded on the first upload, attempting each upload
+// --max-attempts times
+func s3OutputWriter(ctx context.Context, location string) (*persistence.S3Writer, error) {
+	container, err := application.NewContainer(ctx,
+		application.WithRegion(awsRegion),
+		application.WithProfile(awsProfile),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize application container: %w", err)
+	}
+
+	retry := awsutil.DefaultRetryOptions()
+	if maxAttempts > 0 {
+		retry.MaxAttempts = maxAttempts
+	}
+	w, err := persistence.NewS3Writer(container.GetReportUploader(), location, persistence.WithS3RetryOptions(retry))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --output-s3: %w", err)
+	}
+	return w, nil
+}
+
+// writeOutput renders the output with write and delivers it to sink as name
+func writeOutput(ctx context.Context, sink persistence.Writer, name string, write func(w io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return err
+	}
+	return sink.Write(ctx, name, buf.Bytes())
+}
+
+// writeInstanceReports delivers each report to sink on its own, named after
+// its instance and, unless at is zero, the time it was checked. It is used
+// with --output-s3, next to the aggregate.
+func writeInstanceReports(ctx context.Context, sink persistence.Writer, reports []*models.DriftReport, format string, at time.Time, opts ...driftdetector.FormatterOption) error {
+	for _, report := range reports {
+		name := persistence.ReportFileName(report.InstanceID, at, persistence.FormatType(format))
+		err := writeOutput(ctx, sink, name, func(w io.Writer) error {
+			return outputResults(w, report, format, true, false, opts...)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
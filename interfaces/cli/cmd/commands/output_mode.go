@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/persistence"
+)
+
+// outputModeFlags holds the flags that shorten the report for CI logs
+type outputModeFlags struct {
+	summary bool
+	quiet   bool
+}
+
+// register adds the output mode flags to cmd
+func (f *outputModeFlags) register(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.summary, "summary", false, "Print one line per instance with its counts by drift type and highest severity, then the totals, instead of the full report")
+	cmd.Flags().BoolVarP(&f.quiet, "quiet", "q", false, "Print no report and no drift error; the exit code tells whether drift was found")
+	cmd.MarkFlagsMutuallyExclusive("summary", "quiet")
+}
+
+// writeSummary writes the summary of aggregate to w, as printed with --summary
+func writeSummary(w io.Writer, aggregate *models.AggregateReport, format string) error {
+	out, err := persistence.FormatSummary(persistence.FormatType(format), aggregate)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, out)
+	return nil
+}
+
+// outcome returns err, which reports the outcome of a check such as
+// --fail-on-drift rather than a misuse of the command, so it is printed once
+// by main without the usage text, and only sets the exit code with --quiet
+func (f *outputModeFlags) outcome(cmd *cobra.Command, err error) error {
+	if err == nil {
+		return nil
+	}
+	cmd.SilenceErrors = true
+	cmd.SilenceUsage = true
+	if !f.quiet {
+		return err
+	}
+	return &silentError{err: err}
+}
+
+// silentError is a command error that is not printed
+type silentError struct {
+	err error
+}
+
+func (e *silentError) Error() string { return e.err.Error() }
+
+func (e *silentError) Unwrap() error { return e.err }
+
+// IsSilent reports whether err should set the exit code without being printed
+func IsSilent(err error) bool {
+	var silent *silentError
+	return errors.As(err, &silent)
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mockTestsDir = "../../../../testdata/mock_tests/"
+
+// testRoot is the root command, whose subcommands may only be added once
+var testRoot = sync.OnceValue(NewRootCmd)
+
+// execute runs the command line args and returns what it printed. Flags
+// are reset afterwards, so each run starts from the defaults.
+func execute(t *testing.T, args ...string) (stdout, stderr string, err error) {
+	t.Helper()
+
+	root := testRoot()
+	t.Cleanup(func() { resetCommand(root) })
+
+	var out, errOut bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&errOut)
+	root.SetArgs(args)
+	err = root.ExecuteContext(context.Background())
+	return out.String(), errOut.String(), err
+}
+
+// resetCommand restores the flags of cmd and its subcommands to their
+// defaults and clears what a run left on the commands
+func resetCommand(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if slice, ok := f.Value.(pflag.SliceValue); ok {
+			_ = slice.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	cmd.SilenceErrors = false
+	cmd.SilenceUsage = false
+	// A context left from a run is kept by the next; nil takes the new one
+	cmd.SetContext(nil)
+	for _, sub := range cmd.Commands() {
+		resetCommand(sub)
+	}
+}
+
+func TestOutcome_NoUsageText(t *testing.T) {
+	tests := map[string][]string{
+		"drift when checking every instance": {"--summary"},
+		"fail on severity":                   {"--summary", "--fail-on-severity", "INFO"},
+	}
+
+	for name, flags := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Given mock instances that have drifted
+			args := append([]string{"detect-ddd", "--mock-file", mockTestsDir + "batch", "--state-file", mockTestsDir + "batch.tfstate"}, flags...)
+
+			// When the check fails the run
+			stdout, stderr, err := execute(t, args...)
+
+			// Then the error is left for main to print once, without usage
+			require.Error(t, err)
+			assert.False(t, IsSilent(err))
+			assert.NotContains(t, stderr, "Usage:")
+			assert.NotContains(t, stdout, "Usage:")
+			assert.NotContains(t, stderr, "Error:")
+		})
+	}
+
+	t.Run("quiet", func(t *testing.T) {
+		_, stderr, err := execute(t, "detect-ddd", "--mock-file", mockTestsDir+"batch", "--state-file", mockTestsDir+"batch.tfstate", "--quiet")
+
+		require.Error(t, err)
+		assert.True(t, IsSilent(err))
+		assert.Empty(t, stderr)
+	})
+
+	t.Run("misuse still prints usage", func(t *testing.T) {
+		stdout, stderr, err := execute(t, "detect-ddd", "--no-such-flag")
+
+		require.Error(t, err)
+		assert.Contains(t, stdout+stderr, "Usage:")
+	})
+}
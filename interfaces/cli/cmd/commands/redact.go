@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"driftdetector/domain/services"
+)
+
+// redactFlags holds the flags that hide sensitive values in reports
+type redactFlags struct {
+	paths    []string
+	disabled bool
+}
+
+// register adds the redaction flags to cmd
+func (f *redactFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&f.paths, "redact", nil, "Field path whose values are replaced with a fingerprint in the report, in addition to "+strings.Join(services.DefaultRedactedPaths, ", ")+" (repeatable)")
+	cmd.Flags().BoolVar(&f.disabled, "no-redact", false, "Show sensitive values in full, e.g. for local debugging")
+	cmd.MarkFlagsMutuallyExclusive("redact", "no-redact")
+}
+
+// redactor builds the configured redactor, or returns nil with --no-redact
+func (f *redactFlags) redactor() (*services.Redactor, error) {
+	if f.disabled {
+		return nil, nil
+	}
+	r, err := services.NewRedactor(f.paths...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --redact: %w", err)
+	}
+	return r, nil
+}
@@ -1,15 +1,40 @@
 package cmd
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
+	"driftdetector/application"
+	"driftdetector/infrastructure/cache"
+	"driftdetector/infrastructure/config"
+	"driftdetector/infrastructure/logger"
+	"driftdetector/infrastructure/terraform"
 )
 
 // Global flags
 var (
-	awsRegion string
-	outputFmt string
+	awsRegion   string
+	awsProfile  string
+	outputFmt   string
+	verbose     bool
+	logLevel    string
+	logFormat   string
+	configFile  string
+	maxAttempts int
+
+	assumeRoleARN string
+	externalID    string
+
+	cacheDir     string
+	cacheTTL     time.Duration
+	refreshCache bool
+
+	requireFullAccess bool
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -22,6 +47,16 @@ between your AWS EC2 instances and their corresponding Terraform configurations.
 It can detect changes in instance types, security groups, tags, and other
 configuration parameters that might have been modified outside of your
 infrastructure as code.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := configureLogger(); err != nil {
+			return err
+		}
+		// The config file may set the log level, so the logger is configured again
+		if err := applyConfigDefaults(cmd); err != nil {
+			return err
+		}
+		return configureLogger()
+	},
 }
 
 // NewRootCmd creates a new root command
@@ -32,6 +67,14 @@ func NewRootCmd() *cobra.Command {
 	// Add commands
 	rootCmd.AddCommand(NewListDDDCmd())   // DDD-based list command
 	rootCmd.AddCommand(NewDetectDDDCmd()) // DDD-based detect command
+	rootCmd.AddCommand(NewScanCmd())
+	rootCmd.AddCommand(NewDiffCmd())
+	rootCmd.AddCommand(NewAuditCmd())
+	rootCmd.AddCommand(NewSnapshotCmd())
+	rootCmd.AddCommand(NewValidateMockCmd())
+	rootCmd.AddCommand(NewServeCmd())
+	rootCmd.AddCommand(NewWatchCmd())
+	rootCmd.AddCommand(NewTrendCmd())
 	rootCmd.AddCommand(NewVersionCmd())
 	
 	return rootCmd
@@ -47,6 +90,135 @@ func Execute() {
 
 func init() {
 	// Global flags
-	rootCmd.PersistentFlags().StringVarP(&awsRegion, "region", "r", "", "AWS region (defaults to AWS_REGION environment variable)")
-	rootCmd.PersistentFlags().StringVarP(&outputFmt, "output", "o", "text", "Output format (text, json)")
+	rootCmd.PersistentFlags().StringVarP(&awsRegion, "region", "r", "", "AWS region (default: AWS_REGION, AWS_DEFAULT_REGION, then the shared config)")
+	rootCmd.PersistentFlags().StringVar(&awsProfile, "profile", "", "AWS shared config profile to use")
+	rootCmd.PersistentFlags().StringVarP(&outputFmt, "output", "o", "text", "Output format (text, json, yaml, html, markdown, csv, sarif)")
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Log debug diagnostics to stderr (same as --log-level debug)")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "warn", "Minimum level logged to stderr (debug, info, warn, error)")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "Format of log entries on stderr (text, json)")
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "", "Config file of flag defaults (default: "+config.CLIConfigFileName+" in the working directory, then the home directory)")
+	rootCmd.PersistentFlags().StringVar(&assumeRoleARN, "assume-role-arn", "", "IAM role assumed with the loaded credentials to read instances, e.g. in another account")
+	rootCmd.PersistentFlags().StringVar(&externalID, "external-id", "", "External ID passed when assuming --assume-role-arn, if its trust policy requires one")
+	rootCmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", "", "Directory caching the instances read from AWS, reused by later runs within --cache-ttl")
+	rootCmd.PersistentFlags().DurationVar(&cacheTTL, "cache-ttl", cache.DefaultTTL, "How long instances cached in --cache-dir are used instead of reading them from AWS")
+	rootCmd.PersistentFlags().BoolVar(&refreshCache, "refresh", false, "Read instances from AWS even if --cache-dir holds fresh copies, and cache them again")
+	rootCmd.PersistentFlags().IntVar(&maxAttempts, "max-attempts", 3, "Times each AWS API call is attempted before a throttling or transient error is reported")
+	rootCmd.PersistentFlags().BoolVar(&requireFullAccess, "require-full-access", false, "Fail when the credentials may not read every setting of an instance, such as its volumes without ec2:DescribeVolumes, instead of leaving those settings uncompared")
+}
+
+// applyConfigDefaults sets the flags of cmd that were not given from the
+// environment and the config file, so flags take precedence over the
+// environment, which takes precedence over the file
+func applyConfigDefaults(cmd *cobra.Command) error {
+	path := configFile
+	if path == "" {
+		cwd, _ := os.Getwd()
+		home, _ := os.UserHomeDir()
+		path = config.FindCLIConfig(cwd, home)
+	}
+
+	var cfg *config.CLIConfig
+	if path != "" {
+		var err error
+		if cfg, err = config.LoadCLIConfig(path); err != nil {
+			return err
+		}
+		logger.Debug("loaded config file", "path", path)
+	}
+	return config.ApplyCLIDefaults(cmd.Flags(), cfg, os.LookupEnv)
+}
+
+// configureLogger applies --verbose, --log-level and --log-format to the logger
+func configureLogger() error {
+	level, err := logger.ParseLevel(logLevel)
+	if err != nil {
+		return fmt.Errorf("invalid --log-level: %w", err)
+	}
+	format, err := logger.ParseFormat(logFormat)
+	if err != nil {
+		return fmt.Errorf("invalid --log-format: %w", err)
+	}
+	if verbose {
+		level = slog.LevelDebug
+	}
+	logger.SetLevel(level)
+	logger.SetFormat(format)
+	return nil
+}
+
+// withTimeout bounds run with the duration timeout points to, read when the
+// command runs so flag values apply; zero means no limit. Running out of time
+// is reported as such rather than as the error of whichever call was cut short.
+func withTimeout(timeout *time.Duration, run func(cmd *cobra.Command, args []string) error) func(cmd *cobra.Command, args []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		if *timeout <= 0 {
+			return run(cmd, args)
+		}
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), *timeout)
+		defer cancel()
+		cmd.SetContext(ctx)
+
+		err := run(cmd, args)
+		if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("timed out after %s (raise --timeout): %w", *timeout, err)
+		}
+		return err
+	}
+}
+
+// awsConfigOption resolves the AWS config from --region and --profile for
+// commands that call AWS, applies --max-attempts and --require-full-access to
+// their clients and assumes --assume-role-arn for EC2 calls
+func awsConfigOption(ctx context.Context) (application.ContainerOption, error) {
+	if externalID != "" && assumeRoleARN == "" {
+		return nil, errors.New("--external-id requires --assume-role-arn")
+	}
+	if refreshCache && cacheDir == "" {
+		return nil, errors.New("--refresh requires --cache-dir")
+	}
+	return accountConfigOption(ctx, awsRegion, assumeRoleARN, externalID)
+}
+
+// accountConfigOption is awsConfigOption for the region and role of one
+// account; an empty roleARN keeps the loaded credentials. Instances are
+// cached in --cache-dir when it is set.
+func accountConfigOption(ctx context.Context, region, roleARN, externalID string) (application.ContainerOption, error) {
+	awsConfig, err := application.ResolveAWSConfig(ctx, region, awsProfile)
+	if err != nil {
+		return nil, err
+	}
+	attempts := application.WithMaxAttempts(maxAttempts)
+	return func(c *application.Container) error {
+		if err := awsConfig(c); err != nil {
+			return err
+		}
+		if err := attempts(c); err != nil {
+			return fmt.Errorf("invalid --max-attempts: %w", err)
+		}
+		if cacheDir != "" {
+			if err := application.WithInstanceCache(cacheDir, cacheTTL, refreshCache)(c); err != nil {
+				return fmt.Errorf("invalid --cache-dir or --cache-ttl: %w", err)
+			}
+		}
+		if requireFullAccess {
+			if err := application.WithRequireFullAccess()(c); err != nil {
+				return err
+			}
+		}
+		if roleARN != "" {
+			return application.WithAssumeRole(roleARN, externalID)(c)
+		}
+		return nil
+	}, nil
+}
+
+// terraformVariablesOption applies --var-file and --var to commands that read
+// Terraform configuration files
+func terraformVariablesOption(varFiles, vars []string) (application.ContainerOption, error) {
+	parsed, err := terraform.ParseVarFlags(vars)
+	if err != nil {
+		return nil, err
+	}
+	return application.WithTerraformVariables(varFiles, parsed), nil
 }
@@ -0,0 +1,332 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"driftdetector/application"
+	appcommands "driftdetector/application/commands"
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	awsrepo "driftdetector/infrastructure/aws"
+	"driftdetector/infrastructure/config"
+	"driftdetector/infrastructure/mock"
+	"driftdetector/infrastructure/persistence"
+	"driftdetector/infrastructure/terraform"
+)
+
+// NewScanCmd creates a command that discovers running instances by tag and
+// reports which of them have drifted from Terraform
+func NewScanCmd() *cobra.Command {
+	var (
+		tags        []string
+		filters     []string
+		tfState     string
+		stateRegion string
+		varFiles    []string
+		vars        []string
+		tfDir       string
+		workspace   workspaceFlags
+		strictState strictStateFlag
+		jsonOutput  bool
+		output      outputFlags
+		redact      redactFlags
+		browser     tuiFlags
+		accountMap  string
+		mockFile    string
+		mockFormat  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "scan",
+		Short: "Discover drifted EC2 instances by tag",
+		Long: `Scan all running EC2 instances matching the given tag and instance filters
+and compare each of them with Terraform. --filter instance-state=... scans
+instances in other states instead. Instances are matched to Terraform by instance ID,
+then by Name tag. Instances with no matching configuration are listed as unmanaged.`,
+		Example: `  driftdetector scan --tag Environment=prod --tf-state prod.tfstate
+  driftdetector scan --filter instance-type=t3.* --filter launched-before=2023-01-01 --tf-state prod.tfstate`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format := persistence.FormatType(outputFmt)
+			if jsonOutput {
+				format = persistence.FormatJSON
+			}
+			switch format {
+			case persistence.FormatText, persistence.FormatJSON, persistence.FormatCSV, persistence.FormatSARIF:
+			default:
+				return fmt.Errorf("invalid --output: scan supports text, json, csv and sarif, not %s", format)
+			}
+
+			tagFilter, err := parseTagFilters(tags)
+			if err != nil {
+				return err
+			}
+			instanceFilter, err := repositories.ParseInstanceFilter(filters)
+			if err != nil {
+				return fmt.Errorf("invalid --filter: %w", err)
+			}
+			redactor, err := redact.redactor()
+			if err != nil {
+				return err
+			}
+			sink, err := output.writer(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			tfVars, err := terraformVariablesOption(varFiles, vars)
+			if err != nil {
+				return err
+			}
+
+			tfWorkspace, err := workspace.option(cmd, tfDir)
+			if err != nil {
+				return err
+			}
+
+			// Mocked instances are scanned instead of EC2's, so AWS is only
+			// needed for remote state
+			var mockRepo *mock.InstanceRepository
+			if mockFile != "" {
+				mockConfigs, err := loadMockConfigs(cmd, mockFile, mockFormat)
+				if err != nil {
+					return err
+				}
+				mockRepo = mock.NewInstanceRepository(mockConfigs...)
+			}
+
+			// Piped state is read once, as every account is compared with it
+			var pipedState []byte
+			if tfState == terraform.StdinState {
+				if mockFile == stdinMockFile {
+					return errors.New("--mock-file and --tf-state cannot both read stdin")
+				}
+				if pipedState, err = io.ReadAll(cmd.InOrStdin()); err != nil {
+					return fmt.Errorf("reading state from stdin: %w", err)
+				}
+			}
+
+			// Without an account map, the account of the loaded credentials
+			// or of --assume-role-arn is scanned
+			accounts := []config.Account{{}}
+			if accountMap != "" {
+				if assumeRoleARN != "" || externalID != "" {
+					return errors.New("--account-map cannot be combined with --assume-role-arn or --external-id; set the role of each account in the map")
+				}
+				if accounts, err = config.LoadAccountMap(accountMap); err != nil {
+					return err
+				}
+			}
+
+			// An account that cannot be scanned, e.g. because its role cannot
+			// be assumed, does not stop the others
+			var results []*appcommands.ScanResult
+			var failures []error
+			for _, account := range accounts {
+				containerOpts := append([]application.ContainerOption{application.WithStateRegion(stateRegion), tfVars, tfWorkspace}, strictState.options()...)
+				if pipedState != nil {
+					containerOpts = append(containerOpts, application.WithStdin(bytes.NewReader(pipedState)))
+				}
+				accountID := awsrepo.AssumeRole{RoleARN: assumeRoleARN}.AccountID()
+				if mockRepo != nil {
+					containerOpts = append(containerOpts, application.WithInstanceRepository(mockRepo))
+					if !terraform.IsRemoteState(tfState) {
+						containerOpts = append(containerOpts, application.WithoutAWS())
+					}
+				} else {
+					awsConfig, err := awsConfigOption(cmd.Context())
+					if account.RoleARN != "" {
+						region := account.Region
+						if region == "" {
+							region = awsRegion
+						}
+						awsConfig, err = accountConfigOption(cmd.Context(), region, account.RoleARN, account.ExternalID)
+						accountID = account.ID
+					}
+					if err != nil {
+						return err
+					}
+					containerOpts = append(containerOpts, awsConfig)
+				}
+
+				container, err := application.NewContainer(cmd.Context(), containerOpts...)
+				if err != nil {
+					return fmt.Errorf("failed to initialize application container: %w", err)
+				}
+
+				handler := appcommands.NewScanDriftHandler(
+					container.GetDetectionService(),
+					container.GetInstanceRepository(),
+					container.GetTerraformRepository(),
+				)
+				accountResults, err := handler.Handle(cmd.Context(), appcommands.ScanDriftCommand{
+					Tags:               tagFilter,
+					Filter:             instanceFilter,
+					TerraformStateFile: tfState,
+					TerraformDir:       tfDir,
+					AccountID:          accountID,
+				})
+				if err != nil {
+					if accountMap == "" {
+						return err
+					}
+					failures = append(failures, fmt.Errorf("account %s (role %s): %w", account.ID, account.RoleARN, err))
+					continue
+				}
+				results = append(results, accountResults...)
+			}
+			if len(failures) == len(accounts) {
+				return errors.Join(failures...)
+			}
+			for _, result := range results {
+				result.Report = redactor.Redact(result.Report)
+			}
+
+			// Unmanaged instances have no report to browse or upload
+			var reports []*models.DriftReport
+			for _, result := range results {
+				if result.Managed {
+					reports = append(reports, result.Report)
+				}
+			}
+
+			// The browser replaces the results on stdout, not in --output-file
+			// or --output-s3
+			browsing := browser.active(cmd)
+			if !browsing || !output.toStdout() {
+				name := persistence.ReportFileName(aggregateReportName, time.Time{}, format)
+				err = writeOutput(cmd.Context(), sink, name, func(out io.Writer) error {
+					return printScanResults(out, results, format)
+				})
+				if err == nil && output.s3 != "" {
+					err = writeInstanceReports(cmd.Context(), sink, reports, string(format), time.Time{})
+				}
+				if err != nil {
+					return err
+				}
+			}
+			if browsing {
+				if err := browser.browse(cmd, reports); err != nil {
+					return err
+				}
+			}
+			return errors.Join(failures...)
+		},
+	}
+
+	// Add flags
+	cmd.Flags().StringArrayVar(&tags, "tag", nil, "Tag filter as Key=Value, or Key to match any value (repeatable)")
+	cmd.Flags().StringArrayVar(&filters, "filter", nil, "Instance filter as key=value, e.g. instance-type=t3.*, subnet-id=subnet-abc or launched-before=2023-01-01; all must match (repeatable)")
+	cmd.Flags().StringVarP(&tfState, "tf-state", "s", "", "Path to Terraform state file or s3://bucket/key URL; - reads stdin, e.g. from terraform state pull")
+	cmd.Flags().StringVar(&stateRegion, "tf-state-region", "", "Region of the S3 bucket holding remote state (default: the AWS region)")
+	cmd.Flags().StringVarP(&tfDir, "tf-dir", "d", "", "Path to Terraform configuration directory")
+	workspace.register(cmd)
+	strictState.register(cmd)
+	cmd.Flags().StringArrayVar(&varFiles, "var-file", nil, "Terraform variable file used to evaluate --tf-dir, after terraform.tfvars and *.auto.tfvars (repeatable)")
+	cmd.Flags().StringArrayVar(&vars, "var", nil, "Terraform variable as name=value, overriding variable files (repeatable)")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print results as JSON (same as -o json)")
+	output.register(cmd, "results")
+	cmd.Flags().StringVar(&accountMap, "account-map", "", "YAML file listing the accounts to scan and the role assumed in each; results are tagged with the account ID")
+	cmd.Flags().StringVar(&mockFile, "mock-file", "", "Instance configurations written by snapshot, as a JSON or YAML array or a directory of files, scanned instead of EC2; - reads stdin")
+	cmd.Flags().StringVar(&mockFormat, "mock-format", "", mockFormatUsage)
+	redact.register(cmd)
+	browser.register(cmd)
+
+	// Mark flags
+	cmd.MarkFlagsOneRequired("tf-state", "tf-dir")
+	cmd.MarkFlagsMutuallyExclusive("tf-state", "tf-dir")
+	cmd.MarkFlagsMutuallyExclusive("mock-file", "account-map")
+
+	return cmd
+}
+
+// printScanResults writes scan results to out as a table, as JSON, or as CSV
+// or SARIF holding the findings of the managed instances
+func printScanResults(out io.Writer, results []*appcommands.ScanResult, format persistence.FormatType) error {
+	switch format {
+	case persistence.FormatJSON:
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal scan results: %w", err)
+		}
+		fmt.Fprintln(out, string(data))
+		return nil
+	case persistence.FormatCSV, persistence.FormatSARIF:
+		var reports []*models.DriftReport
+		for _, result := range results {
+			if result.Managed {
+				reports = append(reports, result.Report)
+			}
+		}
+		data, err := persistence.FormatReports(format, reports)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(out, data)
+		return nil
+	}
+
+	if len(results) == 0 {
+		fmt.Fprintln(out, "No instances match the tag and instance filters.")
+		return nil
+	}
+
+	// Results read from several accounts start with their account
+	withAccounts := false
+	for _, result := range results {
+		if result.AccountID != "" {
+			withAccounts = true
+		}
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	if withAccounts {
+		fmt.Fprint(w, "ACCOUNT\t")
+	}
+	fmt.Fprintln(w, "INSTANCE ID\tNAME\tDRIFT\tDRIFTS")
+
+	for _, result := range results {
+		name := result.Name
+		if name == "" {
+			name = "-"
+		}
+
+		drift, count := "unmanaged", "-"
+		if result.Managed {
+			drift = "no"
+			if result.Report.HasDrifts() {
+				drift = "yes"
+			}
+			count = strconv.Itoa(result.DriftCount())
+		}
+
+		if withAccounts {
+			fmt.Fprintf(w, "%s\t", result.AccountID)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", result.InstanceID, name, drift, count)
+	}
+
+	return w.Flush()
+}
+
+// parseTagFilters converts Key=Value flags into a tag filter
+func parseTagFilters(values []string) (map[string]string, error) {
+	tags := make(map[string]string, len(values))
+	for _, v := range values {
+		key, value, _ := strings.Cut(v, "=")
+		key = strings.TrimSpace(key)
+		if key == "" {
+			return nil, fmt.Errorf("invalid --tag %q: expected Key=Value", v)
+		}
+		tags[key] = value
+	}
+	return tags, nil
+}
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"driftdetector/application"
+	appcommands "driftdetector/application/commands"
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/persistence"
+	"driftdetector/interfaces/server"
+)
+
+// readinessCacheTTL is how long serve reuses the result of reading the
+// Terraform source for /readyz
+const readinessCacheTTL = time.Minute
+
+// NewServeCmd creates a command that serves drift detection over HTTP
+func NewServeCmd() *cobra.Command {
+	var (
+		addr        string
+		stateFile   string
+		stateRegion string
+		tfDir       string
+		workspace   workspaceFlags
+		grace       time.Duration
+		instanceID  string
+		interval    time.Duration
+		maxBackoff  time.Duration
+		maxMissed   int
+		reportDir   string
+		webhook     webhookFlags
+	)
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve drift detection and health probes over HTTP",
+		Long: `Serve drift detection over HTTP with Kubernetes-style /livez, /readyz and /healthz
+probes. On SIGTERM the server stops accepting requests, lets in-flight detections
+finish within the grace period, flushes pending writes and exits.
+
+With --instance the instance is also checked on every --interval in the
+background, like watch, and /livez fails when those checks stop completing.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notifier, err := webhook.notifier()
+			if err != nil {
+				return err
+			}
+
+			var writer *persistence.ReportWriter
+			if reportDir != "" {
+				if instanceID == "" {
+					return fmt.Errorf("--report-dir requires --instance")
+				}
+				writer, err = persistence.NewReportWriter(reportDir, persistence.FormatType(outputFmt))
+				if err != nil {
+					return fmt.Errorf("invalid --report-dir: %w", err)
+				}
+			}
+
+			awsConfig, err := awsConfigOption(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			tfWorkspace, err := workspace.option(cmd, tfDir)
+			if err != nil {
+				return err
+			}
+
+			container, err := application.NewContainer(cmd.Context(), awsConfig, application.WithStateRegion(stateRegion), tfWorkspace)
+			if err != nil {
+				return fmt.Errorf("failed to initialize application container: %w", err)
+			}
+
+			handler := appcommands.NewDetectDriftHandler(
+				container.GetDetectionService(),
+				container.GetInstanceRepository(),
+				container.GetTerraformRepository(),
+			)
+
+			detect := func(ctx context.Context, instanceID string) (*models.DriftReport, error) {
+				return handler.Handle(ctx, appcommands.DetectDriftCommand{
+					InstanceID:         instanceID,
+					TerraformStateFile: stateFile,
+					TerraformDir:       tfDir,
+				})
+			}
+
+			coordinator := server.NewShutdownCoordinator()
+			heartbeat := server.NewHeartbeat(nil)
+			probeOptions := []server.ProbesOption{
+				// Reading the Terraform source, perhaps from S3, is too costly
+				// for every probe
+				server.WithCachedReadinessCheck("terraform", readinessCacheTTL, func(ctx context.Context) error {
+					if stateFile != "" {
+						_, err := container.GetTerraformRepository().GetInstanceConfigs(ctx, stateFile)
+						return err
+					}
+					_, err := container.GetTerraformRepository().GetInstanceConfigsFromDir(ctx, tfDir)
+					return err
+				}),
+				server.WithReadinessCheck("aws-credentials", func(ctx context.Context) error {
+					creds := container.GetAWSConfig().Credentials
+					if creds == nil {
+						return fmt.Errorf("no credentials provider configured")
+					}
+					_, err := creds.Retrieve(ctx)
+					return err
+				}),
+			}
+
+			// The background checks end when the coordinator stops; the
+			// check in flight runs on and is waited for
+			runCtx, stopRun := context.WithCancel(cmd.Context())
+			defer stopRun()
+			watchErr := make(chan error, 1)
+			if instanceID != "" {
+				out := cmd.OutOrStdout()
+				watcher := newWatchLoop(watchLoop{
+					detect: func(ctx context.Context) (*models.DriftReport, error) {
+						return detect(ctx, instanceID)
+					},
+					interval:    interval,
+					maxBackoff:  maxBackoff,
+					coordinator: coordinator,
+					heartbeat:   heartbeat,
+					writer:      writer,
+					notifier:    notifier,
+					out:         out,
+					logf: func(format string, a ...interface{}) {
+						fmt.Fprintf(out, "%s %s\n", time.Now().UTC().Format(time.RFC3339), fmt.Sprintf(format, a...))
+					},
+				})
+				probeOptions = append(probeOptions, server.WithHeartbeat(heartbeat, livenessInterval(interval, maxBackoff), maxMissed))
+
+				loopCtx, cancelLoop := context.WithCancel(context.WithoutCancel(cmd.Context()))
+				defer cancelLoop()
+				coordinator.OnStop(func(context.Context) error {
+					cancelLoop()
+					return nil
+				})
+				go func() {
+					// A watch that fails shuts the server down with it
+					watchErr <- watcher.Run(loopCtx)
+					stopRun()
+				}()
+			}
+
+			srv := server.NewServer(addr, server.NewProbes(probeOptions...), coordinator, detect)
+
+			signals, stop := server.NotifyShutdown()
+			defer stop()
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Serving drift detection on %s\n", addr)
+			err = srv.Run(runCtx, signals, grace)
+			select {
+			case werr := <-watchErr:
+				if werr != nil {
+					return werr
+				}
+			default:
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().StringVar(&addr, "addr", ":8080", "Address to listen on")
+	cmd.Flags().StringVarP(&stateFile, "state-file", "s", "", "Path to Terraform state file or s3://bucket/key URL")
+	cmd.Flags().StringVar(&stateRegion, "tf-state-region", "", "Region of the S3 bucket holding remote state (default: the AWS region)")
+	cmd.Flags().StringVarP(&tfDir, "tf-dir", "d", "", "Path to Terraform configuration directory")
+	workspace.register(cmd)
+	cmd.Flags().DurationVar(&grace, "grace-period", 30*time.Second, "Time to let in-flight detections finish on shutdown")
+	cmd.Flags().StringVarP(&instanceID, "instance", "i", "", "EC2 instance ID to also check on every --interval in the background")
+	cmd.Flags().DurationVar(&interval, "interval", 5*time.Minute, "Time between background checks")
+	cmd.Flags().DurationVar(&maxBackoff, "max-backoff", 30*time.Minute, "Longest wait between background checks after repeated failures")
+	cmd.Flags().IntVar(&maxMissed, "max-missed", 3, "Fail /livez after this many intervals without a completed background check")
+	cmd.Flags().StringVar(&reportDir, "report-dir", "", "Directory to write each background report to as a timestamped file, in the --output format")
+	webhook.register(cmd)
+
+	cmd.MarkFlagsOneRequired("state-file", "tf-dir")
+	cmd.MarkFlagsMutuallyExclusive("state-file", "tf-dir")
+
+	return cmd
+}
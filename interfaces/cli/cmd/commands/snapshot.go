@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"driftdetector/application"
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/infrastructure/config"
+	"driftdetector/infrastructure/mock"
+	legacy "driftdetector/models"
+)
+
+// NewSnapshotCmd creates a command that captures the live configuration of
+// instances as files usable with detect-ddd --mock-file
+func NewSnapshotCmd() *cobra.Command {
+	var (
+		instanceIDs    []string
+		all            bool
+		tags           []string
+		output         string
+		format         string
+		redactUserData bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Save the live configuration of EC2 instances as mock files",
+		Long: `Fetch the configuration of EC2 instances from AWS and write it as JSON, or
+YAML with --format yaml, that detect-ddd --mock-file reads in place of the live
+instance.
+
+A single instance is written to the --output file, in YAML if its extension is
+.yaml or .yml, or stdout. Several instances, or --all, are written to the
+--output directory as <instance-id>.json or <instance-id>.yaml.`,
+		Example: `  driftdetector snapshot -i i-1234567890abcdef0 -o instance.json
+  driftdetector snapshot --all --tag Environment=prod --format yaml -o snapshots/`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !all && len(instanceIDs) == 0 {
+				return errors.New("one of --instance or --all must be specified")
+			}
+			if len(tags) > 0 && !all {
+				return errors.New("--tag can only be used with --all")
+			}
+			tagFilter, err := parseTagFilters(tags)
+			if err != nil {
+				return err
+			}
+			fileFormat := mock.FormatJSON
+			if format != "" {
+				if fileFormat, err = mock.ParseFormat(format); err != nil {
+					return fmt.Errorf("invalid --format: %w", err)
+				}
+			}
+
+			awsConfig, err := awsConfigOption(cmd.Context())
+			if err != nil {
+				return err
+			}
+			container, err := application.NewContainer(cmd.Context(), awsConfig)
+			if err != nil {
+				return fmt.Errorf("failed to initialize application container: %w", err)
+			}
+
+			repo := container.GetInstanceRepository()
+			var instances []*models.Instance
+			if all {
+				instances, err = repo.Find(cmd.Context(), repositories.InstanceFilter{Tags: tagFilter})
+			} else {
+				instances, err = repo.GetByIDs(cmd.Context(), instanceIDs)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to fetch instances from AWS: %w", err)
+			}
+
+			configs := make([]*legacy.InstanceConfig, 0, len(instances))
+			for _, instance := range instances {
+				snapshot := legacy.NewInstanceConfig(instance)
+				if redactUserData {
+					snapshot.UserData = ""
+				}
+				configs = append(configs, snapshot)
+			}
+
+			// A single requested instance goes to a file or stdout
+			if !all && len(instanceIDs) == 1 {
+				if len(configs) == 0 {
+					return fmt.Errorf("%w: %s", repositories.ErrInstanceNotFound, instanceIDs[0])
+				}
+				if output == "" {
+					return mock.EncodeInstanceConfig(cmd.OutOrStdout(), configs[0], fileFormat)
+				}
+				if format == "" {
+					fileFormat = mock.FormatOf(output)
+				}
+				return mock.WriteInstanceConfig(output, configs[0], fileFormat)
+			}
+
+			if output == "" {
+				return errors.New("--output must name a directory when snapshotting several instances")
+			}
+			if err := os.MkdirAll(output, 0o755); err != nil {
+				return fmt.Errorf("failed to create snapshot directory: %w", err)
+			}
+			for _, snapshot := range configs {
+				path := filepath.Join(output, snapshot.InstanceID+fileFormat.Extension())
+				if err := mock.WriteInstanceConfig(path, snapshot, fileFormat); err != nil {
+					return err
+				}
+			}
+			fmt.Fprintf(cmd.ErrOrStderr(), "Wrote %d snapshot(s) to %s\n", len(configs), output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&instanceIDs, "instance", "i", nil, "EC2 instance ID to snapshot (repeatable)")
+	cmd.Flags().BoolVar(&all, "all", false, "Snapshot every instance, optionally filtered with --tag")
+	cmd.Flags().StringArrayVar(&tags, "tag", nil, "Only snapshot instances with this tag, as Key=Value or Key (repeatable)")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "File to write a single instance to (default: stdout), or directory for several")
+	cmd.Flags().StringVar(&format, "format", "", "Format of the snapshots, json or yaml (default: by the --output extension, else json)")
+	cmd.Flags().BoolVar(&redactUserData, "redact-user-data", false, "Leave user data, which may hold secrets, out of the snapshot")
+	cmd.MarkFlagsMutuallyExclusive("instance", "all")
+
+	// --output is a path here, not a report format, so the config file's
+	// output setting must not apply to it
+	_ = cmd.Flags().SetAnnotation("output", config.NoCLIDefaultsAnnotation, []string{"true"})
+
+	return cmd
+}
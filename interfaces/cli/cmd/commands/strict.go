@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"driftdetector/pkg/driftdetector"
+)
+
+// strictFlags holds the flags that compare the fields computed by AWS,
+// which are skipped by default
+type strictFlags struct {
+	enabled bool
+	compare []string
+}
+
+// register adds the strict comparison flags to cmd
+func (f *strictFlags) register(cmd *cobra.Command) {
+	skipped := strings.Join(driftdetector.DefaultIgnoredFields(), ", ")
+	cmd.Flags().BoolVar(&f.enabled, "strict", false, "Compare every field, including those computed by AWS and skipped by default: "+skipped)
+	cmd.Flags().StringArrayVar(&f.compare, "compare", nil, "Field skipped by default to compare anyway, one of "+skipped+" (repeatable)")
+	cmd.MarkFlagsMutuallyExclusive("strict", "compare")
+}
+
+// options returns the detector options for the flags
+func (f *strictFlags) options() []driftdetector.Option {
+	if f.enabled {
+		return []driftdetector.Option{driftdetector.WithStrict()}
+	}
+	return []driftdetector.Option{driftdetector.WithComparedFields(f.compare...)}
+}
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+	"driftdetector/application"
+)
+
+// strictStateFlag holds the flag that fails on Terraform state holding a
+// resource that cannot be decoded
+type strictStateFlag struct {
+	enabled bool
+}
+
+// register adds the --strict-state flag to cmd
+func (f *strictStateFlag) register(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.enabled, "strict-state", false, "Exit with an error when a resource in the Terraform state cannot be decoded, instead of leaving it out with a warning")
+}
+
+// options returns the container options for the flag
+func (f *strictStateFlag) options() []application.ContainerOption {
+	if !f.enabled {
+		return nil
+	}
+	return []application.ContainerOption{application.WithStrictState()}
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"driftdetector/infrastructure/persistence"
+	"driftdetector/pkg/driftdetector"
+)
+
+// templateFlags holds the flags of the template output format
+type templateFlags struct {
+	file   string
+	schema bool
+}
+
+// register adds --template-file and --template-schema to cmd
+func (f *templateFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.file, "template-file", "", "text/template file rendering the report with -o template")
+	cmd.Flags().BoolVar(&f.schema, "template-schema", false, "Print the fields and functions available to --template-file and exit")
+}
+
+// printSchema prints the template schema when --template-schema is given,
+// reporting whether it did
+func (f *templateFlags) printSchema(cmd *cobra.Command) bool {
+	if !f.schema {
+		return false
+	}
+	fmt.Fprint(cmd.OutOrStdout(), persistence.TemplateSchema())
+	return true
+}
+
+// options parses --template-file for format, so syntax errors are reported
+// before any AWS calls, and returns the formatter option rendering with it.
+// It fails when format is template without a file, or a file is given for
+// another format.
+func (f *templateFlags) options(format string) ([]driftdetector.FormatterOption, error) {
+	if driftdetector.FormatType(format) != driftdetector.FormatTemplate {
+		if f.file != "" {
+			return nil, fmt.Errorf("--template-file requires -o %s", driftdetector.FormatTemplate)
+		}
+		return nil, nil
+	}
+	if f.file == "" {
+		return nil, fmt.Errorf("-o %s requires --template-file", driftdetector.FormatTemplate)
+	}
+	tmpl, err := driftdetector.ParseTemplateFile(f.file)
+	if err != nil {
+		return nil, err
+	}
+	return []driftdetector.FormatterOption{driftdetector.WithTemplate(tmpl)}, nil
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/domain/services"
+	"driftdetector/infrastructure/persistence"
+)
+
+// NewTrendCmd creates a command that summarizes how an instance's drift
+// evolved across the reports saved by watch --report-dir
+func NewTrendCmd() *cobra.Command {
+	var (
+		instanceID string
+		reportDir  string
+		since      string
+		jsonOutput bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "trend",
+		Short: "Summarize how an instance's drift evolved over saved reports",
+		Long: `Read the JSON reports watch --report-dir saved for an instance and list each
+drift path with when it first appeared, whether it has since been resolved, how
+long it persisted and how many checks reported it.
+
+--since limits the history to recent checks, as a duration such as 30d or 12h,
+or a date such as 2025-06-01.`,
+		Example: `  driftdetector trend -i i-1234567890abcdef0 --report-dir reports/ --since 30d
+  driftdetector trend -i i-1234567890abcdef0 --report-dir reports/ --json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			format := persistence.FormatType(outputFmt)
+			if jsonOutput {
+				format = persistence.FormatJSON
+			}
+			switch format {
+			case persistence.FormatText, persistence.FormatJSON:
+			default:
+				return fmt.Errorf("invalid --output: trend supports text and json, not %s", format)
+			}
+
+			var from time.Time
+			if since != "" {
+				var err error
+				if from, err = parseSince(since, time.Now()); err != nil {
+					return err
+				}
+			}
+
+			var history repositories.DriftHistoryRepository = persistence.NewReportHistory(reportDir)
+			reports, err := history.GetDriftHistory(instanceID, 0)
+			if err != nil {
+				return err
+			}
+			recent := reports[:0]
+			for _, report := range reports {
+				if !report.CheckedAt.Before(from) {
+					recent = append(recent, report)
+				}
+			}
+
+			trend := services.BuildDriftTrend(instanceID, recent)
+			if format == persistence.FormatJSON {
+				data, err := json.MarshalIndent(trend, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to marshal drift trend: %w", err)
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), string(data))
+				return nil
+			}
+			if trend.Checks == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "No drift history for %s in %s%s.\n", instanceID, reportDir, sinceSuffix(since))
+				return nil
+			}
+			return printTrend(cmd.OutOrStdout(), trend)
+		},
+	}
+
+	cmd.Flags().StringVarP(&instanceID, "instance", "i", "", "EC2 instance ID")
+	cmd.Flags().StringVar(&reportDir, "report-dir", "", "Directory of JSON reports written by watch --report-dir")
+	cmd.Flags().StringVar(&since, "since", "", "Only use reports from this long ago, e.g. 30d or 12h, or since a date such as 2025-06-01")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the trend as JSON (same as -o json)")
+	_ = cmd.MarkFlagRequired("instance")
+	_ = cmd.MarkFlagRequired("report-dir")
+
+	return cmd
+}
+
+// parseSince converts --since into the time history starts at: a duration
+// before now, where d counts days, or an RFC 3339 time or date
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: expected a duration such as 30d or 12h, or a date such as 2025-06-01", value)
+}
+
+// sinceSuffix describes --since at the end of a message
+func sinceSuffix(since string) string {
+	if since == "" {
+		return ""
+	}
+	return " since " + since
+}
+
+// printTrend writes a table with a row per drift path of trend
+func printTrend(out io.Writer, trend *models.DriftTrend) error {
+	fmt.Fprintf(out, "%s: %d check(s) from %s to %s\n", trend.InstanceID, trend.Checks,
+		trend.From.Format(time.RFC3339), trend.To.Format(time.RFC3339))
+	if len(trend.Paths) == 0 {
+		fmt.Fprintln(out, "No drift was reported.")
+		return nil
+	}
+	fmt.Fprintln(out)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PATH\tFIRST SEEN\tSTATUS\tPERSISTED\tOCCURRENCES")
+	for _, path := range trend.Paths {
+		name := path.Path
+		if name == "" {
+			name = "(instance)"
+		}
+		status := "drifting"
+		if path.Resolved {
+			status = "resolved " + path.Intervals[len(path.Intervals)-1].ResolvedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d/%d\n", name, path.FirstSeen.Format(time.RFC3339), status,
+			path.Duration(), path.Occurrences, trend.Checks)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"driftdetector/domain/models"
+	"driftdetector/interfaces/tui"
+)
+
+// tuiFlags holds the flag opening the terminal browser over the reports
+type tuiFlags struct {
+	enabled bool
+}
+
+// register adds the TUI flag to cmd
+func (f *tuiFlags) register(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&f.enabled, "tui", false, "Browse the reports in an interactive terminal UI instead of printing them (text is printed when stdout is not a terminal)")
+}
+
+// active reports whether the terminal browser opens. When --tui is given
+// but stdin or stdout is not a terminal, the report is printed instead.
+func (f *tuiFlags) active(cmd *cobra.Command) bool {
+	if !f.enabled {
+		return false
+	}
+	if isTerminal(os.Stdin) && isTerminal(os.Stdout) {
+		return true
+	}
+	fmt.Fprintln(cmd.ErrOrStderr(), "Warning: --tui needs a terminal; printing the report instead")
+	return false
+}
+
+// browse opens the terminal browser over reports and waits until it is
+// closed. Browsing is not limited by --timeout, which bounds the detection.
+func (f *tuiFlags) browse(cmd *cobra.Command, reports []*models.DriftReport) error {
+	return tui.Run(context.WithoutCancel(cmd.Context()), reports)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"driftdetector/infrastructure/mock"
+)
+
+// NewValidateMockCmd creates a command that checks mock files without
+// running a detection
+func NewValidateMockCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate-mock <file|dir>...",
+		Short: "Check mock instance files for unknown fields and invalid values",
+		Long: `Check instance configuration files used with detect-ddd --mock-file, in JSON or
+YAML, including arrays of instances and directories of them. Fields the
+configuration does not have are reported with their line and column, and
+settings such as volume types, tenancy and http_tokens must hold values AWS
+accepts.`,
+		Example: `  driftdetector validate-mock testdata/instance.json`,
+		Args:    cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			invalid := 0
+			for _, path := range args {
+				if _, err := mock.LoadInstanceConfigs(path); err != nil {
+					fmt.Fprintf(cmd.OutOrStdout(), "FAIL %v\n", err)
+					invalid++
+					continue
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "ok   %s\n", path)
+			}
+			if invalid > 0 {
+				return fmt.Errorf("%d of %d mock file(s) are invalid", invalid, len(args))
+			}
+			return nil
+		},
+	}
+}
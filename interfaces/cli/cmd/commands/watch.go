@@ -0,0 +1,277 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	"driftdetector/application"
+	appcommands "driftdetector/application/commands"
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/notify"
+	"driftdetector/infrastructure/persistence"
+	"driftdetector/interfaces/server"
+)
+
+// NewWatchCmd creates a command that checks an instance for drift on an interval
+func NewWatchCmd() *cobra.Command {
+	var (
+		instanceID  string
+		stateFile   string
+		stateRegion string
+		tfDir       string
+		workspace   workspaceFlags
+		varFiles    []string
+		vars        []string
+		interval    time.Duration
+		maxBackoff  time.Duration
+		reportDir   string
+		outputS3    string
+		webhook     webhookFlags
+		redact      redactFlags
+		healthAddr  string
+		maxMissed   int
+		grace       time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "watch",
+		Short: "Check an instance for drift on an interval",
+		Long: `Check an instance for drift on an interval, logging only when the drift status or
+the set of findings changes. Failed checks are retried with exponential backoff.
+On SIGINT or SIGTERM the check in flight finishes within the grace period and
+pending report writes and notifications are delivered before the watch stops.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			notifier, err := webhook.notifier()
+			if err != nil {
+				return err
+			}
+			redactor, err := redact.redactor()
+			if err != nil {
+				return err
+			}
+
+			var writer *persistence.ReportWriter
+			switch {
+			case reportDir != "":
+				writer, err = persistence.NewReportWriter(reportDir, persistence.FormatType(outputFmt))
+				if err != nil {
+					return fmt.Errorf("invalid --report-dir: %w", err)
+				}
+			case outputS3 != "":
+				uploads, err := s3OutputWriter(cmd.Context(), outputS3)
+				if err != nil {
+					return err
+				}
+				// A report that cannot be uploaded is printed instead, so the
+				// watch keeps running through an S3 outage
+				sink := persistence.NewFallbackWriter(uploads, persistence.NewStreamWriter(cmd.OutOrStdout()))
+				writer, err = persistence.NewReportWriterTo(sink, persistence.FormatType(outputFmt))
+				if err != nil {
+					return fmt.Errorf("invalid --output: %w", err)
+				}
+			}
+
+			awsConfig, err := awsConfigOption(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			tfVars, err := terraformVariablesOption(varFiles, vars)
+			if err != nil {
+				return err
+			}
+
+			tfWorkspace, err := workspace.option(cmd, tfDir)
+			if err != nil {
+				return err
+			}
+
+			container, err := application.NewContainer(cmd.Context(),
+				awsConfig,
+				application.WithStateRegion(stateRegion),
+				tfVars,
+				tfWorkspace,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to initialize application container: %w", err)
+			}
+
+			handler := appcommands.NewDetectDriftHandler(
+				container.GetDetectionService(),
+				container.GetInstanceRepository(),
+				container.GetTerraformRepository(),
+			)
+
+			out := cmd.OutOrStdout()
+			logf := func(format string, a ...interface{}) {
+				fmt.Fprintf(out, "%s %s\n", time.Now().UTC().Format(time.RFC3339), fmt.Sprintf(format, a...))
+			}
+
+			coordinator := server.NewShutdownCoordinator()
+			heartbeat := server.NewHeartbeat(nil)
+			watcher := newWatchLoop(watchLoop{
+				detect: func(ctx context.Context) (*models.DriftReport, error) {
+					report, err := handler.Handle(ctx, appcommands.DetectDriftCommand{
+						InstanceID:         instanceID,
+						TerraformStateFile: stateFile,
+						TerraformDir:       tfDir,
+					})
+					return redactor.Redact(report), err
+				},
+				interval:    interval,
+				maxBackoff:  maxBackoff,
+				coordinator: coordinator,
+				heartbeat:   heartbeat,
+				writer:      writer,
+				notifier:    notifier,
+				out:         out,
+				logf:        logf,
+			})
+
+			// Stopping the coordinator ends the watch; the check in flight
+			// runs on and is waited for
+			ctx, cancel := context.WithCancel(context.WithoutCancel(cmd.Context()))
+			defer cancel()
+			coordinator.OnStop(func(context.Context) error {
+				cancel()
+				return nil
+			})
+
+			var serveErr <-chan error
+			if healthAddr != "" {
+				probes := server.NewProbes(server.WithHeartbeat(heartbeat, livenessInterval(interval, maxBackoff), maxMissed))
+				if serveErr, err = server.NewServer(healthAddr, probes, coordinator, nil).Start(); err != nil {
+					return err
+				}
+				logf("serving health probes on %s", healthAddr)
+			}
+
+			signals, stopSignals := server.NotifyShutdown()
+			defer stopSignals()
+
+			logf("watching %s every %s", instanceID, interval)
+			watchErr := make(chan error, 1)
+			go func() {
+				watchErr <- watcher.Run(ctx)
+			}()
+
+			var runErr error
+			select {
+			case runErr = <-watchErr:
+			case runErr = <-serveErr:
+			case <-signals:
+			case <-cmd.Context().Done():
+			}
+
+			shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), grace)
+			defer cancelShutdown()
+			if err := coordinator.Shutdown(shutdownCtx); err != nil && runErr == nil {
+				runErr = err
+			}
+			if runErr != nil {
+				return runErr
+			}
+			logf("watch stopped")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&instanceID, "instance", "i", "", "EC2 instance ID to watch")
+	cmd.Flags().StringVarP(&stateFile, "state-file", "s", "", "Path to Terraform state file or s3://bucket/key URL")
+	cmd.Flags().StringVar(&stateRegion, "tf-state-region", "", "Region of the S3 bucket holding remote state (default: the AWS region)")
+	cmd.Flags().StringVarP(&tfDir, "tf-dir", "d", "", "Path to Terraform configuration directory")
+	workspace.register(cmd)
+	cmd.Flags().StringArrayVar(&varFiles, "var-file", nil, "Terraform variable file used to evaluate --tf-dir, after terraform.tfvars and *.auto.tfvars (repeatable)")
+	cmd.Flags().StringArrayVar(&vars, "var", nil, "Terraform variable as name=value, overriding variable files (repeatable)")
+	cmd.Flags().DurationVar(&interval, "interval", 5*time.Minute, "Time between checks")
+	cmd.Flags().DurationVar(&maxBackoff, "max-backoff", 30*time.Minute, "Longest wait between checks after repeated failures")
+	cmd.Flags().StringVar(&reportDir, "report-dir", "", "Directory to write each report to as a timestamped file, in the --output format")
+	cmd.Flags().StringVar(&outputS3, "output-s3", "", "Upload each report to this s3://bucket/prefix/ as a timestamped object, in the --output format")
+	cmd.Flags().StringVar(&healthAddr, "health-addr", "", "Address to serve /livez, /readyz and /healthz on, e.g. :8080")
+	cmd.Flags().IntVar(&maxMissed, "max-missed", 3, "Fail /livez after this many intervals without a completed check")
+	cmd.Flags().DurationVar(&grace, "grace-period", 30*time.Second, "Time to let the check in flight finish and pending writes be delivered on shutdown")
+
+	webhook.register(cmd)
+	redact.register(cmd)
+
+	cmd.MarkFlagRequired("instance")
+	cmd.MarkFlagsOneRequired("state-file", "tf-dir")
+	cmd.MarkFlagsMutuallyExclusive("state-file", "tf-dir")
+	cmd.MarkFlagsMutuallyExclusive("report-dir", "output-s3")
+
+	return cmd
+}
+
+// watchLoop is what newWatchLoop builds a Watcher from
+type watchLoop struct {
+	detect      application.DetectFunc
+	interval    time.Duration
+	maxBackoff  time.Duration
+	coordinator *server.ShutdownCoordinator
+	heartbeat   *server.Heartbeat
+	writer      *persistence.ReportWriter
+	notifier    notify.Notifier
+	out         io.Writer
+	logf        func(format string, a ...interface{})
+}
+
+// newWatchLoop creates a Watcher whose checks are tracked by the loop's
+// coordinator and beat its heartbeat. Changes in drift are logged, and
+// reports saved and notifications sent through outboxes the coordinator
+// flushes on shutdown, so neither holds up checks nor is lost on exit.
+func newWatchLoop(loop watchLoop) *application.Watcher {
+	history := application.NewOutbox(func(err error) {
+		loop.logf("failed to save report: %v", err)
+	})
+	notifications := application.NewOutbox(nil)
+	loop.coordinator.OnFlush(history.Flush)
+	loop.coordinator.OnFlush(notifications.Flush)
+
+	return application.NewWatcher(loop.detect,
+		application.WithWatchInterval(loop.interval),
+		application.WithWatchMaxBackoff(loop.maxBackoff),
+		application.WithWatchTracker(loop.coordinator),
+		application.OnDriftChange(func(report *models.DriftReport) {
+			if !report.HasDrifts() {
+				loop.logf("%s: no drift", report.InstanceID)
+				return
+			}
+			loop.logf("%s: %d drift finding(s)", report.InstanceID, len(report.Drifts))
+			for _, d := range report.Drifts {
+				fmt.Fprintf(loop.out, "  [%s] %s\n", d.Type, d.Path)
+			}
+			// notifyDrift logs its own failures
+			notifications.Send(func(ctx context.Context) error {
+				notifyDrift(ctx, loop.notifier, report)
+				return nil
+			})
+		}),
+		application.OnWatchError(func(err error, retryIn time.Duration) {
+			loop.logf("check failed, retrying in %s: %v", retryIn, err)
+		}),
+		application.OnWatchReport(func(report *models.DriftReport, at time.Time) error {
+			if loop.writer != nil {
+				history.Send(func(ctx context.Context) error {
+					_, err := loop.writer.Write(ctx, report, at)
+					return err
+				})
+			}
+			return nil
+		}),
+		application.OnWatchCheck(func(time.Time) {
+			loop.heartbeat.Beat()
+		}),
+	)
+}
+
+// livenessInterval is the longest a watch loop waits between checks, failed
+// checks included, so that backing off is not mistaken for being stuck
+func livenessInterval(interval, maxBackoff time.Duration) time.Duration {
+	if maxBackoff > interval {
+		return maxBackoff
+	}
+	return interval
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"driftdetector/domain/models"
+	"driftdetector/infrastructure/notify"
+)
+
+// webhookURLEnv supplies the webhook URL when --webhook-url is not given
+const webhookURLEnv = "WEBHOOK_URL"
+
+// webhookFlags holds the flags that configure drift notifications
+type webhookFlags struct {
+	url     string
+	headers []string
+	format  string
+}
+
+// register adds the webhook flags to cmd
+func (f *webhookFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.url, "webhook-url", "", "URL to POST the drift report to when drift is detected (default: $WEBHOOK_URL)")
+	cmd.Flags().StringArrayVar(&f.headers, "webhook-header", nil, "Header to send with webhook requests as name=value (repeatable)")
+	cmd.Flags().StringVar(&f.format, "webhook-format", string(notify.PayloadJSON), "Webhook payload format (json, slack)")
+}
+
+// notifier builds the configured notifier, or returns nil when no webhook URL is set
+func (f *webhookFlags) notifier() (notify.Notifier, error) {
+	url := f.url
+	if url == "" {
+		url = os.Getenv(webhookURLEnv)
+	}
+	if url == "" {
+		return nil, nil
+	}
+
+	headers := make(map[string]string, len(f.headers))
+	for _, h := range f.headers {
+		name, value, ok := strings.Cut(h, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --webhook-header %q: expected name=value", h)
+		}
+		headers[name] = value
+	}
+
+	n, err := notify.NewWebhookNotifier(url,
+		notify.WithHeaders(headers),
+		notify.WithPayloadFormat(notify.PayloadFormat(f.format)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --webhook-format: %w", err)
+	}
+	return n, nil
+}
+
+// notifyDrift sends reports with drift to the notifier. Failures are logged
+// and do not affect the exit code.
+func notifyDrift(ctx context.Context, n notify.Notifier, report *models.DriftReport) {
+	if n == nil || !report.HasDrifts() {
+		return
+	}
+	if err := n.Notify(ctx, report); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to send webhook notification for %s: %v\n", report.InstanceID, err)
+	}
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"driftdetector/application"
+	"driftdetector/infrastructure/terraform"
+)
+
+// workspaceFlags holds the flag choosing the Terraform workspace whose state
+// is read from --tf-dir
+type workspaceFlags struct {
+	name string
+}
+
+// register adds the workspace flag to cmd
+func (f *workspaceFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.name, "workspace", "", "Terraform workspace whose state is read from --tf-dir (default: the workspace selected in .terraform/environment)")
+}
+
+// option returns the container option selecting the workspace for dir. When
+// no workspace is given or selected and dir holds state for several, the user
+// is asked to choose one if stdin is a terminal; otherwise reading the
+// directory fails with an error listing them.
+func (f *workspaceFlags) option(cmd *cobra.Command, dir string) (application.ContainerOption, error) {
+	name := f.name
+	if name == "" && dir != "" && isTerminal(os.Stdin) {
+		_, err := terraform.ResolveWorkspaceState(dir, "")
+		var choice *terraform.WorkspaceChoiceError
+		if errors.As(err, &choice) {
+			name, err = promptWorkspace(cmd.InOrStdin(), cmd.ErrOrStderr(), choice.Workspaces)
+			if errors.Is(err, io.EOF) {
+				return nil, choice
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return application.WithWorkspace(name), nil
+}
+
+// promptWorkspace asks for one of workspaces, by number or name, until a
+// valid answer is given. It returns io.EOF when input ends first.
+func promptWorkspace(in io.Reader, out io.Writer, workspaces []string) (string, error) {
+	fmt.Fprintln(out, "Terraform state was found for several workspaces:")
+	for i, name := range workspaces {
+		fmt.Fprintf(out, "  %d) %s\n", i+1, name)
+	}
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(out, "Choose a workspace [1-%d]: ", len(workspaces))
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return "", fmt.Errorf("reading workspace choice: %w", err)
+			}
+			fmt.Fprintln(out)
+			return "", io.EOF
+		}
+
+		answer := strings.TrimSpace(scanner.Text())
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(workspaces) {
+			return workspaces[n-1], nil
+		}
+		for _, name := range workspaces {
+			if answer == name {
+				return name, nil
+			}
+		}
+		fmt.Fprintf(out, "%q is not one of the workspaces listed\n", answer)
+	}
+}
+
+// isTerminal reports whether f is an interactive terminal. The null device
+// is a character device too, but nobody is there to answer.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(info, null)
+}
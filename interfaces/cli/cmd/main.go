@@ -1,15 +1,27 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"driftdetector/interfaces/cli/cmd/commands"
 )
 
 func main() {
+	// Ctrl+C cancels the command's context, stopping in-flight AWS calls
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
 	rootCmd := cmd.NewRootCmd()
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.ExecuteContext(ctx)
+	stop()
+	if err != nil {
+		// Drift found with --quiet sets only the exit code
+		if cmd.IsSilent(err) {
+			os.Exit(1)
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Clock abstracts time so probe logic can be tested deterministically
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the default Clock backed by time.Now
+type systemClock struct{}
+
+// Now returns the current wall-clock time
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// ReadinessCheck verifies that a dependency required to serve traffic is available
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// Heartbeat records when a periodic worker last completed a unit of work
+type Heartbeat struct {
+	mu        sync.RWMutex
+	clock     Clock
+	started   time.Time
+	lastBeat  time.Time
+	completed int
+}
+
+// NewHeartbeat creates a Heartbeat that starts counting from the clock's current time
+func NewHeartbeat(clock Clock) *Heartbeat {
+	if clock == nil {
+		clock = systemClock{}
+	}
+	now := clock.Now()
+	return &Heartbeat{
+		clock:   clock,
+		started: now,
+	}
+}
+
+// Beat records a completed unit of work
+func (h *Heartbeat) Beat() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastBeat = h.clock.Now()
+	h.completed++
+}
+
+// Last returns the time of the last completed unit of work, or the start
+// time when nothing has completed yet
+func (h *Heartbeat) Last() time.Time {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.completed == 0 {
+		return h.started
+	}
+	return h.lastBeat
+}
+
+// Probes evaluates Kubernetes-style readiness and liveness
+type Probes struct {
+	clock     Clock
+	checks    []ReadinessCheck
+	heartbeat *Heartbeat
+	interval  time.Duration
+	maxMissed int
+}
+
+// ProbesOption configures Probes
+type ProbesOption func(*Probes)
+
+// WithClock sets the clock used for liveness evaluation
+func WithClock(clock Clock) ProbesOption {
+	return func(p *Probes) {
+		p.clock = clock
+	}
+}
+
+// WithReadinessCheck adds a dependency check to the readiness probe
+func WithReadinessCheck(name string, check func(ctx context.Context) error) ProbesOption {
+	return func(p *Probes) {
+		p.checks = append(p.checks, ReadinessCheck{Name: name, Check: check})
+	}
+}
+
+// WithCachedReadinessCheck adds a dependency check to the readiness probe
+// whose result is reused for ttl, for checks too costly to run on every
+// probe, such as reading a Terraform state kept in S3
+func WithCachedReadinessCheck(name string, ttl time.Duration, check func(ctx context.Context) error) ProbesOption {
+	return func(p *Probes) {
+		cached := &cachedCheck{check: check, ttl: ttl}
+		p.checks = append(p.checks, ReadinessCheck{Name: name, Check: func(ctx context.Context) error {
+			// The clock is looked up per probe, as WithClock may come later
+			return cached.run(ctx, p.clock)
+		}})
+	}
+}
+
+// cachedCheck remembers the result of a readiness check for a while
+type cachedCheck struct {
+	mu      sync.Mutex
+	check   func(ctx context.Context) error
+	ttl     time.Duration
+	checked bool
+	at      time.Time
+	err     error
+}
+
+// run returns the last result while it is fresh, and checks again otherwise
+func (c *cachedCheck) run(ctx context.Context, clock Clock) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := clock.Now()
+	if c.checked && now.Sub(c.at) < c.ttl {
+		return c.err
+	}
+	c.err = c.check(ctx)
+	c.checked, c.at = true, now
+	return c.err
+}
+
+// WithHeartbeat makes liveness fail when the heartbeat has not advanced for
+// more than maxMissed intervals
+func WithHeartbeat(hb *Heartbeat, interval time.Duration, maxMissed int) ProbesOption {
+	return func(p *Probes) {
+		p.heartbeat = hb
+		p.interval = interval
+		p.maxMissed = maxMissed
+	}
+}
+
+// NewProbes creates a new Probes with the given options
+func NewProbes(opts ...ProbesOption) *Probes {
+	p := &Probes{
+		clock: systemClock{},
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Readiness returns an error naming the first dependency that is not available
+func (p *Probes) Readiness(ctx context.Context) error {
+	for _, c := range p.checks {
+		if err := c.Check(ctx); err != nil {
+			return fmt.Errorf("%s: %w", c.Name, err)
+		}
+	}
+	return nil
+}
+
+// Liveness returns an error when the worker loop appears wedged
+func (p *Probes) Liveness() error {
+	if p.heartbeat == nil || p.interval <= 0 {
+		return nil
+	}
+
+	maxMissed := p.maxMissed
+	if maxMissed < 1 {
+		maxMissed = 1
+	}
+
+	since := p.clock.Now().Sub(p.heartbeat.Last())
+	if since > time.Duration(maxMissed)*p.interval {
+		return fmt.Errorf("no completed check in %s (limit %d intervals of %s)", since.Round(time.Second), maxMissed, p.interval)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package server_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"driftdetector/interfaces/server"
+)
+
+// fakeClock is a manually advanced Clock
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestProbes_Liveness(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	hb := server.NewHeartbeat(clock)
+	probes := server.NewProbes(
+		server.WithClock(clock),
+		server.WithHeartbeat(hb, time.Minute, 3),
+	)
+
+	assert.NoError(t, probes.Liveness(), "Fresh scheduler should be live")
+
+	clock.Advance(2 * time.Minute)
+	hb.Beat()
+	clock.Advance(3 * time.Minute)
+	assert.NoError(t, probes.Liveness(), "Exactly N intervals without a check is still live")
+
+	clock.Advance(time.Second)
+	assert.Error(t, probes.Liveness(), "Wedged scheduler should fail liveness")
+
+	hb.Beat()
+	assert.NoError(t, probes.Liveness(), "Completing a check restores liveness")
+}
+
+func TestProbes_Readiness(t *testing.T) {
+	var credsErr error
+	probes := server.NewProbes(
+		server.WithReadinessCheck("terraform", func(ctx context.Context) error { return nil }),
+		server.WithReadinessCheck("aws-credentials", func(ctx context.Context) error { return credsErr }),
+	)
+
+	assert.NoError(t, probes.Readiness(context.Background()))
+
+	credsErr = errors.New("no credentials")
+	err := probes.Readiness(context.Background())
+	assert.ErrorContains(t, err, "aws-credentials")
+}
+
+func TestServer_ProbeHandlers(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	hb := server.NewHeartbeat(clock)
+	probes := server.NewProbes(server.WithClock(clock), server.WithHeartbeat(hb, time.Minute, 1))
+	coordinator := server.NewShutdownCoordinator()
+	srv := server.NewServer("127.0.0.1:0", probes, coordinator, nil)
+
+	get := func(path string) int {
+		rec := httptest.NewRecorder()
+		srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusOK, get("/livez"))
+	assert.Equal(t, http.StatusOK, get("/readyz"))
+
+	clock.Advance(2 * time.Minute)
+	assert.Equal(t, http.StatusServiceUnavailable, get("/healthz"))
+
+	assert.NoError(t, coordinator.Shutdown(context.Background()))
+	assert.Equal(t, http.StatusServiceUnavailable, get("/readyz"), "Draining server should not be ready")
+	assert.Equal(t, http.StatusServiceUnavailable, get("/detect?instance_id=i-123"), "Draining server should reject new detections")
+}
+
+func TestServer_ProbesOnly(t *testing.T) {
+	// A server without a detect function, as next to a watch loop, serves
+	// only the probes
+	srv := server.NewServer("127.0.0.1:0", server.NewProbes(), server.NewShutdownCoordinator(), nil)
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/detect?instance_id=i-123", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestProbes_CachedReadiness(t *testing.T) {
+	// Given a costly check whose result is kept for a minute
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	calls := 0
+	var parseErr error
+	probes := server.NewProbes(
+		server.WithCachedReadinessCheck("terraform", time.Minute, func(ctx context.Context) error {
+			calls++
+			return parseErr
+		}),
+		server.WithClock(clock),
+	)
+
+	// When probes come in within the minute
+	assert.NoError(t, probes.Readiness(context.Background()))
+	clock.Advance(30 * time.Second)
+	parseErr = errors.New("state unreadable")
+	assert.NoError(t, probes.Readiness(context.Background()), "A fresh result is reused")
+
+	// Then the check runs once, and again once its result is stale
+	assert.Equal(t, 1, calls)
+	clock.Advance(30 * time.Second)
+	assert.ErrorContains(t, probes.Readiness(context.Background()), "state unreadable")
+	assert.Equal(t, 2, calls)
+}
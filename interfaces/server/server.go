@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"driftdetector/domain/models"
+)
+
+// DetectFunc runs drift detection for a single instance
+type DetectFunc func(ctx context.Context, instanceID string) (*models.DriftReport, error)
+
+// Server exposes drift detection and health probes over HTTP
+type Server struct {
+	httpServer  *http.Server
+	probes      *Probes
+	coordinator *ShutdownCoordinator
+	detect      DetectFunc
+}
+
+// NewServer creates a new Server listening on addr. Without detect it serves
+// only the health probes, e.g. next to a watch loop.
+func NewServer(addr string, probes *Probes, coordinator *ShutdownCoordinator, detect DetectFunc) *Server {
+	s := &Server{
+		probes:      probes,
+		coordinator: coordinator,
+		detect:      detect,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleLiveness)
+	mux.HandleFunc("/livez", s.handleLiveness)
+	mux.HandleFunc("/readyz", s.handleReadiness)
+	mux.HandleFunc("/detect", s.handleDetect)
+
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	coordinator.OnStop(s.httpServer.Shutdown)
+
+	return s
+}
+
+// Handler returns the HTTP handler, primarily for testing
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
+}
+
+// Start listens on the server's address and serves HTTP in the background.
+// The returned channel receives the error that stopped serving, or nil once
+// the server was shut down.
+func (s *Server) Start() (<-chan error, error) {
+	ln, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("listening on %s: %w", s.httpServer.Addr, err)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		err := s.httpServer.Serve(ln)
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		} else {
+			err = fmt.Errorf("serving HTTP: %w", err)
+		}
+		serveErr <- err
+	}()
+	return serveErr, nil
+}
+
+// Run serves HTTP until a signal arrives on signals, then drains in-flight
+// requests for up to grace before returning
+func (s *Server) Run(ctx context.Context, signals <-chan os.Signal, grace time.Duration) error {
+	serveErr, err := s.Start()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-signals:
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+
+	return s.coordinator.Shutdown(shutdownCtx)
+}
+
+func (s *Server) handleLiveness(w http.ResponseWriter, r *http.Request) {
+	if err := s.probes.Liveness(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+func (s *Server) handleReadiness(w http.ResponseWriter, r *http.Request) {
+	if s.coordinator.Draining() {
+		http.Error(w, ErrShuttingDown.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err := s.probes.Readiness(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+func (s *Server) handleDetect(w http.ResponseWriter, r *http.Request) {
+	instanceID := r.URL.Query().Get("instance_id")
+	if instanceID == "" {
+		http.Error(w, "instance_id is required", http.StatusBadRequest)
+		return
+	}
+
+	done, err := s.coordinator.Begin()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer done()
+
+	if s.detect == nil {
+		http.NotFound(w, r)
+		return
+	}
+	report, err := s.detect(r.Context(), instanceID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(report)
+}
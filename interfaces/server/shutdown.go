@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// defaultFlushTimeout bounds how long pending writes are flushed on shutdown
+const defaultFlushTimeout = 10 * time.Second
+
+// ErrShuttingDown is returned when new work is rejected during shutdown
+var ErrShuttingDown = errors.New("shutting down")
+
+// ShutdownCoordinator tracks in-flight work and pending writes so the HTTP
+// server and the watch scheduler can drain together on SIGTERM
+type ShutdownCoordinator struct {
+	mu       sync.Mutex
+	draining bool
+	inFlight sync.WaitGroup
+	flushers []func(ctx context.Context) error
+	stopFns  []func(ctx context.Context) error
+
+	flushTimeout time.Duration
+}
+
+// ShutdownOption configures a ShutdownCoordinator
+type ShutdownOption func(*ShutdownCoordinator)
+
+// WithFlushTimeout sets how long pending writes may take to flush on
+// shutdown, after in-flight work has ended or the grace period expired
+func WithFlushTimeout(timeout time.Duration) ShutdownOption {
+	return func(c *ShutdownCoordinator) {
+		c.flushTimeout = timeout
+	}
+}
+
+// NewShutdownCoordinator creates a new ShutdownCoordinator
+func NewShutdownCoordinator(opts ...ShutdownOption) *ShutdownCoordinator {
+	c := &ShutdownCoordinator{flushTimeout: defaultFlushTimeout}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Begin registers a unit of in-flight work. The returned function must be
+// called when the work completes. ErrShuttingDown is returned once draining.
+func (c *ShutdownCoordinator) Begin() (func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.draining {
+		return nil, ErrShuttingDown
+	}
+
+	c.inFlight.Add(1)
+	var once sync.Once
+	return func() { once.Do(c.inFlight.Done) }, nil
+}
+
+// Draining reports whether shutdown has started
+func (c *ShutdownCoordinator) Draining() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.draining
+}
+
+// OnStop registers a function that stops a component from accepting new work
+func (c *ShutdownCoordinator) OnStop(fn func(ctx context.Context) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopFns = append(c.stopFns, fn)
+}
+
+// OnFlush registers a function that persists pending writes after in-flight work ends
+func (c *ShutdownCoordinator) OnFlush(fn func(ctx context.Context) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.flushers = append(c.flushers, fn)
+}
+
+// Shutdown stops accepting new work, waits for in-flight work until ctx
+// expires, then runs all flush functions within the flush timeout, so a
+// destination that hangs cannot keep the process from exiting
+func (c *ShutdownCoordinator) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	c.draining = true
+	stopFns := append([]func(context.Context) error{}, c.stopFns...)
+	flushers := append([]func(context.Context) error{}, c.flushers...)
+	c.mu.Unlock()
+
+	var errs []error
+	for _, stop := range stopFns {
+		if err := stop(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		c.inFlight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		errs = append(errs, fmt.Errorf("waiting for in-flight work: %w", ctx.Err()))
+	}
+
+	// Flush with a fresh context so pending writes are not lost when the
+	// grace period was consumed by in-flight work
+	flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.flushTimeout)
+	defer cancel()
+	for _, flush := range flushers {
+		if err := runFlush(flushCtx, flush); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// runFlush runs flush, giving up once ctx is done even if flush does not
+// return
+func runFlush(ctx context.Context, flush func(ctx context.Context) error) error {
+	result := make(chan error, 1)
+	go func() {
+		result <- flush(ctx)
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("flushing pending writes: %w", ctx.Err())
+	}
+}
+
+// NotifyShutdown returns a channel that receives SIGTERM and SIGINT
+func NotifyShutdown() (<-chan os.Signal, func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGTERM, os.Interrupt)
+	return ch, func() { signal.Stop(ch) }
+}
@@ -0,0 +1,148 @@
+package server_test
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/application"
+	"driftdetector/domain/models"
+	"driftdetector/interfaces/server"
+)
+
+func TestServer_SIGTERMDrainsInFlightWork(t *testing.T) {
+	coordinator := server.NewShutdownCoordinator()
+	srv := server.NewServer("127.0.0.1:0", server.NewProbes(), coordinator, nil)
+
+	var finished, flushedAfterFinish atomic.Bool
+	coordinator.OnFlush(func(ctx context.Context) error {
+		flushedAfterFinish.Store(finished.Load())
+		return nil
+	})
+
+	done, err := coordinator.Begin()
+	require.NoError(t, err)
+
+	signals := make(chan os.Signal, 1)
+	result := make(chan error, 1)
+	go func() {
+		result <- srv.Run(context.Background(), signals, 5*time.Second)
+	}()
+
+	signals <- syscall.SIGTERM
+
+	select {
+	case <-result:
+		t.Fatal("Run returned before in-flight work completed")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	_, err = coordinator.Begin()
+	assert.ErrorIs(t, err, server.ErrShuttingDown, "New work should be rejected while draining")
+
+	finished.Store(true)
+	done()
+
+	select {
+	case err := <-result:
+		assert.NoError(t, err, "Graceful shutdown should exit cleanly")
+	case <-time.After(2 * time.Second):
+		t.Fatal("Run did not return after in-flight work completed")
+	}
+	assert.True(t, flushedAfterFinish.Load(), "Pending writes should flush after in-flight work")
+}
+
+func TestShutdownCoordinator_GracePeriodExpires(t *testing.T) {
+	coordinator := server.NewShutdownCoordinator()
+
+	var flushed atomic.Bool
+	coordinator.OnFlush(func(ctx context.Context) error {
+		flushed.Store(true)
+		return nil
+	})
+
+	_, err := coordinator.Begin()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err = coordinator.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, flushed.Load(), "Flush should still run when the grace period expires")
+}
+
+func TestShutdownCoordinator_WatchLoop(t *testing.T) {
+	// Given a watch loop tracked by the coordinator whose report is saved
+	// through an outbox it flushes
+	coordinator := server.NewShutdownCoordinator()
+	outbox := application.NewOutbox(nil)
+	coordinator.OnFlush(outbox.Flush)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	coordinator.OnStop(func(context.Context) error {
+		cancel()
+		return nil
+	})
+
+	checking := make(chan struct{})
+	release := make(chan struct{})
+	var saved atomic.Bool
+	watcher := application.NewWatcher(
+		func(context.Context) (*models.DriftReport, error) {
+			close(checking)
+			<-release
+			return models.NewDriftReport("i-1"), nil
+		},
+		application.WithWatchTracker(coordinator),
+		application.OnWatchReport(func(report *models.DriftReport, at time.Time) error {
+			outbox.Send(func(context.Context) error {
+				saved.Store(true)
+				return nil
+			})
+			return nil
+		}),
+	)
+	go func() { _ = watcher.Run(ctx) }()
+	<-checking
+
+	// When shutdown starts while the check is running
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- coordinator.Shutdown(context.Background()) }()
+	close(release)
+
+	// Then the check finishes and its report is saved before shutdown ends
+	require.NoError(t, <-shutdown)
+	assert.True(t, saved.Load())
+}
+
+func TestShutdownCoordinator_FlushTimeout(t *testing.T) {
+	// Given a flusher that hangs, ignoring its context
+	coordinator := server.NewShutdownCoordinator(server.WithFlushTimeout(20 * time.Millisecond))
+	hang := make(chan struct{})
+	defer close(hang)
+	coordinator.OnFlush(func(ctx context.Context) error {
+		<-hang
+		return nil
+	})
+
+	// When the coordinator shuts down
+	result := make(chan error, 1)
+	go func() { result <- coordinator.Shutdown(context.Background()) }()
+
+	// Then shutdown still returns once the flush timeout expires
+	select {
+	case err := <-result:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.ErrorContains(t, err, "flushing pending writes")
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown did not return while a flusher hung")
+	}
+}
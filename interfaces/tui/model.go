@@ -0,0 +1,202 @@
+// Package tui is the terminal browser opened by --tui. It reads the drift
+// reports it is given and nothing else; filtering them by drift type or by
+// ignored paths happens in memory, without comparing the instances again.
+package tui
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+)
+
+// pane is the part of the screen that arrow keys move in
+type pane int
+
+const (
+	instancesPane pane = iota
+	findingsPane
+)
+
+// typeFilters are the drift types cycled through with t; "" shows every type
+var typeFilters = []models.DriftType{
+	"",
+	models.DriftTypeModified,
+	models.DriftTypeAdded,
+	models.DriftTypeRemoved,
+}
+
+// Model is the bubbletea model of the browser
+type Model struct {
+	reports []*models.DriftReport
+	// shown holds reports with the ignored paths and the type filter
+	// applied, in the order of reports
+	shown []*models.DriftReport
+
+	ignored    []string
+	typeFilter int
+	status     string
+
+	focus    pane
+	selected int
+	finding  int
+
+	width  int
+	height int
+}
+
+// NewModel creates a browser over reports
+func NewModel(reports []*models.DriftReport) Model {
+	m := Model{reports: reports, width: 80, height: 24}
+	m.refilter()
+	return m
+}
+
+// Run opens the browser over reports and returns once it is closed
+func Run(ctx context.Context, reports []*models.DriftReport) error {
+	_, err := tea.NewProgram(NewModel(reports), tea.WithAltScreen(), tea.WithContext(ctx)).Run()
+	if err != nil {
+		return fmt.Errorf("terminal UI: %w", err)
+	}
+	return nil
+}
+
+// Init implements tea.Model
+func (m Model) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tea.KeyMsg:
+		m.status = ""
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			return m, tea.Quit
+		case "up", "k":
+			m.move(-1)
+		case "down", "j":
+			m.move(1)
+		case "tab", "left", "right", "h", "l":
+			m.focus = 1 - m.focus
+		case "t":
+			m.typeFilter = (m.typeFilter + 1) % len(typeFilters)
+			m.refilter()
+		case "i":
+			if d := m.selectedFinding(); d != nil {
+				m.ignore(d.Path)
+			}
+		case "f":
+			if d := m.selectedFinding(); d != nil {
+				m.ignore(services.TopLevelField(d.Path))
+			}
+		case "u":
+			if len(m.ignored) > 0 {
+				m.status = "no longer ignoring " + m.ignored[len(m.ignored)-1]
+				m.ignored = m.ignored[:len(m.ignored)-1]
+				m.refilter()
+			}
+		}
+	}
+	return m, nil
+}
+
+// move moves the selection in the focused pane by delta
+func (m *Model) move(delta int) {
+	if m.focus == instancesPane {
+		m.selected = clamp(m.selected+delta, len(m.shown))
+		m.finding = 0
+		return
+	}
+	if report := m.selectedReport(); report != nil {
+		m.finding = clamp(m.finding+delta, len(report.Drifts))
+	}
+}
+
+// ignore adds path to the ignored paths, unless it is empty or already there
+func (m *Model) ignore(path string) {
+	if path == "" {
+		return
+	}
+	for _, p := range m.ignored {
+		if p == path {
+			return
+		}
+	}
+	m.ignored = append(m.ignored, path)
+	if err := m.refilter(); err != nil {
+		// Paths read back from findings parse, but keep the view usable
+		m.ignored = m.ignored[:len(m.ignored)-1]
+		m.status = err.Error()
+		m.refilter()
+		return
+	}
+	m.status = "ignoring " + path
+}
+
+// refilter rebuilds the shown reports from the ignored paths and the type filter
+func (m *Model) refilter() error {
+	filter, err := services.NewPathFilter(m.ignored...)
+	if err != nil {
+		return err
+	}
+
+	driftType := typeFilters[m.typeFilter]
+	m.shown = make([]*models.DriftReport, len(m.reports))
+	for i, report := range m.reports {
+		shown := filter.Apply(report)
+		if driftType != "" {
+			kept := make([]models.Drift, 0, len(shown.Drifts))
+			for _, d := range shown.Drifts {
+				if d.Type == driftType {
+					kept = append(kept, d)
+				}
+			}
+			copied := *shown
+			copied.Drifts = kept
+			copied.HasDrift = models.ContainsDrift(kept)
+			shown = &copied
+		}
+		m.shown[i] = shown
+	}
+
+	m.selected = clamp(m.selected, len(m.shown))
+	if report := m.selectedReport(); report != nil {
+		m.finding = clamp(m.finding, len(report.Drifts))
+	}
+	return nil
+}
+
+// selectedReport returns the shown report of the selected instance
+func (m *Model) selectedReport() *models.DriftReport {
+	if m.selected < len(m.shown) {
+		return m.shown[m.selected]
+	}
+	return nil
+}
+
+// selectedFinding returns the selected finding of the selected instance
+func (m *Model) selectedFinding() *models.Drift {
+	report := m.selectedReport()
+	if report == nil || m.finding >= len(report.Drifts) {
+		return nil
+	}
+	return &report.Drifts[m.finding]
+}
+
+// clamp limits i to an index of a list of n items
+func clamp(i, n int) int {
+	if i >= n {
+		i = n - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	return i
+}
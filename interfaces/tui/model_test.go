@@ -0,0 +1,111 @@
+package tui_test
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/stretchr/testify/assert"
+
+	"driftdetector/domain/models"
+	"driftdetector/interfaces/tui"
+)
+
+func testReports() []*models.DriftReport {
+	drifted := models.NewDriftReport("i-drifted")
+	drifted.AddDrift(models.NewDrift(models.DriftTypeModified, "InstanceType", "t3.large", "t3.micro", "instance type changed"))
+	drifted.AddDrift(models.NewDrift(models.DriftTypeAdded, ".Tags.Owner", "ops", nil, "tag added"))
+	drifted.AddDrift(models.NewDrift(models.DriftTypeRemoved, ".Tags.Team", nil, "web", "tag removed"))
+
+	clean := models.NewDriftReport("i-clean")
+	return []*models.DriftReport{drifted, clean}
+}
+
+// press sends keys to m one after the other
+func press(m tea.Model, keys ...string) tea.Model {
+	for _, key := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		switch key {
+		case "tab":
+			msg = tea.KeyMsg{Type: tea.KeyTab}
+		case "down":
+			msg = tea.KeyMsg{Type: tea.KeyDown}
+		}
+		m, _ = m.Update(msg)
+	}
+	return m
+}
+
+func newModel() tea.Model {
+	m, _ := tui.NewModel(testReports()).Update(tea.WindowSizeMsg{Width: 120, Height: 20})
+	return m
+}
+
+func TestModel_View(t *testing.T) {
+	view := newModel().View()
+
+	assert.Contains(t, view, "i-drifted (3)")
+	assert.Contains(t, view, "i-clean")
+	assert.Contains(t, view, "InstanceType")
+	assert.Contains(t, view, "t3.micro")
+	assert.Contains(t, view, "t3.large")
+	assert.Contains(t, view, "type: all")
+}
+
+func TestModel_SelectInstance(t *testing.T) {
+	view := press(newModel(), "down").View()
+
+	assert.Contains(t, view, "No drift")
+	assert.NotContains(t, view, "InstanceType")
+}
+
+func TestModel_FilterByDriftType(t *testing.T) {
+	m := press(newModel(), "t", "t")
+	view := m.View()
+
+	assert.Contains(t, view, "type: ADDED")
+	assert.Contains(t, view, ".Tags.Owner")
+	assert.NotContains(t, view, "InstanceType")
+	assert.Contains(t, view, "i-drifted (1)")
+
+	view = press(m, "t", "t").View()
+	assert.Contains(t, view, "type: all")
+	assert.Contains(t, view, "i-drifted (3)")
+}
+
+func TestModel_IgnorePaths(t *testing.T) {
+	t.Run("selected path", func(t *testing.T) {
+		view := press(newModel(), "tab", "i").View()
+
+		assert.Contains(t, view, "ignoring: InstanceType")
+		assert.Contains(t, view, "i-drifted (2)")
+	})
+
+	t.Run("whole field", func(t *testing.T) {
+		view := press(newModel(), "tab", "down", "f").View()
+
+		assert.Contains(t, view, "ignoring: Tags")
+		assert.Contains(t, view, "i-drifted (1)")
+		assert.NotContains(t, view, ".Tags.Owner")
+	})
+
+	t.Run("undo", func(t *testing.T) {
+		view := press(newModel(), "tab", "f", "u").View()
+
+		assert.NotContains(t, view, "ignoring:")
+		assert.Contains(t, view, "i-drifted (3)")
+	})
+
+	t.Run("every finding", func(t *testing.T) {
+		view := press(newModel(), "tab", "f", "f").View()
+
+		assert.Contains(t, view, "ignoring: InstanceType, Tags")
+		assert.Contains(t, view, "No drift")
+	})
+}
+
+func TestModel_Quit(t *testing.T) {
+	_, cmd := newModel().Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+
+	assert.NotNil(t, cmd)
+	assert.Equal(t, tea.Quit(), cmd())
+}
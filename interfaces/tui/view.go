@@ -0,0 +1,224 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"driftdetector/domain/models"
+)
+
+var (
+	paneStyle     = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("8"))
+	focusedStyle  = paneStyle.BorderForeground(lipgloss.Color("12"))
+	cleanStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	driftStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("3"))
+	criticalStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1")).Bold(true)
+	headerStyle   = lipgloss.NewStyle().Bold(true)
+	cursorStyle   = lipgloss.NewStyle().Reverse(true)
+	helpStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+)
+
+// help lists the keybindings in the footer
+const help = "↑/↓ move  tab switch pane  t drift type  i ignore path  f ignore field  u undo ignore  q quit"
+
+// View implements tea.Model
+func (m Model) View() string {
+	// Two lines of footer, and a border around each pane
+	inner := m.height - 4
+	if inner < 3 {
+		inner = 3
+	}
+	leftWidth := m.instancesWidth()
+	rightWidth := m.width - leftWidth - 4
+	if rightWidth < 20 {
+		rightWidth = 20
+	}
+
+	left, right := paneStyle, paneStyle
+	if m.focus == instancesPane {
+		left = focusedStyle
+	} else {
+		right = focusedStyle
+	}
+
+	panes := lipgloss.JoinHorizontal(lipgloss.Top,
+		left.Render(m.instancesView(leftWidth, inner)),
+		right.Render(m.findingsView(rightWidth, inner)),
+	)
+	return lipgloss.JoinVertical(lipgloss.Left, panes, m.statusLine(), helpStyle.Render(help))
+}
+
+// instancesWidth is the width of the instance list, wide enough for the
+// longest instance ID and its finding count but at most a third of the screen
+func (m Model) instancesWidth() int {
+	width := 16
+	for _, report := range m.shown {
+		if w := lipgloss.Width(report.InstanceID) + 7; w > width {
+			width = w
+		}
+	}
+	if limit := m.width / 3; width > limit && limit >= 16 {
+		width = limit
+	}
+	return width
+}
+
+// instancesView lists the instances, colored by whether they drifted
+func (m Model) instancesView(width, height int) string {
+	if len(m.shown) == 0 {
+		return pad([]string{fit("No instances", width)}, width, height)
+	}
+
+	start := scrollStart(m.selected, len(m.shown), height)
+	var lines []string
+	for i := start; i < len(m.shown) && i < start+height; i++ {
+		report := m.shown[i]
+		line := report.InstanceID
+		if n := len(report.Drifts); n > 0 {
+			line = fmt.Sprintf("%s (%d)", line, n)
+		}
+		line = fit(line, width)
+
+		if i == m.selected {
+			line = cursorStyle.Render(line)
+		} else {
+			line = statusStyle(report).Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return pad(lines, width, height)
+}
+
+// statusStyle colors an instance red when it has a critical finding, yellow
+// when it has other findings and green when it has none
+func statusStyle(report *models.DriftReport) lipgloss.Style {
+	if len(report.Drifts) == 0 {
+		return cleanStyle
+	}
+	for _, d := range report.Drifts {
+		if d.Severity == models.SeverityCritical {
+			return criticalStyle
+		}
+	}
+	return driftStyle
+}
+
+// findingsView shows the findings of the selected instance with their
+// expected and actual values side by side, and the description of the
+// selected finding below them
+func (m Model) findingsView(width, height int) string {
+	report := m.selectedReport()
+	if report == nil {
+		return pad(nil, width, height)
+	}
+
+	lines := []string{headerStyle.Render(fit(report.InstanceID, width))}
+	for _, warning := range report.Warnings {
+		lines = append(lines, driftStyle.Render(fit("Warning: "+warning, width)))
+	}
+	if len(report.Drifts) == 0 {
+		lines = append(lines, cleanStyle.Render(fit("No drift", width)))
+		return pad(lines, width, height)
+	}
+
+	typeWidth := 10
+	pathWidth := width * 3 / 10
+	valueWidth := (width - pathWidth - typeWidth - 3) / 2
+	row := func(path, driftType, expected, actual string) string {
+		return fit(path, pathWidth) + " " + fit(driftType, typeWidth) + " " + fit(expected, valueWidth) + " " + fit(actual, valueWidth)
+	}
+	lines = append(lines, headerStyle.Render(row("PATH", "TYPE", "EXPECTED", "ACTUAL")))
+
+	// Keep room below the rows for the description of the selected finding
+	rows := height - len(lines) - 2
+	if rows < 1 {
+		rows = 1
+	}
+	start := scrollStart(m.finding, len(report.Drifts), rows)
+	for i := start; i < len(report.Drifts) && i < start+rows; i++ {
+		d := report.Drifts[i]
+		line := row(d.Path, string(d.Type), formatValue(d.Expected), formatValue(d.Actual))
+		if i == m.finding && m.focus == findingsPane {
+			line = cursorStyle.Render(line)
+		} else if d.Severity == models.SeverityCritical {
+			line = criticalStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	if d := m.selectedFinding(); d != nil {
+		details := d.Description
+		if d.Severity != "" {
+			details = fmt.Sprintf("[%s] %s", d.Severity, details)
+		}
+		lines = append(lines, "", fit(details, width))
+	}
+	return pad(lines, width, height)
+}
+
+// statusLine shows the type filter, the ignored paths and the last action
+func (m Model) statusLine() string {
+	parts := []string{"type: all"}
+	if t := typeFilters[m.typeFilter]; t != "" {
+		parts[0] = "type: " + string(t)
+	}
+	if len(m.ignored) > 0 {
+		parts = append(parts, "ignoring: "+strings.Join(m.ignored, ", "))
+	}
+	if m.status != "" {
+		parts = append(parts, m.status)
+	}
+	return ansi.Truncate(strings.Join(parts, " | "), m.width, "…")
+}
+
+// scrollStart returns the first of n items shown in a window of height
+// lines, so that the selected item stays visible
+func scrollStart(selected, n, height int) int {
+	start := selected - height + 1
+	if start > n-height {
+		start = n - height
+	}
+	if start < 0 {
+		start = 0
+	}
+	return start
+}
+
+// fit truncates or pads s to exactly width cells, on one line
+func fit(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	s = strings.ReplaceAll(s, "\n", "⏎")
+	s = ansi.Truncate(s, width, "…")
+	return s + strings.Repeat(" ", width-lipgloss.Width(s))
+}
+
+// pad adds blank lines to lines until the pane is height lines tall
+func pad(lines []string, width, height int) string {
+	for len(lines) < height {
+		lines = append(lines, strings.Repeat(" ", width))
+	}
+	return strings.Join(lines[:height], "\n")
+}
+
+// formatValue renders an expected or actual value on one line; "-" stands
+// for a value that is not set
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "-"
+	case string:
+		return v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	}
+}
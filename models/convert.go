@@ -0,0 +1,168 @@
+package models
+
+import (
+    domain "driftdetector/domain/models"
+)
+
+// NewInstanceConfig converts a domain Instance into an InstanceConfig. Every
+// setting the two share is copied, so ToInstance returns an equal Instance;
+// metadata only the detector sets, such as ResourceAddress, is left out.
+func NewInstanceConfig(instance *domain.Instance) *InstanceConfig {
+    ic := &InstanceConfig{
+        InstanceID:               instance.ID,
+        InstanceType:             instance.Type,
+        AMI:                      instance.AMI,
+        KeyName:                  instance.KeyName,
+        Tags:                     copyTags(instance.Tags),
+        State:                    instance.State,
+        LaunchTime:               instance.LaunchTime,
+        VPCID:                    instance.VPCID,
+        SubnetID:                 instance.SubnetID,
+        PublicIPAddress:          instance.PublicIPAddress,
+        PrivateIPAddress:         instance.PrivateIPAddress,
+        AssociatePublicIPAddress: instance.AssociatePublicIPAddress,
+        PrivateDNSName:           instance.PrivateDNSName,
+        PublicDNSName:            instance.PublicDNSName,
+        RootVolumeSize:           instance.RootVolumeSize,
+        RootVolumeType:           instance.RootVolumeType,
+        RootVolumeIops:           instance.RootVolumeIops,
+        RootVolumeThroughput:     instance.RootVolumeThroughput,
+        RootVolumeEncrypted:      instance.RootVolumeEncrypted,
+        RootVolumeKMSKeyID:       instance.RootVolumeKMSKeyID,
+        EBSOptimized:             instance.EBSOptimized,
+        IAMInstanceProfile:       instance.IAMInstanceProfile,
+        Monitoring:               instance.Monitoring,
+        AvailabilityZone:         instance.AvailabilityZone,
+        Tenancy:                  instance.Tenancy,
+        HostID:                   instance.HostID,
+        PlacementGroup:           instance.PlacementGroup,
+        PartitionNumber:          optionalInt(instance.PartitionNumber),
+        CPUCoreCount:             optionalInt(instance.CPUCoreCount),
+        CPUThreadsPerCore:        optionalInt(instance.CPUThreadsPerCore),
+        UserData:                 instance.UserData,
+        DisableAPITermination:    instance.DisableAPITermination,
+        InstanceInitiatedShutdownBehavior: instance.InstanceInitiatedShutdownBehavior,
+        InstanceLifecycle:        instance.InstanceLifecycle,
+        SpotInstanceRequestID:    instance.SpotInstanceRequestID,
+    }
+
+    if len(instance.SecurityGroups) > 0 {
+        ic.SecurityGroups = append([]SecurityGroup(nil), instance.SecurityGroups...)
+    }
+    for _, device := range instance.EBSBlockDevices {
+        ic.EBSBlockDevices = append(ic.EBSBlockDevices, &EBSBlockDevice{EBSBlockDevice: device})
+    }
+    for _, ni := range instance.NetworkInterfaces {
+        ic.NetworkInterfaces = append(ic.NetworkInterfaces, &NetworkInterface{NetworkInterface: ni})
+    }
+
+    ic.Hibernation = copyPtr(instance.Hibernation)
+    ic.EnclaveOptions = copyPtr(instance.EnclaveOptions)
+    ic.MetadataOptions = copyPtr(instance.MetadataOptions)
+    ic.InstanceMarketOptions = copyPtr(instance.InstanceMarketOptions)
+    ic.LaunchTemplate = copyPtr(instance.LaunchTemplate)
+
+    return ic
+}
+
+// ToInstance converts the InstanceConfig into a domain Instance. Settings the
+// domain model does not compare are dropped: SourceDestCheck,
+// CreditSpecification, Timeouts, EphemeralBlockDevices, the snapshot of each
+// EBS block device and the network card of each network interface.
+func (ic *InstanceConfig) ToInstance() *domain.Instance {
+    instance := &domain.Instance{
+        ID:                       ic.InstanceID,
+        Type:                     ic.InstanceType,
+        AMI:                      ic.AMI,
+        KeyName:                  ic.KeyName,
+        Tags:                     copyTags(ic.Tags),
+        State:                    ic.State,
+        LaunchTime:               ic.LaunchTime,
+        VPCID:                    ic.VPCID,
+        SubnetID:                 ic.SubnetID,
+        PublicIPAddress:          ic.PublicIPAddress,
+        PrivateIPAddress:         ic.PrivateIPAddress,
+        AssociatePublicIPAddress: ic.AssociatePublicIPAddress,
+        PrivateDNSName:           ic.PrivateDNSName,
+        PublicDNSName:            ic.PublicDNSName,
+        RootVolumeSize:           ic.RootVolumeSize,
+        RootVolumeType:           ic.RootVolumeType,
+        RootVolumeIops:           ic.RootVolumeIops,
+        RootVolumeThroughput:     ic.RootVolumeThroughput,
+        RootVolumeEncrypted:      ic.RootVolumeEncrypted,
+        RootVolumeKMSKeyID:       ic.RootVolumeKMSKeyID,
+        EBSOptimized:             ic.EBSOptimized,
+        IAMInstanceProfile:       ic.IAMInstanceProfile,
+        Monitoring:               ic.Monitoring,
+        AvailabilityZone:         ic.AvailabilityZone,
+        Tenancy:                  ic.Tenancy,
+        HostID:                   ic.HostID,
+        PlacementGroup:           ic.PlacementGroup,
+        PartitionNumber:          intValue(ic.PartitionNumber),
+        CPUCoreCount:             intValue(ic.CPUCoreCount),
+        CPUThreadsPerCore:        intValue(ic.CPUThreadsPerCore),
+        UserData:                 ic.UserData,
+        DisableAPITermination:    ic.DisableAPITermination,
+        InstanceInitiatedShutdownBehavior: ic.InstanceInitiatedShutdownBehavior,
+        InstanceLifecycle:        ic.InstanceLifecycle,
+        SpotInstanceRequestID:    ic.SpotInstanceRequestID,
+    }
+
+    if len(ic.SecurityGroups) > 0 {
+        instance.SecurityGroups = append([]domain.SecurityGroup(nil), ic.SecurityGroups...)
+    }
+    for _, device := range ic.EBSBlockDevices {
+        if device != nil {
+            instance.EBSBlockDevices = append(instance.EBSBlockDevices, device.EBSBlockDevice)
+        }
+    }
+    for _, ni := range ic.NetworkInterfaces {
+        if ni != nil {
+            instance.NetworkInterfaces = append(instance.NetworkInterfaces, ni.NetworkInterface)
+        }
+    }
+
+    instance.Hibernation = copyPtr(ic.Hibernation)
+    instance.EnclaveOptions = copyPtr(ic.EnclaveOptions)
+    instance.MetadataOptions = copyPtr(ic.MetadataOptions)
+    instance.InstanceMarketOptions = copyPtr(ic.InstanceMarketOptions)
+    instance.LaunchTemplate = copyPtr(ic.LaunchTemplate)
+
+    return instance
+}
+
+// copyTags returns a copy of tags, empty rather than nil when there are none
+func copyTags(tags map[string]string) map[string]string {
+    copied := make(map[string]string, len(tags))
+    for k, v := range tags {
+        copied[k] = v
+    }
+    return copied
+}
+
+// copyPtr returns a pointer to a copy of what p points to, or nil for nil, so
+// the converted value does not share settings with the original
+func copyPtr[T any](p *T) *T {
+    if p == nil {
+        return nil
+    }
+    copied := *p
+    return &copied
+}
+
+// optionalInt returns a pointer to v, or nil for zero, which the domain
+// model uses for unset
+func optionalInt(v int) *int {
+    if v == 0 {
+        return nil
+    }
+    return &v
+}
+
+// intValue returns the value p points to, or zero for nil
+func intValue(p *int) int {
+    if p == nil {
+        return 0
+    }
+    return *p
+}
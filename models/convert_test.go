@@ -0,0 +1,187 @@
+package models_test
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	domain "driftdetector/domain/models"
+	legacy "driftdetector/models"
+)
+
+// detectorOnlyFields are the Instance fields the detector sets while reading
+// Terraform or comparing, which an InstanceConfig has no place for
+var detectorOnlyFields = map[string]bool{
+	"ResourceAddress":   true,
+	"Source":            true,
+	"SourceMap":         true,
+	"UnknownFields":     true,
+	"UnresolvedFields":  true,
+	"UnresolvedRefs":    true,
+	"UnavailableFields": true,
+	"IgnoreChanges":     true,
+	"ParseWarnings":     true,
+}
+
+// sharedInstance is a random Instance with every field an InstanceConfig
+// also holds set or left unset at random
+type sharedInstance struct {
+	*domain.Instance
+}
+
+// Generate implements quick.Generator
+func (sharedInstance) Generate(r *rand.Rand, _ int) reflect.Value {
+	instance := &domain.Instance{}
+	v := reflect.ValueOf(instance).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if detectorOnlyFields[field.Name] {
+			continue
+		}
+		v.Field(i).Set(randomValue(r, field.Type))
+	}
+	if instance.Tags == nil {
+		// Conversions always produce tags, empty when there are none
+		instance.Tags = map[string]string{}
+	}
+	return reflect.ValueOf(sharedInstance{instance})
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// randomValue returns a random value of type t. Pointers, slices and maps
+// are nil a third of the time, and numbers zero a quarter of the time, so
+// unset settings are covered as well; strings are plain text so they survive
+// JSON unchanged.
+func randomValue(r *rand.Rand, t reflect.Type) reflect.Value {
+	v := reflect.New(t).Elem()
+	switch t.Kind() {
+	case reflect.String:
+		const letters = "abcdefghijklmnopqrstuvwxyz0123456789-/"
+		b := make([]byte, r.Intn(12))
+		for i := range b {
+			b[i] = letters[r.Intn(len(letters))]
+		}
+		v.SetString(string(b))
+	case reflect.Bool:
+		v.SetBool(r.Intn(2) == 0)
+	case reflect.Int:
+		if r.Intn(4) > 0 {
+			v.SetInt(int64(r.Intn(1000) + 1))
+		}
+	case reflect.Ptr:
+		if r.Intn(3) > 0 {
+			v.Set(reflect.New(t.Elem()))
+			v.Elem().Set(randomValue(r, t.Elem()))
+		}
+	case reflect.Slice:
+		if n := r.Intn(4); n > 0 {
+			v.Set(reflect.MakeSlice(t, n, n))
+			for i := 0; i < n; i++ {
+				v.Index(i).Set(randomValue(r, t.Elem()))
+			}
+		}
+	case reflect.Map:
+		if n := r.Intn(4); n > 0 {
+			v.Set(reflect.MakeMap(t))
+			for i := 0; i < n; i++ {
+				v.SetMapIndex(randomValue(r, t.Key()), randomValue(r, t.Elem()))
+			}
+		}
+	case reflect.Struct:
+		if t == timeType {
+			v.Set(reflect.ValueOf(time.Unix(r.Int63n(1<<32), 0).UTC()))
+			break
+		}
+		for i := 0; i < t.NumField(); i++ {
+			v.Field(i).Set(randomValue(r, t.Field(i).Type))
+		}
+	}
+	return v
+}
+
+func TestInstanceConfig_RoundTrip(t *testing.T) {
+	// Every setting survives a conversion to InstanceConfig and back
+	roundTrip := func(in sharedInstance) bool {
+		return assert.Equal(t, in.Instance, legacy.NewInstanceConfig(in.Instance).ToInstance())
+	}
+	require.NoError(t, quick.Check(roundTrip, &quick.Config{MaxCount: 500}))
+
+	// And an InstanceConfig made from an Instance converts back to itself
+	configRoundTrip := func(in sharedInstance) bool {
+		config := legacy.NewInstanceConfig(in.Instance)
+		return assert.Equal(t, config, legacy.NewInstanceConfig(config.ToInstance()))
+	}
+	require.NoError(t, quick.Check(configRoundTrip, &quick.Config{MaxCount: 500}))
+}
+
+func TestInstanceConfig_DecodesLikeInstance(t *testing.T) {
+	// The same JSON document reads the same whether decoded as an
+	// InstanceConfig or as an Instance
+	sameDecoding := func(in sharedInstance) bool {
+		data, err := json.Marshal(in.Instance)
+		require.NoError(t, err)
+
+		var config legacy.InstanceConfig
+		require.NoError(t, json.Unmarshal(data, &config))
+		var instance domain.Instance
+		require.NoError(t, json.Unmarshal(data, &instance))
+
+		return assert.Equal(t, &instance, config.ToInstance())
+	}
+	require.NoError(t, quick.Check(sameDecoding, &quick.Config{MaxCount: 200}))
+}
+
+func TestInstanceConfig_TagFormats(t *testing.T) {
+	documents := map[string]string{
+		"map":             `{"tags": {"Name": "web", "Env": "prod"}}`,
+		"AWS tag list":    `{"tags": [{"Key": "Name", "Value": "web"}, {"Key": "Env", "Value": "prod"}]}`,
+		"lowercase pairs": `{"tags": [{"key": "Name", "value": "web"}, {"key": "Env", "value": "prod"}]}`,
+	}
+
+	for name, document := range documents {
+		t.Run(name, func(t *testing.T) {
+			// When the document is decoded by both models
+			var config legacy.InstanceConfig
+			require.NoError(t, json.Unmarshal([]byte(document), &config))
+			var instance domain.Instance
+			require.NoError(t, json.Unmarshal([]byte(document), &instance))
+
+			// Then both read the same tags
+			expected := map[string]string{"Name": "web", "Env": "prod"}
+			assert.Equal(t, expected, config.Tags)
+			assert.Equal(t, expected, instance.Tags)
+		})
+	}
+}
+
+func TestInstanceConfig_LegacyOnlySettings(t *testing.T) {
+	// Given a mock file with settings the detector does not compare
+	var config legacy.InstanceConfig
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"instance_id": "i-1",
+		"source_dest_check": false,
+		"ebs_block_devices": [{"device_name": "/dev/sdf", "volume_size": 100, "snapshot_id": "snap-1"}],
+		"network_interfaces": [{"device_index": 0, "network_card_index": 1, "groups": [{"id": "sg-1"}]}]
+	}`), &config))
+
+	// When it is converted
+	instance := config.ToInstance()
+
+	// Then the shared settings are kept and the others dropped
+	assert.Equal(t, "snap-1", config.EBSBlockDevices[0].SnapshotID)
+	assert.Equal(t, []domain.EBSBlockDevice{{DeviceName: "/dev/sdf", VolumeSize: 100}}, instance.EBSBlockDevices)
+	assert.Equal(t, []domain.NetworkInterface{{DeviceIndex: 0, Groups: []domain.SecurityGroup{{GroupID: "sg-1"}}}}, instance.NetworkInterfaces)
+
+	// And written back out, the mock file keeps them
+	data, err := json.Marshal(config)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"snapshot_id":"snap-1"`)
+	assert.Contains(t, string(data), `"network_card_index":1`)
+}
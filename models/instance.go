@@ -1,6 +1,11 @@
 package models
 
-import "encoding/json"
+import (
+    "encoding/json"
+    "time"
+
+    domain "driftdetector/domain/models"
+)
 
 // InstanceConfig represents the configuration of an EC2 instance
 type InstanceConfig struct {
@@ -14,6 +19,12 @@ type InstanceConfig struct {
     // RawTags is used for custom unmarshaling
     RawTags          interface{}       `json:"-"`
     
+    // State is the lifecycle state AWS reports, such as running or stopped
+    State            string            `json:"state,omitempty"`
+    
+    // LaunchTime is when AWS last launched the instance
+    LaunchTime       *time.Time        `json:"launch_time,omitempty"`
+    
     // Networking
     VPCID                   string         `json:"vpc_id"`
     SubnetID                string         `json:"subnet_id"`
@@ -43,6 +54,7 @@ type InstanceConfig struct {
     Tenancy                string         `json:"tenancy,omitempty"`
     HostID                 string         `json:"host_id,omitempty"`
     PlacementGroup         string         `json:"placement_group,omitempty"`
+    PartitionNumber        *int           `json:"partition_number,omitempty"`
     
     // CPU and Credits
     CPUCoreCount           *int           `json:"cpu_core_count,omitempty"`
@@ -61,6 +73,11 @@ type InstanceConfig struct {
     DisableAPITermination  *bool          `json:"disable_api_termination,omitempty"`
     InstanceInitiatedShutdownBehavior string `json:"instance_initiated_shutdown_behavior,omitempty"`
     
+    // Spot
+    InstanceLifecycle      string         `json:"instance_lifecycle,omitempty"`
+    SpotInstanceRequestID  string         `json:"spot_instance_request_id,omitempty"`
+    InstanceMarketOptions  *InstanceMarketOptions `json:"instance_market_options,omitempty"`
+    
     // Launch Template
     LaunchTemplate         *LaunchTemplateSpecification `json:"launch_template,omitempty"`
     
@@ -98,63 +115,40 @@ func (ic *InstanceConfig) UnmarshalJSON(data []byte) error {
 
     // Handle tags conversion
     if aux.RawTags != nil {
-        switch v := aux.RawTags.(type) {
-        case map[string]interface{}:
-            // Tags are already in map format
-            ic.Tags = make(map[string]string)
-            for k, val := range v {
-                if strVal, ok := val.(string); ok {
-                    ic.Tags[k] = strVal
-                }
-            }
-        case []interface{}:
-            // Tags are in array format: [{"Key": "Name", "Value": "example"}]
-            ic.Tags = make(map[string]string)
-            for _, item := range v {
-                if tagMap, ok := item.(map[string]interface{}); ok {
-                    if key, keyOk := tagMap["Key"].(string); keyOk {
-                        if val, valOk := tagMap["Value"].(string); valOk {
-                            ic.Tags[key] = val
-                        }
-                    }
-                }
-            }
-        }
+        ic.Tags = domain.DecodeTags(aux.RawTags)
     }
 
     return nil
 }
 
-// Supporting types
-type SecurityGroup struct {
-    GroupID   string `json:"id"`
-    GroupName string `json:"name,omitempty"`
+// MarshalJSON implements custom JSON marshaling for InstanceConfig so tags are
+// always written as a map, never null, whatever form they were read in
+func (ic InstanceConfig) MarshalJSON() ([]byte, error) {
+    type Alias InstanceConfig
+    alias := Alias(ic)
+    if alias.Tags == nil {
+        alias.Tags = map[string]string{}
+    }
+    return json.Marshal(alias)
 }
 
+// Supporting types. Those the domain model shares are aliases of its types,
+// so a mock file and the detector read them the same way.
+type SecurityGroup = domain.SecurityGroup
+
 type CreditSpecification struct {
     CPUCredits string `json:"cpu_credits,omitempty"`
 }
 
-type HibernationOptions struct {
-    Configured bool `json:"configured"`
-}
+type HibernationOptions = domain.HibernationOptions
 
-type EnclaveOptions struct {
-    Enabled bool `json:"enabled"`
-}
+type EnclaveOptions = domain.EnclaveOptions
 
-type MetadataOptions struct {
-    HTTPEndpoint            string `json:"http_endpoint,omitempty"`
-    HTTPTokens              string `json:"http_tokens,omitempty"`
-    HTTPPutResponseHopLimit *int   `json:"http_put_response_hop_limit,omitempty"`
-    InstanceMetadataTags    string `json:"instance_metadata_tags,omitempty"`
-}
+type MetadataOptions = domain.MetadataOptions
 
-type LaunchTemplateSpecification struct {
-    ID      string `json:"id,omitempty"`
-    Name    string `json:"name,omitempty"`
-    Version string `json:"version,omitempty"`
-}
+type InstanceMarketOptions = domain.InstanceMarketOptions
+
+type LaunchTemplateSpecification = domain.LaunchTemplateSpecification
 
 type Timeouts struct {
     Create string `json:"create,omitempty"`
@@ -162,16 +156,11 @@ type Timeouts struct {
     Delete string `json:"delete,omitempty"`
 }
 
+// EBSBlockDevice is a domain block device with the snapshot it was created
+// from, which mock files may record but the detector does not compare
 type EBSBlockDevice struct {
-    DeviceName          string `json:"device_name"`
-    SnapshotID          string `json:"snapshot_id,omitempty"`
-    VolumeType          string `json:"volume_type,omitempty"`
-    VolumeSize          *int   `json:"volume_size,omitempty"`
-    Iops                *int   `json:"iops,omitempty"`
-    DeleteOnTermination *bool  `json:"delete_on_termination,omitempty"`
-    Encrypted           *bool  `json:"encrypted,omitempty"`
-    KMSKeyID           string `json:"kms_key_id,omitempty"`
-    Throughput          *int   `json:"throughput,omitempty"`
+    domain.EBSBlockDevice
+    SnapshotID string `json:"snapshot_id,omitempty"`
 }
 
 type EphemeralBlockDevice struct {
@@ -180,11 +169,11 @@ type EphemeralBlockDevice struct {
     VirtualName string `json:"virtual_name,omitempty"`
 }
 
+// NetworkInterface is a domain network interface with the network card it
+// is attached to, which the detector does not compare
 type NetworkInterface struct {
-    DeviceIndex         int     `json:"device_index"`
-    NetworkInterfaceID string  `json:"network_interface_id,omitempty"`
-    DeleteOnTermination *bool   `json:"delete_on_termination,omitempty"`
-    NetworkCardIndex    int     `json:"network_card_index,omitempty"`
+    domain.NetworkInterface
+    NetworkCardIndex int `json:"network_card_index,omitempty"`
 }
 
 // // InstanceConfig represents the configuration of an EC2 instance
@@ -208,3 +197,9 @@ type NetworkInterface struct {
 // 	GroupID   string `json:"group_id"`
 // 	GroupName string `json:"group_name"`
 // }
+
+// NormalizedUserData returns the decoded, normalized user data so that plain
+// text and base64 forms of the same script compare equal
+func (ic *InstanceConfig) NormalizedUserData() string {
+    return domain.NormalizeUserData(ic.UserData)
+}
@@ -0,0 +1,122 @@
+package driftdetector_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+	"driftdetector/pkg/driftdetector"
+)
+
+// comparison is a drift reduced to what every detector must agree on.
+// Values are compared in their plain form, as reports hold them.
+type comparison struct {
+	Path     string
+	Type     models.DriftType
+	Actual   interface{}
+	Expected interface{}
+}
+
+// comparisonScenarios are instances differing in one way each, with the
+// findings expected of them
+var comparisonScenarios = []struct {
+	name     string
+	change   func(actual, desired *models.Instance)
+	expected []comparison
+}{
+	{
+		name: "tag modified",
+		change: func(actual, desired *models.Instance) {
+			actual.Tags["Team"] = "data"
+		},
+		expected: []comparison{{"Tags.Team", models.DriftTypeModified, "data", "web"}},
+	},
+	{
+		name: "tag set in AWS only",
+		change: func(actual, desired *models.Instance) {
+			actual.Tags["Owner"] = "ops"
+		},
+		expected: []comparison{{"Tags.Owner", models.DriftTypeRemoved, "ops", nil}},
+	},
+	{
+		name: "tag declared in Terraform only",
+		change: func(actual, desired *models.Instance) {
+			desired.Tags["Owner"] = "ops"
+		},
+		expected: []comparison{{"Tags.Owner", models.DriftTypeAdded, nil, "ops"}},
+	},
+	{
+		name: "security groups differ in number",
+		change: func(actual, desired *models.Instance) {
+			actual.SecurityGroups = []models.SecurityGroup{{GroupID: "sg-web"}, {GroupID: "sg-ssh"}}
+			desired.SecurityGroups = []models.SecurityGroup{{GroupID: "sg-web"}, {GroupID: "sg-db"}, {GroupID: "sg-lb"}}
+		},
+		expected: []comparison{
+			{"SecurityGroups[sg-db]", models.DriftTypeAdded, nil, map[string]interface{}{"id": "sg-db"}},
+			{"SecurityGroups[sg-lb]", models.DriftTypeAdded, nil, map[string]interface{}{"id": "sg-lb"}},
+			{"SecurityGroups[sg-ssh]", models.DriftTypeRemoved, map[string]interface{}{"id": "sg-ssh"}, nil},
+		},
+	},
+	{
+		name: "block devices in another order",
+		change: func(actual, desired *models.Instance) {
+			actual.EBSBlockDevices = []models.EBSBlockDevice{{DeviceName: "/dev/sdg", VolumeSize: 50}, {DeviceName: "/dev/sdf", VolumeSize: 200}}
+			desired.EBSBlockDevices = []models.EBSBlockDevice{{DeviceName: "/dev/sdf", VolumeSize: 100}, {DeviceName: "/dev/sdg", VolumeSize: 50}}
+		},
+		expected: []comparison{{"EBSBlockDevices[/dev/sdf].VolumeSize", models.DriftTypeModified, 200, 100}},
+	},
+	{
+		name: "lists differ in length",
+		change: func(actual, desired *models.Instance) {
+			actual.NetworkInterfaces = []models.NetworkInterface{{PrivateIPAddresses: []string{"10.0.0.5", "10.0.0.9", "10.0.0.7"}}}
+			desired.NetworkInterfaces = []models.NetworkInterface{{PrivateIPAddresses: []string{"10.0.0.5", "10.0.0.6"}}}
+		},
+		expected: []comparison{
+			{"NetworkInterfaces[0].PrivateIPAddresses[1]", models.DriftTypeModified, "10.0.0.9", "10.0.0.6"},
+			{"NetworkInterfaces[0].PrivateIPAddresses[2]", models.DriftTypeRemoved, "10.0.0.7", nil},
+		},
+	},
+}
+
+// TestComparisonScenarios runs the scenarios against the domain detector and
+// the public Detector built on it, so the two cannot diverge
+func TestComparisonScenarios(t *testing.T) {
+	detector, err := driftdetector.New()
+	require.NoError(t, err)
+
+	detectors := map[string]func(actual, desired *models.Instance) *models.DriftReport{
+		"domain": services.NewDriftDetector().CompareInstances,
+		"public": func(actual, desired *models.Instance) *models.DriftReport {
+			report, err := detector.Detect(context.Background(), actual, desired)
+			require.NoError(t, err)
+			return report
+		},
+	}
+
+	for _, scenario := range comparisonScenarios {
+		for name, detect := range detectors {
+			t.Run(scenario.name+"/"+name, func(t *testing.T) {
+				// Given
+				actual := models.NewInstance("i-1", "t3.micro", "ami-1")
+				actual.AddTag("Team", "web")
+				desired := models.NewInstance("i-1", "t3.micro", "ami-1")
+				desired.AddTag("Team", "web")
+				scenario.change(actual, desired)
+
+				// When
+				report := detect(actual, desired)
+
+				// Then
+				found := make([]comparison, 0, len(report.Drifts))
+				for _, d := range report.Drifts {
+					found = append(found, comparison{d.Path, d.Type, d.Actual, d.Expected})
+				}
+				assert.Equal(t, scenario.expected, found)
+			})
+		}
+	}
+}
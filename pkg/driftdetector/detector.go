@@ -0,0 +1,235 @@
+package driftdetector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	appcommands "driftdetector/application/commands"
+	"driftdetector/domain/models"
+	"driftdetector/domain/repositories"
+	"driftdetector/domain/services"
+)
+
+// Detector compares instances and classifies the findings by severity. It
+// is safe for concurrent use once created.
+type Detector struct {
+	detectorOpts   []services.DetectorOption
+	severityRules  []SeverityRule
+	minSeverity    Severity
+	includeStopped bool
+
+	service *services.DefaultDetectionService
+}
+
+// Option configures a Detector
+type Option func(*Detector) error
+
+// WithIgnoredFields leaves findings under the given field paths out of
+// reports, e.g. PublicIPAddress or Tags[aws:*]
+func WithIgnoredFields(paths ...string) Option {
+	return func(d *Detector) error {
+		d.detectorOpts = append(d.detectorOpts, services.WithIgnoredPaths(paths...))
+		return nil
+	}
+}
+
+// WithOnlyFields restricts comparison to the given field paths, e.g.
+// Type or Tags[Environment]; fields outside them are not compared.
+// Paths given to WithIgnoredFields are still left out of them.
+func WithOnlyFields(paths ...string) Option {
+	return func(d *Detector) error {
+		d.detectorOpts = append(d.detectorOpts, services.WithOnlyPaths(paths...))
+		return nil
+	}
+}
+
+// WithComparer compares the fields matching pathGlob with fn instead of by equality
+func WithComparer(pathGlob string, fn Comparer) Option {
+	return func(d *Detector) error {
+		if fn == nil {
+			return fmt.Errorf("comparer for %s cannot be nil", pathGlob)
+		}
+		d.detectorOpts = append(d.detectorOpts, services.WithComparer(pathGlob, fn))
+		return nil
+	}
+}
+
+// WithSeverityRules adds severity rules after the defaults; a rule for the
+// same path as a default replaces it
+func WithSeverityRules(rules ...SeverityRule) Option {
+	return func(d *Detector) error {
+		d.severityRules = append(d.severityRules, rules...)
+		d.detectorOpts = append(d.detectorOpts, services.WithSeverityRules(rules...))
+		return nil
+	}
+}
+
+// WithMinSeverity leaves findings below min out of reports
+func WithMinSeverity(min Severity) Option {
+	return func(d *Detector) error {
+		if _, err := models.ParseSeverity(string(min)); err != nil {
+			return err
+		}
+		d.minSeverity = min
+		return nil
+	}
+}
+
+// WithDefaultTags expects tags on every instance whose configuration does
+// not set the key, like the AWS provider's default_tags
+func WithDefaultTags(tags map[string]string) Option {
+	return func(d *Detector) error {
+		d.detectorOpts = append(d.detectorOpts, services.WithDefaultTags(tags))
+		return nil
+	}
+}
+
+// WithAWSTags compares tags with the aws: prefix, which are skipped by default
+func WithAWSTags() Option {
+	return func(d *Detector) error {
+		d.detectorOpts = append(d.detectorOpts, services.WithAWSTags())
+		return nil
+	}
+}
+
+// WithStrictNil makes an unset value differ from its zero value, e.g. an
+// unset monitoring flag from one set to false
+func WithStrictNil() Option {
+	return func(d *Detector) error {
+		d.detectorOpts = append(d.detectorOpts, services.WithStrictNil())
+		return nil
+	}
+}
+
+// WithStrict compares every field, including the fields computed by AWS
+// that are skipped by default; see DefaultIgnoredFields
+func WithStrict() Option {
+	return func(d *Detector) error {
+		d.detectorOpts = append(d.detectorOpts, services.WithStrict())
+		return nil
+	}
+}
+
+// WithComparedFields compares the named fields of DefaultIgnoredFields
+// while still skipping the others
+func WithComparedFields(fields ...string) Option {
+	return func(d *Detector) error {
+		d.detectorOpts = append(d.detectorOpts, services.WithComparedFields(fields...))
+		return nil
+	}
+}
+
+// WithIncludeStopped compares stopped instances field by field instead of
+// reporting them as removed
+func WithIncludeStopped() Option {
+	return func(d *Detector) error {
+		d.includeStopped = true
+		return nil
+	}
+}
+
+// New creates a Detector configured by opts
+func New(opts ...Option) (*Detector, error) {
+	d := &Detector{minSeverity: SeverityInfo}
+	for _, opt := range opts {
+		if err := opt(d); err != nil {
+			return nil, err
+		}
+	}
+
+	service, err := services.NewDetectionServiceWithOptions(d.detectorOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("configuring drift detector: %w", err)
+	}
+	d.service = service
+	return d, nil
+}
+
+// Service returns the detection service the Detector compares with, for
+// wiring it into the application container
+func (d *Detector) Service() services.DetectionService {
+	return d.service
+}
+
+// Classify assigns a severity to the findings of report that have none and
+// returns a copy without the findings below the minimum severity. Findings
+// added after detection, such as policy violations, are classified this way.
+func (d *Detector) Classify(report *DriftReport) *DriftReport {
+	rules := append(models.DefaultSeverityRules(), d.severityRules...)
+	report.ApplySeverity(rules)
+	return report.FilterBySeverity(d.minSeverity)
+}
+
+// Detect compares actual with desired, which must have the same ID
+func (d *Detector) Detect(ctx context.Context, actual, desired *Instance) (*DriftReport, error) {
+	report, err := d.service.DetectDrift(ctx, actual, desired)
+	if err != nil {
+		return nil, err
+	}
+	return d.Classify(report), nil
+}
+
+// DetectInstance compares the instance with the given ID in AWS with its
+// configuration in desired, matched by ID or Name tag. An instance that AWS
+// no longer knows, or that is terminated, is reported as removed.
+func (d *Detector) DetectInstance(ctx context.Context, live *AWS, id string, desired Source) (*DriftReport, error) {
+	actual, err := live.repo.GetByID(ctx, id)
+	if err != nil && !errors.Is(err, repositories.ErrInstanceNotFound) {
+		return nil, fmt.Errorf("failed to fetch instance from AWS: %w", err)
+	}
+	fetchErr := err
+
+	configs, err := desired.Instances(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if actual == nil {
+		if appcommands.FindMatchingConfig(configs, id, "") == nil {
+			return nil, fmt.Errorf("failed to fetch instance from AWS: %w", fetchErr)
+		}
+		return d.Classify(appcommands.MissingInstanceReport(id)), nil
+	}
+
+	config, err := appcommands.MatchInstanceConfig(configs, actual, "")
+	if err != nil {
+		return nil, err
+	}
+	// Configurations parsed from .tf files carry no instance ID
+	if config.ID == "" {
+		matched := *config
+		matched.ID = id
+		config = &matched
+	}
+
+	if report := appcommands.InactiveInstanceReport(actual, d.includeStopped); report != nil {
+		return d.Classify(report), nil
+	}
+	return d.Detect(ctx, actual, config)
+}
+
+// DetectAll compares every instance with an ID in desired with the instance
+// in AWS. Instances that fail to compare are listed in the aggregate's
+// Failures, and returned together as a *services.BatchError alongside it.
+func (d *Detector) DetectAll(ctx context.Context, live *AWS, desired Source) (*AggregateReport, error) {
+	handler := appcommands.NewDetectAllDriftHandler(d.service, live.repo, nil,
+		appcommands.WithIncludeStopped(d.includeStopped))
+	results, err := handler.Handle(ctx, appcommands.DetectAllDriftCommand{Desired: desired})
+
+	var failures []string
+	var batchErr *services.BatchError
+	if errors.As(err, &batchErr) {
+		for _, failure := range batchErr.Unwrap() {
+			failures = append(failures, failure.Error())
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	reports := make([]*DriftReport, 0, len(results))
+	for _, result := range results {
+		reports = append(reports, d.Classify(result.Report))
+	}
+	return models.NewAggregateReport(reports, failures), err
+}
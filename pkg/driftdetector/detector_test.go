@@ -0,0 +1,141 @@
+package driftdetector_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"driftdetector/pkg/driftdetector"
+)
+
+func instancePair() (actual, desired *driftdetector.Instance) {
+	desired = &driftdetector.Instance{ID: "i-1", Type: "t3.micro", AMI: "ami-1", Tags: map[string]string{"Team": "web"}}
+	actual = &driftdetector.Instance{ID: "i-1", Type: "t3.large", AMI: "ami-1", Tags: map[string]string{"Team": "data"}}
+	return actual, desired
+}
+
+func detectPaths(t *testing.T, opts ...driftdetector.Option) map[string]driftdetector.Severity {
+	t.Helper()
+	detector, err := driftdetector.New(opts...)
+	require.NoError(t, err)
+
+	actual, desired := instancePair()
+	report, err := detector.Detect(context.Background(), actual, desired)
+	require.NoError(t, err)
+
+	paths := make(map[string]driftdetector.Severity)
+	for _, d := range report.Drifts {
+		paths[d.Path] = d.Severity
+	}
+	return paths
+}
+
+func TestDetector_Detect(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		assert.Equal(t, map[string]driftdetector.Severity{
+			"Type":      driftdetector.SeverityWarning,
+			"Tags.Team": driftdetector.SeverityInfo,
+		}, detectPaths(t))
+	})
+
+	t.Run("ignored fields", func(t *testing.T) {
+		assert.Equal(t, map[string]driftdetector.Severity{
+			"Type": driftdetector.SeverityWarning,
+		}, detectPaths(t, driftdetector.WithIgnoredFields("Tags")))
+	})
+
+	t.Run("only fields", func(t *testing.T) {
+		assert.Equal(t, map[string]driftdetector.Severity{
+			"Tags.Team": driftdetector.SeverityInfo,
+		}, detectPaths(t, driftdetector.WithOnlyFields("Tags", "AMI")))
+	})
+
+	t.Run("severity rules and minimum", func(t *testing.T) {
+		paths := detectPaths(t,
+			driftdetector.WithSeverityRules(driftdetector.SeverityRule{Path: "Tags.Team", Severity: driftdetector.SeverityCritical}),
+			driftdetector.WithMinSeverity(driftdetector.SeverityCritical),
+		)
+		assert.Equal(t, map[string]driftdetector.Severity{"Tags.Team": driftdetector.SeverityCritical}, paths)
+	})
+
+	t.Run("comparer", func(t *testing.T) {
+		same := func(actual, expected interface{}) (bool, string) { return true, "" }
+		assert.NotContains(t, detectPaths(t, driftdetector.WithComparer("Type", same)), "Type")
+	})
+
+	t.Run("different instances", func(t *testing.T) {
+		detector, err := driftdetector.New()
+		require.NoError(t, err)
+		actual, desired := instancePair()
+		desired.ID = "i-2"
+
+		_, err = detector.Detect(context.Background(), actual, desired)
+		assert.Error(t, err)
+	})
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	tests := map[string]driftdetector.Option{
+		"ignore pattern": driftdetector.WithIgnoredFields("Tags["),
+		"only pattern":   driftdetector.WithOnlyFields("Tags["),
+		"nil comparer":   driftdetector.WithComparer("Type", nil),
+		"min severity":   driftdetector.WithMinSeverity("URGENT"),
+	}
+	for name, opt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := driftdetector.New(opt)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewAWS_NilClient(t *testing.T) {
+	_, err := driftdetector.NewAWS(nil)
+	assert.Error(t, err)
+}
+
+func TestDetector_DetectAll(t *testing.T) {
+	client := &fakeEC2{instance: types.Instance{
+		InstanceId:   aws.String("i-0123456789abcdef0"),
+		ImageId:      aws.String("ami-0abcdef1234567890"),
+		InstanceType: types.InstanceTypeT3Micro,
+		State:        &types.InstanceState{Name: types.InstanceStateNameStopped},
+		Tags:         []types.Tag{{Key: aws.String("Name"), Value: aws.String("web")}},
+	}}
+	live, err := driftdetector.NewAWS(client)
+	require.NoError(t, err)
+
+	t.Run("stopped reported as removed", func(t *testing.T) {
+		detector, err := driftdetector.New()
+		require.NoError(t, err)
+
+		aggregate, err := detector.DetectAll(context.Background(), live, driftdetector.StateFile("testdata/terraform.tfstate"))
+		require.NoError(t, err)
+		require.Len(t, aggregate.Reports, 1)
+		require.Len(t, aggregate.Reports[0].Drifts, 1)
+		assert.Equal(t, driftdetector.DriftTypeRemoved, aggregate.Reports[0].Drifts[0].Type)
+		assert.Equal(t, 1, aggregate.Drifted)
+	})
+
+	t.Run("stopped compared", func(t *testing.T) {
+		detector, err := driftdetector.New(driftdetector.WithIncludeStopped())
+		require.NoError(t, err)
+
+		aggregate, err := detector.DetectAll(context.Background(), live, driftdetector.StateFile("testdata/terraform.tfstate"))
+		require.NoError(t, err)
+		require.Len(t, aggregate.Reports, 1)
+		assert.Empty(t, aggregate.Reports[0].Drifts)
+	})
+
+	t.Run("unreadable state", func(t *testing.T) {
+		detector, err := driftdetector.New()
+		require.NoError(t, err)
+
+		_, err = detector.DetectAll(context.Background(), live, driftdetector.StateFile("testdata/missing.tfstate"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,75 @@
+// Package driftdetector is the library API of the drift detector, for
+// programs that detect drift themselves rather than running the CLI. It
+// compares EC2 instances read from AWS, Terraform state, plans or
+// configuration, and formats the reports. Nothing in it prints or exits;
+// every failure is returned as an error.
+//
+// The CLI is built on this package, so a Detector configured with the same
+// options reports the same findings as the detect command.
+package driftdetector
+
+import (
+	"driftdetector/domain/models"
+	"driftdetector/domain/services"
+	"driftdetector/infrastructure/awsutil"
+)
+
+// Instance is the configuration of an EC2 instance, as read from AWS or Terraform
+type Instance = models.Instance
+
+// DriftReport lists the findings for one instance
+type DriftReport = models.DriftReport
+
+// Drift is one finding of a report
+type Drift = models.Drift
+
+// DriftType tells whether a field was added, removed or modified
+type DriftType = models.DriftType
+
+// Drift types
+const (
+	DriftTypeAdded    = models.DriftTypeAdded
+	DriftTypeRemoved  = models.DriftTypeRemoved
+	DriftTypeModified = models.DriftTypeModified
+)
+
+// Severity ranks how much a finding matters
+type Severity = models.Severity
+
+// Severities, from least to most important
+const (
+	SeverityInfo     = models.SeverityInfo
+	SeverityWarning  = models.SeverityWarning
+	SeverityCritical = models.SeverityCritical
+)
+
+// SeverityRule assigns a severity to the findings under a path prefix
+type SeverityRule = models.SeverityRule
+
+// AggregateReport holds the reports of several instances with their counts
+type AggregateReport = models.AggregateReport
+
+// Comparer decides whether an actual and an expected value are equal,
+// returning a description of the difference when they are not
+type Comparer = services.Comparer
+
+// EC2API is the subset of the EC2 client the detector calls. An
+// *ec2.Client satisfies it; tests can substitute a fake.
+type EC2API = awsutil.EC2API
+
+// ParseSeverity parses a severity name, ignoring case
+func ParseSeverity(name string) (Severity, error) {
+	return models.ParseSeverity(name)
+}
+
+// DefaultIgnoredFields returns the fields computed by AWS, such as
+// PublicIPAddress, that a Detector skips unless created WithStrict
+func DefaultIgnoredFields() []string {
+	return services.ListDefaultIgnoredFields()
+}
+
+// ComparerByName returns a built-in comparer: "ci" compares strings
+// case-insensitively and "tolerance:N" compares numbers within N of each other
+func ComparerByName(name string) (Comparer, error) {
+	return services.NamedComparer(name)
+}
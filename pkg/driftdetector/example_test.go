@@ -0,0 +1,101 @@
+package driftdetector_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/ec2/types"
+
+	"driftdetector/pkg/driftdetector"
+)
+
+// fakeEC2 answers the EC2 calls made to read one instance. Embedding the
+// interface leaves the calls it does not implement to panic if made.
+type fakeEC2 struct {
+	driftdetector.EC2API
+	instance types.Instance
+}
+
+func (f *fakeEC2) DescribeInstances(ctx context.Context, params *ec2.DescribeInstancesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	return &ec2.DescribeInstancesOutput{
+		Reservations: []types.Reservation{{Instances: []types.Instance{f.instance}}},
+	}, nil
+}
+
+func (f *fakeEC2) DescribeVolumes(ctx context.Context, params *ec2.DescribeVolumesInput, optFns ...func(*ec2.Options)) (*ec2.DescribeVolumesOutput, error) {
+	return &ec2.DescribeVolumesOutput{}, nil
+}
+
+func (f *fakeEC2) DescribeInstanceAttribute(ctx context.Context, params *ec2.DescribeInstanceAttributeInput, optFns ...func(*ec2.Options)) (*ec2.DescribeInstanceAttributeOutput, error) {
+	return &ec2.DescribeInstanceAttributeOutput{}, nil
+}
+
+// An instance resized outside Terraform, compared with its state file
+func ExampleDetector_DetectInstance() {
+	client := &fakeEC2{instance: types.Instance{
+		InstanceId:   aws.String("i-0123456789abcdef0"),
+		ImageId:      aws.String("ami-0abcdef1234567890"),
+		InstanceType: types.InstanceTypeT3Large,
+		State:        &types.InstanceState{Name: types.InstanceStateNameRunning},
+		Tags:         []types.Tag{{Key: aws.String("Name"), Value: aws.String("web")}},
+	}}
+
+	live, err := driftdetector.NewAWS(client)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	detector, err := driftdetector.New(driftdetector.WithIgnoredFields("PublicIPAddress"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	report, err := detector.DetectInstance(context.Background(), live, "i-0123456789abcdef0",
+		driftdetector.StateFile("testdata/terraform.tfstate"))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	for _, d := range report.Drifts {
+		fmt.Printf("%s %s: %v -> %v (%s)\n", d.Type, d.Path, d.Expected, d.Actual, d.Severity)
+	}
+	// Output:
+	// MODIFIED Type: t3.micro -> t3.large (WARNING)
+}
+
+// Two instances built in code, compared and rendered as CSV
+func ExampleDetector_Detect() {
+	desired := driftdetector.Instance{ID: "i-1", Type: "t3.micro", AMI: "ami-1", Tags: map[string]string{"Team": "web"}}
+	actual := desired
+	actual.Tags = map[string]string{"Team": "data"}
+
+	detector, err := driftdetector.New()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	report, err := detector.Detect(context.Background(), &actual, &desired)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	formatter, err := driftdetector.NewFormatter(driftdetector.FormatCSV)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	out, err := formatter.Format(report)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(out)
+	// Output:
+	// instance_id,path,type,severity,expected,actual,description
+	// i-1,Tags.Team,MODIFIED,INFO,web,data,Value modified
+}
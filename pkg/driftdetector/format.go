@@ -0,0 +1,61 @@
+package driftdetector
+
+import (
+	"driftdetector/infrastructure/persistence"
+)
+
+// FormatType names an output format
+type FormatType = persistence.FormatType
+
+// Output formats
+const (
+	FormatText     = persistence.FormatText
+	FormatJSON     = persistence.FormatJSON
+	FormatYAML     = persistence.FormatYAML
+	FormatHTML     = persistence.FormatHTML
+	FormatMarkdown = persistence.FormatMarkdown
+	FormatCSV      = persistence.FormatCSV
+	FormatSARIF    = persistence.FormatSARIF
+	FormatTemplate = persistence.FormatTemplate
+)
+
+// Formatter renders a drift report
+type Formatter = persistence.Formatter
+
+// FormatterOption configures a Formatter
+type FormatterOption = persistence.FormatterOption
+
+// WithMaxValueLength truncates values longer than n characters in markdown
+// output; 0 disables truncation
+func WithMaxValueLength(n int) FormatterOption {
+	return persistence.WithMaxValueLength(n)
+}
+
+// WithColor colors text output with ANSI escape sequences; other formats
+// ignore it
+func WithColor(enabled bool) FormatterOption {
+	return persistence.WithColor(enabled)
+}
+
+// ReportTemplate is a parsed text/template for FormatTemplate
+type ReportTemplate = persistence.ReportTemplate
+
+// ParseTemplateFile parses the report template at path
+func ParseTemplateFile(path string) (*ReportTemplate, error) {
+	return persistence.ParseTemplateFile(path)
+}
+
+// WithTemplate renders FormatTemplate output with t
+func WithTemplate(t *ReportTemplate) FormatterOption {
+	return persistence.WithTemplate(t)
+}
+
+// NewFormatter returns the formatter for format
+func NewFormatter(format FormatType, opts ...FormatterOption) (Formatter, error) {
+	return persistence.NewFormatter(format, opts...)
+}
+
+// FormatAggregate renders the reports of several instances as one document
+func FormatAggregate(format FormatType, aggregate *AggregateReport, opts ...FormatterOption) (string, error) {
+	return persistence.FormatAggregate(format, aggregate, opts...)
+}
@@ -0,0 +1,55 @@
+package driftdetector
+
+import (
+	"context"
+	"fmt"
+
+	appcommands "driftdetector/application/commands"
+	awsrepo "driftdetector/infrastructure/aws"
+	"driftdetector/infrastructure/terraform"
+)
+
+// Source provides the desired configuration of instances
+type Source = appcommands.Source
+
+// StateFile returns a Source reading the instances recorded in a local
+// Terraform state file
+func StateFile(path string) Source {
+	return appcommands.NewTerraformSource(terraform.NewTerraformRepository(nil), path, "", "")
+}
+
+// PlanFile returns a Source reading the planned values of a plan rendered
+// with terraform show -json
+func PlanFile(path string) Source {
+	return appcommands.NewTerraformSource(terraform.NewTerraformRepository(nil), "", "", path)
+}
+
+// ConfigDir returns a Source reading the Terraform configuration and state
+// files in dir and its subdirectories
+func ConfigDir(dir string) Source {
+	return appcommands.NewTerraformSource(terraform.NewTerraformRepository(nil), "", dir, "")
+}
+
+// Instances returns a Source holding instances, e.g. ones built in code
+func Instances(name string, instances ...*Instance) Source {
+	return appcommands.NewStaticSource(name, instances...)
+}
+
+// AWS reads live instances through an EC2 client, with their user data and
+// the attributes DescribeInstances does not return
+type AWS struct {
+	repo *awsrepo.EC2Repository
+}
+
+// NewAWS returns an AWS reading instances through client
+func NewAWS(client EC2API) (*AWS, error) {
+	if client == nil {
+		return nil, fmt.Errorf("EC2 client cannot be nil")
+	}
+	return &AWS{repo: awsrepo.NewEC2Repository(client, awsrepo.WithUserData(), awsrepo.WithInstanceAttributes())}, nil
+}
+
+// Instance returns the instance with the given ID
+func (a *AWS) Instance(ctx context.Context, id string) (*Instance, error) {
+	return a.repo.GetByID(ctx, id)
+}
@@ -0,0 +1,12 @@
+# Sample policy: instances must not have a public IP address.
+#
+# Policies receive the drift report and both configurations as `input`:
+#   input.report    - the drift report (instance_id, has_drift, drifts, metadata)
+#   input.actual    - the instance configuration observed in AWS
+#   input.expected  - the instance configuration declared in Terraform
+package driftdetector.public_ip
+
+deny contains msg if {
+	input.actual.public_ip_address != ""
+	msg := sprintf("instance %s has public IP address %s", [input.actual.instance_id, input.actual.public_ip_address])
+}
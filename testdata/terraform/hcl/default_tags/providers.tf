@@ -0,0 +1,26 @@
+provider "aws" {
+  region = "us-east-1"
+
+  default_tags {
+    tags = {
+      Environment = var.environment
+      ManagedBy   = "terraform"
+      Team        = "platform"
+    }
+  }
+}
+
+provider "aws" {
+  alias  = "dr"
+  region = "us-west-2"
+
+  default_tags {
+    tags = {
+      Region = "dr"
+    }
+  }
+}
+
+variable "environment" {
+  default = "prod"
+}
@@ -0,0 +1,13 @@
+locals {
+  name = "${local.prefix}-web"
+  common_tags = {
+    Environment = var.environment
+    ManagedBy   = "terraform"
+  }
+  prefix = "app-${var.environment}"
+}
+
+data "aws_ami" "ubuntu" {
+  most_recent = true
+  owners      = ["099720109477"]
+}
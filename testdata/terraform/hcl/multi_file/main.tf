@@ -0,0 +1,10 @@
+resource "aws_instance" "web" {
+  ami           = data.aws_ami.ubuntu.id
+  instance_type = var.instance_type
+  subnet_id     = "subnet-0123456789abcdef0"
+
+  tags = {
+    Name        = local.name
+    Environment = local.common_tags.Environment
+  }
+}
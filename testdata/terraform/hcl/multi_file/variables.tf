@@ -0,0 +1,7 @@
+variable "environment" {
+  default = "staging"
+}
+
+variable "instance_type" {
+  default = "t3.medium"
+}
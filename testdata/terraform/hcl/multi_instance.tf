@@ -0,0 +1,43 @@
+# Several aws_instance resources declared in a single file
+
+variable "base_ami" {
+  type    = string
+  default = "ami-0c55b159cbfafe1f0"
+}
+
+resource "aws_instance" "web" {
+  ami           = var.base_ami
+  instance_type = "t3.small"
+
+  tags = {
+    Name = "web"
+    Role = "frontend"
+  }
+}
+
+resource "aws_instance" "worker" {
+  ami           = var.base_ami
+  instance_type = "t3.small"
+
+  tags = {
+    Name = "worker"
+    Role = "backend"
+  }
+}
+
+resource "aws_security_group" "bastion" {
+  name = "bastion"
+}
+
+resource "aws_instance" "bastion" {
+  ami                         = "ami-0abcdef1234567890"
+  instance_type               = "t3.micro"
+  associate_public_ip_address = true
+  vpc_security_group_ids      = [aws_security_group.bastion.id]
+
+  root_block_device {
+    volume_size = 16
+    volume_type = "gp3"
+    encrypted   = true
+  }
+}
@@ -0,0 +1,54 @@
+# Native-syntax twin of syntax_equivalence.tf.json; keep the two in sync
+
+variable "environment" {
+  type    = string
+  default = "staging"
+}
+
+variable "instance_type" {
+  type    = string
+  default = "m5.large"
+}
+
+resource "aws_instance" "app" {
+  ami                         = "ami-0c55b159cbfafe1f0"
+  instance_type               = var.instance_type
+  key_name                    = "deployer"
+  subnet_id                   = "subnet-0123456789abcdef0"
+  vpc_security_group_ids      = ["sg-0123456789abcdef0", "sg-0fedcba9876543210"]
+  associate_public_ip_address = false
+  monitoring                  = true
+  hibernation                 = true
+
+  root_block_device {
+    volume_size = 40
+    volume_type = "gp3"
+    iops        = 3000
+    encrypted   = true
+  }
+
+  metadata_options {
+    http_endpoint               = "enabled"
+    http_tokens                 = "required"
+    http_put_response_hop_limit = 2
+    instance_metadata_tags      = "disabled"
+  }
+
+  enclave_options {
+    enabled = false
+  }
+
+  tags = {
+    Name        = "${var.environment}-app"
+    Environment = var.environment
+  }
+}
+
+resource "aws_instance" "cache" {
+  ami           = "ami-0c55b159cbfafe1f0"
+  instance_type = "r5.large"
+
+  tags = {
+    Name = "${var.environment}-cache"
+  }
+}